@@ -17,20 +17,29 @@ import (
 	"github.com/fatih/color"
 	"github.com/mattn/go-isatty"
 	"github.com/spf13/cobra"
+	"github.com/terassyi/tomei/internal/bundle"
 	"github.com/terassyi/tomei/internal/config"
-	"github.com/terassyi/tomei/internal/github"
+	"github.com/terassyi/tomei/internal/credential"
+	"github.com/terassyi/tomei/internal/history"
+	"github.com/terassyi/tomei/internal/installer/bootstrap"
+	"github.com/terassyi/tomei/internal/installer/command"
 	"github.com/terassyi/tomei/internal/installer/download"
 	"github.com/terassyi/tomei/internal/installer/engine"
 	"github.com/terassyi/tomei/internal/installer/place"
 	"github.com/terassyi/tomei/internal/installer/repository"
+	"github.com/terassyi/tomei/internal/installer/resolve"
 	"github.com/terassyi/tomei/internal/installer/runtime"
 	"github.com/terassyi/tomei/internal/installer/tool"
+	"github.com/terassyi/tomei/internal/lockfile"
 	tomeilog "github.com/terassyi/tomei/internal/log"
 	"github.com/terassyi/tomei/internal/path"
+	"github.com/terassyi/tomei/internal/planfile"
+	"github.com/terassyi/tomei/internal/project"
 	"github.com/terassyi/tomei/internal/registry/aqua"
 	"github.com/terassyi/tomei/internal/resource"
 	"github.com/terassyi/tomei/internal/state"
 	"github.com/terassyi/tomei/internal/ui"
+	"github.com/terassyi/tomei/internal/verify"
 )
 
 // applyConfig holds configuration for the apply command.
@@ -40,6 +49,29 @@ type applyConfig struct {
 	parallel int
 	yes      bool
 	timeout  time.Duration
+	retries  int
+
+	// updateToolNames and updateRuntimeNames select resources to re-resolve
+	// (set by "tomei lock update kind/name").
+	updateToolNames    []string
+	updateRuntimeNames []string
+
+	// rollbackTo is the state generation to return to (set by "tomei state rollback").
+	rollbackTo *state.UserState
+
+	// repair selects the resources to reinstall and remove (set by "tomei doctor --fix").
+	repair *repairSet
+	// canceled is set when the user declines the confirmation prompt.
+	canceled bool
+
+	// bundlePath is an offline bundle to install from instead of the network.
+	bundlePath string
+
+	// project applies the manifest of the project at the working directory
+	// (or the given directory) into its own state.
+	project bool
+	// scope is the project being applied, resolved from --project.
+	scope *project.Project
 }
 
 var applyCfg applyConfig
@@ -62,9 +94,36 @@ For user-level resources (Runtime, Tool, ToolSet):
   tomei apply tools.cue runtime.cue
   tomei apply ~/.config/tomei/
 
-For system-level resources (SystemPackageRepository, SystemPackageSet):
-  sudo tomei apply --system .`,
-	Args: cobra.MinimumNArgs(1),
+For system-level resources (SystemInstaller, SystemPackageRepository,
+SystemPackageSet), run with --system as root. System state is stored in
+/var/lib/tomei/state.json:
+  sudo tomei apply --system .
+
+User-level apply honors and updates tomei.lock next to the manifests
+(see "tomei lock --help").
+
+Given a plan file saved by "tomei plan --out", apply executes exactly the
+saved actions with the resolved versions and download URLs. It refuses to
+run if state.json, tomei.lock or the manifests changed since the plan was
+created:
+  tomei apply plan.json
+
+With --bundle, artifacts, checksums, signatures and registry definitions are
+read from an offline bundle created by "tomei bundle create" and nothing is
+fetched from the network. The versions pinned in the bundle are installed, and
+tomei.lock next to the manifests is not written:
+  tomei apply --bundle bundle.tar .
+
+With --project, the tomei.cue of the project at the given directory (default:
+the working directory) is applied into a state and bin directory of the
+project. The manifest must have been allowed with "tomei allow" first:
+  tomei apply --project`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if applyCfg.project {
+			return cobra.MaximumNArgs(1)(cmd, args)
+		}
+		return cobra.MinimumNArgs(1)(cmd, args)
+	},
 	RunE: runApply,
 }
 
@@ -73,7 +132,10 @@ func init() {
 	applyCmd.Flags().BoolVar(&applyCfg.quiet, "quiet", false, "Suppress progress output")
 	applyCmd.Flags().IntVar(&applyCfg.parallel, "parallel", engine.DefaultParallelism, "Maximum number of parallel installations (1-20)")
 	applyCmd.Flags().BoolVarP(&applyCfg.yes, "yes", "y", false, "Skip confirmation prompt")
-	applyCmd.Flags().DurationVar(&applyCfg.timeout, "timeout", download.DefaultDownloadTimeout, "Per-download timeout (e.g., 5m, 10m, 1h)")
+	applyCmd.Flags().DurationVar(&applyCfg.timeout, "timeout", download.DefaultDownloadTimeout, "Timeout per download attempt (e.g., 5m, 10m, 1h)")
+	applyCmd.Flags().IntVar(&applyCfg.retries, "retries", download.DefaultRetries, "Number of retries of a failed download (0 disables retries)")
+	applyCmd.Flags().StringVar(&applyCfg.bundlePath, "bundle", "", "Install from an offline bundle created by 'tomei bundle create'")
+	applyCmd.Flags().BoolVar(&applyCfg.project, "project", false, "Apply the tomei.cue of the project at the given directory (default: working directory)")
 }
 
 func runApply(cmd *cobra.Command, args []string) error {
@@ -81,11 +143,25 @@ func runApply(cmd *cobra.Command, args []string) error {
 		color.NoColor = true
 	}
 
+	if systemMode && applyCfg.project {
+		return errors.New("--project cannot be combined with --system: projects only contain user-level tools")
+	}
 	if systemMode {
+		if _, ok := savedPlanArg(args); ok {
+			return errors.New("saved plans only cover user-level resources; --system is not supported")
+		}
 		cmd.Printf("Applying system-level resources from %v\n", args)
-		// TODO: implement system apply in Phase 4
-		cmd.Println("System apply not yet implemented")
-		return nil
+		return runSystemApply(cmd.Context(), args, cmd.OutOrStdout(), &applyCfg)
+	}
+
+	if applyCfg.project {
+		proj, err := allowedProject(args)
+		if err != nil {
+			return err
+		}
+		applyCfg.scope = proj
+		cmd.Printf("Applying project resources from %s\n", proj.Manifest())
+		return runUserApply(cmd.Context(), []string{proj.Manifest()}, cmd.OutOrStdout(), &applyCfg)
 	}
 
 	cmd.Printf("Applying user-level resources from %v\n", args)
@@ -93,8 +169,26 @@ func runApply(cmd *cobra.Command, args []string) error {
 }
 
 func runUserApply(ctx context.Context, paths []string, w io.Writer, cfg *applyConfig) error {
+	// A saved plan names the manifests it was computed from
+	var saved *planfile.Plan
+	if planPath, ok := savedPlanArg(paths); ok {
+		if cfg.bundlePath != "" || cfg.syncRegistry || cfg.updateTools || cfg.updateRuntimes || cfg.updateAll {
+			return errors.New("a saved plan cannot be combined with --bundle, --sync or --update-* flags")
+		}
+		var err error
+		saved, err = planfile.Load(planPath)
+		if err != nil {
+			return err
+		}
+		paths = saved.Paths
+	}
+
 	// Load resources from paths (manifests)
-	loader := config.NewLoader(nil, cfg.verifierOpts()...)
+	verifierOpts, err := cfg.verifierOpts()
+	if err != nil {
+		return err
+	}
+	loader := config.NewLoader(nil, verifierOpts...)
 	resources, err := loader.LoadPaths(paths)
 	if err != nil {
 		return fmt.Errorf("failed to load resources: %w", err)
@@ -105,6 +199,63 @@ func runUserApply(ctx context.Context, paths []string, w io.Writer, cfg *applyCo
 	if err != nil {
 		return fmt.Errorf("failed to expand sets: %w", err)
 	}
+	if cfg.scope != nil {
+		if err := project.CheckResources(resources); err != nil {
+			return err
+		}
+	}
+	manifestHash, err := planfile.HashResources(resources)
+	if err != nil {
+		return err
+	}
+
+	// System resources are applied separately with --system
+	resources = excludeSystemResources(resources)
+
+	// Credentials configure the installers and are not reconciled
+	creds, resources := credential.Split(resources)
+	if err := creds.Check(resources); err != nil {
+		return err
+	}
+
+	if err := validateLockTargets(resources, cfg.updateToolNames, cfg.updateRuntimeNames); err != nil {
+		return err
+	}
+	if cfg.rollbackTo != nil {
+		resources = rollbackResources(resources, cfg.rollbackTo)
+	}
+
+	// Load tomei.lock next to the manifests
+	lockPath := lockfile.PathFor(paths)
+	lockHash, err := planfile.HashFile(lockPath)
+	if err != nil {
+		return err
+	}
+	lock, err := lockfile.Load(lockPath)
+	if err != nil {
+		return err
+	}
+
+	// Install from an offline bundle: its lockfile pins what it contains.
+	// It is only used for pinning; tomei.lock next to the manifests is left as is.
+	var offline *bundle.Bundle
+	if cfg.bundlePath != "" {
+		if cfg.syncRegistry || cfg.updateTools || cfg.updateRuntimes || cfg.updateAll {
+			return errors.New("--bundle cannot be combined with --sync or --update-* flags")
+		}
+		offline, err = bundle.Open(cfg.bundlePath)
+		if err != nil {
+			return err
+		}
+		defer offline.Close()
+		if err := offline.CheckPlatform(); err != nil {
+			return err
+		}
+		lock = offline.Lock
+		for _, u := range offline.Manifest.Unsupported {
+			fmt.Fprintf(w, "Warning: %s/%s is not supported in offline mode (%s)\n", u.Kind, u.Name, u.Reason)
+		}
+	}
 
 	// Load config from fixed path (~/.config/tomei/config.cue)
 	appCfg, err := config.LoadConfig(config.DefaultConfigDir)
@@ -124,6 +275,31 @@ func runUserApply(ctx context.Context, paths []string, w io.Writer, cfg *applyCo
 		return fmt.Errorf("tomei is not initialized. Run 'tomei init' first")
 	}
 
+	// Applies of projects are recorded in the global apply history as well
+	historyFile := pathConfig.ApplyHistoryFile()
+
+	// A project has a state of its own next to its bin directory
+	if cfg.scope != nil {
+		projectPaths := pathConfig.ForProject(cfg.scope.ID())
+		if err := initProjectState(pathConfig, projectPaths); err != nil {
+			return err
+		}
+		pathConfig = projectPaths
+		stateFile = pathConfig.UserStateFile()
+		project.PlaceRuntimes(resources, pathConfig.UserBinDir())
+	}
+
+	// Refuse to apply a saved plan computed from other manifests or state
+	if saved != nil {
+		stateHash, err := planfile.HashFile(stateFile)
+		if err != nil {
+			return err
+		}
+		if err := saved.Check(manifestHash, lockHash, stateHash); err != nil {
+			return err
+		}
+	}
+
 	// Ensure directories exist
 	if err := path.EnsureDir(pathConfig.UserDataDir()); err != nil {
 		return fmt.Errorf("failed to create data directory: %w", err)
@@ -138,14 +314,11 @@ func runUserApply(ctx context.Context, paths []string, w io.Writer, cfg *applyCo
 		return fmt.Errorf("failed to create state store: %w", err)
 	}
 
-	// Create GitHub-aware HTTP clients:
-	// - ghClient: API client with Client.Timeout for registry sync, version resolution
-	// - dlClient: download client with transport-level timeouts only (no Client.Timeout)
-	//   to allow large binary downloads to complete at any speed
-	token := github.TokenFromEnv()
-	ghClient := github.NewHTTPClient(token)
-	dlClient := &http.Client{
-		Transport: github.WrapTransport(token, download.DefaultTransport()),
+	// Create HTTP clients, replaced by the bundle in offline mode
+	ghClient, dlClient := newHTTPClients(appCfg.Mirrors)
+	if offline != nil {
+		ghClient = &http.Client{Transport: offline.Transport()}
+		dlClient = ghClient
 	}
 
 	// Sync registry if --sync flag is set, or if --update-tools/--update-all
@@ -154,46 +327,101 @@ func runUserApply(ctx context.Context, paths []string, w io.Writer, cfg *applyCo
 		if err := aqua.SyncRegistry(ctx, store, ghClient); err != nil {
 			slog.Warn("failed to sync aqua registry", "error", err)
 		}
+		// Re-pin the synced registry ref
+		lock.Registry = nil
 	}
 
 	// Show plan and ask for confirmation when there are changes
 	updCfg := engine.UpdateConfig{
-		SyncMode:       cfg.syncRegistry,
-		UpdateTools:    cfg.updateTools || cfg.updateAll,
-		UpdateRuntimes: cfg.updateRuntimes || cfg.updateAll,
+		SyncMode:           cfg.syncRegistry,
+		UpdateTools:        cfg.updateTools || cfg.updateAll,
+		UpdateRuntimes:     cfg.updateRuntimes || cfg.updateAll,
+		UpdateToolNames:    cfg.updateToolNames,
+		UpdateRuntimeNames: cfg.updateRuntimeNames,
+	}
+	if saved != nil {
+		updCfg = savedPlanUpdateConfig(saved)
+	}
+	toolPins, runtimePins := applyLockPins(lock, resources, &updCfg)
+	if cfg.rollbackTo != nil {
+		pinRollbackVersions(cfg.rollbackTo, resources, toolPins, runtimePins, &updCfg)
+	}
+	if saved != nil {
+		savedPlanPins(saved, toolPins, runtimePins)
 	}
-	hasChanges, err := planForResources(w, resources, cfg.noColor, updCfg)
+	var allowed map[string]resource.ActionType
+	if cfg.repair != nil {
+		pinRepairVersions(cfg.repair, toolPins, runtimePins, &updCfg)
+		allowed = cfg.repair.actions()
+	}
+	hasChanges, err := planForResources(ctx, w, &userPlanInput{
+		paths:       pathConfig,
+		resources:   resources,
+		creds:       creds,
+		lock:        lock,
+		updCfg:      updCfg,
+		toolPins:    toolPins,
+		runtimePins: runtimePins,
+		allowed:     allowed,
+	}, cfg.noColor)
 	if err != nil {
 		return fmt.Errorf("failed to plan: %w", err)
 	}
-	if hasChanges && !cfg.yes {
+	// A saved plan has already been reviewed
+	if hasChanges && !cfg.yes && saved == nil {
 		fmt.Fprint(w, "\nDo you want to continue? [y/N] ")
 		reader := bufio.NewReader(os.Stdin)
 		answer, _ := reader.ReadString('\n')
 		answer = strings.TrimSpace(strings.ToLower(answer))
 		if answer != "y" && answer != "yes" { //nolint:goconst // simple confirmation pattern
 			fmt.Fprintln(w, "Canceled.")
+			cfg.canceled = true
 			return nil
 		}
 	}
 	fmt.Fprintln(w)
 
 	// Create installers
-	downloader := download.NewDownloaderWithClient(dlClient, download.WithDownloadTimeout(cfg.timeout))
+	downloadCache := download.NewCache(download.CacheDir(pathConfig.UserCacheDir()))
+	retryPolicy := download.DefaultRetryPolicy()
+	retryPolicy.MaxAttempts = cfg.retries + 1
+	downloader := download.NewDownloaderWithClient(dlClient, download.WithDownloadTimeout(cfg.timeout), download.WithRetryPolicy(retryPolicy), download.WithCache(downloadCache))
 	toolsDir := pathConfig.UserDataDir() + "/tools"
 	runtimesDir := pathConfig.UserDataDir() + "/runtimes"
 	binDir := pathConfig.UserBinDir()
 
 	placer := place.NewPlacer(toolsDir, binDir)
 	toolInstaller := tool.NewInstaller(downloader, placer)
+	toolInstaller.SetPins(toolPins)
+	toolInstaller.SetCredentials(creds)
 	runtimeInstaller := runtime.NewInstaller(downloader, runtimesDir)
+	runtimeInstaller.SetPins(runtimePins)
+	runtimeInstaller.SetCredentials(creds)
+	if offline != nil {
+		toolInstaller.SetOffline(true)
+		runtimeInstaller.SetOffline(true)
+		// Signatures are verified against the material recorded in the bundle
+		toolInstaller.SetSignatureVerifier(verify.NewArtifactVerifier(ghClient).WithTrustedRootFile(offline.TrustedRootPath()))
+	} else {
+		// Version lookups (e.g. the latest GitHub release) go through the mirrors too
+		toolInstaller.SetVersionResolver(resolve.NewResolver(command.NewExecutor(""), ghClient))
+		toolInstaller.SetSignatureVerifier(verify.NewArtifactVerifier(ghClient))
+		runtimeInstaller.SetHTTPClient(ghClient)
+	}
+	installerInstaller := bootstrap.NewInstaller()
 	reposDir := pathConfig.UserDataDir() + "/repositories"
 	repoInstaller := repository.NewInstaller(reposDir)
 
 	// Create engine with event handler for progress display
-	eng := engine.NewEngine(toolInstaller, runtimeInstaller, repoInstaller, store)
+	eng := engine.NewEngine(toolInstaller, runtimeInstaller, installerInstaller, repoInstaller, store)
 	eng.SetParallelism(cfg.parallel)
 	eng.SetUpdateConfig(updCfg)
+	if saved != nil {
+		eng.SetPlannedActions(saved.ActionTypes())
+	}
+	if allowed != nil {
+		eng.SetPlannedActions(allowed)
+	}
 
 	// Track results for summary
 	results := &ui.ApplyResults{}
@@ -210,27 +438,75 @@ func runUserApply(ctx context.Context, paths []string, w io.Writer, cfg *applyCo
 
 	// Set resolver configurer to be called after lock is acquired and state is loaded
 	cacheDir := pathConfig.UserCacheDir() + "/registry/aqua"
+	// The registry ref pinned in tomei.lock takes precedence over the state ref.
 	eng.SetResolverConfigurer(func(st *state.UserState) error {
-		if st.Registry != nil && st.Registry.Aqua != nil {
+		ref := lock.AquaRef()
+		if saved != nil && saved.Registry != "" {
+			ref = saved.Registry
+		}
+		if ref == "" && st.Registry != nil && st.Registry.Aqua != nil {
+			ref = st.Registry.Aqua.Ref
+		}
+		if ref != "" {
 			resolver := aqua.NewResolver(cacheDir, ghClient)
-			toolInstaller.SetResolver(resolver, aqua.RegistryRef(st.Registry.Aqua.Ref))
-			slog.Debug("configured aqua-registry resolver", "ref", st.Registry.Aqua.Ref)
+			toolInstaller.SetResolver(resolver, aqua.RegistryRef(ref))
+			slog.Debug("configured aqua-registry resolver", "ref", ref)
 		}
 		return nil
 	})
 
+	// Record the apply in the apply history
+	record := history.Record{StartedAt: time.Now(), ManifestHash: manifestHash}
+	if cfg.scope != nil {
+		record.Project = cfg.scope.Root
+	}
+	runner := newRecordingRunner(eng, store, record)
+
 	// Choose TUI or ProgressManager based on TTY
 	isTTY := isatty.IsTerminal(os.Stdout.Fd()) || isatty.IsCygwinTerminal(os.Stdout.Fd())
 	if isTTY && !cfg.quiet {
-		return runApplyWithTUI(ctx, eng, resources, results, logStore, w, cfg)
+		err = runApplyWithTUI(ctx, runner, resources, results, logStore, w, cfg)
+	} else {
+		err = runApplyWithProgressManager(ctx, runner, resources, results, logStore, w, cfg)
+	}
+	runner.save(historyFile, store)
+	if err != nil {
+		return err
+	}
+
+	// Pin what was installed so other machines reproduce it. An offline
+	// install only reproduces the bundle, so it does not rewrite tomei.lock.
+	if offline != nil {
+		return nil
+	}
+	if err := writeLockfile(store, lock, lockPath, resources); err != nil {
+		return fmt.Errorf("failed to write lockfile: %w", err)
+	}
+	return nil
+}
+
+// applyRunner is implemented by both Engine and SystemEngine so that
+// user and system apply share the same progress display.
+type applyRunner interface {
+	Apply(ctx context.Context, resources []resource.Resource) error
+	SetEventHandler(handler engine.EventHandler)
+}
+
+// excludeSystemResources returns resources without system-privilege kinds.
+func excludeSystemResources(resources []resource.Resource) []resource.Resource {
+	var result []resource.Resource
+	for _, res := range resources {
+		if !engine.IsSystemKind(res.Kind()) {
+			result = append(result, res)
+		}
 	}
-	return runApplyWithProgressManager(ctx, eng, resources, results, logStore, w, cfg)
+	return result
 }
 
 // runApplyWithTUI runs apply with Bubble Tea TUI (for TTY mode).
 func runApplyWithTUI(
 	ctx context.Context,
-	eng *engine.Engine,
+	eng applyRunner,
 	resources []resource.Resource,
 	results *ui.ApplyResults,
 	logStore *tomeilog.Store,
@@ -306,7 +582,7 @@ func runApplyWithTUI(
 // runApplyWithProgressManager runs apply with mpb-based progress bars (for non-TTY/quiet mode).
 func runApplyWithProgressManager(
 	ctx context.Context,
-	eng *engine.Engine,
+	eng applyRunner,
 	resources []resource.Resource,
 	results *ui.ApplyResults,
 	logStore *tomeilog.Store,
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/terassyi/tomei/internal/bundle"
+	"github.com/terassyi/tomei/internal/config"
+	"github.com/terassyi/tomei/internal/credential"
+	"github.com/terassyi/tomei/internal/github"
+	"github.com/terassyi/tomei/internal/installer/download"
+	"github.com/terassyi/tomei/internal/lockfile"
+	"github.com/terassyi/tomei/internal/mirror"
+	"github.com/terassyi/tomei/internal/path"
+	"github.com/terassyi/tomei/internal/registry/aqua"
+	"github.com/terassyi/tomei/internal/resource"
+	"github.com/terassyi/tomei/internal/state"
+)
+
+var bundleCmd = &cobra.Command{
+	Use:   "bundle",
+	Short: "Create offline bundles for air-gapped machines",
+	Long: `Create offline bundles for machines without network access.
+
+A bundle holds the archives, checksum files and aqua registry definitions
+of every download-pattern Tool and Runtime in the manifests, together with
+a tomei.lock that pins the resolved versions. Install from it with
+"tomei apply --bundle".`,
+}
+
+var bundleCreateCmd = &cobra.Command{
+	Use:   "create <files or directories...>",
+	Short: "Resolve and download artifacts into an offline bundle",
+	Long: `Resolve every download-pattern Tool and Runtime in the manifests for this
+platform, download their artifacts, checksum files and upstream signatures,
+and write them into a single tar archive. Signatures are verified under the
+signaturePolicy of the Installer, and recorded so that "tomei apply --bundle"
+verifies them again offline.
+
+tomei.lock next to the manifests is honored, and the pinned versions are
+bundled. Resources that fetch from the network on their own (commands,
+runtime and installer delegation, delegation runtimes, installer bootstrap
+and repositories) are reported as unsupported; "tomei apply --bundle" fails
+for them unless they are already installed.
+
+  tomei bundle create . -o bundle.tar
+  tomei apply --bundle bundle.tar .`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runBundleCreate,
+}
+
+var (
+	bundleCreateCfg    loadConfig
+	bundleCreateOutput string
+)
+
+func init() {
+	bundleCreateCmd.Flags().StringVarP(&bundleCreateOutput, "output", "o", "bundle.tar", "Output bundle path")
+	bundleCreateCmd.Flags().BoolVar(&bundleCreateCfg.ignoreCosign, "ignore-cosign", false, "Skip cosign signature verification for CUE module dependencies")
+	bundleCmd.AddCommand(bundleCreateCmd)
+}
+
+func runBundleCreate(cmd *cobra.Command, args []string) error {
+	if systemMode {
+		return errors.New("bundles only cover user-level resources; --system is not supported")
+	}
+	ctx := cmd.Context()
+
+	verifierOpts, err := bundleCreateCfg.verifierOpts()
+	if err != nil {
+		return err
+	}
+	loader := config.NewLoader(nil, verifierOpts...)
+	resources, err := loader.LoadPaths(args)
+	if err != nil {
+		return fmt.Errorf("failed to load resources: %w", err)
+	}
+	resources, err = resource.ExpandSets(resources)
+	if err != nil {
+		return fmt.Errorf("failed to expand sets: %w", err)
+	}
+	resources = excludeSystemResources(resources)
+
+	lock, err := lockfile.Load(lockfile.PathFor(args))
+	if err != nil {
+		return err
+	}
+
+	mirrors, err := loadMirrors()
+	if err != nil {
+		return err
+	}
+	token := github.TokenFromEnv()
+	ref, err := bundleAquaRef(ctx, lock, mirror.WrapClient(github.NewHTTPClient(token), mirrors))
+	if err != nil {
+		return err
+	}
+
+	workDir, err := os.MkdirTemp("", "tomei-bundle-create-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	cmd.Printf("Creating bundle for %s from %v (aqua-registry %s)\n", bundle.CurrentPlatform(), args, ref)
+	builder := bundle.NewBuilder(workDir, mirror.WrapTransport(mirrors, github.WrapTransport(token, credential.WrapTransport(download.DefaultTransport()))), lock, ref)
+	if err := builder.Add(ctx, resources); err != nil {
+		return err
+	}
+	if err := builder.Write(bundleCreateOutput); err != nil {
+		return err
+	}
+
+	for _, u := range builder.Unsupported() {
+		cmd.Printf("  unsupported offline: %s/%s (%s)\n", u.Kind, u.Name, u.Reason)
+	}
+	cmd.Printf("Wrote %s: %d tools, %d runtimes\n", bundleCreateOutput, len(lock.Tools), len(lock.Runtimes))
+	return nil
+}
+
+// bundleAquaRef returns the aqua registry ref to resolve with: the ref pinned
+// in the lockfile, else the ref in the local state, else the latest ref.
+func bundleAquaRef(ctx context.Context, lock *lockfile.Lockfile, client *http.Client) (string, error) {
+	if ref := lock.AquaRef(); ref != "" {
+		return ref, nil
+	}
+	if appCfg, err := config.LoadConfig(config.DefaultConfigDir); err == nil {
+		if pathConfig, err := path.NewFromConfig(appCfg); err == nil {
+			if store, err := state.NewStore[state.UserState](pathConfig.UserDataDir()); err == nil {
+				if st, err := store.LoadReadOnly(); err == nil && st.Registry != nil && st.Registry.Aqua != nil && st.Registry.Aqua.Ref != "" {
+					return st.Registry.Aqua.Ref, nil
+				}
+			}
+		}
+	}
+	ref, err := aqua.NewVersionClient(client).GetLatestRef(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get latest aqua registry ref: %w", err)
+	}
+	return ref, nil
+}
@@ -0,0 +1,228 @@
+// Package cache implements the "tomei cache" subcommands for the download cache.
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/terassyi/tomei/internal/config"
+	"github.com/terassyi/tomei/internal/installer/download"
+	"github.com/terassyi/tomei/internal/path"
+	"github.com/terassyi/tomei/internal/ui"
+)
+
+// Cmd is the parent command for cache subcommands.
+var Cmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the download cache",
+	Long: `Commands for inspecting and cleaning the download cache.
+
+Verified archives downloaded by "tomei apply" are kept under
+<cacheDir>/downloads (default ~/.cache/tomei/downloads), so reinstalls caused
+by taints, --sync or rollback reuse them instead of downloading again.
+Entries are keyed by URL and stored by the sha256 digest of their content.`,
+}
+
+var listOutput string
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List cached downloads",
+	RunE:  runList,
+}
+
+var (
+	pruneMaxAge  string
+	pruneMaxSize string
+)
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Evict old cached downloads",
+	Long: `Evict cached downloads by age and total size.
+
+Entries not used within --max-age are removed first. If the cache is still
+larger than --max-size, the least recently used entries are removed until
+it fits. Set a limit to 0 to disable it.
+
+  tomei cache prune
+  tomei cache prune --max-age 7d --max-size 2GiB`,
+	RunE: runPrune,
+}
+
+var clearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove all cached downloads",
+	RunE:  runClear,
+}
+
+func init() {
+	listCmd.Flags().StringVarP(&listOutput, "output", "o", "text", "Output format: text, json")
+	pruneCmd.Flags().StringVar(&pruneMaxAge, "max-age", "30d", "Evict entries not used within this duration (e.g., 7d, 72h; 0 disables)")
+	pruneCmd.Flags().StringVar(&pruneMaxSize, "max-size", "0", "Evict least recently used entries until the cache fits (e.g., 500MiB, 2GiB; 0 disables)")
+	Cmd.AddCommand(listCmd)
+	Cmd.AddCommand(pruneCmd)
+	Cmd.AddCommand(clearCmd)
+}
+
+func openCache() (*download.Cache, error) {
+	cfg, err := config.LoadConfig(config.DefaultConfigDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	paths, err := path.NewFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create paths: %w", err)
+	}
+	return download.NewCache(download.CacheDir(paths.UserCacheDir())), nil
+}
+
+func runList(cmd *cobra.Command, _ []string) error {
+	cache, err := openCache()
+	if err != nil {
+		return err
+	}
+	entries, err := cache.List()
+	if err != nil {
+		return err
+	}
+
+	if listOutput == "json" {
+		if entries == nil {
+			entries = []download.CacheEntry{}
+		}
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal cache entries: %w", err)
+		}
+		cmd.Println(string(data))
+		return nil
+	}
+
+	if len(entries) == 0 {
+		cmd.Println("Download cache is empty.")
+		return nil
+	}
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SIZE\tLAST USED\tDIGEST\tURL")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", ui.FormatSize(e.Size), e.LastUsed.Local().Format("2006-01-02 15:04"), shortDigest(string(e.Digest)), e.URL)
+	}
+	_ = w.Flush()
+
+	total, err := cache.Size()
+	if err != nil {
+		return err
+	}
+	cmd.Printf("\n%d entries, %s in %s\n", len(entries), ui.FormatSize(total), cache.Dir())
+	return nil
+}
+
+func runPrune(cmd *cobra.Command, _ []string) error {
+	maxAge, err := parseAge(pruneMaxAge)
+	if err != nil {
+		return fmt.Errorf("invalid --max-age: %w", err)
+	}
+	maxSize, err := parseSize(pruneMaxSize)
+	if err != nil {
+		return fmt.Errorf("invalid --max-size: %w", err)
+	}
+
+	cache, err := openCache()
+	if err != nil {
+		return err
+	}
+	before, err := cache.Size()
+	if err != nil {
+		return err
+	}
+	evicted, err := cache.Prune(maxAge, maxSize)
+	if err != nil {
+		return err
+	}
+	after, err := cache.Size()
+	if err != nil {
+		return err
+	}
+	cmd.Printf("Evicted %d entries, freed %s (%s remaining)\n", len(evicted), ui.FormatSize(before-after), ui.FormatSize(after))
+	return nil
+}
+
+func runClear(cmd *cobra.Command, _ []string) error {
+	cache, err := openCache()
+	if err != nil {
+		return err
+	}
+	size, err := cache.Size()
+	if err != nil {
+		return err
+	}
+	if err := cache.Clear(); err != nil {
+		return err
+	}
+	cmd.Printf("Removed %s from %s\n", ui.FormatSize(size), cache.Dir())
+	return nil
+}
+
+func shortDigest(digest string) string {
+	if len(digest) > 12 {
+		return digest[:12]
+	}
+	return digest
+}
+
+// parseAge parses a duration that also accepts a day suffix (e.g., "30d").
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid number of days %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	if s == "0" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, errors.New("duration must not be negative")
+	}
+	return d, nil
+}
+
+// sizeUnits maps size suffixes to their multiplier, longest suffix first.
+var sizeUnits = []struct {
+	suffix string
+	factor int64
+}{
+	{"GiB", 1 << 30}, {"MiB", 1 << 20}, {"KiB", 1 << 10},
+	{"GB", 1e9}, {"MB", 1e6}, {"KB", 1e3},
+	{"B", 1},
+}
+
+// parseSize parses a byte size such as "500MiB", "2GB" or "1048576".
+func parseSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	factor := int64(1)
+	for _, u := range sizeUnits {
+		if num, ok := strings.CutSuffix(s, u.suffix); ok {
+			s, factor = strings.TrimSpace(num), u.factor
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return int64(n * float64(factor)), nil
+}
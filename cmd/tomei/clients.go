@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/terassyi/tomei/internal/config"
+	"github.com/terassyi/tomei/internal/credential"
+	"github.com/terassyi/tomei/internal/github"
+	"github.com/terassyi/tomei/internal/installer/download"
+	"github.com/terassyi/tomei/internal/mirror"
+)
+
+// newHTTPClients creates the GitHub-aware HTTP clients used to resolve and
+// download resources, with requests routed through the download mirrors:
+//   - ghClient: API client with Client.Timeout for registry sync, version resolution
+//   - dlClient: download client with transport-level timeouts only (no Client.Timeout)
+//     to allow large binary downloads to complete at any speed. Downloads of
+//     resources with a credentialRef are authenticated with that credential.
+func newHTTPClients(mirrors []mirror.Rule) (ghClient, dlClient *http.Client) {
+	token := github.TokenFromEnv()
+	ghClient = mirror.WrapClient(github.NewHTTPClient(token), mirrors)
+	dlClient = &http.Client{
+		Transport: mirror.WrapTransport(mirrors, github.WrapTransport(token, credential.WrapTransport(download.DefaultTransport()))),
+	}
+	return ghClient, dlClient
+}
+
+// loadMirrors returns the download mirrors configured in config.cue.
+func loadMirrors() ([]mirror.Rule, error) {
+	appCfg, err := config.LoadConfig(config.DefaultConfigDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	return appCfg.Mirrors, nil
+}
@@ -5,7 +5,7 @@ import (
 )
 
 var completionCmd = &cobra.Command{
-	Use:   "completion [bash|zsh|fish|powershell]",
+	Use:   "completion [bash|zsh|fish|powershell|pwsh]",
 	Short: "Generate shell completion scripts",
 	Long: `Generate shell completion scripts for tomei.
 
@@ -19,10 +19,12 @@ Usage:
   fish:
     tomei completion fish | source
 
-  powershell:
-    tomei completion powershell | Out-String | Invoke-Expression`,
+  powershell (pwsh):
+    tomei completion powershell | Out-String | Invoke-Expression
+
+Cobra does not generate completions for nushell, elvish or xonsh.`,
 	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
-	ValidArgs: []string{"bash", "zsh", "fish", "powershell"},
+	ValidArgs: []string{"bash", "zsh", "fish", "powershell", "pwsh"},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		switch args[0] {
 		case "bash":
@@ -31,8 +33,8 @@ Usage:
 			return rootCmd.GenZshCompletion(cmd.OutOrStdout())
 		case "fish":
 			return rootCmd.GenFishCompletion(cmd.OutOrStdout(), true)
-		case "powershell":
-			return rootCmd.GenPowerShellCompletion(cmd.OutOrStdout())
+		case "powershell", "pwsh":
+			return rootCmd.GenPowerShellCompletionWithDesc(cmd.OutOrStdout())
 		}
 		return nil
 	},
@@ -1,8 +1,14 @@
 package main
 
 import (
-	"context"
+	"bufio"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/fatih/color"
@@ -10,12 +16,22 @@ import (
 
 	"github.com/terassyi/tomei/internal/config"
 	"github.com/terassyi/tomei/internal/doctor"
+	"github.com/terassyi/tomei/internal/env"
+	"github.com/terassyi/tomei/internal/installer"
+	"github.com/terassyi/tomei/internal/installer/download"
+	"github.com/terassyi/tomei/internal/installer/engine"
 	"github.com/terassyi/tomei/internal/path"
+	"github.com/terassyi/tomei/internal/resource"
 	"github.com/terassyi/tomei/internal/state"
 	"github.com/terassyi/tomei/internal/ui"
 )
 
-var doctorNoColor bool
+var (
+	doctorCfg         applyConfig
+	doctorFix         bool
+	doctorInteractive bool
+	doctorManifests   []string
+)
 
 var doctorCmd = &cobra.Command{
 	Use:   "doctor",
@@ -25,20 +41,46 @@ var doctorCmd = &cobra.Command{
 Checks for:
   - Unmanaged tools in runtime bin paths (~/go/bin, ~/.cargo/bin)
   - Conflicts between tomei-managed and unmanaged tools
-  - State file integrity`,
+  - State file integrity
+  - Binaries and runtime trees modified after installation (see "tomei verify")
+
+With --fix, the issues are repaired after showing a repair plan:
+  - Missing or broken symlinks are re-created from the installed files
+  - Tools and runtimes whose files are gone or were modified are reinstalled at the installed version
+  - Broken resources no longer defined in the manifests are removed from state
+  - PATH ordering problems are fixed by regenerating the exported env files
+
+Resource repairs run through the same pipeline as "tomei apply" and are
+shown as a plan before anything changes. Doctor refuses to repair when the
+manifests have other pending changes. --fix requires the manifests (-f),
+since resources they do not define are dropped from state. With
+--interactive, each repair is confirmed separately.
+
+  tomei doctor --fix -f ~/dotfiles/tomei
+  tomei doctor --interactive`,
+	Args: cobra.NoArgs,
 	RunE: runDoctor,
 }
 
 func init() {
-	doctorCmd.Flags().BoolVar(&doctorNoColor, "no-color", false, "Disable color output")
+	doctorCmd.Flags().BoolVar(&doctorCfg.noColor, "no-color", false, "Disable color output")
+	doctorCmd.Flags().BoolVar(&doctorFix, "fix", false, "Repair the detected issues")
+	doctorCmd.Flags().BoolVarP(&doctorInteractive, "interactive", "i", false, "Confirm each repair (implies --fix)")
+	doctorCmd.Flags().StringSliceVarP(&doctorManifests, "manifests", "f", nil, "Manifest files or directories (required with --fix)")
+	doctorCmd.Flags().BoolVarP(&doctorCfg.yes, "yes", "y", false, "Skip confirmation prompt")
+	doctorCmd.Flags().BoolVar(&doctorCfg.quiet, "quiet", false, "Suppress progress output")
+	doctorCmd.Flags().BoolVar(&doctorCfg.ignoreCosign, "ignore-cosign", false, "Skip cosign signature verification for CUE module dependencies")
+	doctorCmd.Flags().IntVar(&doctorCfg.parallel, "parallel", engine.DefaultParallelism, "Maximum number of parallel installations (1-20)")
+	doctorCmd.Flags().DurationVar(&doctorCfg.timeout, "timeout", download.DefaultDownloadTimeout, "Timeout per download attempt (e.g., 5m, 10m, 1h)")
+	doctorCmd.Flags().IntVar(&doctorCfg.retries, "retries", download.DefaultRetries, "Number of retries of a failed download (0 disables retries)")
 }
 
 func runDoctor(cmd *cobra.Command, _ []string) error {
-	if doctorNoColor {
+	if doctorCfg.noColor {
 		color.NoColor = true
 	}
 
-	ctx := context.Background()
+	ctx := cmd.Context()
 
 	// Load config
 	cfg, err := config.LoadConfig(config.DefaultConfigDir)
@@ -57,15 +99,9 @@ func runDoctor(cmd *cobra.Command, _ []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to create state store: %w", err)
 	}
-
-	if err := store.Lock(); err != nil {
-		return fmt.Errorf("failed to lock state: %w", err)
-	}
-	defer func() { _ = store.Unlock() }()
-
-	userState, err := store.Load()
+	userState, err := loadStateLocked(store)
 	if err != nil {
-		return fmt.Errorf("failed to load state: %w", err)
+		return err
 	}
 
 	// Run doctor
@@ -81,6 +117,246 @@ func runDoctor(cmd *cobra.Command, _ []string) error {
 	// Print results
 	printDoctorResult(cmd, result)
 
+	if !doctorFix && !doctorInteractive {
+		return nil
+	}
+	if systemMode {
+		return errors.New("doctor --fix only supports user-level resources; --system is not supported")
+	}
+	if len(doctorManifests) == 0 {
+		return errors.New("doctor --fix requires the manifests: pass them with -f")
+	}
+	return runDoctorFix(cmd, doc, result, userState, paths, store)
+}
+
+// loadStateLocked loads the state while holding the state lock. The lock is
+// released before returning so that repairs can run apply.
+func loadStateLocked(store *state.Store[state.UserState]) (*state.UserState, error) {
+	if err := store.Lock(); err != nil {
+		return nil, fmt.Errorf("failed to lock state: %w", err)
+	}
+	defer func() { _ = store.Unlock() }()
+
+	userState, err := store.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load state: %w", err)
+	}
+	return userState, nil
+}
+
+// runDoctorFix plans repairs for the doctor result, lets the user confirm
+// them and executes them.
+func runDoctorFix(cmd *cobra.Command, doc *doctor.Doctor, result *doctor.Result, userState *state.UserState, paths *path.Paths, store *state.Store[state.UserState]) error {
+	// Manifests decide between reinstalling and dropping broken resources
+	declared := func(resource.Kind, string) bool { return true }
+	if len(result.StateIssues) > 0 || len(result.Drift) > 0 {
+		defined, err := loadDefinedResources(doctorManifests, &doctorCfg)
+		if err != nil {
+			return err
+		}
+		declared = func(kind resource.Kind, name string) bool {
+			return defined[string(kind)+"/"+name]
+		}
+	}
+
+	repairs := doc.PlanRepairs(result, declared)
+	if len(repairs) == 0 {
+		cmd.Println("Nothing to repair.")
+		return nil
+	}
+	printRepairPlan(cmd, repairs)
+
+	reader := bufio.NewReader(os.Stdin)
+	if doctorInteractive {
+		repairs = selectRepairs(cmd.OutOrStdout(), reader, repairs)
+		if len(repairs) == 0 {
+			cmd.Println("No repairs selected.")
+			return nil
+		}
+	}
+
+	set, regenerateEnv := newRepairSet(repairs, userState)
+	if !set.empty() {
+		cfg := doctorCfg
+		cfg.repair = set
+		cmd.Printf("Repairing resources using manifests from %v\n", doctorManifests)
+		if err := runUserApply(cmd.Context(), doctorManifests, cmd.OutOrStdout(), &cfg); err != nil {
+			return err
+		}
+		if cfg.canceled {
+			return nil
+		}
+	} else if regenerateEnv && !doctorCfg.yes && !doctorInteractive {
+		if !askYesNo(cmd.OutOrStdout(), reader, "\nDo you want to continue?") {
+			cmd.Println("Canceled.")
+			return nil
+		}
+	}
+
+	if regenerateEnv {
+		return regenerateEnvFiles(cmd, paths, store)
+	}
+	return nil
+}
+
+// loadDefinedResources returns the "Kind/name" keys of the user-level
+// resources defined in the manifests. Manifests defining none are rejected,
+// since every broken resource would then be dropped from state.
+func loadDefinedResources(manifests []string, cfg *applyConfig) (map[string]bool, error) {
+	verifierOpts, err := cfg.verifierOpts()
+	if err != nil {
+		return nil, err
+	}
+	loader := config.NewLoader(nil, verifierOpts...)
+	resources, err := loader.LoadPaths(manifests)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load resources: %w", err)
+	}
+	resources, err = resource.ExpandSets(resources)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand sets: %w", err)
+	}
+	defined := make(map[string]bool)
+	for _, res := range excludeSystemResources(resources) {
+		defined[string(res.Kind())+"/"+res.Name()] = true
+	}
+	if len(defined) == 0 {
+		return nil, fmt.Errorf("manifests %v define no user-level resources; pass the manifests that installed them with -f", manifests)
+	}
+	return defined, nil
+}
+
+// printRepairPlan prints the planned repairs with the issues they fix.
+func printRepairPlan(cmd *cobra.Command, repairs []doctor.Repair) {
+	style := ui.NewStyle()
+	style.Header.Fprintln(cmd.OutOrStdout(), "Repair Plan:")
+	for _, r := range repairs {
+		cmd.Printf("  %s %s\n", style.WarnMark, r.Message())
+		cmd.Printf("       %s\n", r.Reason)
+	}
+	cmd.Println()
+}
+
+// selectRepairs asks for each repair whether to perform it and returns the accepted ones.
+func selectRepairs(w io.Writer, reader *bufio.Reader, repairs []doctor.Repair) []doctor.Repair {
+	var selected []doctor.Repair
+	for _, r := range repairs {
+		if askYesNo(w, reader, fmt.Sprintf("Repair: %s?", r.Message())) {
+			selected = append(selected, r)
+		}
+	}
+	fmt.Fprintln(w)
+	return selected
+}
+
+// askYesNo prints a prompt and reports whether the answer is yes.
+func askYesNo(w io.Writer, reader *bufio.Reader, prompt string) bool {
+	fmt.Fprintf(w, "%s [y/N] ", prompt)
+	answer, _ := reader.ReadString('\n')
+	answer = strings.TrimSpace(strings.ToLower(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// repairSet is what "tomei doctor --fix" asks apply to do.
+type repairSet struct {
+	// tools and runtimes map names to the installed versions to reinstall.
+	tools    map[string]installer.Pin
+	runtimes map[string]installer.Pin
+	// remove lists the "Kind/name" keys of state entries to drop.
+	remove []string
+}
+
+// newRepairSet collects the resource repairs and reports whether the env
+// files should be regenerated.
+func newRepairSet(repairs []doctor.Repair, userState *state.UserState) (*repairSet, bool) {
+	set := &repairSet{
+		tools:    make(map[string]installer.Pin),
+		runtimes: make(map[string]installer.Pin),
+	}
+	regenerateEnv := false
+	for _, r := range repairs {
+		switch r.Action {
+		case doctor.RepairRelink, doctor.RepairReinstall:
+			switch r.ResourceKind {
+			case resource.KindTool:
+				if ts, ok := userState.Tools[r.Name]; ok {
+					set.tools[r.Name] = installer.Pin{Version: ts.Version, Digest: ts.Digest}
+				}
+			case resource.KindRuntime:
+				if rs, ok := userState.Runtimes[r.Name]; ok {
+					set.runtimes[r.Name] = installer.Pin{Version: rs.Version, Digest: rs.Digest}
+				}
+			}
+		case doctor.RepairDrop:
+			set.remove = append(set.remove, string(r.ResourceKind)+"/"+r.Name)
+		case doctor.RepairRegenerateEnv:
+			regenerateEnv = true
+		}
+	}
+	return set, regenerateEnv
+}
+
+// empty reports whether the set has no resource repairs.
+func (s *repairSet) empty() bool {
+	return len(s.tools) == 0 && len(s.runtimes) == 0 && len(s.remove) == 0
+}
+
+// actions returns the engine actions of the repairs, keyed by "Kind/name".
+// Repaired resources are tainted, which the engine executes as an upgrade.
+func (s *repairSet) actions() map[string]resource.ActionType {
+	actions := make(map[string]resource.ActionType)
+	for name := range s.tools {
+		actions[string(resource.KindTool)+"/"+name] = resource.ActionUpgrade
+	}
+	for name := range s.runtimes {
+		actions[string(resource.KindRuntime)+"/"+name] = resource.ActionUpgrade
+	}
+	for _, key := range s.remove {
+		actions[key] = resource.ActionRemove
+	}
+	return actions
+}
+
+// pinRepairVersions taints the repaired tools and runtimes and pins them to
+// their installed versions so that reinstalling does not upgrade them.
+func pinRepairVersions(s *repairSet, tools, runtimes map[string]installer.Pin, updCfg *engine.UpdateConfig) {
+	for _, name := range slices.Sorted(maps.Keys(s.tools)) {
+		pin := s.tools[name]
+		tools[name] = pin
+		updCfg.LockedTools[name] = pin.Version
+		updCfg.RepairToolNames = append(updCfg.RepairToolNames, name)
+	}
+	for _, name := range slices.Sorted(maps.Keys(s.runtimes)) {
+		pin := s.runtimes[name]
+		runtimes[name] = pin
+		updCfg.LockedRuntimes[name] = pin.Version
+		updCfg.RepairRuntimeNames = append(updCfg.RepairRuntimeNames, name)
+	}
+}
+
+// regenerateEnvFiles rewrites the env files written by "tomei env --export"
+// from the current state.
+func regenerateEnvFiles(cmd *cobra.Command, paths *path.Paths, store *state.Store[state.UserState]) error {
+	store.SetQuiet(true)
+	userState, err := loadStateLocked(store)
+	if err != nil {
+		return err
+	}
+
+	written := 0
+	for _, shellType := range env.ShellTypes() {
+		formatter := env.NewFormatter(shellType)
+		if _, err := os.Stat(filepath.Join(paths.EnvDir(), "env"+formatter.Ext())); err != nil {
+			continue
+		}
+		if err := writeEnvFile(cmd, renderEnv(userState, paths, formatter), paths.EnvDir(), formatter.Ext()); err != nil {
+			return err
+		}
+		written++
+	}
+	if written == 0 {
+		cmd.Println(`No exported env file found. Make sure 'eval "$(tomei env)"' runs after other PATH changes in your shell profile.`)
+	}
 	return nil
 }
 
@@ -99,6 +375,7 @@ func printDoctorResult(cmd *cobra.Command, result *doctor.Result) {
 	warningCount := 0
 	conflictCount := len(result.Conflicts)
 	stateIssueCount := len(result.StateIssues)
+	driftCount := len(result.Drift)
 
 	// Print unmanaged tools by category
 	for category, tools := range result.UnmanagedTools {
@@ -147,6 +424,15 @@ func printDoctorResult(cmd *cobra.Command, result *doctor.Result) {
 		cmd.Println()
 	}
 
+	// Print drifted files
+	if len(result.Drift) > 0 {
+		cmd.Printf("[%s]\n", color.New(color.FgRed).Sprint("Drift"))
+		for _, issue := range result.Drift {
+			cmd.Printf("  %s %s: %s\n", style.FailMark, issue.Name, issue.Message())
+		}
+		cmd.Println()
+	}
+
 	// Print summary
 	summaryParts := []string{}
 	if warningCount > 0 {
@@ -158,6 +444,9 @@ func printDoctorResult(cmd *cobra.Command, result *doctor.Result) {
 	if stateIssueCount > 0 {
 		summaryParts = append(summaryParts, color.New(color.FgRed).Sprintf("%d state issues", stateIssueCount))
 	}
+	if driftCount > 0 {
+		summaryParts = append(summaryParts, color.New(color.FgRed).Sprintf("%d drifted files", driftCount))
+	}
 	if len(summaryParts) > 0 {
 		cmd.Printf("Summary: %s\n", strings.Join(summaryParts, ", "))
 		cmd.Println()
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/terassyi/tomei/internal/doctor"
+	"github.com/terassyi/tomei/internal/graph"
+	"github.com/terassyi/tomei/internal/installer"
+	"github.com/terassyi/tomei/internal/installer/engine"
+	"github.com/terassyi/tomei/internal/resource"
+	"github.com/terassyi/tomei/internal/state"
+)
+
+func doctorTestRepairs() []doctor.Repair {
+	return []doctor.Repair{
+		{Action: doctor.RepairRelink, ResourceKind: resource.KindTool, Name: "rg", Version: "14.1.0"},
+		{Action: doctor.RepairReinstall, ResourceKind: resource.KindRuntime, Name: "go", Version: "1.25.5"},
+		{Action: doctor.RepairDrop, ResourceKind: resource.KindTool, Name: "old", Version: "1.0.0"},
+		{Action: doctor.RepairRegenerateEnv},
+	}
+}
+
+func TestNewRepairSet(t *testing.T) {
+	t.Parallel()
+
+	st := state.NewUserState()
+	st.Tools["rg"] = &resource.ToolState{Version: "14.1.0", Digest: "abc"}
+	st.Tools["old"] = &resource.ToolState{Version: "1.0.0"}
+	st.Runtimes["go"] = &resource.RuntimeState{Version: "1.25.5"}
+
+	set, regenerateEnv := newRepairSet(doctorTestRepairs(), st)
+	assert.True(t, regenerateEnv)
+	assert.False(t, set.empty())
+	assert.Equal(t, map[string]installer.Pin{"rg": {Version: "14.1.0", Digest: "abc"}}, set.tools)
+	assert.Equal(t, map[string]installer.Pin{"go": {Version: "1.25.5"}}, set.runtimes)
+	assert.Equal(t, map[string]resource.ActionType{
+		"Tool/rg":    resource.ActionUpgrade,
+		"Runtime/go": resource.ActionUpgrade,
+		"Tool/old":   resource.ActionRemove,
+	}, set.actions())
+
+	set, regenerateEnv = newRepairSet([]doctor.Repair{{Action: doctor.RepairRegenerateEnv}}, st)
+	assert.True(t, regenerateEnv)
+	assert.True(t, set.empty())
+}
+
+func TestPinRepairVersions(t *testing.T) {
+	t.Parallel()
+
+	set := &repairSet{
+		tools:    map[string]installer.Pin{"rg": {Version: "14.1.0"}},
+		runtimes: map[string]installer.Pin{"go": {Version: "1.25.5"}},
+	}
+	tools := map[string]installer.Pin{"rg": {Version: "14.1.1"}}
+	runtimes := map[string]installer.Pin{}
+	updCfg := engine.UpdateConfig{
+		LockedTools:    map[string]string{"rg": "14.1.1"},
+		LockedRuntimes: map[string]string{},
+	}
+
+	pinRepairVersions(set, tools, runtimes, &updCfg)
+	assert.Equal(t, installer.Pin{Version: "14.1.0"}, tools["rg"], "installed version overrides the lock")
+	assert.Equal(t, installer.Pin{Version: "1.25.5"}, runtimes["go"])
+	assert.Equal(t, "14.1.0", updCfg.LockedTools["rg"])
+	assert.Equal(t, []string{"rg"}, updCfg.RepairToolNames)
+	assert.Equal(t, []string{"go"}, updCfg.RepairRuntimeNames)
+}
+
+func TestSelectRepairs(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	reader := bufio.NewReader(strings.NewReader("y\nn\nyes\n"))
+	selected := selectRepairs(&out, reader, doctorTestRepairs())
+
+	require.Len(t, selected, 2)
+	assert.Equal(t, "rg", selected[0].Name)
+	assert.Equal(t, "old", selected[1].Name)
+	assert.Contains(t, out.String(), "Repair: re-create symlinks of Tool/rg 14.1.0? [y/N]")
+}
+
+func TestCheckAllowedActions(t *testing.T) {
+	t.Parallel()
+
+	info := map[graph.NodeID]graph.ResourceInfo{
+		graph.NewNodeID(resource.KindTool, "rg"):    {Action: resource.ActionUpgrade},
+		graph.NewNodeID(resource.KindTool, "jq"):    {Action: resource.ActionNone},
+		graph.NewNodeID(resource.KindTool, "bat"):   {Action: resource.ActionSkip},
+		graph.NewNodeID(resource.KindRuntime, "go"): {Action: resource.ActionUpgrade},
+	}
+	allowed := map[string]resource.ActionType{
+		"Tool/rg":    resource.ActionUpgrade,
+		"Runtime/go": resource.ActionUpgrade,
+	}
+	require.NoError(t, checkAllowedActions(info, allowed))
+
+	info[graph.NewNodeID(resource.KindTool, "fd")] = graph.ResourceInfo{Action: resource.ActionInstall}
+	err := checkAllowedActions(info, allowed)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Tool/fd (install)")
+}
+
+func TestLoadDefinedResources(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	manifest := `
+apiVersion: "tomei.terassyi.net/v1beta1"
+kind: "Tool"
+metadata: name: "jq"
+spec: {
+    installerRef: "aqua"
+    version: "1.7.1"
+    package: "jqlang/jq"
+}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tools.cue"), []byte(manifest), 0644))
+	defined, err := loadDefinedResources([]string{dir}, &applyConfig{loadConfig: loadConfig{ignoreCosign: true}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"Tool/jq": true}, defined)
+
+	// Manifests without user-level resources would drop everything from state.
+	_, err = loadDefinedResources([]string{t.TempDir()}, &applyConfig{loadConfig: loadConfig{ignoreCosign: true}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "define no user-level resources")
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
 	"strings"
@@ -11,12 +12,15 @@ import (
 	"github.com/terassyi/tomei/internal/config"
 	"github.com/terassyi/tomei/internal/env"
 	"github.com/terassyi/tomei/internal/path"
+	"github.com/terassyi/tomei/internal/project"
+	"github.com/terassyi/tomei/internal/resource"
 	"github.com/terassyi/tomei/internal/state"
 )
 
 var (
 	envShell  string
 	envExport bool
+	envHook   bool
 )
 
 var envCmd = &cobra.Command{
@@ -30,25 +34,55 @@ Sets up:
   - CUE_REGISTRY for CUE module resolution (when cue.mod/ is present)
 
 Stdout mode (default):
-  eval "$(tomei env)"              # bash / zsh
-  tomei env --shell fish | source  # fish
+  eval "$(tomei env)"                                            # bash / zsh
+  tomei env --shell fish | source                                # fish
+  tomei env --shell powershell | Out-String | Invoke-Expression  # PowerShell
+  eval (tomei env --shell elvish | slurp)                        # elvish
+  execx($(tomei env --shell xonsh))                              # xonsh
 
-File export mode (writes to env directory, extension matches --shell):
+Project versions:
+  When a .tomei-version file is found in the working directory or a parent,
+  the runtime versions it selects are used instead of the default versions.
+  Each line is "<runtime> <version>"; the version must be the runtime's
+  version or one of its extraVersions:
+    go 1.24.3
+    node 22.14.0
+
+File export mode (writes to env directory, extension matches --shell,
+ignores .tomei-version):
   tomei env --export
   source ~/.config/tomei/env.sh    # posix (default path)
   source ~/.config/tomei/env.fish  # fish (default path)
+  source ~/.config/tomei/env.nu    # nushell (config.nu; nushell cannot
+                                   # evaluate command output, so use --export)
+
+Hook mode (run by the shell hook installed with "tomei hook"):
+  Outputs the statements that switch from the project environment active
+  in the shell to the one of the working directory: the bin directory of
+  an allowed project and the versions selected by .tomei-version.
+  tomei env --hook
 
 Shell types:
-  --shell posix   POSIX-compatible (bash, zsh) [default]
-  --shell fish    fish shell`,
+  --shell posix       POSIX-compatible (bash, zsh) [default]
+  --shell fish        fish shell
+  --shell nushell     nushell (alias: nu)
+  --shell powershell  PowerShell (alias: pwsh)
+  --shell elvish      elvish shell
+  --shell xonsh       xonsh shell`,
 	RunE: runEnv,
 }
 
 func init() {
-	envCmd.Flags().StringVar(&envShell, "shell", "posix", "Shell type (posix, fish)")
+	envCmd.Flags().StringVar(&envShell, "shell", "posix", "Shell type (posix, fish, nushell, powershell, elvish, xonsh)")
 	envCmd.Flags().BoolVar(&envExport, "export", false, "Write to file instead of stdout")
+	envCmd.Flags().BoolVar(&envHook, "hook", false, "Output the project environment changes for the shell hook")
+	envCmd.MarkFlagsMutuallyExclusive("export", "hook")
 	_ = envCmd.RegisterFlagCompletionFunc("shell", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
-		return []string{"posix", "fish"}, cobra.ShellCompDirectiveNoFileComp
+		shells := make([]string, 0, len(env.ShellTypes()))
+		for _, st := range env.ShellTypes() {
+			shells = append(shells, string(st))
+		}
+		return shells, cobra.ShellCompDirectiveNoFileComp
 	})
 }
 
@@ -71,6 +105,10 @@ func runEnv(cmd *cobra.Command, _ []string) error {
 		return fmt.Errorf("failed to create paths: %w", err)
 	}
 
+	if envHook {
+		return runEnvHook(cmd, paths, env.NewFormatter(shellType))
+	}
+
 	// Load state (quiet: suppress warnings since stdout is eval'd by shell)
 	store, err := state.NewStore[state.UserState](paths.UserDataDir())
 	if err != nil {
@@ -90,6 +128,144 @@ func runEnv(cmd *cobra.Command, _ []string) error {
 
 	// Generate env output
 	formatter := env.NewFormatter(shellType)
+
+	// Export to file or print to stdout. Exported files are shared by every
+	// shell, so project version selection only applies to stdout.
+	if envExport {
+		return writeEnvFile(cmd, renderEnv(userState, paths, formatter), paths.EnvDir(), formatter.Ext())
+	}
+
+	if err := selectProjectVersions(cmd, userState); err != nil {
+		return err
+	}
+	fmt.Fprint(cmd.OutOrStdout(), renderEnv(userState, paths, formatter))
+	return nil
+}
+
+// selectProjectVersions replaces the runtimes in userState with the versions
+// selected by the nearest .tomei-version file. Versions that are not
+// installed are reported on stderr, since stdout is eval'd by the shell.
+func selectProjectVersions(cmd *cobra.Command, userState *state.UserState) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		// Without a working directory there is no project to select versions for
+		return nil
+	}
+	versionFile := env.FindVersionFile(cwd)
+	if versionFile == "" {
+		return nil
+	}
+	versions, err := env.ReadVersionFile(versionFile)
+	if err != nil {
+		return err
+	}
+	runtimes, warnings := env.SelectVersions(userState.Runtimes, versions)
+	for _, w := range warnings {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %s\n", w)
+	}
+	userState.Runtimes = runtimes
+	return nil
+}
+
+// runEnvHook prints the statements that activate the project layer of the
+// working directory. It runs before every prompt, so it does not take the
+// state lock, and warnings are only printed when the layer changes.
+func runEnvHook(cmd *cobra.Command, paths *path.Paths, formatter env.Formatter) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		// Without a working directory there is no project to activate
+		return nil
+	}
+
+	var layer env.Layer
+	var warnings []string
+	// Runtimes of the project override the global runtimes of the same name
+	var projectRuntimes map[string]*resource.RuntimeState
+
+	cfgDir, err := path.Expand(config.DefaultConfigDir)
+	if err != nil {
+		return fmt.Errorf("failed to expand config directory: %w", err)
+	}
+	if proj := project.Find(cwd, cfgDir); proj != nil {
+		status, err := project.NewTrustStore(paths.ProjectsDir()).Status(proj)
+		if err != nil {
+			return err
+		}
+		layer.Sources = append(layer.Sources, proj.Manifest())
+		if status == project.TrustAllowed {
+			projectPaths := paths.ForProject(proj.ID())
+			if _, err := os.Stat(projectPaths.UserStateFile()); err == nil {
+				projectState, err := loadHookState(projectPaths)
+				if err != nil {
+					return err
+				}
+				projectRuntimes = projectState.Runtimes
+			}
+			projectLayer := env.ProjectLayer(projectPaths.UserBinDir(), projectRuntimes)
+			layer.Path = append(layer.Path, projectLayer.Path...)
+			layer.Env = projectLayer.Env
+		} else {
+			warnings = append(warnings, fmt.Sprintf("%s is %s; review it and run 'tomei allow %s'", proj.Manifest(), status, proj.Root))
+		}
+	}
+
+	if versionFile := env.FindVersionFile(cwd); versionFile != "" {
+		layer.Sources = append(layer.Sources, versionFile)
+		versions, err := env.ReadVersionFile(versionFile)
+		if err != nil {
+			warnings = append(warnings, err.Error())
+		} else {
+			userState, err := loadHookState(paths)
+			if err != nil {
+				return err
+			}
+			runtimes := maps.Clone(userState.Runtimes)
+			if runtimes == nil {
+				runtimes = make(map[string]*resource.RuntimeState)
+			}
+			maps.Copy(runtimes, projectRuntimes)
+			selected, w := env.SelectedLayer(runtimes, versions)
+			warnings = append(warnings, w...)
+			layer.Path = append(layer.Path, selected.Path...)
+			for key, value := range selected.Env {
+				if layer.Env == nil {
+					layer.Env = make(map[string]string)
+				}
+				layer.Env[key] = value
+			}
+		}
+	}
+
+	lines, changed := env.Hook(layer, os.LookupEnv, formatter)
+	if changed {
+		for _, w := range warnings {
+			fmt.Fprintf(cmd.ErrOrStderr(), "tomei: %s\n", w)
+		}
+	}
+	for _, line := range lines {
+		fmt.Fprintln(cmd.OutOrStdout(), line)
+	}
+	return nil
+}
+
+// loadHookState loads the user state in paths without locking it, so that the
+// shell hook does not wait for a running apply.
+func loadHookState(paths *path.Paths) (*state.UserState, error) {
+	store, err := state.NewStore[state.UserState](paths.UserDataDir())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create state store: %w", err)
+	}
+	store.SetQuiet(true)
+	userState, err := store.LoadReadOnly()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load state: %w", err)
+	}
+	return userState, nil
+}
+
+// renderEnv returns the env statements for the installed runtimes and
+// installers, plus CUE_REGISTRY when the working directory is a CUE module.
+func renderEnv(userState *state.UserState, paths *path.Paths, formatter env.Formatter) string {
 	lines := env.Generate(userState.Runtimes, userState.Installers, paths.UserBinDir(), formatter)
 
 	// Add CUE_REGISTRY if cue.mod/ exists and CUE_REGISTRY is not already set.
@@ -112,14 +288,7 @@ func runEnv(cmd *cobra.Command, _ []string) error {
 	if len(lines) > 0 {
 		output += "\n"
 	}
-
-	// Export to file or print to stdout
-	if envExport {
-		return writeEnvFile(cmd, output, paths.EnvDir(), formatter.Ext())
-	}
-
-	fmt.Fprint(cmd.OutOrStdout(), output)
-	return nil
+	return output
 }
 
 // hasCueMod checks whether a cue.mod/ directory exists at or above dir.
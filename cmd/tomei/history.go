@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/terassyi/tomei/internal/history"
+	"github.com/terassyi/tomei/internal/installer/engine"
+	"github.com/terassyi/tomei/internal/resource"
+	"github.com/terassyi/tomei/internal/state"
+)
+
+var (
+	historyKind   string
+	historyName   string
+	historySince  string
+	historyFailed bool
+	historyOutput string
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history [number]",
+	Short: "Show the history of applies",
+	Long: `Show the history of "tomei apply" runs.
+
+Every apply (including "tomei state rollback", "tomei lock update" and
+"tomei doctor --fix", and applies of project manifests) appends a record
+with its time, tomei version, manifest hash, duration and outcome, and each
+action with the old and new version, duration, outcome and error.
+
+Without arguments, lists the recorded applies, oldest first. With a record
+number, shows the record in detail.
+
+Examples:
+  tomei history                          # list all applies
+  tomei history --name ripgrep           # when did ripgrep change?
+  tomei history --kind runtime --since 30d
+  tomei history --failed                 # failed actions only
+  tomei history 12                       # show apply #12
+  tomei history -o json                  # records as JSON`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runHistory,
+}
+
+func init() {
+	historyCmd.Flags().StringVar(&historyKind, "kind", "", "Show only actions on resources of this kind (e.g. tool, runtime)")
+	historyCmd.Flags().StringVar(&historyName, "name", "", "Show only actions on resources with this name")
+	historyCmd.Flags().StringVar(&historySince, "since", "", "Show only applies since a duration ago (e.g. 36h, 7d), a date or an RFC 3339 timestamp")
+	historyCmd.Flags().BoolVar(&historyFailed, "failed", false, "Show only failed actions and applies")
+	historyCmd.Flags().StringVarP(&historyOutput, "output", "o", "text", "Output format: text, json")
+	_ = historyCmd.RegisterFlagCompletionFunc("output", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return []string{"text", "json"}, cobra.ShellCompDirectiveNoFileComp
+	})
+}
+
+func runHistory(cmd *cobra.Command, args []string) error {
+	filter := history.Filter{Name: historyName, Failed: historyFailed}
+	if historyKind != "" {
+		kind, ok := resource.NormalizeKind(historyKind)
+		if !ok {
+			return fmt.Errorf("unknown resource kind %q", historyKind)
+		}
+		filter.Kind = kind
+	}
+	if historySince != "" {
+		since, err := history.ParseSince(historySince, time.Now())
+		if err != nil {
+			return err
+		}
+		filter.Since = since
+	}
+
+	pathConfig, err := userPaths()
+	if err != nil {
+		return err
+	}
+	records, err := history.Load(pathConfig.ApplyHistoryFile())
+	if err != nil {
+		return err
+	}
+
+	if len(args) > 0 {
+		number, err := strconv.Atoi(args[0])
+		if err != nil || number < 1 {
+			return fmt.Errorf("invalid record number %q", args[0])
+		}
+		if number > len(records) {
+			return fmt.Errorf("apply #%d not found in the history (%d records)", number, len(records))
+		}
+		records = records[number-1 : number]
+	}
+	records = filter.Apply(records)
+
+	if historyOutput == outputJSON {
+		if records == nil {
+			records = []history.Record{}
+		}
+		data, err := json.MarshalIndent(records, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal history: %w", err)
+		}
+		cmd.Println(string(data))
+		return nil
+	}
+
+	if len(records) == 0 {
+		cmd.Println("No applies found.")
+		return nil
+	}
+	if len(args) > 0 {
+		printHistoryRecord(cmd.OutOrStdout(), &records[0])
+		return nil
+	}
+	printHistoryList(cmd.OutOrStdout(), records)
+	return nil
+}
+
+func printHistoryList(out io.Writer, records []history.Record) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tSTARTED\tOUTCOME\tDURATION\tCHANGES")
+	for _, rec := range records {
+		changes := summarizeActions(rec.Actions)
+		if rec.Project != "" {
+			changes += " (project " + rec.Project + ")"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", rec.Number, rec.StartedAt.Local().Format(time.DateTime), rec.Outcome, rec.Duration, changes)
+	}
+	_ = w.Flush()
+}
+
+// summarizeActions renders the actions of an apply on one line, e.g.
+// "+jq@1.7.1 ~gh(2.60.0→2.62.0) -fd !rg".
+func summarizeActions(actions []history.Action) string {
+	if len(actions) == 0 {
+		return "-"
+	}
+	parts := make([]string, 0, len(actions))
+	for _, a := range actions {
+		switch {
+		case a.Outcome != history.OutcomeSuccess:
+			parts = append(parts, "!"+a.Name)
+		case a.Action == resource.ActionRemove:
+			parts = append(parts, "-"+a.Name)
+		case a.OldVersion == "":
+			parts = append(parts, "+"+a.Name+"@"+a.NewVersion)
+		case a.OldVersion != a.NewVersion:
+			parts = append(parts, fmt.Sprintf("~%s(%s→%s)", a.Name, a.OldVersion, a.NewVersion))
+		default:
+			parts = append(parts, "~"+a.Name)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func printHistoryRecord(out io.Writer, rec *history.Record) {
+	fmt.Fprintf(out, "Apply #%d\n", rec.Number)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "  Started:\t%s\n", rec.StartedAt.Local().Format(time.DateTime))
+	fmt.Fprintf(w, "  Command:\t%s\n", rec.Command)
+	fmt.Fprintf(w, "  Tomei version:\t%s\n", rec.TomeiVersion)
+	if rec.ManifestHash != "" {
+		fmt.Fprintf(w, "  Manifest hash:\t%s\n", rec.ManifestHash)
+	}
+	if rec.Project != "" {
+		fmt.Fprintf(w, "  Project:\t%s\n", rec.Project)
+	}
+	fmt.Fprintf(w, "  Duration:\t%s\n", rec.Duration)
+	fmt.Fprintf(w, "  Outcome:\t%s\n", rec.Outcome)
+	_ = w.Flush()
+	if rec.Error != "" {
+		fmt.Fprintln(out, "  Error:")
+		for line := range strings.SplitSeq(rec.Error, "\n") {
+			fmt.Fprintf(out, "    %s\n", line)
+		}
+	}
+
+	if len(rec.Actions) == 0 {
+		fmt.Fprintln(out, "\nNo actions.")
+		return
+	}
+	fmt.Fprintln(out)
+	w = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  KIND\tNAME\tACTION\tOLD\tNEW\tDURATION\tOUTCOME")
+	for _, a := range rec.Actions {
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\t%s\t%s\n", a.Kind, a.Name, a.Action, orDash(a.OldVersion), orDash(a.NewVersion), a.Duration, a.Outcome)
+	}
+	_ = w.Flush()
+	for _, a := range rec.Actions {
+		if a.Error != "" {
+			fmt.Fprintf(out, "\n%s/%s: %s\n", a.Kind, a.Name, a.Error)
+		}
+	}
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// recordingRunner feeds the events of an apply into the apply history
+// recorder before passing them to the progress display.
+type recordingRunner struct {
+	*engine.Engine
+	recorder *history.Recorder
+	handler  engine.EventHandler
+	err      error
+}
+
+// newRecordingRunner starts the history record of an apply by the engine.
+func newRecordingRunner(eng *engine.Engine, store *state.Store[state.UserState], rec history.Record) *recordingRunner {
+	before, err := store.LoadReadOnly()
+	if err != nil {
+		slog.Warn("failed to load state for the apply history", "error", err)
+	}
+	rec.Command = strings.Join(append([]string{filepath.Base(os.Args[0])}, os.Args[1:]...), " ")
+	rec.TomeiVersion = version
+	return &recordingRunner{Engine: eng, recorder: history.NewRecorder(rec, history.Versions(before))}
+}
+
+func (r *recordingRunner) SetEventHandler(handler engine.EventHandler) {
+	r.handler = handler
+}
+
+func (r *recordingRunner) Apply(ctx context.Context, resources []resource.Resource) error {
+	r.Engine.SetEventHandler(func(event engine.Event) {
+		switch event.Type {
+		case engine.EventStart:
+			r.recorder.RecordStart(event.Kind, event.Name, event.Version, event.Action)
+		case engine.EventError:
+			r.recorder.RecordError(event.Kind, event.Name, event.Error)
+		case engine.EventComplete:
+			r.recorder.RecordComplete(event.Kind, event.Name)
+		}
+		if r.handler != nil {
+			r.handler(event)
+		}
+	})
+	r.err = r.Engine.Apply(ctx, resources)
+	return r.err
+}
+
+// save appends the record of the apply to the history file. A failure to
+// record does not fail the apply.
+func (r *recordingRunner) save(path string, store *state.Store[state.UserState]) {
+	after, err := store.LoadReadOnly()
+	if err != nil {
+		slog.Warn("failed to load state for the apply history", "error", err)
+	}
+	if err := history.Append(path, r.recorder.Finish(r.err, history.Versions(after))); err != nil {
+		slog.Warn("failed to record the apply history", "error", err)
+	}
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/terassyi/tomei/internal/history"
+	"github.com/terassyi/tomei/internal/resource"
+)
+
+func TestSummarizeActions(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "-", summarizeActions(nil))
+	assert.Equal(t, "+jq@1.7.1 ~gh(2.60.0→2.62.0) ~go -fd !bat", summarizeActions([]history.Action{
+		{Name: "jq", Action: resource.ActionInstall, NewVersion: "1.7.1", Outcome: history.OutcomeSuccess},
+		{Name: "gh", Action: resource.ActionUpgrade, OldVersion: "2.60.0", NewVersion: "2.62.0", Outcome: history.OutcomeSuccess},
+		{Name: "go", Action: resource.ActionReinstall, OldVersion: "1.25.6", NewVersion: "1.25.6", Outcome: history.OutcomeSuccess},
+		{Name: "fd", Action: resource.ActionRemove, OldVersion: "10.2.0", Outcome: history.OutcomeSuccess},
+		{Name: "bat", Action: resource.ActionInstall, NewVersion: "0.25.0", Outcome: history.OutcomeFailed},
+	}))
+}
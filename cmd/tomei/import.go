@@ -0,0 +1,229 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/terassyi/tomei/internal/config"
+	"github.com/terassyi/tomei/internal/doctor"
+	"github.com/terassyi/tomei/internal/github"
+	"github.com/terassyi/tomei/internal/importer"
+	"github.com/terassyi/tomei/internal/mirror"
+	"github.com/terassyi/tomei/internal/path"
+	"github.com/terassyi/tomei/internal/registry/aqua"
+	"github.com/terassyi/tomei/internal/state"
+	"github.com/terassyi/tomei/internal/ui"
+)
+
+var (
+	importOut     string
+	importAdopt   bool
+	importForce   bool
+	importNoColor bool
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Generate manifests for tools not managed by tomei",
+	Long: `Generate manifests for executables that tomei does not manage yet.
+
+Scans the tomei bin directory, the Go bin directory ($GOBIN or ~/go/bin),
+and the cargo bin directory ($CARGO_HOME/bin or ~/.cargo/bin), and matches
+each unmanaged executable to a tool declaration:
+  - Go binaries in the Go bin directory are declared in a #GoToolSet, using
+    the module information embedded by "go install"
+  - Binaries installed by cargo are declared in a #BinstallToolSet, using
+    the crates recorded in ~/.cargo/.crates2.json
+  - Other binaries are matched against aqua-registry packages by command
+    name and declared in an #AquaToolSet
+
+The manifest is printed, or written to --out. Binaries that could not be
+matched are listed in a comment at the end.
+
+With --adopt, Go and cargo tools at a known version are also recorded in
+state as installed, so that the next "tomei apply" does not reinstall them.
+Aqua packages are always installed by the next apply.
+
+  tomei import
+  tomei import --out imported.cue
+  tomei import --out ~/dotfiles/tomei/imported.cue --adopt`,
+	Args: cobra.NoArgs,
+	RunE: runImport,
+}
+
+func init() {
+	importCmd.Flags().StringVar(&importOut, "out", "", "Write the manifest to a file instead of stdout")
+	importCmd.Flags().BoolVar(&importAdopt, "adopt", false, "Record Go and cargo tools in state without reinstalling them (requires --out)")
+	importCmd.Flags().BoolVar(&importForce, "force", false, "Overwrite the --out file if it exists")
+	importCmd.Flags().BoolVar(&importNoColor, "no-color", false, "Disable color output")
+}
+
+func runImport(cmd *cobra.Command, _ []string) error {
+	if importNoColor {
+		color.NoColor = true
+	}
+	if importAdopt && importOut == "" {
+		return errors.New("--adopt requires --out: adopted tools must be declared in a manifest, or the next apply removes them")
+	}
+	if importOut != "" && !importForce {
+		if _, err := os.Stat(importOut); err == nil {
+			return fmt.Errorf("%s already exists; use --force to overwrite", importOut)
+		}
+	}
+
+	cfg, err := config.LoadConfig(config.DefaultConfigDir)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	paths, err := path.NewFromConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create paths: %w", err)
+	}
+	store, err := state.NewStore[state.UserState](paths.UserDataDir())
+	if err != nil {
+		return fmt.Errorf("failed to create state store: %w", err)
+	}
+
+	// Adopting writes state, so hold the lock from the scan to the save
+	var userState *state.UserState
+	if importAdopt {
+		if err := store.Lock(); err != nil {
+			return fmt.Errorf("failed to lock state: %w", err)
+		}
+		defer func() { _ = store.Unlock() }()
+		userState, err = store.Load()
+	} else {
+		userState, err = store.LoadReadOnly()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	binaries, err := scanImportBinaries(paths, userState)
+	if err != nil {
+		return err
+	}
+	if len(binaries) == 0 {
+		cmd.PrintErrln("No unmanaged tools found.")
+		return nil
+	}
+
+	im, err := newImporter(cmd.ErrOrStderr(), paths, userState, cfg.Mirrors)
+	if err != nil {
+		return err
+	}
+	candidates, err := im.Match(cmd.Context(), binaries)
+	if err != nil {
+		return fmt.Errorf("failed to match unmanaged tools: %w", err)
+	}
+
+	manifest := importer.Manifest(candidates, userState)
+	if importOut == "" {
+		cmd.Print(string(manifest))
+	} else if err := os.WriteFile(importOut, manifest, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", importOut, err)
+	}
+
+	printImportSummary(cmd.ErrOrStderr(), candidates)
+	if importOut != "" {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Wrote %s\n", importOut)
+	}
+
+	if !importAdopt {
+		return nil
+	}
+	adopted := importer.Adopt(userState, candidates, time.Now())
+	if len(adopted) == 0 {
+		fmt.Fprintln(cmd.ErrOrStderr(), "No tools to record in state.")
+		return nil
+	}
+	if err := store.Save(userState); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+	fmt.Fprintf(cmd.ErrOrStderr(), "Recorded %d tools in state: %s\n", len(adopted), strings.Join(adopted, ", "))
+	return nil
+}
+
+// scanImportBinaries returns the unmanaged executables in the tomei bin
+// directory, the runtime bin directories recorded in state, and the default
+// Go and cargo bin directories. Tools already recorded in state are skipped.
+func scanImportBinaries(paths *path.Paths, userState *state.UserState) ([]importer.Binary, error) {
+	doc, err := doctor.New(paths, userState)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create doctor: %w", err)
+	}
+	goBin, err := importer.GoBinDir()
+	if err != nil {
+		return nil, err
+	}
+	cargoHome, err := importer.CargoHome()
+	if err != nil {
+		return nil, err
+	}
+	doc.AddScanPath("go", goBin)
+	doc.AddScanPath("rust", filepath.Join(cargoHome, "bin"))
+
+	unmanaged, err := doc.ScanUnmanaged()
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan for unmanaged tools: %w", err)
+	}
+
+	var binaries []importer.Binary
+	for category, tools := range unmanaged {
+		for _, t := range tools {
+			if _, ok := userState.Tools[importer.ToolName(t.Name)]; ok {
+				continue
+			}
+			binaries = append(binaries, importer.Binary{Name: t.Name, Path: t.Path, Category: category})
+		}
+	}
+	return binaries, nil
+}
+
+// newImporter returns an importer that matches binaries against the
+// aqua-registry ref recorded in state. Without a ref, aqua-registry is not used.
+func newImporter(w io.Writer, paths *path.Paths, userState *state.UserState, mirrors []mirror.Rule) (*importer.Importer, error) {
+	cargoHome, err := importer.CargoHome()
+	if err != nil {
+		return nil, err
+	}
+	if userState.Registry == nil || userState.Registry.Aqua == nil || userState.Registry.Aqua.Ref == "" {
+		fmt.Fprintln(w, "aqua-registry ref not found in state; run 'tomei init' to match binaries against aqua-registry.")
+		return importer.New(nil, "", cargoHome), nil
+	}
+	ref := aqua.RegistryRef(userState.Registry.Aqua.Ref)
+	resolver := aqua.NewResolver(paths.UserCacheDir()+"/registry/aqua", mirror.WrapClient(github.NewHTTPClient(github.TokenFromEnv()), mirrors))
+	return importer.New(resolver, ref, cargoHome), nil
+}
+
+// printImportSummary prints how each binary was matched.
+func printImportSummary(w io.Writer, candidates []importer.Candidate) {
+	style := ui.NewStyle()
+
+	fmt.Fprintln(w)
+	style.Header.Fprintf(w, "Found %d unmanaged tools:\n", len(candidates))
+	for _, c := range candidates {
+		if !c.Matched() {
+			fmt.Fprintf(w, "  %s %-20s %-6s not matched (%s)\n", style.WarnMark, c.Name, "-", c.Path)
+			continue
+		}
+		version := c.Version
+		if !c.Pinned() {
+			version = "unknown version"
+		}
+		fmt.Fprintf(w, "  %s %-20s %-6s %s %s\n", style.SuccessMark, c.Name, c.Source, c.Package, version)
+		if len(c.Alternatives) > 0 {
+			fmt.Fprintf(w, "       also provided by: %s\n", strings.Join(slices.Sorted(slices.Values(c.Alternatives)), ", "))
+		}
+	}
+	fmt.Fprintln(w)
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/terassyi/tomei/internal/config"
 	"github.com/terassyi/tomei/internal/github"
+	"github.com/terassyi/tomei/internal/mirror"
 	"github.com/terassyi/tomei/internal/path"
 	"github.com/terassyi/tomei/internal/registry/aqua"
 	"github.com/terassyi/tomei/internal/state"
@@ -174,7 +175,7 @@ func runInit(cmd *cobra.Command, _ []string) error {
 
 	// Registry section
 	style.Header.Fprintln(cmd.OutOrStdout(), "Registry:")
-	if err := initRegistry(ctx, initialState); err != nil {
+	if err := initRegistry(ctx, initialState, cfg.Mirrors); err != nil {
 		// Log warning but don't fail init if registry initialization fails
 		slog.Warn("failed to initialize aqua registry", "error", err)
 		cmd.Printf("  %s aqua-registry (failed to fetch)\n", style.WarnMark)
@@ -200,8 +201,8 @@ func runInit(cmd *cobra.Command, _ []string) error {
 }
 
 // initRegistry initializes the aqua-registry state by fetching the latest ref.
-func initRegistry(ctx context.Context, st *state.UserState) error {
-	ghClient := github.NewHTTPClient(github.TokenFromEnv())
+func initRegistry(ctx context.Context, st *state.UserState, mirrors []mirror.Rule) error {
+	ghClient := mirror.WrapClient(github.NewHTTPClient(github.TokenFromEnv()), mirrors)
 	client := aqua.NewVersionClient(ghClient)
 
 	ref, err := client.GetLatestRef(ctx)
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/terassyi/tomei/internal/installer"
+	"github.com/terassyi/tomei/internal/installer/download"
+	"github.com/terassyi/tomei/internal/installer/engine"
+	"github.com/terassyi/tomei/internal/lockfile"
+	"github.com/terassyi/tomei/internal/resource"
+	"github.com/terassyi/tomei/internal/state"
+)
+
+var lockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Manage the tomei.lock lockfile",
+	Long: `Manage the tomei.lock lockfile.
+
+"tomei apply" writes tomei.lock next to the manifests (in the first
+directory given, or next to the first file). It pins, for every Tool and
+Runtime, the resolved version, download URL and archive digest, plus the
+aqua registry ref. Commit it together with the manifests so that every
+machine installs the same artifacts.
+
+"tomei apply" and "tomei plan" honor the lockfile: "latest" and alias
+versions resolve to the pinned version, and downloads are verified against
+the pinned digest. An entry stops applying when the manifest version changes.
+--sync, --update-tools, --update-runtimes and --update-all bypass the pins
+for the resources they update and re-pin them after apply.`,
+}
+
+var lockUpdateCmd = &cobra.Command{
+	Use:   "update [kind/name...]",
+	Short: "Re-resolve and re-pin lockfile entries",
+	Long: `Re-resolve the versions of the selected resources, install them,
+and record the new pins in tomei.lock.
+
+Without arguments, the aqua registry is synced and every Tool and Runtime
+with a "latest" or alias version is updated (like "tomei apply --update-all").
+With arguments, only the named resources are updated:
+
+  tomei lock update
+  tomei lock update tool/gh runtime/go
+  tomei lock update -f ~/dotfiles/tomei tool/gh`,
+	RunE: runLockUpdate,
+}
+
+var (
+	lockUpdateCfg       applyConfig
+	lockUpdateManifests []string
+)
+
+func init() {
+	lockUpdateCmd.Flags().StringSliceVarP(&lockUpdateManifests, "manifests", "f", []string{"."}, "Manifest files or directories")
+	lockUpdateCmd.Flags().BoolVarP(&lockUpdateCfg.yes, "yes", "y", false, "Skip confirmation prompt")
+	lockUpdateCmd.Flags().BoolVar(&lockUpdateCfg.quiet, "quiet", false, "Suppress progress output")
+	lockUpdateCmd.Flags().BoolVar(&lockUpdateCfg.noColor, "no-color", false, "Disable colored output")
+	lockUpdateCmd.Flags().BoolVar(&lockUpdateCfg.ignoreCosign, "ignore-cosign", false, "Skip cosign signature verification for CUE module dependencies")
+	lockUpdateCmd.Flags().IntVar(&lockUpdateCfg.parallel, "parallel", engine.DefaultParallelism, "Maximum number of parallel installations (1-20)")
+	lockUpdateCmd.Flags().DurationVar(&lockUpdateCfg.timeout, "timeout", download.DefaultDownloadTimeout, "Timeout per download attempt (e.g., 5m, 10m, 1h)")
+	lockUpdateCmd.Flags().IntVar(&lockUpdateCfg.retries, "retries", download.DefaultRetries, "Number of retries of a failed download (0 disables retries)")
+	lockCmd.AddCommand(lockUpdateCmd)
+}
+
+func runLockUpdate(cmd *cobra.Command, args []string) error {
+	if systemMode {
+		return errors.New("tomei.lock only covers user-level resources; --system is not supported")
+	}
+
+	cfg := lockUpdateCfg
+	if len(args) == 0 {
+		cfg.syncRegistry = true
+		cfg.updateAll = true
+	} else {
+		tools, runtimes, err := parseLockTargets(args)
+		if err != nil {
+			return err
+		}
+		cfg.updateToolNames = tools
+		cfg.updateRuntimeNames = runtimes
+	}
+
+	cmd.Printf("Updating lockfile entries from %v\n", lockUpdateManifests)
+	return runUserApply(cmd.Context(), lockUpdateManifests, cmd.OutOrStdout(), &cfg)
+}
+
+// parseLockTargets parses "kind/name" arguments into tool and runtime names.
+func parseLockTargets(args []string) (tools, runtimes []string, err error) {
+	for _, arg := range args {
+		kind, name, ok := strings.Cut(arg, "/")
+		if !ok || name == "" {
+			return nil, nil, fmt.Errorf("invalid target %q: expected <kind>/<name>", arg)
+		}
+		switch {
+		case strings.EqualFold(kind, string(resource.KindTool)):
+			tools = append(tools, name)
+		case strings.EqualFold(kind, string(resource.KindRuntime)):
+			runtimes = append(runtimes, name)
+		default:
+			return nil, nil, fmt.Errorf("invalid target %q: kind must be tool or runtime", arg)
+		}
+	}
+	return tools, runtimes, nil
+}
+
+// validateLockTargets checks that every targeted tool and runtime is defined in the manifests.
+func validateLockTargets(resources []resource.Resource, tools, runtimes []string) error {
+	defined := make(map[string]bool)
+	for _, res := range resources {
+		defined[string(res.Kind())+"/"+res.Name()] = true
+	}
+	for _, name := range tools {
+		if !defined[string(resource.KindTool)+"/"+name] {
+			return fmt.Errorf("tool %q is not defined in the manifests", name)
+		}
+	}
+	for _, name := range runtimes {
+		if !defined[string(resource.KindRuntime)+"/"+name] {
+			return fmt.Errorf("runtime %q is not defined in the manifests", name)
+		}
+	}
+	return nil
+}
+
+// applyLockPins returns the lockfile pins that apply to resources and records
+// their versions in updCfg, so that installed resources whose version differs
+// from the lockfile are reinstalled. Resources selected for update by updCfg
+// are not pinned; they are resolved again and re-pinned after apply.
+func applyLockPins(lock *lockfile.Lockfile, resources []resource.Resource, updCfg *engine.UpdateConfig) (tools, runtimes map[string]installer.Pin) {
+	tools, runtimes = lock.Pins(resources)
+	if updCfg.SyncMode || updCfg.UpdateTools {
+		clear(tools)
+	}
+	if updCfg.UpdateRuntimes {
+		clear(runtimes)
+	}
+	for _, name := range updCfg.UpdateToolNames {
+		delete(tools, name)
+	}
+	for _, name := range updCfg.UpdateRuntimeNames {
+		delete(runtimes, name)
+	}
+
+	updCfg.LockedTools = make(map[string]string, len(tools))
+	for name, pin := range tools {
+		updCfg.LockedTools[name] = pin.Version
+	}
+	updCfg.LockedRuntimes = make(map[string]string, len(runtimes))
+	for name, pin := range runtimes {
+		updCfg.LockedRuntimes[name] = pin.Version
+	}
+	return tools, runtimes
+}
+
+// writeLockfile records the state after apply into the lockfile at lockPath.
+func writeLockfile(store *state.Store[state.UserState], lock *lockfile.Lockfile, lockPath string, resources []resource.Resource) error {
+	st, err := store.LoadReadOnly()
+	if err != nil {
+		return fmt.Errorf("failed to load state for lockfile: %w", err)
+	}
+	lock.Update(resources, st)
+	if err := lock.Save(lockPath); err != nil {
+		return err
+	}
+	slog.Debug("wrote lockfile", "path", lockPath, "tools", len(lock.Tools), "runtimes", len(lock.Runtimes))
+	return nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/terassyi/tomei/internal/installer/engine"
+	"github.com/terassyi/tomei/internal/lockfile"
+	"github.com/terassyi/tomei/internal/resource"
+)
+
+func TestParseLockTargets(t *testing.T) {
+	t.Parallel()
+
+	tools, runtimes, err := parseLockTargets([]string{"tool/gh", "Runtime/go", "TOOL/jq"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"gh", "jq"}, tools)
+	assert.Equal(t, []string{"go"}, runtimes)
+
+	for _, arg := range []string{"gh", "tool/", "installer/aqua"} {
+		_, _, err := parseLockTargets([]string{arg})
+		assert.Error(t, err, arg)
+	}
+}
+
+func TestApplyLockPins(t *testing.T) {
+	t.Parallel()
+
+	lock := &lockfile.Lockfile{
+		Version: lockfile.FormatVersion,
+		Tools: map[string]*lockfile.Entry{
+			"gh": {SpecVersion: "latest", Version: "2.62.0"},
+			"jq": {SpecVersion: "latest", Version: "1.7.1"},
+		},
+		Runtimes: map[string]*lockfile.Entry{
+			"go": {SpecVersion: "stable", Version: "1.25.5"},
+		},
+	}
+	resources := []resource.Resource{
+		&resource.Tool{BaseResource: resource.BaseResource{Metadata: resource.Metadata{Name: "gh"}}, ToolSpec: &resource.ToolSpec{Version: "latest"}},
+		&resource.Tool{BaseResource: resource.BaseResource{Metadata: resource.Metadata{Name: "jq"}}, ToolSpec: &resource.ToolSpec{Version: "latest"}},
+		&resource.Runtime{BaseResource: resource.BaseResource{Metadata: resource.Metadata{Name: "go"}}, RuntimeSpec: &resource.RuntimeSpec{Version: "stable"}},
+	}
+
+	t.Run("all pins apply", func(t *testing.T) {
+		t.Parallel()
+		cfg := engine.UpdateConfig{}
+		tools, runtimes := applyLockPins(lock, resources, &cfg)
+		assert.Len(t, tools, 2)
+		assert.Len(t, runtimes, 1)
+		assert.Equal(t, map[string]string{"gh": "2.62.0", "jq": "1.7.1"}, cfg.LockedTools)
+		assert.Equal(t, map[string]string{"go": "1.25.5"}, cfg.LockedRuntimes)
+	})
+
+	t.Run("update targets are not pinned", func(t *testing.T) {
+		t.Parallel()
+		cfg := engine.UpdateConfig{UpdateToolNames: []string{"gh"}, UpdateRuntimes: true}
+		tools, runtimes := applyLockPins(lock, resources, &cfg)
+		assert.Contains(t, tools, "jq")
+		assert.NotContains(t, tools, "gh")
+		assert.Empty(t, runtimes)
+		assert.Equal(t, map[string]string{"jq": "1.7.1"}, cfg.LockedTools)
+	})
+}
@@ -3,20 +3,33 @@ package main
 import (
 	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"os"
 	"slices"
+	"strings"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 
 	"github.com/terassyi/tomei/internal/config"
+	"github.com/terassyi/tomei/internal/credential"
 	"github.com/terassyi/tomei/internal/github"
 	"github.com/terassyi/tomei/internal/graph"
+	"github.com/terassyi/tomei/internal/installer"
+	"github.com/terassyi/tomei/internal/installer/command"
+	"github.com/terassyi/tomei/internal/installer/download"
 	"github.com/terassyi/tomei/internal/installer/engine"
+	"github.com/terassyi/tomei/internal/installer/reconciler"
+	"github.com/terassyi/tomei/internal/installer/resolve"
+	"github.com/terassyi/tomei/internal/installer/runtime"
+	"github.com/terassyi/tomei/internal/installer/tool"
+	"github.com/terassyi/tomei/internal/lockfile"
+	"github.com/terassyi/tomei/internal/mirror"
 	"github.com/terassyi/tomei/internal/path"
+	"github.com/terassyi/tomei/internal/planfile"
 	"github.com/terassyi/tomei/internal/registry/aqua"
 	"github.com/terassyi/tomei/internal/resource"
 	"github.com/terassyi/tomei/internal/state"
@@ -30,16 +43,33 @@ var planCmd = &cobra.Command{
 Compares CUE manifests (desired state) with the current state and shows
 what actions would be taken by "tomei apply":
   - install: New resources to install
-  - upgrade: Resources with version changes
-  - reinstall: Tainted resources to reinstall (e.g., runtime upgrade, update flags)
+  - upgrade: Resources with spec changes, or tainted by update flags
+  - reinstall: Tools tainted by an upgrade of their runtime
   - remove: Resources in state but not in manifests
   - skip: Resources disabled via enabled: false
 
+Actions are computed by the same reconcilers as apply. The reason of each
+change is shown, and "latest" and alias versions are resolved to the
+version, download URL and checksum source that apply would use.
+
 Resources are shown in dependency order as a tree. Execution layers
 show which resources run in parallel.
 
+Versions pinned in tomei.lock next to the manifests are shown instead of
+"latest" or alias versions (see "tomei lock --help").
+
 Use --output json or --output yaml for machine-readable output
-(suitable for scripting and programmatic consumption).`,
+(suitable for scripting and programmatic consumption).
+
+Use --out to save the plan to a file. "tomei apply <plan.json>" then
+executes exactly the saved actions with the resolved versions, and refuses
+to run if state.json, tomei.lock or the manifests changed in the meantime:
+  tomei plan --out plan.json .
+  tomei apply plan.json
+
+With --system, plans system-level resources (SystemInstaller,
+SystemPackageRepository, SystemPackageSet) against /var/lib/tomei/state.json.
+Root privileges are not required for planning.`,
 	Args: cobra.MinimumNArgs(1),
 	RunE: runPlan,
 }
@@ -48,6 +78,7 @@ Use --output json or --output yaml for machine-readable output
 type planConfig struct {
 	loadConfig
 	outputFormat string
+	out          string
 }
 
 var planCfg planConfig
@@ -55,6 +86,7 @@ var planCfg planConfig
 func init() {
 	planCfg.registerFlags(planCmd)
 	planCmd.Flags().StringVarP(&planCfg.outputFormat, "output", "o", "text", "Output format: text, json, yaml")
+	planCmd.Flags().StringVar(&planCfg.out, "out", "", "Save the plan to a file for 'tomei apply <file>'")
 	_ = planCmd.RegisterFlagCompletionFunc("output", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
 		return []string{"text", "json", "yaml"}, cobra.ShellCompDirectiveNoFileComp
 	})
@@ -66,19 +98,28 @@ func runPlan(cmd *cobra.Command, args []string) error {
 		color.NoColor = true
 	}
 
+	if planCfg.out != "" && systemMode {
+		return errors.New("saved plans only cover user-level resources; --out cannot be combined with --system")
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
 	// Sync registry if --sync or --update-tools/--update-all flag is set
 	if planCfg.syncRegistry || planCfg.updateTools || planCfg.updateAll {
-		ctx := cmd.Context()
-		if ctx == nil {
-			ctx = context.Background()
-		}
 		if err := syncRegistryForPlan(ctx); err != nil {
 			slog.Warn("failed to sync aqua registry", "error", err)
 		}
 	}
 
 	// Load configuration
-	loader := config.NewLoader(nil, planCfg.verifierOpts()...)
+	verifierOpts, err := planCfg.verifierOpts()
+	if err != nil {
+		return err
+	}
+	loader := config.NewLoader(nil, verifierOpts...)
 	resources, err := loader.LoadPaths(args)
 	if err != nil {
 		return fmt.Errorf("failed to load configuration: %w", err)
@@ -97,20 +138,89 @@ func runPlan(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to expand sets: %w", err)
 	}
-
-	updateCfg := engine.UpdateConfig{
-		SyncMode:       planCfg.syncRegistry,
-		UpdateTools:    planCfg.updateTools || planCfg.updateAll,
-		UpdateRuntimes: planCfg.updateRuntimes || planCfg.updateAll,
-	}
-	result, err := resolvePlan(resources, updateCfg)
+	manifestHash, err := planfile.HashResources(resources)
 	if err != nil {
 		return err
 	}
 
+	var result *planResult
+	var up *userPlan
+	var saved *savedPlanInput
+	if systemMode {
+		resources = engine.FilterSystemResources(resources)
+		disabledResources = engine.FilterSystemResources(disabledResources)
+		st, err := loadSystemStateForPlan()
+		if err != nil {
+			return err
+		}
+		result, err = resolveSystemPlan(resources, st)
+		if err != nil {
+			return err
+		}
+	} else {
+		resources = excludeSystemResources(resources)
+		disabledResources = excludeSystemResources(disabledResources)
+		var creds credential.Set
+		creds, resources = credential.Split(resources)
+		_, disabledResources = credential.Split(disabledResources)
+		updateCfg := engine.UpdateConfig{
+			SyncMode:       planCfg.syncRegistry,
+			UpdateTools:    planCfg.updateTools || planCfg.updateAll,
+			UpdateRuntimes: planCfg.updateRuntimes || planCfg.updateAll,
+		}
+		lockPath := lockfile.PathFor(args)
+		lock, err := lockfile.Load(lockPath)
+		if err != nil {
+			return err
+		}
+		toolPins, runtimePins := applyLockPins(lock, resources, &updateCfg)
+		in := &userPlanInput{
+			resources:   resources,
+			creds:       creds,
+			lock:        lock,
+			updCfg:      updateCfg,
+			toolPins:    toolPins,
+			runtimePins: runtimePins,
+			resolve:     true,
+		}
+		up, err = planUser(ctx, in)
+		if err != nil {
+			return fmt.Errorf("failed to plan: %w", err)
+		}
+		result, err = resolvePlan(resources, up, updateCfg)
+		if err != nil {
+			return err
+		}
+		if planCfg.out != "" {
+			paths, err := absPaths(args)
+			if err != nil {
+				return err
+			}
+			lockHash, err := planfile.HashFile(lockPath)
+			if err != nil {
+				return err
+			}
+			saved = &savedPlanInput{
+				paths:        paths,
+				manifestHash: manifestHash,
+				lockHash:     lockHash,
+				updCfg:       updateCfg,
+			}
+		}
+	}
+
 	// Inject disabled resource info into the plan
 	addDisabledResourceInfo(result.resourceInfo, disabledResources)
 
+	if saved != nil {
+		p, err := writeSavedPlan(saved, up, planCfg.out)
+		if err != nil {
+			return fmt.Errorf("failed to save plan: %w", err)
+		}
+		// Print after the plan output
+		defer cmd.Printf("\nSaved %d action(s) to %s. Apply them with: tomei apply %s\n", len(p.Actions), planCfg.out, planCfg.out)
+	}
+
 	// Output based on format
 	switch planCfg.outputFormat {
 	case outputJSON:
@@ -126,149 +236,307 @@ func runPlan(cmd *cobra.Command, args []string) error {
 	}
 }
 
-func buildResourceInfo(resources []resource.Resource, updCfg engine.UpdateConfig) map[graph.NodeID]graph.ResourceInfo {
-	info := make(map[graph.NodeID]graph.ResourceInfo)
+// userPlanInput is what a user-level plan is computed from.
+type userPlanInput struct {
+	// paths locates the state to plan against. The global paths are used when nil.
+	paths       *path.Paths
+	resources   []resource.Resource
+	creds       credential.Set
+	lock        *lockfile.Lockfile
+	updCfg      engine.UpdateConfig
+	toolPins    map[string]installer.Pin
+	runtimePins map[string]installer.Pin
+	// resolve resolves the versions and download URLs of the installs
+	// without downloading anything.
+	resolve bool
+	// allowed restricts the plan to these actions, keyed by "Kind/name".
+	// Planning fails after printing the plan if it contains other changes.
+	allowed map[string]resource.ActionType
+}
 
-	// Load config and state
-	var userState *state.UserState
-	cfg, err := config.LoadConfig(config.DefaultConfigDir)
-	if err == nil {
-		pathConfig, err := path.NewFromConfig(cfg)
-		if err == nil {
-			store, err := state.NewStore[state.UserState](pathConfig.UserDataDir())
+// userPlan holds the actions that apply would execute for user-level resources.
+type userPlan struct {
+	stateHash  string
+	registry   string
+	runtimes   []engine.RuntimeAction
+	installers []engine.InstallerAction
+	repos      []engine.InstallerRepositoryAction
+	tools      []engine.ToolAction
+	// resolved holds the dry-run resolution of runtime and tool installs.
+	resolved map[graph.NodeID]resolvedSource
+	// tainted maps the tools that are reinstalled after a runtime upgrade to that runtime.
+	tainted map[string]string
+}
+
+// resolvedSource is the dry-run resolution of a runtime or tool install.
+type resolvedSource struct {
+	version  string
+	url      string
+	checksum string
+	err      error
+}
+
+// planUser computes the actions of user-level resources with the same
+// reconcilers, update taints, lockfile pins and registry ref as apply.
+func planUser(ctx context.Context, in *userPlanInput) (*userPlan, error) {
+	pathConfig := in.paths
+	if pathConfig == nil {
+		var err error
+		if pathConfig, err = userPaths(); err != nil {
+			return nil, err
+		}
+	}
+	stateFile := pathConfig.UserStateFile()
+	if _, err := os.Stat(stateFile); os.IsNotExist(err) {
+		fmt.Fprintln(os.Stderr, "Warning: tomei is not initialized. Run 'tomei init' for accurate state comparison.")
+	}
+	stateHash, err := planfile.HashFile(stateFile)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := state.NewStore[state.UserState](pathConfig.UserDataDir())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create state store: %w", err)
+	}
+	st, err := store.LoadReadOnly()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load state: %w", err)
+	}
+
+	mirrors, err := loadMirrors()
+	if err != nil {
+		return nil, err
+	}
+	ghClient, dlClient := newHTTPClients(mirrors)
+	downloader := download.NewDownloaderWithClient(dlClient)
+	toolInstaller := tool.NewInstaller(downloader, nil)
+	toolInstaller.SetPins(in.toolPins)
+	toolInstaller.SetCredentials(in.creds)
+	toolInstaller.SetVersionResolver(resolve.NewResolver(command.NewExecutor(""), ghClient))
+	runtimeInstaller := runtime.NewInstaller(downloader, "")
+	runtimeInstaller.SetHTTPClient(ghClient)
+	runtimeInstaller.SetPins(in.runtimePins)
+	runtimeInstaller.SetCredentials(in.creds)
+
+	// The registry ref pinned in tomei.lock takes precedence over the state ref
+	ref := in.lock.AquaRef()
+	if ref == "" && st.Registry != nil && st.Registry.Aqua != nil {
+		ref = st.Registry.Aqua.Ref
+	}
+	if ref != "" {
+		cacheDir := pathConfig.UserCacheDir() + "/registry/aqua"
+		toolInstaller.SetResolver(aqua.NewResolver(cacheDir, ghClient), aqua.RegistryRef(ref))
+	}
+
+	delegation := make(map[string]bool)
+	for _, res := range in.resources {
+		if inst, ok := res.(*resource.Installer); ok && inst.InstallerSpec != nil {
+			toolInstaller.RegisterInstaller(inst.Name(), &tool.InstallerInfo{
+				Type:          inst.InstallerSpec.Type,
+				CredentialRef: inst.InstallerSpec.CredentialRef,
+			})
+			if inst.InstallerSpec.Type == resource.InstallTypeDelegation {
+				delegation[inst.Name()] = true
+			}
+		}
+	}
+
+	eng := engine.NewEngine(toolInstaller, runtimeInstaller, nil, nil, store)
+	eng.SetUpdateConfig(in.updCfg)
+	runtimeActions, repoActions, toolActions, err := eng.PlanAll(ctx, in.resources)
+	if err != nil {
+		return nil, err
+	}
+	installers := extractResources[*resource.Installer](in.resources)
+
+	p := &userPlan{
+		stateHash:  stateHash,
+		registry:   ref,
+		runtimes:   runtimeActions,
+		installers: reconciler.NewInstallerReconciler().Reconcile(installers, st.Installers),
+		repos:      repoActions,
+		tools:      toolActions,
+		resolved:   make(map[graph.NodeID]resolvedSource),
+	}
+
+	if in.resolve {
+		for _, a := range runtimeActions {
+			if a.Type == resource.ActionRemove || a.Resource.RuntimeSpec.Type == resource.InstallTypeDelegation {
+				continue
+			}
+			var r resolvedSource
+			rctx, err := runtimeInstaller.CredentialContext(ctx, a.Resource)
+			if err == nil {
+				var src *runtime.ResolvedSource
+				if src, err = runtimeInstaller.ResolveSource(rctx, a.Resource.RuntimeSpec, a.Name); err == nil {
+					r = resolvedSource{version: src.Version, url: src.URL, checksum: checksumSource(src.Checksum)}
+				}
+			}
+			r.err = err
+			p.resolved[graph.NewNodeID(resource.KindRuntime, a.Name)] = r
+		}
+		for _, a := range toolActions {
+			if a.Type == resource.ActionRemove {
+				continue
+			}
+			downloaded, err := isDownloadTool(ctx, toolInstaller, a.Resource, delegation)
+			if err == nil && !downloaded {
+				continue
+			}
+			var r resolvedSource
 			if err == nil {
-				loaded, err := store.LoadReadOnly()
-				if err == nil {
-					userState = loaded
+				var tctx context.Context
+				if tctx, err = toolInstaller.CredentialContext(ctx, a.Resource); err == nil {
+					var spec *resource.ToolSpec
+					if spec, _, err = toolInstaller.ResolveSource(tctx, a.Resource, a.Name); err == nil {
+						r = resolvedSource{version: spec.Version, url: spec.Source.URL, checksum: checksumSource(spec.Source.Checksum)}
+					}
 				}
 			}
+			r.err = err
+			p.resolved[graph.NewNodeID(resource.KindTool, a.Name)] = r
+		}
+		for nodeID, r := range p.resolved {
+			if r.err != nil {
+				slog.Warn("failed to resolve version", "resource", nodeID, "error", r.err)
+			}
 		}
 	}
 
-	if userState == nil {
-		fmt.Fprintln(os.Stderr, "Warning: tomei is not initialized. Run 'tomei init' for accurate state comparison.")
+	// Predict the tools that apply reinstalls after upgrading their runtime.
+	// Apply only taints them when the runtime version actually changes.
+	upgraded := make(map[string]bool)
+	taintOnUpgrade := make(map[string]bool)
+	for _, a := range runtimeActions {
+		if a.Type != resource.ActionUpgrade {
+			continue
+		}
+		newVersion := a.Resource.RuntimeSpec.Version
+		if r, ok := p.resolved[graph.NewNodeID(resource.KindRuntime, a.Name)]; ok && r.err == nil {
+			newVersion = r.version
+		}
+		if !resource.IsExactVersion(newVersion) || newVersion != a.State.Version {
+			upgraded[a.Name] = true
+		}
+		taintOnUpgrade[a.Name] = a.Resource.RuntimeSpec.TaintOnUpgrade
+	}
+	p.tainted = engine.RuntimeTaints(st.Tools, upgraded, func(name string) bool { return taintOnUpgrade[name] })
+
+	return p, nil
+}
+
+// checksumSource describes where the checksum of a download comes from.
+func checksumSource(c *resource.Checksum) string {
+	switch {
+	case c == nil:
+		return ""
+	case c.URL != "":
+		return c.URL
+	case c.Value != "":
+		return "inline"
+	default:
+		return ""
 	}
+}
 
-	// Apply taint logic based on update flags (for plan preview)
-	if userState != nil {
-		engine.ApplyUpdateTaints(userState, updCfg)
+// extractResources filters resources of a specific concrete type from a list.
+func extractResources[R resource.Resource](resources []resource.Resource) []R {
+	var result []R
+	for _, res := range resources {
+		if r, ok := res.(R); ok {
+			result = append(result, r)
+		}
 	}
+	return result
+}
+
+// buildResourceInfo builds the plan display of resources from the actions in p.
+// Resources without an action are unchanged.
+func buildResourceInfo(resources []resource.Resource, p *userPlan, updCfg engine.UpdateConfig) map[graph.NodeID]graph.ResourceInfo {
+	info := make(map[graph.NodeID]graph.ResourceInfo)
 
 	for _, res := range resources {
-		nodeID := graph.NewNodeID(res.Kind(), res.Name())
 		resInfo := graph.ResourceInfo{
 			Kind:   res.Kind(),
 			Name:   res.Name(),
-			Action: resource.ActionInstall, // default to install
+			Action: resource.ActionNone,
 		}
 
-		// Get version from spec
-		switch res.Kind() {
-		case resource.KindRuntime:
-			if rt, ok := res.(*resource.Runtime); ok && rt.RuntimeSpec != nil {
-				resInfo.Version = rt.RuntimeSpec.Version
+		// Get version from spec, or the version pinned in tomei.lock
+		switch r := res.(type) {
+		case *resource.Runtime:
+			if r.RuntimeSpec != nil {
+				resInfo.Version = r.RuntimeSpec.Version
 			}
-		case resource.KindTool:
-			if tool, ok := res.(*resource.Tool); ok && tool.ToolSpec != nil {
-				resInfo.Version = tool.ToolSpec.Version
+			if locked, ok := updCfg.LockedRuntimes[res.Name()]; ok {
+				resInfo.Version = locked
 			}
-		}
-
-		// Determine action by comparing with state
-		if userState != nil {
-			switch res.Kind() {
-			case resource.KindRuntime:
-				if rt, ok := userState.Runtimes[res.Name()]; ok {
-					if rt.IsTainted() {
-						resInfo.Action = resource.ActionReinstall
-					} else if rt.Version == resInfo.Version {
-						resInfo.Action = resource.ActionNone
-					} else {
-						resInfo.Action = resource.ActionUpgrade
-					}
-				}
-			case resource.KindTool:
-				if tool, ok := userState.Tools[res.Name()]; ok {
-					if tool.IsTainted() {
-						resInfo.Action = resource.ActionReinstall
-					} else if tool.Version == resInfo.Version {
-						resInfo.Action = resource.ActionNone
-					} else {
-						resInfo.Action = resource.ActionUpgrade
-					}
-				}
-			case resource.KindInstaller:
-				// Installers don't have versions in state typically
-				resInfo.Action = resource.ActionNone
+		case *resource.Tool:
+			if r.ToolSpec != nil {
+				resInfo.Version = r.ToolSpec.Version
+			}
+			if locked, ok := updCfg.LockedTools[res.Name()]; ok {
+				resInfo.Version = locked
 			}
 		}
-
-		info[nodeID] = resInfo
+		info[graph.NewNodeID(res.Kind(), res.Name())] = resInfo
 	}
 
-	// Detect removals: resources in state but not in manifests
-	if userState != nil {
-		for name, rt := range userState.Runtimes {
-			nodeID := graph.NewNodeID(resource.KindRuntime, name)
-			if _, exists := info[nodeID]; !exists {
-				info[nodeID] = graph.ResourceInfo{
-					Kind:    resource.KindRuntime,
-					Name:    name,
-					Version: rt.Version,
-					Action:  resource.ActionRemove,
-				}
-			}
+	addActions(info, resource.KindRuntime, p.runtimes, func(s *resource.RuntimeState) string { return s.Version })
+	addActions(info, resource.KindInstaller, p.installers, func(*resource.InstallerState) string { return "" })
+	addActions(info, resource.KindInstallerRepository, p.repos, func(*resource.InstallerRepositoryState) string { return "" })
+	addActions(info, resource.KindTool, p.tools, func(s *resource.ToolState) string { return s.Version })
+
+	for nodeID, r := range p.resolved {
+		ri, ok := info[nodeID]
+		if !ok || r.err != nil {
+			continue
 		}
-		for name, tool := range userState.Tools {
-			nodeID := graph.NewNodeID(resource.KindTool, name)
-			if _, exists := info[nodeID]; !exists {
-				info[nodeID] = graph.ResourceInfo{
-					Kind:    resource.KindTool,
-					Name:    name,
-					Version: tool.Version,
-					Action:  resource.ActionRemove,
-				}
-			}
+		if r.version != ri.Version {
+			ri.ResolvedVersion = r.version
 		}
+		ri.URL = r.url
+		ri.ChecksumSource = r.checksum
+		info[nodeID] = ri
+	}
 
-		// Predict taint reinstalls: if a runtime with TaintOnUpgrade is being upgraded,
-		// tools that depend on it (via RuntimeRef) will be reinstalled.
-		// Build a map of runtime specs to check TaintOnUpgrade from the manifest.
-		runtimeSpecs := map[string]*resource.RuntimeSpec{}
-		upgradedRuntimes := map[string]bool{}
-		for _, res := range resources {
-			if res.Kind() == resource.KindRuntime {
-				if rt, ok := res.(*resource.Runtime); ok && rt.RuntimeSpec != nil {
-					runtimeSpecs[res.Name()] = rt.RuntimeSpec
-				}
-				nodeID := graph.NewNodeID(res.Kind(), res.Name())
-				if ri, ok := info[nodeID]; ok && (ri.Action == resource.ActionUpgrade || ri.Action == resource.ActionReinstall) {
-					upgradedRuntimes[res.Name()] = true
-				}
-			}
-		}
-		if len(upgradedRuntimes) > 0 {
-			for name, toolState := range userState.Tools {
-				if toolState.RuntimeRef == "" || !upgradedRuntimes[toolState.RuntimeRef] {
-					continue
-				}
-				// Check TaintOnUpgrade from the runtime spec in the manifest
-				spec, ok := runtimeSpecs[toolState.RuntimeRef]
-				if !ok || !spec.TaintOnUpgrade {
-					continue
-				}
-				nodeID := graph.NewNodeID(resource.KindTool, name)
-				if ri, ok := info[nodeID]; ok && ri.Action == resource.ActionNone {
-					ri.Action = resource.ActionReinstall
-					info[nodeID] = ri
-				}
-			}
+	for name, runtimeName := range p.tainted {
+		nodeID := graph.NewNodeID(resource.KindTool, name)
+		if ri, ok := info[nodeID]; ok && ri.Action == resource.ActionNone {
+			ri.Action = resource.ActionReinstall
+			ri.Reason = "tainted: " + string(resource.TaintReasonRuntimeUpgraded)
+			ri.TaintedBy = runtimeName
+			info[nodeID] = ri
 		}
 	}
 
 	return info
 }
 
+// addActions records the action and reason of each planned action in info.
+// Removals, which are not in the manifests, are added with the installed version.
+func addActions[R resource.Resource, S resource.State](
+	info map[graph.NodeID]graph.ResourceInfo,
+	kind resource.Kind,
+	actions []reconciler.Action[R, S],
+	installedVersion func(S) string,
+) {
+	for _, a := range actions {
+		nodeID := graph.NewNodeID(kind, a.Name)
+		ri, ok := info[nodeID]
+		if !ok {
+			ri = graph.ResourceInfo{Kind: kind, Name: a.Name}
+		}
+		if a.Type == resource.ActionRemove {
+			ri.Version = installedVersion(a.State)
+		}
+		ri.Action = a.Type
+		ri.Reason = a.Reason
+		info[nodeID] = ri
+	}
+}
+
 func printTextPlan(cmd *cobra.Command, args []string, resources []resource.Resource, result *planResult) error {
 	cmd.Printf("Planning changes for %v\n\n", args)
 	cmd.Printf("Found %d resource(s)\n\n", len(resources))
@@ -281,6 +549,9 @@ func printTextPlan(cmd *cobra.Command, args []string, resources []resource.Resou
 	// Print execution layers
 	printer.PrintLayers(result.filteredLayers, result.resourceInfo)
 
+	// Print reasons and resolved sources of changes
+	printer.PrintDetails(result.resourceInfo)
+
 	// Print disabled resources
 	disabledInfos := collectSkipInfos(result.resourceInfo)
 	if len(disabledInfos) > 0 {
@@ -303,13 +574,7 @@ type planResult struct {
 
 // resolvePlan builds the dependency graph, resolves execution layers, and
 // computes resource actions from the current state.
-func resolvePlan(resources []resource.Resource, updateCfg engine.UpdateConfig) (*planResult, error) {
-	definedResources := make(map[string]struct{})
-	for _, res := range resources {
-		id := graph.NewNodeID(res.Kind(), res.Name())
-		definedResources[id.String()] = struct{}{}
-	}
-
+func resolvePlan(resources []resource.Resource, p *userPlan, updateCfg engine.UpdateConfig) (*planResult, error) {
 	// Inject builtin installers into the resolver only so that dependency
 	// nodes like "Installer/aqua" are properly resolved.
 	resolver := graph.NewResolver()
@@ -322,6 +587,26 @@ func resolvePlan(resources []resource.Resource, updateCfg engine.UpdateConfig) (
 		return nil, err
 	}
 
+	resourceInfo := buildResourceInfo(resources, p, updateCfg)
+
+	return &planResult{
+		resolver:       resolver,
+		filteredLayers: filterLayers(layers, resources),
+		resourceInfo:   resourceInfo,
+		edges:          resolver.GetEdges(),
+	}, nil
+}
+
+// filterLayers drops nodes that are not defined in resources
+// (e.g., builtin installers added only for dependency resolution)
+// and removes layers left empty.
+func filterLayers(layers []graph.Layer, resources []resource.Resource) []graph.Layer {
+	definedResources := make(map[string]struct{})
+	for _, res := range resources {
+		id := graph.NewNodeID(res.Kind(), res.Name())
+		definedResources[id.String()] = struct{}{}
+	}
+
 	var filteredLayers []graph.Layer
 	for _, layer := range layers {
 		var filteredNodes []*graph.Node
@@ -335,22 +620,19 @@ func resolvePlan(resources []resource.Resource, updateCfg engine.UpdateConfig) (
 			filteredLayers = append(filteredLayers, graph.Layer{Nodes: filteredNodes})
 		}
 	}
-
-	resourceInfo := buildResourceInfo(resources, updateCfg)
-
-	return &planResult{
-		resolver:       resolver,
-		filteredLayers: filteredLayers,
-		resourceInfo:   resourceInfo,
-		edges:          resolver.GetEdges(),
-	}, nil
+	return filteredLayers
 }
 
 // planForResources runs the plan logic on already-loaded resources and
 // writes the text plan to w. It returns true if there are any changes
 // (install, upgrade, reinstall, or remove).
-func planForResources(w io.Writer, resources []resource.Resource, disableColor bool, updateCfg engine.UpdateConfig) (bool, error) {
-	result, err := resolvePlan(resources, updateCfg)
+func planForResources(ctx context.Context, w io.Writer, in *userPlanInput, disableColor bool) (bool, error) {
+	p, err := planUser(ctx, in)
+	if err != nil {
+		return false, err
+	}
+	resources := in.resources
+	result, err := resolvePlan(resources, p, in.updCfg)
 	if err != nil {
 		return false, err
 	}
@@ -369,9 +651,33 @@ func planForResources(w io.Writer, resources []resource.Resource, disableColor b
 	printer.PrintLayers(result.filteredLayers, result.resourceInfo)
 	printer.PrintSummary(result.resourceInfo)
 
+	if in.allowed != nil {
+		if err := checkAllowedActions(result.resourceInfo, in.allowed); err != nil {
+			return false, err
+		}
+	}
 	return hasChanges, nil
 }
 
+// checkAllowedActions returns an error listing the planned changes that are
+// not in allowed.
+func checkAllowedActions(resourceInfo map[graph.NodeID]graph.ResourceInfo, allowed map[string]resource.ActionType) error {
+	var extra []string
+	for id, info := range resourceInfo {
+		if info.Action == resource.ActionNone || info.Action == resource.ActionSkip {
+			continue
+		}
+		if allowed[id.String()] != info.Action {
+			extra = append(extra, fmt.Sprintf("%s (%s)", id, info.Action))
+		}
+	}
+	if len(extra) == 0 {
+		return nil
+	}
+	slices.Sort(extra)
+	return fmt.Errorf("the manifests have pending changes besides the repairs: %s; run 'tomei apply' first", strings.Join(extra, ", "))
+}
+
 // addDisabledResourceInfo injects disabled resources into the resource info map.
 // Resources that already have an entry (e.g., ActionRemove for previously installed) are not overwritten.
 func addDisabledResourceInfo(info map[graph.NodeID]graph.ResourceInfo, disabled []resource.Resource) {
@@ -430,6 +736,6 @@ func syncRegistryForPlan(ctx context.Context) error {
 		return fmt.Errorf("failed to create state store: %w", err)
 	}
 
-	ghClient := github.NewHTTPClient(github.TokenFromEnv())
+	ghClient := mirror.WrapClient(github.NewHTTPClient(github.TokenFromEnv()), cfg.Mirrors)
 	return aqua.SyncRegistry(ctx, store, ghClient)
 }
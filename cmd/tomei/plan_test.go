@@ -7,6 +7,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/terassyi/tomei/internal/graph"
+	"github.com/terassyi/tomei/internal/installer/engine"
 	"github.com/terassyi/tomei/internal/resource"
 )
 
@@ -135,3 +136,61 @@ func TestAddDisabledResourceInfo(t *testing.T) {
 		assert.Empty(t, info[nodeID].Version)
 	})
 }
+
+func TestBuildResourceInfo(t *testing.T) {
+	t.Parallel()
+
+	goRuntime := &resource.Runtime{
+		BaseResource: resource.BaseResource{ResourceKind: resource.KindRuntime, Metadata: resource.Metadata{Name: "go"}},
+		RuntimeSpec:  &resource.RuntimeSpec{Version: "1.26.0", TaintOnUpgrade: true},
+	}
+	rg := &resource.Tool{
+		BaseResource: resource.BaseResource{ResourceKind: resource.KindTool, Metadata: resource.Metadata{Name: "rg"}},
+		ToolSpec:     &resource.ToolSpec{InstallerRef: "aqua", Version: "latest"},
+	}
+	fd := &resource.Tool{
+		BaseResource: resource.BaseResource{ResourceKind: resource.KindTool, Metadata: resource.Metadata{Name: "fd"}},
+		ToolSpec:     &resource.ToolSpec{InstallerRef: "aqua", Version: "10.2.0", BinaryName: "fdfind"},
+	}
+	gopls := &resource.Tool{
+		BaseResource: resource.BaseResource{ResourceKind: resource.KindTool, Metadata: resource.Metadata{Name: "gopls"}},
+		ToolSpec:     &resource.ToolSpec{RuntimeRef: "go", Package: &resource.Package{Name: "golang.org/x/tools/gopls"}, Version: "latest"},
+	}
+	resources := []resource.Resource{goRuntime, rg, fd, gopls}
+
+	p := &userPlan{
+		runtimes: []engine.RuntimeAction{
+			{Type: resource.ActionUpgrade, Name: "go", Resource: goRuntime, State: &resource.RuntimeState{Version: "1.25.5"}, Reason: "version changed: 1.25.5 -> 1.26.0"},
+		},
+		tools: []engine.ToolAction{
+			{Type: resource.ActionInstall, Name: "rg", Resource: rg, Reason: "new resource"},
+			{Type: resource.ActionUpgrade, Name: "fd", Resource: fd, State: &resource.ToolState{Version: "10.2.0"}, Reason: "binaryName changed:  -> fdfind"},
+			{Type: resource.ActionRemove, Name: "jq", State: &resource.ToolState{Version: "1.7.1"}, Reason: "removed from spec"},
+		},
+		resolved: map[graph.NodeID]resolvedSource{
+			graph.NewNodeID(resource.KindTool, "rg"): {version: "14.1.1", url: "https://example.com/rg.tar.gz", checksum: "inline"},
+		},
+		tainted: map[string]string{"gopls": "go"},
+	}
+
+	info := buildResourceInfo(resources, p, engine.UpdateConfig{})
+
+	assert.Equal(t, graph.ResourceInfo{
+		Kind: resource.KindRuntime, Name: "go", Version: "1.26.0",
+		Action: resource.ActionUpgrade, Reason: "version changed: 1.25.5 -> 1.26.0",
+	}, info[graph.NewNodeID(resource.KindRuntime, "go")])
+	assert.Equal(t, graph.ResourceInfo{
+		Kind: resource.KindTool, Name: "rg", Version: "latest",
+		Action: resource.ActionInstall, Reason: "new resource",
+		ResolvedVersion: "14.1.1", URL: "https://example.com/rg.tar.gz", ChecksumSource: "inline",
+	}, info[graph.NewNodeID(resource.KindTool, "rg")])
+	assert.Equal(t, resource.ActionUpgrade, info[graph.NewNodeID(resource.KindTool, "fd")].Action, "binaryName change should be an upgrade")
+	assert.Equal(t, graph.ResourceInfo{
+		Kind: resource.KindTool, Name: "jq", Version: "1.7.1",
+		Action: resource.ActionRemove, Reason: "removed from spec",
+	}, info[graph.NewNodeID(resource.KindTool, "jq")])
+	assert.Equal(t, graph.ResourceInfo{
+		Kind: resource.KindTool, Name: "gopls", Version: "latest",
+		Action: resource.ActionReinstall, Reason: "tainted: runtime_upgraded", TaintedBy: "go",
+	}, info[graph.NewNodeID(resource.KindTool, "gopls")])
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/terassyi/tomei/internal/config"
+	"github.com/terassyi/tomei/internal/env"
+	"github.com/terassyi/tomei/internal/path"
+	"github.com/terassyi/tomei/internal/project"
+	"github.com/terassyi/tomei/internal/state"
+)
+
+var allowCmd = &cobra.Command{
+	Use:   "allow [directory]",
+	Short: "Allow the project manifest to be applied and activated",
+	Long: `Allow the tomei.cue of the project at the given directory (default: the
+working directory) to be applied with "tomei apply --project" and activated
+by the shell hook.
+
+A project manifest can install any tool, so a manifest found in a newly
+checked-out repository is not applied until it is reviewed and allowed.
+After the manifest, its cue.mod/module.cue or a package it imports from its
+CUE module changes, it must be allowed again.
+
+  tomei allow
+  tomei allow ~/src/service`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		proj, trust, err := findProject(args)
+		if err != nil {
+			return err
+		}
+		if err := trust.Allow(proj); err != nil {
+			return err
+		}
+		cmd.Printf("Allowed %s\n", proj.Manifest())
+		return nil
+	},
+}
+
+var denyCmd = &cobra.Command{
+	Use:   "deny [directory]",
+	Short: "Revoke a project manifest allowed with 'tomei allow'",
+	Long: `Revoke the permission given by "tomei allow" to the project at the given
+directory (default: the working directory). The shell hook stops activating
+the project; tools already installed for it are left in place.
+
+  tomei deny`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		proj, trust, err := findProject(args)
+		if err != nil {
+			return err
+		}
+		if err := trust.Deny(proj); err != nil {
+			return err
+		}
+		cmd.Printf("Denied %s\n", proj.Manifest())
+		return nil
+	},
+}
+
+var hookCmd = &cobra.Command{
+	Use:   "hook <bash|zsh|fish>",
+	Short: "Output the shell hook that activates project environments",
+	Long: `Output a shell hook that activates the environment of the current project
+when changing directories, similar to direnv.
+
+Inside a project with an allowed tomei.cue, the project's bin directory is
+prepended to PATH and the environment variables of the project's runtimes
+override those of the global runtimes. Runtime versions selected by
+.tomei-version are activated as well. Leaving the directory restores the previous PATH and
+environment variables.
+
+Add to your shell configuration after the "tomei env" line:
+  eval "$(tomei hook bash)"     # ~/.bashrc
+  eval "$(tomei hook zsh)"      # ~/.zshrc
+  tomei hook fish | source      # ~/.config/fish/config.fish`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"bash", "zsh", "fish"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		exe, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("failed to locate the tomei executable: %w", err)
+		}
+		script, err := env.HookScript(args[0], exe)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(cmd.OutOrStdout(), script)
+		return nil
+	},
+}
+
+// findProject returns the project at or above the directory in args (default:
+// the working directory) and the trust store recording allowed projects.
+func findProject(args []string) (*project.Project, *project.TrustStore, error) {
+	dir := "."
+	if len(args) > 0 {
+		dir = args[0]
+	}
+	cfgDir, err := path.Expand(config.DefaultConfigDir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to expand config directory: %w", err)
+	}
+	proj := project.Find(dir, cfgDir)
+	if proj == nil {
+		return nil, nil, fmt.Errorf("no %s found in %s or its parent directories", project.ManifestFile, dir)
+	}
+	pathConfig, err := userPaths()
+	if err != nil {
+		return nil, nil, err
+	}
+	return proj, project.NewTrustStore(pathConfig.ProjectsDir()), nil
+}
+
+// allowedProject returns the project to apply with --project, which must
+// have been allowed with "tomei allow".
+func allowedProject(args []string) (*project.Project, error) {
+	if _, ok := savedPlanArg(args); ok {
+		return nil, errors.New("saved plans cannot be applied with --project")
+	}
+	proj, trust, err := findProject(args)
+	if err != nil {
+		return nil, err
+	}
+	status, err := trust.Status(proj)
+	if err != nil {
+		return nil, err
+	}
+	switch status {
+	case project.TrustAllowed:
+		return proj, nil
+	case project.TrustChanged:
+		return nil, fmt.Errorf("%s changed since it was allowed; review it and run 'tomei allow %s'", proj.Manifest(), proj.Root)
+	default:
+		return nil, fmt.Errorf("%s is not allowed; review it and run 'tomei allow %s'", proj.Manifest(), proj.Root)
+	}
+}
+
+// initProjectState creates the state of a project on its first apply. The
+// project uses the aqua registry ref of the global state.
+func initProjectState(global, projectPaths *path.Paths) error {
+	if _, err := os.Stat(projectPaths.UserStateFile()); err == nil {
+		return nil
+	}
+
+	globalStore, err := state.NewStore[state.UserState](global.UserDataDir())
+	if err != nil {
+		return fmt.Errorf("failed to create state store: %w", err)
+	}
+	globalState, err := globalStore.LoadReadOnly()
+	if err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	store, err := state.NewStore[state.UserState](projectPaths.UserDataDir())
+	if err != nil {
+		return fmt.Errorf("failed to create project state store: %w", err)
+	}
+	if err := store.Lock(); err != nil {
+		return fmt.Errorf("failed to lock project state: %w", err)
+	}
+	defer func() { _ = store.Unlock() }()
+
+	initialState := state.NewUserState()
+	initialState.Registry = globalState.Registry
+	if err := store.Save(initialState); err != nil {
+		return fmt.Errorf("failed to initialize project state: %w", err)
+	}
+	return nil
+}
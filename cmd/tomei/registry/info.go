@@ -0,0 +1,324 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"runtime"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/goccy/go-yaml"
+	"github.com/spf13/cobra"
+
+	"github.com/terassyi/tomei/internal/registry/aqua"
+)
+
+var (
+	infoOutput  string
+	infoVersion string
+)
+
+var infoCmd = &cobra.Command{
+	Use:   "info <package>",
+	Short: "Show an aqua-registry package definition",
+	Long: `Show the aqua-registry definition of a package and the download URL it
+resolves to on the current platform.
+
+The URL is resolved for --version, or for the latest release when omitted.
+Use -o cue to print an #AquaTool declaration pinned to that version:
+
+  tomei registry info BurntSushi/ripgrep
+  tomei registry info kubernetes/kubernetes/kubectl --version v1.35.1
+  tomei registry info cli/cli -o cue`,
+	Args: cobra.ExactArgs(1),
+	RunE: runInfo,
+}
+
+func init() {
+	infoCmd.Flags().StringVarP(&infoOutput, "output", "o", outputText, "Output format: text, json, cue")
+	infoCmd.Flags().StringVar(&infoVersion, "version", "", "Version to resolve (default: latest release)")
+}
+
+// infoResult is the info output in JSON format.
+type infoResult struct {
+	Package    string          `json:"package"`
+	Ref        string          `json:"ref"`
+	Version    string          `json:"version,omitempty"`
+	Platform   string          `json:"platform"`
+	Definition map[string]any  `json:"definition"`
+	Resolved   *resolvedResult `json:"resolved,omitempty"`
+}
+
+// resolvedResult is the resolved download source in JSON format.
+type resolvedResult struct {
+	URL         string   `json:"url"`
+	ChecksumURL string   `json:"checksumUrl,omitempty"`
+	Format      string   `json:"format,omitempty"`
+	Warnings    []string `json:"warnings,omitempty"`
+	Errors      []string `json:"errors,omitempty"`
+}
+
+func runInfo(cmd *cobra.Command, args []string) error {
+	if err := validateOutput(infoOutput); err != nil {
+		return err
+	}
+	resolver, ref, err := openResolver()
+	if err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+	pkg := args[0]
+
+	info, err := resolver.FetchPackageInfo(ctx, ref, pkg)
+	if err != nil {
+		return fmt.Errorf("failed to fetch package %s: %w", pkg, err)
+	}
+	// The registry file of a package may omit its name
+	if info.Name == "" && info.PackageName() != pkg {
+		info.Name = pkg
+	}
+
+	version := infoVersion
+	if version == "" && info.RepoOwner != "" && info.RepoName != "" {
+		latest, err := resolver.VersionClient().GetLatestToolVersion(ctx, info.RepoOwner, info.RepoName)
+		if err != nil {
+			cmd.PrintErrf("Warning: failed to get latest version of %s: %v\n", pkg, err)
+		} else {
+			version = latest
+		}
+	}
+
+	var resolved *aqua.ResolvedSource
+	if version != "" {
+		resolved, err = resolver.Resolve(ctx, ref, pkg, version)
+		if err != nil {
+			return fmt.Errorf("failed to resolve package %s: %w", pkg, err)
+		}
+	}
+
+	switch infoOutput {
+	case outputCUE:
+		snippetVersion := version
+		if snippetVersion == "" {
+			snippetVersion = latestVersion
+		}
+		cmd.Println(presetImport)
+		cmd.Println()
+		cmd.Print(cueSnippet(info, snippetVersion))
+		return nil
+
+	case outputJSON:
+		// Round-trip through YAML to keep the registry's field names
+		data, err := yaml.Marshal(info)
+		if err != nil {
+			return fmt.Errorf("failed to marshal package definition: %w", err)
+		}
+		out := infoResult{
+			Package:  info.PackageName(),
+			Ref:      ref.String(),
+			Version:  version,
+			Platform: platform(),
+		}
+		if err := yaml.Unmarshal(data, &out.Definition); err != nil {
+			return fmt.Errorf("failed to convert package definition: %w", err)
+		}
+		if resolved != nil {
+			out.Resolved = &resolvedResult{
+				URL:         resolved.URL,
+				ChecksumURL: resolved.ChecksumURL,
+				Format:      string(resolved.Format),
+				Warnings:    resolved.Warnings,
+				Errors:      resolved.Errors,
+			}
+		}
+		jsonData, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal package info: %w", err)
+		}
+		cmd.Println(string(jsonData))
+		return nil
+	}
+
+	printInfo(cmd.OutOrStdout(), info, ref)
+	if resolved == nil {
+		return nil
+	}
+	cmd.Printf("\nResolved for %s (version %s):\n", platform(), version)
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	for _, e := range resolved.Errors {
+		fmt.Fprintf(w, "  Error:\t%s\n", e)
+	}
+	if resolved.URL != "" {
+		fmt.Fprintf(w, "  URL:\t%s\n", resolved.URL)
+	}
+	if resolved.ChecksumURL != "" {
+		fmt.Fprintf(w, "  Checksum:\t%s (%s)\n", resolved.ChecksumURL, resolved.ChecksumAlgorithm)
+	}
+	if resolved.Format != "" {
+		fmt.Fprintf(w, "  Format:\t%s\n", resolved.Format)
+	}
+	for _, warning := range resolved.Warnings {
+		fmt.Fprintf(w, "  Warning:\t%s\n", warning)
+	}
+	_ = w.Flush()
+	return nil
+}
+
+// printInfo prints the package definition.
+func printInfo(out io.Writer, info *aqua.PackageInfo, ref aqua.RegistryRef) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Package:\t%s\n", info.PackageName())
+	fmt.Fprintf(w, "Registry:\taqua-registry %s\n", ref)
+	fmt.Fprintf(w, "Type:\t%s\n", info.Type)
+	if info.RepoOwner != "" && info.RepoName != "" {
+		fmt.Fprintf(w, "Repository:\thttps://github.com/%s/%s\n", info.RepoOwner, info.RepoName)
+	}
+	if info.Description != "" {
+		fmt.Fprintf(w, "Description:\t%s\n", strings.TrimSpace(info.Description))
+	}
+	if aliases := aliasNames(info); len(aliases) > 0 {
+		fmt.Fprintf(w, "Aliases:\t%s\n", strings.Join(aliases, ", "))
+	}
+	supported := "all"
+	if len(info.SupportedEnvs) > 0 {
+		supported = strings.Join(info.SupportedEnvs, ", ")
+	}
+	fmt.Fprintf(w, "Supported envs:\t%s\n", supported)
+	if info.Asset != "" {
+		fmt.Fprintf(w, "Asset:\t%s\n", info.Asset)
+	}
+	if info.URL != "" {
+		fmt.Fprintf(w, "URL:\t%s\n", info.URL)
+	}
+	if info.Format != "" {
+		fmt.Fprintf(w, "Format:\t%s\n", info.Format)
+	}
+	if info.VersionPrefix != "" {
+		fmt.Fprintf(w, "Version prefix:\t%s\n", info.VersionPrefix)
+	}
+	if len(info.Files) > 0 {
+		fmt.Fprintf(w, "Files:\t%s\n", formatFiles(info.Files))
+	}
+	fmt.Fprintf(w, "Checksum:\t%s\n", formatChecksum(info.Checksum))
+	_ = w.Flush()
+
+	if len(info.VersionOverrides) > 0 {
+		fmt.Fprintln(out, "\nVersion overrides:")
+		w = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		for _, vo := range info.VersionOverrides {
+			fmt.Fprintf(w, "  %s\t%s\n", vo.VersionConstraint, formatVersionOverride(&vo))
+		}
+		_ = w.Flush()
+	}
+	if len(info.Overrides) > 0 {
+		fmt.Fprintln(out, "\nPlatform overrides:")
+		w = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		for _, o := range info.Overrides {
+			fmt.Fprintf(w, "  %s\t%s\n", formatPlatform(o.GOOS, o.GOArch), formatOverride(&o))
+		}
+		_ = w.Flush()
+	}
+}
+
+// platform returns the current platform as "<os>/<arch>".
+func platform() string {
+	return runtime.GOOS + "/" + runtime.GOARCH
+}
+
+func formatFiles(files []aqua.FileSpec) string {
+	parts := make([]string, 0, len(files))
+	for _, f := range files {
+		if f.Src != "" && f.Src != f.Name {
+			parts = append(parts, fmt.Sprintf("%s (from %s)", f.Name, f.Src))
+		} else {
+			parts = append(parts, f.Name)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatChecksum(c *aqua.ChecksumSpec) string {
+	if c == nil || (c.Asset == "" && !c.Enabled) {
+		return "none"
+	}
+	parts := []string{}
+	if c.Type != "" {
+		parts = append(parts, c.Type)
+	}
+	algorithm := c.Algorithm
+	if algorithm == "" {
+		algorithm = "sha256"
+	}
+	parts = append(parts, algorithm)
+	if c.Asset != "" {
+		parts = append(parts, c.Asset)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatPlatform(goos, goarch string) string {
+	switch {
+	case goos != "" && goarch != "":
+		return goos + "/" + goarch
+	case goos != "":
+		return goos
+	default:
+		return goarch
+	}
+}
+
+func formatVersionOverride(vo *aqua.VersionOverride) string {
+	var parts []string
+	if vo.Asset != "" {
+		parts = append(parts, "asset: "+vo.Asset)
+	}
+	if vo.URL != "" {
+		parts = append(parts, "url: "+vo.URL)
+	}
+	if vo.Format != "" {
+		parts = append(parts, "format: "+vo.Format)
+	}
+	if vo.VersionPrefix != nil {
+		parts = append(parts, fmt.Sprintf("version_prefix: %q", *vo.VersionPrefix))
+	}
+	if vo.Checksum != nil {
+		parts = append(parts, "checksum: "+formatChecksum(vo.Checksum))
+	}
+	if len(vo.SupportedEnvs) > 0 {
+		parts = append(parts, "supported_envs: "+strings.Join(vo.SupportedEnvs, ", "))
+	}
+	if len(vo.Replacements) > 0 {
+		parts = append(parts, "replacements")
+	}
+	if len(vo.Overrides) > 0 {
+		parts = append(parts, fmt.Sprintf("%d platform overrides", len(vo.Overrides)))
+	}
+	if len(parts) == 0 {
+		return "(no changes)"
+	}
+	return strings.Join(parts, "; ")
+}
+
+func formatOverride(o *aqua.Override) string {
+	var parts []string
+	if o.Asset != "" {
+		parts = append(parts, "asset: "+o.Asset)
+	}
+	if o.Format != "" {
+		parts = append(parts, "format: "+o.Format)
+	}
+	for _, k := range sortedKeys(o.Replacements) {
+		parts = append(parts, fmt.Sprintf("%s → %s", k, o.Replacements[k]))
+	}
+	if len(parts) == 0 {
+		return "(no changes)"
+	}
+	return strings.Join(parts, "; ")
+}
+
+func sortedKeys(m map[string]string) []string {
+	return slices.Sorted(maps.Keys(m))
+}
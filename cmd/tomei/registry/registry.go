@@ -0,0 +1,90 @@
+// Package registry implements the "tomei registry" subcommands for browsing aqua-registry.
+package registry
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/terassyi/tomei/internal/config"
+	"github.com/terassyi/tomei/internal/github"
+	"github.com/terassyi/tomei/internal/mirror"
+	"github.com/terassyi/tomei/internal/path"
+	"github.com/terassyi/tomei/internal/registry/aqua"
+	"github.com/terassyi/tomei/internal/state"
+)
+
+// Output formats shared by the registry subcommands.
+const (
+	outputText = "text"
+	outputJSON = "json"
+	outputCUE  = "cue"
+)
+
+// Cmd is the parent command for registry subcommands.
+var Cmd = &cobra.Command{
+	Use:   "registry",
+	Short: "Browse the aqua registry",
+	Long: `Commands for finding packages in aqua-registry.
+
+Packages are looked up at the registry ref recorded by "tomei init" or
+"tomei apply --sync" (override with --ref), so results match what
+"tomei apply" would install. Registry files are cached under
+<cacheDir>/registry/aqua and shared with "tomei apply".`,
+}
+
+var registryRef string
+
+func init() {
+	Cmd.PersistentFlags().StringVar(&registryRef, "ref", "", "aqua-registry ref to browse (default: the ref in state)")
+	Cmd.AddCommand(searchCmd)
+	Cmd.AddCommand(infoCmd)
+}
+
+// openResolver returns a resolver using the registry cache of "tomei apply",
+// and the registry ref to browse.
+func openResolver() (*aqua.Resolver, aqua.RegistryRef, error) {
+	cfg, err := config.LoadConfig(config.DefaultConfigDir)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load config: %w", err)
+	}
+	paths, err := path.NewFromConfig(cfg)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create paths: %w", err)
+	}
+
+	ref := registryRef
+	if ref == "" {
+		store, err := state.NewStore[state.UserState](paths.UserDataDir())
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to create state store: %w", err)
+		}
+		st, err := store.LoadReadOnly()
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to load state: %w", err)
+		}
+		if st.Registry != nil && st.Registry.Aqua != nil {
+			ref = st.Registry.Aqua.Ref
+		}
+	}
+	if ref == "" {
+		return nil, "", fmt.Errorf("aqua-registry ref not found in state; run 'tomei init' first or pass --ref")
+	}
+	if err := aqua.RegistryRef(ref).Validate(); err != nil {
+		return nil, "", err
+	}
+
+	cacheDir := paths.UserCacheDir() + "/registry/aqua"
+	client := mirror.WrapClient(github.NewHTTPClient(github.TokenFromEnv()), cfg.Mirrors)
+	return aqua.NewResolver(cacheDir, client), aqua.RegistryRef(ref), nil
+}
+
+// validateOutput returns an error if format is not a supported output format.
+func validateOutput(format string) error {
+	switch format {
+	case outputText, outputJSON, outputCUE:
+		return nil
+	default:
+		return fmt.Errorf("unsupported output format %q: must be text, json, or cue", format)
+	}
+}
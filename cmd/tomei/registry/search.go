@@ -0,0 +1,138 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/terassyi/tomei/internal/registry/aqua"
+)
+
+const (
+	// maxDescriptionWidth is the description width in the search result table.
+	maxDescriptionWidth = 60
+	// latestVersion is the version used in snippets when none is resolved.
+	latestVersion = "latest"
+)
+
+var (
+	searchOutput string
+	searchLimit  int
+)
+
+var searchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Search aqua-registry packages",
+	Long: `Search aqua-registry packages by name, alias, and description.
+
+Every word of the query must match. Exact name and alias matches are listed
+first, followed by name, alias, and description matches.
+
+Use -o cue to print #AquaTool declarations for the results, ready to paste
+into a manifest:
+
+  tomei registry search ripgrep
+  tomei registry search kubernetes cli --limit 5
+  tomei registry search rg -o cue --limit 1`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runSearch,
+}
+
+func init() {
+	searchCmd.Flags().StringVarP(&searchOutput, "output", "o", outputText, "Output format: text, json, cue")
+	searchCmd.Flags().IntVar(&searchLimit, "limit", 20, "Maximum number of results (0 for no limit)")
+}
+
+// searchResult is a search result in JSON output.
+type searchResult struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type"`
+	Aliases     []string `json:"aliases,omitempty"`
+	Description string   `json:"description,omitempty"`
+}
+
+func runSearch(cmd *cobra.Command, args []string) error {
+	if err := validateOutput(searchOutput); err != nil {
+		return err
+	}
+	resolver, ref, err := openResolver()
+	if err != nil {
+		return err
+	}
+
+	results, err := resolver.Search(cmd.Context(), ref, strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+	total := len(results)
+	if searchLimit > 0 && total > searchLimit {
+		results = results[:searchLimit]
+	}
+
+	switch searchOutput {
+	case outputJSON:
+		out := make([]searchResult, 0, len(results))
+		for _, p := range results {
+			out = append(out, searchResult{
+				Name:        p.PackageName(),
+				Type:        p.Type,
+				Aliases:     aliasNames(&p),
+				Description: strings.TrimSpace(p.Description),
+			})
+		}
+		data, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal search results: %w", err)
+		}
+		cmd.Println(string(data))
+		return nil
+
+	case outputCUE:
+		if len(results) == 0 {
+			return fmt.Errorf("no packages matching %q in aqua-registry %s", strings.Join(args, " "), ref)
+		}
+		cmd.Println(presetImport)
+		for _, p := range results {
+			cmd.Println()
+			cmd.Print(cueSnippet(&p, latestVersion))
+		}
+		return nil
+	}
+
+	if len(results) == 0 {
+		cmd.Printf("No packages matching %q in aqua-registry %s.\n", strings.Join(args, " "), ref)
+		return nil
+	}
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tALIASES\tDESCRIPTION")
+	for _, p := range results {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", p.PackageName(), strings.Join(aliasNames(&p), ","), truncate(p.Description, maxDescriptionWidth))
+	}
+	_ = w.Flush()
+	if len(results) < total {
+		cmd.Printf("\nShowing %d of %d packages (use --limit to show more).\n", len(results), total)
+	}
+	return nil
+}
+
+// aliasNames returns the alias names of a package.
+func aliasNames(p *aqua.PackageInfo) []string {
+	names := make([]string, 0, len(p.Aliases))
+	for _, a := range p.Aliases {
+		names = append(names, a.Name)
+	}
+	return names
+}
+
+// truncate shortens s to at most width runes on a single line.
+func truncate(s string, width int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	r := []rune(s)
+	if len(r) <= width {
+		return s
+	}
+	return string(r[:width-3]) + "..."
+}
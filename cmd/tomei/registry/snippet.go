@@ -0,0 +1,69 @@
+package registry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/terassyi/tomei/internal/registry/aqua"
+)
+
+// presetImport is the import declaration required by the generated snippets.
+const presetImport = `import "tomei.terassyi.net/presets/aqua"`
+
+var (
+	invalidNameChars = regexp.MustCompile(`[^a-z0-9._-]+`)
+	cueIdentifier    = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+)
+
+// toolName returns the tool name for a package: the first installed file,
+// else the last element of the package name, normalized to a valid
+// metadata name.
+func toolName(info *aqua.PackageInfo) string {
+	name := path.Base(info.PackageName())
+	if len(info.Files) > 0 && info.Files[0].Name != "" {
+		name = info.Files[0].Name
+	}
+	name = invalidNameChars.ReplaceAllString(strings.ToLower(name), "-")
+	return strings.Trim(name, "._-")
+}
+
+// cueSnippet returns an #AquaTool declaration for the package, ready to paste
+// into a manifest that imports the aqua preset.
+func cueSnippet(info *aqua.PackageInfo, version string) string {
+	name := toolName(info)
+	label := name
+	if !cueIdentifier.MatchString(label) {
+		label = cueString(label)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: aqua.#AquaTool & {\n", label)
+	if info.Description != "" {
+		b.WriteString("\tmetadata: {\n")
+		fmt.Fprintf(&b, "\t\tname:        %s\n", cueString(name))
+		fmt.Fprintf(&b, "\t\tdescription: %s\n", cueString(strings.TrimSpace(info.Description)))
+		b.WriteString("\t}\n")
+	} else {
+		fmt.Fprintf(&b, "\tmetadata: name: %s\n", cueString(name))
+	}
+	b.WriteString("\tspec: {\n")
+	fmt.Fprintf(&b, "\t\tpackage: %s\n", cueString(info.PackageName()))
+	fmt.Fprintf(&b, "\t\tversion: %s\n", cueString(version))
+	b.WriteString("\t}\n")
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// cueString quotes s as a CUE string literal.
+// JSON string escapes are valid in CUE strings.
+func cueString(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
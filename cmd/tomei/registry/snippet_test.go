@@ -0,0 +1,73 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/terassyi/tomei/internal/registry/aqua"
+)
+
+func TestCUESnippet(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		info    *aqua.PackageInfo
+		version string
+		want    string
+	}{
+		{
+			name: "file name and description",
+			info: &aqua.PackageInfo{
+				RepoOwner:   "BurntSushi",
+				RepoName:    "ripgrep",
+				Description: `ripgrep recursively searches directories for a "regex" pattern`,
+				Files:       []aqua.FileSpec{{Name: "rg"}},
+			},
+			version: "15.1.0",
+			want: `rg: aqua.#AquaTool & {
+	metadata: {
+		name:        "rg"
+		description: "ripgrep recursively searches directories for a \"regex\" pattern"
+	}
+	spec: {
+		package: "BurntSushi/ripgrep"
+		version: "15.1.0"
+	}
+}
+`,
+		},
+		{
+			name:    "named package without description",
+			info:    &aqua.PackageInfo{Name: "kubernetes/kubernetes/kubectl", RepoOwner: "kubernetes", RepoName: "kubernetes"},
+			version: "latest",
+			want: `kubectl: aqua.#AquaTool & {
+	metadata: name: "kubectl"
+	spec: {
+		package: "kubernetes/kubernetes/kubectl"
+		version: "latest"
+	}
+}
+`,
+		},
+		{
+			name:    "label that is not an identifier",
+			info:    &aqua.PackageInfo{RepoOwner: "getsops", RepoName: "SOPS.v3"},
+			version: "v3.9.0",
+			want: `"sops.v3": aqua.#AquaTool & {
+	metadata: name: "sops.v3"
+	spec: {
+		package: "getsops/SOPS.v3"
+		version: "v3.9.0"
+	}
+}
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, cueSnippet(tt.info, tt.version))
+		})
+	}
+}
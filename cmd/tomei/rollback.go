@@ -0,0 +1,162 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/terassyi/tomei/internal/config"
+	"github.com/terassyi/tomei/internal/installer"
+	"github.com/terassyi/tomei/internal/installer/download"
+	"github.com/terassyi/tomei/internal/installer/engine"
+	"github.com/terassyi/tomei/internal/path"
+	"github.com/terassyi/tomei/internal/resource"
+	"github.com/terassyi/tomei/internal/state"
+)
+
+// stateRollbackCmd is registered under "tomei state" but lives in the main
+// package because it runs through the same pipeline as "tomei apply".
+var stateRollbackCmd = &cobra.Command{
+	Use:   "rollback <generation>",
+	Short: "Return the environment to a recorded state generation",
+	Long: `Return tools and runtimes to the versions recorded in a state generation
+(see "tomei state history").
+
+The manifests are still the source of truth for how resources are installed.
+Rollback applies them with the versions of the generation: "latest" and alias
+versions are pinned to the recorded version, exact versions are replaced by
+it, and tools and runtimes that did not exist in the generation are removed.
+Download-pattern versions that are still on disk are re-linked without
+downloading. tomei.lock is updated to the rolled-back versions.
+
+Resources recorded in the generation but no longer defined in the manifests
+cannot be restored and are reported as warnings.
+
+  tomei state history
+  tomei state rollback 12
+  tomei state rollback -f ~/dotfiles/tomei 12`,
+	Args: cobra.ExactArgs(1),
+	RunE: runStateRollback,
+}
+
+var (
+	rollbackCfg       applyConfig
+	rollbackManifests []string
+)
+
+func init() {
+	stateRollbackCmd.Flags().StringSliceVarP(&rollbackManifests, "manifests", "f", []string{"."}, "Manifest files or directories")
+	stateRollbackCmd.Flags().BoolVarP(&rollbackCfg.yes, "yes", "y", false, "Skip confirmation prompt")
+	stateRollbackCmd.Flags().BoolVar(&rollbackCfg.quiet, "quiet", false, "Suppress progress output")
+	stateRollbackCmd.Flags().BoolVar(&rollbackCfg.noColor, "no-color", false, "Disable colored output")
+	stateRollbackCmd.Flags().BoolVar(&rollbackCfg.ignoreCosign, "ignore-cosign", false, "Skip cosign signature verification for CUE module dependencies")
+	stateRollbackCmd.Flags().IntVar(&rollbackCfg.parallel, "parallel", engine.DefaultParallelism, "Maximum number of parallel installations (1-20)")
+	stateRollbackCmd.Flags().DurationVar(&rollbackCfg.timeout, "timeout", download.DefaultDownloadTimeout, "Timeout per download attempt (e.g., 5m, 10m, 1h)")
+	stateRollbackCmd.Flags().IntVar(&rollbackCfg.retries, "retries", download.DefaultRetries, "Number of retries of a failed download (0 disables retries)")
+}
+
+func runStateRollback(cmd *cobra.Command, args []string) error {
+	if systemMode {
+		return errors.New("state rollback only supports user-level resources; --system is not supported")
+	}
+
+	number, err := strconv.Atoi(args[0])
+	if err != nil || number <= 0 {
+		return fmt.Errorf("invalid generation %q: expected a positive number", args[0])
+	}
+
+	appCfg, err := config.LoadConfig(config.DefaultConfigDir)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	pathConfig, err := path.NewFromConfig(appCfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize paths: %w", err)
+	}
+	store, err := state.NewStore[state.UserState](pathConfig.UserDataDir())
+	if err != nil {
+		return fmt.Errorf("failed to create state store: %w", err)
+	}
+	target, err := state.LoadGeneration[state.UserState](store.StatePath(), number)
+	if err != nil {
+		return err
+	}
+
+	cfg := rollbackCfg
+	cfg.rollbackTo = target
+	cmd.Printf("Rolling back to generation %d using manifests from %v\n", number, rollbackManifests)
+	return runUserApply(cmd.Context(), rollbackManifests, cmd.OutOrStdout(), &cfg)
+}
+
+// rollbackResources adapts the manifest resources to the target generation.
+// Tools and runtimes missing from the generation are dropped so that apply
+// removes them, and exact versions are replaced by the recorded version.
+// Non-exact versions are pinned separately by pinRollbackVersions.
+func rollbackResources(resources []resource.Resource, target *state.UserState) []resource.Resource {
+	defined := make(map[string]bool)
+	result := make([]resource.Resource, 0, len(resources))
+	for _, res := range resources {
+		defined[string(res.Kind())+"/"+res.Name()] = true
+		switch r := res.(type) {
+		case *resource.Tool:
+			ts, ok := target.Tools[r.Name()]
+			if !ok {
+				continue
+			}
+			if r.ToolSpec != nil && resource.IsExactVersion(r.ToolSpec.Version) && r.ToolSpec.Version != ts.Version {
+				spec := *r.ToolSpec
+				spec.Version = ts.Version
+				res = &resource.Tool{BaseResource: r.BaseResource, ToolSpec: &spec}
+			}
+		case *resource.Runtime:
+			rs, ok := target.Runtimes[r.Name()]
+			if !ok {
+				continue
+			}
+			if r.RuntimeSpec != nil && resource.IsExactVersion(r.RuntimeSpec.Version) && r.RuntimeSpec.Version != rs.Version {
+				spec := *r.RuntimeSpec
+				spec.Version = rs.Version
+				res = &resource.Runtime{BaseResource: r.BaseResource, RuntimeSpec: &spec}
+			}
+		}
+		result = append(result, res)
+	}
+
+	for name := range target.Runtimes {
+		if !defined[string(resource.KindRuntime)+"/"+name] {
+			slog.Warn("runtime from the generation is not defined in the manifests and cannot be restored", "runtime", name)
+		}
+	}
+	for name := range target.Tools {
+		if !defined[string(resource.KindTool)+"/"+name] {
+			slog.Warn("tool from the generation is not defined in the manifests and cannot be restored", "tool", name)
+		}
+	}
+	return result
+}
+
+// pinRollbackVersions pins tools and runtimes with non-exact versions to the
+// versions recorded in the target generation, overriding lockfile pins.
+func pinRollbackVersions(target *state.UserState, resources []resource.Resource, tools, runtimes map[string]installer.Pin, updCfg *engine.UpdateConfig) {
+	for _, res := range resources {
+		switch r := res.(type) {
+		case *resource.Tool:
+			ts, ok := target.Tools[r.Name()]
+			if !ok || r.ToolSpec == nil || resource.IsExactVersion(r.ToolSpec.Version) || !resource.IsExactVersion(ts.Version) {
+				continue
+			}
+			tools[r.Name()] = installer.Pin{Version: ts.Version, Digest: ts.Digest}
+			updCfg.LockedTools[r.Name()] = ts.Version
+		case *resource.Runtime:
+			rs, ok := target.Runtimes[r.Name()]
+			if !ok || r.RuntimeSpec == nil || resource.IsExactVersion(r.RuntimeSpec.Version) || !resource.IsExactVersion(rs.Version) {
+				continue
+			}
+			runtimes[r.Name()] = installer.Pin{Version: rs.Version, Digest: rs.Digest}
+			updCfg.LockedRuntimes[r.Name()] = rs.Version
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/terassyi/tomei/internal/installer"
+	"github.com/terassyi/tomei/internal/installer/engine"
+	"github.com/terassyi/tomei/internal/resource"
+	"github.com/terassyi/tomei/internal/state"
+)
+
+func rollbackTestResources() []resource.Resource {
+	return []resource.Resource{
+		&resource.Tool{BaseResource: resource.BaseResource{ResourceKind: resource.KindTool, Metadata: resource.Metadata{Name: "gh"}}, ToolSpec: &resource.ToolSpec{Version: "latest"}},
+		&resource.Tool{BaseResource: resource.BaseResource{ResourceKind: resource.KindTool, Metadata: resource.Metadata{Name: "ripgrep"}}, ToolSpec: &resource.ToolSpec{Version: "14.1.0"}},
+		&resource.Tool{BaseResource: resource.BaseResource{ResourceKind: resource.KindTool, Metadata: resource.Metadata{Name: "fd"}}, ToolSpec: &resource.ToolSpec{Version: "latest"}},
+		&resource.Runtime{BaseResource: resource.BaseResource{ResourceKind: resource.KindRuntime, Metadata: resource.Metadata{Name: "go"}}, RuntimeSpec: &resource.RuntimeSpec{Version: "stable"}},
+		&resource.Installer{BaseResource: resource.BaseResource{ResourceKind: resource.KindInstaller, Metadata: resource.Metadata{Name: "brew"}}},
+		&resource.Tool{BaseResource: resource.BaseResource{ResourceKind: resource.KindTool, Metadata: resource.Metadata{Name: "kubectl"}}, ToolSpec: &resource.ToolSpec{Version: "stable"}},
+	}
+}
+
+func rollbackTestTarget() *state.UserState {
+	st := state.NewUserState()
+	st.Tools["gh"] = &resource.ToolState{Version: "2.60.0", VersionKind: resource.VersionLatest, Digest: "abc"}
+	st.Tools["ripgrep"] = &resource.ToolState{Version: "14.0.0", VersionKind: resource.VersionExact, SpecVersion: "14.0.0"}
+	st.Tools["kubectl"] = &resource.ToolState{Version: "1.31.0", SpecVersion: "stable"}
+	st.Tools["bat"] = &resource.ToolState{Version: "0.24.0"} // no longer in manifests
+	st.Runtimes["go"] = &resource.RuntimeState{Version: "1.25.4", VersionKind: resource.VersionAlias, SpecVersion: "stable"}
+	return st
+}
+
+func TestRollbackResources(t *testing.T) {
+	t.Parallel()
+
+	resources := rollbackTestResources()
+	got := rollbackResources(resources, rollbackTestTarget())
+
+	names := make([]string, 0, len(got))
+	for _, res := range got {
+		names = append(names, res.Name())
+	}
+	// fd did not exist in the generation and is dropped so that apply removes it
+	assert.Equal(t, []string{"gh", "ripgrep", "go", "brew", "kubectl"}, names)
+
+	// Exact versions are replaced without mutating the manifest resource
+	rg, ok := got[1].(*resource.Tool)
+	require.True(t, ok)
+	assert.Equal(t, "14.0.0", rg.ToolSpec.Version)
+	assert.Equal(t, "14.1.0", resources[1].(*resource.Tool).ToolSpec.Version)
+
+	// Non-exact versions are left for pinning
+	assert.Equal(t, "latest", got[0].(*resource.Tool).ToolSpec.Version)
+}
+
+func TestPinRollbackVersions(t *testing.T) {
+	t.Parallel()
+
+	target := rollbackTestTarget()
+	resources := rollbackResources(rollbackTestResources(), target)
+	tools := map[string]installer.Pin{"gh": {Version: "2.62.0"}}
+	runtimes := map[string]installer.Pin{}
+	updCfg := engine.UpdateConfig{LockedTools: map[string]string{"gh": "2.62.0"}, LockedRuntimes: map[string]string{}}
+
+	pinRollbackVersions(target, resources, tools, runtimes, &updCfg)
+
+	assert.Equal(t, map[string]installer.Pin{
+		"gh":      {Version: "2.60.0", Digest: "abc"},
+		"kubectl": {Version: "1.31.0"},
+	}, tools)
+	assert.Equal(t, map[string]installer.Pin{"go": {Version: "1.25.4"}}, runtimes)
+	assert.Equal(t, map[string]string{"gh": "2.60.0", "kubectl": "1.31.0"}, updCfg.LockedTools)
+	assert.Equal(t, map[string]string{"go": "1.25.4"}, updCfg.LockedRuntimes)
+}
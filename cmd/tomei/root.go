@@ -4,13 +4,17 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/spf13/cobra"
 
+	cachecmd "github.com/terassyi/tomei/cmd/tomei/cache"
 	cuecmd "github.com/terassyi/tomei/cmd/tomei/cue"
+	registrycmd "github.com/terassyi/tomei/cmd/tomei/registry"
 	statecmd "github.com/terassyi/tomei/cmd/tomei/state"
 	"github.com/terassyi/tomei/internal/config"
+	"github.com/terassyi/tomei/internal/path"
 	"github.com/terassyi/tomei/internal/verify"
 )
 
@@ -66,18 +70,31 @@ func (c *loadConfig) registerFlags(cmd *cobra.Command) {
 	cmd.Flags().BoolVar(&c.ignoreCosign, "ignore-cosign", false, "Skip cosign signature verification for CUE module dependencies")
 }
 
-// verifierOpts returns LoaderOptions for cosign signature verification.
-// If ignoreCosign is set or the verifier cannot be created, returns nil (no verification).
-func (c *loadConfig) verifierOpts() []config.LoaderOption {
+// verifierOpts returns LoaderOptions for cosign signature verification,
+// configured by moduleVerification in config.cue.
+// If ignoreCosign is set, returns nil (no verification). If the verifier
+// cannot be created, returns nil in warn mode and an error in require mode.
+func (c *loadConfig) verifierOpts() ([]config.LoaderOption, error) {
 	if c.ignoreCosign {
-		return nil
+		return nil, nil
+	}
+	appCfg, err := config.LoadConfig(config.DefaultConfigDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
 	v, err := verify.NewSigstoreVerifier(config.CUERegistryOrDefault())
 	if err != nil {
+		if appCfg.ModuleVerification.Requires() {
+			return nil, fmt.Errorf("failed to create cosign verifier, which moduleVerification mode require needs: %w", err)
+		}
 		slog.Warn("failed to create cosign verifier, skipping verification", "error", err)
-		return nil
+		return nil, nil
+	}
+	v.WithPolicy(appCfg.ModuleVerification)
+	if paths, err := path.NewFromConfig(appCfg); err == nil {
+		v.WithTrustedRootCache(filepath.Join(paths.UserCacheDir(), "sigstore", "trusted_root.json"))
 	}
-	return []config.LoaderOption{config.WithVerifier(v)}
+	return []config.LoaderOption{config.WithVerifier(v)}, nil
 }
 
 var rootCmd = &cobra.Command{
@@ -104,7 +121,7 @@ see "tomei cue --help".
 
 Commands are separated by privilege level:
   tomei apply              Apply user-level resources (Runtime, Tool)
-  sudo tomei apply --system  Apply system-level resources (SystemPackage)`,
+  sudo tomei apply --system  Apply system-level resources (SystemInstaller, SystemPackageSet)`,
 	SilenceUsage:  true,
 	SilenceErrors: true,
 	PersistentPreRunE: func(_ *cobra.Command, _ []string) error {
@@ -114,6 +131,8 @@ Commands are separated by privilege level:
 }
 
 func init() {
+	statecmd.Cmd.AddCommand(stateRollbackCmd)
+
 	// Global flags
 	rootCmd.PersistentFlags().BoolVar(&systemMode, "system", false, "Apply system-level resources (requires root)")
 	rootCmd.PersistentFlags().Var(globalLogLevel, "log-level", "Log level (debug, info, warn, error)")
@@ -128,13 +147,23 @@ func init() {
 		applyCmd,
 		validateCmd,
 		planCmd,
+		lockCmd,
+		bundleCmd,
 		doctorCmd,
+		verifyCmd,
+		importCmd,
 		envCmd,
+		hookCmd,
+		allowCmd,
+		denyCmd,
 		logsCmd,
+		historyCmd,
 		getCmd,
 		completionCmd,
 		cuecmd.Cmd,
 		statecmd.Cmd,
+		cachecmd.Cmd,
+		registrycmd.Cmd,
 		upgradeCmd,
 	)
 }
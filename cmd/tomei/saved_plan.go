@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/terassyi/tomei/internal/config"
+	"github.com/terassyi/tomei/internal/graph"
+	"github.com/terassyi/tomei/internal/installer"
+	"github.com/terassyi/tomei/internal/installer/engine"
+	"github.com/terassyi/tomei/internal/installer/tool"
+	"github.com/terassyi/tomei/internal/path"
+	"github.com/terassyi/tomei/internal/planfile"
+	"github.com/terassyi/tomei/internal/resource"
+)
+
+// savedPlanArg returns the plan file path if args name a single saved plan
+// file instead of manifests.
+func savedPlanArg(args []string) (string, bool) {
+	if len(args) != 1 || !strings.HasSuffix(args[0], planfile.Extension) {
+		return "", false
+	}
+	info, err := os.Stat(args[0])
+	if err != nil || info.IsDir() || !planfile.IsPlanFile(args[0]) {
+		return "", false
+	}
+	return args[0], true
+}
+
+// absPaths returns the absolute form of the manifest paths.
+func absPaths(paths []string) ([]string, error) {
+	abs := make([]string, len(paths))
+	for i, p := range paths {
+		a, err := filepath.Abs(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve path %s: %w", p, err)
+		}
+		abs[i] = a
+	}
+	return abs, nil
+}
+
+// userPaths returns the paths from the config at the fixed path (~/.config/tomei/config.cue).
+func userPaths() (*path.Paths, error) {
+	appCfg, err := config.LoadConfig(config.DefaultConfigDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	pathConfig, err := path.NewFromConfig(appCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize paths: %w", err)
+	}
+	return pathConfig, nil
+}
+
+// savedPlanInput is what a saved plan records besides the planned actions.
+type savedPlanInput struct {
+	paths        []string
+	manifestHash string
+	lockHash     string
+	updCfg       engine.UpdateConfig
+}
+
+// writeSavedPlan writes the actions of up, with their resolved versions and
+// download URLs, and the state and manifest hashes to out. up must have been
+// computed with resolve set.
+func writeSavedPlan(in *savedPlanInput, up *userPlan, out string) (*planfile.Plan, error) {
+	p := planfile.New()
+	p.CreatedAt = time.Now().UTC()
+	p.Paths = in.paths
+	p.ManifestHash = in.manifestHash
+	p.LockHash = in.lockHash
+	p.StateHash = up.stateHash
+	p.Registry = up.registry
+	p.Update = planfile.Update{
+		Sync:           in.updCfg.SyncMode,
+		UpdateTools:    in.updCfg.UpdateTools,
+		UpdateRuntimes: in.updCfg.UpdateRuntimes,
+	}
+
+	// resolve fills in the resolved version and URL of an install
+	resolve := func(pa *planfile.Action) error {
+		r, ok := up.resolved[graph.NewNodeID(pa.Kind, pa.Name)]
+		if !ok {
+			return nil
+		}
+		if r.err != nil {
+			return fmt.Errorf("%s %s: %w", pa.Kind, pa.Name, r.err)
+		}
+		pa.Version, pa.URL = r.version, r.url
+		return nil
+	}
+
+	for _, a := range up.runtimes {
+		pa := planfile.Action{Kind: resource.KindRuntime, Name: a.Name, Action: a.Type}
+		if a.Type == resource.ActionRemove {
+			pa.Version = a.State.Version
+		} else {
+			pa.SpecVersion, pa.Version = a.Resource.RuntimeSpec.Version, a.Resource.RuntimeSpec.Version
+			if err := resolve(&pa); err != nil {
+				return nil, err
+			}
+		}
+		p.Add(pa)
+	}
+	for _, a := range up.installers {
+		p.Add(planfile.Action{Kind: resource.KindInstaller, Name: a.Name, Action: a.Type})
+	}
+	for _, a := range up.repos {
+		p.Add(planfile.Action{Kind: resource.KindInstallerRepository, Name: a.Name, Action: a.Type})
+	}
+	for _, a := range up.tools {
+		pa := planfile.Action{Kind: resource.KindTool, Name: a.Name, Action: a.Type}
+		if a.Type == resource.ActionRemove {
+			pa.Version = a.State.Version
+		} else {
+			pa.SpecVersion, pa.Version = a.Resource.ToolSpec.Version, a.Resource.ToolSpec.Version
+			if err := resolve(&pa); err != nil {
+				return nil, err
+			}
+		}
+		p.Add(pa)
+	}
+
+	if err := p.Save(out); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// isDownloadTool reports whether t is installed by the download pattern,
+// i.e. from an explicit source or a downloaded aqua registry package.
+func isDownloadTool(ctx context.Context, toolInstaller *tool.Installer, t *resource.Tool, delegation map[string]bool) (bool, error) {
+	spec := t.ToolSpec
+	if spec.Commands != nil || spec.RuntimeRef != "" || delegation[spec.InstallerRef] {
+		return false, nil
+	}
+	if spec.Source != nil {
+		return true, nil
+	}
+	if !spec.Package.IsRegistry() {
+		return false, nil
+	}
+	runtimeName, err := toolInstaller.RegistryRuntime(ctx, t)
+	if err != nil {
+		return false, err
+	}
+	return runtimeName == "", nil
+}
+
+// savedPlanPins overlays the versions and URLs resolved in a saved plan onto
+// the lockfile pins. Lockfile digests are kept when the lockfile pins the same version.
+func savedPlanPins(p *planfile.Plan, tools, runtimes map[string]installer.Pin) {
+	planTools, planRuntimes := p.Pins()
+	overlay := func(dst, src map[string]installer.Pin) {
+		for name, pin := range src {
+			if cur, ok := dst[name]; ok && cur.Version == pin.Version {
+				if cur.URL == "" {
+					cur.URL = pin.URL
+					dst[name] = cur
+				}
+				continue
+			}
+			dst[name] = pin
+		}
+	}
+	overlay(tools, planTools)
+	overlay(runtimes, planRuntimes)
+}
+
+// savedPlanUpdateConfig returns the update config recorded in a saved plan.
+func savedPlanUpdateConfig(p *planfile.Plan) engine.UpdateConfig {
+	return engine.UpdateConfig{
+		SyncMode:       p.Update.Sync,
+		UpdateTools:    p.Update.UpdateTools,
+		UpdateRuntimes: p.Update.UpdateRuntimes,
+	}
+}
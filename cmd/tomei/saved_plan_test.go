@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/terassyi/tomei/internal/installer"
+	"github.com/terassyi/tomei/internal/installer/download"
+	"github.com/terassyi/tomei/internal/installer/place"
+	"github.com/terassyi/tomei/internal/installer/tool"
+	"github.com/terassyi/tomei/internal/planfile"
+	"github.com/terassyi/tomei/internal/resource"
+)
+
+func TestSavedPlanPins(t *testing.T) {
+	t.Parallel()
+
+	p := planfile.New()
+	p.Add(planfile.Action{Kind: resource.KindTool, Name: "rg", Action: resource.ActionInstall, SpecVersion: "latest", Version: "14.1.0", URL: "https://example.com/rg-14.1.0.tar.gz"})
+	p.Add(planfile.Action{Kind: resource.KindTool, Name: "fd", Action: resource.ActionUpgrade, SpecVersion: "latest", Version: "10.2.0", URL: "https://example.com/fd-10.2.0.tar.gz"})
+	p.Add(planfile.Action{Kind: resource.KindRuntime, Name: "go", Action: resource.ActionInstall, SpecVersion: "stable", Version: "1.25.5", URL: "https://go.dev/dl/go1.25.5.tar.gz"})
+
+	tools := map[string]installer.Pin{
+		"rg": {Version: "14.1.0", Digest: "abc"},                                              // same version: digest kept
+		"fd": {Version: "10.1.0", URL: "https://example.com/fd-10.1.0.tar.gz", Digest: "def"}, // replaced by the plan
+	}
+	runtimes := map[string]installer.Pin{}
+	savedPlanPins(p, tools, runtimes)
+
+	assert.Equal(t, map[string]installer.Pin{
+		"rg": {Version: "14.1.0", URL: "https://example.com/rg-14.1.0.tar.gz", Digest: "abc"},
+		"fd": {Version: "10.2.0", URL: "https://example.com/fd-10.2.0.tar.gz"},
+	}, tools)
+	assert.Equal(t, map[string]installer.Pin{
+		"go": {Version: "1.25.5", URL: "https://go.dev/dl/go1.25.5.tar.gz"},
+	}, runtimes)
+}
+
+// TestSavedPlanPins_URLChanged applies a saved plan after the registry started
+// resolving the reviewed version to a different URL.
+func TestSavedPlanPins_URLChanged(t *testing.T) {
+	t.Parallel()
+
+	p := planfile.New()
+	p.Add(planfile.Action{Kind: resource.KindTool, Name: "rg", Action: resource.ActionInstall, SpecVersion: "latest", Version: "14.1.0", URL: "https://example.com/rg-14.1.0.tar.gz"})
+	tools := map[string]installer.Pin{}
+	savedPlanPins(p, tools, map[string]installer.Pin{})
+
+	dir := t.TempDir()
+	inst := tool.NewInstaller(download.NewDownloader(), place.NewPlacer(filepath.Join(dir, "tools"), filepath.Join(dir, "bin")))
+	inst.SetPins(tools)
+	_, err := inst.Install(context.Background(), &resource.Tool{
+		BaseResource: resource.BaseResource{ResourceKind: resource.KindTool, Metadata: resource.Metadata{Name: "rg"}},
+		ToolSpec: &resource.ToolSpec{
+			InstallerRef: "download",
+			Version:      "14.1.0",
+			Source:       &resource.DownloadSource{URL: "https://attacker.example.com/rg-14.1.0.tar.gz"},
+		},
+	}, "rg")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not match URL pinned")
+}
@@ -0,0 +1,155 @@
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/terassyi/tomei/internal/config"
+	"github.com/terassyi/tomei/internal/path"
+	internalstate "github.com/terassyi/tomei/internal/state"
+)
+
+var historyOutput string
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List recorded state generations",
+	Long: fmt.Sprintf(`List the state generations recorded in the history directory.
+
+Each "tomei apply" that changes the state records a new generation
+(the latest %d are kept). The CHANGES column summarizes the diff from the
+previous generation: + added, ~ modified, - removed. The oldest generation
+has no previous one to diff against and is shown as the baseline.
+
+Use "tomei state rollback <generation>" to return to a generation.`, internalstate.HistoryLimit),
+	RunE: runHistory,
+}
+
+func init() {
+	historyCmd.Flags().StringVarP(&historyOutput, "output", "o", "text", "Output format: text, json")
+}
+
+// historyEntry is a generation with its diff from the previous generation.
+// Diff is nil for the oldest kept generation, whose previous generation is
+// not known (it was pruned, or there was none).
+type historyEntry struct {
+	internalstate.Generation
+	Current bool                `json:"current"`
+	Diff    *internalstate.Diff `json:"diff,omitempty"`
+}
+
+func runHistory(cmd *cobra.Command, _ []string) error {
+	cfg, err := config.LoadConfig(config.DefaultConfigDir)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	paths, err := path.NewFromConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create paths: %w", err)
+	}
+	store, err := internalstate.NewStore[internalstate.UserState](paths.UserDataDir())
+	if err != nil {
+		return fmt.Errorf("failed to create state store: %w", err)
+	}
+
+	entries, err := loadHistory(store.StatePath())
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		cmd.Println("No state history found. Run 'tomei apply' first.")
+		return nil
+	}
+
+	switch historyOutput {
+	case "json":
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal history: %w", err)
+		}
+		cmd.Println(string(data))
+		return nil
+	default:
+		printHistoryText(cmd, entries)
+		return nil
+	}
+}
+
+// loadHistory loads every generation and diffs it against the previous one.
+// The oldest generation is the baseline and has no diff.
+func loadHistory(statePath string) ([]historyEntry, error) {
+	gens, err := internalstate.ListGenerations(statePath)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := os.ReadFile(statePath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read current state: %w", err)
+	}
+
+	entries := make([]historyEntry, 0, len(gens))
+	var prev *internalstate.UserState
+	for _, gen := range gens {
+		st, err := internalstate.LoadGeneration[internalstate.UserState](statePath, gen.Number)
+		if err != nil {
+			return nil, err
+		}
+		entry := historyEntry{Generation: gen}
+		if prev != nil {
+			entry.Diff = internalstate.DiffUserStates(prev, st)
+		}
+		if data, err := os.ReadFile(gen.Path); err == nil && current != nil && bytes.Equal(data, current) {
+			entry.Current = true
+		}
+		entries = append(entries, entry)
+		prev = st
+	}
+	return entries, nil
+}
+
+func printHistoryText(cmd *cobra.Command, entries []historyEntry) {
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "GENERATION\tCREATED\tCHANGES")
+	for _, e := range entries {
+		gen := fmt.Sprintf("%d", e.Number)
+		if e.Current {
+			gen += " (current)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", gen, e.CreatedAt.Local().Format("2006-01-02 15:04:05"), summarizeDiff(e.Diff))
+	}
+	_ = w.Flush()
+}
+
+// summarizeDiff renders a diff on one line, e.g. "+jq ~gh(2.60.0→2.62.0) -fd".
+// A nil diff is the baseline generation.
+func summarizeDiff(diff *internalstate.Diff) string {
+	if diff == nil {
+		return "(baseline)"
+	}
+	if !diff.HasChanges() {
+		return "-"
+	}
+	parts := make([]string, 0, len(diff.Changes))
+	for _, c := range diff.Changes {
+		switch c.Type {
+		case internalstate.DiffAdded:
+			parts = append(parts, "+"+c.Name)
+		case internalstate.DiffRemoved:
+			parts = append(parts, "-"+c.Name)
+		case internalstate.DiffModified:
+			if c.OldVersion != "" && c.NewVersion != "" && c.OldVersion != c.NewVersion {
+				parts = append(parts, fmt.Sprintf("~%s(%s→%s)", c.Name, c.OldVersion, c.NewVersion))
+			} else {
+				parts = append(parts, "~"+c.Name)
+			}
+		}
+	}
+	return strings.Join(parts, " ")
+}
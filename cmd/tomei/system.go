@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+
+	"github.com/terassyi/tomei/internal/config"
+	"github.com/terassyi/tomei/internal/graph"
+	"github.com/terassyi/tomei/internal/installer/engine"
+	"github.com/terassyi/tomei/internal/installer/reconciler"
+	"github.com/terassyi/tomei/internal/installer/system"
+	tomeilog "github.com/terassyi/tomei/internal/log"
+	"github.com/terassyi/tomei/internal/path"
+	"github.com/terassyi/tomei/internal/resource"
+	"github.com/terassyi/tomei/internal/state"
+	"github.com/terassyi/tomei/internal/ui"
+)
+
+// loadSystemResources loads manifests from paths and returns only the
+// system-privilege resources, with sets expanded.
+func loadSystemResources(paths []string, cfg *loadConfig) ([]resource.Resource, error) {
+	verifierOpts, err := cfg.verifierOpts()
+	if err != nil {
+		return nil, err
+	}
+	loader := config.NewLoader(nil, verifierOpts...)
+	resources, err := loader.LoadPaths(paths)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load resources: %w", err)
+	}
+	resources, err = resource.ExpandSets(resources)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand sets: %w", err)
+	}
+	return engine.FilterSystemResources(resources), nil
+}
+
+func runSystemApply(ctx context.Context, paths []string, w io.Writer, cfg *applyConfig) error {
+	if os.Geteuid() != 0 {
+		return fmt.Errorf("system apply requires root privileges. Run 'sudo tomei apply --system'")
+	}
+
+	resources, err := loadSystemResources(paths, &cfg.loadConfig)
+	if err != nil {
+		return err
+	}
+	if len(resources) == 0 {
+		fmt.Fprintln(w, "No system resources found")
+		return nil
+	}
+
+	store, err := state.NewStore[state.SystemState](path.DefaultSystemDataDir)
+	if err != nil {
+		return fmt.Errorf("failed to create state store: %w", err)
+	}
+
+	// Show plan and ask for confirmation when there are changes
+	st, err := store.LoadReadOnly()
+	if err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+	hasChanges, err := planForSystemResources(w, resources, st, cfg.noColor)
+	if err != nil {
+		return fmt.Errorf("failed to plan: %w", err)
+	}
+	if hasChanges && !cfg.yes {
+		fmt.Fprint(w, "\nDo you want to continue? [y/N] ")
+		reader := bufio.NewReader(os.Stdin)
+		answer, _ := reader.ReadString('\n')
+		answer = strings.TrimSpace(strings.ToLower(answer))
+		if answer != "y" && answer != "yes" { //nolint:goconst // simple confirmation pattern
+			fmt.Fprintln(w, "Canceled.")
+			return nil
+		}
+	}
+	fmt.Fprintln(w)
+
+	registry := system.NewRegistry()
+	eng := engine.NewSystemEngine(
+		system.NewInstaller(registry),
+		system.NewRepositoryInstaller(registry),
+		system.NewPackageSetInstaller(registry),
+		store,
+	)
+
+	results := &ui.ApplyResults{}
+
+	logStore, err := tomeilog.NewStore(filepath.Join(path.DefaultSystemDataDir, "logs"))
+	if err != nil {
+		slog.Warn("failed to create log store", "error", err)
+	}
+	if logStore != nil {
+		defer logStore.Close()
+	}
+
+	isTTY := isatty.IsTerminal(os.Stdout.Fd()) || isatty.IsCygwinTerminal(os.Stdout.Fd())
+	if isTTY && !cfg.quiet {
+		return runApplyWithTUI(ctx, eng, resources, results, logStore, w, cfg)
+	}
+	return runApplyWithProgressManager(ctx, eng, resources, results, logStore, w, cfg)
+}
+
+// loadSystemStateForPlan loads the system state without requiring root.
+// A missing state directory is treated as an empty state.
+func loadSystemStateForPlan() (*state.SystemState, error) {
+	if _, err := os.Stat(path.DefaultSystemDataDir); errors.Is(err, fs.ErrNotExist) {
+		return state.NewSystemState(), nil
+	}
+	store, err := state.NewStore[state.SystemState](path.DefaultSystemDataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create state store: %w", err)
+	}
+	st, err := store.LoadReadOnly()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load state: %w", err)
+	}
+	return st, nil
+}
+
+// resolveSystemPlan builds the dependency graph of system resources and
+// computes their actions against the system state.
+func resolveSystemPlan(resources []resource.Resource, st *state.SystemState) (*planResult, error) {
+	resolver := graph.NewResolver()
+	for _, res := range resources {
+		resolver.AddResource(res)
+	}
+	layers, err := resolver.Resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	installerActions, repoActions, setActions, err := engine.PlanSystem(resources, st)
+	if err != nil {
+		return nil, err
+	}
+
+	info := make(map[graph.NodeID]graph.ResourceInfo)
+	for _, res := range resources {
+		info[graph.NewNodeID(res.Kind(), res.Name())] = graph.ResourceInfo{
+			Kind:   res.Kind(),
+			Name:   res.Name(),
+			Action: resource.ActionNone,
+		}
+	}
+	addSystemActionInfo(info, resource.KindSystemInstaller, installerActions)
+	addSystemActionInfo(info, resource.KindSystemPackageRepository, repoActions)
+	addSystemActionInfo(info, resource.KindSystemPackageSet, setActions)
+
+	return &planResult{
+		resolver:       resolver,
+		filteredLayers: filterLayers(layers, resources),
+		resourceInfo:   info,
+		edges:          resolver.GetEdges(),
+	}, nil
+}
+
+// addSystemActionInfo records planned actions in the resource info map.
+func addSystemActionInfo[R resource.Resource, S resource.State](
+	info map[graph.NodeID]graph.ResourceInfo,
+	kind resource.Kind,
+	actions []reconciler.Action[R, S],
+) {
+	for _, a := range actions {
+		info[graph.NewNodeID(kind, a.Name)] = graph.ResourceInfo{
+			Kind:   kind,
+			Name:   a.Name,
+			Action: a.Type,
+		}
+	}
+}
+
+// planForSystemResources writes the text plan for system resources to w.
+// It returns true if there are any changes.
+func planForSystemResources(w io.Writer, resources []resource.Resource, st *state.SystemState, disableColor bool) (bool, error) {
+	result, err := resolveSystemPlan(resources, st)
+	if err != nil {
+		return false, err
+	}
+
+	hasChanges := false
+	for _, info := range result.resourceInfo {
+		if info.Action != resource.ActionNone {
+			hasChanges = true
+			break
+		}
+	}
+
+	fmt.Fprintf(w, "Found %d system resource(s)\n\n", len(resources))
+	printer := graph.NewTreePrinter(w, disableColor)
+	printer.PrintTree(result.resolver, result.resourceInfo)
+	printer.PrintLayers(result.filteredLayers, result.resourceInfo)
+	printer.PrintSummary(result.resourceInfo)
+
+	return hasChanges, nil
+}
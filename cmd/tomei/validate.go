@@ -7,6 +7,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/terassyi/tomei/internal/config"
+	"github.com/terassyi/tomei/internal/credential"
 	"github.com/terassyi/tomei/internal/graph"
 	"github.com/terassyi/tomei/internal/resource"
 	"github.com/terassyi/tomei/internal/ui"
@@ -22,6 +23,7 @@ var validateCmd = &cobra.Command{
 Checks for:
   - CUE syntax errors and schema conformance (types, required fields)
   - Spec-level validation (required fields, mutual exclusivity, basic structure)
+  - References to undefined Credential resources (credentialRef)
   - Circular dependency detection in the resource DAG`,
 	Args: cobra.MinimumNArgs(1),
 	RunE: runValidate,
@@ -71,6 +73,16 @@ func runValidate(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("validation failed")
 	}
 
+	// Credentials are not part of the DAG; check that references resolve
+	creds, rest := credential.Split(resources)
+	if err := creds.Check(rest); err != nil {
+		cmd.Println("Credentials:")
+		cmd.Printf("  %s %v\n", style.FailMark, err)
+		cmd.Println()
+		cmd.Printf("%s Validation failed\n", style.FailMark)
+		return err
+	}
+
 	// Check for circular dependencies
 	resolver := graph.NewResolver()
 	for _, res := range resources {
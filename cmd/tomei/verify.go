@@ -0,0 +1,234 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/terassyi/tomei/internal/config"
+	"github.com/terassyi/tomei/internal/doctor"
+	"github.com/terassyi/tomei/internal/path"
+	"github.com/terassyi/tomei/internal/resource"
+	"github.com/terassyi/tomei/internal/state"
+	"github.com/terassyi/tomei/internal/ui"
+)
+
+var (
+	verifyTaint   bool
+	verifyOutput  string
+	verifyNoColor bool
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify installed binaries against the digests recorded at install time",
+	Long: `Verify installed binaries against the digests recorded at install time.
+
+Rehashes every tomei-managed tool binary and runtime install tree and
+reports files that were modified, replaced, removed or added after
+installation. Resources installed before digests were recorded, and tools
+installed through delegation, are listed as unrecorded.
+
+With --taint, drifted resources are marked for reinstallation so that the
+next "tomei apply" restores them.
+
+Exits with a non-zero status when drift is found.
+
+  tomei verify
+  tomei verify --taint && tomei apply
+  tomei verify -o json`,
+	Args: cobra.NoArgs,
+	RunE: runVerify,
+}
+
+func init() {
+	verifyCmd.Flags().BoolVar(&verifyTaint, "taint", false, "Mark drifted resources for reinstallation on the next apply")
+	verifyCmd.Flags().StringVarP(&verifyOutput, "output", "o", "text", "Output format (text, json)")
+	verifyCmd.Flags().BoolVar(&verifyNoColor, "no-color", false, "Disable color output")
+	_ = verifyCmd.RegisterFlagCompletionFunc("output", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return []string{"text", "json"}, cobra.ShellCompDirectiveNoFileComp
+	})
+}
+
+// verifyIssueJSON is the JSON representation of a drifted file.
+type verifyIssueJSON struct {
+	Kind     doctor.DriftKind `json:"kind"`
+	Resource string           `json:"resource"`
+	Path     string           `json:"path"`
+	Detail   string           `json:"detail,omitempty"`
+}
+
+// verifyResultJSON is the JSON representation of a verify run.
+type verifyResultJSON struct {
+	Issues     []verifyIssueJSON `json:"issues"`
+	Verified   []string          `json:"verified"`
+	Unrecorded []string          `json:"unrecorded"`
+	Tainted    []string          `json:"tainted,omitempty"`
+}
+
+func runVerify(cmd *cobra.Command, _ []string) error {
+	if verifyNoColor {
+		color.NoColor = true
+	}
+	if verifyOutput != "text" && verifyOutput != "json" {
+		return fmt.Errorf("unsupported output format %q (use text or json)", verifyOutput)
+	}
+
+	cfg, err := config.LoadConfig(config.DefaultConfigDir)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	paths, err := path.NewFromConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create paths: %w", err)
+	}
+	store, err := state.NewStore[state.UserState](paths.UserDataDir())
+	if err != nil {
+		return fmt.Errorf("failed to create state store: %w", err)
+	}
+
+	if err := store.Lock(); err != nil {
+		return fmt.Errorf("failed to lock state: %w", err)
+	}
+	defer func() { _ = store.Unlock() }()
+
+	userState, err := store.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	doc, err := doctor.New(paths, userState)
+	if err != nil {
+		return fmt.Errorf("failed to create doctor: %w", err)
+	}
+	report, err := doc.CheckDrift(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("verify failed: %w", err)
+	}
+
+	drifted := report.Drifted()
+	var tainted []string
+	if verifyTaint && len(drifted) > 0 {
+		tainted = taintDrifted(userState, drifted)
+		if err := store.Save(userState); err != nil {
+			return fmt.Errorf("failed to save state: %w", err)
+		}
+	}
+
+	switch verifyOutput {
+	case "json":
+		if err := printVerifyJSON(cmd, report, tainted); err != nil {
+			return err
+		}
+	default:
+		printVerifyText(cmd, report, tainted)
+	}
+
+	if len(drifted) > 0 {
+		return fmt.Errorf("%d resource(s) drifted from their recorded digests", len(drifted))
+	}
+	return nil
+}
+
+// taintDrifted marks the drifted resources ("Kind/name") in state for
+// reinstallation and returns the ones that were tainted.
+func taintDrifted(userState *state.UserState, drifted []string) []string {
+	var tainted []string
+	for _, key := range drifted {
+		kind, name, _ := strings.Cut(key, "/")
+		switch resource.Kind(kind) {
+		case resource.KindTool:
+			if ts, ok := userState.Tools[name]; ok {
+				ts.Taint(resource.TaintReasonDrifted)
+				tainted = append(tainted, key)
+			}
+		case resource.KindRuntime:
+			if rs, ok := userState.Runtimes[name]; ok {
+				rs.Taint(resource.TaintReasonDrifted)
+				tainted = append(tainted, key)
+			}
+		}
+	}
+	return tainted
+}
+
+func printVerifyJSON(cmd *cobra.Command, report *doctor.DriftReport, tainted []string) error {
+	out := verifyResultJSON{
+		Issues:     make([]verifyIssueJSON, 0, len(report.Issues)),
+		Verified:   append([]string{}, report.Verified...),
+		Unrecorded: append([]string{}, report.Unrecorded...),
+		Tainted:    tainted,
+	}
+	for _, issue := range report.Issues {
+		out.Issues = append(out.Issues, verifyIssueJSON{
+			Kind:     issue.Kind,
+			Resource: string(issue.ResourceKind) + "/" + issue.Name,
+			Path:     issue.Path,
+			Detail:   issue.Detail,
+		})
+	}
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal verify result: %w", err)
+	}
+	cmd.Println(string(data))
+	return nil
+}
+
+func printVerifyText(cmd *cobra.Command, report *doctor.DriftReport, tainted []string) {
+	style := ui.NewStyle()
+
+	style.Header.Fprintln(cmd.OutOrStdout(), "Installed File Verification")
+	cmd.Println()
+
+	if len(report.Issues) > 0 {
+		current := ""
+		for _, issue := range report.Issues {
+			key := string(issue.ResourceKind) + "/" + issue.Name
+			if key != current {
+				if current != "" {
+					cmd.Println()
+				}
+				cmd.Printf("[%s]\n", color.New(color.FgRed).Sprint(key))
+				current = key
+			}
+			cmd.Printf("  %s %-10s %s", style.FailMark, issue.Kind, style.Path.Sprint(issue.Path))
+			if issue.Detail != "" {
+				cmd.Printf(" (%s)", issue.Detail)
+			}
+			cmd.Println()
+		}
+		cmd.Println()
+	}
+
+	summary := []string{color.New(color.FgGreen).Sprintf("%d verified", len(report.Verified))}
+	if drifted := report.Drifted(); len(drifted) > 0 {
+		summary = append(summary, color.New(color.FgRed).Sprintf("%d drifted", len(drifted)))
+	}
+	if len(report.Unrecorded) > 0 {
+		summary = append(summary, color.New(color.FgYellow).Sprintf("%d unrecorded", len(report.Unrecorded)))
+	}
+	cmd.Printf("Summary: %s\n", strings.Join(summary, ", "))
+
+	if len(report.Unrecorded) > 0 {
+		cmd.Println()
+		cmd.Printf("%s No digests recorded for %s; they are recorded on the next install or upgrade.\n",
+			style.WarnMark, strings.Join(report.Unrecorded, ", "))
+	}
+
+	switch {
+	case len(tainted) > 0:
+		cmd.Println()
+		cmd.Printf("%s Marked %s for reinstallation. Run 'tomei apply' to restore them.\n",
+			style.UpgradeMark, strings.Join(tainted, ", "))
+	case len(report.Issues) > 0:
+		cmd.Println()
+		cmd.Println("Run 'tomei verify --taint' and 'tomei apply', or 'tomei doctor --fix', to restore them.")
+	default:
+		cmd.Println()
+		cmd.Printf("%s All recorded files match.\n", style.SuccessMark)
+	}
+}
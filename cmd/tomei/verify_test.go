@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/terassyi/tomei/internal/resource"
+	"github.com/terassyi/tomei/internal/state"
+)
+
+func TestTaintDrifted(t *testing.T) {
+	t.Parallel()
+
+	userState := &state.UserState{
+		Tools: map[string]*resource.ToolState{
+			"kubectl": {Version: "1.31.0"},
+			"rg":      {Version: "14.1.0"},
+		},
+		Runtimes: map[string]*resource.RuntimeState{
+			"go": {Version: "1.25.5"},
+		},
+	}
+
+	tainted := taintDrifted(userState, []string{"Runtime/go", "Tool/kubectl", "Tool/removed"})
+	assert.Equal(t, []string{"Runtime/go", "Tool/kubectl"}, tainted)
+	assert.Equal(t, resource.TaintReasonDrifted, userState.Runtimes["go"].TaintReason)
+	assert.Equal(t, resource.TaintReasonDrifted, userState.Tools["kubectl"].TaintReason)
+	assert.False(t, userState.Tools["rg"].IsTainted())
+}
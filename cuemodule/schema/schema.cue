@@ -26,7 +26,7 @@ package schema
 #DownloadSource: {
 	url:          #HTTPSURL
 	checksum?:    #Checksum
-	archiveType?: "tar.gz" | "tar.xz" | "zip" | "raw" | "pkg"
+	archiveType?: "tar.gz" | "tar.xz" | "tar.bz2" | "tar.zst" | "zip" | "7z" | "gz" | "xz" | "zst" | "bz2" | "raw" | "pkg"
 	asset?:       string
 }
 
@@ -36,6 +36,15 @@ package schema
 	remove?: [...string]
 }
 
+// DelegationCommandSet extends CommandSet with batch installation for the
+// commands that install tools through a runtime or installer.
+#DelegationCommandSet: {
+	install: [...string] & [_, ...]
+	check?: [...string]
+	remove?: [...string]
+	batchInstall?: [...string]
+}
+
 // RuntimeBootstrap extends CommandSet with update and version resolution support.
 #RuntimeBootstrap: {
 	install: [...string] & [_, ...]
@@ -81,10 +90,12 @@ package schema
 		bootstrap?:   #RuntimeBootstrap
 		binaries?: [...string]
 		binDir?:   string
-		commands?: #CommandSet
+		commands?: #DelegationCommandSet
 		env?: {[string]: string}
 		taintOnUpgrade?: bool
 		resolveVersion?: [...string]
+		extraVersions?: [...string & !=""]
+		credentialRef?: string
 
 		// Conditional required fields
 		if type == "download" {
@@ -118,12 +129,16 @@ package schema
 		// dependsOn declares additional tool dependencies for DAG ordering only.
 		// Unlike toolRef, these tools are NOT added to PATH.
 		dependsOn?: [...string]
-		bootstrap?: #CommandSet
-		commands?:  #CommandSet
+		bootstrap?:     #CommandSet
+		commands?:      #DelegationCommandSet
+		credentialRef?: string
+		// signaturePolicy enforces upstream signatures declared by aqua-registry
+		// for tools downloaded by this installer (default: "warn").
+		signaturePolicy?: "require" | "warn" | "off"
 
 		// Conditional required fields
 		if type == "delegation" {
-			commands: #CommandSet
+			commands: #DelegationCommandSet
 			binDir?:  string & =~"^(~/|/)"
 		}
 	}
@@ -170,6 +185,7 @@ package schema
 		commands?:      #ToolCommandSet
 		binaryName?:    string & =~"^[a-zA-Z0-9][a-zA-Z0-9._-]*$"
 		args?: [...string]
+		credentialRef?: string
 	}
 }
 
@@ -181,6 +197,7 @@ package schema
 		installerRef?:  string
 		runtimeRef?:    string
 		repositoryRef?: string
+		credentialRef?: string
 		tools: {[string]: {
 			version?:    string
 			enabled?:    bool
@@ -192,14 +209,25 @@ package schema
 	}
 }
 
+// SystemCommand is invoked as "<command> <verb> <packages...>".
+#SystemCommand: {
+	command: string & !=""
+	verb:    string
+}
+
 #SystemInstaller: {
 	apiVersion: #APIVersion
 	kind:       "SystemInstaller"
 	metadata:   #Metadata
 	spec: {
-		pattern:    string & !=""
+		pattern:    "delegation"
 		privileged: bool
-		commands: {...}
+		commands: {
+			install: #SystemCommand
+			remove:  #SystemCommand
+			check?:  #SystemCommand
+			update?: string
+		}
 	}
 }
 
@@ -209,7 +237,16 @@ package schema
 	metadata:   #Metadata
 	spec: {
 		installerRef: string & !=""
-		source: {url: #HTTPSURL, ...}
+		// With the builtin apt installer, the key is verified against keyHash,
+		// written to /etc/apt/keyrings and referenced from a deb822 .sources file
+		// whose fields come from options (suites, components, architectures, ...).
+		source: {
+			url:      #HTTPSURL
+			keyUrl?:  #HTTPSURL
+			keyHash?: string & =~"^sha(256|512):[a-f0-9]+$"
+			options?: {[string]: string}
+			...
+		}
 	}
 }
 
@@ -224,5 +261,30 @@ package schema
 	}
 }
 
-#Resource: #Runtime | #Installer | #InstallerRepository | #Tool | #ToolSet |
+// Credential authenticates downloads for the Tools, Installers and Runtimes
+// that reference it via credentialRef. The secret is read from exactly one of
+// envVar, file or command at install time.
+#Credential: {
+	apiVersion: #APIVersion
+	kind:       "Credential"
+	metadata:   #Metadata
+	spec: {
+		type: "github" | "bearer" | "basic"
+		hosts?: [...string & =~"^[^/ ]+$"]
+		username?: string & !=""
+		envVar?:   string & !=""
+		file?:     string & !=""
+		command?:  string & !=""
+
+		// Conditional required fields
+		if type == "basic" {
+			username: string & !=""
+		}
+		if type != "github" {
+			hosts: [string, ...string]
+		}
+	}
+}
+
+#Resource: #Runtime | #Installer | #InstallerRepository | #Tool | #ToolSet | #Credential |
 	#SystemInstaller | #SystemPackageRepository | #SystemPackageSet
@@ -40,6 +40,7 @@ func TestSchema_Definitions_Exist(t *testing.T) {
 		"#InstallerRepository",
 		"#Tool",
 		"#ToolSet",
+		"#Credential",
 		"#SystemInstaller",
 		"#SystemPackageRepository",
 		"#SystemPackageSet",
@@ -375,6 +376,33 @@ func TestSchema_ValidResources(t *testing.T) {
 				}
 			}`,
 		},
+		{
+			name: "Credential for GitHub Enterprise",
+			cue: `{
+				apiVersion: "tomei.terassyi.net/v1beta1"
+				kind:       "Credential"
+				metadata: name: "corp"
+				spec: {
+					type: "github"
+					hosts: ["github.example.com"]
+					command: "gh auth token --hostname github.example.com"
+				}
+			}`,
+		},
+		{
+			name: "Tool with credentialRef",
+			cue: `{
+				apiVersion: "tomei.terassyi.net/v1beta1"
+				kind:       "Tool"
+				metadata: name: "internal-cli"
+				spec: {
+					installerRef:  "download"
+					version:       "1.0.0"
+					credentialRef: "corp"
+					source: url: "https://github.com/example/internal-cli/releases/download/v1.0.0/internal-cli.tar.gz"
+				}
+			}`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -587,6 +615,31 @@ func TestSchema_InvalidResources(t *testing.T) {
 				}
 			}`,
 		},
+		{
+			name: "Credential basic without username",
+			cue: `{
+				apiVersion: "tomei.terassyi.net/v1beta1"
+				kind:       "Credential"
+				metadata: name: "artifacts"
+				spec: {
+					type: "basic"
+					hosts: ["artifacts.example.com"]
+					envVar: "ARTIFACTS_PASSWORD"
+				}
+			}`,
+		},
+		{
+			name: "Credential bearer without hosts",
+			cue: `{
+				apiVersion: "tomei.terassyi.net/v1beta1"
+				kind:       "Credential"
+				metadata: name: "artifacts"
+				spec: {
+					type:   "bearer"
+					envVar: "ARTIFACTS_TOKEN"
+				}
+			}`,
+		},
 		{
 			name: "invalid InstallerRepository source type",
 			cue: `{
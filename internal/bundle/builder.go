@@ -0,0 +1,359 @@
+package bundle
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/terassyi/tomei/internal/checksum"
+	"github.com/terassyi/tomei/internal/credential"
+	"github.com/terassyi/tomei/internal/installer/download"
+	"github.com/terassyi/tomei/internal/installer/runtime"
+	"github.com/terassyi/tomei/internal/installer/tool"
+	"github.com/terassyi/tomei/internal/lockfile"
+	"github.com/terassyi/tomei/internal/registry/aqua"
+	"github.com/terassyi/tomei/internal/resource"
+	"github.com/terassyi/tomei/internal/verify"
+)
+
+// Builder resolves and downloads the artifacts of a set of resources through
+// a Recorder and writes them into a bundle.
+type Builder struct {
+	workDir     string
+	recorder    *Recorder
+	downloader  download.Downloader
+	tools       *tool.Installer
+	runtimes    *runtime.Installer
+	signatures  *verify.ArtifactVerifier
+	lock        *lockfile.Lockfile
+	unsupported []Unsupported
+}
+
+// NewBuilder creates a Builder that stages files in workDir and sends
+// requests through base. Resources are resolved with the aqua registry ref
+// and the pins of lock; the resolved versions are recorded back into lock.
+func NewBuilder(workDir string, base http.RoundTripper, lock *lockfile.Lockfile, aquaRef string) *Builder {
+	recorder := NewRecorder(base, workDir)
+	client := &http.Client{Transport: recorder}
+	downloader := download.NewDownloaderWithClient(client)
+
+	// Use an empty registry cache so that every registry definition is
+	// fetched, and therefore recorded, through the client.
+	toolInstaller := tool.NewInstaller(downloader, nil)
+	toolInstaller.SetResolver(aqua.NewResolver(filepath.Join(workDir, "registry"), client), aqua.RegistryRef(aquaRef))
+	// Signature files and transparency log lookups are recorded too, so that
+	// apply --bundle verifies the same signatures offline.
+	signatures := verify.NewArtifactVerifier(client)
+	toolInstaller.SetSignatureVerifier(signatures)
+	runtimeInstaller := runtime.NewInstaller(downloader, filepath.Join(workDir, "runtimes"))
+	runtimeInstaller.SetHTTPClient(client)
+
+	lock.Registry = &lockfile.Registry{Aqua: aquaRef}
+	if lock.Tools == nil {
+		lock.Tools = make(map[string]*lockfile.Entry)
+	}
+	if lock.Runtimes == nil {
+		lock.Runtimes = make(map[string]*lockfile.Entry)
+	}
+
+	return &Builder{
+		workDir:    workDir,
+		recorder:   recorder,
+		downloader: downloader,
+		tools:      toolInstaller,
+		runtimes:   runtimeInstaller,
+		signatures: signatures,
+		lock:       lock,
+	}
+}
+
+// Add resolves and downloads every download-pattern Tool and Runtime in
+// resources. Resources that need the network at install time are recorded
+// as unsupported instead. Credential resources authenticate the downloads
+// of the resources that reference them.
+func (b *Builder) Add(ctx context.Context, resources []resource.Resource) error {
+	creds, resources := credential.Split(resources)
+	if err := creds.Check(resources); err != nil {
+		return err
+	}
+	b.tools.SetCredentials(creds)
+	b.runtimes.SetCredentials(creds)
+
+	toolPins, runtimePins := b.lock.Pins(resources)
+	b.tools.SetPins(toolPins)
+	b.runtimes.SetPins(runtimePins)
+
+	delegation := make(map[string]bool)
+	for _, res := range resources {
+		if inst, ok := res.(*resource.Installer); ok && inst.InstallerSpec != nil {
+			b.tools.RegisterInstaller(inst.Name(), &tool.InstallerInfo{
+				Type:            inst.InstallerSpec.Type,
+				CredentialRef:   inst.InstallerSpec.CredentialRef,
+				SignaturePolicy: inst.InstallerSpec.SignaturePolicy,
+			})
+			if inst.InstallerSpec.Type == resource.InstallTypeDelegation {
+				delegation[inst.Name()] = true
+			}
+		}
+	}
+
+	for _, res := range resources {
+		if reason := unsupportedReason(res, delegation); reason != "" {
+			b.unsupported = append(b.unsupported, Unsupported{Kind: res.Kind(), Name: res.Name(), Reason: reason})
+			continue
+		}
+		switch r := res.(type) {
+		case *resource.Tool:
+			if err := b.addTool(ctx, r); err != nil {
+				return fmt.Errorf("tool %s: %w", r.Name(), err)
+			}
+		case *resource.Runtime:
+			if err := b.addRuntime(ctx, r); err != nil {
+				return fmt.Errorf("runtime %s: %w", r.Name(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// Unsupported returns the resources that cannot be installed from the bundle.
+func (b *Builder) Unsupported() []Unsupported {
+	return b.unsupported
+}
+
+// unsupportedReason returns why res cannot be installed offline, or an
+// empty string if it can (or needs nothing from the network).
+func unsupportedReason(res resource.Resource, delegation map[string]bool) string {
+	switch r := res.(type) {
+	case *resource.Tool:
+		spec := r.ToolSpec
+		switch {
+		case spec == nil:
+			return ""
+		case spec.Commands != nil:
+			return "installed by commands"
+		case spec.RuntimeRef != "":
+			return fmt.Sprintf("installed by runtime delegation (%s)", spec.RuntimeRef)
+		case delegation[spec.InstallerRef]:
+			return fmt.Sprintf("installed by installer delegation (%s)", spec.InstallerRef)
+		}
+	case *resource.Runtime:
+		if r.RuntimeSpec != nil && r.RuntimeSpec.Type == resource.InstallTypeDelegation {
+			return "installed by delegation"
+		}
+	case *resource.Installer:
+		if r.InstallerSpec != nil && r.InstallerSpec.Bootstrap != nil {
+			return "installed by bootstrap commands"
+		}
+	case *resource.InstallerRepository:
+		return "repositories are fetched at install time"
+	}
+	return ""
+}
+
+func (b *Builder) addTool(ctx context.Context, res *resource.Tool) error {
+	ctx, err := b.tools.CredentialContext(ctx, res)
+	if err != nil {
+		return err
+	}
+	spec, sigs, err := b.tools.ResolveSource(ctx, res, res.Name())
+	if err != nil {
+		return err
+	}
+	verifyArchive := func(archivePath string) error {
+		return b.tools.VerifyArchive(ctx, res, sigs, archivePath)
+	}
+	digest, err := b.fetch(ctx, res.Name(), spec.Source.URL, spec.Source.Checksum, b.toolPin(res.Name(), spec.Version), verifyArchive)
+	if err != nil {
+		return err
+	}
+	b.pin(b.lock.Tools, res.Name(), res.ToolSpec.Version, spec.Version, spec.Source.URL, digest)
+	slog.Debug("bundled tool", "name", res.Name(), "version", spec.Version, "url", spec.Source.URL)
+	return nil
+}
+
+func (b *Builder) addRuntime(ctx context.Context, res *resource.Runtime) error {
+	ctx, err := b.runtimes.CredentialContext(ctx, res)
+	if err != nil {
+		return err
+	}
+	src, err := b.runtimes.ResolveSource(ctx, res.RuntimeSpec, res.Name())
+	if err != nil {
+		return err
+	}
+	digest, err := b.fetch(ctx, res.Name(), src.URL, src.Checksum, b.runtimePin(res.Name(), src.Version), nil)
+	if err != nil {
+		return err
+	}
+	b.pin(b.lock.Runtimes, res.Name(), res.RuntimeSpec.Version, src.Version, src.URL, digest)
+	slog.Debug("bundled runtime", "name", res.Name(), "version", src.Version, "url", src.URL)
+	return nil
+}
+
+func (b *Builder) toolPin(name, version string) checksum.Digest {
+	return pinnedDigest(b.lock.Tools[name], version)
+}
+
+func (b *Builder) runtimePin(name, version string) checksum.Digest {
+	return pinnedDigest(b.lock.Runtimes[name], version)
+}
+
+// pinnedDigest returns the digest of e if it pins version.
+func pinnedDigest(e *lockfile.Entry, version string) checksum.Digest {
+	if e == nil || e.Version != version {
+		return ""
+	}
+	if a := e.Artifact(lockfile.Platform()); a != nil {
+		return a.Digest
+	}
+	return ""
+}
+
+// fetch downloads url through the recorder and verifies it against cs, which
+// also records the checksum file. The archive must match pinned when set,
+// and verifyArchive (if set) checks its signatures. Returns the archive digest.
+func (b *Builder) fetch(ctx context.Context, name, url string, cs *resource.Checksum, pinned checksum.Digest, verifyArchive func(archivePath string) error) (checksum.Digest, error) {
+	tmpDir, err := os.MkdirTemp(b.workDir, "download-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	archivePath := filepath.Join(tmpDir, filepath.Base(url))
+	if _, err := b.downloader.Download(ctx, url, archivePath); err != nil {
+		return "", fmt.Errorf("failed to download: %w", err)
+	}
+	if err := b.downloader.Verify(ctx, archivePath, cs); err != nil {
+		return "", fmt.Errorf("failed to verify checksum: %w", err)
+	}
+	if pinned != "" {
+		if err := checksum.Verify(archivePath, checksum.DetectAlgorithm(string(pinned)), pinned); err != nil {
+			return "", fmt.Errorf("archive does not match digest pinned in lockfile: %w", err)
+		}
+	}
+	if verifyArchive != nil {
+		if err := verifyArchive(archivePath); err != nil {
+			return "", err
+		}
+	}
+	if pinned != "" {
+		return pinned, nil
+	}
+	digest, err := checksum.Calculate(archivePath, checksum.AlgorithmSHA256)
+	if err != nil {
+		return "", fmt.Errorf("failed to calculate archive digest for %s: %w", name, err)
+	}
+	return digest, nil
+}
+
+// pin records a resolved version in the lockfile. Versions that are not
+// exact (e.g., unresolved aliases) are not pinned, as in lockfile.Update.
+func (b *Builder) pin(entries map[string]*lockfile.Entry, name, specVersion, version, url string, digest checksum.Digest) {
+	if e := lockfile.NewEntry(specVersion, version, url, digest, entries[name]); e != nil {
+		entries[name] = e
+	}
+}
+
+// Write writes the bundle to dest as a tar archive.
+func (b *Builder) Write(dest string) error {
+	files := b.recorder.Files()
+	manifest := &Manifest{
+		Version:     FormatVersion,
+		CreatedAt:   time.Now().UTC().Truncate(time.Second),
+		Platform:    CurrentPlatform(),
+		Files:       files,
+		Unsupported: b.unsupported,
+	}
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal bundle manifest: %w", err)
+	}
+	lockPath := filepath.Join(b.workDir, lockfile.FileName)
+	if err := b.lock.Save(lockPath); err != nil {
+		return err
+	}
+
+	tmpPath := dest + ".tmp"
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("failed to create bundle: %w", err)
+	}
+	defer os.Remove(tmpPath)
+
+	tw := tar.NewWriter(out)
+	if err := writeTarBytes(tw, manifestFileName, manifestData, manifest.CreatedAt); err != nil {
+		out.Close()
+		return err
+	}
+	if err := writeTarFile(tw, lockfile.FileName, lockPath); err != nil {
+		out.Close()
+		return err
+	}
+	if trustedRoot := b.signatures.LoadedTrustedRoot(); trustedRoot != nil {
+		if err := writeTarBytes(tw, trustedRootFileName, trustedRoot, manifest.CreatedAt); err != nil {
+			out.Close()
+			return err
+		}
+	}
+	names := make([]string, 0, len(files))
+	for _, name := range files {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range slices.Compact(names) {
+		if err := writeTarFile(tw, filesDirName+"/"+name, filepath.Join(b.recorder.dir, name)); err != nil {
+			out.Close()
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to finish bundle: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("failed to close bundle: %w", err)
+	}
+	if err := os.Rename(tmpPath, dest); err != nil {
+		return fmt.Errorf("failed to rename bundle: %w", err)
+	}
+	return nil
+}
+
+func writeTarBytes(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: modTime}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+func writeTarFile(tw *tar.Writer, name, src string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", name, err)
+	}
+	hdr := &tar.Header{Name: name, Mode: 0644, Size: info.Size(), ModTime: info.ModTime()}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if _, err := io.Copy(tw, f); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
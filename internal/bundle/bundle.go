@@ -0,0 +1,278 @@
+// Package bundle creates and opens offline bundles for air-gapped machines.
+//
+// A bundle is a tar archive that holds every HTTP response needed to install
+// the download-pattern Tools and Runtimes of a set of manifests (archives,
+// checksum files, aqua registry definitions and the upstream signatures of
+// the archives), together with a tomei.lock that pins the resolved versions:
+//
+//	bundle.json        manifest: platform, request -> file index, unsupported resources
+//	tomei.lock         resolved versions, URLs and digests
+//	trusted_root.json  Sigstore trusted root that signatures were verified against (optional)
+//	files/<sha256>     recorded response bodies, keyed by the sha256 of the request key
+//
+// "tomei apply --bundle" serves the recorded responses through Transport, so
+// the regular resolver, downloader, checksum and signature verification run
+// unchanged without network access.
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/terassyi/tomei/internal/lockfile"
+	"github.com/terassyi/tomei/internal/resource"
+)
+
+// FormatVersion is the current bundle format version.
+const FormatVersion = "1"
+
+const (
+	manifestFileName    = "bundle.json"
+	trustedRootFileName = "trusted_root.json"
+	filesDirName        = "files"
+)
+
+// Manifest describes the contents of a bundle.
+type Manifest struct {
+	Version   string    `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+	// Platform is the OS/architecture the artifacts were resolved for (e.g., "linux/amd64").
+	Platform string `json:"platform"`
+	// Files maps each recorded request to its file name under files/. GET
+	// requests are keyed by their URL, and POST requests by
+	// "POST <url> <sha256 of the body>".
+	Files map[string]string `json:"files"`
+	// Unsupported lists the resources that cannot be installed from the bundle.
+	Unsupported []Unsupported `json:"unsupported,omitempty"`
+}
+
+// Unsupported is a resource that cannot be installed offline.
+type Unsupported struct {
+	Kind   resource.Kind `json:"kind"`
+	Name   string        `json:"name"`
+	Reason string        `json:"reason"`
+}
+
+// CurrentPlatform returns the platform string of the running binary.
+func CurrentPlatform() string {
+	return runtime.GOOS + "/" + runtime.GOARCH
+}
+
+// fileKey returns the file name under files/ for a request key.
+func fileKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// requestKey returns the key that a request is recorded under: the URL of
+// a GET, or "POST <url> <sha256 of the body>". The body of a POST is read,
+// so the returned request carries a copy of it.
+func requestKey(req *http.Request) (string, *http.Request, error) {
+	url := req.URL.String()
+	if req.Method != http.MethodPost {
+		return url, req, nil
+	}
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to read request body of %s: %w", url, err)
+		}
+	}
+	clone := req.Clone(req.Context())
+	clone.Body = io.NopCloser(bytes.NewReader(body))
+	clone.ContentLength = int64(len(body))
+	sum := sha256.Sum256(body)
+	return http.MethodPost + " " + url + " " + hex.EncodeToString(sum[:]), clone, nil
+}
+
+// Bundle is an opened bundle extracted to a temporary directory.
+type Bundle struct {
+	dir      string
+	Manifest *Manifest
+	Lock     *lockfile.Lockfile
+}
+
+// Open extracts the bundle at path and reads its manifest and lockfile.
+// The caller must call Close to remove the extracted files.
+func Open(path string) (*Bundle, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bundle: %w", err)
+	}
+	defer f.Close()
+
+	dir, err := os.MkdirTemp("", "tomei-bundle-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	b := &Bundle{dir: dir}
+	if err := b.extract(f); err != nil {
+		b.Close()
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, manifestFileName))
+	if err != nil {
+		b.Close()
+		return nil, fmt.Errorf("invalid bundle %s: %s is missing", path, manifestFileName)
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		b.Close()
+		return nil, fmt.Errorf("failed to parse bundle manifest: %w", err)
+	}
+	if m.Version != FormatVersion {
+		b.Close()
+		return nil, fmt.Errorf("unsupported bundle version %q (expected %q)", m.Version, FormatVersion)
+	}
+	b.Manifest = &m
+
+	lock, err := lockfile.Load(filepath.Join(dir, lockfile.FileName))
+	if err != nil {
+		b.Close()
+		return nil, err
+	}
+	b.Lock = lock
+	return b, nil
+}
+
+// extract unpacks the tar stream into the bundle directory. Only the
+// manifest, the lockfile, the trusted root and flat files under files/ are
+// accepted.
+func (b *Bundle) extract(r io.Reader) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read bundle: %w", err)
+		}
+		if hdr.Typeflag == tar.TypeDir {
+			continue
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			return fmt.Errorf("invalid bundle entry %q: not a regular file", hdr.Name)
+		}
+		if !validEntryName(hdr.Name) {
+			return fmt.Errorf("invalid bundle entry %q", hdr.Name)
+		}
+
+		dest := filepath.Join(b.dir, filepath.FromSlash(hdr.Name))
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+		out, err := os.Create(dest)
+		if err != nil {
+			return fmt.Errorf("failed to create file: %w", err)
+		}
+		if _, err := io.Copy(out, tr); err != nil {
+			out.Close()
+			return fmt.Errorf("failed to extract %s: %w", hdr.Name, err)
+		}
+		if err := out.Close(); err != nil {
+			return fmt.Errorf("failed to close file: %w", err)
+		}
+	}
+}
+
+// validEntryName reports whether name is a file that a bundle may contain.
+func validEntryName(name string) bool {
+	if name == manifestFileName || name == lockfile.FileName || name == trustedRootFileName {
+		return true
+	}
+	key, ok := strings.CutPrefix(name, filesDirName+"/")
+	if !ok || len(key) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(key)
+	return err == nil
+}
+
+// Close removes the extracted bundle files.
+func (b *Bundle) Close() error {
+	return os.RemoveAll(b.dir)
+}
+
+// CheckPlatform returns an error if the bundle was created for another platform.
+func (b *Bundle) CheckPlatform() error {
+	if b.Manifest.Platform != CurrentPlatform() {
+		return fmt.Errorf("bundle was created for %s, but this machine is %s", b.Manifest.Platform, CurrentPlatform())
+	}
+	return nil
+}
+
+// TrustedRootPath returns the path of the Sigstore trusted root recorded in
+// the bundle. The file does not exist if no Sigstore signature was verified
+// when the bundle was created.
+func (b *Bundle) TrustedRootPath() string {
+	return filepath.Join(b.dir, trustedRootFileName)
+}
+
+// Transport returns an http.RoundTripper that serves the recorded responses
+// and fails every other request, so that nothing reaches the network.
+func (b *Bundle) Transport() http.RoundTripper {
+	return &offlineTransport{dir: filepath.Join(b.dir, filesDirName), files: b.Manifest.Files}
+}
+
+// offlineTransport serves GET and POST requests from the files recorded in
+// a bundle.
+type offlineTransport struct {
+	dir   string
+	files map[string]string
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *offlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	url := req.URL.String()
+	if req.Method != http.MethodGet && req.Method != http.MethodPost {
+		return nil, fmt.Errorf("%s %s is not in the offline bundle", req.Method, url)
+	}
+	key, _, err := requestKey(req)
+	if err != nil {
+		return nil, err
+	}
+	name, ok := t.files[key]
+	if !ok {
+		if req.Method == http.MethodPost {
+			return nil, fmt.Errorf("POST %s is not in the offline bundle", url)
+		}
+		return nil, fmt.Errorf("%s is not in the offline bundle", url)
+	}
+	f, err := os.Open(filepath.Join(t.dir, name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bundled file for %s: %w", url, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to stat bundled file for %s: %w", url, err)
+	}
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        make(http.Header),
+		Body:          f,
+		ContentLength: info.Size(),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,344 @@
+package bundle
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/terassyi/tomei/internal/installer/download"
+	"github.com/terassyi/tomei/internal/lockfile"
+	"github.com/terassyi/tomei/internal/resource"
+)
+
+func newArtifactServer(t *testing.T, files map[string]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = io.WriteString(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestBuilder_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	toolArchive := "tool archive"
+	runtimeArchive := "runtime archive"
+	srv := newArtifactServer(t, map[string]string{
+		"/jq/jq-1.7.1.tar.gz":      toolArchive,
+		"/jq/checksums.txt":        fmt.Sprintf("%s  jq-1.7.1.tar.gz\n", sha256Hex(toolArchive)),
+		"/go/go1.25.5.tar.gz":      runtimeArchive,
+		"/go/go1.25.5.tar.gz.hash": sha256Hex(runtimeArchive),
+	})
+
+	resources := []resource.Resource{
+		&resource.Tool{
+			BaseResource: resource.BaseResource{ResourceKind: resource.KindTool, Metadata: resource.Metadata{Name: "jq"}},
+			ToolSpec: &resource.ToolSpec{
+				InstallerRef: "download",
+				Version:      "1.7.1",
+				Source: &resource.DownloadSource{
+					URL:      srv.URL + "/jq/jq-1.7.1.tar.gz",
+					Checksum: &resource.Checksum{URL: srv.URL + "/jq/checksums.txt"},
+				},
+			},
+		},
+		&resource.Runtime{
+			BaseResource: resource.BaseResource{ResourceKind: resource.KindRuntime, Metadata: resource.Metadata{Name: "go"}},
+			RuntimeSpec: &resource.RuntimeSpec{
+				Type:    resource.InstallTypeDownload,
+				Version: "1.25.5",
+				Source: &resource.DownloadSource{
+					URL:      srv.URL + "/go/go{{.Version}}.tar.gz",
+					Checksum: &resource.Checksum{URL: srv.URL + "/go/go{{.Version}}.tar.gz.hash"},
+				},
+			},
+		},
+		&resource.Tool{
+			BaseResource: resource.BaseResource{ResourceKind: resource.KindTool, Metadata: resource.Metadata{Name: "gopls"}},
+			ToolSpec:     &resource.ToolSpec{RuntimeRef: "go", Package: &resource.Package{Name: "golang.org/x/tools/gopls"}},
+		},
+		&resource.Runtime{
+			BaseResource: resource.BaseResource{ResourceKind: resource.KindRuntime, Metadata: resource.Metadata{Name: "rust"}},
+			RuntimeSpec:  &resource.RuntimeSpec{Type: resource.InstallTypeDelegation, Version: "stable"},
+		},
+	}
+
+	workDir := t.TempDir()
+	builder := NewBuilder(workDir, http.DefaultTransport, lockfile.New(), "v4.465.0")
+	require.NoError(t, builder.Add(context.Background(), resources))
+	assert.Equal(t, []Unsupported{
+		{Kind: resource.KindTool, Name: "gopls", Reason: "installed by runtime delegation (go)"},
+		{Kind: resource.KindRuntime, Name: "rust", Reason: "installed by delegation"},
+	}, builder.Unsupported())
+
+	bundlePath := filepath.Join(t.TempDir(), "bundle.tar")
+	require.NoError(t, builder.Write(bundlePath))
+
+	b, err := Open(bundlePath)
+	require.NoError(t, err)
+	defer b.Close()
+
+	require.NoError(t, b.CheckPlatform())
+	assert.Len(t, b.Manifest.Files, 4)
+	assert.Len(t, b.Manifest.Unsupported, 2)
+	assert.Equal(t, "v4.465.0", b.Lock.AquaRef())
+	require.Contains(t, b.Lock.Tools, "jq")
+	assert.Equal(t, "1.7.1", b.Lock.Tools["jq"].Version)
+	assert.Equal(t, sha256Hex(toolArchive), string(b.Lock.Tools["jq"].Artifact(lockfile.Platform()).Digest))
+	require.Contains(t, b.Lock.Runtimes, "go")
+	assert.Equal(t, srv.URL+"/go/go1.25.5.tar.gz", b.Lock.Runtimes["go"].Artifact(lockfile.Platform()).URL)
+
+	// The bundle serves the recorded artifacts without the server.
+	srv.Close()
+	dl := download.NewDownloaderWithClient(&http.Client{Transport: b.Transport()})
+	dest := filepath.Join(t.TempDir(), "go1.25.5.tar.gz")
+	_, err = dl.Download(context.Background(), srv.URL+"/go/go1.25.5.tar.gz", dest)
+	require.NoError(t, err)
+	data, err := os.ReadFile(dest)
+	require.NoError(t, err)
+	assert.Equal(t, runtimeArchive, string(data))
+	require.NoError(t, dl.Verify(context.Background(), dest, &resource.Checksum{URL: srv.URL + "/go/go1.25.5.tar.gz.hash"}))
+
+	_, err = dl.Download(context.Background(), srv.URL+"/go/go1.26.0.tar.gz", dest)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to download")
+}
+
+func TestBuilder_PinnedDigestMismatch(t *testing.T) {
+	t.Parallel()
+
+	srv := newArtifactServer(t, map[string]string{"/jq/jq-1.7.1.tar.gz": "tampered"})
+	lock := lockfile.New()
+	lock.Tools = map[string]*lockfile.Entry{
+		"jq": {SpecVersion: "1.7.1", Version: "1.7.1", Platforms: map[string]*lockfile.Artifact{
+			lockfile.Platform(): {Digest: "0000000000000000000000000000000000000000000000000000000000000000"},
+		}},
+	}
+	resources := []resource.Resource{
+		&resource.Tool{
+			BaseResource: resource.BaseResource{ResourceKind: resource.KindTool, Metadata: resource.Metadata{Name: "jq"}},
+			ToolSpec: &resource.ToolSpec{
+				InstallerRef: "download",
+				Version:      "1.7.1",
+				Source:       &resource.DownloadSource{URL: srv.URL + "/jq/jq-1.7.1.tar.gz"},
+			},
+		},
+	}
+
+	builder := NewBuilder(t.TempDir(), http.DefaultTransport, lock, "v4.465.0")
+	err := builder.Add(context.Background(), resources)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "archive does not match digest pinned in lockfile")
+}
+
+// routeTransport serves fixed response bodies by URL, so that requests to
+// public hosts (GitHub, the aqua registry) never reach the network.
+type routeTransport map[string]string
+
+func (rt routeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, ok := rt[req.URL.String()]
+	status := http.StatusOK
+	if !ok {
+		status = http.StatusNotFound
+	}
+	return &http.Response{
+		Status:     http.StatusText(status),
+		StatusCode: status,
+		Header:     make(http.Header),
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func TestBuilder_SignaturePolicy(t *testing.T) {
+	t.Parallel()
+
+	archive := "tool archive"
+	asset := fmt.Sprintf("mytool_%s_%s.tar.gz", runtime.GOOS, runtime.GOARCH)
+	attestationsURL := "https://api.github.com/repos/test/mytool/attestations/sha256:" + sha256Hex(archive)
+	transport := routeTransport{
+		"https://raw.githubusercontent.com/aquaproj/aqua-registry/v4.465.0/pkgs/test/mytool/registry.yaml": `packages:
+  - type: github_release
+    repo_owner: test
+    repo_name: mytool
+    asset: mytool_{{.OS}}_{{.Arch}}.tar.gz
+    format: tar.gz
+    github_artifact_attestations: {}
+`,
+		"https://github.com/test/mytool/releases/download/v1.0.0/" + asset: archive,
+		attestationsURL: `{"attestations":[]}`,
+	}
+
+	tests := []struct {
+		name    string
+		policy  resource.SignaturePolicy
+		wantErr string
+	}{
+		{
+			name:    "require fails bundle create",
+			policy:  resource.SignaturePolicyRequire,
+			wantErr: "failed to verify signature of package test/mytool",
+		},
+		{
+			name:   "warn records the signature material",
+			policy: resource.SignaturePolicyWarn,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			resources := []resource.Resource{
+				&resource.Installer{
+					BaseResource:  resource.BaseResource{ResourceKind: resource.KindInstaller, Metadata: resource.Metadata{Name: "aqua"}},
+					InstallerSpec: &resource.InstallerSpec{Type: resource.InstallTypeDownload, SignaturePolicy: tt.policy},
+				},
+				&resource.Tool{
+					BaseResource: resource.BaseResource{ResourceKind: resource.KindTool, Metadata: resource.Metadata{Name: "mytool"}},
+					ToolSpec: &resource.ToolSpec{
+						InstallerRef: "aqua",
+						Version:      "v1.0.0",
+						Package:      &resource.Package{Owner: "test", Repo: "mytool"},
+					},
+				},
+			}
+
+			builder := NewBuilder(t.TempDir(), transport, lockfile.New(), "v4.465.0")
+			err := builder.Add(context.Background(), resources)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Contains(t, builder.recorder.Files(), attestationsURL)
+		})
+	}
+}
+
+func TestRecorder_RecordsPost(t *testing.T) {
+	t.Parallel()
+
+	var posted []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		posted = append(posted, string(body))
+		_, _ = io.WriteString(w, `["entry"]`)
+	}))
+	t.Cleanup(srv.Close)
+
+	dir := t.TempDir()
+	recorder := NewRecorder(http.DefaultTransport, dir)
+	url := srv.URL + "/api/v1/index/retrieve"
+	resp, err := (&http.Client{Transport: recorder}).Post(url, "application/json", strings.NewReader(`{"hash":"sha256:abc"}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, []string{`{"hash":"sha256:abc"}`}, posted, "the body reaches the server")
+
+	key := "POST " + url + " " + sha256Hex(`{"hash":"sha256:abc"}`)
+	assert.Equal(t, map[string]string{key: fileKey(key)}, recorder.Files())
+
+	// Replayed for the same body only
+	srv.Close()
+	b := &Bundle{dir: dir, Manifest: &Manifest{Files: recorder.Files()}}
+	client := &http.Client{Transport: b.Transport()}
+	resp, err = client.Post(url, "application/json", strings.NewReader(`{"hash":"sha256:abc"}`))
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, `["entry"]`, string(body))
+
+	_, err = client.Post(url, "application/json", strings.NewReader(`{"hash":"sha256:def"}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is not in the offline bundle")
+}
+
+func TestRecorder_FollowsRedirects(t *testing.T) {
+	t.Parallel()
+
+	storage := newArtifactServer(t, map[string]string{"/signed": "archive"})
+	srv := httptest.NewServer(http.RedirectHandler(storage.URL+"/signed", http.StatusFound))
+	t.Cleanup(srv.Close)
+
+	recorder := NewRecorder(http.DefaultTransport, t.TempDir())
+	client := &http.Client{Transport: recorder}
+	resp, err := client.Get(srv.URL + "/releases/download/v1.0.0/tool.tar.gz")
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "archive", string(body))
+
+	// Recorded under the requested URL, not the storage URL
+	assert.Equal(t, map[string]string{
+		srv.URL + "/releases/download/v1.0.0/tool.tar.gz": fileKey(srv.URL + "/releases/download/v1.0.0/tool.tar.gz"),
+	}, recorder.Files())
+}
+
+func TestOfflineTransport(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	url := "https://example.com/a.tar.gz"
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, filesDirName), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, filesDirName, fileKey(url)), []byte("data"), 0644))
+	b := &Bundle{dir: dir, Manifest: &Manifest{Files: map[string]string{url: fileKey(url)}}}
+	client := &http.Client{Transport: b.Transport()}
+
+	resp, err := client.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int64(4), resp.ContentLength)
+
+	_, err = client.Get("https://example.com/other.tar.gz")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is not in the offline bundle")
+}
+
+func TestValidEntryName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{name: "bundle.json", want: true},
+		{name: "tomei.lock", want: true},
+		{name: "trusted_root.json", want: true},
+		{name: "files/" + fileKey("https://example.com"), want: true},
+		{name: "files/../../etc/passwd", want: false},
+		{name: "files/abc", want: false},
+		{name: "other.txt", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, validEntryName(tt.name))
+		})
+	}
+}
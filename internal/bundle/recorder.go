@@ -0,0 +1,136 @@
+package bundle
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Recorder is an http.RoundTripper that stores the body of every successful
+// GET or POST response under dir/files, so that it can be replayed from a
+// bundle.
+type Recorder struct {
+	base http.RoundTripper
+	dir  string
+
+	mu    sync.Mutex
+	files map[string]string // request key -> file name under files/
+}
+
+// NewRecorder creates a Recorder that sends requests through base and stores
+// responses in dir.
+func NewRecorder(base http.RoundTripper, dir string) *Recorder {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Recorder{
+		base:  base,
+		dir:   filepath.Join(dir, filesDirName),
+		files: make(map[string]string),
+	}
+}
+
+// RoundTrip implements http.RoundTripper. The response body is written to
+// disk before it is returned, and the caller reads it back from the file.
+// Redirects of GET requests are followed here, so that the content is recorded
+// under the requested URL rather than a (often pre-signed) storage URL.
+// POST requests, such as transparency log searches, are recorded under
+// their URL and body.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet && req.Method != http.MethodPost {
+		return r.base.RoundTrip(req)
+	}
+	key, req, err := requestKey(req)
+	if err != nil {
+		return nil, err
+	}
+	var resp *http.Response
+	if req.Method == http.MethodGet {
+		resp, err = r.followRedirects(req)
+	} else {
+		resp, err = r.base.RoundTrip(req)
+	}
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+	defer resp.Body.Close()
+
+	url := req.URL.String()
+	name := fileKey(key)
+	path := filepath.Join(r.dir, name)
+	if err := os.MkdirAll(r.dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create bundle directory: %w", err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bundle file: %w", err)
+	}
+	n, err := io.Copy(f, resp.Body)
+	if err != nil {
+		f.Close()
+		os.Remove(path)
+		return nil, fmt.Errorf("failed to record %s: %w", url, err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to rewind bundle file: %w", err)
+	}
+
+	r.mu.Lock()
+	r.files[key] = name
+	r.mu.Unlock()
+
+	resp.Body = f
+	resp.ContentLength = n
+	return resp, nil
+}
+
+// followRedirects sends req and follows up to maxRedirects redirects.
+// The redirected requests carry the context but not the headers of req,
+// as http.Client does for cross-host redirects.
+func (r *Recorder) followRedirects(req *http.Request) (*http.Response, error) {
+	resp, err := r.base.RoundTrip(req)
+	for range maxRedirects {
+		if err != nil || !isRedirect(resp.StatusCode) {
+			return resp, err
+		}
+		loc, locErr := resp.Location()
+		if locErr != nil {
+			return resp, nil
+		}
+		resp.Body.Close()
+		next, reqErr := http.NewRequestWithContext(req.Context(), http.MethodGet, loc.String(), nil)
+		if reqErr != nil {
+			return nil, fmt.Errorf("failed to follow redirect: %w", reqErr)
+		}
+		resp, err = r.base.RoundTrip(next)
+	}
+	if err == nil && isRedirect(resp.StatusCode) {
+		resp.Body.Close()
+		return nil, fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	return resp, err
+}
+
+// maxRedirects matches the redirect limit of http.Client.
+const maxRedirects = 10
+
+func isRedirect(status int) bool {
+	switch status {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+// Files returns a copy of the recorded URL -> file name index.
+func (r *Recorder) Files() map[string]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return maps.Clone(r.files)
+}
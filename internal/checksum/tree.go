@@ -0,0 +1,174 @@
+package checksum
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// TreeManifestFile is the name of the file that records the tree manifest
+// inside an installed directory. It is excluded from hashing.
+const TreeManifestFile = ".tomei.sha256"
+
+// symlinkPrefix marks symlink entries, whose digest is taken over the link
+// target instead of the file contents.
+const symlinkPrefix = "symlink:"
+
+// TreeManifest maps slash-separated paths relative to a directory to the
+// SHA256 digests of their contents. Directories are not recorded.
+type TreeManifest map[string]Digest
+
+// HashTree calculates the SHA256 digest of every regular file and symlink
+// under dir. Symlinks are not followed.
+func HashTree(dir string) (TreeManifest, error) {
+	m := make(TreeManifest)
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == TreeManifestFile {
+			return nil
+		}
+
+		switch {
+		case d.Type()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(p)
+			if err != nil {
+				return fmt.Errorf("failed to read symlink: %w", err)
+			}
+			m[rel] = hashString(symlinkPrefix + target)
+		case d.Type().IsRegular():
+			digest, err := Calculate(p, AlgorithmSHA256)
+			if err != nil {
+				return err
+			}
+			m[rel] = digest
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash %s: %w", dir, err)
+	}
+	return m, nil
+}
+
+// Bytes encodes the manifest in GNU coreutils format ("<hash>  <path>"), sorted by path.
+func (m TreeManifest) Bytes() []byte {
+	var buf bytes.Buffer
+	for _, p := range slices.Sorted(maps.Keys(m)) {
+		fmt.Fprintf(&buf, "%s  %s\n", m[p], p)
+	}
+	return buf.Bytes()
+}
+
+// Digest returns the SHA256 digest of the encoded manifest, which identifies
+// the whole tree.
+func (m TreeManifest) Digest() Digest {
+	sum := sha256.Sum256(m.Bytes())
+	return Digest(hex.EncodeToString(sum[:]))
+}
+
+// ParseTreeManifest decodes a manifest encoded by Bytes.
+func ParseTreeManifest(content []byte) (TreeManifest, error) {
+	m := make(TreeManifest)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		hash, p, ok := strings.Cut(line, "  ")
+		if !ok || DetectAlgorithm(hash) != AlgorithmSHA256 || p == "" {
+			return nil, fmt.Errorf("invalid tree manifest line: %q", line)
+		}
+		m[p] = Digest(hash)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read tree manifest: %w", err)
+	}
+	return m, nil
+}
+
+// WriteTreeManifest hashes dir, writes the manifest to TreeManifestFile in
+// dir and returns the manifest digest.
+func WriteTreeManifest(dir string) (Digest, error) {
+	m, err := HashTree(dir)
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(dir, TreeManifestFile), m.Bytes(), 0644); err != nil {
+		return "", fmt.Errorf("failed to write tree manifest: %w", err)
+	}
+	return m.Digest(), nil
+}
+
+// ReadTreeManifest reads TreeManifestFile from dir and checks it against the
+// recorded manifest digest.
+func ReadTreeManifest(dir string, expected Digest) (TreeManifest, error) {
+	content, err := os.ReadFile(filepath.Join(dir, TreeManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tree manifest: %w", err)
+	}
+	m, err := ParseTreeManifest(content)
+	if err != nil {
+		return nil, err
+	}
+	if digest := m.Digest(); digest != expected {
+		return nil, fmt.Errorf("tree manifest mismatch: expected %s, got %s", expected, digest)
+	}
+	return m, nil
+}
+
+// TreeDiff lists the paths that differ between a recorded and a current manifest.
+type TreeDiff struct {
+	Modified   []string // recorded paths whose contents changed
+	Missing    []string // recorded paths that no longer exist
+	Unexpected []string // paths that were not recorded
+}
+
+// Empty reports whether the trees are identical.
+func (d TreeDiff) Empty() bool {
+	return len(d.Modified) == 0 && len(d.Missing) == 0 && len(d.Unexpected) == 0
+}
+
+// Diff compares the recorded manifest m with current. The paths are sorted.
+func (m TreeManifest) Diff(current TreeManifest) TreeDiff {
+	var diff TreeDiff
+	for _, p := range slices.Sorted(maps.Keys(m)) {
+		digest, ok := current[p]
+		switch {
+		case !ok:
+			diff.Missing = append(diff.Missing, p)
+		case digest != m[p]:
+			diff.Modified = append(diff.Modified, p)
+		}
+	}
+	for _, p := range slices.Sorted(maps.Keys(current)) {
+		if _, ok := m[p]; !ok {
+			diff.Unexpected = append(diff.Unexpected, p)
+		}
+	}
+	return diff
+}
+
+// hashString returns the SHA256 digest of s.
+func hashString(s string) Digest {
+	sum := sha256.Sum256([]byte(s))
+	return Digest(hex.EncodeToString(sum[:]))
+}
@@ -0,0 +1,81 @@
+package checksum
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestTree(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "bin"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bin", "go"), []byte("go binary"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "VERSION"), []byte("go1.25.5"), 0644))
+	require.NoError(t, os.Symlink("go", filepath.Join(dir, "bin", "golink")))
+	return dir
+}
+
+func TestHashTree(t *testing.T) {
+	t.Parallel()
+
+	dir := writeTestTree(t)
+	m, err := HashTree(dir)
+	require.NoError(t, err)
+
+	assert.Len(t, m, 3)
+	assert.Equal(t, hashString("go binary"), m["bin/go"])
+	assert.Equal(t, hashString("symlink:go"), m["bin/golink"])
+
+	// The manifest file itself is not part of the tree
+	digest, err := WriteTreeManifest(dir)
+	require.NoError(t, err)
+	assert.Equal(t, m.Digest(), digest)
+	again, err := HashTree(dir)
+	require.NoError(t, err)
+	assert.Equal(t, m, again)
+}
+
+func TestTreeManifest_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	dir := writeTestTree(t)
+	digest, err := WriteTreeManifest(dir)
+	require.NoError(t, err)
+
+	m, err := ReadTreeManifest(dir, digest)
+	require.NoError(t, err)
+	assert.Len(t, m, 3)
+
+	_, err = ReadTreeManifest(dir, hashString("other"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "tree manifest mismatch")
+
+	_, err = ParseTreeManifest([]byte("not-a-hash  bin/go\n"))
+	require.Error(t, err)
+}
+
+func TestTreeManifest_Diff(t *testing.T) {
+	t.Parallel()
+
+	dir := writeTestTree(t)
+	recorded, err := HashTree(dir)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bin", "go"), []byte("tampered"), 0755))
+	require.NoError(t, os.Remove(filepath.Join(dir, "VERSION")))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bin", "backdoor"), []byte("x"), 0755))
+
+	current, err := HashTree(dir)
+	require.NoError(t, err)
+	diff := recorded.Diff(current)
+	assert.False(t, diff.Empty())
+	assert.Equal(t, []string{"bin/go"}, diff.Modified)
+	assert.Equal(t, []string{"VERSION"}, diff.Missing)
+	assert.Equal(t, []string{"bin/backdoor"}, diff.Unexpected)
+
+	assert.True(t, recorded.Diff(recorded).Empty())
+}
@@ -10,6 +10,9 @@ import (
 	"cuelang.org/go/cue/cuecontext"
 	"cuelang.org/go/cue/format"
 	"cuelang.org/go/cue/load"
+
+	"github.com/terassyi/tomei/internal/mirror"
+	"github.com/terassyi/tomei/internal/verify"
 )
 
 // Default path constants
@@ -25,6 +28,14 @@ type Config struct {
 	DataDir string `json:"dataDir"`
 	BinDir  string `json:"binDir"`
 	EnvDir  string `json:"envDir"`
+
+	// Mirrors are ordered URL rewrite rules applied to every download,
+	// checksum file, aqua registry and GitHub API request.
+	Mirrors []mirror.Rule `json:"mirrors,omitempty"`
+
+	// ModuleVerification configures the cosign signature verification of
+	// CUE module dependencies. Nil verifies first-party modules in warn mode.
+	ModuleVerification *verify.Policy `json:"moduleVerification,omitempty"`
 }
 
 // DefaultConfig returns the default configuration.
@@ -82,6 +93,12 @@ func LoadConfig(configDir string) (*Config, error) {
 	if err := json.Unmarshal(jsonBytes, cfg); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
+	if err := mirror.Validate(cfg.Mirrors); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	if err := cfg.ModuleVerification.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
 
 	return cfg, nil
 }
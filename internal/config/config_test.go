@@ -7,6 +7,9 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/terassyi/tomei/internal/mirror"
+	"github.com/terassyi/tomei/internal/verify"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -99,6 +102,110 @@ somethingElse: "value"
 	assert.Equal(t, DefaultBinDir, cfg.BinDir)
 }
 
+func TestLoadConfig_Mirrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		mirrors string
+		want    []mirror.Rule
+		wantErr string
+	}{
+		{
+			name: "ordered rules",
+			mirrors: `[
+        {prefix: "https://github.com/", url: "https://artifactory.example.com/github/"},
+        {prefix: "https://go.dev/dl/", url: "https://artifactory.example.com/go/", fallback: true},
+    ]`,
+			want: []mirror.Rule{
+				{Prefix: "https://github.com/", URL: "https://artifactory.example.com/github/"},
+				{Prefix: "https://go.dev/dl/", URL: "https://artifactory.example.com/go/", Fallback: true},
+			},
+		},
+		{
+			name:    "plain HTTP mirror",
+			mirrors: `[{prefix: "https://github.com/", url: "http://mirror.example.com/github/"}]`,
+			wantErr: "mirrors[0].url",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			tmpDir := t.TempDir()
+			cueContent := "package tomei\n\nconfig: {\n    mirrors: " + tt.mirrors + "\n}\n"
+			require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "config.cue"), []byte(cueContent), 0644))
+
+			cfg, err := LoadConfig(tmpDir)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cfg.Mirrors)
+			assert.Equal(t, DefaultDataDir, cfg.DataDir)
+		})
+	}
+}
+
+func TestLoadConfig_ModuleVerification(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		policy  string
+		want    *verify.Policy
+		wantErr string
+	}{
+		{
+			name: "require mode with identities",
+			policy: `{
+        mode:        "require"
+        trustedRoot: "cached"
+        identities: [{
+            module:    "example.com/presets"
+            issuer:    "https://token.actions.githubusercontent.com"
+            sanRegexp: "^https://github\\.com/example/presets/"
+        }]
+    }`,
+			want: &verify.Policy{
+				Mode:        verify.ModeRequire,
+				TrustedRoot: verify.TrustedRootCached,
+				Identities: []verify.Identity{{
+					Module:    "example.com/presets",
+					Issuer:    "https://token.actions.githubusercontent.com",
+					SANRegexp: `^https://github\.com/example/presets/`,
+				}},
+			},
+		},
+		{
+			name:    "unknown mode",
+			policy:  `{mode: "strict"}`,
+			wantErr: "moduleVerification.mode",
+		},
+		{
+			name:    "identity without san",
+			policy:  `{identities: [{module: "example.com", issuer: "https://token.actions.githubusercontent.com"}]}`,
+			wantErr: "moduleVerification.identities[0]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			tmpDir := t.TempDir()
+			cueContent := "package tomei\n\nconfig: {\n    moduleVerification: " + tt.policy + "\n}\n"
+			require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "config.cue"), []byte(cueContent), 0644))
+
+			cfg, err := LoadConfig(tmpDir)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cfg.ModuleVerification)
+		})
+	}
+}
+
 func TestLoadConfig_InvalidCue(t *testing.T) {
 	t.Parallel()
 
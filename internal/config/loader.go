@@ -587,6 +587,8 @@ func (l *Loader) parseResource(value cue.Value) (resource.Resource, error) {
 		return decodeResource[*resource.Installer](value)
 	case resource.KindInstallerRepository:
 		return decodeResource[*resource.InstallerRepository](value)
+	case resource.KindCredential:
+		return decodeResource[*resource.Credential](value)
 	case resource.KindSystemInstaller:
 		return decodeResource[*resource.SystemInstaller](value)
 	case resource.KindSystemPackageRepository:
@@ -609,8 +611,9 @@ func decodeResource[R resource.Resource](value cue.Value) (R, error) {
 	return res, nil
 }
 
-// verifyModuleDeps verifies cosign signatures on first-party CUE module dependencies.
-// It looks for cue.mod/ in or above absDir, extracts first-party deps, and verifies them.
+// verifyModuleDeps verifies cosign signatures on CUE module dependencies.
+// It looks for cue.mod/ in or above absDir, extracts the deps, and passes them
+// to the verifier, whose policy decides which modules are verified.
 // Skips verification when no verifier is set, cue.mod/ doesn't exist,
 // or CUE_REGISTRY is set to "none" (vendor mode).
 func (l *Loader) verifyModuleDeps(absDir string) error {
@@ -618,9 +621,13 @@ func (l *Loader) verifyModuleDeps(absDir string) error {
 		return nil
 	}
 
-	// Check if CUE_REGISTRY is "none" (vendor mode) — skip verification
+	// Check if CUE_REGISTRY is "none" (vendor mode) — skip verification,
+	// unless the verifier requires every module to be verified
 	cueRegistry := os.Getenv(EnvCUERegistry)
 	if cueRegistry == "none" {
+		if r, ok := l.verifier.(interface{ Requires() bool }); ok && r.Requires() {
+			return fmt.Errorf("cosign signatures cannot be verified in vendor mode (%s=none), but moduleVerification mode is require", EnvCUERegistry)
+		}
 		slog.Debug("cosign verification skipped: vendor mode (CUE_REGISTRY=none)")
 		return nil
 	}
@@ -637,7 +644,7 @@ func (l *Loader) verifyModuleDeps(absDir string) error {
 		return nil
 	}
 
-	deps, err := verify.ExtractFirstPartyDeps(cueModDir)
+	deps, err := verify.ExtractDeps(cueModDir)
 	if err != nil {
 		return fmt.Errorf("failed to extract module dependencies: %w", err)
 	}
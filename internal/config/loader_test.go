@@ -666,6 +666,51 @@ spec: {
 	}
 }
 
+func TestLoader_LoadFile_Credential(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	cueFile := filepath.Join(dir, "credential.cue")
+
+	content := `
+apiVersion: "tomei.terassyi.net/v1beta1"
+kind: "Credential"
+metadata: name: "corp-github"
+spec: {
+    type: "github"
+    hosts: ["github.example.com"]
+    command: "gh auth token --hostname github.example.com"
+}
+`
+	if err := os.WriteFile(cueFile, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	loader := NewLoader(nil)
+	resources, err := loader.LoadFile(cueFile)
+	if err != nil {
+		t.Fatalf("failed to load file: %v", err)
+	}
+
+	if len(resources) != 1 {
+		t.Fatalf("expected 1 resource, got %d", len(resources))
+	}
+
+	cred, ok := resources[0].(*resource.Credential)
+	if !ok {
+		t.Fatalf("expected *resource.Credential, got %T", resources[0])
+	}
+	if cred.CredentialSpec.Type != resource.CredentialTypeGitHub {
+		t.Errorf("expected type github, got %s", cred.CredentialSpec.Type)
+	}
+	if len(cred.CredentialSpec.Hosts) != 1 || cred.CredentialSpec.Hosts[0] != "github.example.com" {
+		t.Errorf("unexpected hosts: %v", cred.CredentialSpec.Hosts)
+	}
+	if err := cred.CredentialSpec.Validate(); err != nil {
+		t.Errorf("unexpected validation error: %v", err)
+	}
+}
+
 func TestLoader_SchemaValidation_DirectoryMode(t *testing.T) {
 	t.Parallel()
 
@@ -1170,9 +1215,14 @@ myTool: {
 
 // mockVerifier implements verify.Verifier for testing.
 type mockVerifier struct {
-	called bool
-	deps   []module.Version
-	err    error
+	called   bool
+	deps     []module.Version
+	err      error
+	requires bool
+}
+
+func (m *mockVerifier) Requires() bool {
+	return m.requires
 }
 
 func (m *mockVerifier) Verify(_ context.Context, deps []module.Version) ([]verify.Result, error) {
@@ -1349,6 +1399,11 @@ myTool: {
 	_, _ = loader.EvalDir(dir) // May fail for CUE reasons, but verifier should be skipped
 
 	assert.False(t, mv.called, "verifier should not be called in vendor mode (CUE_REGISTRY=none)")
+
+	requiring := &mockVerifier{requires: true}
+	_, err := NewLoader(&Env{OS: "linux", Arch: "amd64"}, WithVerifier(requiring)).EvalDir(dir)
+	require.ErrorContains(t, err, "cannot be verified in vendor mode (CUE_REGISTRY=none), but moduleVerification mode is require")
+	assert.False(t, requiring.called)
 }
 
 func TestFindCueModDir(t *testing.T) {
@@ -0,0 +1,216 @@
+// Package credential resolves Credential resources and authenticates
+// download requests with them.
+//
+// Installers attach the credential referenced by a resource to the request
+// context with NewContext. The Transport returned by WrapTransport then
+// authenticates requests whose host the credential covers. Secrets are read
+// lazily and cached once read successfully, so credentials that are never
+// used are never read.
+package credential
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/terassyi/tomei/internal/path"
+	"github.com/terassyi/tomei/internal/resource"
+)
+
+// Credential is a resolved Credential resource.
+type Credential struct {
+	name string
+	spec *resource.CredentialSpec
+
+	mu     sync.Mutex
+	secret string // cached after the first successful read
+}
+
+// New creates a Credential from a Credential resource.
+func New(res *resource.Credential) *Credential {
+	return &Credential{name: res.Name(), spec: res.CredentialSpec}
+}
+
+// Name returns the credential name.
+func (c *Credential) Name() string {
+	return c.name
+}
+
+// Type returns the credential type.
+func (c *Credential) Type() resource.CredentialType {
+	return c.spec.Type
+}
+
+// Username returns the user name for basic credentials.
+func (c *Credential) Username() string {
+	return c.spec.Username
+}
+
+// Secret returns the secret, reading it from the configured source on first
+// use. Only a successful read is cached: a failure, such as a command
+// canceled with its context, is returned and the next call reads again.
+func (c *Credential) Secret(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.secret != "" {
+		return c.secret, nil
+	}
+	secret, err := c.read(ctx)
+	if err != nil {
+		return "", err
+	}
+	c.secret = secret
+	return secret, nil
+}
+
+func (c *Credential) read(ctx context.Context) (string, error) {
+	var secret string
+	switch {
+	case c.spec.EnvVar != "":
+		secret = os.Getenv(c.spec.EnvVar)
+		if secret == "" {
+			return "", fmt.Errorf("credential %s: environment variable %s is not set", c.name, c.spec.EnvVar)
+		}
+	case c.spec.File != "":
+		file, err := path.Expand(c.spec.File)
+		if err != nil {
+			return "", fmt.Errorf("credential %s: %w", c.name, err)
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("credential %s: failed to read secret file: %w", c.name, err)
+		}
+		secret = string(data)
+	case c.spec.Command != "":
+		// Run directly rather than through the command executor, which logs
+		// captured output.
+		var stdout, stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, "sh", "-c", c.spec.Command)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return "", fmt.Errorf("credential %s: command failed: %w: %s", c.name, err, strings.TrimSpace(stderr.String()))
+		}
+		secret = stdout.String()
+	default:
+		return "", fmt.Errorf("credential %s: no secret source configured", c.name)
+	}
+
+	secret = strings.TrimSpace(secret)
+	if secret == "" {
+		return "", fmt.Errorf("credential %s: secret is empty", c.name)
+	}
+	return secret, nil
+}
+
+// Matches reports whether requests to host are authenticated with this credential.
+// A github credential for github.com also covers api.github.com.
+func (c *Credential) Matches(host string) bool {
+	host = strings.ToLower(host)
+	for _, h := range c.spec.CredentialHosts() {
+		h = strings.ToLower(h)
+		if host == h {
+			return true
+		}
+		if c.spec.Type == resource.CredentialTypeGitHub && h == resource.DefaultGitHubHost && host == githubAPIHost {
+			return true
+		}
+	}
+	return false
+}
+
+// Set holds the credentials of a manifest, keyed by name.
+type Set map[string]*Credential
+
+// Split separates Credential resources from the rest. Credentials are
+// configuration for the installers, not resources to reconcile.
+func Split(resources []resource.Resource) (Set, []resource.Resource) {
+	set := make(Set)
+	rest := make([]resource.Resource, 0, len(resources))
+	for _, res := range resources {
+		if c, ok := res.(*resource.Credential); ok {
+			set[c.Name()] = New(c)
+			continue
+		}
+		rest = append(rest, res)
+	}
+	return set, rest
+}
+
+// Check returns an error if a resource references a credential that is not in the set.
+func (s Set) Check(resources []resource.Resource) error {
+	for _, res := range resources {
+		ref := Ref(res)
+		if ref == "" {
+			continue
+		}
+		if _, ok := s[ref]; !ok {
+			return fmt.Errorf("%s/%s references unknown credential %q", res.Kind(), res.Name(), ref)
+		}
+	}
+	return nil
+}
+
+// Names returns the sorted credential names.
+func (s Set) Names() []string {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Context returns ctx carrying the credential named ref.
+// An empty ref returns ctx unchanged.
+func (s Set) Context(ctx context.Context, ref string) (context.Context, error) {
+	if ref == "" {
+		return ctx, nil
+	}
+	c, ok := s[ref]
+	if !ok {
+		return ctx, fmt.Errorf("credential %q not found", ref)
+	}
+	return NewContext(ctx, c), nil
+}
+
+// Ref returns the credentialRef of a Tool, ToolSet, Installer or Runtime.
+func Ref(res resource.Resource) string {
+	switch r := res.(type) {
+	case *resource.Tool:
+		if r.ToolSpec != nil {
+			return r.ToolSpec.CredentialRef
+		}
+	case *resource.ToolSet:
+		if r.ToolSetSpec != nil {
+			return r.ToolSetSpec.CredentialRef
+		}
+	case *resource.Installer:
+		if r.InstallerSpec != nil {
+			return r.InstallerSpec.CredentialRef
+		}
+	case *resource.Runtime:
+		if r.RuntimeSpec != nil {
+			return r.RuntimeSpec.CredentialRef
+		}
+	}
+	return ""
+}
+
+type contextKey struct{}
+
+// NewContext returns a context that carries c.
+func NewContext(ctx context.Context, c *Credential) context.Context {
+	return context.WithValue(ctx, contextKey{}, c)
+}
+
+// FromContext returns the credential carried by ctx, or nil.
+func FromContext(ctx context.Context) *Credential {
+	c, _ := ctx.Value(contextKey{}).(*Credential)
+	return c
+}
@@ -0,0 +1,230 @@
+package credential
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/terassyi/tomei/internal/resource"
+)
+
+func newCredential(name string, spec *resource.CredentialSpec) *Credential {
+	return New(&resource.Credential{
+		BaseResource:   resource.BaseResource{ResourceKind: resource.KindCredential, Metadata: resource.Metadata{Name: name}},
+		CredentialSpec: spec,
+	})
+}
+
+func TestCredential_Secret(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(secretFile, []byte("from-file\n"), 0600))
+	t.Setenv("TOMEI_TEST_CREDENTIAL", "from-env")
+
+	tests := []struct {
+		name    string
+		spec    *resource.CredentialSpec
+		want    string
+		wantErr string
+	}{
+		{name: "env var", spec: &resource.CredentialSpec{EnvVar: "TOMEI_TEST_CREDENTIAL"}, want: "from-env"},
+		{name: "file", spec: &resource.CredentialSpec{File: secretFile}, want: "from-file"},
+		{name: "command", spec: &resource.CredentialSpec{Command: "echo from-command"}, want: "from-command"},
+		{name: "unset env var", spec: &resource.CredentialSpec{EnvVar: "TOMEI_TEST_CREDENTIAL_UNSET"}, wantErr: "is not set"},
+		{name: "failing command", spec: &resource.CredentialSpec{Command: "echo denied >&2; exit 1"}, wantErr: "denied"},
+		{name: "empty output", spec: &resource.CredentialSpec{Command: "true"}, wantErr: "secret is empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newCredential("test", tt.spec).Secret(context.Background())
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCredential_SecretCachesSuccessOnly(t *testing.T) {
+	t.Parallel()
+
+	counter := filepath.Join(t.TempDir(), "reads")
+	cred := newCredential("test", &resource.CredentialSpec{Command: "echo x >> " + counter + "; echo token"})
+	reads := func() int {
+		data, err := os.ReadFile(counter)
+		if os.IsNotExist(err) {
+			return 0
+		}
+		require.NoError(t, err)
+		return strings.Count(string(data), "x")
+	}
+
+	// A read canceled with its context is not cached
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := cred.Secret(ctx)
+	require.Error(t, err)
+
+	got, err := cred.Secret(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token", got)
+	assert.Equal(t, 1, reads())
+
+	// A successful read is cached
+	got, err = cred.Secret(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token", got)
+	assert.Equal(t, 1, reads())
+}
+
+func TestCredential_Matches(t *testing.T) {
+	t.Parallel()
+
+	gh := newCredential("gh", &resource.CredentialSpec{Type: resource.CredentialTypeGitHub})
+	assert.True(t, gh.Matches("github.com"))
+	assert.True(t, gh.Matches("api.github.com"))
+	assert.False(t, gh.Matches("objects.githubusercontent.com"))
+
+	ghe := newCredential("ghe", &resource.CredentialSpec{Type: resource.CredentialTypeGitHub, Hosts: []string{"GitHub.example.com"}})
+	assert.True(t, ghe.Matches("github.example.com"))
+	assert.False(t, ghe.Matches("api.github.com"))
+}
+
+func TestSet_SplitCheck(t *testing.T) {
+	t.Parallel()
+
+	resources := []resource.Resource{
+		&resource.Credential{
+			BaseResource:   resource.BaseResource{ResourceKind: resource.KindCredential, Metadata: resource.Metadata{Name: "corp"}},
+			CredentialSpec: &resource.CredentialSpec{Type: resource.CredentialTypeGitHub, EnvVar: "CORP_TOKEN"},
+		},
+		&resource.Tool{
+			BaseResource: resource.BaseResource{ResourceKind: resource.KindTool, Metadata: resource.Metadata{Name: "internal-cli"}},
+			ToolSpec:     &resource.ToolSpec{InstallerRef: "download", CredentialRef: "corp"},
+		},
+	}
+	set, rest := Split(resources)
+	assert.Equal(t, []string{"corp"}, set.Names())
+	require.Len(t, rest, 1)
+	require.NoError(t, set.Check(rest))
+
+	ctx, err := set.Context(context.Background(), "corp")
+	require.NoError(t, err)
+	assert.Equal(t, "corp", FromContext(ctx).Name())
+
+	dangling := &resource.Runtime{
+		BaseResource: resource.BaseResource{ResourceKind: resource.KindRuntime, Metadata: resource.Metadata{Name: "go"}},
+		RuntimeSpec:  &resource.RuntimeSpec{Type: resource.InstallTypeDownload, CredentialRef: "missing"},
+	}
+	err = set.Check(append(rest, dangling))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `Runtime/go references unknown credential "missing"`)
+
+	_, err = set.Context(context.Background(), "missing")
+	require.Error(t, err)
+}
+
+func TestTransport_Authorization(t *testing.T) {
+	t.Parallel()
+
+	var gotAuth []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = append(gotAuth, r.Header.Get("Authorization"))
+	}))
+	t.Cleanup(srv.Close)
+	srvURL, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	basic := newCredential("artifacts", &resource.CredentialSpec{
+		Type: resource.CredentialTypeBasic, Hosts: []string{srvURL.Host}, Username: "ci", Command: "echo s3cret",
+	})
+	other := newCredential("other", &resource.CredentialSpec{
+		Type: resource.CredentialTypeBearer, Hosts: []string{"other.example.com"}, Command: "echo s3cret",
+	})
+	client := &http.Client{Transport: WrapTransport(nil)}
+
+	get := func(ctx context.Context) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/tool.tar.gz", nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	get(context.Background())
+	get(NewContext(context.Background(), other))
+	get(NewContext(context.Background(), basic))
+
+	require.Len(t, gotAuth, 3)
+	assert.Empty(t, gotAuth[0], "no credential in context")
+	assert.Empty(t, gotAuth[1], "credential for another host")
+	assert.Equal(t, "Basic Y2k6czNjcmV0", gotAuth[2])
+}
+
+func TestTransport_GitHubReleaseAsset(t *testing.T) {
+	t.Parallel()
+
+	storage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("Authorization"), "credential must not leak to storage")
+		_, _ = io.WriteString(w, "private archive")
+	}))
+	t.Cleanup(storage.Close)
+
+	var ghe *httptest.Server
+	ghe = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer ghe-token" {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v3/repos/corp/cli/releases/tags/v1.0.0":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"assets": []map[string]string{
+					{"name": "cli_linux_amd64.tar.gz", "url": ghe.URL + "/api/v3/repos/corp/cli/releases/assets/1"},
+				},
+			})
+		case "/api/v3/repos/corp/cli/releases/assets/1":
+			assert.Equal(t, "application/octet-stream", r.Header.Get("Accept"))
+			http.Redirect(w, r, storage.URL+"/signed?token=abc", http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(ghe.Close)
+	gheURL, err := url.Parse(ghe.URL)
+	require.NoError(t, err)
+
+	cred := newCredential("corp", &resource.CredentialSpec{
+		Type: resource.CredentialTypeGitHub, Hosts: []string{gheURL.Host}, Command: "echo ghe-token",
+	})
+	client := &http.Client{Transport: WrapTransport(nil)}
+	ctx := NewContext(context.Background(), cred)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ghe.URL+"/corp/cli/releases/download/v1.0.0/cli_linux_amd64.tar.gz", nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "private archive", string(body))
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, ghe.URL+"/corp/cli/releases/download/v1.0.0/missing.tar.gz", nil)
+	require.NoError(t, err)
+	_, err = client.Do(req)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "asset missing.tar.gz not found in release v1.0.0 of corp/cli")
+}
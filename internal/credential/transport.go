@@ -0,0 +1,188 @@
+package credential
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/terassyi/tomei/internal/resource"
+)
+
+const (
+	// githubAPIHost is the API host of github.com.
+	githubAPIHost = "api.github.com"
+	// enterpriseAPIPath is the API path prefix of GitHub Enterprise Server hosts.
+	enterpriseAPIPath = "/api/v3"
+)
+
+// WrapTransport wraps base so that requests carrying a credential in their
+// context (see NewContext) are authenticated when the credential covers the
+// request host. If base is nil, http.DefaultTransport is used.
+//
+// For github credentials, release download URLs
+// (https://<host>/<owner>/<repo>/releases/download/<tag>/<asset>) are resolved
+// through the releases API and fetched from the asset endpoint with
+// "Accept: application/octet-stream", which is the only way to download
+// assets of private repositories.
+//
+// WrapTransport must be the innermost authenticating transport so that its
+// Authorization header takes precedence over the GITHUB_TOKEN one.
+func WrapTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{
+		base:     base,
+		releases: make(map[string][]releaseAsset),
+	}
+}
+
+type transport struct {
+	base http.RoundTripper
+
+	mu       sync.Mutex
+	releases map[string][]releaseAsset // release API URL -> assets
+}
+
+// releaseAsset is the subset of a GitHub release asset used for downloads.
+type releaseAsset struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c := FromContext(req.Context())
+	if c == nil || !c.Matches(req.URL.Host) {
+		return t.base.RoundTrip(req)
+	}
+	secret, err := c.Secret(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Type() == resource.CredentialTypeGitHub && req.Method == http.MethodGet {
+		if rel, ok := parseReleaseDownload(req.URL); ok {
+			return t.roundTripReleaseAsset(req, c, secret, rel)
+		}
+	}
+
+	req = req.Clone(req.Context())
+	setAuthorization(req, c, secret)
+	return t.base.RoundTrip(req)
+}
+
+// roundTripReleaseAsset downloads a release asset through the API asset endpoint.
+// The response is usually a redirect to a pre-signed storage URL, which the
+// client follows without the credential.
+func (t *transport) roundTripReleaseAsset(req *http.Request, c *Credential, secret string, rel releaseDownload) (*http.Response, error) {
+	assets, err := t.releaseAssets(req, c, secret, rel)
+	if err != nil {
+		return nil, err
+	}
+	var assetURL string
+	for _, a := range assets {
+		if a.Name == rel.asset {
+			assetURL = a.URL
+			break
+		}
+	}
+	if assetURL == "" {
+		return nil, fmt.Errorf("asset %s not found in release %s of %s/%s", rel.asset, rel.tag, rel.owner, rel.repo)
+	}
+
+	assetReq, err := http.NewRequestWithContext(req.Context(), http.MethodGet, assetURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create asset request: %w", err)
+	}
+	assetReq.Header.Set("Accept", "application/octet-stream")
+	setAuthorization(assetReq, c, secret)
+	resp, err := t.base.RoundTrip(assetReq)
+	if err != nil {
+		return nil, err
+	}
+	// Report the response against the requested URL
+	resp.Request = req
+	return resp, nil
+}
+
+// releaseAssets returns the assets of a release, cached per apply.
+func (t *transport) releaseAssets(req *http.Request, c *Credential, secret string, rel releaseDownload) ([]releaseAsset, error) {
+	apiURL := rel.apiURL(req.URL)
+
+	t.mu.Lock()
+	assets, ok := t.releases[apiURL]
+	t.mu.Unlock()
+	if ok {
+		return assets, nil
+	}
+
+	apiReq, err := http.NewRequestWithContext(req.Context(), http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create release request: %w", err)
+	}
+	apiReq.Header.Set("Accept", "application/vnd.github+json")
+	setAuthorization(apiReq, c, secret)
+	resp, err := t.base.RoundTrip(apiReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get release %s of %s/%s: %w", rel.tag, rel.owner, rel.repo, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil, fmt.Errorf("failed to get release %s of %s/%s: %s (check that credential %s has access)",
+			rel.tag, rel.owner, rel.repo, resp.Status, c.Name())
+	}
+	var release struct {
+		Assets []releaseAsset `json:"assets"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, fmt.Errorf("failed to decode release %s of %s/%s: %w", rel.tag, rel.owner, rel.repo, err)
+	}
+
+	t.mu.Lock()
+	t.releases[apiURL] = release.Assets
+	t.mu.Unlock()
+	return release.Assets, nil
+}
+
+// releaseDownload is a parsed release download URL.
+type releaseDownload struct {
+	owner, repo, tag, asset string
+}
+
+// parseReleaseDownload parses /<owner>/<repo>/releases/download/<tag>/<asset>.
+func parseReleaseDownload(u *url.URL) (releaseDownload, bool) {
+	parts := strings.Split(strings.TrimPrefix(u.Path, "/"), "/")
+	if len(parts) != 6 || parts[2] != "releases" || parts[3] != "download" {
+		return releaseDownload{}, false
+	}
+	for _, p := range parts {
+		if p == "" {
+			return releaseDownload{}, false
+		}
+	}
+	return releaseDownload{owner: parts[0], repo: parts[1], tag: parts[4], asset: parts[5]}, true
+}
+
+// apiURL returns the releases API URL for the release on the host of u:
+// api.github.com for github.com, <host>/api/v3 for GitHub Enterprise.
+func (r releaseDownload) apiURL(u *url.URL) string {
+	base := u.Scheme + "://" + u.Host + enterpriseAPIPath
+	if strings.EqualFold(u.Hostname(), resource.DefaultGitHubHost) {
+		base = "https://" + githubAPIHost
+	}
+	return fmt.Sprintf("%s/repos/%s/%s/releases/tags/%s", base, r.owner, r.repo, url.PathEscape(r.tag))
+}
+
+// setAuthorization sets the Authorization header for c.
+func setAuthorization(req *http.Request, c *Credential, secret string) {
+	if c.Type() == resource.CredentialTypeBasic {
+		req.SetBasicAuth(c.Username(), secret)
+		return
+	}
+	req.Header.Set("Authorization", "Bearer "+secret)
+}
@@ -24,6 +24,8 @@ type Result struct {
 	Conflicts []Conflict
 	// StateIssues contains state integrity problems.
 	StateIssues []StateIssue
+	// Drift contains installed files that changed after installation.
+	Drift []DriftIssue
 }
 
 // UnmanagedTool represents a tool not managed by tomei.
@@ -53,10 +55,11 @@ const (
 
 // StateIssue represents a state integrity problem.
 type StateIssue struct {
-	Kind   StateIssueKind
-	Name   string // tool or runtime name
-	Path   string // the path that has the issue
-	Target string // symlink target (for broken_symlink)
+	Kind         StateIssueKind
+	ResourceKind resource.Kind // KindTool or KindRuntime
+	Name         string        // tool or runtime name
+	Path         string        // the path that has the issue
+	Target       string        // symlink target (for broken_symlink)
 }
 
 // Message returns a human-readable description of the issue.
@@ -138,6 +141,13 @@ func (d *Doctor) Check(ctx context.Context) (*Result, error) {
 	}
 	result.StateIssues = issues
 
+	// 4. Rehash installed files
+	drift, err := d.CheckDrift(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result.Drift = drift.Issues
+
 	return result, nil
 }
 
@@ -148,7 +158,7 @@ func (r *Result) HasIssues() bool {
 			return true
 		}
 	}
-	return len(r.Conflicts) > 0 || len(r.StateIssues) > 0
+	return len(r.Conflicts) > 0 || len(r.StateIssues) > 0 || len(r.Drift) > 0
 }
 
 // UnmanagedToolNames returns all unmanaged tool names for suggestions.
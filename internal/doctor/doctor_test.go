@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/terassyi/tomei/internal/checksum"
 	"github.com/terassyi/tomei/internal/path"
 	"github.com/terassyi/tomei/internal/resource"
 	"github.com/terassyi/tomei/internal/state"
@@ -215,6 +216,34 @@ func TestDoctor_ScanForUnmanaged(t *testing.T) {
 	})
 }
 
+func TestDoctor_AddScanPath(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	binDir := filepath.Join(tmpDir, "bin")
+	goBin := filepath.Join(tmpDir, "go", "bin")
+	otherBin := filepath.Join(tmpDir, "other", "bin")
+	for _, dir := range []string{binDir, goBin, otherBin} {
+		require.NoError(t, os.MkdirAll(dir, 0755))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(goBin, "gopls"), []byte("gopls"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(otherBin, "other"), []byte("other"), 0755))
+
+	paths, err := path.New(path.WithUserBinDir(binDir))
+	require.NoError(t, err)
+
+	doc, err := New(paths, &state.UserState{})
+	require.NoError(t, err)
+	doc.AddScanPath("go", goBin)
+	doc.AddScanPath("go", otherBin) // already scanned
+
+	unmanaged, err := doc.ScanUnmanaged()
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]UnmanagedTool{
+		"go": {{Name: "gopls", Path: filepath.Join(goBin, "gopls")}},
+	}, unmanaged)
+}
+
 func TestDoctor_DetectConflicts(t *testing.T) {
 	t.Parallel()
 
@@ -559,3 +588,183 @@ func TestDoctor_IsRuntimeBinary(t *testing.T) {
 		assert.Empty(t, unmanaged["go"])
 	})
 }
+
+func TestDoctor_PlanRepairs(t *testing.T) {
+	t.Parallel()
+
+	paths, err := path.New(path.WithUserBinDir("/home/user/.local/bin"))
+	require.NoError(t, err)
+
+	userState := &state.UserState{
+		Tools: map[string]*resource.ToolState{
+			"rg": {
+				Version:     "14.1.0",
+				InstallPath: "/data/tools/rg/14.1.0/rg",
+				BinPath:     "/home/user/.local/bin/rg",
+			},
+			"jq": {
+				Version:     "1.7.1",
+				InstallPath: "/data/tools/jq/1.7.1/jq",
+				BinPath:     "/home/user/.local/bin/jq",
+			},
+			"gopls": {
+				Version:     "0.17.0",
+				RuntimeRef:  "go",
+				InstallPath: "/home/user/go/bin/gopls",
+				BinPath:     "/home/user/go/bin/gopls",
+			},
+			"old": {
+				Version:     "1.0.0",
+				InstallPath: "/data/tools/old/1.0.0/old",
+				BinPath:     "/home/user/.local/bin/old",
+			},
+		},
+		Runtimes: map[string]*resource.RuntimeState{
+			"go": {
+				Version:     "1.25.5",
+				InstallPath: "/data/runtimes/go/1.25.5",
+				BinDir:      "/home/user/go/bin",
+				Binaries:    []string{"go", "gofmt"},
+			},
+		},
+	}
+	doc, err := New(paths, userState)
+	require.NoError(t, err)
+
+	result := &Result{
+		StateIssues: []StateIssue{
+			{Kind: StateIssueBrokenSymlink, ResourceKind: resource.KindTool, Name: "rg", Path: "/home/user/.local/bin/rg"},
+			{Kind: StateIssueMissingBinary, ResourceKind: resource.KindTool, Name: "jq", Path: "/home/user/.local/bin/jq"},
+			{Kind: StateIssueMissingInstallDir, ResourceKind: resource.KindTool, Name: "jq", Path: "/data/tools/jq/1.7.1/jq"},
+			{Kind: StateIssueMissingBinary, ResourceKind: resource.KindTool, Name: "gopls", Path: "/home/user/go/bin/gopls"},
+			{Kind: StateIssueMissingBinary, ResourceKind: resource.KindTool, Name: "old", Path: "/home/user/.local/bin/old"},
+			{Kind: StateIssueMissingBinary, ResourceKind: resource.KindRuntime, Name: "go", Path: "/home/user/go/bin/gofmt"},
+		},
+		Conflicts: []Conflict{
+			{Name: "unmanaged", Locations: []string{"/home/user/.local/bin", "/home/user/go/bin"}, ResolvedTo: "/home/user/go/bin/unmanaged"},
+			{Name: "go", Locations: []string{"/home/user/.local/bin", "/home/user/go/bin"}, ResolvedTo: "/home/user/.local/bin/go"},
+		},
+	}
+	declared := func(_ resource.Kind, name string) bool { return name != "old" }
+
+	repairs := doc.PlanRepairs(result, declared)
+	require.Len(t, repairs, 6)
+	assert.Equal(t, Repair{Action: RepairRelink, ResourceKind: resource.KindRuntime, Name: "go", Version: "1.25.5", Reason: "binary not found at /home/user/go/bin/gofmt"}, repairs[0])
+	assert.Equal(t, Repair{Action: RepairRelink, ResourceKind: resource.KindTool, Name: "rg", Version: "14.1.0", Reason: "broken symlink at /home/user/.local/bin/rg"}, repairs[1])
+	assert.Equal(t, RepairReinstall, repairs[2].Action)
+	assert.Equal(t, "gopls", repairs[2].Name, "runtime-installed tools have nothing to relink")
+	assert.Equal(t, RepairReinstall, repairs[3].Action)
+	assert.Equal(t, "jq", repairs[3].Name)
+	assert.Equal(t, Repair{Action: RepairDrop, ResourceKind: resource.KindTool, Name: "old", Version: "1.0.0", Reason: "binary not found at /home/user/.local/bin/old"}, repairs[4])
+	assert.Equal(t, RepairRegenerateEnv, repairs[5].Action)
+	assert.Equal(t, "go resolves to /home/user/.local/bin/go instead of the tomei-managed binary", repairs[5].Reason)
+
+	t.Run("no repairs when PATH resolves to the managed binary", func(t *testing.T) {
+		t.Parallel()
+		result := &Result{
+			Conflicts: []Conflict{
+				{Name: "go", Locations: []string{"/home/user/.local/bin", "/home/user/go/bin"}, ResolvedTo: "/home/user/go/bin/go"},
+			},
+		}
+		assert.Empty(t, doc.PlanRepairs(result, declared))
+	})
+
+	t.Run("drifted resources are reinstalled", func(t *testing.T) {
+		t.Parallel()
+		result := &Result{
+			StateIssues: []StateIssue{
+				{Kind: StateIssueBrokenSymlink, ResourceKind: resource.KindTool, Name: "rg", Path: "/home/user/.local/bin/rg"},
+			},
+			Drift: []DriftIssue{
+				{Kind: DriftModified, ResourceKind: resource.KindTool, Name: "rg", Path: "/data/tools/rg/14.1.0/rg"},
+				{Kind: DriftModified, ResourceKind: resource.KindTool, Name: "old", Path: "/data/tools/old/1.0.0/old"},
+			},
+		}
+		repairs := doc.PlanRepairs(result, declared)
+		require.Len(t, repairs, 2)
+		assert.Equal(t, Repair{Action: RepairReinstall, ResourceKind: resource.KindTool, Name: "rg", Version: "14.1.0", Reason: "/data/tools/rg/14.1.0/rg was modified after installation"}, repairs[0])
+		assert.Equal(t, RepairDrop, repairs[1].Action)
+	})
+}
+
+func TestDoctor_CheckDrift(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	binDir := filepath.Join(tmpDir, "bin")
+	toolDir := filepath.Join(tmpDir, "tools", "kubectl", "1.31.0")
+	runtimeDir := filepath.Join(tmpDir, "runtimes", "go", "1.25.5")
+	goBinDir := filepath.Join(tmpDir, "go", "bin")
+	for _, dir := range []string{binDir, toolDir, filepath.Join(runtimeDir, "bin"), goBinDir} {
+		require.NoError(t, os.MkdirAll(dir, 0755))
+	}
+
+	binary := filepath.Join(toolDir, "kubectl")
+	require.NoError(t, os.WriteFile(binary, []byte("kubectl"), 0755))
+	binaryDigest, err := checksum.Calculate(binary, checksum.AlgorithmSHA256)
+	require.NoError(t, err)
+	link := filepath.Join(binDir, "kubectl")
+	require.NoError(t, os.Symlink(binary, link))
+
+	goBinary := filepath.Join(runtimeDir, "bin", "go")
+	require.NoError(t, os.WriteFile(goBinary, []byte("go"), 0755))
+	require.NoError(t, os.Symlink(goBinary, filepath.Join(goBinDir, "go")))
+	treeDigest, err := checksum.WriteTreeManifest(runtimeDir)
+	require.NoError(t, err)
+
+	extraDir := filepath.Join(tmpDir, "runtimes", "go", "1.24.3")
+	require.NoError(t, os.MkdirAll(filepath.Join(extraDir, "bin"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(extraDir, "bin", "go"), []byte("go1.24"), 0755))
+	extraDigest, err := checksum.WriteTreeManifest(extraDir)
+	require.NoError(t, err)
+
+	paths, err := path.New(path.WithUserBinDir(binDir))
+	require.NoError(t, err)
+
+	newState := func() *state.UserState {
+		return &state.UserState{
+			Tools: map[string]*resource.ToolState{
+				"kubectl": {Version: "1.31.0", InstallPath: binary, BinPath: link, BinaryDigest: binaryDigest},
+				"legacy":  {Version: "1.0.0", InstallPath: filepath.Join(tmpDir, "legacy"), BinPath: filepath.Join(binDir, "legacy")},
+			},
+			Runtimes: map[string]*resource.RuntimeState{
+				"go": {
+					Version: "1.25.5", InstallPath: runtimeDir, BinDir: goBinDir, Binaries: []string{"go"}, TreeDigest: treeDigest,
+					Extras: map[string]*resource.RuntimeVersionState{
+						"1.24.3": {InstallPath: extraDir, BinDir: filepath.Join(extraDir, "bin"), TreeDigest: extraDigest},
+					},
+				},
+			},
+		}
+	}
+
+	doc, err := New(paths, newState())
+	require.NoError(t, err)
+	report, err := doc.CheckDrift(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, report.Issues)
+	assert.Equal(t, []string{"Runtime/go", "Tool/kubectl"}, report.Verified)
+	assert.Equal(t, []string{"Tool/legacy"}, report.Unrecorded)
+
+	// Overwrite the binary, drop a file next to it and tamper with the runtime tree
+	require.NoError(t, os.WriteFile(binary, []byte("tampered"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(toolDir, "extra"), []byte("x"), 0644))
+	require.NoError(t, os.WriteFile(goBinary, []byte("tampered"), 0755))
+	require.NoError(t, os.Remove(filepath.Join(goBinDir, "go")))
+	require.NoError(t, os.WriteFile(filepath.Join(goBinDir, "go"), []byte("go"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(extraDir, "bin", "gopls"), []byte("gopls"), 0755))
+
+	doc, err = New(paths, newState())
+	require.NoError(t, err)
+	report, err = doc.CheckDrift(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []DriftIssue{
+		{Kind: DriftReplaced, ResourceKind: resource.KindRuntime, Name: "go", Path: filepath.Join(goBinDir, "go"), Detail: "regular file instead of symlink"},
+		{Kind: DriftUnexpected, ResourceKind: resource.KindRuntime, Name: "go", Path: filepath.Join(extraDir, "bin", "gopls")},
+		{Kind: DriftModified, ResourceKind: resource.KindRuntime, Name: "go", Path: goBinary},
+		{Kind: DriftModified, ResourceKind: resource.KindTool, Name: "kubectl", Path: binary},
+		{Kind: DriftUnexpected, ResourceKind: resource.KindTool, Name: "kubectl", Path: filepath.Join(toolDir, "extra")},
+	}, report.Issues)
+	assert.Equal(t, []string{"Runtime/go", "Tool/kubectl"}, report.Drifted())
+	assert.Empty(t, report.Verified)
+}
@@ -0,0 +1,313 @@
+package doctor
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/terassyi/tomei/internal/checksum"
+	"github.com/terassyi/tomei/internal/path"
+	"github.com/terassyi/tomei/internal/resource"
+)
+
+// DriftKind represents how an installed file differs from what was recorded at install time.
+type DriftKind string
+
+const (
+	// DriftModified indicates the file contents differ from the recorded digest.
+	DriftModified DriftKind = "modified"
+	// DriftReplaced indicates a tomei-managed symlink was replaced or points elsewhere.
+	DriftReplaced DriftKind = "replaced"
+	// DriftMissing indicates a recorded file no longer exists.
+	DriftMissing DriftKind = "missing"
+	// DriftUnexpected indicates a file that was not installed by tomei.
+	DriftUnexpected DriftKind = "unexpected"
+)
+
+// DriftIssue represents an installed file that changed after installation.
+type DriftIssue struct {
+	Kind         DriftKind
+	ResourceKind resource.Kind // KindTool or KindRuntime
+	Name         string        // tool or runtime name
+	Path         string        // the file that changed
+	Detail       string        // e.g., the current symlink target
+}
+
+// Message returns a human-readable description of the issue.
+func (i DriftIssue) Message() string {
+	switch i.Kind {
+	case DriftModified:
+		return fmt.Sprintf("%s was modified after installation", i.Path)
+	case DriftReplaced:
+		if i.Detail != "" {
+			return fmt.Sprintf("%s was replaced (%s)", i.Path, i.Detail)
+		}
+		return fmt.Sprintf("%s was replaced", i.Path)
+	case DriftMissing:
+		return fmt.Sprintf("%s was removed after installation", i.Path)
+	case DriftUnexpected:
+		return fmt.Sprintf("%s was not installed by tomei", i.Path)
+	default:
+		return fmt.Sprintf("unknown drift at %s", i.Path)
+	}
+}
+
+// DriftReport contains the results of rehashing installed files.
+type DriftReport struct {
+	// Issues contains the files that changed after installation.
+	Issues []DriftIssue
+	// Verified lists the resources ("Kind/name") whose files match their recorded digests.
+	Verified []string
+	// Unrecorded lists the resources ("Kind/name") installed without a recorded
+	// digest (installed by an older tomei, or by a delegation installer).
+	Unrecorded []string
+}
+
+// Drifted returns the resources ("Kind/name") that have drift issues, sorted.
+func (r *DriftReport) Drifted() []string {
+	seen := make(map[string]bool)
+	for _, issue := range r.Issues {
+		seen[string(issue.ResourceKind)+"/"+issue.Name] = true
+	}
+	return slices.Sorted(maps.Keys(seen))
+}
+
+// CheckDrift rehashes every managed binary and runtime tree and compares
+// them with the digests recorded at install time.
+func (d *Doctor) CheckDrift(ctx context.Context) (*DriftReport, error) {
+	report := &DriftReport{}
+	if d.state == nil {
+		return report, nil
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(d.state.Runtimes)) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		key := string(resource.KindRuntime) + "/" + name
+		rs := d.state.Runtimes[name]
+		if rs.TreeDigest == "" || rs.InstallPath == "" {
+			report.Unrecorded = append(report.Unrecorded, key)
+			continue
+		}
+		issues, err := checkRuntimeDrift(name, rs)
+		if err != nil {
+			return nil, err
+		}
+		report.add(key, issues)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(d.state.Tools)) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		key := string(resource.KindTool) + "/" + name
+		ts := d.state.Tools[name]
+		if ts.BinaryDigest == "" {
+			report.Unrecorded = append(report.Unrecorded, key)
+			continue
+		}
+		issues, err := checkToolDrift(name, ts)
+		if err != nil {
+			return nil, err
+		}
+		report.add(key, issues)
+	}
+
+	return report, nil
+}
+
+// add records the issues of a resource, or the resource as verified.
+func (r *DriftReport) add(key string, issues []DriftIssue) {
+	if len(issues) == 0 {
+		r.Verified = append(r.Verified, key)
+		return
+	}
+	r.Issues = append(r.Issues, issues...)
+}
+
+// checkToolDrift rehashes the installed binary of a tool and checks that its
+// symlink still points to it. Files next to a placed binary are unexpected.
+func checkToolDrift(name string, ts *resource.ToolState) ([]DriftIssue, error) {
+	issue := func(kind DriftKind, p, detail string) DriftIssue {
+		return DriftIssue{Kind: kind, ResourceKind: resource.KindTool, Name: name, Path: p, Detail: detail}
+	}
+
+	binaryPath := ts.InstallPath
+	if binaryPath == "" {
+		binaryPath = ts.BinPath
+	}
+	binaryPath, err := path.Expand(binaryPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []DriftIssue
+	if kind, ok, err := compareFile(binaryPath, ts.BinaryDigest); err != nil {
+		return nil, err
+	} else if !ok {
+		issues = append(issues, issue(kind, binaryPath, ""))
+	}
+
+	// Download pattern: the binary is placed in its own directory and linked
+	if ts.InstallPath == "" || ts.BinPath == "" || ts.InstallPath == ts.BinPath {
+		return issues, nil
+	}
+	linkPath, err := path.Expand(ts.BinPath)
+	if err != nil {
+		return nil, err
+	}
+	if detail, ok := checkSymlink(linkPath, func(target string) bool { return target == binaryPath }); !ok {
+		issues = append(issues, issue(DriftReplaced, linkPath, detail))
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(binaryPath))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if p := filepath.Join(filepath.Dir(binaryPath), entry.Name()); p != binaryPath {
+			issues = append(issues, issue(DriftUnexpected, p, ""))
+		}
+	}
+	return issues, nil
+}
+
+// checkRuntimeDrift rehashes the install tree of a runtime against its
+// recorded manifest and checks the symlinks of its binaries.
+func checkRuntimeDrift(name string, rs *resource.RuntimeState) ([]DriftIssue, error) {
+	issue := func(kind DriftKind, p, detail string) DriftIssue {
+		return DriftIssue{Kind: kind, ResourceKind: resource.KindRuntime, Name: name, Path: p, Detail: detail}
+	}
+
+	installPath, err := path.Expand(rs.InstallPath)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(installPath); os.IsNotExist(err) {
+		return []DriftIssue{issue(DriftMissing, installPath, "")}, nil
+	}
+
+	issues, err := checkTreeDrift(issue, installPath, rs.TreeDigest)
+	if err != nil {
+		return nil, err
+	}
+
+	// Extra versions are installed side by side without symlinks
+	for _, version := range rs.ExtraVersions() {
+		extra := rs.Extras[version]
+		if extra.TreeDigest == "" || extra.InstallPath == "" {
+			continue
+		}
+		extraPath, err := path.Expand(extra.InstallPath)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(extraPath); os.IsNotExist(err) {
+			issues = append(issues, issue(DriftMissing, extraPath, ""))
+			continue
+		}
+		extraIssues, err := checkTreeDrift(issue, extraPath, extra.TreeDigest)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, extraIssues...)
+	}
+
+	// Binaries linked from outside the install tree must point into it
+	binDir, err := path.Expand(rs.BinDir)
+	if err != nil {
+		return nil, err
+	}
+	if binDir != "" && !isUnder(binDir, installPath) {
+		for _, binary := range rs.Binaries {
+			linkPath := filepath.Join(binDir, binary)
+			if detail, ok := checkSymlink(linkPath, func(target string) bool { return isUnder(target, installPath) }); !ok {
+				issues = append(issues, issue(DriftReplaced, linkPath, detail))
+			}
+		}
+	}
+
+	slices.SortFunc(issues, func(a, b DriftIssue) int {
+		return cmp.Compare(a.Path, b.Path)
+	})
+	return issues, nil
+}
+
+// checkTreeDrift rehashes an install tree against the manifest recorded as treeDigest.
+func checkTreeDrift(issue func(kind DriftKind, p, detail string) DriftIssue, installPath string, treeDigest checksum.Digest) ([]DriftIssue, error) {
+	manifest, err := checksum.ReadTreeManifest(installPath, treeDigest)
+	if err != nil {
+		// Without a trustworthy manifest the individual files cannot be compared
+		return []DriftIssue{issue(DriftModified, filepath.Join(installPath, checksum.TreeManifestFile), "")}, nil
+	}
+	current, err := checksum.HashTree(installPath)
+	if err != nil {
+		return nil, err
+	}
+	var issues []DriftIssue
+	diff := manifest.Diff(current)
+	for _, p := range diff.Modified {
+		issues = append(issues, issue(DriftModified, filepath.Join(installPath, filepath.FromSlash(p)), ""))
+	}
+	for _, p := range diff.Missing {
+		issues = append(issues, issue(DriftMissing, filepath.Join(installPath, filepath.FromSlash(p)), ""))
+	}
+	for _, p := range diff.Unexpected {
+		issues = append(issues, issue(DriftUnexpected, filepath.Join(installPath, filepath.FromSlash(p)), ""))
+	}
+	return issues, nil
+}
+
+// compareFile rehashes a file and reports whether it matches the recorded digest.
+func compareFile(p string, recorded checksum.Digest) (DriftKind, bool, error) {
+	current, err := checksum.Calculate(p, checksum.AlgorithmSHA256)
+	if err != nil {
+		if _, statErr := os.Stat(p); os.IsNotExist(statErr) {
+			return DriftMissing, false, nil
+		}
+		return "", false, err
+	}
+	if current != recorded {
+		return DriftModified, false, nil
+	}
+	return "", true, nil
+}
+
+// checkSymlink reports whether linkPath is a symlink whose resolved target
+// satisfies want. Otherwise it returns a description of what is there.
+// A missing link is reported by the state integrity check and accepted here.
+func checkSymlink(linkPath string, want func(target string) bool) (string, bool) {
+	info, err := os.Lstat(linkPath)
+	if err != nil {
+		return "", true
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		return "regular file instead of symlink", false
+	}
+	target, err := os.Readlink(linkPath)
+	if err != nil {
+		return "unreadable symlink", false
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(linkPath), target)
+	}
+	if !want(filepath.Clean(target)) {
+		return "points to " + target, false
+	}
+	return "", true
+}
+
+// isUnder reports whether p is dir or inside it.
+func isUnder(p, dir string) bool {
+	rel, err := filepath.Rel(dir, p)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 
 	"github.com/terassyi/tomei/internal/path"
+	"github.com/terassyi/tomei/internal/resource"
 )
 
 // checkStateIntegrity verifies that the state matches the filesystem.
@@ -48,9 +49,10 @@ func (d *Doctor) checkToolIntegrity() ([]StateIssue, error) {
 			if err != nil {
 				if os.IsNotExist(err) {
 					issues = append(issues, StateIssue{
-						Kind: StateIssueMissingBinary,
-						Name: name,
-						Path: binPath,
+						Kind:         StateIssueMissingBinary,
+						ResourceKind: resource.KindTool,
+						Name:         name,
+						Path:         binPath,
 					})
 					continue
 				}
@@ -62,9 +64,10 @@ func (d *Doctor) checkToolIntegrity() ([]StateIssue, error) {
 				target, err := os.Readlink(binPath)
 				if err != nil {
 					issues = append(issues, StateIssue{
-						Kind: StateIssueBrokenSymlink,
-						Name: name,
-						Path: binPath,
+						Kind:         StateIssueBrokenSymlink,
+						ResourceKind: resource.KindTool,
+						Name:         name,
+						Path:         binPath,
 					})
 					continue
 				}
@@ -77,10 +80,11 @@ func (d *Doctor) checkToolIntegrity() ([]StateIssue, error) {
 
 				if _, err := os.Stat(targetPath); os.IsNotExist(err) {
 					issues = append(issues, StateIssue{
-						Kind:   StateIssueBrokenSymlink,
-						Name:   name,
-						Path:   binPath,
-						Target: target,
+						Kind:         StateIssueBrokenSymlink,
+						ResourceKind: resource.KindTool,
+						Name:         name,
+						Path:         binPath,
+						Target:       target,
 					})
 				}
 			}
@@ -95,9 +99,10 @@ func (d *Doctor) checkToolIntegrity() ([]StateIssue, error) {
 
 			if _, err := os.Stat(installPath); os.IsNotExist(err) {
 				issues = append(issues, StateIssue{
-					Kind: StateIssueMissingInstallDir,
-					Name: name,
-					Path: installPath,
+					Kind:         StateIssueMissingInstallDir,
+					ResourceKind: resource.KindTool,
+					Name:         name,
+					Path:         installPath,
 				})
 			}
 		}
@@ -124,9 +129,10 @@ func (d *Doctor) checkRuntimeIntegrity() ([]StateIssue, error) {
 
 			if _, err := os.Stat(installPath); os.IsNotExist(err) {
 				issues = append(issues, StateIssue{
-					Kind: StateIssueMissingInstallDir,
-					Name: name,
-					Path: installPath,
+					Kind:         StateIssueMissingInstallDir,
+					ResourceKind: resource.KindRuntime,
+					Name:         name,
+					Path:         installPath,
 				})
 			}
 		}
@@ -143,9 +149,10 @@ func (d *Doctor) checkRuntimeIntegrity() ([]StateIssue, error) {
 			if err != nil {
 				if os.IsNotExist(err) {
 					issues = append(issues, StateIssue{
-						Kind: StateIssueMissingBinary,
-						Name: name,
-						Path: binPath,
+						Kind:         StateIssueMissingBinary,
+						ResourceKind: resource.KindRuntime,
+						Name:         name,
+						Path:         binPath,
 					})
 					continue
 				}
@@ -157,9 +164,10 @@ func (d *Doctor) checkRuntimeIntegrity() ([]StateIssue, error) {
 				target, err := os.Readlink(binPath)
 				if err != nil {
 					issues = append(issues, StateIssue{
-						Kind: StateIssueBrokenSymlink,
-						Name: name,
-						Path: binPath,
+						Kind:         StateIssueBrokenSymlink,
+						ResourceKind: resource.KindRuntime,
+						Name:         name,
+						Path:         binPath,
 					})
 					continue
 				}
@@ -171,10 +179,11 @@ func (d *Doctor) checkRuntimeIntegrity() ([]StateIssue, error) {
 
 				if _, err := os.Stat(targetPath); os.IsNotExist(err) {
 					issues = append(issues, StateIssue{
-						Kind:   StateIssueBrokenSymlink,
-						Name:   name,
-						Path:   binPath,
-						Target: target,
+						Kind:         StateIssueBrokenSymlink,
+						ResourceKind: resource.KindRuntime,
+						Name:         name,
+						Path:         binPath,
+						Target:       target,
 					})
 				}
 			}
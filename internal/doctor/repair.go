@@ -0,0 +1,219 @@
+package doctor
+
+import (
+	"cmp"
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"github.com/terassyi/tomei/internal/path"
+	"github.com/terassyi/tomei/internal/resource"
+)
+
+// RepairAction represents how an issue found by Check is repaired.
+type RepairAction string
+
+const (
+	// RepairRelink re-creates the symlinks of a resource whose installed files still exist.
+	RepairRelink RepairAction = "relink"
+	// RepairReinstall reinstalls a resource whose installed files are gone.
+	RepairReinstall RepairAction = "reinstall"
+	// RepairDrop removes the state entry of a broken resource that is no longer in the manifests.
+	RepairDrop RepairAction = "drop"
+	// RepairRegenerateEnv rewrites the exported env files to fix PATH ordering.
+	RepairRegenerateEnv RepairAction = "regenerate_env"
+)
+
+// Repair is a planned fix for one or more issues found by Check.
+type Repair struct {
+	Action RepairAction
+	// ResourceKind and Name identify the resource (empty for RepairRegenerateEnv).
+	ResourceKind resource.Kind
+	Name         string
+	// Version is the installed version that is reinstalled or relinked.
+	Version string
+	// Reason describes the issue being repaired.
+	Reason string
+}
+
+// Message returns a human-readable description of the repair.
+func (r Repair) Message() string {
+	switch r.Action {
+	case RepairRelink:
+		return fmt.Sprintf("re-create symlinks of %s/%s %s", r.ResourceKind, r.Name, r.Version)
+	case RepairReinstall:
+		return fmt.Sprintf("reinstall %s/%s %s", r.ResourceKind, r.Name, r.Version)
+	case RepairDrop:
+		return fmt.Sprintf("drop %s/%s from state (not in manifests)", r.ResourceKind, r.Name)
+	case RepairRegenerateEnv:
+		return "regenerate env files to fix PATH ordering"
+	default:
+		return fmt.Sprintf("unknown repair %s", r.Action)
+	}
+}
+
+// PlanRepairs returns the repairs for the state issues, drifted files and PATH
+// conflicts in result, sorted by action order. declared reports whether a resource is
+// still defined in the manifests; broken resources that are not are dropped
+// from state instead of being reinstalled. Unmanaged tools are not repaired.
+func (d *Doctor) PlanRepairs(result *Result, declared func(kind resource.Kind, name string) bool) []Repair {
+	type key struct {
+		kind resource.Kind
+		name string
+	}
+	grouped := make(map[key][]StateIssue)
+	for _, issue := range result.StateIssues {
+		k := key{issue.ResourceKind, issue.Name}
+		grouped[k] = append(grouped[k], issue)
+	}
+	drifted := make(map[key]DriftIssue)
+	for _, issue := range result.Drift {
+		k := key{issue.ResourceKind, issue.Name}
+		if _, ok := drifted[k]; !ok {
+			drifted[k] = issue
+		}
+		if _, ok := grouped[k]; !ok {
+			grouped[k] = nil
+		}
+	}
+
+	var repairs []Repair
+	for k, issues := range grouped {
+		repair := Repair{
+			ResourceKind: k.kind,
+			Name:         k.name,
+			Version:      d.installedVersion(k.kind, k.name),
+		}
+		// Drifted files are restored by reinstalling
+		drift, isDrifted := drifted[k]
+		if isDrifted {
+			repair.Reason = drift.Message()
+		} else {
+			repair.Reason = issues[0].Message()
+		}
+		switch {
+		case !declared(k.kind, k.name):
+			repair.Action = RepairDrop
+		case !isDrifted && d.canRelink(k.kind, k.name, issues):
+			repair.Action = RepairRelink
+		default:
+			repair.Action = RepairReinstall
+		}
+		repairs = append(repairs, repair)
+	}
+	slices.SortFunc(repairs, func(a, b Repair) int {
+		return cmp.Or(
+			cmp.Compare(repairOrder(a.Action), repairOrder(b.Action)),
+			cmp.Compare(a.ResourceKind, b.ResourceKind),
+			cmp.Compare(a.Name, b.Name),
+		)
+	})
+
+	if conflict, ok := d.misorderedConflict(result.Conflicts); ok {
+		reason := fmt.Sprintf("%s is not found in PATH", conflict.Name)
+		if conflict.ResolvedTo != "" {
+			reason = fmt.Sprintf("%s resolves to %s instead of the tomei-managed binary", conflict.Name, conflict.ResolvedTo)
+		}
+		repairs = append(repairs, Repair{Action: RepairRegenerateEnv, Reason: reason})
+	}
+	return repairs
+}
+
+// repairOrder returns the display order of a repair action.
+func repairOrder(a RepairAction) int {
+	switch a {
+	case RepairRelink:
+		return 0
+	case RepairReinstall:
+		return 1
+	case RepairDrop:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// installedVersion returns the version recorded in state for a resource.
+func (d *Doctor) installedVersion(kind resource.Kind, name string) string {
+	if d.state == nil {
+		return ""
+	}
+	switch kind {
+	case resource.KindTool:
+		if ts, ok := d.state.Tools[name]; ok {
+			return ts.Version
+		}
+	case resource.KindRuntime:
+		if rs, ok := d.state.Runtimes[name]; ok {
+			return rs.Version
+		}
+	}
+	return ""
+}
+
+// canRelink reports whether the installed files of a resource still exist,
+// so that reinstalling it only re-creates its symlinks. Tools installed by a
+// runtime or installer have no separate install path to link from.
+func (d *Doctor) canRelink(kind resource.Kind, name string, issues []StateIssue) bool {
+	for _, issue := range issues {
+		if issue.Kind == StateIssueMissingInstallDir {
+			return false
+		}
+	}
+	if kind == resource.KindTool {
+		ts, ok := d.state.Tools[name]
+		return ok && ts.InstallPath != "" && ts.InstallPath != ts.BinPath
+	}
+	return true
+}
+
+// misorderedConflict returns the first conflict where PATH resolves a
+// tomei-managed binary to another location, which means the env file is
+// missing or sourced before other PATH changes.
+func (d *Doctor) misorderedConflict(conflicts []Conflict) (Conflict, bool) {
+	managed := d.managedBinDirs()
+	sorted := slices.SortedFunc(slices.Values(conflicts), func(a, b Conflict) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	for _, c := range sorted {
+		dir, ok := managed[c.Name]
+		if !ok {
+			continue
+		}
+		if c.ResolvedTo == "" || filepath.Dir(c.ResolvedTo) != dir {
+			return c, true
+		}
+	}
+	return Conflict{}, false
+}
+
+// managedBinDirs maps binary names to the directory tomei links them into.
+func (d *Doctor) managedBinDirs() map[string]string {
+	dirs := make(map[string]string)
+	if d.state == nil {
+		return dirs
+	}
+	for _, ts := range d.state.Tools {
+		if ts.BinPath == "" {
+			continue
+		}
+		binPath, err := path.Expand(ts.BinPath)
+		if err != nil {
+			continue
+		}
+		dirs[filepath.Base(binPath)] = filepath.Dir(binPath)
+	}
+	for _, rs := range d.state.Runtimes {
+		if rs.BinDir == "" {
+			continue
+		}
+		binDir, err := path.Expand(rs.BinDir)
+		if err != nil {
+			continue
+		}
+		for _, binary := range rs.Binaries {
+			dirs[binary] = binDir
+		}
+	}
+	return dirs
+}
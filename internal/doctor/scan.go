@@ -12,6 +12,20 @@ import (
 // executableBits is the Unix permission bitmask for executable files (owner/group/other execute).
 const executableBits os.FileMode = 0111
 
+// AddScanPath adds a directory to scan for unmanaged tools under category,
+// unless the category is already scanned (e.g., a runtime recorded in state).
+func (d *Doctor) AddScanPath(category, dir string) {
+	if _, ok := d.scanPaths[category]; ok {
+		return
+	}
+	d.scanPaths[category] = dir
+}
+
+// ScanUnmanaged scans all paths and returns unmanaged tools by category.
+func (d *Doctor) ScanUnmanaged() (map[string][]UnmanagedTool, error) {
+	return d.scanForUnmanaged()
+}
+
 // scanForUnmanaged scans all paths and returns unmanaged tools.
 func (d *Doctor) scanForUnmanaged() (map[string][]UnmanagedTool, error) {
 	result := make(map[string][]UnmanagedTool)
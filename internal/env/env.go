@@ -3,6 +3,7 @@ package env
 import (
 	"maps"
 	"os"
+	"path/filepath"
 	"slices"
 	"strings"
 
@@ -13,12 +14,15 @@ import (
 // Generate produces environment variable statements for the given runtimes and installers.
 // It collects env vars from each runtime and builds a PATH statement
 // with BinDir, ToolBinPath from runtimes, BinDir from installers, plus the user bin directory.
-// PATH ordering: userBinDir > runtime BinDir/ToolBinPath > installer BinDir > $PATH.
+// PATH ordering: version bin dirs > userBinDir > runtime BinDir/ToolBinPath > installer BinDir > $PATH.
+// A runtime BinDir inside its InstallPath (a version selected with ForVersion)
+// holds that version's own binaries, so it precedes userBinDir, whose
+// symlinks point at the default version.
 func Generate(runtimes map[string]*resource.RuntimeState, installers map[string]*resource.InstallerState, userBinDir string, f Formatter) []string {
 	var lines []string
-	var pathDirs []string
+	var versionDirs, pathDirs []string
 
-	// Add user bin dir first (highest priority in PATH)
+	// Add user bin dir first (highest priority in PATH after version bin dirs)
 	pathDirs = append(pathDirs, toShellPath(userBinDir))
 
 	// Process each runtime in sorted order (deterministic output)
@@ -31,7 +35,11 @@ func Generate(runtimes map[string]*resource.RuntimeState, installers map[string]
 		}
 
 		// Collect PATH directories
-		if rs.BinDir != "" {
+		switch {
+		case rs.BinDir == "":
+		case isInstallDir(rs.BinDir, rs.InstallPath):
+			versionDirs = append(versionDirs, toShellPath(rs.BinDir))
+		default:
 			pathDirs = append(pathDirs, toShellPath(rs.BinDir))
 		}
 		if rs.ToolBinPath != "" && rs.ToolBinPath != rs.BinDir {
@@ -47,7 +55,7 @@ func Generate(runtimes map[string]*resource.RuntimeState, installers map[string]
 	}
 
 	// Deduplicate and add PATH statement
-	pathDirs = dedupStrings(pathDirs)
+	pathDirs = dedupStrings(append(versionDirs, pathDirs...))
 	if len(pathDirs) > 0 {
 		lines = append(lines, f.ExportPath(pathDirs))
 	}
@@ -55,6 +63,15 @@ func Generate(runtimes map[string]*resource.RuntimeState, installers map[string]
 	return lines
 }
 
+// isInstallDir reports whether dir is inside the install path installPath.
+func isInstallDir(dir, installPath string) bool {
+	if installPath == "" {
+		return false
+	}
+	rel, err := filepath.Rel(installPath, dir)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, "../")
+}
+
 // GenerateCUERegistry returns a CUE_REGISTRY export statement if cueModExists is true
 // and cueRegistry is non-empty.
 // This enables CUE tooling (cue eval, LSP) to resolve tomei module imports.
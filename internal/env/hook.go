@@ -0,0 +1,237 @@
+package env
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/terassyi/tomei/internal/resource"
+)
+
+// HookVar is the environment variable in which the shell hook records the
+// active layer, so that the next prompt can deactivate it.
+const HookVar = "TOMEI_HOOK"
+
+// Layer is the environment that the shell hook activates for the working
+// directory on top of the global environment.
+type Layer struct {
+	// Sources lists what the layer was built from (project roots and version
+	// files). Warnings about them are printed once, when the layer changes.
+	Sources []string `json:"sources,omitempty"`
+	// Path lists the directories prepended to PATH.
+	Path []string `json:"path,omitempty"`
+	// Env holds the environment variables set by the layer.
+	Env map[string]string `json:"env,omitempty"`
+}
+
+// empty reports whether the layer changes nothing.
+func (l *Layer) empty() bool {
+	return len(l.Sources) == 0 && len(l.Path) == 0 && len(l.Env) == 0
+}
+
+// equal reports whether two layers are the same.
+func (l *Layer) equal(o *Layer) bool {
+	return slices.Equal(l.Sources, o.Sources) && slices.Equal(l.Path, o.Path) && maps.Equal(l.Env, o.Env)
+}
+
+// ProjectLayer returns a layer with the bin directory of a project, which
+// holds the binaries of its tools and runtimes, and the Env of the runtimes
+// installed for it. The Env of a project runtime overrides that of the global
+// runtime of the same name.
+func ProjectLayer(binDir string, runtimes map[string]*resource.RuntimeState) Layer {
+	layer := Layer{Path: []string{binDir}}
+	for _, name := range slices.Sorted(maps.Keys(runtimes)) {
+		rs := runtimes[name]
+		if rs.BinDir != "" && !slices.Contains(layer.Path, rs.BinDir) {
+			layer.Path = append(layer.Path, rs.BinDir)
+		}
+		for key, value := range rs.Env {
+			if layer.Env == nil {
+				layer.Env = make(map[string]string)
+			}
+			layer.Env[key] = value
+		}
+	}
+	return layer
+}
+
+// SelectedLayer returns a layer with the Env and bin directory of each runtime
+// whose version selected by a version file is an extra version.
+func SelectedLayer(runtimes map[string]*resource.RuntimeState, versions map[string]string) (Layer, []string) {
+	selected, warnings := SelectVersions(runtimes, versions)
+	var layer Layer
+	for _, name := range slices.Sorted(maps.Keys(versions)) {
+		rs, ok := selected[name]
+		if !ok || rs == runtimes[name] {
+			continue
+		}
+		if rs.BinDir != "" {
+			layer.Path = append(layer.Path, rs.BinDir)
+		}
+		for key, value := range rs.Env {
+			if layer.Env == nil {
+				layer.Env = make(map[string]string)
+			}
+			layer.Env[key] = value
+		}
+	}
+	return layer, warnings
+}
+
+// hookState is the active layer with the values it replaced.
+type hookState struct {
+	Layer
+	// Saved holds the values of the Env keys before the layer was activated.
+	// A nil value means the variable was unset.
+	Saved map[string]*string `json:"saved,omitempty"`
+}
+
+// Hook returns the statements that switch the shell from the layer recorded
+// in HookVar to next, and whether the layer changed. lookup reads the shell's
+// environment (os.LookupEnv). It returns no statements when next is already active.
+func Hook(next Layer, lookup func(string) (string, bool), f Formatter) ([]string, bool) {
+	active := decodeHookState(lookup)
+	if active == nil && next.empty() {
+		return nil, false
+	}
+	if active != nil && active.equal(&next) {
+		return nil, false
+	}
+
+	// current returns the value of a variable before the active layer
+	current := func(key string) *string {
+		if active != nil {
+			if saved, ok := active.Saved[key]; ok {
+				return saved
+			}
+		}
+		if v, ok := lookup(key); ok {
+			return &v
+		}
+		return nil
+	}
+
+	var lines []string
+	if active != nil {
+		for _, key := range slices.Sorted(maps.Keys(active.Saved)) {
+			if _, ok := next.Env[key]; ok {
+				continue
+			}
+			if saved := active.Saved[key]; saved != nil {
+				lines = append(lines, f.ExportVar(key, *saved))
+			} else {
+				lines = append(lines, f.UnsetVar(key))
+			}
+		}
+	}
+
+	saved := make(map[string]*string, len(next.Env))
+	for _, key := range slices.Sorted(maps.Keys(next.Env)) {
+		saved[key] = current(key)
+		lines = append(lines, f.ExportVar(key, next.Env[key]))
+	}
+
+	pathValue, _ := lookup("PATH")
+	dirs := filepath.SplitList(pathValue)
+	if active != nil {
+		dirs = removeDirs(dirs, active.Path)
+	}
+	dirs = append(slices.Clone(next.Path), removeDirs(dirs, next.Path)...)
+	lines = append(lines, f.SetPath(dirs))
+
+	if next.empty() {
+		lines = append(lines, f.UnsetVar(HookVar))
+	} else {
+		lines = append(lines, f.ExportVar(HookVar, encodeHookState(&hookState{Layer: next, Saved: saved})))
+	}
+	return lines, true
+}
+
+// removeDirs removes the first occurrence of each of remove from dirs.
+func removeDirs(dirs, remove []string) []string {
+	result := slices.Clone(dirs)
+	for _, r := range remove {
+		if i := slices.Index(result, r); i >= 0 {
+			result = slices.Delete(result, i, i+1)
+		}
+	}
+	return result
+}
+
+func encodeHookState(st *hookState) string {
+	data, err := json.Marshal(st)
+	if err != nil {
+		// hookState only holds strings
+		panic(fmt.Sprintf("failed to encode hook state: %v", err))
+	}
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+// decodeHookState returns the state recorded in HookVar, or nil when there is
+// none or it cannot be decoded.
+func decodeHookState(lookup func(string) (string, bool)) *hookState {
+	value, ok := lookup(HookVar)
+	if !ok || value == "" {
+		return nil
+	}
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil
+	}
+	var st hookState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil
+	}
+	return &st
+}
+
+// HookScript returns the script that installs the shell hook for shell
+// (bash, zsh or fish). The hook runs "tomei env --hook" before each prompt
+// and on directory changes, and evaluates its output. exe is the path of
+// the tomei binary.
+func HookScript(shell, exe string) (string, error) {
+	var tmpl string
+	switch shell {
+	case "bash":
+		tmpl = bashHook
+	case "zsh":
+		tmpl = zshHook
+	case "fish":
+		tmpl = fishHook
+	default:
+		return "", fmt.Errorf("unsupported shell for hook: %q (supported: bash, zsh, fish)", shell)
+	}
+	return strings.ReplaceAll(tmpl, "{{exe}}", fmt.Sprintf("%q", exe)), nil
+}
+
+const bashHook = `_tomei_hook() {
+  local previous_exit_status=$?
+  eval "$({{exe}} env --hook --shell posix)"
+  return $previous_exit_status
+}
+if [[ ";${PROMPT_COMMAND[*]:-};" != *";_tomei_hook;"* ]]; then
+  PROMPT_COMMAND="_tomei_hook${PROMPT_COMMAND:+;$PROMPT_COMMAND}"
+fi
+`
+
+const zshHook = `_tomei_hook() {
+  eval "$({{exe}} env --hook --shell posix)"
+}
+typeset -ag precmd_functions chpwd_functions
+if (( ! ${precmd_functions[(I)_tomei_hook]} )); then
+  precmd_functions=(_tomei_hook $precmd_functions)
+fi
+if (( ! ${chpwd_functions[(I)_tomei_hook]} )); then
+  chpwd_functions=(_tomei_hook $chpwd_functions)
+fi
+`
+
+const fishHook = `function __tomei_hook --on-variable PWD --description 'Activate the tomei project environment'
+  {{exe}} env --hook --shell fish | source
+end
+__tomei_hook
+`
@@ -0,0 +1,155 @@
+package env
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/terassyi/tomei/internal/resource"
+)
+
+// hookShell applies posix hook output to a fake environment, so that a test
+// can run the hook repeatedly like successive prompts.
+type hookShell map[string]string
+
+func (s hookShell) lookup(key string) (string, bool) {
+	v, ok := s[key]
+	return v, ok
+}
+
+func (s hookShell) run(t *testing.T, next Layer) []string {
+	t.Helper()
+	lines, _ := Hook(next, s.lookup, NewFormatter(ShellPosix))
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "unset "):
+			delete(s, strings.TrimPrefix(line, "unset "))
+		case strings.HasPrefix(line, "export "):
+			key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+			require.True(t, ok, line)
+			s[key] = strings.Trim(value, `"`)
+		default:
+			t.Fatalf("unexpected line %q", line)
+		}
+	}
+	return lines
+}
+
+func TestHook(t *testing.T) {
+	t.Parallel()
+
+	shell := hookShell{
+		"PATH":   "/home/user/.local/bin:/usr/bin",
+		"GOROOT": "/data/runtimes/go/1.25.6",
+	}
+	project := Layer{
+		Sources: []string{"/src/service"},
+		Path:    []string{"/data/projects/abc/bin", "/data/runtimes/go/1.24.3/bin"},
+		Env:     map[string]string{"GOROOT": "/data/runtimes/go/1.24.3", "GOTOOLCHAIN": "local"},
+	}
+
+	// Entering the project
+	lines := shell.run(t, project)
+	require.NotEmpty(t, lines)
+	assert.Equal(t, "/data/projects/abc/bin:/data/runtimes/go/1.24.3/bin:/home/user/.local/bin:/usr/bin", shell["PATH"])
+	assert.Equal(t, "/data/runtimes/go/1.24.3", shell["GOROOT"])
+	assert.Equal(t, "local", shell["GOTOOLCHAIN"])
+	assert.NotEmpty(t, shell[HookVar])
+
+	// Staying in the project
+	assert.Empty(t, shell.run(t, project), "an active layer is not applied again")
+
+	// Leaving the project restores the previous environment
+	shell.run(t, Layer{})
+	assert.Equal(t, hookShell{
+		"PATH":   "/home/user/.local/bin:/usr/bin",
+		"GOROOT": "/data/runtimes/go/1.25.6",
+	}, shell)
+
+	// Outside any project
+	assert.Empty(t, shell.run(t, Layer{}))
+}
+
+func TestHook_SwitchLayer(t *testing.T) {
+	t.Parallel()
+
+	shell := hookShell{"PATH": "/usr/bin"}
+	shell.run(t, Layer{
+		Sources: []string{"/src/a"},
+		Path:    []string{"/data/projects/a/bin"},
+		Env:     map[string]string{"GOROOT": "/data/runtimes/go/1.24.3"},
+	})
+	shell.run(t, Layer{
+		Sources: []string{"/src/b"},
+		Path:    []string{"/data/projects/b/bin"},
+		Env:     map[string]string{"GOROOT": "/data/runtimes/go/1.23.9"},
+	})
+	assert.Equal(t, "/data/projects/b/bin:/usr/bin", shell["PATH"])
+	assert.Equal(t, "/data/runtimes/go/1.23.9", shell["GOROOT"])
+
+	shell.run(t, Layer{})
+	assert.Equal(t, hookShell{"PATH": "/usr/bin"}, shell, "the value saved by the first layer is restored")
+}
+
+func TestProjectLayer(t *testing.T) {
+	t.Parallel()
+
+	runtimes := map[string]*resource.RuntimeState{
+		"go":   {Version: "1.24.3", BinDir: "/data/projects/abc/bin", Env: map[string]string{"GOROOT": "/data/projects/abc/runtimes/go/1.24.3"}},
+		"node": {Version: "22.14.0", BinDir: "/data/projects/abc/node/bin"},
+	}
+
+	assert.Equal(t, Layer{
+		Path: []string{"/data/projects/abc/bin", "/data/projects/abc/node/bin"},
+		Env:  map[string]string{"GOROOT": "/data/projects/abc/runtimes/go/1.24.3"},
+	}, ProjectLayer("/data/projects/abc/bin", runtimes))
+	assert.Equal(t, Layer{Path: []string{"/data/projects/abc/bin"}}, ProjectLayer("/data/projects/abc/bin", nil))
+}
+
+func TestSelectedLayer(t *testing.T) {
+	t.Parallel()
+
+	runtimes := map[string]*resource.RuntimeState{
+		"go": {
+			Version: "1.25.6",
+			BinDir:  "/home/user/go/bin",
+			Env:     map[string]string{"GOROOT": "/data/runtimes/go/1.25.6"},
+			Extras: map[string]*resource.RuntimeVersionState{
+				"1.24.3": {
+					BinDir: "/data/runtimes/go/1.24.3/bin",
+					Env:    map[string]string{"GOROOT": "/data/runtimes/go/1.24.3"},
+				},
+			},
+		},
+		"node": {Version: "22.14.0", BinDir: "/data/bin"},
+	}
+
+	layer, warnings := SelectedLayer(runtimes, map[string]string{"go": "1.24.3", "node": "22.14.0", "rust": "1.85.0"})
+	assert.Equal(t, Layer{
+		Path: []string{"/data/runtimes/go/1.24.3/bin"},
+		Env:  map[string]string{"GOROOT": "/data/runtimes/go/1.24.3"},
+	}, layer, "default versions are already in the global environment")
+	assert.Equal(t, []string{"runtime rust selected by .tomei-version is not installed"}, warnings)
+}
+
+func TestHookScript(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		shell string
+		want  string
+	}{
+		{shell: "bash", want: `eval "$("/usr/local/bin/tomei" env --hook --shell posix)"`},
+		{shell: "zsh", want: `eval "$("/usr/local/bin/tomei" env --hook --shell posix)"`},
+		{shell: "fish", want: `"/usr/local/bin/tomei" env --hook --shell fish | source`},
+	} {
+		script, err := HookScript(tt.shell, "/usr/local/bin/tomei")
+		require.NoError(t, err, tt.shell)
+		assert.Contains(t, script, tt.want, tt.shell)
+	}
+
+	_, err := HookScript("tcsh", "tomei")
+	assert.ErrorContains(t, err, `unsupported shell for hook: "tcsh"`)
+}
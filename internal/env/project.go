@@ -0,0 +1,103 @@
+package env
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/terassyi/tomei/internal/resource"
+)
+
+// VersionFile is the project file that selects runtime versions.
+// Each line is "<runtime> <version>", e.g. "go 1.24.3".
+const VersionFile = ".tomei-version"
+
+// FindVersionFile returns the path of the nearest VersionFile at or above dir,
+// or an empty string when there is none.
+func FindVersionFile(dir string) string {
+	cur := dir
+	for {
+		p := filepath.Join(cur, VersionFile)
+		if info, err := os.Stat(p); err == nil && !info.IsDir() {
+			return p
+		}
+		parent := filepath.Dir(cur)
+		if parent == cur {
+			return ""
+		}
+		cur = parent
+	}
+}
+
+// ReadVersionFile reads the runtime versions selected by a version file.
+func ReadVersionFile(p string) (map[string]string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", p, err)
+	}
+	defer f.Close()
+
+	versions, err := ParseVersionFile(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p, err)
+	}
+	return versions, nil
+}
+
+// ParseVersionFile parses the contents of a version file into runtime names
+// and versions. Blank lines and lines starting with # are ignored.
+func ParseVersionFile(r io.Reader) (map[string]string, error) {
+	versions := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected \"<runtime> <version>\", got %q", n, line)
+		}
+		if _, ok := versions[fields[0]]; ok {
+			return nil, fmt.Errorf("line %d: runtime %s is selected more than once", n, fields[0])
+		}
+		versions[fields[0]] = fields[1]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// SelectVersions returns the runtimes with each selected runtime replaced by
+// the state of its selected version, so that Generate exports the Env and
+// BinDir of that version. The given map is not modified. Selections that are
+// not installed are left out and reported as warnings.
+func SelectVersions(runtimes map[string]*resource.RuntimeState, versions map[string]string) (map[string]*resource.RuntimeState, []string) {
+	if len(versions) == 0 {
+		return runtimes, nil
+	}
+
+	selected := maps.Clone(runtimes)
+	var warnings []string
+	for _, name := range slices.Sorted(maps.Keys(versions)) {
+		version := versions[name]
+		rs, ok := runtimes[name]
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("runtime %s selected by %s is not installed", name, VersionFile))
+			continue
+		}
+		vs, ok := rs.ForVersion(version)
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("%s %s selected by %s is not installed; add it to extraVersions of the runtime", name, version, VersionFile))
+			continue
+		}
+		selected[name] = vs
+	}
+	return selected, warnings
+}
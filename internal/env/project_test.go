@@ -0,0 +1,162 @@
+package env
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/terassyi/tomei/internal/resource"
+)
+
+func TestParseVersionFile(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name:    "versions with comments and blank lines",
+			content: "# pinned by the service\ngo 1.24.3\n\n  node   22.14.0  \n",
+			want:    map[string]string{"go": "1.24.3", "node": "22.14.0"},
+		},
+		{
+			name:    "empty file",
+			content: "",
+			want:    map[string]string{},
+		},
+		{
+			name:    "missing version",
+			content: "go 1.24.3\nnode\n",
+			wantErr: `line 2: expected "<runtime> <version>", got "node"`,
+		},
+		{
+			name:    "runtime selected twice",
+			content: "go 1.24.3\ngo 1.25.6\n",
+			wantErr: "line 2: runtime go is selected more than once",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := ParseVersionFile(strings.NewReader(tt.content))
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFindVersionFile(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	sub := filepath.Join(root, "service", "cmd")
+	require.NoError(t, os.MkdirAll(sub, 0755))
+	assert.Empty(t, FindVersionFile(sub))
+
+	p := filepath.Join(root, "service", VersionFile)
+	require.NoError(t, os.WriteFile(p, []byte("go 1.24.3\n"), 0644))
+	assert.Equal(t, p, FindVersionFile(sub))
+
+	versions, err := ReadVersionFile(p)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"go": "1.24.3"}, versions)
+}
+
+func TestSelectVersions(t *testing.T) {
+	t.Parallel()
+
+	runtimes := map[string]*resource.RuntimeState{
+		"go": {
+			Version:     "1.25.6",
+			InstallPath: "/data/runtimes/go/1.25.6",
+			BinDir:      "/home/user/go/bin",
+			ToolBinPath: "/home/user/go/bin",
+			Env:         map[string]string{"GOROOT": "/data/runtimes/go/1.25.6"},
+			Extras: map[string]*resource.RuntimeVersionState{
+				"1.24.3": {
+					InstallPath: "/data/runtimes/go/1.24.3",
+					BinDir:      "/data/runtimes/go/1.24.3/bin",
+					Env:         map[string]string{"GOROOT": "/data/runtimes/go/1.24.3"},
+				},
+			},
+		},
+		"node": {Version: "22.14.0", BinDir: "/data/bin"},
+	}
+
+	selected, warnings := SelectVersions(runtimes, map[string]string{
+		"go":     "1.24.3",
+		"node":   "20.0.0",
+		"python": "3.13.0",
+	})
+	assert.Equal(t, []string{
+		"node 20.0.0 selected by .tomei-version is not installed; add it to extraVersions of the runtime",
+		"runtime python selected by .tomei-version is not installed",
+	}, warnings)
+	assert.Equal(t, "1.24.3", selected["go"].Version)
+	assert.Same(t, runtimes["node"], selected["node"], "runtimes without an installed selection keep their default version")
+	assert.Equal(t, "1.25.6", runtimes["go"].Version, "the given runtimes are not modified")
+
+	lines := Generate(selected, nil, "/home/user/.local/bin", NewFormatter(ShellPosix))
+	assert.Equal(t, []string{
+		`export GOROOT="/data/runtimes/go/1.24.3"`,
+		`export PATH="/data/runtimes/go/1.24.3/bin:/home/user/.local/bin:/home/user/go/bin:/data/bin:$PATH"`,
+	}, lines, "the selected version's bin directory precedes the user bin directory")
+}
+
+func TestSelectVersions_ResolvesSelectedBinary(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeBinary := func(dir, version string) string {
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		p := filepath.Join(dir, "mybin")
+		require.NoError(t, os.WriteFile(p, []byte("#!/bin/sh\necho "+version+"\n"), 0755))
+		return p
+	}
+	defaultBin := writeBinary(filepath.Join(root, "runtimes/my/2.0.0/bin"), "2.0.0")
+	writeBinary(filepath.Join(root, "runtimes/my/1.0.0/bin"), "1.0.0")
+	userBinDir := filepath.Join(root, "bin")
+	require.NoError(t, os.MkdirAll(userBinDir, 0755))
+	require.NoError(t, os.Symlink(defaultBin, filepath.Join(userBinDir, "mybin")))
+
+	runtimes := map[string]*resource.RuntimeState{
+		"my": {
+			Version:     "2.0.0",
+			InstallPath: filepath.Join(root, "runtimes/my/2.0.0"),
+			BinDir:      userBinDir,
+			Extras: map[string]*resource.RuntimeVersionState{
+				"1.0.0": {
+					InstallPath: filepath.Join(root, "runtimes/my/1.0.0"),
+					BinDir:      filepath.Join(root, "runtimes/my/1.0.0/bin"),
+				},
+			},
+		},
+	}
+
+	resolve := func(versions map[string]string) string {
+		selected, warnings := SelectVersions(runtimes, versions)
+		require.Empty(t, warnings)
+		lines := Generate(selected, nil, userBinDir, NewFormatter(ShellPosix))
+		script := "PATH=/usr/bin:/bin\n" + strings.Join(lines, "\n") + "\nmybin\n"
+		out, err := exec.Command("/bin/sh", "-c", script).CombinedOutput()
+		require.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+
+	assert.Equal(t, "2.0.0", resolve(nil), "the default version is resolved through the user bin directory")
+	assert.Equal(t, "1.0.0", resolve(map[string]string{"my": "1.0.0"}), "the selected version shadows the default symlink")
+}
@@ -20,8 +20,21 @@ const (
 	ShellPosix ShellType = "posix"
 	// ShellFish represents the fish shell.
 	ShellFish ShellType = "fish"
+	// ShellNushell represents nushell.
+	ShellNushell ShellType = "nushell"
+	// ShellPowerShell represents PowerShell (pwsh).
+	ShellPowerShell ShellType = "powershell"
+	// ShellElvish represents the elvish shell.
+	ShellElvish ShellType = "elvish"
+	// ShellXonsh represents the xonsh shell.
+	ShellXonsh ShellType = "xonsh"
 )
 
+// ShellTypes returns all supported shell types.
+func ShellTypes() []ShellType {
+	return []ShellType{ShellPosix, ShellFish, ShellNushell, ShellPowerShell, ShellElvish, ShellXonsh}
+}
+
 // ParseShellType parses a string into a ShellType.
 func ParseShellType(s string) (ShellType, error) {
 	switch s {
@@ -29,8 +42,16 @@ func ParseShellType(s string) (ShellType, error) {
 		return ShellPosix, nil
 	case "fish":
 		return ShellFish, nil
+	case "nushell", "nu":
+		return ShellNushell, nil
+	case "powershell", "pwsh":
+		return ShellPowerShell, nil
+	case "elvish":
+		return ShellElvish, nil
+	case "xonsh":
+		return ShellXonsh, nil
 	default:
-		return "", fmt.Errorf("unsupported shell type: %q (supported: posix, fish)", s)
+		return "", fmt.Errorf("unsupported shell type: %q (supported: posix, fish, nushell, powershell, elvish, xonsh)", s)
 	}
 }
 
@@ -40,6 +61,10 @@ type Formatter interface {
 	ExportVar(key, value string) string
 	// ExportPath formats a PATH export statement with the given directories prepended.
 	ExportPath(dirs []string) string
+	// SetPath formats a statement that sets PATH to exactly the given directories.
+	SetPath(dirs []string) string
+	// UnsetVar formats a statement that removes an environment variable.
+	UnsetVar(key string) string
 	// Ext returns the file extension for this shell type (e.g., ".sh", ".fish").
 	// The format matches filepath.Ext() convention (dot-prefixed).
 	Ext() string
@@ -50,6 +75,14 @@ func NewFormatter(st ShellType) Formatter {
 	switch st {
 	case ShellFish:
 		return fishFormatter{}
+	case ShellNushell:
+		return nushellFormatter{}
+	case ShellPowerShell:
+		return powershellFormatter{}
+	case ShellElvish:
+		return elvishFormatter{}
+	case ShellXonsh:
+		return xonshFormatter{}
 	default:
 		return posixFormatter{}
 	}
@@ -58,8 +91,25 @@ func NewFormatter(st ShellType) Formatter {
 var (
 	_ Formatter = (*posixFormatter)(nil)
 	_ Formatter = (*fishFormatter)(nil)
+	_ Formatter = (*nushellFormatter)(nil)
+	_ Formatter = (*powershellFormatter)(nil)
+	_ Formatter = (*elvishFormatter)(nil)
+	_ Formatter = (*xonshFormatter)(nil)
 )
 
+// splitHome splits a value written in the $HOME/... form by toShellPath into
+// the part after $HOME. Shells without $HOME expansion in strings reference
+// the home directory with their own syntax.
+func splitHome(value string) (string, bool) {
+	if value == shellHome {
+		return "", true
+	}
+	if rest, ok := strings.CutPrefix(value, shellHome+"/"); ok {
+		return "/" + rest, true
+	}
+	return "", false
+}
+
 type posixFormatter struct{}
 
 func (posixFormatter) ExportVar(key, value string) string {
@@ -70,6 +120,14 @@ func (posixFormatter) ExportPath(dirs []string) string {
 	return fmt.Sprintf("export PATH=%q", strings.Join(dirs, ":")+":"+shellPath)
 }
 
+func (posixFormatter) SetPath(dirs []string) string {
+	return fmt.Sprintf("export PATH=%q", strings.Join(dirs, ":"))
+}
+
+func (posixFormatter) UnsetVar(key string) string {
+	return "unset " + key
+}
+
 func (posixFormatter) Ext() string { return ".sh" }
 
 type fishFormatter struct{}
@@ -86,4 +144,193 @@ func (fishFormatter) ExportPath(dirs []string) string {
 	return fmt.Sprintf("%s %s", fishAddPath, strings.Join(quoted, " "))
 }
 
+func (fishFormatter) SetPath(dirs []string) string {
+	quoted := make([]string, len(dirs))
+	for i, d := range dirs {
+		quoted[i] = fmt.Sprintf("%q", d)
+	}
+	return "set -gx PATH " + strings.Join(quoted, " ")
+}
+
+func (fishFormatter) UnsetVar(key string) string {
+	return "set -e " + key
+}
+
 func (fishFormatter) Ext() string { return ".fish" }
+
+// nushellFormatter writes nushell statements. Values are double-quoted
+// strings, and the home directory is read from $env.HOME.
+type nushellFormatter struct{}
+
+func (nushellFormatter) quote(value string) string {
+	if rest, ok := splitHome(value); ok {
+		if rest == "" {
+			return "$env.HOME"
+		}
+		return "($env.HOME + " + nushellString(rest) + ")"
+	}
+	return nushellString(value)
+}
+
+func (f nushellFormatter) list(dirs []string) string {
+	quoted := make([]string, len(dirs))
+	for i, d := range dirs {
+		quoted[i] = f.quote(d)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+func (f nushellFormatter) ExportVar(key, value string) string {
+	return fmt.Sprintf("load-env {%s: %s}", nushellString(key), f.quote(value))
+}
+
+func (f nushellFormatter) ExportPath(dirs []string) string {
+	// PATH is a string until the env conversions run, so split it either way
+	return fmt.Sprintf("$env.PATH = ($env.PATH | split row (char esep) | prepend %s | uniq)", f.list(dirs))
+}
+
+func (f nushellFormatter) SetPath(dirs []string) string {
+	return "$env.PATH = " + f.list(dirs)
+}
+
+func (nushellFormatter) UnsetVar(key string) string {
+	return "hide-env -i " + key
+}
+
+func (nushellFormatter) Ext() string { return ".nu" }
+
+// nushellString returns s as a double-quoted nushell string.
+func nushellString(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// powershellFormatter writes PowerShell statements. Values are double-quoted
+// strings, in which $HOME is the automatic variable of the home directory.
+type powershellFormatter struct{}
+
+func (powershellFormatter) quote(value string) string {
+	if rest, ok := splitHome(value); ok {
+		return `"$HOME` + powershellEscape(rest) + `"`
+	}
+	return `"` + powershellEscape(value) + `"`
+}
+
+func (f powershellFormatter) list(dirs []string) string {
+	quoted := make([]string, len(dirs))
+	for i, d := range dirs {
+		quoted[i] = f.quote(d)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func (f powershellFormatter) ExportVar(key, value string) string {
+	return fmt.Sprintf("$env:%s = %s", key, f.quote(value))
+}
+
+func (f powershellFormatter) ExportPath(dirs []string) string {
+	return fmt.Sprintf("$env:PATH = @(%s, $env:PATH) -join [IO.Path]::PathSeparator", f.list(dirs))
+}
+
+func (f powershellFormatter) SetPath(dirs []string) string {
+	return fmt.Sprintf("$env:PATH = @(%s) -join [IO.Path]::PathSeparator", f.list(dirs))
+}
+
+func (powershellFormatter) UnsetVar(key string) string {
+	return fmt.Sprintf("Remove-Item Env:%s -ErrorAction SilentlyContinue", key)
+}
+
+func (powershellFormatter) Ext() string { return ".ps1" }
+
+// powershellEscape escapes the characters that are special in double-quoted
+// PowerShell strings with the backtick.
+func powershellEscape(s string) string {
+	r := strings.NewReplacer("`", "``", `"`, "`\"", "$", "`$", "\n", "`n", "\t", "`t", "\r", "`r")
+	return r.Replace(s)
+}
+
+// elvishFormatter writes elvish statements. Values are single-quoted strings,
+// and the home directory is read from $E:HOME.
+type elvishFormatter struct{}
+
+func (elvishFormatter) quote(value string) string {
+	if rest, ok := splitHome(value); ok {
+		if rest == "" {
+			return "$E:HOME"
+		}
+		return "$E:HOME" + elvishString(rest)
+	}
+	return elvishString(value)
+}
+
+func (f elvishFormatter) list(dirs []string) string {
+	quoted := make([]string, len(dirs))
+	for i, d := range dirs {
+		quoted[i] = f.quote(d)
+	}
+	return strings.Join(quoted, " ")
+}
+
+func (f elvishFormatter) ExportVar(key, value string) string {
+	return fmt.Sprintf("set-env %s %s", key, f.quote(value))
+}
+
+func (f elvishFormatter) ExportPath(dirs []string) string {
+	return fmt.Sprintf("set paths = [%s $@paths]", f.list(dirs))
+}
+
+func (f elvishFormatter) SetPath(dirs []string) string {
+	return fmt.Sprintf("set paths = [%s]", f.list(dirs))
+}
+
+func (elvishFormatter) UnsetVar(key string) string {
+	return "unset-env " + key
+}
+
+func (elvishFormatter) Ext() string { return ".elv" }
+
+// elvishString returns s as a single-quoted elvish string, in which a quote
+// is written twice.
+func elvishString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// xonshFormatter writes xonsh statements. Values are Python string literals,
+// and the home directory is read from $HOME.
+type xonshFormatter struct{}
+
+func (xonshFormatter) quote(value string) string {
+	if rest, ok := splitHome(value); ok {
+		if rest == "" {
+			return "$HOME"
+		}
+		return fmt.Sprintf("$HOME + %q", rest)
+	}
+	return fmt.Sprintf("%q", value)
+}
+
+func (f xonshFormatter) list(dirs []string) string {
+	quoted := make([]string, len(dirs))
+	for i, d := range dirs {
+		quoted[i] = f.quote(d)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+func (f xonshFormatter) ExportVar(key, value string) string {
+	return fmt.Sprintf("$%s = %s", key, f.quote(value))
+}
+
+func (f xonshFormatter) ExportPath(dirs []string) string {
+	return fmt.Sprintf("$PATH = %s + list($PATH)", f.list(dirs))
+}
+
+func (f xonshFormatter) SetPath(dirs []string) string {
+	return "$PATH = " + f.list(dirs)
+}
+
+func (xonshFormatter) UnsetVar(key string) string {
+	return fmt.Sprintf("${...}.pop(%q, None)", key)
+}
+
+func (xonshFormatter) Ext() string { return ".xsh" }
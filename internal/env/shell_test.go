@@ -46,9 +46,29 @@ func TestParseShellType(t *testing.T) {
 			input: "zsh",
 			want:  ShellPosix,
 		},
+		{
+			name:  "nu maps to nushell",
+			input: "nu",
+			want:  ShellNushell,
+		},
+		{
+			name:  "pwsh maps to powershell",
+			input: "pwsh",
+			want:  ShellPowerShell,
+		},
+		{
+			name:  "elvish",
+			input: "elvish",
+			want:  ShellElvish,
+		},
+		{
+			name:  "xonsh",
+			input: "xonsh",
+			want:  ShellXonsh,
+		},
 		{
 			name:    "unsupported shell",
-			input:   "powershell",
+			input:   "tcsh",
 			wantErr: true,
 		},
 	}
@@ -88,6 +108,19 @@ func TestPosixFormatter(t *testing.T) {
 		assert.Equal(t, `export PATH="$HOME/.local/bin:$HOME/go/bin:$PATH"`, got)
 	})
 
+	t.Run("SetPath", func(t *testing.T) {
+		t.Parallel()
+
+		got := f.SetPath([]string{"/project/bin", "/usr/bin"})
+		assert.Equal(t, `export PATH="/project/bin:/usr/bin"`, got)
+	})
+
+	t.Run("UnsetVar", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Equal(t, "unset GOROOT", f.UnsetVar("GOROOT"))
+	})
+
 	t.Run("Ext", func(t *testing.T) {
 		t.Parallel()
 
@@ -114,6 +147,19 @@ func TestFishFormatter(t *testing.T) {
 		assert.Equal(t, `fish_add_path "$HOME/.local/bin" "$HOME/go/bin"`, got)
 	})
 
+	t.Run("SetPath", func(t *testing.T) {
+		t.Parallel()
+
+		got := f.SetPath([]string{"/project/bin", "/usr/bin"})
+		assert.Equal(t, `set -gx PATH "/project/bin" "/usr/bin"`, got)
+	})
+
+	t.Run("UnsetVar", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Equal(t, "set -e GOROOT", f.UnsetVar("GOROOT"))
+	})
+
 	t.Run("Ext", func(t *testing.T) {
 		t.Parallel()
 
@@ -121,6 +167,65 @@ func TestFishFormatter(t *testing.T) {
 	})
 }
 
+func TestShellFormatters(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		shell      ShellType
+		exportVar  string
+		exportPath string
+		setPath    string
+		unsetVar   string
+		quoted     string
+	}{
+		{
+			shell:      ShellNushell,
+			exportVar:  `load-env {"GOROOT": ($env.HOME + "/.local/share/tomei/runtimes/go/1.25.6")}`,
+			exportPath: `$env.PATH = ($env.PATH | split row (char esep) | prepend [($env.HOME + "/.local/bin"), "/opt/go/bin"] | uniq)`,
+			setPath:    `$env.PATH = ["/project/bin", "/usr/bin"]`,
+			unsetVar:   "hide-env -i GOROOT",
+			quoted:     `load-env {"MSG": "say \"hi\" \\ $USER"}`,
+		},
+		{
+			shell:      ShellPowerShell,
+			exportVar:  `$env:GOROOT = "$HOME/.local/share/tomei/runtimes/go/1.25.6"`,
+			exportPath: `$env:PATH = @("$HOME/.local/bin", "/opt/go/bin", $env:PATH) -join [IO.Path]::PathSeparator`,
+			setPath:    `$env:PATH = @("/project/bin", "/usr/bin") -join [IO.Path]::PathSeparator`,
+			unsetVar:   "Remove-Item Env:GOROOT -ErrorAction SilentlyContinue",
+			quoted:     "$env:MSG = \"say `\"hi`\" \\ `$USER\"",
+		},
+		{
+			shell:      ShellElvish,
+			exportVar:  `set-env GOROOT $E:HOME'/.local/share/tomei/runtimes/go/1.25.6'`,
+			exportPath: `set paths = [$E:HOME'/.local/bin' '/opt/go/bin' $@paths]`,
+			setPath:    `set paths = ['/project/bin' '/usr/bin']`,
+			unsetVar:   "unset-env GOROOT",
+			quoted:     `set-env MSG 'say "hi" \ $USER'`,
+		},
+		{
+			shell:      ShellXonsh,
+			exportVar:  `$GOROOT = $HOME + "/.local/share/tomei/runtimes/go/1.25.6"`,
+			exportPath: `$PATH = [$HOME + "/.local/bin", "/opt/go/bin"] + list($PATH)`,
+			setPath:    `$PATH = ["/project/bin", "/usr/bin"]`,
+			unsetVar:   `${...}.pop("GOROOT", None)`,
+			quoted:     `$MSG = "say \"hi\" \\ $USER"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.shell), func(t *testing.T) {
+			t.Parallel()
+
+			f := NewFormatter(tt.shell)
+			assert.Equal(t, tt.exportVar, f.ExportVar("GOROOT", "$HOME/.local/share/tomei/runtimes/go/1.25.6"))
+			assert.Equal(t, tt.exportPath, f.ExportPath([]string{"$HOME/.local/bin", "/opt/go/bin"}))
+			assert.Equal(t, tt.setPath, f.SetPath([]string{"/project/bin", "/usr/bin"}))
+			assert.Equal(t, tt.unsetVar, f.UnsetVar("GOROOT"))
+			assert.Equal(t, tt.quoted, f.ExportVar("MSG", `say "hi" \ $USER`), "only a leading $HOME is expanded")
+		})
+	}
+}
+
 func TestNewFormatter(t *testing.T) {
 	t.Parallel()
 
@@ -139,6 +244,26 @@ func TestNewFormatter(t *testing.T) {
 			shellType: ShellFish,
 			wantType:  ".fish",
 		},
+		{
+			name:      "nushell",
+			shellType: ShellNushell,
+			wantType:  ".nu",
+		},
+		{
+			name:      "powershell",
+			shellType: ShellPowerShell,
+			wantType:  ".ps1",
+		},
+		{
+			name:      "elvish",
+			shellType: ShellElvish,
+			wantType:  ".elv",
+		},
+		{
+			name:      "xonsh",
+			shellType: ShellXonsh,
+			wantType:  ".xsh",
+		},
 	}
 
 	for _, tt := range tests {
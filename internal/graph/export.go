@@ -26,6 +26,33 @@ type PlanResource struct {
 	Action       resource.ActionType `json:"action" yaml:"action"`
 	Layer        int                 `json:"layer" yaml:"layer"`
 	Dependencies []string            `json:"dependencies,omitempty" yaml:"dependencies,omitempty"`
+	// Reason explains the action (e.g., "version changed: 1.0.0 -> 1.1.0").
+	Reason string `json:"reason,omitempty" yaml:"reason,omitempty"`
+	// ResolvedVersion is the version that Version resolves to (e.g., for "latest").
+	ResolvedVersion string `json:"resolvedVersion,omitempty" yaml:"resolvedVersion,omitempty"`
+	// URL is the resolved download URL.
+	URL string `json:"url,omitempty" yaml:"url,omitempty"`
+	// ChecksumSource is the checksums file URL, or "inline" for a checksum in the spec.
+	ChecksumSource string `json:"checksumSource,omitempty" yaml:"checksumSource,omitempty"`
+	// TaintedBy is the runtime whose upgrade taints this tool.
+	TaintedBy string `json:"taintedBy,omitempty" yaml:"taintedBy,omitempty"`
+}
+
+// newPlanResource builds the plan output of a resource in the given layer (0 for none).
+func newPlanResource(info ResourceInfo, layer int, deps []string) PlanResource {
+	return PlanResource{
+		Kind:            info.Kind,
+		Name:            info.Name,
+		Version:         info.Version,
+		Action:          info.Action,
+		Layer:           layer,
+		Dependencies:    deps,
+		Reason:          info.Reason,
+		ResolvedVersion: info.ResolvedVersion,
+		URL:             info.URL,
+		ChecksumSource:  info.ChecksumSource,
+		TaintedBy:       info.TaintedBy,
+	}
 }
 
 // PlanLayer represents an execution layer in the plan output.
@@ -86,14 +113,8 @@ func (e *Exporter) BuildOutput() PlanOutput {
 			nodeID := node.ID
 			info := e.resourceInfo[nodeID]
 
-			planResource := PlanResource{
-				Kind:         node.Kind,
-				Name:         node.Name,
-				Version:      info.Version,
-				Action:       info.Action,
-				Layer:        i + 1,
-				Dependencies: deps[nodeID],
-			}
+			info.Kind, info.Name = node.Kind, node.Name
+			planResource := newPlanResource(info, i+1, deps[nodeID])
 			output.Resources = append(output.Resources, planResource)
 			planLayer.Resources = append(planLayer.Resources, string(nodeID))
 		}
@@ -101,31 +122,25 @@ func (e *Exporter) BuildOutput() PlanOutput {
 		output.Layers = append(output.Layers, planLayer)
 	}
 
-	// Collect ActionSkip resources that are not part of any layer.
+	// Collect ActionSkip and ActionRemove resources that are not part of any layer.
 	// Build sorted slice first for deterministic JSON/YAML output.
 	emitted := make(map[NodeID]bool)
 	for _, res := range output.Resources {
 		emitted[NewNodeID(res.Kind, res.Name)] = true
 	}
-	var skipResources []PlanResource
+	var unlayered []PlanResource
 	for nodeID, info := range e.resourceInfo {
-		if info.Action == resource.ActionSkip && !emitted[nodeID] {
-			skipResources = append(skipResources, PlanResource{
-				Kind:    info.Kind,
-				Name:    info.Name,
-				Version: info.Version,
-				Action:  resource.ActionSkip,
-				Layer:   0,
-			})
+		if (info.Action == resource.ActionSkip || info.Action == resource.ActionRemove) && !emitted[nodeID] {
+			unlayered = append(unlayered, newPlanResource(info, 0, nil))
 		}
 	}
-	slices.SortFunc(skipResources, func(a, b PlanResource) int {
+	slices.SortFunc(unlayered, func(a, b PlanResource) int {
 		if c := cmp.Compare(string(a.Kind), string(b.Kind)); c != 0 {
 			return c
 		}
 		return cmp.Compare(a.Name, b.Name)
 	})
-	output.Resources = append(output.Resources, skipResources...)
+	output.Resources = append(output.Resources, unlayered...)
 
 	// Build summary
 	summary := PlanSummary{
@@ -88,3 +88,37 @@ func TestBuildOutput_SkipResources(t *testing.T) {
 		assert.Equal(t, 1, output.Summary.Skip)
 	})
 }
+
+func TestBuildOutput_Details(t *testing.T) {
+	t.Parallel()
+
+	layers := []Layer{
+		{Nodes: []*Node{
+			{ID: NewNodeID(resource.KindTool, "rg"), Kind: resource.KindTool, Name: "rg"},
+		}},
+	}
+	resourceInfo := map[NodeID]ResourceInfo{
+		NewNodeID(resource.KindTool, "rg"): {
+			Kind: resource.KindTool, Name: "rg", Version: "latest", Action: resource.ActionInstall,
+			Reason: "new resource", ResolvedVersion: "14.1.1",
+			URL: "https://example.com/rg.tar.gz", ChecksumSource: "inline",
+		},
+		NewNodeID(resource.KindTool, "jq"): {Kind: resource.KindTool, Name: "jq", Version: "1.7.1", Action: resource.ActionRemove, Reason: "removed from spec"},
+	}
+
+	output := NewExporter(layers, resourceInfo, nil).BuildOutput()
+
+	assert.Len(t, output.Resources, 2)
+	assert.Equal(t, PlanResource{
+		Kind: resource.KindTool, Name: "rg", Version: "latest", Action: resource.ActionInstall, Layer: 1,
+		Reason: "new resource", ResolvedVersion: "14.1.1",
+		URL: "https://example.com/rg.tar.gz", ChecksumSource: "inline",
+	}, output.Resources[0])
+
+	// Removals are not in any layer
+	assert.Equal(t, PlanResource{
+		Kind: resource.KindTool, Name: "jq", Version: "1.7.1", Action: resource.ActionRemove, Layer: 0,
+		Reason: "removed from spec",
+	}, output.Resources[1])
+	assert.Equal(t, 1, output.Summary.Remove)
+}
@@ -17,6 +17,10 @@ type Resolver interface {
 	// AddResource adds a resource and its dependencies to the graph.
 	AddResource(res resource.Resource)
 
+	// AddDependency adds a dependency edge that is not declared in the
+	// resource spec (e.g., a registry tool delegated to a runtime).
+	AddDependency(res resource.Resource, dep resource.Ref)
+
 	// Resolve validates the graph and returns execution layers.
 	// Returns an error if circular dependencies are detected.
 	Resolve() ([]Layer, error)
@@ -65,6 +69,13 @@ func (r *resolver) AddResource(res resource.Resource) {
 	}
 }
 
+// AddDependency adds a dependency edge that is not declared in the resource spec.
+func (r *resolver) AddDependency(res resource.Resource, dep resource.Ref) {
+	fromNode := r.dag.addNode(res.Kind(), res.Name())
+	toNode := r.dag.addNode(dep.Kind, dep.Name)
+	r.dag.addEdge(fromNode, toNode)
+}
+
 // Resolve validates the graph and returns execution layers.
 // Returns an error if circular dependencies are detected.
 func (r *resolver) Resolve() ([]Layer, error) {
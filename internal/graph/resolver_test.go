@@ -99,6 +99,50 @@ func TestResolver_AddResource_ToolWithInstallerRef(t *testing.T) {
 	assert.Equal(t, 1, resolver.EdgeCount())
 }
 
+func TestResolver_AddDependency_RegistryToolOnRuntime(t *testing.T) {
+	t.Parallel()
+	resolver := NewResolver()
+
+	goRuntime := &resource.Runtime{
+		BaseResource: resource.BaseResource{
+			APIVersion:   resource.GroupVersion,
+			ResourceKind: resource.KindRuntime,
+			Metadata:     resource.Metadata{Name: "go"},
+		},
+		RuntimeSpec: &resource.RuntimeSpec{
+			Version: "1.25.5",
+		},
+	}
+
+	// go_install registry package: the spec only references the aqua installer
+	goimports := &resource.Tool{
+		BaseResource: resource.BaseResource{
+			APIVersion:   resource.GroupVersion,
+			ResourceKind: resource.KindTool,
+			Metadata:     resource.Metadata{Name: "goimports"},
+		},
+		ToolSpec: &resource.ToolSpec{
+			InstallerRef: "aqua",
+			Package:      &resource.Package{Owner: "golang", Repo: "tools"},
+			Version:      "v0.30.0",
+		},
+	}
+
+	resolver.AddResource(goimports)
+	resolver.AddResource(goRuntime)
+	resolver.AddDependency(goimports, resource.Ref{Kind: resource.KindRuntime, Name: "go"})
+
+	assert.Equal(t, 3, resolver.NodeCount()) // tool + installer + runtime
+	assert.Equal(t, 2, resolver.EdgeCount())
+
+	layers, err := resolver.Resolve()
+	require.NoError(t, err)
+	require.Len(t, layers, 2)
+	assert.Len(t, layers[0].Nodes, 2) // Installer/aqua, Runtime/go
+	require.Len(t, layers[1].Nodes, 1)
+	assert.Equal(t, "goimports", layers[1].Nodes[0].Name)
+}
+
 func TestResolver_Resolve_ToolChain(t *testing.T) {
 	t.Parallel()
 	resolver := NewResolver()
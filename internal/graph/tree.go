@@ -17,6 +17,16 @@ type ResourceInfo struct {
 	Name    string
 	Version string
 	Action  resource.ActionType
+	// Reason explains the action (e.g., "version changed: 1.0.0 -> 1.1.0").
+	Reason string
+	// ResolvedVersion is the version that Version resolves to (e.g., for "latest").
+	ResolvedVersion string
+	// URL is the resolved download URL.
+	URL string
+	// ChecksumSource is the checksums file URL, or "inline" for a checksum in the spec.
+	ChecksumSource string
+	// TaintedBy is the runtime whose upgrade taints this tool.
+	TaintedBy string
 }
 
 // TreePrinter prints dependency graphs as ASCII trees with colors.
@@ -55,10 +65,13 @@ func NewTreePrinter(w io.Writer, noColor bool) *TreePrinter {
 		noChangeColor:  newColor(noColor, color.FgWhite),
 		skipColor:      newColor(noColor, color.FgHiBlack),
 		kindColors: map[resource.Kind]*color.Color{
-			resource.KindRuntime:             newColor(noColor, color.FgBlue),
-			resource.KindInstaller:           newColor(noColor, color.FgYellow),
-			resource.KindInstallerRepository: newColor(noColor, color.FgCyan),
-			resource.KindTool:                newColor(noColor, color.FgGreen),
+			resource.KindRuntime:                 newColor(noColor, color.FgBlue),
+			resource.KindInstaller:               newColor(noColor, color.FgYellow),
+			resource.KindInstallerRepository:     newColor(noColor, color.FgCyan),
+			resource.KindTool:                    newColor(noColor, color.FgGreen),
+			resource.KindSystemInstaller:         newColor(noColor, color.FgYellow),
+			resource.KindSystemPackageRepository: newColor(noColor, color.FgCyan),
+			resource.KindSystemPackageSet:        newColor(noColor, color.FgMagenta),
 		},
 	}
 }
@@ -150,7 +163,10 @@ func (p *TreePrinter) formatNode(nodeID NodeID, info ResourceInfo, hasInfo bool)
 	if hasInfo {
 		// Version
 		versionStr := ""
-		if info.Version != "" {
+		switch {
+		case info.Version != "" && info.ResolvedVersion != "":
+			versionStr = fmt.Sprintf(" (%s: %s)", info.Version, info.ResolvedVersion)
+		case info.Version != "":
 			versionStr = fmt.Sprintf(" (%s)", info.Version)
 		}
 
@@ -201,6 +217,43 @@ func (p *TreePrinter) PrintLayers(layers []Layer, resourceInfo map[NodeID]Resour
 	}
 }
 
+// PrintDetails prints the reason of each change, and the resolved version,
+// download URL and checksum source of installs.
+func (p *TreePrinter) PrintDetails(resourceInfo map[NodeID]ResourceInfo) {
+	var changed []NodeID
+	for nodeID, info := range resourceInfo {
+		if info.Action != resource.ActionNone && info.Action != resource.ActionSkip {
+			changed = append(changed, nodeID)
+		}
+	}
+	if len(changed) == 0 {
+		return
+	}
+	slices.Sort(changed)
+
+	fmt.Fprintln(p.writer, "\nChanges:")
+	for _, nodeID := range changed {
+		info := resourceInfo[nodeID]
+		line := fmt.Sprintf("  %s: %s", nodeID, info.Action)
+		if info.Reason != "" {
+			line += fmt.Sprintf(" (%s)", info.Reason)
+		}
+		fmt.Fprintln(p.writer, line)
+		if info.TaintedBy != "" {
+			fmt.Fprintf(p.writer, "      tainted by: %s\n", NewNodeID(resource.KindRuntime, info.TaintedBy))
+		}
+		if info.ResolvedVersion != "" {
+			fmt.Fprintf(p.writer, "      version:    %s\n", info.ResolvedVersion)
+		}
+		if info.URL != "" {
+			fmt.Fprintf(p.writer, "      url:        %s\n", info.URL)
+		}
+		if info.ChecksumSource != "" {
+			fmt.Fprintf(p.writer, "      checksum:   %s\n", info.ChecksumSource)
+		}
+	}
+}
+
 // PrintSummary prints the action summary.
 func (p *TreePrinter) PrintSummary(resourceInfo map[NodeID]ResourceInfo) {
 	counts := map[resource.ActionType]int{
@@ -133,3 +133,40 @@ func TestPrintDisabled(t *testing.T) {
 		})
 	}
 }
+
+func TestPrintDetails(t *testing.T) {
+	t.Parallel()
+
+	info := map[NodeID]ResourceInfo{
+		NewNodeID(resource.KindTool, "rg"): {
+			Kind: resource.KindTool, Name: "rg", Version: "latest", Action: resource.ActionUpgrade,
+			Reason: "tainted: update_requested", ResolvedVersion: "14.1.1",
+			URL:            "https://example.com/rg.tar.gz",
+			ChecksumSource: "https://example.com/rg.tar.gz.sha256",
+		},
+		NewNodeID(resource.KindTool, "gopls"): {
+			Kind: resource.KindTool, Name: "gopls", Action: resource.ActionReinstall,
+			Reason: "tainted: runtime_upgraded", TaintedBy: "go",
+		},
+		NewNodeID(resource.KindTool, "fd"):  {Kind: resource.KindTool, Name: "fd", Action: resource.ActionNone},
+		NewNodeID(resource.KindTool, "bat"): {Kind: resource.KindTool, Name: "bat", Action: resource.ActionSkip},
+	}
+
+	var buf bytes.Buffer
+	NewTreePrinter(&buf, true).PrintDetails(info)
+
+	want := "\nChanges:\n" +
+		"  Tool/gopls: reinstall (tainted: runtime_upgraded)\n" +
+		"      tainted by: Runtime/go\n" +
+		"  Tool/rg: upgrade (tainted: update_requested)\n" +
+		"      version:    14.1.1\n" +
+		"      url:        https://example.com/rg.tar.gz\n" +
+		"      checksum:   https://example.com/rg.tar.gz.sha256\n"
+	assert.Equal(t, want, buf.String())
+
+	buf.Reset()
+	NewTreePrinter(&buf, true).PrintDetails(map[NodeID]ResourceInfo{
+		NewNodeID(resource.KindTool, "fd"): {Kind: resource.KindTool, Name: "fd", Action: resource.ActionNone},
+	})
+	assert.Empty(t, buf.String(), "no changes should print nothing")
+}
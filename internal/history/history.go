@@ -0,0 +1,315 @@
+package history
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/terassyi/tomei/internal/resource"
+	"github.com/terassyi/tomei/internal/state"
+)
+
+// Outcome is the result of an apply or of one of its actions.
+type Outcome string
+
+const (
+	OutcomeSuccess  Outcome = "success"
+	OutcomeFailed   Outcome = "failed"
+	OutcomeCanceled Outcome = "canceled"
+)
+
+// Action is one action executed by an apply.
+type Action struct {
+	Kind       resource.Kind       `json:"kind"`
+	Name       string              `json:"name"`
+	Action     resource.ActionType `json:"action"`
+	OldVersion string              `json:"oldVersion,omitempty"`
+	NewVersion string              `json:"newVersion,omitempty"`
+	Duration   time.Duration       `json:"duration"`
+	Outcome    Outcome             `json:"outcome"`
+	Error      string              `json:"error,omitempty"`
+}
+
+// Record is the history entry of one apply.
+type Record struct {
+	// Number is the position of the record in the history file, starting at 1.
+	// It is assigned when the history is loaded and is not stored.
+	Number int `json:"number,omitempty"`
+
+	StartedAt    time.Time     `json:"startedAt"`
+	Command      string        `json:"command"`
+	TomeiVersion string        `json:"tomeiVersion"`
+	ManifestHash string        `json:"manifestHash,omitempty"`
+	Project      string        `json:"project,omitempty"`
+	Duration     time.Duration `json:"duration"`
+	Outcome      Outcome       `json:"outcome"`
+	Error        string        `json:"error,omitempty"`
+	Actions      []Action      `json:"actions"`
+}
+
+// Append adds the record as a line to the history file at path, creating the
+// file if needed.
+func Append(path string, rec *Record) error {
+	stored := *rec
+	stored.Number = 0
+	data, err := json.Marshal(&stored)
+	if err != nil {
+		return fmt.Errorf("failed to marshal history record: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write history record: %w", err)
+	}
+	return nil
+}
+
+// Load reads the records of the history file at path, oldest first.
+// Returns an empty list if the file doesn't exist. Lines that cannot be
+// parsed (e.g. a record truncated by a crash) are skipped.
+func Load(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			slog.Warn("skipping malformed history record", "path", path, "line", line, "error", err)
+			continue
+		}
+		rec.Number = len(records) + 1
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history file: %w", err)
+	}
+	return records, nil
+}
+
+// Filter selects records and the actions shown for them.
+type Filter struct {
+	Kind   resource.Kind
+	Name   string
+	Since  time.Time
+	Failed bool
+}
+
+// actionFilter reports whether the filter narrows records down to actions.
+func (f Filter) actionFilter() bool {
+	return f.Kind != "" || f.Name != "" || f.Failed
+}
+
+func (f Filter) matchAction(a *Action) bool {
+	if f.Kind != "" && a.Kind != f.Kind {
+		return false
+	}
+	if f.Name != "" && a.Name != f.Name {
+		return false
+	}
+	return !f.Failed || a.Outcome == OutcomeFailed
+}
+
+// Apply returns the records matching the filter. With a kind, name or
+// failed filter, only the matching actions of each record are kept and
+// records without any are dropped; a failed apply that failed before
+// executing any action matches a failed filter without kind or name.
+func (f Filter) Apply(records []Record) []Record {
+	var result []Record
+	for _, rec := range records {
+		if !f.Since.IsZero() && rec.StartedAt.Before(f.Since) {
+			continue
+		}
+		if !f.actionFilter() {
+			result = append(result, rec)
+			continue
+		}
+		var actions []Action
+		for _, a := range rec.Actions {
+			if f.matchAction(&a) {
+				actions = append(actions, a)
+			}
+		}
+		failedEarly := f.Failed && f.Kind == "" && f.Name == "" && rec.Outcome == OutcomeFailed && len(rec.Actions) == 0
+		if len(actions) == 0 && !failedEarly {
+			continue
+		}
+		rec.Actions = actions
+		result = append(result, rec)
+	}
+	return result
+}
+
+// ParseSince parses the --since value of "tomei history": a duration such as
+// "36h" or "7d", a date ("2026-01-02") or an RFC 3339 timestamp.
+func ParseSince(s string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		var n int
+		if _, err := fmt.Sscanf(days, "%d", &n); err == nil && fmt.Sprint(n) == days && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, s, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since value %q: expected a duration (e.g. 36h, 7d), a date (2006-01-02) or an RFC 3339 timestamp", s)
+}
+
+// Versions returns the installed versions in the state, keyed by kind/name.
+func Versions(st *state.UserState) map[string]string {
+	versions := make(map[string]string)
+	if st == nil {
+		return versions
+	}
+	for name, t := range st.Tools {
+		versions[key(resource.KindTool, name)] = t.Version
+	}
+	for name, rt := range st.Runtimes {
+		versions[key(resource.KindRuntime, name)] = rt.Version
+	}
+	for name, inst := range st.Installers {
+		versions[key(resource.KindInstaller, name)] = inst.Version
+	}
+	return versions
+}
+
+func key(kind resource.Kind, name string) string {
+	return string(kind) + "/" + name
+}
+
+// Recorder builds the record of an apply from its events.
+type Recorder struct {
+	mu      sync.Mutex
+	record  Record
+	before  map[string]string
+	started map[string]time.Time
+	actions map[string]*Action
+	order   []string
+}
+
+// NewRecorder starts the record of an apply. before holds the versions
+// installed before the apply, as returned by Versions.
+func NewRecorder(rec Record, before map[string]string) *Recorder {
+	if rec.StartedAt.IsZero() {
+		rec.StartedAt = time.Now()
+	}
+	return &Recorder{
+		record:  rec,
+		before:  before,
+		started: make(map[string]time.Time),
+		actions: make(map[string]*Action),
+	}
+}
+
+// RecordStart records the start of an action. version is the version the
+// action installs, replaced by the installed version in Finish.
+func (r *Recorder) RecordStart(kind resource.Kind, name, version string, action resource.ActionType) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	k := key(kind, name)
+	if _, ok := r.actions[k]; !ok {
+		r.order = append(r.order, k)
+	}
+	a := &Action{
+		Kind:       kind,
+		Name:       name,
+		Action:     action,
+		OldVersion: r.before[k],
+	}
+	if action != resource.ActionRemove {
+		a.NewVersion = version
+	}
+	r.actions[k] = a
+	r.started[k] = time.Now()
+}
+
+// RecordComplete records the successful completion of an action.
+func (r *Recorder) RecordComplete(kind resource.Kind, name string) {
+	r.finishAction(kind, name, OutcomeSuccess, nil)
+}
+
+// RecordError records the failure of an action.
+func (r *Recorder) RecordError(kind resource.Kind, name string, err error) {
+	r.finishAction(kind, name, OutcomeFailed, err)
+}
+
+func (r *Recorder) finishAction(kind resource.Kind, name string, outcome Outcome, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	k := key(kind, name)
+	a, ok := r.actions[k]
+	if !ok {
+		return
+	}
+	a.Duration = time.Since(r.started[k]).Round(time.Millisecond)
+	a.Outcome = outcome
+	if err != nil {
+		a.Error = err.Error()
+	}
+}
+
+// Finish completes the record with the result of the apply. after holds the
+// versions installed after the apply, as returned by Versions.
+func (r *Recorder) Finish(applyErr error, after map[string]string) *Record {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rec := r.record
+	rec.Duration = time.Since(rec.StartedAt).Round(time.Millisecond)
+	rec.Outcome = OutcomeSuccess
+	switch {
+	case errors.Is(applyErr, context.Canceled):
+		rec.Outcome = OutcomeCanceled
+	case applyErr != nil:
+		rec.Outcome = OutcomeFailed
+		rec.Error = applyErr.Error()
+	}
+
+	rec.Actions = make([]Action, 0, len(r.order))
+	for _, k := range r.order {
+		a := *r.actions[k]
+		if a.Outcome == "" {
+			// Started but neither completed nor failed: interrupted
+			a.Outcome = OutcomeCanceled
+			a.Duration = time.Since(r.started[k]).Round(time.Millisecond)
+		}
+		if v, ok := after[k]; ok && a.Outcome == OutcomeSuccess && a.Action != resource.ActionRemove {
+			a.NewVersion = v
+		}
+		rec.Actions = append(rec.Actions, a)
+	}
+	return &rec
+}
@@ -0,0 +1,165 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/terassyi/tomei/internal/resource"
+	"github.com/terassyi/tomei/internal/state"
+)
+
+func TestRecorder(t *testing.T) {
+	t.Parallel()
+
+	before := Versions(&state.UserState{
+		Tools: map[string]*resource.ToolState{
+			"rg": {Version: "14.1.0"},
+			"fd": {Version: "10.2.0"},
+		},
+	})
+	r := NewRecorder(Record{Command: "tomei apply .", TomeiVersion: "v0.5.0"}, before)
+
+	r.RecordStart(resource.KindTool, "rg", "14.1.1", resource.ActionUpgrade)
+	r.RecordComplete(resource.KindTool, "rg")
+	r.RecordStart(resource.KindTool, "jq", "latest", resource.ActionInstall)
+	r.RecordComplete(resource.KindTool, "jq")
+	r.RecordStart(resource.KindTool, "fd", "", resource.ActionRemove)
+	r.RecordComplete(resource.KindTool, "fd")
+	r.RecordStart(resource.KindTool, "bat", "0.25.0", resource.ActionInstall)
+	r.RecordError(resource.KindTool, "bat", errors.New("checksum mismatch"))
+
+	rec := r.Finish(errors.New("1 action failed"), map[string]string{
+		"Tool/rg": "14.1.1",
+		"Tool/jq": "1.7.1",
+	})
+
+	assert.Equal(t, OutcomeFailed, rec.Outcome)
+	assert.Equal(t, "1 action failed", rec.Error)
+	assert.Equal(t, "tomei apply .", rec.Command)
+	require.Len(t, rec.Actions, 4)
+	for i := range rec.Actions {
+		rec.Actions[i].Duration = 0
+	}
+	assert.Equal(t, []Action{
+		{Kind: resource.KindTool, Name: "rg", Action: resource.ActionUpgrade, OldVersion: "14.1.0", NewVersion: "14.1.1", Outcome: OutcomeSuccess},
+		{Kind: resource.KindTool, Name: "jq", Action: resource.ActionInstall, NewVersion: "1.7.1", Outcome: OutcomeSuccess},
+		{Kind: resource.KindTool, Name: "fd", Action: resource.ActionRemove, OldVersion: "10.2.0", Outcome: OutcomeSuccess},
+		{Kind: resource.KindTool, Name: "bat", Action: resource.ActionInstall, NewVersion: "0.25.0", Outcome: OutcomeFailed, Error: "checksum mismatch"},
+	}, rec.Actions)
+}
+
+func TestRecorder_Canceled(t *testing.T) {
+	t.Parallel()
+
+	r := NewRecorder(Record{}, nil)
+	r.RecordStart(resource.KindRuntime, "go", "1.25.6", resource.ActionInstall)
+	rec := r.Finish(context.Canceled, nil)
+
+	assert.Equal(t, OutcomeCanceled, rec.Outcome)
+	assert.Empty(t, rec.Error)
+	require.Len(t, rec.Actions, 1)
+	assert.Equal(t, OutcomeCanceled, rec.Actions[0].Outcome, "an action interrupted by the cancellation")
+}
+
+func TestAppendLoad(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "data", "apply-history.jsonl")
+
+	records, err := Load(path)
+	require.NoError(t, err)
+	assert.Empty(t, records)
+
+	first := &Record{StartedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), Outcome: OutcomeSuccess}
+	second := &Record{StartedAt: time.Date(2026, 1, 3, 3, 4, 5, 0, time.UTC), Outcome: OutcomeFailed, Error: "boom"}
+	require.NoError(t, Append(path, first))
+	require.NoError(t, Append(path, second))
+
+	// A record truncated by a crash is skipped
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"startedAt":"2026-01-04`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	records, err = Load(path)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, 1, records[0].Number)
+	assert.True(t, first.StartedAt.Equal(records[0].StartedAt))
+	assert.Equal(t, 2, records[1].Number)
+	assert.Equal(t, "boom", records[1].Error)
+}
+
+func TestFilter(t *testing.T) {
+	t.Parallel()
+
+	day := func(d int) time.Time { return time.Date(2026, 1, d, 0, 0, 0, 0, time.UTC) }
+	records := []Record{
+		{Number: 1, StartedAt: day(1), Outcome: OutcomeSuccess, Actions: []Action{
+			{Kind: resource.KindRuntime, Name: "go", Outcome: OutcomeSuccess},
+			{Kind: resource.KindTool, Name: "rg", Outcome: OutcomeSuccess},
+		}},
+		{Number: 2, StartedAt: day(2), Outcome: OutcomeFailed, Actions: []Action{
+			{Kind: resource.KindTool, Name: "rg", Outcome: OutcomeSuccess},
+			{Kind: resource.KindTool, Name: "bat", Outcome: OutcomeFailed},
+		}},
+		{Number: 3, StartedAt: day(3), Outcome: OutcomeFailed, Error: "failed to resolve versions"},
+	}
+
+	numbers := func(rs []Record) []int {
+		var n []int
+		for _, r := range rs {
+			n = append(n, r.Number)
+		}
+		return n
+	}
+
+	assert.Equal(t, []int{1, 2, 3}, numbers(Filter{}.Apply(records)))
+	assert.Equal(t, []int{2, 3}, numbers(Filter{Since: day(2)}.Apply(records)))
+
+	byName := Filter{Name: "rg"}.Apply(records)
+	assert.Equal(t, []int{1, 2}, numbers(byName))
+	assert.Len(t, byName[0].Actions, 1, "only the matching actions are kept")
+
+	assert.Equal(t, []int{1}, numbers(Filter{Kind: resource.KindRuntime}.Apply(records)))
+
+	failed := Filter{Failed: true}.Apply(records)
+	assert.Equal(t, []int{2, 3}, numbers(failed))
+	assert.Equal(t, "bat", failed[0].Actions[0].Name)
+	assert.Empty(t, Filter{Failed: true, Name: "rg"}.Apply(records))
+}
+
+func TestParseSince(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{in: "36h", want: now.Add(-36 * time.Hour)},
+		{in: "7d", want: now.AddDate(0, 0, -7)},
+		{in: "2026-10-01T00:00:00Z", want: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)},
+		{in: "2026-10-01", want: time.Date(2026, 10, 1, 0, 0, 0, 0, time.Local)},
+		{in: "yesterday", wantErr: true},
+		{in: "-1d", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSince(tt.in, now)
+		if tt.wantErr {
+			assert.Error(t, err, tt.in)
+			continue
+		}
+		require.NoError(t, err, tt.in)
+		assert.True(t, tt.want.Equal(got), "%s: got %s", tt.in, got)
+	}
+}
@@ -0,0 +1,66 @@
+package importer
+
+import (
+	"time"
+
+	"github.com/terassyi/tomei/internal/checksum"
+	"github.com/terassyi/tomei/internal/resource"
+	"github.com/terassyi/tomei/internal/state"
+)
+
+// Adoptable reports whether a candidate can be recorded in state as
+// installed. Only tools installed in place by a runtime or installer, at a
+// known version, match what "tomei apply" would install; aqua packages are
+// downloaded into the tomei data directory and must be reinstalled.
+func (c Candidate) Adoptable() bool {
+	return (c.Source == SourceGo || c.Source == SourceCargo) && c.Pinned()
+}
+
+// Adopt records the adoptable candidates in st as installed, in the same
+// form as the manifest generated by Manifest, so that the next apply does not
+// reinstall them. Tools already in state are left unchanged. It returns the
+// names of the adopted tools.
+func Adopt(st *state.UserState, candidates []Candidate, now time.Time) []string {
+	if st.Tools == nil {
+		st.Tools = make(map[string]*resource.ToolState)
+	}
+
+	var adopted []string
+	for _, c := range candidates {
+		if !c.Adoptable() {
+			continue
+		}
+		name := ToolName(c.Name)
+		if _, ok := st.Tools[name]; ok {
+			continue
+		}
+
+		ts := &resource.ToolState{
+			Version:     c.Version,
+			VersionKind: resource.ClassifyVersion(c.Version),
+			SpecVersion: c.Version,
+			BinPath:     c.Path,
+			Package:     &resource.Package{Name: c.Package},
+			UpdatedAt:   now,
+		}
+		switch {
+		case c.Source == SourceGo:
+			ts.RuntimeRef = goRuntimeName
+		case c.Package == crateCargoBinstall:
+			ts.RuntimeRef = rustRuntimeName
+		default:
+			ts.InstallerRef = binstallInstaller
+		}
+		if name != c.Name {
+			ts.BinaryName = c.Name
+		}
+		// Record the adopted binary so that "tomei verify" detects later changes
+		if digest, err := checksum.Calculate(c.Path, checksum.AlgorithmSHA256); err == nil {
+			ts.BinaryDigest = digest
+		}
+
+		st.Tools[name] = ts
+		adopted = append(adopted, name)
+	}
+	return adopted
+}
@@ -0,0 +1,272 @@
+// Package importer turns executables that tomei does not manage into tool
+// declarations, so that an existing workstation can be migrated to tomei.
+package importer
+
+import (
+	"cmp"
+	"context"
+	"debug/buildinfo"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/terassyi/tomei/internal/path"
+	"github.com/terassyi/tomei/internal/registry/aqua"
+)
+
+// Source represents how an imported tool is declared.
+type Source string
+
+const (
+	// SourceGo is a tool built by "go install", declared in a #GoToolSet.
+	SourceGo Source = "go"
+	// SourceCargo is a crate installed by cargo, declared in a #BinstallToolSet.
+	SourceCargo Source = "cargo"
+	// SourceAqua is a tool matched against aqua-registry, declared in an #AquaToolSet.
+	SourceAqua Source = "aqua"
+)
+
+// Runtime categories whose tools are matched by their build metadata.
+const (
+	categoryGo   = "go"
+	categoryRust = "rust"
+)
+
+// crateCargoBinstall is the crate of the cargo-binstall installer, which is
+// declared with the #CargoBinstall preset instead of a tool set entry.
+const crateCargoBinstall = "cargo-binstall"
+
+var releaseVersion = regexp.MustCompile(`^v?[0-9]+\.[0-9]+\.[0-9]+$`)
+
+// Binary is an executable found by the doctor scan.
+type Binary struct {
+	Name     string
+	Path     string
+	Category string // runtime name or "tomei"
+}
+
+// Candidate is a binary with the declaration it was matched to.
+type Candidate struct {
+	Binary
+	// Source is empty when the binary was not matched.
+	Source Source
+	// Package is the Go package path, crate name, or aqua package name.
+	Package string
+	// Version is the installed version, or empty when it is unknown.
+	Version string
+	// Alternatives lists other aqua packages that install the same command.
+	Alternatives []string
+}
+
+// Matched reports whether the binary was matched to a declaration.
+func (c Candidate) Matched() bool {
+	return c.Source != ""
+}
+
+// Pinned reports whether the installed version is known.
+func (c Candidate) Pinned() bool {
+	return c.Version != ""
+}
+
+// PackageFinder looks up aqua-registry packages by executable name.
+type PackageFinder interface {
+	FindCommand(ctx context.Context, ref aqua.RegistryRef, command string) ([]aqua.PackageInfo, error)
+}
+
+// Importer matches unmanaged binaries to tool declarations.
+type Importer struct {
+	finder    PackageFinder
+	ref       aqua.RegistryRef
+	cargoHome string
+}
+
+// New creates an Importer. Binaries are matched against aqua-registry at ref
+// unless finder is nil. cargoHome locates the .crates2.json written by cargo.
+func New(finder PackageFinder, ref aqua.RegistryRef, cargoHome string) *Importer {
+	return &Importer{finder: finder, ref: ref, cargoHome: cargoHome}
+}
+
+// Match returns a candidate for every binary, sorted by name. Binaries found
+// in several directories are matched once, preferring runtime directories.
+//
+// Binaries are matched in order:
+//  1. cargo: listed in .crates2.json with a crates.io source
+//  2. go: built with module information and found in the Go bin directory
+//  3. aqua: an aqua-registry package installs a command of the same name,
+//     preferring the repository of the Go module the binary was built from
+func (im *Importer) Match(ctx context.Context, binaries []Binary) ([]Candidate, error) {
+	crates, err := readCrates(filepath.Join(im.cargoHome, cratesFile))
+	if err != nil {
+		return nil, err
+	}
+
+	binaries = slices.Clone(binaries)
+	slices.SortStableFunc(binaries, func(a, b Binary) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(categoryOrder(a.Category), categoryOrder(b.Category)))
+	})
+	binaries = slices.CompactFunc(binaries, func(a, b Binary) bool { return a.Name == b.Name })
+
+	candidates := make([]Candidate, 0, len(binaries))
+	for _, b := range binaries {
+		c, err := im.match(ctx, b, crates)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, c)
+	}
+	return candidates, nil
+}
+
+// match matches a single binary.
+func (im *Importer) match(ctx context.Context, b Binary, crates map[string]crate) (Candidate, error) {
+	c := Candidate{Binary: b}
+
+	if cr, ok := crates[b.Name]; ok && b.Category == categoryRust {
+		c.Source, c.Package, c.Version = SourceCargo, cr.name, cr.version
+		return c, nil
+	}
+
+	var module, moduleVersion string
+	if info, err := buildinfo.ReadFile(b.Path); err == nil {
+		// Local builds are not installable by version
+		version := info.Main.Version
+		if version == "(devel)" || strings.HasSuffix(version, "+dirty") {
+			version = ""
+		}
+		if b.Category == categoryGo && info.Path != "" {
+			c.Source, c.Package, c.Version = SourceGo, info.Path, version
+			return c, nil
+		}
+		module, moduleVersion = info.Main.Path, version
+	}
+
+	if im.finder == nil {
+		return c, nil
+	}
+	pkgs, err := im.finder.FindCommand(ctx, im.ref, b.Name)
+	if err != nil {
+		return c, err
+	}
+	if len(pkgs) == 0 {
+		return c, nil
+	}
+	best := bestPackage(pkgs, module)
+	c.Source, c.Package = SourceAqua, pkgs[best].PackageName()
+	if releaseVersion.MatchString(moduleVersion) {
+		c.Version = moduleVersion
+	}
+	for i, p := range pkgs {
+		if i != best {
+			c.Alternatives = append(c.Alternatives, p.PackageName())
+		}
+	}
+	return c, nil
+}
+
+// bestPackage returns the index of the package built from the GitHub
+// repository of module, or 0.
+func bestPackage(pkgs []aqua.PackageInfo, module string) int {
+	owner, repo, ok := githubRepo(module)
+	if !ok {
+		return 0
+	}
+	for i, p := range pkgs {
+		if strings.EqualFold(p.RepoOwner, owner) && strings.EqualFold(p.RepoName, repo) {
+			return i
+		}
+	}
+	return 0
+}
+
+// githubRepo returns the owner and repository of a github.com module path.
+func githubRepo(module string) (string, string, bool) {
+	parts := strings.Split(module, "/")
+	if len(parts) < 3 || parts[0] != "github.com" {
+		return "", "", false
+	}
+	return parts[1], parts[2], true
+}
+
+// categoryOrder returns the preference of a scan category when a binary is
+// found in several directories: runtime directories carry build metadata.
+func categoryOrder(category string) int {
+	switch category {
+	case categoryGo, categoryRust:
+		return 0
+	default:
+		return 1
+	}
+}
+
+// cratesFile is the file where cargo records installed crates.
+const cratesFile = ".crates2.json"
+
+// crate is a crate installed from crates.io.
+type crate struct {
+	name    string
+	version string
+}
+
+// readCrates maps binary names to the crates.io crates that installed them.
+// Crates installed from git or local paths are ignored.
+func readCrates(p string) (map[string]crate, error) {
+	data, err := os.ReadFile(p)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", p, err)
+	}
+
+	var file struct {
+		Installs map[string]struct {
+			Bins []string `json:"bins"`
+		} `json:"installs"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", p, err)
+	}
+
+	crates := make(map[string]crate)
+	for key, install := range file.Installs {
+		// key: "<name> <version> (<source>)"
+		fields := strings.Fields(key)
+		if len(fields) != 3 || !isRegistrySource(fields[2]) {
+			continue
+		}
+		for _, bin := range install.Bins {
+			crates[bin] = crate{name: fields[0], version: fields[1]}
+		}
+	}
+	return crates, nil
+}
+
+// isRegistrySource reports whether a cargo package source is a crate registry.
+func isRegistrySource(source string) bool {
+	source = strings.Trim(source, "()")
+	return strings.HasPrefix(source, "registry+") || strings.HasPrefix(source, "sparse+")
+}
+
+// GoBinDir returns the directory where "go install" places binaries:
+// $GOBIN, else the bin directory of the first $GOPATH entry, else ~/go/bin.
+func GoBinDir() (string, error) {
+	if dir := os.Getenv("GOBIN"); dir != "" {
+		return dir, nil
+	}
+	if gopath := filepath.SplitList(os.Getenv("GOPATH")); len(gopath) > 0 && gopath[0] != "" {
+		return filepath.Join(gopath[0], "bin"), nil
+	}
+	return path.Expand("~/go/bin")
+}
+
+// CargoHome returns the cargo home directory: $CARGO_HOME, else ~/.cargo.
+func CargoHome() (string, error) {
+	if dir := os.Getenv("CARGO_HOME"); dir != "" {
+		return dir, nil
+	}
+	return path.Expand("~/.cargo")
+}
@@ -0,0 +1,129 @@
+package importer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/terassyi/tomei/internal/registry/aqua"
+)
+
+const testCrates = `{
+  "installs": {
+    "ripgrep 14.1.0 (registry+https://github.com/rust-lang/crates.io-index)": {"bins": ["rg"]},
+    "cargo-binstall 1.10.0 (sparse+https://index.crates.io/)": {"bins": ["cargo-binstall"]},
+    "mytool 0.1.0 (git+https://github.com/example/mytool#abcdef)": {"bins": ["mytool"]}
+  }
+}`
+
+// fakeFinder returns fixed packages by command name.
+type fakeFinder map[string][]aqua.PackageInfo
+
+func (f fakeFinder) FindCommand(_ context.Context, _ aqua.RegistryRef, command string) ([]aqua.PackageInfo, error) {
+	return f[command], nil
+}
+
+// copyExecutable copies the running test binary, which embeds Go build
+// information, to dir/name.
+func copyExecutable(t *testing.T, dir, name string) string {
+	t.Helper()
+	self, err := os.Executable()
+	require.NoError(t, err)
+	data, err := os.ReadFile(self)
+	require.NoError(t, err)
+	p := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(p, data, 0755))
+	return p
+}
+
+func TestReadCrates(t *testing.T) {
+	t.Parallel()
+
+	p := filepath.Join(t.TempDir(), cratesFile)
+	require.NoError(t, os.WriteFile(p, []byte(testCrates), 0644))
+
+	crates, err := readCrates(p)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]crate{
+		"rg":             {name: "ripgrep", version: "14.1.0"},
+		"cargo-binstall": {name: "cargo-binstall", version: "1.10.0"},
+	}, crates, "crates installed from git are ignored")
+
+	crates, err = readCrates(filepath.Join(t.TempDir(), cratesFile))
+	require.NoError(t, err)
+	assert.Empty(t, crates)
+}
+
+func TestImporter_Match(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	cargoHome := filepath.Join(tmpDir, "cargo")
+	require.NoError(t, os.MkdirAll(cargoHome, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(cargoHome, cratesFile), []byte(testCrates), 0644))
+
+	goBin := filepath.Join(tmpDir, "go", "bin")
+	binDir := filepath.Join(tmpDir, "bin")
+	require.NoError(t, os.MkdirAll(goBin, 0755))
+	require.NoError(t, os.MkdirAll(binDir, 0755))
+	goTool := copyExecutable(t, goBin, "gotool")
+	released := copyExecutable(t, binDir, "tomei")
+	script := filepath.Join(binDir, "fd")
+	require.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\n"), 0755))
+
+	finder := fakeFinder{
+		"tomei": {
+			{RepoOwner: "example", RepoName: "tomei"},
+			{RepoOwner: "terassyi", RepoName: "tomei"},
+		},
+		"fd": {{RepoOwner: "sharkdp", RepoName: "fd"}},
+	}
+	im := New(finder, "v4.465.0", cargoHome)
+
+	candidates, err := im.Match(context.Background(), []Binary{
+		{Name: "unknown", Path: filepath.Join(binDir, "unknown"), Category: "tomei"},
+		{Name: "rg", Path: filepath.Join(binDir, "rg"), Category: "tomei"},
+		{Name: "rg", Path: filepath.Join(cargoHome, "bin", "rg"), Category: "rust"},
+		{Name: "gotool", Path: goTool, Category: "go"},
+		{Name: "tomei", Path: released, Category: "tomei"},
+		{Name: "fd", Path: script, Category: "tomei"},
+	})
+	require.NoError(t, err)
+	require.Len(t, candidates, 5)
+
+	byName := make(map[string]Candidate)
+	for _, c := range candidates {
+		byName[c.Name] = c
+	}
+
+	assert.Equal(t, SourceGo, byName["gotool"].Source)
+	assert.Equal(t, "github.com/terassyi/tomei/internal/importer.test", byName["gotool"].Package)
+
+	assert.Equal(t, SourceCargo, byName["rg"].Source, "the cargo bin directory is preferred over the tomei bin directory")
+	assert.Equal(t, "ripgrep", byName["rg"].Package)
+	assert.Equal(t, "14.1.0", byName["rg"].Version)
+
+	assert.Equal(t, SourceAqua, byName["tomei"].Source)
+	assert.Equal(t, "terassyi/tomei", byName["tomei"].Package, "the package of the Go module repository is preferred")
+	assert.Equal(t, []string{"example/tomei"}, byName["tomei"].Alternatives)
+
+	assert.Equal(t, SourceAqua, byName["fd"].Source)
+	assert.Equal(t, "sharkdp/fd", byName["fd"].Package)
+	assert.False(t, byName["fd"].Pinned())
+
+	assert.False(t, byName["unknown"].Matched())
+
+	t.Run("without aqua-registry", func(t *testing.T) {
+		t.Parallel()
+		candidates, err := New(nil, "", cargoHome).Match(context.Background(), []Binary{
+			{Name: "fd", Path: script, Category: "tomei"},
+		})
+		require.NoError(t, err)
+		require.Len(t, candidates, 1)
+		assert.False(t, candidates[0].Matched())
+	})
+}
@@ -0,0 +1,212 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/terassyi/tomei/internal/state"
+)
+
+// Names of the presets and resources referenced by the generated manifest.
+const (
+	goRuntimeName      = "go"
+	rustRuntimeName    = "rust"
+	binstallInstaller  = "binstall"
+	latestVersion      = "latest"
+	importedNamePrefix = "imported-"
+)
+
+var (
+	invalidNameChars = regexp.MustCompile(`[^a-z0-9._-]+`)
+	cueIdentifier    = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+)
+
+// ToolName returns the tool name for a binary, normalized to a valid
+// metadata name.
+func ToolName(binary string) string {
+	name := invalidNameChars.ReplaceAllString(strings.ToLower(binary), "-")
+	return strings.Trim(name, "._-")
+}
+
+// toolSet is a tool set of the generated manifest.
+type toolSet struct {
+	label  string // CUE field name
+	preset string // e.g., "gopreset.#GoToolSet"
+	name   string // metadata name
+	note   string // comment above the declaration
+	tools  []Candidate
+}
+
+// Manifest renders the matched candidates as a CUE file in package tomei.
+// Go tools, cargo crates, and aqua packages are declared in one tool set
+// each. Resources already recorded in st are not declared again, and
+// unmatched binaries are listed in a trailing comment.
+func Manifest(candidates []Candidate, st *state.UserState) []byte {
+	goTools := toolSet{label: "importedGoTools", preset: "gopreset.#GoToolSet", name: importedNamePrefix + "go-tools"}
+	rustTools := toolSet{label: "importedRustTools", preset: "rust.#BinstallToolSet", name: importedNamePrefix + "rust-tools"}
+	aquaTools := toolSet{label: "importedAquaTools", preset: "aqua.#AquaToolSet", name: importedNamePrefix + "aqua-tools"}
+	var binstall *Candidate
+	var unmatched []Candidate
+	for _, c := range candidates {
+		switch {
+		case c.Source == SourceGo:
+			goTools.tools = append(goTools.tools, c)
+		case c.Source == SourceCargo && c.Package == crateCargoBinstall:
+			binstall = &c
+		case c.Source == SourceCargo:
+			rustTools.tools = append(rustTools.tools, c)
+		case c.Source == SourceAqua:
+			aquaTools.tools = append(aquaTools.tools, c)
+		default:
+			unmatched = append(unmatched, c)
+		}
+	}
+	if len(goTools.tools) > 0 && !hasRuntime(st, goRuntimeName) {
+		goTools.note = "Requires the go runtime, e.g. gopreset.#GoRuntime."
+	}
+
+	// Crates are installed by cargo-binstall, which is installed by cargo
+	needBinstall := binstall != nil || len(rustTools.tools) > 0
+	declareBinstall := needBinstall && !hasTool(st, crateCargoBinstall)
+	declareInstaller := len(rustTools.tools) > 0 && !hasInstaller(st, binstallInstaller)
+	rustNote := ""
+	if needBinstall && !hasRuntime(st, rustRuntimeName) {
+		rustNote = "Requires the rust runtime, e.g. rust.#RustRuntime."
+	}
+
+	var imports []string
+	if len(goTools.tools) > 0 {
+		imports = append(imports, `gopreset "tomei.terassyi.net/presets/go"`)
+	}
+	if declareBinstall || declareInstaller || len(rustTools.tools) > 0 {
+		imports = append(imports, `"tomei.terassyi.net/presets/rust"`)
+	}
+	if len(aquaTools.tools) > 0 {
+		imports = append(imports, `"tomei.terassyi.net/presets/aqua"`)
+	}
+
+	var b strings.Builder
+	b.WriteString("package tomei\n")
+	switch len(imports) {
+	case 0:
+	case 1:
+		fmt.Fprintf(&b, "\nimport %s\n", imports[0])
+	default:
+		b.WriteString("\nimport (\n")
+		for _, imp := range imports {
+			fmt.Fprintf(&b, "\t%s\n", imp)
+		}
+		b.WriteString(")\n")
+	}
+	b.WriteString("\n// Imported by \"tomei import\". Review the versions before running \"tomei apply\".\n")
+
+	writeToolSet(&b, goTools)
+	if declareBinstall || declareInstaller {
+		b.WriteString("\n")
+		if rustNote != "" {
+			fmt.Fprintf(&b, "// %s\n", rustNote)
+			rustNote = ""
+		}
+	}
+	if declareBinstall {
+		if binstall != nil && binstall.Pinned() {
+			fmt.Fprintf(&b, "cargoBinstall: rust.#CargoBinstall & {spec: version: %s}\n", cueString(binstall.Version))
+		} else {
+			b.WriteString("cargoBinstall: rust.#CargoBinstall\n")
+		}
+	}
+	if declareInstaller {
+		b.WriteString("binstallInstaller: rust.#BinstallInstaller\n")
+	}
+	rustTools.note = rustNote
+	writeToolSet(&b, rustTools)
+	writeToolSet(&b, aquaTools)
+
+	if len(unmatched) > 0 {
+		b.WriteString("\n// Not matched, declare these manually:\n")
+		for _, c := range unmatched {
+			fmt.Fprintf(&b, "//   %s: %s\n", c.Name, c.Path)
+		}
+	}
+	return []byte(b.String())
+}
+
+// writeToolSet writes a tool set declaration, if it has tools.
+func writeToolSet(b *strings.Builder, set toolSet) {
+	if len(set.tools) == 0 {
+		return
+	}
+	b.WriteString("\n")
+	if set.note != "" {
+		fmt.Fprintf(b, "// %s\n", set.note)
+	}
+	fmt.Fprintf(b, "%s: %s & {\n", set.label, set.preset)
+	fmt.Fprintf(b, "\tmetadata: name: %s\n", cueString(set.name))
+	b.WriteString("\tspec: tools: {\n")
+	for _, c := range set.tools {
+		fmt.Fprintf(b, "\t\t%s: {%s}\n", cueLabel(ToolName(c.Name)), strings.Join(toolFields(c), ", "))
+	}
+	b.WriteString("\t}\n")
+	b.WriteString("}\n")
+}
+
+// toolFields returns the fields of a tool set entry.
+func toolFields(c Candidate) []string {
+	fields := []string{"package: " + cueString(c.Package)}
+	switch {
+	case c.Pinned():
+		fields = append(fields, "version: "+cueString(c.Version))
+	case c.Source != SourceCargo:
+		// The Go and aqua presets require a version
+		fields = append(fields, "version: "+cueString(latestVersion))
+	}
+	if ToolName(c.Name) != c.Name {
+		fields = append(fields, "binaryName: "+cueString(c.Name))
+	}
+	return fields
+}
+
+func hasRuntime(st *state.UserState, name string) bool {
+	if st == nil {
+		return false
+	}
+	_, ok := st.Runtimes[name]
+	return ok
+}
+
+func hasTool(st *state.UserState, name string) bool {
+	if st == nil {
+		return false
+	}
+	_, ok := st.Tools[name]
+	return ok
+}
+
+func hasInstaller(st *state.UserState, name string) bool {
+	if st == nil {
+		return false
+	}
+	_, ok := st.Installers[name]
+	return ok
+}
+
+// cueLabel returns s as a CUE field label, quoting it when it is not an identifier.
+func cueLabel(s string) string {
+	if cueIdentifier.MatchString(s) {
+		return s
+	}
+	return cueString(s)
+}
+
+// cueString quotes s as a CUE string literal.
+// JSON string escapes are valid in CUE strings.
+func cueString(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
@@ -0,0 +1,157 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/terassyi/tomei/internal/resource"
+	"github.com/terassyi/tomei/internal/state"
+)
+
+func testCandidates() []Candidate {
+	return []Candidate{
+		{Binary: Binary{Name: "cargo-binstall", Path: "/home/user/.cargo/bin/cargo-binstall"}, Source: SourceCargo, Package: "cargo-binstall", Version: "1.10.0"},
+		{Binary: Binary{Name: "gh", Path: "/home/user/.local/bin/gh"}, Source: SourceAqua, Package: "cli/cli", Version: "v2.40.0"},
+		{Binary: Binary{Name: "gopls", Path: "/home/user/go/bin/gopls"}, Source: SourceGo, Package: "golang.org/x/tools/gopls", Version: "v0.21.0"},
+		{Binary: Binary{Name: "mystery", Path: "/home/user/.local/bin/mystery"}},
+		{Binary: Binary{Name: "rg", Path: "/home/user/.cargo/bin/rg"}, Source: SourceCargo, Package: "ripgrep", Version: "14.1.0"},
+		{Binary: Binary{Name: "staticcheck", Path: "/home/user/go/bin/staticcheck"}, Source: SourceGo, Package: "honnef.co/go/tools/cmd/staticcheck"},
+		{Binary: Binary{Name: "yq", Path: "/home/user/.local/bin/yq"}, Source: SourceAqua, Package: "mikefarah/yq"},
+		{Binary: Binary{Name: "Foo_Bar", Path: "/home/user/go/bin/Foo_Bar"}, Source: SourceGo, Package: "example.com/Foo_Bar", Version: "v1.0.0"},
+	}
+}
+
+func TestToolName(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "rg", ToolName("rg"))
+	assert.Equal(t, "foo_bar", ToolName("Foo_Bar"))
+	assert.Equal(t, "my-tool", ToolName("My Tool"))
+	assert.Equal(t, "kubectl-krew", ToolName("kubectl-krew"))
+	assert.Equal(t, "x", ToolName(".x."))
+}
+
+func TestManifest(t *testing.T) {
+	t.Parallel()
+
+	want := `package tomei
+
+import (
+	gopreset "tomei.terassyi.net/presets/go"
+	"tomei.terassyi.net/presets/rust"
+	"tomei.terassyi.net/presets/aqua"
+)
+
+// Imported by "tomei import". Review the versions before running "tomei apply".
+
+// Requires the go runtime, e.g. gopreset.#GoRuntime.
+importedGoTools: gopreset.#GoToolSet & {
+	metadata: name: "imported-go-tools"
+	spec: tools: {
+		gopls: {package: "golang.org/x/tools/gopls", version: "v0.21.0"}
+		staticcheck: {package: "honnef.co/go/tools/cmd/staticcheck", version: "latest"}
+		foo_bar: {package: "example.com/Foo_Bar", version: "v1.0.0", binaryName: "Foo_Bar"}
+	}
+}
+
+// Requires the rust runtime, e.g. rust.#RustRuntime.
+cargoBinstall: rust.#CargoBinstall & {spec: version: "1.10.0"}
+binstallInstaller: rust.#BinstallInstaller
+
+importedRustTools: rust.#BinstallToolSet & {
+	metadata: name: "imported-rust-tools"
+	spec: tools: {
+		rg: {package: "ripgrep", version: "14.1.0"}
+	}
+}
+
+importedAquaTools: aqua.#AquaToolSet & {
+	metadata: name: "imported-aqua-tools"
+	spec: tools: {
+		gh: {package: "cli/cli", version: "v2.40.0"}
+		yq: {package: "mikefarah/yq", version: "latest"}
+	}
+}
+
+// Not matched, declare these manually:
+//   mystery: /home/user/.local/bin/mystery
+`
+	assert.Equal(t, want, string(Manifest(testCandidates(), state.NewUserState())))
+
+	t.Run("resources in state are not declared again", func(t *testing.T) {
+		t.Parallel()
+
+		st := state.NewUserState()
+		st.Runtimes["go"] = &resource.RuntimeState{Version: "1.25.5"}
+		st.Runtimes["rust"] = &resource.RuntimeState{Version: "stable"}
+		st.Tools["cargo-binstall"] = &resource.ToolState{Version: "1.10.0", RuntimeRef: "rust"}
+		st.Installers["binstall"] = &resource.InstallerState{}
+
+		candidates := []Candidate{
+			{Binary: Binary{Name: "gopls"}, Source: SourceGo, Package: "golang.org/x/tools/gopls", Version: "v0.21.0"},
+			{Binary: Binary{Name: "rg"}, Source: SourceCargo, Package: "ripgrep"},
+		}
+		want := `package tomei
+
+import (
+	gopreset "tomei.terassyi.net/presets/go"
+	"tomei.terassyi.net/presets/rust"
+)
+
+// Imported by "tomei import". Review the versions before running "tomei apply".
+
+importedGoTools: gopreset.#GoToolSet & {
+	metadata: name: "imported-go-tools"
+	spec: tools: {
+		gopls: {package: "golang.org/x/tools/gopls", version: "v0.21.0"}
+	}
+}
+
+importedRustTools: rust.#BinstallToolSet & {
+	metadata: name: "imported-rust-tools"
+	spec: tools: {
+		rg: {package: "ripgrep"}
+	}
+}
+`
+		assert.Equal(t, want, string(Manifest(candidates, st)))
+	})
+}
+
+func TestAdopt(t *testing.T) {
+	t.Parallel()
+
+	binDir := t.TempDir()
+	gopls := filepath.Join(binDir, "gopls")
+	require.NoError(t, os.WriteFile(gopls, []byte("gopls"), 0755))
+
+	candidates := testCandidates()
+	candidates[2].Path = gopls
+
+	st := state.NewUserState()
+	st.Tools["rg"] = &resource.ToolState{Version: "13.0.0", InstallerRef: "aqua"}
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	adopted := Adopt(st, candidates, now)
+	assert.Equal(t, []string{"cargo-binstall", "gopls", "foo_bar"}, adopted,
+		"aqua packages, unknown versions, and tools in state are not adopted")
+
+	goplsState := st.Tools["gopls"]
+	assert.Equal(t, "go", goplsState.RuntimeRef)
+	assert.Equal(t, "v0.21.0", goplsState.Version)
+	assert.Equal(t, resource.VersionExact, goplsState.VersionKind)
+	assert.Equal(t, "v0.21.0", goplsState.SpecVersion)
+	assert.Equal(t, &resource.Package{Name: "golang.org/x/tools/gopls"}, goplsState.Package)
+	assert.Equal(t, gopls, goplsState.BinPath)
+	assert.NotEmpty(t, goplsState.BinaryDigest)
+	assert.Equal(t, now, goplsState.UpdatedAt)
+
+	assert.Equal(t, "rust", st.Tools["cargo-binstall"].RuntimeRef)
+	assert.Equal(t, "Foo_Bar", st.Tools["foo_bar"].BinaryName)
+	assert.Equal(t, "13.0.0", st.Tools["rg"].Version, "tools in state are left unchanged")
+}
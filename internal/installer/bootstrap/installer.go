@@ -0,0 +1,141 @@
+// Package bootstrap installs Installer resources.
+//
+// Installers that are not provided by a runtime or tool (e.g., Homebrew)
+// declare bootstrap commands that install the installer itself. Installers
+// without bootstrap commands are recorded in state only.
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/terassyi/tomei/internal/installer/command"
+	"github.com/terassyi/tomei/internal/installer/download"
+	"github.com/terassyi/tomei/internal/path"
+	"github.com/terassyi/tomei/internal/resource"
+)
+
+// commandRunner is the interface for executing shell commands.
+// This enables testing with mocks instead of real command execution.
+type commandRunner interface {
+	ExecuteWithEnv(ctx context.Context, cmds []string, vars command.Vars, env map[string]string) error
+	ExecuteWithOutput(ctx context.Context, cmds []string, vars command.Vars, env map[string]string, callback command.OutputCallback) error
+	Check(ctx context.Context, cmds []string, vars command.Vars, env map[string]string) bool
+}
+
+// Installer installs and removes Installer resources.
+type Installer struct {
+	cmdRunner commandRunner
+}
+
+// NewInstaller creates a new bootstrap Installer.
+func NewInstaller() *Installer {
+	return &Installer{cmdRunner: command.NewExecutor("")}
+}
+
+// newInstallerWithRunner creates a new bootstrap Installer with a custom runner (for testing).
+func newInstallerWithRunner(runner commandRunner) *Installer {
+	return &Installer{cmdRunner: runner}
+}
+
+// Install runs the bootstrap commands of the installer (if any) and returns its state.
+// When the check command already succeeds, the install command is skipped so that
+// installers set up outside tomei are adopted without being reinstalled.
+func (i *Installer) Install(ctx context.Context, res *resource.Installer, name string) (*resource.InstallerState, error) {
+	spec := res.InstallerSpec
+
+	var binDir string
+	if spec.BinDir != "" {
+		var err error
+		binDir, err = path.Expand(spec.BinDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand binDir: %w", err)
+		}
+	}
+
+	st := &resource.InstallerState{
+		ToolRef:   spec.ToolRef,
+		BinDir:    binDir,
+		UpdatedAt: time.Now(),
+	}
+
+	if spec.Bootstrap == nil {
+		return st, nil
+	}
+	if len(spec.Bootstrap.Install) == 0 {
+		return nil, fmt.Errorf("bootstrap.install is required")
+	}
+
+	vars := command.Vars{Name: name}
+	env := buildEnv(binDir)
+
+	if len(spec.Bootstrap.Check) > 0 && i.cmdRunner.Check(ctx, spec.Bootstrap.Check, vars, env) {
+		slog.Debug("installer already bootstrapped, skipping install", "name", name)
+	} else {
+		slog.Debug("bootstrapping installer", "name", name)
+		if err := i.execute(ctx, spec.Bootstrap.Install, vars, env); err != nil {
+			return nil, fmt.Errorf("bootstrap install failed: %w", err)
+		}
+		if len(spec.Bootstrap.Check) > 0 && !i.cmdRunner.Check(ctx, spec.Bootstrap.Check, vars, env) {
+			return nil, fmt.Errorf("bootstrap check failed after install")
+		}
+	}
+
+	st.Bootstrap = &resource.BootstrapSpec{
+		Install: slices.Clone(spec.Bootstrap.Install),
+		Check:   slices.Clone(spec.Bootstrap.Check),
+		Remove:  slices.Clone(spec.Bootstrap.Remove),
+	}
+	return st, nil
+}
+
+// Remove runs the bootstrap remove command recorded in state.
+// Installers without a remove command are only dropped from state.
+func (i *Installer) Remove(ctx context.Context, st *resource.InstallerState, name string) error {
+	if st.Bootstrap == nil || len(st.Bootstrap.Remove) == 0 {
+		if st.Bootstrap != nil {
+			slog.Warn("no bootstrap remove command for installer, skipping", "name", name)
+		}
+		return nil
+	}
+
+	if err := i.execute(ctx, st.Bootstrap.Remove, command.Vars{Name: name}, buildEnv(st.BinDir)); err != nil {
+		return fmt.Errorf("bootstrap remove failed: %w", err)
+	}
+
+	slog.Debug("installer removed", "name", name)
+	return nil
+}
+
+// IsInstalled runs the bootstrap check command recorded in state.
+// Returns true when the installer has no check command, since there is
+// nothing to detect drift against.
+func (i *Installer) IsInstalled(ctx context.Context, st *resource.InstallerState, name string) bool {
+	if st.Bootstrap == nil || len(st.Bootstrap.Check) == 0 {
+		return true
+	}
+	return i.cmdRunner.Check(ctx, st.Bootstrap.Check, command.Vars{Name: name}, buildEnv(st.BinDir))
+}
+
+// execute runs commands, routing output to the context callback if present.
+func (i *Installer) execute(ctx context.Context, cmds []string, vars command.Vars, env map[string]string) error {
+	if cb := download.CallbackFromContext[download.OutputCallback](ctx); cb != nil {
+		return i.cmdRunner.ExecuteWithOutput(ctx, cmds, vars, env, command.OutputCallback(cb))
+	}
+	return i.cmdRunner.ExecuteWithEnv(ctx, cmds, vars, env)
+}
+
+// buildEnv prepends binDir to PATH so that check commands can find
+// binaries the bootstrap placed outside the user's PATH (e.g., /opt/homebrew/bin).
+func buildEnv(binDir string) map[string]string {
+	if binDir == "" {
+		return nil
+	}
+	return map[string]string{
+		"PATH": binDir + string(os.PathListSeparator) + os.Getenv("PATH"),
+	}
+}
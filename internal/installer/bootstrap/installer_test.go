@@ -0,0 +1,204 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/terassyi/tomei/internal/installer/command"
+	"github.com/terassyi/tomei/internal/resource"
+)
+
+type cmdCall struct {
+	cmds []string
+	vars command.Vars
+	env  map[string]string
+}
+
+// mockCommandRunner returns checkResults in order (false once exhausted).
+type mockCommandRunner struct {
+	executeErr   error
+	checkResults []bool
+	executeCalls []cmdCall
+	checkCalls   []cmdCall
+}
+
+func (m *mockCommandRunner) ExecuteWithEnv(_ context.Context, cmds []string, vars command.Vars, env map[string]string) error {
+	m.executeCalls = append(m.executeCalls, cmdCall{cmds: cmds, vars: vars, env: env})
+	return m.executeErr
+}
+
+func (m *mockCommandRunner) ExecuteWithOutput(ctx context.Context, cmds []string, vars command.Vars, env map[string]string, _ command.OutputCallback) error {
+	return m.ExecuteWithEnv(ctx, cmds, vars, env)
+}
+
+func (m *mockCommandRunner) Check(_ context.Context, cmds []string, vars command.Vars, env map[string]string) bool {
+	m.checkCalls = append(m.checkCalls, cmdCall{cmds: cmds, vars: vars, env: env})
+	if len(m.checkResults) == 0 {
+		return false
+	}
+	result := m.checkResults[0]
+	m.checkResults = m.checkResults[1:]
+	return result
+}
+
+func newTestInstaller(spec *resource.InstallerSpec) *resource.Installer {
+	return &resource.Installer{
+		BaseResource: resource.BaseResource{
+			APIVersion:   resource.GroupVersion,
+			ResourceKind: resource.KindInstaller,
+			Metadata:     resource.Metadata{Name: "brew"},
+		},
+		InstallerSpec: spec,
+	}
+}
+
+func brewSpec() *resource.InstallerSpec {
+	return &resource.InstallerSpec{
+		Type: resource.InstallTypeDelegation,
+		Bootstrap: &resource.BootstrapSpec{
+			Install: []string{"install-brew.sh"},
+			Check:   []string{"command -v brew"},
+			Remove:  []string{"uninstall-brew.sh"},
+		},
+		Commands: &resource.CommandsSpec{Install: []string{"brew install {{.Package}}"}},
+		BinDir:   "/opt/homebrew/bin",
+	}
+}
+
+func TestInstaller_Install(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		spec         *resource.InstallerSpec
+		checkResults []bool
+		executeErr   error
+		wantExecuted bool
+		wantErr      string
+	}{
+		{
+			name:         "bootstrap runs install and verifies",
+			spec:         brewSpec(),
+			checkResults: []bool{false, true},
+			wantExecuted: true,
+		},
+		{
+			name:         "already installed skips install",
+			spec:         brewSpec(),
+			checkResults: []bool{true},
+		},
+		{
+			name:         "check fails after install",
+			spec:         brewSpec(),
+			checkResults: []bool{false, false},
+			wantExecuted: true,
+			wantErr:      "bootstrap check failed after install",
+		},
+		{
+			name:         "install command fails",
+			spec:         brewSpec(),
+			executeErr:   errors.New("exit status 1"),
+			wantExecuted: true,
+			wantErr:      "bootstrap install failed",
+		},
+		{
+			name: "no bootstrap only records state",
+			spec: &resource.InstallerSpec{
+				Type:     resource.InstallTypeDelegation,
+				ToolRef:  "cargo-binstall",
+				Commands: &resource.CommandsSpec{Install: []string{"cargo binstall -y {{.Package}}"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			runner := &mockCommandRunner{checkResults: tt.checkResults, executeErr: tt.executeErr}
+			inst := newInstallerWithRunner(runner)
+
+			st, err := inst.Install(context.Background(), newTestInstaller(tt.spec), "brew")
+			assert.Equal(t, tt.wantExecuted, len(runner.executeCalls) > 0)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.spec.ToolRef, st.ToolRef)
+			assert.Equal(t, tt.spec.Bootstrap, st.Bootstrap)
+		})
+	}
+}
+
+func TestInstaller_Install_BinDirOnPath(t *testing.T) {
+	t.Parallel()
+
+	runner := &mockCommandRunner{checkResults: []bool{true}}
+	st, err := newInstallerWithRunner(runner).Install(context.Background(), newTestInstaller(brewSpec()), "brew")
+	require.NoError(t, err)
+	assert.Equal(t, "/opt/homebrew/bin", st.BinDir)
+
+	require.Len(t, runner.checkCalls, 1)
+	assert.Equal(t, "brew", runner.checkCalls[0].vars.Name)
+	assert.True(t, strings.HasPrefix(runner.checkCalls[0].env["PATH"], "/opt/homebrew/bin"+string(os.PathListSeparator)))
+}
+
+func TestInstaller_Install_ExpandsBinDir(t *testing.T) {
+	t.Parallel()
+
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	spec := brewSpec()
+	spec.BinDir = "~/.linuxbrew/bin"
+	st, err := newInstallerWithRunner(&mockCommandRunner{checkResults: []bool{true}}).Install(context.Background(), newTestInstaller(spec), "brew")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, ".linuxbrew/bin"), st.BinDir)
+}
+
+func TestInstaller_Remove(t *testing.T) {
+	t.Parallel()
+
+	t.Run("runs bootstrap remove", func(t *testing.T) {
+		t.Parallel()
+		runner := &mockCommandRunner{}
+		st := &resource.InstallerState{Bootstrap: brewSpec().Bootstrap}
+		require.NoError(t, newInstallerWithRunner(runner).Remove(context.Background(), st, "brew"))
+		require.Len(t, runner.executeCalls, 1)
+		assert.Equal(t, []string{"uninstall-brew.sh"}, runner.executeCalls[0].cmds)
+	})
+
+	t.Run("no remove command is a no-op", func(t *testing.T) {
+		t.Parallel()
+		runner := &mockCommandRunner{}
+		st := &resource.InstallerState{Bootstrap: &resource.BootstrapSpec{Install: []string{"install.sh"}}}
+		require.NoError(t, newInstallerWithRunner(runner).Remove(context.Background(), st, "brew"))
+		assert.Empty(t, runner.executeCalls)
+	})
+
+	t.Run("remove failure", func(t *testing.T) {
+		t.Parallel()
+		runner := &mockCommandRunner{executeErr: errors.New("exit status 1")}
+		st := &resource.InstallerState{Bootstrap: brewSpec().Bootstrap}
+		err := newInstallerWithRunner(runner).Remove(context.Background(), st, "brew")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "bootstrap remove failed")
+	})
+}
+
+func TestInstaller_IsInstalled(t *testing.T) {
+	t.Parallel()
+
+	inst := newInstallerWithRunner(&mockCommandRunner{checkResults: []bool{false}})
+	assert.False(t, inst.IsInstalled(context.Background(), &resource.InstallerState{Bootstrap: brewSpec().Bootstrap}, "brew"))
+
+	// Nothing to check against
+	assert.True(t, inst.IsInstalled(context.Background(), &resource.InstallerState{}, "binstall"))
+}
@@ -39,6 +39,33 @@ type Vars struct {
 	// Security: values are sourced from the user's own CUE manifest,
 	// not from external/untrusted input.
 	Args string
+	// Packages holds the tools installed by a batchInstall command.
+	Packages Packages
+}
+
+// Package is a tool installed by a batchInstall command.
+type Package struct {
+	Package string // Package path (e.g., typescript)
+	Version string // Version string (e.g., 5.8.3)
+	Name    string // Tool name (e.g., tsc)
+}
+
+// Packages is the list of tools of a batchInstall command. In templates,
+// {{.Packages}} expands to the space-separated "<package>@<version>" of each
+// tool (just the package when the version is empty), and
+// {{range .Packages}} iterates over them.
+type Packages []Package
+
+// String returns the space-separated "<package>@<version>" of each tool.
+func (p Packages) String() string {
+	specs := make([]string, len(p))
+	for i, pkg := range p {
+		specs[i] = pkg.Package
+		if pkg.Version != "" {
+			specs[i] += "@" + pkg.Version
+		}
+	}
+	return strings.Join(specs, " ")
 }
 
 // Executor executes shell commands with variable substitution.
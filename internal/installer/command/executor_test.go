@@ -81,6 +81,28 @@ func TestExecutor_expand(t *testing.T) {
 			},
 			expected: "cmd  ",
 		},
+		{
+			name:   "expand packages",
+			cmdStr: "pnpm add -g {{.Packages}}",
+			vars: Vars{
+				Packages: Packages{
+					{Package: "typescript", Version: "5.8.3", Name: "tsc"},
+					{Package: "prettier", Name: "prettier"},
+				},
+			},
+			expected: "pnpm add -g typescript@5.8.3 prettier",
+		},
+		{
+			name:   "range over packages",
+			cmdStr: "uv pip install{{range .Packages}} {{.Package}}=={{.Version}}{{end}}",
+			vars: Vars{
+				Packages: Packages{
+					{Package: "ruff", Version: "0.11.2"},
+					{Package: "black", Version: "25.1.0"},
+				},
+			},
+			expected: "uv pip install ruff==0.11.2 black==25.1.0",
+		},
 	}
 
 	for _, tt := range tests {
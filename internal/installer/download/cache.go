@@ -0,0 +1,373 @@
+package download
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/terassyi/tomei/internal/checksum"
+)
+
+const (
+	cacheDirName    = "downloads"
+	cacheEntriesDir = "entries"
+	cacheBlobsDir   = "blobs"
+	cachePartialDir = "partial"
+)
+
+// CacheDir returns the download cache directory under the user cache directory.
+func CacheDir(userCacheDir string) string {
+	return filepath.Join(userCacheDir, cacheDirName)
+}
+
+// CacheEntry records a verified download stored in the cache.
+type CacheEntry struct {
+	URL       string          `json:"url"`
+	Digest    checksum.Digest `json:"digest"` // sha256 of the content
+	Size      int64           `json:"size"`
+	CreatedAt time.Time       `json:"createdAt"`
+	LastUsed  time.Time       `json:"lastUsed"`
+}
+
+// Cache is a content-addressed store of verified downloads, keyed by URL and
+// the sha256 digest the content was expected to have.
+//
+// Layout:
+//
+//	<dir>/blobs/<sha256>            archive content
+//	<dir>/entries/<sha256(url, digest)>.json URL and digest -> size and timestamps
+//	<dir>/partial/<sha256(url)>.partial incomplete download, resumed by the next attempt
+//
+// Archives with identical content share a blob, so the same artifact
+// published under several URLs is stored once. A URL re-published with other
+// content gets an entry of its own, so a lookup never returns content other
+// than the expected one.
+type Cache struct {
+	dir string
+	now func() time.Time
+}
+
+// NewCache creates a Cache rooted at dir (e.g., ~/.cache/tomei/downloads).
+func NewCache(dir string) *Cache {
+	return &Cache{dir: dir, now: time.Now}
+}
+
+// Dir returns the cache root directory.
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+func (c *Cache) entryPath(url string, digest checksum.Digest) string {
+	sum := sha256.Sum256([]byte(url + "\x00" + string(digest)))
+	return filepath.Join(c.dir, cacheEntriesDir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (c *Cache) blobPath(digest checksum.Digest) string {
+	return filepath.Join(c.dir, cacheBlobsDir, string(digest))
+}
+
+// PartialPath returns the path of the incomplete download of url.
+func (c *Cache) PartialPath(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(c.dir, cachePartialDir, hex.EncodeToString(sum[:])+partialSuffix)
+}
+
+// Fetch copies the cached content of url with the sha256 digest to destPath.
+// The blob is verified against the digest; a corrupted blob is evicted and
+// reported as a miss. Returns the size and whether it was a hit.
+func (c *Cache) Fetch(url string, digest checksum.Digest, destPath string) (int64, bool) {
+	entry, err := c.readEntry(c.entryPath(url, digest))
+	if err != nil || entry.URL != url || entry.Digest != digest {
+		return 0, false
+	}
+	blob := c.blobPath(entry.Digest)
+	if err := checksum.Verify(blob, checksum.AlgorithmSHA256, entry.Digest); err != nil {
+		slog.Warn("evicting corrupted download cache entry", "url", url, "error", err)
+		_ = os.Remove(c.entryPath(url, digest))
+		_ = os.Remove(blob)
+		return 0, false
+	}
+	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+		return 0, false
+	}
+	if err := copyFile(blob, destPath); err != nil {
+		slog.Debug("failed to copy cached download", "url", url, "error", err)
+		return 0, false
+	}
+
+	entry.LastUsed = c.now().UTC()
+	if err := c.writeEntry(entry); err != nil {
+		slog.Debug("failed to update download cache entry", "url", url, "error", err)
+	}
+	return entry.Size, true
+}
+
+// Store adds the file at path to the cache under url. The content must have
+// the expected sha256 digest; other content is not stored.
+func (c *Cache) Store(url, path string, expected checksum.Digest) error {
+	digest, err := checksum.Calculate(path, checksum.AlgorithmSHA256)
+	if err != nil {
+		return fmt.Errorf("failed to calculate digest: %w", err)
+	}
+	if digest != expected {
+		return fmt.Errorf("content of %s has digest %s, expected %s", url, digest, expected)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat download: %w", err)
+	}
+
+	blob := c.blobPath(digest)
+	if _, err := os.Stat(blob); err != nil {
+		if err := os.MkdirAll(filepath.Dir(blob), 0755); err != nil {
+			return fmt.Errorf("failed to create cache directory: %w", err)
+		}
+		tmp, err := os.CreateTemp(filepath.Dir(blob), ".blob-*.tmp")
+		if err != nil {
+			return fmt.Errorf("failed to create cache blob: %w", err)
+		}
+		tmp.Close()
+		if err := copyFile(path, tmp.Name()); err != nil {
+			os.Remove(tmp.Name())
+			return err
+		}
+		if err := os.Rename(tmp.Name(), blob); err != nil {
+			os.Remove(tmp.Name())
+			return fmt.Errorf("failed to rename cache blob: %w", err)
+		}
+	}
+
+	now := c.now().UTC()
+	return c.writeEntry(&CacheEntry{
+		URL:       url,
+		Digest:    digest,
+		Size:      info.Size(),
+		CreatedAt: now,
+		LastUsed:  now,
+	})
+}
+
+// List returns the cache entries, most recently used first.
+func (c *Cache) List() ([]CacheEntry, error) {
+	dir := filepath.Join(c.dir, cacheEntriesDir)
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read download cache: %w", err)
+	}
+
+	var entries []CacheEntry
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), ".json") || strings.HasPrefix(f.Name(), ".") {
+			continue
+		}
+		entry, err := c.readEntry(filepath.Join(dir, f.Name()))
+		if err != nil {
+			slog.Debug("skipping invalid download cache entry", "file", f.Name(), "error", err)
+			continue
+		}
+		entries = append(entries, *entry)
+	}
+	slices.SortFunc(entries, func(a, b CacheEntry) int { return b.LastUsed.Compare(a.LastUsed) })
+	return entries, nil
+}
+
+// Size returns the total size of the stored blobs in bytes.
+func (c *Cache) Size() (int64, error) {
+	blobs, err := os.ReadDir(filepath.Join(c.dir, cacheBlobsDir))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to read download cache: %w", err)
+	}
+	var total int64
+	for _, b := range blobs {
+		if info, err := b.Info(); err == nil && !b.IsDir() {
+			total += info.Size()
+		}
+	}
+	return total, nil
+}
+
+// Prune evicts entries not used within maxAge, then the least recently used
+// entries until the blobs fit in maxSize. A zero maxAge or maxSize disables
+// that limit. Blobs no longer referenced by any entry are removed.
+// Returns the evicted entries.
+func (c *Cache) Prune(maxAge time.Duration, maxSize int64) ([]CacheEntry, error) {
+	entries, err := c.List()
+	if err != nil {
+		return nil, err
+	}
+
+	// Oldest last-use first
+	slices.Reverse(entries)
+
+	var evicted []CacheEntry
+	kept := entries
+	if maxAge > 0 {
+		cutoff := c.now().Add(-maxAge)
+		kept = kept[:0:0]
+		for _, e := range entries {
+			if e.LastUsed.Before(cutoff) {
+				evicted = append(evicted, e)
+			} else {
+				kept = append(kept, e)
+			}
+		}
+	}
+	if maxSize > 0 {
+		for len(kept) > 0 && blobSize(kept) > maxSize {
+			evicted = append(evicted, kept[0])
+			kept = kept[1:]
+		}
+	}
+
+	for _, e := range evicted {
+		if err := os.Remove(c.entryPath(e.URL, e.Digest)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return evicted, fmt.Errorf("failed to remove cache entry: %w", err)
+		}
+	}
+	if err := c.removeUnreferencedBlobs(kept); err != nil {
+		return evicted, err
+	}
+	if maxAge > 0 {
+		c.removeStalePartials(c.now().Add(-maxAge))
+	}
+	return evicted, nil
+}
+
+// removeStalePartials removes incomplete downloads not written since cutoff.
+func (c *Cache) removeStalePartials(cutoff time.Time) {
+	dir := filepath.Join(c.dir, cachePartialDir)
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, f := range files {
+		if info, err := f.Info(); err == nil && info.ModTime().Before(cutoff) {
+			_ = os.Remove(filepath.Join(dir, f.Name()))
+		}
+	}
+}
+
+// Clear removes the whole cache.
+func (c *Cache) Clear() error {
+	if err := os.RemoveAll(c.dir); err != nil {
+		return fmt.Errorf("failed to clear download cache: %w", err)
+	}
+	return nil
+}
+
+// blobSize returns the size of the distinct blobs referenced by entries.
+func blobSize(entries []CacheEntry) int64 {
+	seen := make(map[checksum.Digest]bool)
+	var total int64
+	for _, e := range entries {
+		if !seen[e.Digest] {
+			seen[e.Digest] = true
+			total += e.Size
+		}
+	}
+	return total
+}
+
+func (c *Cache) removeUnreferencedBlobs(entries []CacheEntry) error {
+	referenced := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		referenced[string(e.Digest)] = true
+	}
+	dir := filepath.Join(c.dir, cacheBlobsDir)
+	blobs, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to read download cache: %w", err)
+	}
+	for _, b := range blobs {
+		if referenced[b.Name()] || strings.HasPrefix(b.Name(), ".") {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, b.Name())); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove cache blob: %w", err)
+		}
+	}
+	return nil
+}
+
+func (c *Cache) readEntry(path string) (*CacheEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entry CacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	if _, err := hex.DecodeString(string(entry.Digest)); err != nil || len(entry.Digest) != sha256.Size*2 {
+		return nil, fmt.Errorf("invalid digest %q", entry.Digest)
+	}
+	return &entry, nil
+}
+
+// writeEntry writes an entry atomically (write to temp file then rename).
+func (c *Cache) writeEntry(entry *CacheEntry) error {
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache entry: %w", err)
+	}
+	path := c.entryPath(entry.URL, entry.Digest)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".entry-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create cache entry: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to close cache entry: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to rename cache entry: %w", err)
+	}
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", src, err)
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", dst, err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to copy to %s: %w", dst, err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", dst, err)
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+package download
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/terassyi/tomei/internal/checksum"
+	"github.com/terassyi/tomei/internal/resource"
+)
+
+func writeTempFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func digestOf(content string) checksum.Digest {
+	return checksum.Digest(fmt.Sprintf("%x", sha256.Sum256([]byte(content))))
+}
+
+func TestCache_StoreFetch(t *testing.T) {
+	t.Parallel()
+	cache := NewCache(t.TempDir())
+	url := "https://example.com/a.tar.gz"
+
+	_, ok := cache.Fetch(url, digestOf("archive"), filepath.Join(t.TempDir(), "a.tar.gz"))
+	assert.False(t, ok)
+
+	require.NoError(t, cache.Store(url, writeTempFile(t, "archive"), digestOf("archive")))
+	// Same content under another URL shares the blob
+	require.NoError(t, cache.Store("https://mirror.example.com/a.tar.gz", writeTempFile(t, "archive"), digestOf("archive")))
+	// Content other than the expected one is not stored
+	err := cache.Store(url, writeTempFile(t, "tampered"), digestOf("archive"))
+	require.Error(t, err)
+
+	dest := filepath.Join(t.TempDir(), "a.tar.gz")
+	size, ok := cache.Fetch(url, digestOf("archive"), dest)
+	require.True(t, ok)
+	assert.Equal(t, int64(len("archive")), size)
+	data, err := os.ReadFile(dest)
+	require.NoError(t, err)
+	assert.Equal(t, "archive", string(data))
+
+	// The URL is only served for the digest it was stored with
+	_, ok = cache.Fetch(url, digestOf("other archive"), filepath.Join(t.TempDir(), "a.tar.gz"))
+	assert.False(t, ok)
+
+	entries, err := cache.List()
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+	total, err := cache.Size()
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("archive")), total)
+}
+
+func TestCache_Fetch_CorruptedBlob(t *testing.T) {
+	t.Parallel()
+	cache := NewCache(t.TempDir())
+	require.NoError(t, cache.Store("https://example.com/a.tar.gz", writeTempFile(t, "archive"), digestOf("archive")))
+	require.NoError(t, os.WriteFile(cache.blobPath(digestOf("archive")), []byte("tampered"), 0644))
+
+	_, ok := cache.Fetch("https://example.com/a.tar.gz", digestOf("archive"), filepath.Join(t.TempDir(), "a.tar.gz"))
+	assert.False(t, ok)
+	entries, err := cache.List()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestCache_Prune(t *testing.T) {
+	t.Parallel()
+	cache := NewCache(t.TempDir())
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	store := func(url, content string, lastUsed time.Time) {
+		cache.now = func() time.Time { return lastUsed }
+		require.NoError(t, cache.Store(url, writeTempFile(t, content), digestOf(content)))
+	}
+	store("https://example.com/old", strings.Repeat("o", 100), now.Add(-60*24*time.Hour))
+	store("https://example.com/mid", strings.Repeat("m", 100), now.Add(-2*time.Hour))
+	store("https://example.com/new", strings.Repeat("n", 100), now.Add(-time.Hour))
+	cache.now = func() time.Time { return now }
+
+	// Age-based eviction
+	evicted, err := cache.Prune(30*24*time.Hour, 0)
+	require.NoError(t, err)
+	require.Len(t, evicted, 1)
+	assert.Equal(t, "https://example.com/old", evicted[0].URL)
+
+	// Size-based eviction removes the least recently used entries
+	evicted, err = cache.Prune(0, 150)
+	require.NoError(t, err)
+	require.Len(t, evicted, 1)
+	assert.Equal(t, "https://example.com/mid", evicted[0].URL)
+
+	entries, err := cache.List()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "https://example.com/new", entries[0].URL)
+	total, err := cache.Size()
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), total)
+
+	require.NoError(t, cache.Clear())
+	entries, err = cache.List()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestDownloader_WithCache(t *testing.T) {
+	t.Parallel()
+	content := "runtime archive"
+	cs := &resource.Checksum{Value: "sha256:" + string(digestOf(content))}
+
+	var requests atomic.Int32
+	client := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		requests.Add(1)
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(content)), ContentLength: int64(len(content)), Request: req}, nil
+	})}
+	cache := NewCache(t.TempDir())
+	d := NewDownloaderWithClient(client, WithCache(cache))
+	url := "https://example.com/go1.25.5.tar.gz"
+
+	// Downloads with no digest known beforehand bypass the cache, even when
+	// verified against a checksum afterwards
+	dest := filepath.Join(t.TempDir(), "go1.25.5.tar.gz")
+	_, err := d.Download(context.Background(), url, dest)
+	require.NoError(t, err)
+	require.NoError(t, d.Verify(context.Background(), dest, cs))
+	entries, err := cache.List()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	// Downloads with the expected digest are cached
+	ctx := WithExpectedDigest(context.Background(), ExpectedDigest("", cs))
+	_, err = d.Download(ctx, url, dest)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), requests.Load())
+	entries, err = cache.List()
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	// A reinstall is served from the cache
+	dest2 := filepath.Join(t.TempDir(), "go1.25.5.tar.gz")
+	var reported int64
+	_, err = d.DownloadWithProgress(ctx, url, dest2, func(downloaded, _ int64) { reported = downloaded })
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), requests.Load())
+	assert.Equal(t, int64(len(content)), reported)
+	require.NoError(t, d.Verify(ctx, dest2, cs))
+}
+
+func TestDownloader_WithCache_Republished(t *testing.T) {
+	t.Parallel()
+	var content atomic.Value
+	content.Store("original archive")
+	var requests atomic.Int32
+	client := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		requests.Add(1)
+		body := content.Load().(string)
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), ContentLength: int64(len(body)), Request: req}, nil
+	})}
+	cache := NewCache(t.TempDir())
+	d := NewDownloaderWithClient(client, WithCache(cache))
+	url := "https://example.com/tool.tar.gz"
+
+	dest := filepath.Join(t.TempDir(), "tool.tar.gz")
+	_, err := d.Download(WithExpectedDigest(context.Background(), digestOf("original archive")), url, dest)
+	require.NoError(t, err)
+
+	// The asset is re-published: the cached copy is stored for another
+	// digest, so the new content is downloaded
+	content.Store("republished archive")
+	republished := WithExpectedDigest(context.Background(), digestOf("republished archive"))
+	dest2 := filepath.Join(t.TempDir(), "tool.tar.gz")
+	_, err = d.Download(republished, url, dest2)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), requests.Load())
+	data, err := os.ReadFile(dest2)
+	require.NoError(t, err)
+	assert.Equal(t, "republished archive", string(data))
+
+	// The cache now holds both contents
+	dest3 := filepath.Join(t.TempDir(), "tool.tar.gz")
+	_, err = d.Download(republished, url, dest3)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), requests.Load())
+
+	// A download that does not match its expected digest is not cached
+	content.Store("tampered archive")
+	tampered := WithExpectedDigest(context.Background(), digestOf("expected archive"))
+	for range 2 {
+		_, err = d.Download(tampered, url, filepath.Join(t.TempDir(), "tool.tar.gz"))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(4), requests.Load())
+}
+
+func TestExpectedDigest(t *testing.T) {
+	t.Parallel()
+	sha256Hex := string(digestOf("archive"))
+	sha512Hex := strings.Repeat("a", 128)
+
+	tests := []struct {
+		name   string
+		pinned checksum.Digest
+		cs     *resource.Checksum
+		want   checksum.Digest
+	}{
+		{name: "nothing known"},
+		{name: "checksum URL", cs: &resource.Checksum{URL: "https://example.com/sums.txt"}},
+		{name: "pinned", pinned: checksum.Digest(sha256Hex), want: checksum.Digest(sha256Hex)},
+		{name: "pin takes precedence", pinned: checksum.Digest(sha256Hex), cs: &resource.Checksum{Value: "sha256:" + strings.Repeat("0", 64)}, want: checksum.Digest(sha256Hex)},
+		{name: "sha256 value", cs: &resource.Checksum{Value: "sha256:" + sha256Hex}, want: checksum.Digest(sha256Hex)},
+		{name: "sha512 value", cs: &resource.Checksum{Value: "sha512:" + sha512Hex}},
+		{name: "sha512 pin falls back to value", pinned: checksum.Digest(sha512Hex), cs: &resource.Checksum{Value: "sha256:" + sha256Hex}, want: checksum.Digest(sha256Hex)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, ExpectedDigest(tt.pinned, tt.cs))
+		})
+	}
+}
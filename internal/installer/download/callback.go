@@ -1,13 +1,18 @@
 package download
 
-import "context"
+import (
+	"context"
+
+	"github.com/terassyi/tomei/internal/checksum"
+	"github.com/terassyi/tomei/internal/resource"
+)
 
 // OutputCallback is called for each line of command output.
 type OutputCallback func(line string)
 
 // Callback is a type constraint for callback functions that can be stored in context.
 type Callback interface {
-	ProgressCallback | OutputCallback
+	ProgressCallback | OutputCallback | RetryCallback
 }
 
 type callbackKey[T Callback] struct{}
@@ -25,3 +30,34 @@ func CallbackFromContext[T Callback](ctx context.Context) T {
 	var zero T
 	return zero
 }
+
+type expectedDigestKey struct{}
+
+// WithExpectedDigest returns a context carrying the sha256 digest the next
+// download is known to have, which lets the download cache serve and store it.
+func WithExpectedDigest(ctx context.Context, digest checksum.Digest) context.Context {
+	return context.WithValue(ctx, expectedDigestKey{}, digest)
+}
+
+// ExpectedDigestFromContext extracts the expected sha256 digest from context, or "".
+func ExpectedDigestFromContext(ctx context.Context) checksum.Digest {
+	if d, ok := ctx.Value(expectedDigestKey{}).(checksum.Digest); ok {
+		return d
+	}
+	return ""
+}
+
+// ExpectedDigest returns the sha256 digest an archive is known to have before
+// it is downloaded: the pinned digest, or else a direct sha256 checksum value.
+// It returns "" when neither is known, e.g. for checksums fetched from a URL.
+func ExpectedDigest(pinned checksum.Digest, cs *resource.Checksum) checksum.Digest {
+	if pinned != "" && checksum.DetectAlgorithm(string(pinned)) == checksum.AlgorithmSHA256 {
+		return pinned
+	}
+	if cs != nil && cs.Value != "" {
+		if alg, digest, err := checksum.Parse(cs.Value); err == nil && alg == checksum.AlgorithmSHA256 {
+			return digest
+		}
+	}
+	return ""
+}
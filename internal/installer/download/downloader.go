@@ -2,6 +2,7 @@ package download
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -10,6 +11,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/terassyi/tomei/internal/checksum"
@@ -73,12 +75,15 @@ var _ Downloader = (*httpDownloader)(nil)
 type httpDownloader struct {
 	client          *http.Client
 	downloadTimeout time.Duration
+	cache           *Cache
+	retry           RetryPolicy
+	partials        sync.Map // partial file path -> *sync.Mutex
 }
 
 // DownloaderOption configures a Downloader.
 type DownloaderOption func(*httpDownloader)
 
-// WithDownloadTimeout sets the per-download timeout applied via context.
+// WithDownloadTimeout sets the timeout of each download attempt, applied via context.
 // If zero, no per-download timeout is applied (transport-level timeouts still apply).
 func WithDownloadTimeout(d time.Duration) DownloaderOption {
 	return func(dl *httpDownloader) {
@@ -86,6 +91,23 @@ func WithDownloadTimeout(d time.Duration) DownloaderOption {
 	}
 }
 
+// WithRetryPolicy sets how failed downloads are retried.
+func WithRetryPolicy(p RetryPolicy) DownloaderOption {
+	return func(dl *httpDownloader) {
+		dl.retry = p
+	}
+}
+
+// WithCache enables the download cache. Only downloads whose sha256 digest is
+// known beforehand (see WithExpectedDigest) use the cache: they are served
+// from it when an entry for the URL and digest is present, and added to it
+// when the downloaded content has the digest. Other downloads bypass it.
+func WithCache(c *Cache) DownloaderOption {
+	return func(dl *httpDownloader) {
+		dl.cache = c
+	}
+}
+
 // NewDownloader creates a new Downloader with a default HTTP client
 // configured with transport-level timeouts to prevent hanging on
 // network failures without limiting large file download time.
@@ -93,6 +115,7 @@ func NewDownloader(opts ...DownloaderOption) Downloader {
 	dl := &httpDownloader{
 		client:          &http.Client{Transport: DefaultTransport()},
 		downloadTimeout: DefaultDownloadTimeout,
+		retry:           DefaultRetryPolicy(),
 	}
 	for _, opt := range opts {
 		opt(dl)
@@ -108,6 +131,7 @@ func NewDownloaderWithClient(client *http.Client, opts ...DownloaderOption) Down
 	dl := &httpDownloader{
 		client:          client,
 		downloadTimeout: DefaultDownloadTimeout,
+		retry:           DefaultRetryPolicy(),
 	}
 	for _, opt := range opts {
 		opt(dl)
@@ -144,92 +168,190 @@ func validateDownloadURL(rawURL string) error {
 }
 
 // DownloadWithProgress downloads a file with optional progress callback.
+//
+// Attempts that fail with a network error, a 5xx status or a rate limit are
+// retried according to the retry policy. The content is written to a
+// .partial file, and a retry resumes it with a Range request when the server
+// supports it. With a cache, the .partial file is kept in the cache so that
+// a later apply resumes a download that ran out of attempts.
 func (d *httpDownloader) DownloadWithProgress(ctx context.Context, url, destPath string, callback ProgressCallback) (string, error) {
 	if err := validateDownloadURL(url); err != nil {
 		return "", err
 	}
 
-	// Apply per-download timeout if configured
+	expected := ExpectedDigestFromContext(ctx)
+	if d.cache != nil && expected != "" {
+		if size, ok := d.cache.Fetch(url, expected, destPath); ok {
+			slog.Debug("using cached download", "url", url, "dest", destPath)
+			if callback != nil {
+				callback(size, size)
+			}
+			return destPath, nil
+		}
+	}
+
+	// Create parent directory if needed
+	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	partial := newPartialFile(destPath + partialSuffix)
+	if d.cache != nil {
+		partial = newPartialFile(d.cache.PartialPath(url))
+	}
+	// Parallel downloads of the same URL share the cached .partial file
+	mu, _ := d.partials.LoadOrStore(partial.path, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	defer mu.(*sync.Mutex).Unlock()
+
+	onRetry := CallbackFromContext[RetryCallback](ctx)
+	for attempt := 1; ; attempt++ {
+		err := d.downloadAttempt(ctx, url, partial, callback)
+		if err == nil {
+			break
+		}
+		wait, finalErr := d.retry.retryWait(attempt, err)
+		if finalErr != nil || ctx.Err() != nil {
+			var re *retryableError
+			if !errors.As(err, &re) {
+				// Permanent failure: the partial content is of no use
+				partial.remove()
+			}
+			if ctx.Err() != nil {
+				return "", err
+			}
+			return "", finalErr
+		}
+
+		var re *retryableError
+		errors.As(err, &re)
+		if onRetry != nil {
+			onRetry(attempt, wait, re.reason)
+		} else {
+			slog.Warn("download failed, retrying", "url", url, "attempt", attempt, "wait", wait.Round(time.Millisecond), "error", err)
+		}
+		slog.Debug("retrying download", "url", url, "attempt", attempt+1, "wait", wait, "error", err)
+		if err := sleepContext(ctx, wait); err != nil {
+			return "", err
+		}
+	}
+
+	// Atomic rename
+	if err := partial.moveTo(destPath); err != nil {
+		return "", err
+	}
+
+	if d.cache != nil && expected != "" {
+		d.cacheVerified(url, destPath, expected)
+	}
+
+	slog.Debug("download completed", "path", destPath)
+	return destPath, nil
+}
+
+// downloadAttempt makes one request for url and appends the response to the
+// partial file. Failures that may succeed on retry are *retryableError.
+func (d *httpDownloader) downloadAttempt(ctx context.Context, url string, partial *partialFile, callback ProgressCallback) error {
+	// Apply per-attempt timeout if configured
 	if d.downloadTimeout > 0 {
 		var cancel context.CancelFunc
 		ctx, cancel = context.WithTimeout(ctx, d.downloadTimeout)
 		defer cancel()
 	}
 
-	slog.Debug("downloading file", "url", url, "dest", destPath)
+	slog.Debug("downloading file", "url", url, "partial", partial.path)
 
 	// Create HTTP request
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	offset := partial.resumeFrom(url)
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		if v := partial.meta.validator(); v != "" {
+			req.Header.Set("If-Range", v)
+		}
+		slog.Debug("resuming download", "url", url, "offset", offset)
 	}
 
 	// Execute request
 	resp, err := d.client.Do(req)
 	if err != nil {
-		return "", &tomeiErrors.Error{
-			Category: tomeiErrors.CategoryNetwork,
-			Code:     tomeiErrors.CodeNetworkFailed,
-			Message:  fmt.Sprintf("failed to download from %s", url),
-			Cause:    err,
+		return &retryableError{
+			err: &tomeiErrors.Error{
+				Category: tomeiErrors.CategoryNetwork,
+				Code:     tomeiErrors.CodeNetworkFailed,
+				Message:  fmt.Sprintf("failed to download from %s", url),
+				Cause:    err,
+			},
+			reason: "connection failed",
 		}
 	}
 	defer resp.Body.Close()
 
 	// Check status code
-	if resp.StatusCode != http.StatusOK {
-		return "", &tomeiErrors.Error{
+	total := resp.ContentLength
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		start, size, ok := parseContentRange(resp.Header.Get("Content-Range"))
+		if !ok || start != offset {
+			partial.remove()
+			return &retryableError{err: fmt.Errorf("unexpected Content-Range %q for a download resumed at byte %d", resp.Header.Get("Content-Range"), offset), reason: "invalid range response"}
+		}
+		total = size
+	case resp.StatusCode == http.StatusOK:
+		// A full response: the server ignored Range or the content changed
+		offset = 0
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		partial.remove()
+		return &retryableError{err: fmt.Errorf("failed to resume download from %s: HTTP %d", url, resp.StatusCode), reason: "range not satisfiable"}
+	default:
+		httpErr := &tomeiErrors.Error{
 			Category: tomeiErrors.CategoryNetwork,
 			Code:     tomeiErrors.CodeHTTPError,
 			Message:  fmt.Sprintf("failed to download: HTTP %d", resp.StatusCode),
 			Details:  map[string]any{"url": url, "status_code": resp.StatusCode},
 		}
+		if wait, ok := retryableStatus(resp, time.Now()); ok {
+			reason := fmt.Sprintf("HTTP %d", resp.StatusCode)
+			if resp.Header.Get("X-RateLimit-Remaining") == "0" || resp.StatusCode == http.StatusTooManyRequests {
+				reason = "rate limited"
+			}
+			return &retryableError{err: httpErr, after: wait, reason: reason}
+		}
+		return httpErr
 	}
 
-	// Create parent directory if needed
-	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
-		return "", fmt.Errorf("failed to create directory: %w", err)
-	}
-
-	// Create destination file
-	tmpPath := destPath + ".tmp"
-	f, err := os.Create(tmpPath)
+	f, err := partial.open(url, resp, offset)
 	if err != nil {
-		return "", fmt.Errorf("failed to create file: %w", err)
+		return err
 	}
-	defer func() {
-		f.Close()
-		os.Remove(tmpPath) // Clean up on error
-	}()
+	defer f.Close()
 
 	// Download with progress
-	total := resp.ContentLength
 	var reader io.Reader = resp.Body
-
 	if callback != nil {
 		reader = &progressReader{
-			reader:   resp.Body,
-			total:    total,
-			callback: callback,
+			reader:     resp.Body,
+			total:      total,
+			downloaded: offset,
+			callback:   callback,
 		}
 	}
 
 	if _, err := io.Copy(f, reader); err != nil {
-		return "", fmt.Errorf("failed to write file: %w", err)
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return &retryableError{err: fmt.Errorf("failed to write file: %w", err), reason: "download timed out"}
+		}
+		return &retryableError{err: fmt.Errorf("failed to write file: %w", err), reason: "connection lost"}
 	}
 
 	// Close file before rename
 	if err := f.Close(); err != nil {
-		return "", fmt.Errorf("failed to close file: %w", err)
+		return fmt.Errorf("failed to close file: %w", err)
 	}
-
-	// Atomic rename
-	if err := os.Rename(tmpPath, destPath); err != nil {
-		return "", fmt.Errorf("failed to rename file: %w", err)
-	}
-
-	slog.Debug("download completed", "path", destPath)
-	return destPath, nil
+	return nil
 }
 
 // progressReader wraps an io.Reader and reports progress.
@@ -252,9 +374,23 @@ func (r *progressReader) Read(p []byte) (int, error) {
 // Verify verifies the checksum of a downloaded file.
 // checksum can be nil (skip verification), have a direct value, or a URL to fetch.
 func (d *httpDownloader) Verify(ctx context.Context, filePath string, cs *resource.Checksum) error {
+	algorithm, expectedHash, err := d.expectedChecksum(ctx, filePath, cs)
+	if err != nil || expectedHash == "" {
+		return err
+	}
+	if err := checksum.Verify(filePath, algorithm, expectedHash); err != nil {
+		return err
+	}
+	slog.Debug("checksum verified", "algorithm", algorithm)
+	return nil
+}
+
+// expectedChecksum returns the algorithm and hash the file must match.
+// An empty hash means there is nothing to verify against.
+func (d *httpDownloader) expectedChecksum(ctx context.Context, filePath string, cs *resource.Checksum) (checksum.Algorithm, checksum.Digest, error) {
 	if cs == nil {
 		slog.Debug("no checksum specified, skipping verification")
-		return nil
+		return "", "", nil
 	}
 
 	slog.Debug("verifying checksum", "file", filePath)
@@ -266,7 +402,7 @@ func (d *httpDownloader) Verify(ctx context.Context, filePath string, cs *resour
 		// Direct value: "sha256:abc123..." or "sha512:abc123..."
 		alg, hash, err := checksum.Parse(cs.Value)
 		if err != nil {
-			return err
+			return "", "", err
 		}
 		algorithm = alg
 		expectedHash = hash
@@ -279,7 +415,7 @@ func (d *httpDownloader) Verify(ctx context.Context, filePath string, cs *resour
 
 		alg, hash, err := d.fetchChecksumFromURL(ctx, cs.URL, filename)
 		if err != nil {
-			return err
+			return "", "", err
 		}
 		algorithm = alg
 		expectedHash = hash
@@ -290,16 +426,19 @@ func (d *httpDownloader) Verify(ctx context.Context, filePath string, cs *resour
 		}
 	} else {
 		slog.Debug("no checksum value or URL specified, skipping verification")
-		return nil
 	}
+	return algorithm, expectedHash, nil
+}
 
-	// Verify
-	if err := checksum.Verify(filePath, algorithm, expectedHash); err != nil {
-		return err
+// cacheVerified adds a network download to the cache when its content has
+// the expected digest. Failures are logged and do not fail the install; a
+// mismatch is reported by the checksum or digest verification that follows.
+func (d *httpDownloader) cacheVerified(url, filePath string, expected checksum.Digest) {
+	if err := d.cache.Store(url, filePath, expected); err != nil {
+		slog.Warn("failed to add download to cache", "url", url, "error", err)
+		return
 	}
-
-	slog.Debug("checksum verified", "algorithm", algorithm)
-	return nil
+	slog.Debug("cached download", "url", url)
 }
 
 // fetchChecksumFromURL fetches a checksums file from URL and extracts the hash for the given filename.
@@ -0,0 +1,149 @@
+package download
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// partialSuffix is the suffix of files holding an incomplete download.
+const partialSuffix = ".partial"
+
+// partialMeta identifies the content of a partial file, so that a download
+// is only resumed for the same URL and, when the server sent a validator,
+// the same version of the file.
+type partialMeta struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+}
+
+// validator returns the If-Range value for resuming the download. Weak
+// ETags cannot be used with If-Range.
+func (m *partialMeta) validator() string {
+	if m.ETag != "" && !strings.HasPrefix(m.ETag, "W/") {
+		return m.ETag
+	}
+	return m.LastModified
+}
+
+// partialFile is an incomplete download and its metadata (<path>.json).
+type partialFile struct {
+	path string
+	meta partialMeta
+}
+
+func newPartialFile(path string) *partialFile {
+	return &partialFile{path: path}
+}
+
+func (p *partialFile) metaPath() string {
+	return p.path + ".json"
+}
+
+// resumeFrom returns the size of the content already downloaded from url,
+// or 0 when the download starts over.
+func (p *partialFile) resumeFrom(url string) int64 {
+	info, err := os.Stat(p.path)
+	if err != nil || info.Size() == 0 {
+		return 0
+	}
+	data, err := os.ReadFile(p.metaPath())
+	if err != nil {
+		p.remove()
+		return 0
+	}
+	var meta partialMeta
+	if err := json.Unmarshal(data, &meta); err != nil || meta.URL != url {
+		p.remove()
+		return 0
+	}
+	p.meta = meta
+	return info.Size()
+}
+
+// open returns the partial file to write the response body to: opened for
+// appending when the download resumes at offset, recreated otherwise.
+func (p *partialFile) open(url string, resp *http.Response, offset int64) (*os.File, error) {
+	if offset > 0 {
+		f, err := os.OpenFile(p.path, os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open partial download: %w", err)
+		}
+		return f, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p.path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+	p.meta = partialMeta{
+		URL:          url,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+	data, err := json.Marshal(&p.meta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal partial download metadata: %w", err)
+	}
+	if err := os.WriteFile(p.metaPath(), data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write partial download metadata: %w", err)
+	}
+	f, err := os.Create(p.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file: %w", err)
+	}
+	return f, nil
+}
+
+// moveTo moves the completed download to destPath.
+func (p *partialFile) moveTo(destPath string) error {
+	defer os.Remove(p.metaPath())
+	if err := os.Rename(p.path, destPath); err != nil {
+		// The cache may be on another file system than destPath
+		if copyErr := copyFile(p.path, destPath); copyErr != nil {
+			return fmt.Errorf("failed to rename file: %w", errors.Join(err, copyErr))
+		}
+		os.Remove(p.path)
+	}
+	return nil
+}
+
+// remove deletes the partial content and its metadata.
+func (p *partialFile) remove() {
+	os.Remove(p.path)
+	os.Remove(p.metaPath())
+}
+
+// parseContentRange parses a "bytes <start>-<end>/<size>" Content-Range
+// header. size is -1 when the server sent "*".
+func parseContentRange(v string) (start, size int64, ok bool) {
+	rest, found := strings.CutPrefix(v, "bytes ")
+	if !found {
+		return 0, 0, false
+	}
+	rng, sizeStr, found := strings.Cut(rest, "/")
+	if !found {
+		return 0, 0, false
+	}
+	startStr, _, found := strings.Cut(rng, "-")
+	if !found {
+		return 0, 0, false
+	}
+	start, err := strconv.ParseInt(startStr, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	if sizeStr == "*" {
+		return start, -1, true
+	}
+	size, err = strconv.ParseInt(sizeStr, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return start, size, true
+}
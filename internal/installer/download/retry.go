@@ -0,0 +1,149 @@
+package download
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy configures how failed downloads are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts per download, including the
+	// first one. Values below 1 disable retries.
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry. It doubles on each
+	// retry up to MaxBackoff, with jitter.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// MaxRateLimitWait is the longest wait for a rate limit reset or a
+	// Retry-After requested by the server. A download asked to wait longer
+	// fails instead.
+	MaxRateLimitWait time.Duration
+}
+
+// DefaultRetries is the default number of retries after a failed download attempt.
+const DefaultRetries = 3
+
+// DefaultRetryPolicy returns the retry policy used by downloaders unless
+// configured with WithRetryPolicy.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:      DefaultRetries + 1,
+		InitialBackoff:   time.Second,
+		MaxBackoff:       30 * time.Second,
+		MaxRateLimitWait: 5 * time.Minute,
+	}
+}
+
+// backoff returns the wait before the retry following the given attempt
+// (1-based): InitialBackoff doubled per attempt, capped at MaxBackoff, with
+// the upper half randomized so parallel downloads do not retry in lockstep.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.InitialBackoff
+	for i := 1; i < attempt && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	half := d / 2
+	return half + rand.N(half+1)
+}
+
+// RetryCallback is called before a failed download is retried, with the
+// wait before the next attempt and the reason of the failure.
+type RetryCallback func(attempt int, wait time.Duration, reason string)
+
+// retryableError marks a failed download attempt that may succeed when retried.
+type retryableError struct {
+	err error
+	// after is the wait requested by the server (Retry-After or a rate limit
+	// reset). Zero means exponential backoff.
+	after time.Duration
+	// reason is a short description of the failure for progress display.
+	reason string
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+func (e *retryableError) Unwrap() error { return e.err }
+
+// retryableStatus reports whether a download that failed with the HTTP
+// status may succeed later, and the wait the server asked for.
+func retryableStatus(resp *http.Response, now time.Time) (time.Duration, bool) {
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return serverWait(resp, now), true
+	case resp.StatusCode == http.StatusForbidden && resp.Header.Get("X-RateLimit-Remaining") == "0":
+		// GitHub reports an exhausted rate limit as 403
+		return serverWait(resp, now), true
+	case resp.StatusCode == http.StatusRequestTimeout:
+		return 0, true
+	case resp.StatusCode >= 500 && resp.StatusCode != http.StatusNotImplemented:
+		return serverWait(resp, now), true
+	}
+	return 0, false
+}
+
+// serverWait returns the wait requested by the Retry-After header (seconds
+// or an HTTP date) or, for an exhausted rate limit, by X-RateLimit-Reset
+// (Unix time). Returns zero when the server did not ask for one.
+func serverWait(resp *http.Response, now time.Time) time.Duration {
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+			return time.Duration(secs) * time.Second
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return max(t.Sub(now), 0)
+		}
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			// A second of margin for clock skew
+			return max(time.Unix(reset, 0).Sub(now)+time.Second, 0)
+		}
+	}
+	return 0
+}
+
+// retryWait returns the wait before retrying after the failed attempt. It
+// returns the error to report instead when the failure is permanent, the
+// attempts are exhausted or the server asked for a wait longer than the
+// policy allows.
+func (p RetryPolicy) retryWait(attempt int, err error) (time.Duration, error) {
+	var re *retryableError
+	if !errors.As(err, &re) {
+		return 0, err
+	}
+	if attempt >= p.MaxAttempts {
+		return 0, re.err
+	}
+	if re.after > 0 {
+		if re.after > p.MaxRateLimitWait {
+			return 0, fmt.Errorf("%w (the server asked to retry in %s; set GITHUB_TOKEN to raise GitHub rate limits)", re.err, re.after.Round(time.Second))
+		}
+		return re.after, nil
+	}
+	return p.backoff(attempt), nil
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
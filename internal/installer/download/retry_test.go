@@ -0,0 +1,316 @@
+package download
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fastRetry retries without noticeable waits.
+var fastRetry = RetryPolicy{
+	MaxAttempts:      3,
+	InitialBackoff:   time.Millisecond,
+	MaxBackoff:       time.Millisecond,
+	MaxRateLimitWait: time.Second,
+}
+
+// failingReader returns its content, then err.
+type failingReader struct {
+	r   io.Reader
+	err error
+}
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	n, err := f.r.Read(p)
+	if err == io.EOF {
+		return n, f.err
+	}
+	return n, err
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	t.Parallel()
+
+	p := RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	for range 20 {
+		d := p.backoff(1)
+		assert.GreaterOrEqual(t, d, 500*time.Millisecond)
+		assert.LessOrEqual(t, d, time.Second)
+
+		d = p.backoff(3)
+		assert.GreaterOrEqual(t, d, 2*time.Second)
+		assert.LessOrEqual(t, d, 4*time.Second)
+
+		d = p.backoff(10)
+		assert.LessOrEqual(t, d, 5*time.Second, "capped at MaxBackoff")
+	}
+}
+
+func TestServerWait(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		status   int
+		header   http.Header
+		want     time.Duration
+		retrying bool
+	}{
+		{name: "503 with Retry-After seconds", status: 503, header: http.Header{"Retry-After": {"7"}}, want: 7 * time.Second, retrying: true},
+		{name: "429 with Retry-After date", status: 429, header: http.Header{"Retry-After": {now.Add(time.Minute).Format(http.TimeFormat)}}, want: time.Minute, retrying: true},
+		{
+			name:   "GitHub rate limit",
+			status: 403,
+			header: http.Header{
+				"X-Ratelimit-Remaining": {"0"},
+				"X-Ratelimit-Reset":     {strconv.FormatInt(now.Add(90*time.Second).Unix(), 10)},
+			},
+			want:     91 * time.Second,
+			retrying: true,
+		},
+		{name: "502 without header", status: 502, header: http.Header{}, retrying: true},
+		{name: "403 forbidden", status: 403, header: http.Header{}},
+		{name: "404 not found", status: 404, header: http.Header{}},
+		{name: "501 not implemented", status: 501, header: http.Header{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			wait, ok := retryableStatus(&http.Response{StatusCode: tt.status, Header: tt.header}, now)
+			assert.Equal(t, tt.retrying, ok)
+			assert.Equal(t, tt.want, wait)
+		})
+	}
+}
+
+func TestDownloader_Download_Retry(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	d := NewDownloaderWithClient(&http.Client{
+		Transport: roundTripFunc(func(_ *http.Request) (*http.Response, error) {
+			switch calls.Add(1) {
+			case 1:
+				return nil, fmt.Errorf("connection reset by peer")
+			case 2:
+				return &http.Response{StatusCode: http.StatusBadGateway, Header: http.Header{}, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+			default:
+				return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(bytes.NewReader([]byte("ok"))), ContentLength: 2}, nil
+			}
+		}),
+	}, WithRetryPolicy(fastRetry))
+
+	var retries []string
+	ctx := WithCallback(context.Background(), RetryCallback(func(attempt int, _ time.Duration, reason string) {
+		retries = append(retries, fmt.Sprintf("%d:%s", attempt, reason))
+	}))
+	destPath := filepath.Join(t.TempDir(), "downloaded")
+	_, err := d.Download(ctx, "https://example.com/test", destPath)
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(destPath)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", string(content))
+	assert.Equal(t, []string{"1:connection failed", "2:HTTP 502"}, retries)
+}
+
+func TestDownloader_Download_RetryExhausted(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	d := NewDownloaderWithClient(&http.Client{
+		Transport: roundTripFunc(func(_ *http.Request) (*http.Response, error) {
+			calls.Add(1)
+			return &http.Response{StatusCode: http.StatusServiceUnavailable, Header: http.Header{}, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+		}),
+	}, WithRetryPolicy(fastRetry))
+
+	_, err := d.Download(context.Background(), "https://example.com/test", filepath.Join(t.TempDir(), "downloaded"))
+	require.ErrorContains(t, err, "HTTP 503")
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestDownloader_Download_RateLimitTooLong(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	d := NewDownloaderWithClient(&http.Client{
+		Transport: roundTripFunc(func(_ *http.Request) (*http.Response, error) {
+			calls.Add(1)
+			return &http.Response{
+				StatusCode: http.StatusForbidden,
+				Header: http.Header{
+					"X-Ratelimit-Remaining": {"0"},
+					"X-Ratelimit-Reset":     {strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)},
+				},
+				Body: io.NopCloser(bytes.NewReader(nil)),
+			}, nil
+		}),
+	}, WithRetryPolicy(fastRetry))
+
+	_, err := d.Download(context.Background(), "https://example.com/test", filepath.Join(t.TempDir(), "downloaded"))
+	require.ErrorContains(t, err, "HTTP 403")
+	assert.ErrorContains(t, err, "the server asked to retry in")
+	assert.Equal(t, int32(1), calls.Load(), "a wait beyond MaxRateLimitWait is not attempted")
+}
+
+func TestDownloader_Download_Resume(t *testing.T) {
+	t.Parallel()
+
+	content := []byte("0123456789abcdefghij")
+	var ranges []string
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		ranges = append(ranges, req.Header.Get("Range")+"|"+req.Header.Get("If-Range"))
+		if len(ranges) == 1 {
+			// The connection drops after 8 bytes
+			return &http.Response{
+				StatusCode:    http.StatusOK,
+				Header:        http.Header{"Etag": {`"v1"`}},
+				Body:          io.NopCloser(&failingReader{r: bytes.NewReader(content[:8]), err: errors.New("unexpected EOF")}),
+				ContentLength: int64(len(content)),
+			}, nil
+		}
+		return &http.Response{
+			StatusCode:    http.StatusPartialContent,
+			Header:        http.Header{"Content-Range": {fmt.Sprintf("bytes 8-%d/%d", len(content)-1, len(content))}},
+			Body:          io.NopCloser(bytes.NewReader(content[8:])),
+			ContentLength: int64(len(content) - 8),
+		}, nil
+	})
+
+	cache := NewCache(t.TempDir())
+	d := NewDownloaderWithClient(&http.Client{Transport: transport}, WithRetryPolicy(fastRetry), WithCache(cache))
+
+	var progress [][2]int64
+	destPath := filepath.Join(t.TempDir(), "downloaded")
+	_, err := d.DownloadWithProgress(context.Background(), "https://example.com/go.tar.gz", destPath, func(downloaded, total int64) {
+		progress = append(progress, [2]int64{downloaded, total})
+	})
+	require.NoError(t, err)
+
+	got, err := os.ReadFile(destPath)
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+	assert.Equal(t, []string{"|", `bytes=8-|"v1"`}, ranges)
+	assert.Equal(t, [2]int64{20, 20}, progress[len(progress)-1])
+
+	_, err = os.Stat(cache.PartialPath("https://example.com/go.tar.gz"))
+	assert.True(t, os.IsNotExist(err), "the partial file is moved to destPath")
+}
+
+func TestDownloader_Download_ResumeAcrossRuns(t *testing.T) {
+	t.Parallel()
+
+	content := []byte("0123456789abcdefghij")
+	url := "https://example.com/go.tar.gz"
+	cache := NewCache(t.TempDir())
+
+	// A previous apply ran out of attempts after 8 bytes
+	partial := newPartialFile(cache.PartialPath(url))
+	f, err := partial.open(url, &http.Response{Header: http.Header{"Last-Modified": {"Mon, 02 Jan 2026 15:04:05 GMT"}}}, 0)
+	require.NoError(t, err)
+	_, err = f.Write(content[:8])
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	tests := []struct {
+		name    string
+		changed bool
+	}{
+		{name: "server resumes"},
+		{name: "content changed", changed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewCache(t.TempDir())
+			p := newPartialFile(cache.PartialPath(url))
+			require.NoError(t, os.MkdirAll(filepath.Dir(p.path), 0755))
+			require.NoError(t, copyFile(partial.path, p.path))
+			require.NoError(t, copyFile(partial.metaPath(), p.metaPath()))
+
+			d := NewDownloaderWithClient(&http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				assert.Equal(t, "bytes=8-", req.Header.Get("Range"))
+				assert.Equal(t, "Mon, 02 Jan 2026 15:04:05 GMT", req.Header.Get("If-Range"))
+				if tt.changed {
+					// If-Range did not match: the whole new content
+					return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(bytes.NewReader([]byte("new content"))), ContentLength: 11}, nil
+				}
+				return &http.Response{
+					StatusCode: http.StatusPartialContent,
+					Header:     http.Header{"Content-Range": {"bytes 8-19/20"}},
+					Body:       io.NopCloser(bytes.NewReader(content[8:])),
+				}, nil
+			})}, WithRetryPolicy(fastRetry), WithCache(cache))
+
+			destPath := filepath.Join(t.TempDir(), "downloaded")
+			_, err := d.Download(context.Background(), url, destPath)
+			require.NoError(t, err)
+			got, err := os.ReadFile(destPath)
+			require.NoError(t, err)
+			if tt.changed {
+				assert.Equal(t, "new content", string(got))
+			} else {
+				assert.Equal(t, content, got)
+			}
+		})
+	}
+}
+
+func TestDownloader_Download_PermanentFailureRemovesPartial(t *testing.T) {
+	t.Parallel()
+
+	url := "https://example.com/go.tar.gz"
+	cache := NewCache(t.TempDir())
+	partial := newPartialFile(cache.PartialPath(url))
+	f, err := partial.open(url, &http.Response{Header: http.Header{}}, 0)
+	require.NoError(t, err)
+	_, err = f.WriteString("stale")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	d := NewDownloaderWithClient(&http.Client{Transport: roundTripFunc(func(_ *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusNotFound, Header: http.Header{}, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+	})}, WithRetryPolicy(fastRetry), WithCache(cache))
+
+	_, err = d.Download(context.Background(), url, filepath.Join(t.TempDir(), "downloaded"))
+	require.ErrorContains(t, err, "HTTP 404")
+	_, err = os.Stat(partial.path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestParseContentRange(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		in    string
+		start int64
+		size  int64
+		ok    bool
+	}{
+		{in: "bytes 100-199/200", start: 100, size: 200, ok: true},
+		{in: "bytes 100-199/*", start: 100, size: -1, ok: true},
+		{in: "bytes */200"},
+		{in: "items 0-1/2"},
+		{in: ""},
+	} {
+		start, size, ok := parseContentRange(tt.in)
+		assert.Equal(t, tt.ok, ok, tt.in)
+		if tt.ok {
+			assert.Equal(t, tt.start, start, tt.in)
+			assert.Equal(t, tt.size, size, tt.in)
+		}
+	}
+}
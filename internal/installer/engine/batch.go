@@ -0,0 +1,169 @@
+package engine
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/terassyi/tomei/internal/graph"
+	"github.com/terassyi/tomei/internal/installer/download"
+	"github.com/terassyi/tomei/internal/installer/executor"
+	"github.com/terassyi/tomei/internal/installer/tool"
+	"github.com/terassyi/tomei/internal/resource"
+)
+
+var _ BatchToolInstaller = (*tool.Installer)(nil)
+
+// BatchToolInstaller is implemented by tool installers that can install the
+// tools of a delegation group with one package manager invocation.
+type BatchToolInstaller interface {
+	// BatchInstall installs the tools with the batchInstall command of their
+	// runtime or installer and returns the names of the tools that passed
+	// their check afterwards.
+	BatchInstall(ctx context.Context, tools []*resource.Tool) map[string]bool
+}
+
+type batchStartedKey struct{}
+
+// toolBatch records the tools of a delegation group handled by a batchInstall command.
+type toolBatch struct {
+	// started holds the tools whose EventStart was emitted before the batch.
+	started map[string]bool
+	// installed holds the tools the batch installed.
+	installed map[string]bool
+}
+
+// nodeContext marks the context of a tool node with what the batch did, so
+// that executeToolNode does not emit EventStart twice and the tool installer
+// only records the state of batch-installed tools.
+func (b *toolBatch) nodeContext(ctx context.Context, name string) context.Context {
+	if b == nil {
+		return ctx
+	}
+	if b.started[name] {
+		ctx = context.WithValue(ctx, batchStartedKey{}, true)
+	}
+	if b.installed[name] {
+		ctx = executor.WithBatchInstalled(ctx)
+	}
+	return ctx
+}
+
+// batchStartedFromContext reports whether EventStart was already emitted for the tool.
+func batchStartedFromContext(ctx context.Context) bool {
+	v, _ := ctx.Value(batchStartedKey{}).(bool)
+	return v
+}
+
+// batchInstallGroup installs the pending tools of a delegation group with one
+// invocation of the batchInstall command of their runtime or installer.
+// Tools without a pending install, and tools with args, are left to the
+// per-node execution. It returns nil when nothing was batched.
+func (e *Engine) batchInstallGroup(ctx context.Context, group []*graph.Node, resourceMap map[string]resource.Resource) *toolBatch {
+	bi, ok := e.toolInstaller.(BatchToolInstaller)
+	if !ok || len(group) < 2 {
+		return nil
+	}
+
+	var pending []*resource.Tool
+	var actions []resource.ActionType
+	for _, node := range group {
+		res, ok := resourceMap[graph.NewNodeID(node.Kind, node.Name).String()]
+		if !ok || node.Kind != resource.KindTool {
+			continue
+		}
+		t := res.(*resource.Tool)
+		if len(t.ToolSpec.Args) > 0 || !hasBatchInstall(t, resourceMap) {
+			continue
+		}
+		actionType, err := e.pendingToolAction(t)
+		if err != nil || actionType == resource.ActionNone || actionType == resource.ActionRemove {
+			continue
+		}
+		if e.checkPlanned(resource.KindTool, t.Name(), actionType) != nil {
+			continue
+		}
+		pending = append(pending, t)
+		actions = append(actions, actionType)
+	}
+	if len(pending) < 2 {
+		return nil
+	}
+
+	batch := &toolBatch{started: make(map[string]bool, len(pending))}
+	for n, t := range pending {
+		e.emitEvent(Event{
+			Type:    EventStart,
+			Kind:    resource.KindTool,
+			Name:    t.Name(),
+			Version: t.ToolSpec.Version,
+			Action:  actions[n],
+			Method:  e.determineInstallMethod(t),
+		})
+		batch.started[t.Name()] = true
+	}
+
+	// The output of the shared invocation belongs to every tool in it
+	ctx = download.WithCallback(ctx, download.OutputCallback(func(line string) {
+		for _, t := range pending {
+			e.emitEvent(Event{
+				Type:    EventOutput,
+				Kind:    resource.KindTool,
+				Name:    t.Name(),
+				Version: t.ToolSpec.Version,
+				Output:  line,
+				Method:  e.determineInstallMethod(t),
+			})
+		}
+	}))
+	batch.installed = bi.BatchInstall(ctx, pending)
+	slog.Debug("batch install completed", "tools", len(pending), "installed", len(batch.installed))
+	return batch
+}
+
+// pendingToolAction returns the action the reconciler computes for the tool
+// against its own state, as executeToolNode does.
+func (e *Engine) pendingToolAction(t *resource.Tool) (resource.ActionType, error) {
+	singleToolState := make(map[string]*resource.ToolState)
+	ts, exists, err := e.toolStore.Load(t.Name())
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		singleToolState[t.Name()] = ts
+	}
+	actions := e.toolReconciler.Reconcile([]*resource.Tool{t}, singleToolState)
+	if len(actions) == 0 {
+		return resource.ActionNone, nil
+	}
+	return actions[0].Type, nil
+}
+
+// hasBatchInstall reports whether the runtime or installer the tool is
+// delegated to defines a batchInstall command.
+func hasBatchInstall(t *resource.Tool, resourceMap map[string]resource.Resource) bool {
+	var cmds *resource.CommandsSpec
+	if ref := t.ToolSpec.RuntimeRef; ref != "" {
+		if rt, ok := resourceMap[graph.NewNodeID(resource.KindRuntime, ref).String()].(*resource.Runtime); ok && rt.RuntimeSpec != nil {
+			cmds = rt.RuntimeSpec.Commands
+		}
+	} else if ref := t.ToolSpec.InstallerRef; ref != "" {
+		if inst, ok := resourceMap[graph.NewNodeID(resource.KindInstaller, ref).String()].(*resource.Installer); ok && inst.InstallerSpec != nil {
+			cmds = inst.InstallerSpec.Commands
+		}
+	}
+	return cmds != nil && len(cmds.BatchInstall) > 0
+}
+
+// withDeclaredBatchInstall returns the delegation commands recorded for a
+// runtime with the batchInstall command of its manifest. batchInstall does
+// not change how the runtime is installed, so adding it to an installed
+// runtime takes effect without reinstalling the runtime.
+func withDeclaredBatchInstall(cmds *resource.CommandsSpec, resourceMap map[string]resource.Resource, name string) *resource.CommandsSpec {
+	rt, ok := resourceMap[graph.NewNodeID(resource.KindRuntime, name).String()].(*resource.Runtime)
+	if cmds == nil || !ok || rt.RuntimeSpec == nil || rt.RuntimeSpec.Commands == nil {
+		return cmds
+	}
+	merged := *cmds
+	merged.BatchInstall = rt.RuntimeSpec.Commands.BatchInstall
+	return &merged
+}
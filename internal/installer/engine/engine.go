@@ -18,7 +18,7 @@ import (
 	"github.com/terassyi/tomei/internal/installer/executor"
 	"github.com/terassyi/tomei/internal/installer/reconciler"
 	"github.com/terassyi/tomei/internal/installer/tool"
-	"github.com/terassyi/tomei/internal/path"
+	"github.com/terassyi/tomei/internal/registry/aqua"
 	"github.com/terassyi/tomei/internal/resource"
 	"github.com/terassyi/tomei/internal/state"
 	"golang.org/x/sync/semaphore"
@@ -30,6 +30,8 @@ type ToolInstaller interface {
 	Remove(ctx context.Context, st *resource.ToolState, name string) error
 	RegisterRuntime(name string, info *tool.RuntimeInfo)
 	RegisterInstaller(name string, info *tool.InstallerInfo)
+	// RegistryRuntime returns the runtime a registry tool is delegated to, if any.
+	RegistryRuntime(ctx context.Context, res *resource.Tool) (string, error)
 	SetToolBinPaths(paths map[string]string)
 	SetProgressCallback(callback download.ProgressCallback)
 	SetOutputCallback(callback download.OutputCallback)
@@ -42,6 +44,14 @@ type RuntimeInstaller interface {
 	SetProgressCallback(callback download.ProgressCallback)
 }
 
+// InstallerInstaller defines the interface for installing installers.
+type InstallerInstaller interface {
+	Install(ctx context.Context, res *resource.Installer, name string) (*resource.InstallerState, error)
+	Remove(ctx context.Context, st *resource.InstallerState, name string) error
+	// IsInstalled runs the bootstrap check recorded in state for drift detection.
+	IsInstalled(ctx context.Context, st *resource.InstallerState, name string) bool
+}
+
 // InstallerRepositoryInstaller defines the interface for installing installer repositories.
 type InstallerRepositoryInstaller interface {
 	Install(ctx context.Context, res *resource.InstallerRepository, name string) (*resource.InstallerRepositoryState, error)
@@ -97,6 +107,10 @@ type Event struct {
 	Output     string // output line (for EventOutput)
 	Method     string // install method: "download", "go install", etc.
 
+	// EventProgress fields for a failed download attempt about to be retried
+	RetryWait   time.Duration // wait before the next attempt
+	RetryReason string        // why the attempt failed (e.g. "HTTP 503", "rate limited")
+
 	// EventLayerStart fields
 	Layer         int        // current layer index (0-based)
 	TotalLayers   int        // total number of layers
@@ -121,23 +135,28 @@ const (
 // Engine orchestrates the apply process.
 type Engine struct {
 	store                   *state.Store[state.UserState]
-	stateCache              *executor.StateCache
+	stateCache              *executor.StateCache[state.UserState]
 	toolStore               executor.StateStore[*resource.ToolState]
 	runtimeStore            executor.StateStore[*resource.RuntimeState]
+	installerStore          executor.StateStore[*resource.InstallerState]
 	installerRepoStore      executor.StateStore[*resource.InstallerRepositoryState]
 	toolInstaller           ToolInstaller
 	runtimeInstaller        RuntimeInstaller
+	installerInstaller      InstallerInstaller
 	installerRepoInstaller  InstallerRepositoryInstaller
 	runtimeReconciler       *reconciler.Reconciler[*resource.Runtime, *resource.RuntimeState]
 	runtimeExecutor         *executor.Executor[*resource.Runtime, *resource.RuntimeState]
 	toolReconciler          *reconciler.Reconciler[*resource.Tool, *resource.ToolState]
 	toolExecutor            *executor.Executor[*resource.Tool, *resource.ToolState]
+	installerReconciler     *reconciler.Reconciler[*resource.Installer, *resource.InstallerState]
+	installerExecutor       *executor.Executor[*resource.Installer, *resource.InstallerState]
 	installerRepoReconciler *reconciler.Reconciler[*resource.InstallerRepository, *resource.InstallerRepositoryState]
 	installerRepoExecutor   *executor.Executor[*resource.InstallerRepository, *resource.InstallerRepositoryState]
 	resolverConfigurer      ResolverConfigurer
 	eventHandler            EventHandler
 	parallelism             int
 	updateCfg               UpdateConfig
+	plannedActions          map[string]resource.ActionType
 }
 
 // UpdateConfig holds update-related flags for apply and plan commands.
@@ -148,32 +167,50 @@ type UpdateConfig struct {
 	UpdateTools bool
 	// UpdateRuntimes taints runtimes with VersionKind=alias or latest (for --update-runtimes).
 	UpdateRuntimes bool
+	// UpdateToolNames and UpdateRuntimeNames taint only the named resources
+	// with VersionKind=latest or alias (for tomei lock update kind/name).
+	UpdateToolNames    []string
+	UpdateRuntimeNames []string
+	// RepairToolNames and RepairRuntimeNames taint the named resources
+	// regardless of VersionKind so that they are reinstalled (for tomei doctor --fix).
+	RepairToolNames    []string
+	RepairRuntimeNames []string
+	// LockedTools and LockedRuntimes map names to the versions pinned in tomei.lock.
+	// Installed resources with non-exact versions that differ from the pin are tainted.
+	LockedTools    map[string]string
+	LockedRuntimes map[string]string
 }
 
 // NewEngine creates a new Engine.
 func NewEngine(
 	toolInstaller ToolInstaller,
 	runtimeInstaller RuntimeInstaller,
+	installerInstaller InstallerInstaller,
 	installerRepoInstaller InstallerRepositoryInstaller,
 	store *state.Store[state.UserState],
 ) *Engine {
 	sc := executor.NewStateCache(store)
 	toolStore := executor.NewToolStore(sc)
 	runtimeStore := executor.NewRuntimeStore(sc)
+	installerStore := executor.NewInstallerStore(sc)
 	repoStore := executor.NewInstallerRepositoryStore(sc)
 	return &Engine{
 		store:                   store,
 		stateCache:              sc,
 		toolStore:               toolStore,
 		runtimeStore:            runtimeStore,
+		installerStore:          installerStore,
 		installerRepoStore:      repoStore,
 		toolInstaller:           toolInstaller,
 		runtimeInstaller:        runtimeInstaller,
+		installerInstaller:      installerInstaller,
 		installerRepoInstaller:  installerRepoInstaller,
 		runtimeReconciler:       reconciler.NewRuntimeReconciler(),
 		runtimeExecutor:         executor.New(resource.KindRuntime, runtimeInstaller, runtimeStore),
 		toolReconciler:          reconciler.NewToolReconciler(),
 		toolExecutor:            executor.New(resource.KindTool, toolInstaller, toolStore),
+		installerReconciler:     reconciler.NewInstallerReconciler(),
+		installerExecutor:       executor.New(resource.KindInstaller, installerInstaller, installerStore),
 		installerRepoReconciler: reconciler.NewInstallerRepositoryReconciler(),
 		installerRepoExecutor:   executor.New(resource.KindInstallerRepository, installerRepoInstaller, repoStore),
 		parallelism:             DefaultParallelism,
@@ -208,6 +245,36 @@ func (e *Engine) SetUpdateConfig(cfg UpdateConfig) {
 	e.updateCfg = cfg
 }
 
+// SetPlannedActions restricts Apply to the actions of a saved plan, keyed by
+// "Kind/name". Apply fails before executing a Runtime, Installer,
+// InstallerRepository or Tool action that differs from the plan, including
+// the reinstall of an installer whose bootstrap check fails. Reinstalls of tools tainted by
+// a planned runtime upgrade follow from the plan and are not checked.
+func (e *Engine) SetPlannedActions(actions map[string]resource.ActionType) {
+	e.plannedActions = actions
+}
+
+// checkPlanned returns an error if a saved plan is set and does not contain
+// the given action. Other kinds (system resources) are not checked.
+func (e *Engine) checkPlanned(kind resource.Kind, name string, actionType resource.ActionType) error {
+	if e.plannedActions == nil {
+		return nil
+	}
+	switch kind {
+	case resource.KindRuntime, resource.KindInstaller, resource.KindInstallerRepository, resource.KindTool:
+	default:
+		return nil
+	}
+	planned, ok := e.plannedActions[graph.NewNodeID(kind, name).String()]
+	if !ok {
+		return fmt.Errorf("%s %s: action %s is not in the saved plan", kind, name, actionType)
+	}
+	if planned != actionType {
+		return fmt.Errorf("%s %s: action %s differs from the saved plan (%s)", kind, name, actionType, planned)
+	}
+	return nil
+}
+
 // emitEvent emits an event to the handler if set.
 func (e *Engine) emitEvent(event Event) {
 	if e.eventHandler != nil {
@@ -221,6 +288,9 @@ type ToolAction = reconciler.Action[*resource.Tool, *resource.ToolState]
 // RuntimeAction is an alias for runtime-specific action type.
 type RuntimeAction = reconciler.Action[*resource.Runtime, *resource.RuntimeState]
 
+// InstallerAction is an alias for installer-specific action type.
+type InstallerAction = reconciler.Action[*resource.Installer, *resource.InstallerState]
+
 // InstallerRepositoryAction is an alias for installer-repository-specific action type.
 type InstallerRepositoryAction = reconciler.Action[*resource.InstallerRepository, *resource.InstallerRepositoryState]
 
@@ -249,13 +319,6 @@ func (e *Engine) Apply(ctx context.Context, resources []resource.Resource) error
 		resolver.AddResource(res)
 	}
 
-	layers, err := resolver.Resolve()
-	if err != nil {
-		return fmt.Errorf("failed to resolve dependencies: %w", err)
-	}
-
-	slog.Debug("dependency resolution completed", "layers", len(layers))
-
 	// Acquire lock for execution
 	if err := e.store.Lock(); err != nil {
 		return fmt.Errorf("failed to acquire lock: %w", err)
@@ -268,11 +331,6 @@ func (e *Engine) Apply(ctx context.Context, resources []resource.Resource) error
 		return fmt.Errorf("failed to load state: %w", err)
 	}
 
-	// Backup state before changes (non-fatal if fails)
-	if err := state.CreateBackup(e.store); err != nil {
-		slog.Warn("failed to create state backup", "error", err)
-	}
-
 	// Configure resolver after state is loaded (while holding lock)
 	if e.resolverConfigurer != nil {
 		if err := e.resolverConfigurer(st); err != nil {
@@ -280,52 +338,48 @@ func (e *Engine) Apply(ctx context.Context, resources []resource.Resource) error
 		}
 	}
 
-	// Apply taint marks based on update flags
-	applyUpdateTaints(st, e.updateCfg)
-
-	// Build resource maps for quick lookup
-	resourceMap := buildResourceMap(resources)
-
-	// Register installers for delegation type and save to state
+	// Register installers for delegation type. Installer state is written
+	// when the installer node is executed in its DAG layer.
 	for _, res := range resources {
 		if inst, ok := res.(*resource.Installer); ok && inst.InstallerSpec != nil {
 			if err := inst.InstallerSpec.Validate(); err != nil {
 				return fmt.Errorf("invalid installer %q: %w", inst.Name(), err)
 			}
 			e.toolInstaller.RegisterInstaller(inst.Name(), &tool.InstallerInfo{
-				Type:     inst.InstallerSpec.Type,
-				ToolRef:  inst.InstallerSpec.ToolRef,
-				Commands: inst.InstallerSpec.Commands,
+				Type:            inst.InstallerSpec.Type,
+				ToolRef:         inst.InstallerSpec.ToolRef,
+				Commands:        inst.InstallerSpec.Commands,
+				CredentialRef:   inst.InstallerSpec.CredentialRef,
+				SignaturePolicy: inst.InstallerSpec.SignaturePolicy,
 			})
-			// Persist installer state (including ToolRef and BinDir) for removal lookup and env
-			if st.Installers == nil {
-				st.Installers = make(map[string]*resource.InstallerState)
-			}
-			var expandedBinDir string
-			if inst.InstallerSpec.BinDir != "" {
-				var err error
-				expandedBinDir, err = path.Expand(inst.InstallerSpec.BinDir)
-				if err != nil {
-					return fmt.Errorf("failed to expand binDir for installer %q: %w", inst.Name(), err)
-				}
-			}
-			// Preserve existing state fields (e.g., Version) that are not set here
-			existing := st.Installers[inst.Name()]
-			newState := &resource.InstallerState{
-				ToolRef:   inst.InstallerSpec.ToolRef,
-				BinDir:    expandedBinDir,
-				UpdatedAt: time.Now(),
-			}
-			if existing != nil {
-				newState.Version = existing.Version
-			}
-			st.Installers[inst.Name()] = newState
 		}
 	}
-	if err := e.store.Save(st); err != nil {
-		return fmt.Errorf("failed to save installer state: %w", err)
+
+	// Registry packages built from source (go_install, cargo) depend on the
+	// runtime that builds them. This needs the resolver configured above.
+	e.addRegistryRuntimeDependencies(ctx, resolver, resources)
+
+	layers, err := resolver.Resolve()
+	if err != nil {
+		return fmt.Errorf("failed to resolve dependencies: %w", err)
 	}
 
+	slog.Debug("dependency resolution completed", "layers", len(layers))
+
+	// Backup state before changes (non-fatal if fails)
+	if err := state.CreateBackup(e.store); err != nil {
+		slog.Warn("failed to create state backup", "error", err)
+	}
+	// Record the pre-apply state as a generation if it is not in history yet
+	// (e.g., first apply with history, or an earlier apply that failed midway).
+	e.recordGeneration()
+
+	// Apply taint marks based on update flags
+	applyUpdateTaints(st, e.updateCfg)
+
+	// Build resource maps for quick lookup
+	resourceMap := buildResourceMap(resources)
+
 	// Initialize the in-memory state cache for batch writes
 	e.stateCache.Init(st)
 
@@ -380,7 +434,7 @@ func (e *Engine) Apply(ctx context.Context, resources []resource.Resource) error
 				BinDir:      runtimeState.BinDir,
 				ToolBinPath: runtimeState.ToolBinPath,
 				Env:         runtimeState.Env,
-				Commands:    runtimeState.Commands,
+				Commands:    withDeclaredBatchInstall(runtimeState.Commands, resourceMap, name),
 			})
 		}
 	}
@@ -408,10 +462,25 @@ func (e *Engine) Apply(ctx context.Context, resources []resource.Resource) error
 		return fmt.Errorf("failed to flush final state: %w", err)
 	}
 
+	e.recordGeneration()
+
 	slog.Debug("apply completed", "total_actions", totalActions)
 	return nil
 }
 
+// recordGeneration snapshots the state file into the history directory
+// (non-fatal if fails). Unchanged state is not recorded twice.
+func (e *Engine) recordGeneration() {
+	gen, err := state.RecordGeneration(e.store, state.HistoryLimit)
+	if err != nil {
+		slog.Warn("failed to record state generation", "error", err)
+		return
+	}
+	if gen != nil {
+		slog.Debug("recorded state generation", "generation", gen.Number)
+	}
+}
+
 // executeLayer executes all nodes in a layer.
 // Nodes are split by kind into three phases:
 //
@@ -567,6 +636,17 @@ func (e *Engine) buildNodeContext(ctx context.Context, node *graph.Node, resourc
 				Method:     method,
 			})
 		}))
+		ctx = download.WithCallback(ctx, download.RetryCallback(func(_ int, wait time.Duration, reason string) {
+			e.emitEvent(Event{
+				Type:        EventProgress,
+				Kind:        resource.KindTool,
+				Name:        node.Name,
+				Version:     t.ToolSpec.Version,
+				Method:      method,
+				RetryWait:   wait,
+				RetryReason: reason,
+			})
+		}))
 		ctx = download.WithCallback(ctx, download.OutputCallback(func(line string) {
 			e.emitEvent(Event{
 				Type:    EventOutput,
@@ -577,6 +657,16 @@ func (e *Engine) buildNodeContext(ctx context.Context, node *graph.Node, resourc
 				Method:  method,
 			})
 		}))
+	case resource.KindInstaller:
+		ctx = download.WithCallback(ctx, download.OutputCallback(func(line string) {
+			e.emitEvent(Event{
+				Type:   EventOutput,
+				Kind:   resource.KindInstaller,
+				Name:   node.Name,
+				Output: line,
+				Method: installerMethod,
+			})
+		}))
 	case resource.KindRuntime:
 		rt := res.(*resource.Runtime)
 		ctx = download.WithCallback(ctx, download.ProgressCallback(func(downloaded, total int64) {
@@ -589,6 +679,16 @@ func (e *Engine) buildNodeContext(ctx context.Context, node *graph.Node, resourc
 				Total:      total,
 			})
 		}))
+		ctx = download.WithCallback(ctx, download.RetryCallback(func(_ int, wait time.Duration, reason string) {
+			e.emitEvent(Event{
+				Type:        EventProgress,
+				Kind:        resource.KindRuntime,
+				Name:        node.Name,
+				Version:     rt.RuntimeSpec.Version,
+				RetryWait:   wait,
+				RetryReason: reason,
+			})
+		}))
 		ctx = download.WithCallback(ctx, download.OutputCallback(func(line string) {
 			e.emitEvent(Event{
 				Type:    EventOutput,
@@ -621,8 +721,7 @@ func (e *Engine) executeNode(
 	case resource.KindRuntime:
 		return e.executeRuntimeNode(ctx, res.(*resource.Runtime), updatedRuntimes, totalActions)
 	case resource.KindInstaller:
-		// Installers don't need execution - they're just registered
-		return nil
+		return e.executeInstallerNode(ctx, res.(*resource.Installer), totalActions)
 	case resource.KindInstallerRepository:
 		return e.executeInstallerRepositoryNode(ctx, res.(*resource.InstallerRepository), totalActions)
 	case resource.KindTool:
@@ -666,6 +765,9 @@ func (e *Engine) executeRuntimeNode(
 	if action.Type == resource.ActionNone {
 		return nil
 	}
+	if err := e.checkPlanned(resource.KindRuntime, action.Name, action.Type); err != nil {
+		return err
+	}
 
 	// Emit start event
 	e.emitEvent(Event{
@@ -722,6 +824,89 @@ func (e *Engine) executeRuntimeNode(
 	return nil
 }
 
+// installerMethod is the install method reported in events for Installer resources.
+const installerMethod = "bootstrap"
+
+// executeInstallerNode executes an installer action.
+// Installers that are up to date are checked for drift with their bootstrap
+// check command and reinstalled when the check fails.
+func (e *Engine) executeInstallerNode(
+	ctx context.Context,
+	inst *resource.Installer,
+	totalActions *int,
+) error {
+	if e.installerExecutor == nil {
+		return fmt.Errorf("installer executor not configured")
+	}
+
+	// Build a single-installer state map to avoid removing other installers.
+	// Use installerStore.Load() for mutex-safe access during parallel execution.
+	singleInstallerState := make(map[string]*resource.InstallerState)
+	is, exists, err := e.installerStore.Load(inst.Name())
+	if err != nil {
+		return fmt.Errorf("failed to load installer state for %s: %w", inst.Name(), err)
+	}
+	if exists {
+		singleInstallerState[inst.Name()] = is
+	}
+
+	// Reconcile single installer against its own state only
+	actions := e.installerReconciler.Reconcile([]*resource.Installer{inst}, singleInstallerState)
+	var action InstallerAction
+	if len(actions) > 0 {
+		action = actions[0]
+	}
+	if action.Type == "" || action.Type == resource.ActionNone {
+		if !exists || e.installerInstaller.IsInstalled(ctx, is, inst.Name()) {
+			return nil
+		}
+		slog.Warn("installer bootstrap check failed, reinstalling", "name", inst.Name())
+		action = InstallerAction{
+			Type:     resource.ActionReinstall,
+			Name:     inst.Name(),
+			Resource: inst,
+			State:    is,
+			Reason:   "drift: bootstrap check failed",
+		}
+	}
+	if err := e.checkPlanned(resource.KindInstaller, action.Name, action.Type); err != nil {
+		return err
+	}
+
+	// Emit start event
+	e.emitEvent(Event{
+		Type:   EventStart,
+		Kind:   resource.KindInstaller,
+		Name:   action.Name,
+		Action: action.Type,
+		Method: installerMethod,
+	})
+
+	if err := e.installerExecutor.Execute(ctx, action); err != nil {
+		e.emitEvent(Event{
+			Type:   EventError,
+			Kind:   resource.KindInstaller,
+			Name:   action.Name,
+			Action: action.Type,
+			Error:  err,
+			Method: installerMethod,
+		})
+		return fmt.Errorf("failed to execute action %s for installer %s: %w", action.Type, action.Name, err)
+	}
+
+	// Emit complete event
+	e.emitEvent(Event{
+		Type:   EventComplete,
+		Kind:   resource.KindInstaller,
+		Name:   action.Name,
+		Action: action.Type,
+		Method: installerMethod,
+	})
+
+	*totalActions++
+	return nil
+}
+
 // updateToolBinPaths builds and sets the mapping from installer name to tool bin directory.
 // This ensures delegation commands can find toolRef binaries in PATH.
 // It first checks resources (for install/apply), then falls back to state (for removals
@@ -744,6 +929,10 @@ func (e *Engine) updateToolBinPaths(resourceMap map[string]resource.Resource, st
 			continue
 		}
 		if instState.ToolRef == "" {
+			// Bootstrapped installers place their own binaries in BinDir
+			if instState.Bootstrap != nil && instState.BinDir != "" {
+				toolBinPaths[name] = instState.BinDir
+			}
 			continue
 		}
 		if ts, exists := st.Tools[instState.ToolRef]; exists && ts.BinPath != "" {
@@ -785,6 +974,9 @@ func (e *Engine) executeInstallerRepositoryNode(
 	if action.Type == resource.ActionNone {
 		return nil
 	}
+	if err := e.checkPlanned(resource.KindInstallerRepository, action.Name, action.Type); err != nil {
+		return err
+	}
 
 	// Emit start event
 	e.emitEvent(Event{
@@ -845,19 +1037,24 @@ func (e *Engine) executeToolNode(
 	if action.Type == resource.ActionNone {
 		return nil
 	}
+	if err := e.checkPlanned(resource.KindTool, action.Name, action.Type); err != nil {
+		return err
+	}
 
 	// Determine install method
 	method := e.determineInstallMethod(t)
 
-	// Emit start event
-	e.emitEvent(Event{
-		Type:    EventStart,
-		Kind:    resource.KindTool,
-		Name:    action.Name,
-		Version: t.ToolSpec.Version,
-		Action:  action.Type,
-		Method:  method,
-	})
+	// Emit start event (batch-installed tools were started before the batch)
+	if !batchStartedFromContext(ctx) {
+		e.emitEvent(Event{
+			Type:    EventStart,
+			Kind:    resource.KindTool,
+			Name:    action.Name,
+			Version: t.ToolSpec.Version,
+			Action:  action.Type,
+			Method:  method,
+		})
+	}
 
 	if err := e.toolExecutor.Execute(ctx, action); err != nil {
 		e.emitEvent(Event{
@@ -972,6 +1169,8 @@ func partitionToolsByDelegation(
 // groups serialized. Download-pattern tools run fully in parallel. Tools sharing
 // the same delegation key (e.g., same RuntimeRef) run sequentially within the group
 // but different groups run in parallel, all under the global semaphore.
+// When the group's runtime or installer defines batchInstall, its pending
+// tools are first installed by one invocation (see batchInstallGroup).
 func (e *Engine) executeToolNodesWithDelegationSerialization(
 	ctx context.Context,
 	nodes []*graph.Node,
@@ -991,8 +1190,9 @@ func (e *Engine) executeToolNodesWithDelegationSerialization(
 	// invocation may leave shared state (GOPATH, module cache) in a broken state,
 	// making subsequent installs in the same group unreliable.
 	if len(downloadNodes) == 0 && len(delegationGroups) == 1 {
+		batch := e.batchInstallGroup(ctx, delegationGroups[0], resourceMap)
 		for _, node := range delegationGroups[0] {
-			nodeCtx := e.buildNodeContext(ctx, node, resourceMap)
+			nodeCtx := e.buildNodeContext(batch.nodeContext(ctx, node.Name), node, resourceMap)
 			if err := e.executeNode(nodeCtx, node, resourceMap, updatedRuntimes, totalActions); err != nil {
 				return err
 			}
@@ -1053,6 +1253,17 @@ func (e *Engine) executeToolNodesWithDelegationSerialization(
 		}
 
 		wg.Go(func() {
+			// Install the group's pending tools with one package manager
+			// invocation where the runtime or installer supports it
+			if err := sem.Acquire(ctx, 1); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+				return
+			}
+			batch := e.batchInstallGroup(ctx, group, resourceMap)
+			sem.Release(1)
+
 			for i, node := range group {
 				// Acquire semaphore per tool to maintain fair scheduling with download tools
 				if err := sem.Acquire(ctx, 1); err != nil {
@@ -1065,7 +1276,7 @@ func (e *Engine) executeToolNodesWithDelegationSerialization(
 				localUpdated := make(map[string]bool)
 				var localActions int
 
-				nodeCtx := e.buildNodeContext(ctx, node, resourceMap)
+				nodeCtx := e.buildNodeContext(batch.nodeContext(ctx, node.Name), node, resourceMap)
 				err := e.executeNode(nodeCtx, node, resourceMap, localUpdated, &localActions)
 
 				sem.Release(1)
@@ -1194,6 +1405,39 @@ func (e *Engine) handleTaintedTools(
 	return nil
 }
 
+// addRegistryRuntimeDependencies adds an edge from each registry tool that is
+// built from source (aqua go_install, cargo) to the runtime that builds it, so
+// that the runtime is installed first. Only runtimes declared in the manifests
+// are considered; lookup failures are left to the tool install to report.
+func (e *Engine) addRegistryRuntimeDependencies(ctx context.Context, resolver graph.Resolver, resources []resource.Resource) {
+	runtimes := make(map[string]bool)
+	for _, res := range resources {
+		if res.Kind() == resource.KindRuntime {
+			runtimes[res.Name()] = true
+		}
+	}
+	if !runtimes[aqua.GoRuntime] && !runtimes[aqua.RustRuntime] {
+		return
+	}
+
+	for _, res := range resources {
+		t, ok := res.(*resource.Tool)
+		if !ok {
+			continue
+		}
+		runtimeName, err := e.toolInstaller.RegistryRuntime(ctx, t)
+		if err != nil {
+			slog.Debug("failed to look up registry package type", "tool", t.Name(), "error", err)
+			continue
+		}
+		if runtimeName == "" || !runtimes[runtimeName] {
+			continue
+		}
+		slog.Debug("registry tool delegated to runtime", "tool", t.Name(), "runtime", runtimeName)
+		resolver.AddDependency(t, resource.Ref{Kind: resource.KindRuntime, Name: runtimeName})
+	}
+}
+
 // buildResourceMap creates a map of resources by their node ID.
 func buildResourceMap(resources []resource.Resource) map[string]resource.Resource {
 	m := make(map[string]resource.Resource)
@@ -1205,7 +1449,7 @@ func buildResourceMap(resources []resource.Resource) map[string]resource.Resourc
 }
 
 // handleRemovals processes resources that are in state but not in the config.
-// Removal order: Tools first, then InstallerRepositories, then Runtimes.
+// Removal order: Tools first, then InstallerRepositories, then Installers, then Runtimes.
 func (e *Engine) handleRemovals(ctx context.Context, resources []resource.Resource, totalActions *int) error {
 	// Use snapshot for current state (may include unflushed changes)
 	st := e.stateCache.Snapshot()
@@ -1214,11 +1458,26 @@ func (e *Engine) handleRemovals(ctx context.Context, resources []resource.Resour
 	tools := extractByKind[*resource.Tool](resources)
 	runtimes := extractByKind[*resource.Runtime](resources)
 	repos := extractByKind[*resource.InstallerRepository](resources)
+	installers := extractByKind[*resource.Installer](resources)
 
 	toolActions := e.toolReconciler.Reconcile(tools, st.Tools)
 	repoActions := e.installerRepoReconciler.Reconcile(repos, st.InstallerRepositories)
+	installerActions := e.installerReconciler.Reconcile(installers, st.Installers)
 	runtimeActions := e.runtimeReconciler.Reconcile(runtimes, st.Runtimes)
 
+	// Validate no remaining tools or repositories depend on installers being removed
+	var installerRemovals []string
+	for _, action := range installerActions {
+		if action.Type == resource.ActionRemove {
+			installerRemovals = append(installerRemovals, action.Name)
+		}
+	}
+	if len(installerRemovals) > 0 {
+		if err := checkInstallerRemovalDependencies(installerRemovals, tools, repos); err != nil {
+			return err
+		}
+	}
+
 	// Validate no remaining tools depend on runtimes being removed
 	var runtimeRemovals []string
 	for _, action := range runtimeActions {
@@ -1236,6 +1495,7 @@ func (e *Engine) handleRemovals(ctx context.Context, resources []resource.Resour
 	var layerNodes []string
 	layerNodes = collectRemovalNodes(layerNodes, resource.KindTool, toolActions)
 	layerNodes = collectRemovalNodes(layerNodes, resource.KindInstallerRepository, repoActions)
+	layerNodes = collectRemovalNodes(layerNodes, resource.KindInstaller, installerActions)
 	layerNodes = collectRemovalNodes(layerNodes, resource.KindRuntime, runtimeActions)
 
 	if len(layerNodes) == 0 {
@@ -1249,7 +1509,7 @@ func (e *Engine) handleRemovals(ctx context.Context, resources []resource.Resour
 		LayerNodes: layerNodes,
 	})
 
-	// Execute remove actions: tools first, then repos, then runtimes
+	// Execute remove actions: tools first, then repos, then installers, then runtimes
 	if err := executeRemovals(ctx, e, resource.KindTool, toolActions, e.toolExecutor, totalActions); err != nil {
 		return err
 	}
@@ -1261,6 +1521,10 @@ func (e *Engine) handleRemovals(ctx context.Context, resources []resource.Resour
 		return err
 	}
 
+	if err := executeRemovals(ctx, e, resource.KindInstaller, installerActions, e.installerExecutor, totalActions); err != nil {
+		return err
+	}
+
 	return executeRemovals(ctx, e, resource.KindRuntime, runtimeActions, e.runtimeExecutor, totalActions)
 }
 
@@ -1291,6 +1555,9 @@ func executeRemovals[R resource.Resource, S resource.State](
 		if action.Type != resource.ActionRemove {
 			continue
 		}
+		if err := e.checkPlanned(kind, action.Name, action.Type); err != nil {
+			return err
+		}
 		e.emitEvent(Event{
 			Type:   EventStart,
 			Phase:  PhaseRemove,
@@ -1382,25 +1649,39 @@ func (e *Engine) PlanAll(ctx context.Context, resources []resource.Resource) ([]
 // taintDependentTools marks tools that depend on the updated runtimes for reinstallation.
 // Tainted state is written to the cache via toolStore.Save() and flushed later.
 func (e *Engine) taintDependentTools(st *state.UserState, updatedRuntimes map[string]bool) {
-	taintedCount := 0
-	for name, toolState := range st.Tools {
+	// Only taint if the runtime has TaintOnUpgrade enabled
+	tainted := RuntimeTaints(st.Tools, updatedRuntimes, func(name string) bool {
+		rs, ok := st.Runtimes[name]
+		return ok && rs.TaintOnUpgrade
+	})
+	for name, runtimeName := range tainted {
+		toolState := st.Tools[name]
+		toolState.Taint(resource.TaintReasonRuntimeUpgraded)
+		_ = e.toolStore.Save(name, toolState)
+		slog.Debug("tainted tool due to runtime upgrade", "tool", name, "runtime", runtimeName)
+	}
+
+	if len(tainted) > 0 {
+		slog.Debug("tainted tools for reinstallation", "count", len(tainted))
+	}
+}
+
+// RuntimeTaints returns the installed tools that Apply reinstalls after the
+// updated runtimes, mapped to the runtime they depend on. taintOnUpgrade
+// reports whether a runtime has TaintOnUpgrade enabled.
+// Exported for use by plan command.
+func RuntimeTaints(tools map[string]*resource.ToolState, updatedRuntimes map[string]bool, taintOnUpgrade func(runtime string) bool) map[string]string {
+	tainted := make(map[string]string)
+	for name, toolState := range tools {
 		if toolState.RuntimeRef == "" || !updatedRuntimes[toolState.RuntimeRef] {
 			continue
 		}
-		// Only taint if the runtime has TaintOnUpgrade enabled
-		rs, ok := st.Runtimes[toolState.RuntimeRef]
-		if !ok || !rs.TaintOnUpgrade {
+		if !taintOnUpgrade(toolState.RuntimeRef) {
 			continue
 		}
-		toolState.Taint(resource.TaintReasonRuntimeUpgraded)
-		_ = e.toolStore.Save(name, toolState)
-		taintedCount++
-		slog.Debug("tainted tool due to runtime upgrade", "tool", name, "runtime", toolState.RuntimeRef)
-	}
-
-	if taintedCount > 0 {
-		slog.Debug("tainted tools for reinstallation", "count", taintedCount)
+		tainted[name] = toolState.RuntimeRef
 	}
+	return tainted
 }
 
 // ApplyUpdateTaints applies taint marks to state based on update flags.
@@ -1430,6 +1711,42 @@ func applyUpdateTaints(st *state.UserState, cfg UpdateConfig) {
 			return isNonExact(s.VersionKind)
 		}, resource.TaintReasonUpdateRequested, "runtime")
 	}
+	for _, name := range cfg.UpdateToolNames {
+		if s, ok := st.Tools[name]; ok && isNonExact(s.VersionKind) {
+			s.Taint(resource.TaintReasonUpdateRequested)
+			slog.Debug("tainted tool for update", "tool", name)
+		}
+	}
+	for _, name := range cfg.UpdateRuntimeNames {
+		if s, ok := st.Runtimes[name]; ok && isNonExact(s.VersionKind) {
+			s.Taint(resource.TaintReasonUpdateRequested)
+			slog.Debug("tainted runtime for update", "runtime", name)
+		}
+	}
+	for _, name := range cfg.RepairToolNames {
+		if s, ok := st.Tools[name]; ok {
+			s.Taint(resource.TaintReasonRepair)
+			slog.Debug("tainted tool for repair", "tool", name)
+		}
+	}
+	for _, name := range cfg.RepairRuntimeNames {
+		if s, ok := st.Runtimes[name]; ok {
+			s.Taint(resource.TaintReasonRepair)
+			slog.Debug("tainted runtime for repair", "runtime", name)
+		}
+	}
+	for name, locked := range cfg.LockedTools {
+		if s, ok := st.Tools[name]; ok && isNonExact(s.VersionKind) && !s.IsTainted() && s.Version != locked {
+			s.Taint(resource.TaintReasonLockChanged)
+			slog.Debug("tainted tool for lockfile version", "tool", name, "installed", s.Version, "locked", locked)
+		}
+	}
+	for name, locked := range cfg.LockedRuntimes {
+		if s, ok := st.Runtimes[name]; ok && isNonExact(s.VersionKind) && !s.IsTainted() && s.Version != locked {
+			s.Taint(resource.TaintReasonLockChanged)
+			slog.Debug("tainted runtime for lockfile version", "runtime", name, "installed", s.Version, "locked", locked)
+		}
+	}
 }
 
 // taintable is the constraint for state types that support taint marking.
@@ -1483,6 +1800,32 @@ func checkRemovalDependencies(runtimeRemovals []string, remainingTools []*resour
 	return nil
 }
 
+// checkInstallerRemovalDependencies validates that no remaining tools or
+// installer repositories reference installers being removed.
+func checkInstallerRemovalDependencies(installerRemovals []string, remainingTools []*resource.Tool, remainingRepos []*resource.InstallerRepository) error {
+	removingInstallers := make(map[string]bool, len(installerRemovals))
+	for _, name := range installerRemovals {
+		removingInstallers[name] = true
+	}
+
+	var blocked []string
+	for _, t := range remainingTools {
+		if ref := t.ToolSpec.InstallerRef; ref != "" && removingInstallers[ref] {
+			blocked = append(blocked, fmt.Sprintf("tool %q depends on installer %q", t.Name(), ref))
+		}
+	}
+	for _, r := range remainingRepos {
+		if ref := r.InstallerRepositorySpec.InstallerRef; removingInstallers[ref] {
+			blocked = append(blocked, fmt.Sprintf("installer repository %q depends on installer %q", r.Name(), ref))
+		}
+	}
+
+	if len(blocked) > 0 {
+		return fmt.Errorf("cannot remove installer: dependent resources still in spec:\n  %s", strings.Join(blocked, "\n  "))
+	}
+	return nil
+}
+
 // AppendBuiltinInstallers adds builtin installer resources (download, aqua)
 // to the resource list if they are not already present. This ensures that
 // DAG dependency nodes like "Installer/aqua" have a real resource backing them.
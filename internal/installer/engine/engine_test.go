@@ -16,6 +16,7 @@ import (
 	"github.com/terassyi/tomei/internal/config"
 	"github.com/terassyi/tomei/internal/graph"
 	"github.com/terassyi/tomei/internal/installer/download"
+	"github.com/terassyi/tomei/internal/installer/executor"
 	"github.com/terassyi/tomei/internal/installer/tool"
 	"github.com/terassyi/tomei/internal/resource"
 	"github.com/terassyi/tomei/internal/state"
@@ -26,6 +27,8 @@ import (
 type mockToolInstaller struct {
 	installFunc func(ctx context.Context, res *resource.Tool, name string) (*resource.ToolState, error)
 	removeFunc  func(ctx context.Context, st *resource.ToolState, name string) error
+	// registryRuntimes maps tool names to the runtime their registry package is delegated to.
+	registryRuntimes map[string]string
 }
 
 func (m *mockToolInstaller) Install(ctx context.Context, res *resource.Tool, name string) (*resource.ToolState, error) {
@@ -51,6 +54,10 @@ func (m *mockToolInstaller) RegisterRuntime(_ string, _ *tool.RuntimeInfo) {}
 
 func (m *mockToolInstaller) RegisterInstaller(_ string, _ *tool.InstallerInfo) {}
 
+func (m *mockToolInstaller) RegistryRuntime(_ context.Context, res *resource.Tool) (string, error) {
+	return m.registryRuntimes[res.Name()], nil
+}
+
 func (m *mockToolInstaller) SetToolBinPaths(_ map[string]string) {}
 
 func (m *mockToolInstaller) SetProgressCallback(_ download.ProgressCallback) {}
@@ -87,6 +94,38 @@ func (m *mockRuntimeInstaller) Remove(ctx context.Context, st *resource.RuntimeS
 
 func (m *mockRuntimeInstaller) SetProgressCallback(_ download.ProgressCallback) {}
 
+// mockInstallerInstaller is a mock implementation for testing.
+type mockInstallerInstaller struct {
+	installFunc     func(ctx context.Context, res *resource.Installer, name string) (*resource.InstallerState, error)
+	removeFunc      func(ctx context.Context, st *resource.InstallerState, name string) error
+	isInstalledFunc func(ctx context.Context, st *resource.InstallerState, name string) bool
+}
+
+func (m *mockInstallerInstaller) Install(ctx context.Context, res *resource.Installer, name string) (*resource.InstallerState, error) {
+	if m.installFunc != nil {
+		return m.installFunc(ctx, res, name)
+	}
+	return &resource.InstallerState{
+		ToolRef:   res.InstallerSpec.ToolRef,
+		BinDir:    res.InstallerSpec.BinDir,
+		Bootstrap: res.InstallerSpec.Bootstrap,
+	}, nil
+}
+
+func (m *mockInstallerInstaller) Remove(ctx context.Context, st *resource.InstallerState, name string) error {
+	if m.removeFunc != nil {
+		return m.removeFunc(ctx, st, name)
+	}
+	return nil
+}
+
+func (m *mockInstallerInstaller) IsInstalled(ctx context.Context, st *resource.InstallerState, name string) bool {
+	if m.isInstalledFunc != nil {
+		return m.isInstalledFunc(ctx, st, name)
+	}
+	return true
+}
+
 // mockInstallerRepositoryInstaller is a mock implementation for testing.
 type mockInstallerRepositoryInstaller struct {
 	installFunc func(ctx context.Context, res *resource.InstallerRepository, name string) (*resource.InstallerRepositoryState, error)
@@ -121,7 +160,7 @@ func TestNewEngine(t *testing.T) {
 
 	toolMock := &mockToolInstaller{}
 	runtimeMock := &mockRuntimeInstaller{}
-	engine := NewEngine(toolMock, runtimeMock, &mockInstallerRepositoryInstaller{}, store)
+	engine := NewEngine(toolMock, runtimeMock, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 
 	assert.NotNil(t, engine)
 }
@@ -178,7 +217,7 @@ tool: {
 	}
 	runtimeMock := &mockRuntimeInstaller{}
 
-	engine := NewEngine(toolMock, runtimeMock, &mockInstallerRepositoryInstaller{}, store)
+	engine := NewEngine(toolMock, runtimeMock, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 
 	// Run Apply
 	err = engine.Apply(context.Background(), resources)
@@ -197,6 +236,126 @@ tool: {
 	assert.Equal(t, "1.0.0", st.Tools["test-tool"].Version)
 }
 
+func TestEngine_Apply_PlannedActions(t *testing.T) {
+	t.Parallel()
+
+	newTool := func(name string) *resource.Tool {
+		return &resource.Tool{
+			BaseResource: resource.BaseResource{
+				APIVersion:   resource.GroupVersion,
+				ResourceKind: resource.KindTool,
+				Metadata:     resource.Metadata{Name: name},
+			},
+			ToolSpec: &resource.ToolSpec{
+				InstallerRef: "download",
+				Version:      "1.0.0",
+				Source:       &resource.DownloadSource{URL: "https://example.com/" + name + ".tar.gz"},
+			},
+		}
+	}
+	resources := []resource.Resource{bootstrapInstallerResource(), newTool("rg"), newTool("fd")}
+
+	tests := []struct {
+		name    string
+		planned map[string]resource.ActionType
+		wantErr string
+	}{
+		{
+			name: "matches plan",
+			planned: map[string]resource.ActionType{
+				"Installer/brew": resource.ActionInstall,
+				"Tool/rg":        resource.ActionInstall,
+				"Tool/fd":        resource.ActionInstall,
+			},
+		},
+		{
+			name: "action not in plan",
+			planned: map[string]resource.ActionType{
+				"Installer/brew": resource.ActionInstall,
+				"Tool/rg":        resource.ActionInstall,
+			},
+			wantErr: "Tool fd: action install is not in the saved plan",
+		},
+		{
+			name: "action differs from plan",
+			planned: map[string]resource.ActionType{
+				"Installer/brew": resource.ActionInstall,
+				"Tool/rg":        resource.ActionInstall,
+				"Tool/fd":        resource.ActionUpgrade,
+			},
+			wantErr: "Tool fd: action install differs from the saved plan (upgrade)",
+		},
+		{
+			name: "installer bootstrap not in plan",
+			planned: map[string]resource.ActionType{
+				"Tool/rg": resource.ActionInstall,
+				"Tool/fd": resource.ActionInstall,
+			},
+			wantErr: "Installer brew: action install is not in the saved plan",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			store, err := state.NewStore[state.UserState](t.TempDir())
+			require.NoError(t, err)
+
+			eng := NewEngine(&mockToolInstaller{}, &mockRuntimeInstaller{}, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
+			eng.SetPlannedActions(tt.planned)
+
+			err = eng.Apply(context.Background(), resources)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestEngine_Apply_RecordsGenerations(t *testing.T) {
+	t.Parallel()
+	store, err := state.NewStore[state.UserState](t.TempDir())
+	require.NoError(t, err)
+
+	toolMock := &mockToolInstaller{
+		installFunc: func(_ context.Context, res *resource.Tool, name string) (*resource.ToolState, error) {
+			return &resource.ToolState{
+				InstallerRef: res.ToolSpec.InstallerRef,
+				Version:      res.ToolSpec.Version,
+				VersionKind:  resource.VersionExact,
+				SpecVersion:  res.ToolSpec.Version,
+				InstallPath:  "/tools/" + name + "/" + res.ToolSpec.Version,
+				BinPath:      "/bin/" + name,
+			}, nil
+		},
+	}
+	eng := NewEngine(toolMock, &mockRuntimeInstaller{}, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
+
+	newTool := func(version string) []resource.Resource {
+		return []resource.Resource{&resource.Tool{
+			BaseResource: resource.BaseResource{ResourceKind: resource.KindTool, Metadata: resource.Metadata{Name: "test-tool"}},
+			ToolSpec:     &resource.ToolSpec{InstallerRef: "download", Version: version},
+		}}
+	}
+
+	require.NoError(t, eng.Apply(context.Background(), newTool("1.0.0")))
+	require.NoError(t, eng.Apply(context.Background(), newTool("1.0.0"))) // no changes: no new generation
+	require.NoError(t, eng.Apply(context.Background(), newTool("1.1.0")))
+
+	gens, err := state.ListGenerations(store.StatePath())
+	require.NoError(t, err)
+	require.Len(t, gens, 2)
+
+	first, err := state.LoadGeneration[state.UserState](store.StatePath(), gens[0].Number)
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.0", first.Tools["test-tool"].Version)
+	second, err := state.LoadGeneration[state.UserState](store.StatePath(), gens[1].Number)
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.0", second.Tools["test-tool"].Version)
+}
+
 func TestEngine_Apply_NoChanges(t *testing.T) {
 	t.Parallel()
 	// Create test config directory with CUE file
@@ -256,7 +415,7 @@ tool: {
 	}
 	runtimeMock := &mockRuntimeInstaller{}
 
-	engine := NewEngine(toolMock, runtimeMock, &mockInstallerRepositoryInstaller{}, store)
+	engine := NewEngine(toolMock, runtimeMock, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 
 	// Run Apply
 	err = engine.Apply(context.Background(), resources)
@@ -355,7 +514,7 @@ tool: {
 		},
 	}
 
-	engine := NewEngine(toolMock, runtimeMock, &mockInstallerRepositoryInstaller{}, store)
+	engine := NewEngine(toolMock, runtimeMock, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 
 	// Run Apply
 	err = engine.Apply(context.Background(), resources)
@@ -480,7 +639,7 @@ tool: {
 		},
 	}
 
-	engine := NewEngine(toolMock, runtimeMock, &mockInstallerRepositoryInstaller{}, store)
+	engine := NewEngine(toolMock, runtimeMock, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 
 	// Run Apply - should upgrade runtime and reinstall tainted tools
 	err = engine.Apply(context.Background(), resources)
@@ -587,7 +746,7 @@ tool: {
 		mu.Unlock()
 	}
 
-	eng := NewEngine(toolMock, runtimeMock, &mockInstallerRepositoryInstaller{}, store)
+	eng := NewEngine(toolMock, runtimeMock, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 	eng.SetEventHandler(collectEvents)
 
 	err = eng.Apply(context.Background(), resources)
@@ -710,7 +869,7 @@ tool: {
 		},
 	}
 
-	eng := NewEngine(toolMock, runtimeMock, &mockInstallerRepositoryInstaller{}, store)
+	eng := NewEngine(toolMock, runtimeMock, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 
 	err = eng.Apply(context.Background(), resources)
 	require.NoError(t, err)
@@ -799,7 +958,7 @@ bat: {
 		mu.Unlock()
 	}
 
-	eng := NewEngine(toolMock, &mockRuntimeInstaller{}, &mockInstallerRepositoryInstaller{}, store)
+	eng := NewEngine(toolMock, &mockRuntimeInstaller{}, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 	eng.SetEventHandler(collectEvents)
 
 	err = eng.Apply(context.Background(), resources)
@@ -946,7 +1105,7 @@ biomeTool: {
 		},
 	}
 
-	engine := NewEngine(toolMock, runtimeMock, &mockInstallerRepositoryInstaller{}, store)
+	engine := NewEngine(toolMock, runtimeMock, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 
 	// Run Apply
 	err = engine.Apply(context.Background(), resources)
@@ -1021,7 +1180,7 @@ toolB: {
 	store, err := state.NewStore[state.UserState](stateDir)
 	require.NoError(t, err)
 
-	engine := NewEngine(&mockToolInstaller{}, &mockRuntimeInstaller{}, &mockInstallerRepositoryInstaller{}, store)
+	engine := NewEngine(&mockToolInstaller{}, &mockRuntimeInstaller{}, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 
 	// Run Apply - should fail due to circular dependency
 	err = engine.Apply(context.Background(), resources)
@@ -1136,7 +1295,7 @@ bat: {
 		},
 	}
 
-	engine := NewEngine(toolMock, &mockRuntimeInstaller{}, &mockInstallerRepositoryInstaller{}, store)
+	engine := NewEngine(toolMock, &mockRuntimeInstaller{}, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 
 	// Run Apply
 	err = engine.Apply(context.Background(), resources)
@@ -1246,7 +1405,7 @@ bat: {
 		},
 	}
 
-	eng := NewEngine(toolMock, &mockRuntimeInstaller{}, &mockInstallerRepositoryInstaller{}, store)
+	eng := NewEngine(toolMock, &mockRuntimeInstaller{}, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 
 	// Run Apply - should return error for fd
 	err = eng.Apply(context.Background(), resources)
@@ -1357,7 +1516,7 @@ toolC: {
 		},
 	}
 
-	eng := NewEngine(toolMock, &mockRuntimeInstaller{}, &mockInstallerRepositoryInstaller{}, store)
+	eng := NewEngine(toolMock, &mockRuntimeInstaller{}, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 
 	err = eng.Apply(context.Background(), resources)
 	require.Error(t, err)
@@ -1452,7 +1611,7 @@ ripgrep: {
 		},
 	}
 
-	eng := NewEngine(toolMock, runtimeMock, &mockInstallerRepositoryInstaller{}, store)
+	eng := NewEngine(toolMock, runtimeMock, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 
 	err = eng.Apply(context.Background(), resources)
 	require.NoError(t, err)
@@ -1474,6 +1633,60 @@ ripgrep: {
 	assert.Less(t, goIndex, rgIndex, "runtime must be installed before tool even without dependency")
 }
 
+func TestEngine_AddRegistryRuntimeDependencies(t *testing.T) {
+	t.Parallel()
+
+	goRuntime := &resource.Runtime{
+		BaseResource: resource.BaseResource{
+			APIVersion:   resource.GroupVersion,
+			ResourceKind: resource.KindRuntime,
+			Metadata:     resource.Metadata{Name: "go"},
+		},
+		RuntimeSpec: &resource.RuntimeSpec{Version: "1.25.5"},
+	}
+	newRegistryTool := func(name, owner, repo string) *resource.Tool {
+		return &resource.Tool{
+			BaseResource: resource.BaseResource{
+				APIVersion:   resource.GroupVersion,
+				ResourceKind: resource.KindTool,
+				Metadata:     resource.Metadata{Name: name},
+			},
+			ToolSpec: &resource.ToolSpec{
+				InstallerRef: "aqua",
+				Package:      &resource.Package{Owner: owner, Repo: repo},
+			},
+		}
+	}
+	goimports := newRegistryTool("goimports", "golang", "tools")
+	xh := newRegistryTool("xh", "ducaale", "xh")
+	ripgrep := newRegistryTool("ripgrep", "BurntSushi", "ripgrep")
+
+	toolMock := &mockToolInstaller{
+		registryRuntimes: map[string]string{
+			"goimports": "go",
+			"xh":        "rust", // rust runtime is not declared
+		},
+	}
+	store, err := state.NewStore[state.UserState](t.TempDir())
+	require.NoError(t, err)
+	eng := NewEngine(toolMock, &mockRuntimeInstaller{}, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
+
+	resources := []resource.Resource{goRuntime, goimports, xh, ripgrep}
+	resolver := graph.NewResolver()
+	for _, res := range resources {
+		resolver.AddResource(res)
+	}
+	edgesBefore := resolver.EdgeCount()
+
+	eng.addRegistryRuntimeDependencies(context.Background(), resolver, resources)
+
+	assert.Equal(t, edgesBefore+1, resolver.EdgeCount())
+	assert.Contains(t, resolver.GetEdges(), graph.Edge{
+		From: graph.NewNodeID(resource.KindTool, "goimports"),
+		To:   graph.NewNodeID(resource.KindRuntime, "go"),
+	})
+}
+
 func TestEngine_Apply_ParallelRuntimeExecution(t *testing.T) {
 	t.Parallel()
 	// Test that multiple independent runtimes are executed in parallel
@@ -1573,7 +1786,7 @@ nodeRuntime: {
 		},
 	}
 
-	eng := NewEngine(&mockToolInstaller{}, runtimeMock, &mockInstallerRepositoryInstaller{}, store)
+	eng := NewEngine(&mockToolInstaller{}, runtimeMock, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 
 	err = eng.Apply(context.Background(), resources)
 	require.NoError(t, err)
@@ -1610,7 +1823,7 @@ func TestEngine_SetParallelism(t *testing.T) {
 			store, err := state.NewStore[state.UserState](stateDir)
 			require.NoError(t, err)
 
-			eng := NewEngine(&mockToolInstaller{}, &mockRuntimeInstaller{}, &mockInstallerRepositoryInstaller{}, store)
+			eng := NewEngine(&mockToolInstaller{}, &mockRuntimeInstaller{}, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 			eng.SetParallelism(tt.input)
 			assert.Equal(t, tt.want, eng.parallelism)
 		})
@@ -1693,7 +1906,7 @@ aquaInstaller: {
 		},
 	}
 
-	eng := NewEngine(toolMock, &mockRuntimeInstaller{}, &mockInstallerRepositoryInstaller{}, store)
+	eng := NewEngine(toolMock, &mockRuntimeInstaller{}, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 	eng.SetParallelism(2) // Limit to 2 concurrent
 
 	err = eng.Apply(context.Background(), resources)
@@ -1773,7 +1986,7 @@ tool: {
 		},
 	}
 
-	engine := NewEngine(toolMock, &mockRuntimeInstaller{}, &mockInstallerRepositoryInstaller{}, store)
+	engine := NewEngine(toolMock, &mockRuntimeInstaller{}, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 
 	// Set resolver configurer
 	engine.SetResolverConfigurer(func(st *state.UserState) error {
@@ -1844,7 +2057,7 @@ tool: {
 		},
 	}
 
-	engine := NewEngine(toolMock, &mockRuntimeInstaller{}, &mockInstallerRepositoryInstaller{}, store)
+	engine := NewEngine(toolMock, &mockRuntimeInstaller{}, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 
 	engine.SetResolverConfigurer(func(st *state.UserState) error {
 		configurerCalled = true
@@ -1909,7 +2122,7 @@ tool: {
 	store, err := state.NewStore[state.UserState](stateDir)
 	require.NoError(t, err)
 
-	engine := NewEngine(&mockToolInstaller{}, &mockRuntimeInstaller{}, &mockInstallerRepositoryInstaller{}, store)
+	engine := NewEngine(&mockToolInstaller{}, &mockRuntimeInstaller{}, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 
 	// Run PlanAll
 	runtimeActions, _, toolActions, err := engine.PlanAll(context.Background(), resources)
@@ -2066,7 +2279,7 @@ func TestEngine_Property_ParallelSafety(t *testing.T) {
 			},
 		}
 
-		eng := NewEngine(toolMock, &mockRuntimeInstaller{}, &mockInstallerRepositoryInstaller{}, store)
+		eng := NewEngine(toolMock, &mockRuntimeInstaller{}, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 
 		if err := eng.Apply(context.Background(), resources); err != nil {
 			t.Fatalf("Apply failed: %v", err)
@@ -2162,7 +2375,7 @@ func TestEngine_Property_RuntimeBeforeTool(t *testing.T) {
 			},
 		}
 
-		eng := NewEngine(toolMock, runtimeMock, &mockInstallerRepositoryInstaller{}, store)
+		eng := NewEngine(toolMock, runtimeMock, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 
 		if err := eng.Apply(context.Background(), resources); err != nil {
 			t.Fatalf("Apply failed: %v", err)
@@ -2244,7 +2457,7 @@ func TestEngine_Property_ParallelismLimit(t *testing.T) {
 			},
 		}
 
-		eng := NewEngine(toolMock, &mockRuntimeInstaller{}, &mockInstallerRepositoryInstaller{}, store)
+		eng := NewEngine(toolMock, &mockRuntimeInstaller{}, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 		eng.SetParallelism(parallelism)
 
 		if err := eng.Apply(context.Background(), resources); err != nil {
@@ -2311,7 +2524,7 @@ func TestEngine_Property_ContinueOnError(t *testing.T) {
 			},
 		}
 
-		eng := NewEngine(toolMock, &mockRuntimeInstaller{}, &mockInstallerRepositoryInstaller{}, store)
+		eng := NewEngine(toolMock, &mockRuntimeInstaller{}, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 
 		err = eng.Apply(context.Background(), resources)
 
@@ -2383,7 +2596,7 @@ func TestEngine_Apply_ToolSet(t *testing.T) {
 	}
 	runtimeMock := &mockRuntimeInstaller{}
 
-	eng := NewEngine(toolMock, runtimeMock, &mockInstallerRepositoryInstaller{}, store)
+	eng := NewEngine(toolMock, runtimeMock, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 
 	resources := []resource.Resource{
 		&resource.Installer{
@@ -2445,7 +2658,7 @@ func TestEngine_Apply_ToolSet_DisabledItem(t *testing.T) {
 	}
 	runtimeMock := &mockRuntimeInstaller{}
 
-	eng := NewEngine(toolMock, runtimeMock, &mockInstallerRepositoryInstaller{}, store)
+	eng := NewEngine(toolMock, runtimeMock, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 
 	disabled := false
 	resources := []resource.Resource{
@@ -2482,7 +2695,7 @@ func TestEngine_Apply_ToolSet_NameConflict(t *testing.T) {
 
 	toolMock := &mockToolInstaller{}
 	runtimeMock := &mockRuntimeInstaller{}
-	eng := NewEngine(toolMock, runtimeMock, &mockInstallerRepositoryInstaller{}, store)
+	eng := NewEngine(toolMock, runtimeMock, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 
 	resources := []resource.Resource{
 		&resource.Tool{
@@ -2618,7 +2831,7 @@ gopls: {
 
 	toolMock := &mockToolInstaller{}
 	runtimeMock := &mockRuntimeInstaller{}
-	eng := NewEngine(toolMock, runtimeMock, &mockInstallerRepositoryInstaller{}, store)
+	eng := NewEngine(toolMock, runtimeMock, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 
 	// First apply: install both
 	err = eng.Apply(context.Background(), resources)
@@ -2702,7 +2915,7 @@ gopls: {
 
 	toolMock := &mockToolInstaller{}
 	runtimeMock := &mockRuntimeInstaller{}
-	eng := NewEngine(toolMock, runtimeMock, &mockInstallerRepositoryInstaller{}, store)
+	eng := NewEngine(toolMock, runtimeMock, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 
 	_, _, _, err = eng.PlanAll(context.Background(), resources)
 	require.Error(t, err)
@@ -2770,7 +2983,7 @@ gopls: {
 			return nil
 		},
 	}
-	eng := NewEngine(toolMock, runtimeMock, &mockInstallerRepositoryInstaller{}, store)
+	eng := NewEngine(toolMock, runtimeMock, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 
 	// First apply: install both
 	err = eng.Apply(context.Background(), resources)
@@ -3084,6 +3297,97 @@ func TestApplyUpdateTaints_UpdateRuntimes(t *testing.T) {
 	}
 }
 
+func TestApplyUpdateTaints_LockedVersions(t *testing.T) {
+	t.Parallel()
+	st := state.NewUserState()
+	st.Tools = map[string]*resource.ToolState{
+		"gh":      {Version: "2.60.0", VersionKind: resource.VersionLatest},
+		"jq":      {Version: "1.7.1", VersionKind: resource.VersionLatest},
+		"ripgrep": {Version: "14.0.0", VersionKind: resource.VersionExact, SpecVersion: "14.0.0"},
+	}
+	st.Runtimes = map[string]*resource.RuntimeState{
+		"go":   {Version: "1.25.4", VersionKind: resource.VersionAlias, SpecVersion: "stable"},
+		"rust": {Version: "1.83.0", VersionKind: resource.VersionAlias, SpecVersion: "stable"},
+	}
+
+	ApplyUpdateTaints(st, UpdateConfig{
+		LockedTools:    map[string]string{"gh": "2.62.0", "jq": "1.7.1", "ripgrep": "14.1.0"},
+		LockedRuntimes: map[string]string{"go": "1.25.5", "rust": "1.83.0"},
+	})
+
+	assert.Equal(t, resource.TaintReasonLockChanged, st.Tools["gh"].TaintReason)
+	assert.False(t, st.Tools["jq"].IsTainted(), "matching lock version should not be tainted")
+	assert.False(t, st.Tools["ripgrep"].IsTainted(), "exact versions are handled by the reconciler")
+	assert.Equal(t, resource.TaintReasonLockChanged, st.Runtimes["go"].TaintReason)
+	assert.False(t, st.Runtimes["rust"].IsTainted())
+}
+
+func TestApplyUpdateTaints_UpdateNames(t *testing.T) {
+	t.Parallel()
+	st := state.NewUserState()
+	st.Tools = map[string]*resource.ToolState{
+		"gh": {Version: "2.60.0", VersionKind: resource.VersionLatest},
+		"jq": {Version: "1.7.1", VersionKind: resource.VersionLatest},
+		"fd": {Version: "10.2.0", VersionKind: resource.VersionExact, SpecVersion: "10.2.0"},
+	}
+	st.Runtimes = map[string]*resource.RuntimeState{
+		"go": {Version: "1.25.4", VersionKind: resource.VersionAlias, SpecVersion: "stable"},
+	}
+
+	ApplyUpdateTaints(st, UpdateConfig{
+		UpdateToolNames:    []string{"gh", "fd", "missing"},
+		UpdateRuntimeNames: []string{"go"},
+	})
+
+	assert.Equal(t, resource.TaintReasonUpdateRequested, st.Tools["gh"].TaintReason)
+	assert.False(t, st.Tools["jq"].IsTainted(), "unselected tool should not be tainted")
+	assert.False(t, st.Tools["fd"].IsTainted(), "exact version should not be tainted")
+	assert.Equal(t, resource.TaintReasonUpdateRequested, st.Runtimes["go"].TaintReason)
+}
+
+func TestApplyUpdateTaints_RepairNames(t *testing.T) {
+	t.Parallel()
+	st := state.NewUserState()
+	st.Tools = map[string]*resource.ToolState{
+		"gh": {Version: "2.60.0", VersionKind: resource.VersionLatest},
+		"fd": {Version: "10.2.0", VersionKind: resource.VersionExact, SpecVersion: "10.2.0"},
+		"jq": {Version: "1.7.1", VersionKind: resource.VersionExact, SpecVersion: "1.7.1"},
+	}
+	st.Runtimes = map[string]*resource.RuntimeState{
+		"go": {Version: "1.25.4", VersionKind: resource.VersionExact, SpecVersion: "1.25.4"},
+	}
+
+	ApplyUpdateTaints(st, UpdateConfig{
+		RepairToolNames:    []string{"gh", "fd", "missing"},
+		RepairRuntimeNames: []string{"go"},
+		LockedTools:        map[string]string{"gh": "2.62.0"},
+	})
+
+	assert.Equal(t, resource.TaintReasonRepair, st.Tools["gh"].TaintReason, "repair takes precedence over the lock")
+	assert.Equal(t, resource.TaintReasonRepair, st.Tools["fd"].TaintReason, "exact versions are repaired too")
+	assert.False(t, st.Tools["jq"].IsTainted(), "unselected tool should not be tainted")
+	assert.Equal(t, resource.TaintReasonRepair, st.Runtimes["go"].TaintReason)
+}
+
+func TestRuntimeTaints(t *testing.T) {
+	t.Parallel()
+	tools := map[string]*resource.ToolState{
+		"gopls":    {Version: "0.17.0", RuntimeRef: "go"},
+		"golangci": {Version: "1.62.0", RuntimeRef: "go"},
+		"ripgrep":  {Version: "14.1.0", RuntimeRef: "rust"},
+		"jq":       {Version: "1.7.1"},
+	}
+	taintOnUpgrade := func(runtime string) bool { return runtime == "go" || runtime == "rust" }
+
+	tainted := RuntimeTaints(tools, map[string]bool{"go": true}, taintOnUpgrade)
+	assert.Equal(t, map[string]string{"gopls": "go", "golangci": "go"}, tainted)
+
+	tainted = RuntimeTaints(tools, map[string]bool{"go": true, "rust": true}, func(runtime string) bool { return runtime == "rust" })
+	assert.Equal(t, map[string]string{"ripgrep": "rust"}, tainted, "runtimes without taintOnUpgrade should not taint tools")
+
+	assert.Empty(t, RuntimeTaints(tools, nil, taintOnUpgrade))
+}
+
 func TestEngine_SyncMode_Apply(t *testing.T) {
 	t.Parallel()
 	// End-to-end: sync mode triggers reinstall of latest-specified tool
@@ -3142,7 +3446,7 @@ fd: {
 		},
 	}
 
-	eng := NewEngine(toolMock, &mockRuntimeInstaller{}, &mockInstallerRepositoryInstaller{}, store)
+	eng := NewEngine(toolMock, &mockRuntimeInstaller{}, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 	eng.SetUpdateConfig(UpdateConfig{SyncMode: true})
 
 	err = eng.Apply(context.Background(), resources)
@@ -3205,7 +3509,7 @@ rg: {
 		},
 	}
 
-	eng := NewEngine(toolMock, &mockRuntimeInstaller{}, &mockInstallerRepositoryInstaller{}, store)
+	eng := NewEngine(toolMock, &mockRuntimeInstaller{}, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 	eng.SetUpdateConfig(UpdateConfig{SyncMode: true})
 
 	err = eng.Apply(context.Background(), resources)
@@ -3261,7 +3565,7 @@ repo: {
 		},
 	}
 
-	eng := NewEngine(&mockToolInstaller{}, &mockRuntimeInstaller{}, repoMock, store)
+	eng := NewEngine(&mockToolInstaller{}, &mockRuntimeInstaller{}, &mockInstallerInstaller{}, repoMock, store)
 
 	err = eng.Apply(context.Background(), resources)
 	require.NoError(t, err)
@@ -3351,7 +3655,7 @@ tool: {
 		},
 	}
 
-	eng := NewEngine(toolMock, &mockRuntimeInstaller{}, repoMock, store)
+	eng := NewEngine(toolMock, &mockRuntimeInstaller{}, &mockInstallerInstaller{}, repoMock, store)
 
 	err = eng.Apply(context.Background(), resources)
 	require.NoError(t, err)
@@ -3386,7 +3690,7 @@ func TestEngine_Apply_InstallerRepository_Remove(t *testing.T) {
 		},
 	}
 
-	eng := NewEngine(&mockToolInstaller{}, &mockRuntimeInstaller{}, repoMock, store)
+	eng := NewEngine(&mockToolInstaller{}, &mockRuntimeInstaller{}, &mockInstallerInstaller{}, repoMock, store)
 	eng.SetUpdateConfig(UpdateConfig{SyncMode: true})
 
 	// Apply with empty resources - should trigger removal
@@ -3430,7 +3734,7 @@ repo: {
 	store, err := state.NewStore[state.UserState](stateDir)
 	require.NoError(t, err)
 
-	eng := NewEngine(&mockToolInstaller{}, &mockRuntimeInstaller{}, &mockInstallerRepositoryInstaller{}, store)
+	eng := NewEngine(&mockToolInstaller{}, &mockRuntimeInstaller{}, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 
 	runtimeActions, repoActions, toolActions, err := eng.PlanAll(context.Background(), resources)
 	require.NoError(t, err)
@@ -3552,7 +3856,7 @@ tool: {
 		mu.Unlock()
 	}
 
-	eng := NewEngine(&mockToolInstaller{}, &mockRuntimeInstaller{}, &mockInstallerRepositoryInstaller{}, store)
+	eng := NewEngine(&mockToolInstaller{}, &mockRuntimeInstaller{}, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 	eng.SetEventHandler(collectEvents)
 
 	err = eng.Apply(context.Background(), resources)
@@ -3655,7 +3959,7 @@ tool: {
 		mu.Unlock()
 	}
 
-	eng := NewEngine(&mockToolInstaller{}, &mockRuntimeInstaller{}, &mockInstallerRepositoryInstaller{}, store)
+	eng := NewEngine(&mockToolInstaller{}, &mockRuntimeInstaller{}, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 	eng.SetEventHandler(collectEvents)
 
 	err = eng.Apply(context.Background(), resources)
@@ -3690,7 +3994,7 @@ func TestEngine_DetermineInstallMethod_Commands(t *testing.T) {
 	store, err := state.NewStore[state.UserState](stateDir)
 	require.NoError(t, err)
 
-	eng := NewEngine(&mockToolInstaller{}, &mockRuntimeInstaller{}, &mockInstallerRepositoryInstaller{}, store)
+	eng := NewEngine(&mockToolInstaller{}, &mockRuntimeInstaller{}, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 
 	tests := []struct {
 		name string
@@ -4054,7 +4358,7 @@ func TestEngine_Apply_DelegationToolsSerialized(t *testing.T) {
 	}
 
 	runtimeMock := &mockRuntimeInstaller{}
-	eng := NewEngine(toolMock, runtimeMock, &mockInstallerRepositoryInstaller{}, store)
+	eng := NewEngine(toolMock, runtimeMock, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 
 	// 3 go delegation tools + 2 download tools
 	resources := []resource.Resource{
@@ -4190,7 +4494,7 @@ func TestEngine_Apply_DifferentDelegationGroupsParallel(t *testing.T) {
 	}
 
 	runtimeMock := &mockRuntimeInstaller{}
-	eng := NewEngine(toolMock, runtimeMock, &mockInstallerRepositoryInstaller{}, store)
+	eng := NewEngine(toolMock, runtimeMock, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 
 	// 2 runtimes x 2 tools
 	resources := []resource.Resource{
@@ -4293,7 +4597,7 @@ func TestEngine_Apply_DelegationGroupErrorStopsGroup(t *testing.T) {
 	}
 
 	runtimeMock := &mockRuntimeInstaller{}
-	eng := NewEngine(toolMock, runtimeMock, &mockInstallerRepositoryInstaller{}, store)
+	eng := NewEngine(toolMock, runtimeMock, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 
 	// 3 go delegation tools + 1 download tool
 	resources := []resource.Resource{
@@ -4373,7 +4677,7 @@ func TestEngine_Apply_AllDelegationSingleGroup(t *testing.T) {
 	}
 
 	runtimeMock := &mockRuntimeInstaller{}
-	eng := NewEngine(toolMock, runtimeMock, &mockInstallerRepositoryInstaller{}, store)
+	eng := NewEngine(toolMock, runtimeMock, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 
 	// All tools in same delegation group (no download tools)
 	resources := []resource.Resource{
@@ -4439,7 +4743,7 @@ func TestEngine_Apply_DelegationWithParallelismOne(t *testing.T) {
 	}
 
 	runtimeMock := &mockRuntimeInstaller{}
-	eng := NewEngine(toolMock, runtimeMock, &mockInstallerRepositoryInstaller{}, store)
+	eng := NewEngine(toolMock, runtimeMock, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 	eng.SetParallelism(1)
 
 	// Mix of delegation and download tools
@@ -4598,7 +4902,7 @@ func TestEngine_Property_DelegationSerialization(t *testing.T) {
 			},
 		}
 
-		eng := NewEngine(toolMock, &mockRuntimeInstaller{}, &mockInstallerRepositoryInstaller{}, store)
+		eng := NewEngine(toolMock, &mockRuntimeInstaller{}, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 		eng.SetParallelism(parallelism)
 
 		if err := eng.Apply(context.Background(), resources); err != nil {
@@ -4675,7 +4979,7 @@ func TestEngine_Apply_CommandsPattern(t *testing.T) {
 		},
 	}
 
-	eng := NewEngine(toolMock, &mockRuntimeInstaller{}, &mockInstallerRepositoryInstaller{}, store)
+	eng := NewEngine(toolMock, &mockRuntimeInstaller{}, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 
 	err = eng.Apply(context.Background(), resources)
 	require.NoError(t, err)
@@ -4742,7 +5046,7 @@ func TestEngine_Apply_CommandsPattern_NoSpuriousUpgrade(t *testing.T) {
 		},
 	}
 
-	eng := NewEngine(toolMock, &mockRuntimeInstaller{}, &mockInstallerRepositoryInstaller{}, store)
+	eng := NewEngine(toolMock, &mockRuntimeInstaller{}, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 
 	err = eng.Apply(context.Background(), resources)
 	require.NoError(t, err)
@@ -4803,7 +5107,7 @@ func TestEngine_Apply_UpdateTools_CommandsPattern(t *testing.T) {
 		},
 	}
 
-	eng := NewEngine(toolMock, &mockRuntimeInstaller{}, &mockInstallerRepositoryInstaller{}, store)
+	eng := NewEngine(toolMock, &mockRuntimeInstaller{}, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 	eng.SetUpdateConfig(UpdateConfig{UpdateTools: true})
 
 	err = eng.Apply(context.Background(), resources)
@@ -4868,7 +5172,7 @@ tool: {
 		},
 	}
 
-	eng := NewEngine(toolMock, &mockRuntimeInstaller{}, &mockInstallerRepositoryInstaller{}, store)
+	eng := NewEngine(toolMock, &mockRuntimeInstaller{}, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 
 	// Cancel context before Apply
 	ctx, cancel := context.WithCancel(context.Background())
@@ -4966,7 +5270,7 @@ tool: {
 		},
 	}
 
-	eng := NewEngine(toolMock, runtimeMock, &mockInstallerRepositoryInstaller{}, store)
+	eng := NewEngine(toolMock, runtimeMock, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
 
 	err = eng.Apply(ctx, resources)
 	require.Error(t, err)
@@ -4974,3 +5278,220 @@ tool: {
 	assert.True(t, runtimeInstalled.Load(), "runtime (layer 0) should have been installed")
 	assert.False(t, toolInstalled.Load(), "tool (layer 1) should not be installed after cancellation")
 }
+
+func bootstrapInstallerResource() *resource.Installer {
+	return &resource.Installer{
+		BaseResource: resource.BaseResource{
+			APIVersion:   resource.GroupVersion,
+			ResourceKind: resource.KindInstaller,
+			Metadata:     resource.Metadata{Name: "brew"},
+		},
+		InstallerSpec: &resource.InstallerSpec{
+			Type: resource.InstallTypeDelegation,
+			Bootstrap: &resource.BootstrapSpec{
+				Install: []string{"install-brew.sh"},
+				Check:   []string{"command -v brew"},
+				Remove:  []string{"uninstall-brew.sh"},
+			},
+			Commands: &resource.CommandsSpec{Install: []string{"brew install {{.Package}}"}},
+			BinDir:   "/opt/homebrew/bin",
+		},
+	}
+}
+
+func TestEngine_Apply_InstallerBootstrap(t *testing.T) {
+	t.Parallel()
+
+	store, err := state.NewStore[state.UserState](t.TempDir())
+	require.NoError(t, err)
+
+	var (
+		installs  []resource.ActionType
+		removed   []string
+		installed = true
+	)
+	instMock := &mockInstallerInstaller{
+		installFunc: func(ctx context.Context, res *resource.Installer, _ string) (*resource.InstallerState, error) {
+			installs = append(installs, executor.ActionFromContext(ctx))
+			installed = true
+			return &resource.InstallerState{BinDir: res.InstallerSpec.BinDir, Bootstrap: res.InstallerSpec.Bootstrap}, nil
+		},
+		removeFunc: func(_ context.Context, st *resource.InstallerState, name string) error {
+			removed = append(removed, name+":"+strings.Join(st.Bootstrap.Remove, ","))
+			return nil
+		},
+		isInstalledFunc: func(context.Context, *resource.InstallerState, string) bool { return installed },
+	}
+	eng := NewEngine(&mockToolInstaller{}, &mockRuntimeInstaller{}, instMock, &mockInstallerRepositoryInstaller{}, store)
+
+	var events []Event
+	eng.SetEventHandler(func(e Event) {
+		if e.Kind == resource.KindInstaller {
+			events = append(events, e)
+		}
+	})
+
+	ctx := context.Background()
+	resources := []resource.Resource{bootstrapInstallerResource()}
+
+	// First apply runs the bootstrap
+	require.NoError(t, eng.Apply(ctx, resources))
+	assert.Equal(t, []resource.ActionType{resource.ActionInstall}, installs)
+	require.NotEmpty(t, events)
+	assert.Equal(t, EventStart, events[0].Type)
+	assert.Equal(t, "bootstrap", events[0].Method)
+
+	st, err := store.LoadReadOnly()
+	require.NoError(t, err)
+	require.Contains(t, st.Installers, "brew")
+	assert.Equal(t, []string{"uninstall-brew.sh"}, st.Installers["brew"].Bootstrap.Remove)
+
+	// Second apply is a no-op while the check passes
+	require.NoError(t, eng.Apply(ctx, resources))
+	assert.Len(t, installs, 1)
+
+	// Drift: check fails, installer is reinstalled
+	installed = false
+	require.NoError(t, eng.Apply(ctx, resources))
+	assert.Equal(t, []resource.ActionType{resource.ActionInstall, resource.ActionReinstall}, installs)
+
+	// Removing the installer from the manifest runs the stored remove command
+	require.NoError(t, eng.Apply(ctx, nil))
+	assert.Equal(t, []string{"brew:uninstall-brew.sh"}, removed)
+
+	st, err = store.LoadReadOnly()
+	require.NoError(t, err)
+	assert.NotContains(t, st.Installers, "brew")
+}
+
+func TestEngine_Apply_InstallerRemovalBlockedByTool(t *testing.T) {
+	t.Parallel()
+
+	store, err := state.NewStore[state.UserState](t.TempDir())
+	require.NoError(t, err)
+
+	instMock := &mockInstallerInstaller{}
+	eng := NewEngine(&mockToolInstaller{}, &mockRuntimeInstaller{}, instMock, &mockInstallerRepositoryInstaller{}, store)
+
+	jq := &resource.Tool{
+		BaseResource: resource.BaseResource{
+			APIVersion:   resource.GroupVersion,
+			ResourceKind: resource.KindTool,
+			Metadata:     resource.Metadata{Name: "jq"},
+		},
+		ToolSpec: &resource.ToolSpec{InstallerRef: "brew", Package: &resource.Package{Name: "jq"}},
+	}
+
+	ctx := context.Background()
+	require.NoError(t, eng.Apply(ctx, []resource.Resource{bootstrapInstallerResource(), jq}))
+
+	err = eng.Apply(ctx, []resource.Resource{jq})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `tool "jq" depends on installer "brew"`)
+}
+
+// batchMockToolInstaller is a mockToolInstaller that also implements BatchToolInstaller.
+type batchMockToolInstaller struct {
+	mockToolInstaller
+	batchFunc func(ctx context.Context, tools []*resource.Tool) map[string]bool
+}
+
+func (m *batchMockToolInstaller) BatchInstall(ctx context.Context, tools []*resource.Tool) map[string]bool {
+	return m.batchFunc(ctx, tools)
+}
+
+func TestEngine_Apply_DelegationBatchInstall(t *testing.T) {
+	t.Parallel()
+
+	stateDir := t.TempDir()
+	store, err := state.NewStore[state.UserState](stateDir)
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var batches [][]string
+	batchInstalled := make(map[string]bool)
+
+	toolMock := &batchMockToolInstaller{
+		mockToolInstaller: mockToolInstaller{
+			installFunc: func(ctx context.Context, res *resource.Tool, name string) (*resource.ToolState, error) {
+				mu.Lock()
+				batchInstalled[name] = executor.BatchInstalledFromContext(ctx)
+				mu.Unlock()
+				return &resource.ToolState{
+					RuntimeRef: res.ToolSpec.RuntimeRef,
+					Version:    res.ToolSpec.Version,
+					BinPath:    "/bin/" + name,
+				}, nil
+			},
+		},
+		batchFunc: func(_ context.Context, tools []*resource.Tool) map[string]bool {
+			var names []string
+			for _, t := range tools {
+				names = append(names, t.Name())
+			}
+			mu.Lock()
+			batches = append(batches, names)
+			mu.Unlock()
+			// prettier is not found after the batch
+			return map[string]bool{"typescript": true, "eslint": true}
+		},
+	}
+
+	eng := NewEngine(toolMock, &mockRuntimeInstaller{}, &mockInstallerInstaller{}, &mockInstallerRepositoryInstaller{}, store)
+	var startsMu sync.Mutex
+	starts := make(map[string]int)
+	eng.SetEventHandler(func(ev Event) {
+		if ev.Type == EventStart && ev.Kind == resource.KindTool {
+			startsMu.Lock()
+			starts[ev.Name]++
+			startsMu.Unlock()
+		}
+	})
+
+	pnpmTool := func(name string, args ...string) *resource.Tool {
+		return &resource.Tool{
+			BaseResource: resource.BaseResource{APIVersion: resource.GroupVersion, ResourceKind: resource.KindTool, Metadata: resource.Metadata{Name: name}},
+			ToolSpec:     &resource.ToolSpec{RuntimeRef: "pnpm", Version: "latest", Package: &resource.Package{Name: name}, Args: args},
+		}
+	}
+	resources := []resource.Resource{
+		&resource.Runtime{
+			BaseResource: resource.BaseResource{APIVersion: resource.GroupVersion, ResourceKind: resource.KindRuntime, Metadata: resource.Metadata{Name: "pnpm"}},
+			RuntimeSpec: &resource.RuntimeSpec{
+				Type:        resource.InstallTypeDownload,
+				Version:     "10.8.0",
+				Binaries:    []string{"pnpm"},
+				ToolBinPath: "~/.local/share/pnpm",
+				Source: &resource.DownloadSource{
+					URL:      "https://example.com/pnpm.tar.gz",
+					Checksum: &resource.Checksum{Value: "sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+				},
+				Commands: &resource.CommandsSpec{
+					Install:      []string{"pnpm add -g {{.Package}}@{{.Version}}"},
+					BatchInstall: []string{"pnpm add -g {{.Packages}}"},
+				},
+			},
+		},
+		pnpmTool("typescript"),
+		pnpmTool("eslint"),
+		pnpmTool("prettier"),
+		pnpmTool("wrangler", "--allow-build=esbuild"),
+	}
+
+	require.NoError(t, eng.Apply(context.Background(), resources))
+
+	require.Len(t, batches, 1, "the group is installed with one batch invocation")
+	assert.ElementsMatch(t, []string{"typescript", "eslint", "prettier"}, batches[0], "tools with args are installed individually")
+	assert.Equal(t, map[string]bool{
+		"typescript": true,
+		"eslint":     true,
+		"prettier":   false,
+		"wrangler":   false,
+	}, batchInstalled, "tools that failed the check after the batch are installed individually")
+	assert.Equal(t, map[string]int{"typescript": 1, "eslint": 1, "prettier": 1, "wrangler": 1}, starts)
+
+	// Nothing is pending on the next apply
+	batches = nil
+	require.NoError(t, eng.Apply(context.Background(), resources))
+	assert.Empty(t, batches)
+}
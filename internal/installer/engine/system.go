@@ -0,0 +1,552 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/terassyi/tomei/internal/graph"
+	"github.com/terassyi/tomei/internal/installer/download"
+	"github.com/terassyi/tomei/internal/installer/executor"
+	"github.com/terassyi/tomei/internal/installer/reconciler"
+	"github.com/terassyi/tomei/internal/resource"
+	"github.com/terassyi/tomei/internal/state"
+)
+
+// SystemInstallerInstaller defines the interface for installing system installers.
+type SystemInstallerInstaller interface {
+	Install(ctx context.Context, res *resource.SystemInstaller, name string) (*resource.SystemInstallerState, error)
+	Remove(ctx context.Context, st *resource.SystemInstallerState, name string) error
+	RegisterInstaller(name string, spec *resource.SystemInstallerSpec)
+}
+
+// SystemPackageRepositoryInstaller defines the interface for installing system package repositories.
+type SystemPackageRepositoryInstaller interface {
+	Install(ctx context.Context, res *resource.SystemPackageRepository, name string) (*resource.SystemPackageRepositoryState, error)
+	Remove(ctx context.Context, st *resource.SystemPackageRepositoryState, name string) error
+}
+
+// SystemPackageSetInstaller defines the interface for installing system package sets.
+type SystemPackageSetInstaller interface {
+	Install(ctx context.Context, res *resource.SystemPackageSet, name string) (*resource.SystemPackageSetState, error)
+	Remove(ctx context.Context, st *resource.SystemPackageSetState, name string) error
+}
+
+// SystemInstallerAction is an alias for system-installer-specific action type.
+type SystemInstallerAction = reconciler.Action[*resource.SystemInstaller, *resource.SystemInstallerState]
+
+// SystemPackageRepositoryAction is an alias for system-package-repository-specific action type.
+type SystemPackageRepositoryAction = reconciler.Action[*resource.SystemPackageRepository, *resource.SystemPackageRepositoryState]
+
+// SystemPackageSetAction is an alias for system-package-set-specific action type.
+type SystemPackageSetAction = reconciler.Action[*resource.SystemPackageSet, *resource.SystemPackageSetState]
+
+// SystemEngine orchestrates the apply process for system-privilege resources
+// (SystemInstaller, SystemPackageRepository, SystemPackageSet) against the
+// root-owned system state.
+//
+// Unlike Engine, nodes are executed sequentially: system package managers
+// (apt, dnf) hold a global lock, so concurrent invocations would fail.
+type SystemEngine struct {
+	store                *state.Store[state.SystemState]
+	stateCache           *executor.StateCache[state.SystemState]
+	installerStore       executor.StateStore[*resource.SystemInstallerState]
+	repoStore            executor.StateStore[*resource.SystemPackageRepositoryState]
+	packageSetStore      executor.StateStore[*resource.SystemPackageSetState]
+	installerInstaller   SystemInstallerInstaller
+	repoInstaller        SystemPackageRepositoryInstaller
+	packageSetInstaller  SystemPackageSetInstaller
+	installerReconciler  *reconciler.Reconciler[*resource.SystemInstaller, *resource.SystemInstallerState]
+	installerExecutor    *executor.Executor[*resource.SystemInstaller, *resource.SystemInstallerState]
+	repoReconciler       *reconciler.Reconciler[*resource.SystemPackageRepository, *resource.SystemPackageRepositoryState]
+	repoExecutor         *executor.Executor[*resource.SystemPackageRepository, *resource.SystemPackageRepositoryState]
+	packageSetReconciler *reconciler.Reconciler[*resource.SystemPackageSet, *resource.SystemPackageSetState]
+	packageSetExecutor   *executor.Executor[*resource.SystemPackageSet, *resource.SystemPackageSetState]
+	eventHandler         EventHandler
+}
+
+// NewSystemEngine creates a new SystemEngine.
+func NewSystemEngine(
+	installerInstaller SystemInstallerInstaller,
+	repoInstaller SystemPackageRepositoryInstaller,
+	packageSetInstaller SystemPackageSetInstaller,
+	store *state.Store[state.SystemState],
+) *SystemEngine {
+	sc := executor.NewStateCache(store)
+	installerStore := executor.NewSystemInstallerStore(sc)
+	repoStore := executor.NewSystemPackageRepositoryStore(sc)
+	packageSetStore := executor.NewSystemPackageSetStore(sc)
+	return &SystemEngine{
+		store:                store,
+		stateCache:           sc,
+		installerStore:       installerStore,
+		repoStore:            repoStore,
+		packageSetStore:      packageSetStore,
+		installerInstaller:   installerInstaller,
+		repoInstaller:        repoInstaller,
+		packageSetInstaller:  packageSetInstaller,
+		installerReconciler:  reconciler.NewSystemInstallerReconciler(),
+		installerExecutor:    executor.New(resource.KindSystemInstaller, installerInstaller, installerStore),
+		repoReconciler:       reconciler.NewSystemPackageRepositoryReconciler(),
+		repoExecutor:         executor.New(resource.KindSystemPackageRepository, repoInstaller, repoStore),
+		packageSetReconciler: reconciler.NewSystemPackageSetReconciler(),
+		packageSetExecutor:   executor.New(resource.KindSystemPackageSet, packageSetInstaller, packageSetStore),
+	}
+}
+
+// SetEventHandler sets a callback for engine events.
+func (e *SystemEngine) SetEventHandler(handler EventHandler) {
+	e.eventHandler = handler
+}
+
+// emitEvent emits an event to the handler if set.
+func (e *SystemEngine) emitEvent(event Event) {
+	if e.eventHandler != nil {
+		e.eventHandler(event)
+	}
+}
+
+// IsSystemKind returns true if the kind is a system-privilege resource kind.
+func IsSystemKind(kind resource.Kind) bool {
+	switch kind {
+	case resource.KindSystemInstaller, resource.KindSystemPackageRepository, resource.KindSystemPackageSet:
+		return true
+	default:
+		return false
+	}
+}
+
+// FilterSystemResources returns only the system-privilege resources.
+func FilterSystemResources(resources []resource.Resource) []resource.Resource {
+	var result []resource.Resource
+	for _, res := range resources {
+		if IsSystemKind(res.Kind()) {
+			result = append(result, res)
+		}
+	}
+	return result
+}
+
+// Apply reconciles system resources with the system state and executes actions
+// using DAG-based ordering. Non-system resources are ignored.
+func (e *SystemEngine) Apply(ctx context.Context, resources []resource.Resource) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	var err error
+	resources, err = resource.ExpandSets(resources)
+	if err != nil {
+		return fmt.Errorf("failed to expand sets: %w", err)
+	}
+	resources = FilterSystemResources(resources)
+
+	slog.Debug("applying system configuration", "resources", len(resources))
+
+	for _, res := range resources {
+		if err := res.Spec().Validate(); err != nil {
+			return fmt.Errorf("invalid %s %q: %w", res.Kind(), res.Name(), err)
+		}
+	}
+
+	resolver := graph.NewResolver()
+	for _, res := range resources {
+		resolver.AddResource(res)
+	}
+	layers, err := resolver.Resolve()
+	if err != nil {
+		return fmt.Errorf("failed to resolve dependencies: %w", err)
+	}
+
+	if err := e.store.Lock(); err != nil {
+		return fmt.Errorf("failed to acquire lock: %w", err)
+	}
+	defer func() { _ = e.store.Unlock() }()
+
+	st, err := e.store.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	// Backup state before changes (non-fatal if fails)
+	if err := state.CreateBackup(e.store); err != nil {
+		slog.Warn("failed to create system state backup", "error", err)
+	}
+
+	// Register installers from state first so that resources referencing an
+	// installer that was dropped from the manifest can still be removed,
+	// then from the manifest so that the declared spec takes precedence.
+	for name, is := range st.SystemInstallers {
+		e.installerInstaller.RegisterInstaller(name, is.Spec())
+	}
+	for _, inst := range extractByKind[*resource.SystemInstaller](resources) {
+		e.installerInstaller.RegisterInstaller(inst.Name(), inst.SystemInstallerSpec)
+	}
+
+	e.stateCache.Init(st)
+
+	resourceMap := buildResourceMap(resources)
+	totalActions := 0
+
+	allLayerNodes := make([][]string, len(layers))
+	for i, layer := range layers {
+		for _, node := range layer.Nodes {
+			allLayerNodes[i] = append(allLayerNodes[i], node.ID.String())
+		}
+	}
+
+	for i, layer := range layers {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		e.emitEvent(Event{
+			Type:          EventLayerStart,
+			Layer:         i,
+			TotalLayers:   len(layers),
+			LayerNodes:    allLayerNodes[i],
+			AllLayerNodes: allLayerNodes,
+		})
+
+		layerErr := e.executeLayer(ctx, layer, resourceMap, &totalActions)
+
+		// Flush after each layer, even on error, to persist completed work.
+		if err := e.stateCache.Flush(); err != nil {
+			return fmt.Errorf("failed to flush system state after layer %d: %w", i, err)
+		}
+		if layerErr != nil {
+			return layerErr
+		}
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err := e.handleRemovals(ctx, resources, &totalActions); err != nil {
+		return err
+	}
+
+	if err := e.stateCache.Flush(); err != nil {
+		return fmt.Errorf("failed to flush final system state: %w", err)
+	}
+
+	slog.Debug("system apply completed", "total_actions", totalActions)
+	return nil
+}
+
+// executeLayer executes the nodes of a layer sequentially, ordered by kind:
+// installers first, then repositories, then package sets.
+func (e *SystemEngine) executeLayer(
+	ctx context.Context,
+	layer graph.Layer,
+	resourceMap map[string]resource.Resource,
+	totalActions *int,
+) error {
+	for _, kind := range []resource.Kind{
+		resource.KindSystemInstaller,
+		resource.KindSystemPackageRepository,
+		resource.KindSystemPackageSet,
+	} {
+		for _, node := range layer.Nodes {
+			if node.Kind != kind {
+				continue
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err := e.executeNode(ctx, node, resourceMap, totalActions); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// executeNode executes a single node based on its kind.
+func (e *SystemEngine) executeNode(
+	ctx context.Context,
+	node *graph.Node,
+	resourceMap map[string]resource.Resource,
+	totalActions *int,
+) error {
+	res, ok := resourceMap[graph.NewNodeID(node.Kind, node.Name).String()]
+	if !ok {
+		// Node was auto-added as a dependency but not in resources
+		slog.Debug("skipping node not in resources", "kind", node.Kind, "name", node.Name)
+		return nil
+	}
+
+	switch r := res.(type) {
+	case *resource.SystemInstaller:
+		return executeSystemNode(ctx, e, r, e.installerStore, e.installerReconciler, e.installerExecutor, "", totalActions)
+	case *resource.SystemPackageRepository:
+		method := r.SystemPackageRepositorySpec.InstallerRef + " repository"
+		return executeSystemNode(ctx, e, r, e.repoStore, e.repoReconciler, e.repoExecutor, method, totalActions)
+	case *resource.SystemPackageSet:
+		return e.executePackageSetNode(ctx, r, totalActions)
+	default:
+		slog.Debug("skipping unknown resource kind", "kind", node.Kind, "name", node.Name)
+		return nil
+	}
+}
+
+// executePackageSetNode executes a package set action. On upgrade, packages
+// dropped from the set are removed before the remaining ones are installed.
+func (e *SystemEngine) executePackageSetNode(ctx context.Context, set *resource.SystemPackageSet, totalActions *int) error {
+	method := set.SystemPackageSetSpec.InstallerRef + " install"
+	return executeSystemNode(ctx, e, set, e.packageSetStore, e.packageSetReconciler, e.packageSetExecutor, method, totalActions,
+		func(ctx context.Context, action SystemPackageSetAction) error {
+			if action.Type != resource.ActionUpgrade || action.State == nil {
+				return nil
+			}
+			_, removed := reconciler.DiffPackages(action.State.Packages, set.SystemPackageSetSpec.Packages)
+			if len(removed) == 0 {
+				return nil
+			}
+			return e.packageSetInstaller.Remove(ctx, &resource.SystemPackageSetState{
+				InstallerRef:  action.State.InstallerRef,
+				RepositoryRef: action.State.RepositoryRef,
+				Packages:      removed,
+				Owned:         action.State.Owned,
+			}, action.Name)
+		})
+}
+
+// executeSystemNode reconciles a single resource against its own state and
+// executes the resulting action, emitting progress events.
+// Optional pre hooks run after the start event and before execution.
+func executeSystemNode[R resource.Resource, S resource.State](
+	ctx context.Context,
+	e *SystemEngine,
+	res R,
+	store executor.StateStore[S],
+	rec *reconciler.Reconciler[R, S],
+	exec *executor.Executor[R, S],
+	method string,
+	totalActions *int,
+	pre ...func(context.Context, reconciler.Action[R, S]) error,
+) error {
+	kind := res.Kind()
+	name := res.Name()
+
+	single := make(map[string]S)
+	st, exists, err := store.Load(name)
+	if err != nil {
+		return fmt.Errorf("failed to load state for %s %s: %w", kind, name, err)
+	}
+	if exists {
+		single[name] = st
+	}
+
+	actions := rec.Reconcile([]R{res}, single)
+	if len(actions) == 0 || actions[0].Type == resource.ActionNone {
+		return nil
+	}
+	action := actions[0]
+
+	ctx = download.WithCallback(ctx, download.OutputCallback(func(line string) {
+		e.emitEvent(Event{
+			Type:   EventOutput,
+			Kind:   kind,
+			Name:   name,
+			Output: line,
+			Method: method,
+		})
+	}))
+
+	e.emitEvent(Event{
+		Type:   EventStart,
+		Kind:   kind,
+		Name:   name,
+		Action: action.Type,
+		Method: method,
+	})
+
+	fail := func(err error) error {
+		e.emitEvent(Event{
+			Type:   EventError,
+			Kind:   kind,
+			Name:   name,
+			Action: action.Type,
+			Error:  err,
+			Method: method,
+		})
+		return fmt.Errorf("failed to execute action %s for %s %s: %w", action.Type, kind, name, err)
+	}
+
+	for _, hook := range pre {
+		if err := hook(ctx, action); err != nil {
+			return fail(err)
+		}
+	}
+	if err := exec.Execute(ctx, action); err != nil {
+		return fail(err)
+	}
+
+	e.emitEvent(Event{
+		Type:   EventComplete,
+		Kind:   kind,
+		Name:   name,
+		Action: action.Type,
+		Method: method,
+	})
+
+	*totalActions++
+	return nil
+}
+
+// handleRemovals processes system resources that are in state but not in the config.
+// Removal order: package sets first, then repositories, then installers.
+func (e *SystemEngine) handleRemovals(ctx context.Context, resources []resource.Resource, totalActions *int) error {
+	st := e.stateCache.Snapshot()
+
+	sets := extractByKind[*resource.SystemPackageSet](resources)
+	repos := extractByKind[*resource.SystemPackageRepository](resources)
+	installers := extractByKind[*resource.SystemInstaller](resources)
+
+	setActions := e.packageSetReconciler.Reconcile(sets, st.SystemPackages)
+	repoActions := e.repoReconciler.Reconcile(repos, st.SystemPackageRepositories)
+	installerActions := e.installerReconciler.Reconcile(installers, st.SystemInstallers)
+
+	if err := checkSystemRemovalDependencies(installerActions, repoActions, sets, repos); err != nil {
+		return err
+	}
+
+	var layerNodes []string
+	layerNodes = collectRemovalNodes(layerNodes, resource.KindSystemPackageSet, setActions)
+	layerNodes = collectRemovalNodes(layerNodes, resource.KindSystemPackageRepository, repoActions)
+	layerNodes = collectRemovalNodes(layerNodes, resource.KindSystemInstaller, installerActions)
+	if len(layerNodes) == 0 {
+		return nil
+	}
+
+	e.emitEvent(Event{
+		Type:       EventLayerStart,
+		Phase:      PhaseRemove,
+		LayerNodes: layerNodes,
+	})
+
+	if err := executeSystemRemovals(ctx, e, resource.KindSystemPackageSet, setActions, e.packageSetExecutor, totalActions); err != nil {
+		return err
+	}
+	if err := executeSystemRemovals(ctx, e, resource.KindSystemPackageRepository, repoActions, e.repoExecutor, totalActions); err != nil {
+		return err
+	}
+	return executeSystemRemovals(ctx, e, resource.KindSystemInstaller, installerActions, e.installerExecutor, totalActions)
+}
+
+// executeSystemRemovals iterates over actions, executing removals with PhaseRemove events.
+func executeSystemRemovals[R resource.Resource, S resource.State](
+	ctx context.Context,
+	e *SystemEngine,
+	kind resource.Kind,
+	actions []reconciler.Action[R, S],
+	exec *executor.Executor[R, S],
+	totalActions *int,
+) error {
+	for _, action := range actions {
+		if action.Type != resource.ActionRemove {
+			continue
+		}
+		e.emitEvent(Event{
+			Type:   EventStart,
+			Phase:  PhaseRemove,
+			Kind:   kind,
+			Name:   action.Name,
+			Action: action.Type,
+		})
+		if err := exec.Execute(ctx, action); err != nil {
+			e.emitEvent(Event{
+				Type:   EventError,
+				Phase:  PhaseRemove,
+				Kind:   kind,
+				Name:   action.Name,
+				Action: action.Type,
+				Error:  err,
+			})
+			return fmt.Errorf("failed to remove %s %s: %w", kind, action.Name, err)
+		}
+		e.emitEvent(Event{
+			Type:   EventComplete,
+			Phase:  PhaseRemove,
+			Kind:   kind,
+			Name:   action.Name,
+			Action: action.Type,
+		})
+		*totalActions++
+	}
+	return nil
+}
+
+// PlanAll returns system installer, repository, and package set actions based on
+// resources and the current system state. The state is read without taking the
+// lock so that plan can run without root privileges.
+func (e *SystemEngine) PlanAll(_ context.Context, resources []resource.Resource) ([]SystemInstallerAction, []SystemPackageRepositoryAction, []SystemPackageSetAction, error) {
+	st, err := e.store.LoadReadOnly()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to load state: %w", err)
+	}
+	return PlanSystem(resources, st)
+}
+
+// PlanSystem returns system installer, repository, and package set actions for
+// resources against the given system state.
+func PlanSystem(resources []resource.Resource, st *state.SystemState) ([]SystemInstallerAction, []SystemPackageRepositoryAction, []SystemPackageSetAction, error) {
+	installers := extractByKind[*resource.SystemInstaller](resources)
+	repos := extractByKind[*resource.SystemPackageRepository](resources)
+	sets := extractByKind[*resource.SystemPackageSet](resources)
+
+	installerActions := reconciler.NewSystemInstallerReconciler().Reconcile(installers, st.SystemInstallers)
+	repoActions := reconciler.NewSystemPackageRepositoryReconciler().Reconcile(repos, st.SystemPackageRepositories)
+	setActions := reconciler.NewSystemPackageSetReconciler().Reconcile(sets, st.SystemPackages)
+
+	if err := checkSystemRemovalDependencies(installerActions, repoActions, sets, repos); err != nil {
+		return nil, nil, nil, err
+	}
+
+	slog.Debug("system plan completed", "installerActions", len(installerActions), "repoActions", len(repoActions), "packageSetActions", len(setActions))
+	return installerActions, repoActions, setActions, nil
+}
+
+// checkSystemRemovalDependencies validates that no remaining resource references
+// a system installer or repository that is being removed.
+func checkSystemRemovalDependencies(
+	installerActions []SystemInstallerAction,
+	repoActions []SystemPackageRepositoryAction,
+	remainingSets []*resource.SystemPackageSet,
+	remainingRepos []*resource.SystemPackageRepository,
+) error {
+	removingInstallers := make(map[string]bool)
+	for _, a := range installerActions {
+		if a.Type == resource.ActionRemove {
+			removingInstallers[a.Name] = true
+		}
+	}
+	removingRepos := make(map[string]bool)
+	for _, a := range repoActions {
+		if a.Type == resource.ActionRemove {
+			removingRepos[a.Name] = true
+		}
+	}
+
+	var blocked []string
+	for _, r := range remainingRepos {
+		if ref := r.SystemPackageRepositorySpec.InstallerRef; removingInstallers[ref] {
+			blocked = append(blocked, fmt.Sprintf("system package repository %q depends on system installer %q", r.Name(), ref))
+		}
+	}
+	for _, s := range remainingSets {
+		if ref := s.SystemPackageSetSpec.InstallerRef; removingInstallers[ref] {
+			blocked = append(blocked, fmt.Sprintf("system package set %q depends on system installer %q", s.Name(), ref))
+		}
+		if ref := s.SystemPackageSetSpec.RepositoryRef; ref != "" && removingRepos[ref] {
+			blocked = append(blocked, fmt.Sprintf("system package set %q depends on system package repository %q", s.Name(), ref))
+		}
+	}
+
+	if len(blocked) > 0 {
+		return fmt.Errorf("cannot remove system resource: dependent resources still in spec:\n  %s", strings.Join(blocked, "\n  "))
+	}
+	return nil
+}
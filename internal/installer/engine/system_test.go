@@ -0,0 +1,271 @@
+package engine
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/terassyi/tomei/internal/resource"
+	"github.com/terassyi/tomei/internal/state"
+)
+
+// mockSystemBackend records system operations in call order.
+type mockSystemBackend struct {
+	mu         sync.Mutex
+	calls      []string
+	registered []string
+}
+
+func (m *mockSystemBackend) record(call string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, call)
+}
+
+type mockSystemInstallerInstaller struct{ *mockSystemBackend }
+
+func (m mockSystemInstallerInstaller) Install(_ context.Context, res *resource.SystemInstaller, name string) (*resource.SystemInstallerState, error) {
+	m.record("install installer " + name)
+	spec := res.SystemInstallerSpec
+	return &resource.SystemInstallerState{Pattern: spec.Pattern, Privileged: spec.Privileged, Commands: spec.Commands, UpdatedAt: time.Now()}, nil
+}
+
+func (m mockSystemInstallerInstaller) Remove(_ context.Context, _ *resource.SystemInstallerState, name string) error {
+	m.record("remove installer " + name)
+	return nil
+}
+
+func (m mockSystemInstallerInstaller) RegisterInstaller(name string, _ *resource.SystemInstallerSpec) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.registered = append(m.registered, name)
+}
+
+type mockSystemRepoInstaller struct{ *mockSystemBackend }
+
+func (m mockSystemRepoInstaller) Install(_ context.Context, res *resource.SystemPackageRepository, name string) (*resource.SystemPackageRepositoryState, error) {
+	m.record("install repository " + name)
+	spec := res.SystemPackageRepositorySpec
+	return &resource.SystemPackageRepositoryState{InstallerRef: spec.InstallerRef, Source: spec.Source, UpdatedAt: time.Now()}, nil
+}
+
+func (m mockSystemRepoInstaller) Remove(_ context.Context, _ *resource.SystemPackageRepositoryState, name string) error {
+	m.record("remove repository " + name)
+	return nil
+}
+
+type mockSystemPackageSetInstaller struct{ *mockSystemBackend }
+
+func (m mockSystemPackageSetInstaller) Install(_ context.Context, res *resource.SystemPackageSet, name string) (*resource.SystemPackageSetState, error) {
+	spec := res.SystemPackageSetSpec
+	m.record("install packages " + name + " " + joinPackages(spec.Packages))
+	return &resource.SystemPackageSetState{InstallerRef: spec.InstallerRef, RepositoryRef: spec.RepositoryRef, Packages: spec.Packages, UpdatedAt: time.Now()}, nil
+}
+
+func (m mockSystemPackageSetInstaller) Remove(_ context.Context, st *resource.SystemPackageSetState, name string) error {
+	m.record("remove packages " + name + " " + joinPackages(st.Packages))
+	return nil
+}
+
+func joinPackages(pkgs []string) string {
+	s := slices.Clone(pkgs)
+	slices.Sort(s)
+	return strings.Join(s, ",")
+}
+
+func newTestSystemEngine(t *testing.T) (*SystemEngine, *mockSystemBackend, *state.Store[state.SystemState]) {
+	t.Helper()
+	store, err := state.NewStore[state.SystemState](t.TempDir())
+	require.NoError(t, err)
+	backend := &mockSystemBackend{}
+	eng := NewSystemEngine(
+		mockSystemInstallerInstaller{backend},
+		mockSystemRepoInstaller{backend},
+		mockSystemPackageSetInstaller{backend},
+		store,
+	)
+	return eng, backend, store
+}
+
+func systemTestResources(packages ...string) []resource.Resource {
+	return []resource.Resource{
+		&resource.SystemInstaller{
+			BaseResource: resource.BaseResource{
+				APIVersion:   resource.GroupVersion,
+				ResourceKind: resource.KindSystemInstaller,
+				Metadata:     resource.Metadata{Name: "apt"},
+			},
+			SystemInstallerSpec: &resource.SystemInstallerSpec{
+				Pattern:    resource.SystemInstallerPatternDelegation,
+				Privileged: true,
+				Commands: resource.SystemInstallerCommandsSpec{
+					Install: resource.CommandSpec{Command: "apt-get", Verb: "install -y"},
+					Remove:  resource.CommandSpec{Command: "apt-get", Verb: "remove -y"},
+				},
+			},
+		},
+		&resource.SystemPackageRepository{
+			BaseResource: resource.BaseResource{
+				APIVersion:   resource.GroupVersion,
+				ResourceKind: resource.KindSystemPackageRepository,
+				Metadata:     resource.Metadata{Name: "docker"},
+			},
+			SystemPackageRepositorySpec: &resource.SystemPackageRepositorySpec{
+				InstallerRef: "apt",
+				Source:       resource.SourceConfig{URL: "https://download.docker.com/linux/ubuntu"},
+			},
+		},
+		&resource.SystemPackageSet{
+			BaseResource: resource.BaseResource{
+				APIVersion:   resource.GroupVersion,
+				ResourceKind: resource.KindSystemPackageSet,
+				Metadata:     resource.Metadata{Name: "docker"},
+			},
+			SystemPackageSetSpec: &resource.SystemPackageSetSpec{
+				InstallerRef:  "apt",
+				RepositoryRef: "docker",
+				Packages:      packages,
+			},
+		},
+	}
+}
+
+func TestSystemEngine_Apply(t *testing.T) {
+	t.Parallel()
+
+	eng, backend, store := newTestSystemEngine(t)
+	ctx := context.Background()
+
+	var events []Event
+	eng.SetEventHandler(func(e Event) { events = append(events, e) })
+
+	require.NoError(t, eng.Apply(ctx, systemTestResources("docker-ce", "containerd.io")))
+	assert.Equal(t, []string{
+		"install installer apt",
+		"install repository docker",
+		"install packages docker containerd.io,docker-ce",
+	}, backend.calls)
+
+	st, err := store.LoadReadOnly()
+	require.NoError(t, err)
+	assert.Contains(t, st.SystemInstallers, "apt")
+	assert.Contains(t, st.SystemPackageRepositories, "docker")
+	require.Contains(t, st.SystemPackages, "docker")
+	assert.Equal(t, []string{"docker-ce", "containerd.io"}, st.SystemPackages["docker"].Packages)
+
+	var completed int
+	for _, e := range events {
+		if e.Type == EventComplete {
+			completed++
+		}
+	}
+	assert.Equal(t, 3, completed)
+
+	// Second apply is a no-op
+	backend.calls = nil
+	require.NoError(t, eng.Apply(ctx, systemTestResources("docker-ce", "containerd.io")))
+	assert.Empty(t, backend.calls)
+}
+
+func TestSystemEngine_ApplyRemovesDroppedPackages(t *testing.T) {
+	t.Parallel()
+
+	eng, backend, store := newTestSystemEngine(t)
+	ctx := context.Background()
+
+	require.NoError(t, eng.Apply(ctx, systemTestResources("docker-ce", "containerd.io")))
+	backend.calls = nil
+
+	require.NoError(t, eng.Apply(ctx, systemTestResources("docker-ce", "docker-compose-plugin")))
+	assert.Equal(t, []string{
+		"remove packages docker containerd.io",
+		"install packages docker docker-ce,docker-compose-plugin",
+	}, backend.calls)
+
+	st, err := store.LoadReadOnly()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"docker-ce", "docker-compose-plugin"}, st.SystemPackages["docker"].Packages)
+}
+
+func TestSystemEngine_ApplyRemovalOrder(t *testing.T) {
+	t.Parallel()
+
+	eng, backend, store := newTestSystemEngine(t)
+	ctx := context.Background()
+
+	require.NoError(t, eng.Apply(ctx, systemTestResources("docker-ce")))
+	backend.calls = nil
+
+	require.NoError(t, eng.Apply(ctx, nil))
+	assert.Equal(t, []string{
+		"remove packages docker docker-ce",
+		"remove repository docker",
+		"remove installer apt",
+	}, backend.calls)
+	// Installers in state are registered so dependents can still be removed
+	assert.Contains(t, backend.registered, "apt")
+
+	st, err := store.LoadReadOnly()
+	require.NoError(t, err)
+	assert.Empty(t, st.SystemInstallers)
+	assert.Empty(t, st.SystemPackageRepositories)
+	assert.Empty(t, st.SystemPackages)
+}
+
+func TestSystemEngine_RemovalBlockedByDependent(t *testing.T) {
+	t.Parallel()
+
+	eng, _, _ := newTestSystemEngine(t)
+	ctx := context.Background()
+
+	resources := systemTestResources("docker-ce")
+	require.NoError(t, eng.Apply(ctx, resources))
+
+	// Drop the installer but keep resources that reference it
+	_, _, _, err := eng.PlanAll(ctx, resources[1:])
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `depends on system installer "apt"`)
+}
+
+func TestSystemEngine_PlanAll(t *testing.T) {
+	t.Parallel()
+
+	eng, _, _ := newTestSystemEngine(t)
+	ctx := context.Background()
+
+	installerActions, repoActions, setActions, err := eng.PlanAll(ctx, systemTestResources("git"))
+	require.NoError(t, err)
+	require.Len(t, installerActions, 1)
+	require.Len(t, repoActions, 1)
+	require.Len(t, setActions, 1)
+	assert.Equal(t, resource.ActionInstall, setActions[0].Type)
+
+	require.NoError(t, eng.Apply(ctx, systemTestResources("git")))
+
+	_, _, setActions, err = eng.PlanAll(ctx, systemTestResources("git", "jq"))
+	require.NoError(t, err)
+	require.Len(t, setActions, 1)
+	assert.Equal(t, resource.ActionUpgrade, setActions[0].Type)
+	assert.Equal(t, "packages changed: +jq", setActions[0].Reason)
+}
+
+func TestSystemEngine_IgnoresUserResources(t *testing.T) {
+	t.Parallel()
+
+	eng, backend, _ := newTestSystemEngine(t)
+	tool := &resource.Tool{
+		BaseResource: resource.BaseResource{
+			APIVersion:   resource.GroupVersion,
+			ResourceKind: resource.KindTool,
+			Metadata:     resource.Metadata{Name: "rg"},
+		},
+		ToolSpec: &resource.ToolSpec{InstallerRef: "download", Version: "14.0.0"},
+	}
+	require.NoError(t, eng.Apply(context.Background(), []resource.Resource{tool}))
+	assert.Empty(t, backend.calls)
+}
@@ -3,6 +3,7 @@ package executor
 import (
 	"context"
 
+	"github.com/terassyi/tomei/internal/checksum"
 	"github.com/terassyi/tomei/internal/resource"
 )
 
@@ -35,3 +36,95 @@ func OldBinPathFromContext(ctx context.Context) string {
 	}
 	return ""
 }
+
+type contentDigestKey struct{}
+
+// ContentDigest is the digest recorded for the files installed at Path.
+type ContentDigest struct {
+	Path   string
+	Digest checksum.Digest
+}
+
+// WithContentDigest returns a context carrying the content digest recorded
+// for the installed files, so that installers can detect drifted files.
+func WithContentDigest(ctx context.Context, cd ContentDigest) context.Context {
+	return context.WithValue(ctx, contentDigestKey{}, cd)
+}
+
+// ContentDigestFromContext extracts the recorded content digest from context, or the zero value.
+func ContentDigestFromContext(ctx context.Context) ContentDigest {
+	if v, ok := ctx.Value(contentDigestKey{}).(ContentDigest); ok {
+		return v
+	}
+	return ContentDigest{}
+}
+
+type signatureKey struct{}
+
+// RecordedSignature is the signature outcome recorded for the binary installed at Path.
+type RecordedSignature struct {
+	Path      string
+	Signature *resource.SignatureState
+}
+
+// WithSignature returns a context carrying the signature outcome recorded for
+// the installed binary, so that installers keep it when the binary is not
+// downloaded again.
+func WithSignature(ctx context.Context, rs RecordedSignature) context.Context {
+	return context.WithValue(ctx, signatureKey{}, rs)
+}
+
+// SignatureFromContext extracts the recorded signature outcome from context, or the zero value.
+func SignatureFromContext(ctx context.Context) RecordedSignature {
+	if v, ok := ctx.Value(signatureKey{}).(RecordedSignature); ok {
+		return v
+	}
+	return RecordedSignature{}
+}
+
+type extraVersionsKey struct{}
+
+// WithExtraVersions returns a context carrying the extra runtime versions
+// recorded in state, so that runtime installers reuse or remove them.
+func WithExtraVersions(ctx context.Context, extras map[string]*resource.RuntimeVersionState) context.Context {
+	return context.WithValue(ctx, extraVersionsKey{}, extras)
+}
+
+// ExtraVersionsFromContext extracts the recorded extra runtime versions from context, or nil.
+func ExtraVersionsFromContext(ctx context.Context) map[string]*resource.RuntimeVersionState {
+	if v, ok := ctx.Value(extraVersionsKey{}).(map[string]*resource.RuntimeVersionState); ok {
+		return v
+	}
+	return nil
+}
+
+type ownedPackagesKey struct{}
+
+// WithOwnedPackages returns a context carrying the system packages recorded
+// as installed by tomei, so that package set installers keep owning them.
+func WithOwnedPackages(ctx context.Context, owned []string) context.Context {
+	return context.WithValue(ctx, ownedPackagesKey{}, owned)
+}
+
+// OwnedPackagesFromContext extracts the recorded owned packages from context, or nil.
+func OwnedPackagesFromContext(ctx context.Context) []string {
+	if v, ok := ctx.Value(ownedPackagesKey{}).([]string); ok {
+		return v
+	}
+	return nil
+}
+
+type batchInstalledKey struct{}
+
+// WithBatchInstalled returns a context marking the tool as already installed
+// by a batchInstall command, so that its installer records the state without
+// running the install command again.
+func WithBatchInstalled(ctx context.Context) context.Context {
+	return context.WithValue(ctx, batchInstalledKey{}, true)
+}
+
+// BatchInstalledFromContext reports whether the tool was installed by a batchInstall command.
+func BatchInstalledFromContext(ctx context.Context) bool {
+	v, _ := ctx.Value(batchInstalledKey{}).(bool)
+	return v
+}
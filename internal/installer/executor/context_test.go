@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/terassyi/tomei/internal/checksum"
 	"github.com/terassyi/tomei/internal/resource"
 )
 
@@ -60,3 +61,42 @@ func TestOldBinPathContext_Empty(t *testing.T) {
 	got := OldBinPathFromContext(context.Background())
 	assert.Empty(t, got)
 }
+
+func TestContentDigestContext(t *testing.T) {
+	t.Parallel()
+	cd := ContentDigest{Path: "/data/tools/rg/14.1.0/rg", Digest: checksum.Digest("abc123")}
+	ctx := WithContentDigest(context.Background(), cd)
+	assert.Equal(t, cd, ContentDigestFromContext(ctx))
+	assert.Empty(t, ContentDigestFromContext(context.Background()))
+}
+
+func TestSignatureContext(t *testing.T) {
+	t.Parallel()
+	rs := RecordedSignature{Path: "/data/tools/rg/14.1.0/rg", Signature: &resource.SignatureState{Outcome: resource.SignatureVerified}}
+	ctx := WithSignature(context.Background(), rs)
+	assert.Equal(t, rs, SignatureFromContext(ctx))
+	assert.Empty(t, SignatureFromContext(context.Background()))
+}
+
+func TestExtraVersionsContext(t *testing.T) {
+	t.Parallel()
+	extras := map[string]*resource.RuntimeVersionState{
+		"1.24.3": {InstallPath: "/data/runtimes/go/1.24.3"},
+	}
+	ctx := WithExtraVersions(context.Background(), extras)
+	assert.Equal(t, extras, ExtraVersionsFromContext(ctx))
+	assert.Nil(t, ExtraVersionsFromContext(context.Background()))
+}
+
+func TestOwnedPackagesContext(t *testing.T) {
+	t.Parallel()
+	owned := []string{"git", "make"}
+	assert.Equal(t, owned, OwnedPackagesFromContext(WithOwnedPackages(context.Background(), owned)))
+	assert.Nil(t, OwnedPackagesFromContext(context.Background()))
+}
+
+func TestBatchInstalledContext(t *testing.T) {
+	t.Parallel()
+	assert.True(t, BatchInstalledFromContext(WithBatchInstalled(context.Background())))
+	assert.False(t, BatchInstalledFromContext(context.Background()))
+}
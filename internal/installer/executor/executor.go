@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log/slog"
 
+	"github.com/terassyi/tomei/internal/checksum"
 	"github.com/terassyi/tomei/internal/installer/reconciler"
 	"github.com/terassyi/tomei/internal/resource"
 )
@@ -71,6 +72,37 @@ func (e *Executor[R, S]) install(ctx context.Context, action reconciler.Action[R
 				ctx = WithOldBinPath(ctx, oldPath)
 			}
 		}
+		// Pass the recorded content digest so that drifted files are restored.
+		if cd, ok := any(action.State).(interface {
+			GetContentDigest() (string, checksum.Digest)
+		}); ok {
+			if path, digest := cd.GetContentDigest(); path != "" && digest != "" {
+				ctx = WithContentDigest(ctx, ContentDigest{Path: path, Digest: digest})
+			}
+		}
+		// Pass the recorded signature outcome so that it is kept for a binary
+		// that is not downloaded again.
+		if sg, ok := any(action.State).(interface {
+			GetSignature() (string, *resource.SignatureState)
+		}); ok {
+			if path, sig := sg.GetSignature(); path != "" && sig != nil {
+				ctx = WithSignature(ctx, RecordedSignature{Path: path, Signature: sig})
+			}
+		}
+		// Pass the installed extra runtime versions so that they are reused or removed.
+		if ev, ok := any(action.State).(interface {
+			GetExtras() map[string]*resource.RuntimeVersionState
+		}); ok {
+			if extras := ev.GetExtras(); len(extras) > 0 {
+				ctx = WithExtraVersions(ctx, extras)
+			}
+		}
+		// Pass the packages installed by tomei so that they stay owned.
+		if ow, ok := any(action.State).(interface{ GetOwned() []string }); ok {
+			if owned := ow.GetOwned(); len(owned) > 0 {
+				ctx = WithOwnedPackages(ctx, owned)
+			}
+		}
 	}
 
 	// Install the resource
@@ -5,12 +5,12 @@ import (
 	"github.com/terassyi/tomei/internal/state"
 )
 
-// mapAccessor abstracts access to a specific map within UserState.
+// mapAccessor abstracts access to a specific map within UserState or SystemState.
 // Each implementation knows only about its own map field.
-type mapAccessor[S resource.State] interface {
-	get(st *state.UserState, name string) (S, bool)
-	set(st *state.UserState, name string, val S)
-	del(st *state.UserState, name string)
+type mapAccessor[T state.State, S resource.State] interface {
+	get(st *T, name string) (S, bool)
+	set(st *T, name string, val S)
+	del(st *T, name string)
 }
 
 // --- Tool ---
@@ -37,8 +37,8 @@ func (toolMapAccessor) del(st *state.UserState, name string) {
 }
 
 // NewToolStore creates a StateStore for tool state backed by the given cache.
-func NewToolStore(cache *StateCache) StateStore[*resource.ToolState] {
-	return &cachedStore[*resource.ToolState]{cache: cache, accessor: toolMapAccessor{}}
+func NewToolStore(cache *StateCache[state.UserState]) StateStore[*resource.ToolState] {
+	return &cachedStore[state.UserState, *resource.ToolState]{cache: cache, accessor: toolMapAccessor{}}
 }
 
 // --- Runtime ---
@@ -65,8 +65,8 @@ func (runtimeMapAccessor) del(st *state.UserState, name string) {
 }
 
 // NewRuntimeStore creates a StateStore for runtime state backed by the given cache.
-func NewRuntimeStore(cache *StateCache) StateStore[*resource.RuntimeState] {
-	return &cachedStore[*resource.RuntimeState]{cache: cache, accessor: runtimeMapAccessor{}}
+func NewRuntimeStore(cache *StateCache[state.UserState]) StateStore[*resource.RuntimeState] {
+	return &cachedStore[state.UserState, *resource.RuntimeState]{cache: cache, accessor: runtimeMapAccessor{}}
 }
 
 // --- InstallerRepository ---
@@ -93,6 +93,118 @@ func (repoMapAccessor) del(st *state.UserState, name string) {
 }
 
 // NewInstallerRepositoryStore creates a StateStore for installer repository state backed by the given cache.
-func NewInstallerRepositoryStore(cache *StateCache) StateStore[*resource.InstallerRepositoryState] {
-	return &cachedStore[*resource.InstallerRepositoryState]{cache: cache, accessor: repoMapAccessor{}}
+func NewInstallerRepositoryStore(cache *StateCache[state.UserState]) StateStore[*resource.InstallerRepositoryState] {
+	return &cachedStore[state.UserState, *resource.InstallerRepositoryState]{cache: cache, accessor: repoMapAccessor{}}
+}
+
+// --- Installer ---
+
+type installerMapAccessor struct{}
+
+func (installerMapAccessor) get(st *state.UserState, name string) (*resource.InstallerState, bool) {
+	if st.Installers == nil {
+		return nil, false
+	}
+	v, ok := st.Installers[name]
+	return v, ok
+}
+
+func (installerMapAccessor) set(st *state.UserState, name string, val *resource.InstallerState) {
+	if st.Installers == nil {
+		st.Installers = make(map[string]*resource.InstallerState)
+	}
+	st.Installers[name] = val
+}
+
+func (installerMapAccessor) del(st *state.UserState, name string) {
+	delete(st.Installers, name)
+}
+
+// NewInstallerStore creates a StateStore for installer state backed by the given cache.
+func NewInstallerStore(cache *StateCache[state.UserState]) StateStore[*resource.InstallerState] {
+	return &cachedStore[state.UserState, *resource.InstallerState]{cache: cache, accessor: installerMapAccessor{}}
+}
+
+// --- SystemInstaller ---
+
+type systemInstallerMapAccessor struct{}
+
+func (systemInstallerMapAccessor) get(st *state.SystemState, name string) (*resource.SystemInstallerState, bool) {
+	if st.SystemInstallers == nil {
+		return nil, false
+	}
+	v, ok := st.SystemInstallers[name]
+	return v, ok
+}
+
+func (systemInstallerMapAccessor) set(st *state.SystemState, name string, val *resource.SystemInstallerState) {
+	if st.SystemInstallers == nil {
+		st.SystemInstallers = make(map[string]*resource.SystemInstallerState)
+	}
+	st.SystemInstallers[name] = val
+}
+
+func (systemInstallerMapAccessor) del(st *state.SystemState, name string) {
+	delete(st.SystemInstallers, name)
+}
+
+// NewSystemInstallerStore creates a StateStore for system installer state backed by the given cache.
+func NewSystemInstallerStore(cache *StateCache[state.SystemState]) StateStore[*resource.SystemInstallerState] {
+	return &cachedStore[state.SystemState, *resource.SystemInstallerState]{cache: cache, accessor: systemInstallerMapAccessor{}}
+}
+
+// --- SystemPackageRepository ---
+
+type systemRepoMapAccessor struct{}
+
+func (systemRepoMapAccessor) get(st *state.SystemState, name string) (*resource.SystemPackageRepositoryState, bool) {
+	if st.SystemPackageRepositories == nil {
+		return nil, false
+	}
+	v, ok := st.SystemPackageRepositories[name]
+	return v, ok
+}
+
+func (systemRepoMapAccessor) set(st *state.SystemState, name string, val *resource.SystemPackageRepositoryState) {
+	if st.SystemPackageRepositories == nil {
+		st.SystemPackageRepositories = make(map[string]*resource.SystemPackageRepositoryState)
+	}
+	st.SystemPackageRepositories[name] = val
+}
+
+func (systemRepoMapAccessor) del(st *state.SystemState, name string) {
+	delete(st.SystemPackageRepositories, name)
+}
+
+// NewSystemPackageRepositoryStore creates a StateStore for system package repository state backed by the given cache.
+func NewSystemPackageRepositoryStore(cache *StateCache[state.SystemState]) StateStore[*resource.SystemPackageRepositoryState] {
+	return &cachedStore[state.SystemState, *resource.SystemPackageRepositoryState]{cache: cache, accessor: systemRepoMapAccessor{}}
+}
+
+// --- SystemPackageSet ---
+
+type systemPackageSetMapAccessor struct{}
+
+func (systemPackageSetMapAccessor) get(st *state.SystemState, name string) (*resource.SystemPackageSetState, bool) {
+	if st.SystemPackages == nil {
+		return nil, false
+	}
+	v, ok := st.SystemPackages[name]
+	return v, ok
+}
+
+func (systemPackageSetMapAccessor) set(st *state.SystemState, name string, val *resource.SystemPackageSetState) {
+	if st.SystemPackages == nil {
+		st.SystemPackages = make(map[string]*resource.SystemPackageSetState)
+	}
+	st.SystemPackages[name] = val
+}
+
+func (systemPackageSetMapAccessor) del(st *state.SystemState, name string) {
+	delete(st.SystemPackages, name)
+}
+
+// NewSystemPackageSetStore creates a StateStore for system package set state backed by the given cache.
+func NewSystemPackageSetStore(cache *StateCache[state.SystemState]) StateStore[*resource.SystemPackageSetState] {
+	return &cachedStore[state.SystemState, *resource.SystemPackageSetState]{cache: cache, accessor: systemPackageSetMapAccessor{}}
 }
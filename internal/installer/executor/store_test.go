@@ -15,7 +15,7 @@ import (
 )
 
 // newStateCache creates a StateCache with Lock already acquired and Init called.
-func newStateCache(t *testing.T) *StateCache {
+func newStateCache(t *testing.T) *StateCache[state.UserState] {
 	t.Helper()
 	dir := t.TempDir()
 	store, err := state.NewStore[state.UserState](dir)
@@ -28,7 +28,7 @@ func newStateCache(t *testing.T) *StateCache {
 }
 
 // newStateCacheRapid creates a StateCache for use inside rapid.Check.
-func newStateCacheRapid(t *rapid.T) *StateCache {
+func newStateCacheRapid(t *rapid.T) *StateCache[state.UserState] {
 	dir, err := os.MkdirTemp("", "store-test-*")
 	if err != nil {
 		t.Fatal(err)
@@ -236,6 +236,38 @@ func TestRuntimeStore_Delete(t *testing.T) {
 	assert.False(t, exists)
 }
 
+func TestSystemStores_SaveFlushAndDelete(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	store, err := state.NewStore[state.SystemState](dir)
+	require.NoError(t, err)
+	require.NoError(t, store.Lock())
+	defer func() { _ = store.Unlock() }()
+
+	sc := NewStateCache(store)
+	sc.Init(state.NewSystemState())
+
+	is := NewSystemInstallerStore(sc)
+	rs := NewSystemPackageRepositoryStore(sc)
+	ps := NewSystemPackageSetStore(sc)
+
+	require.NoError(t, is.Save("apt", &resource.SystemInstallerState{Pattern: "delegation"}))
+	require.NoError(t, rs.Save("docker", &resource.SystemPackageRepositoryState{InstallerRef: "apt"}))
+	require.NoError(t, ps.Save("base", &resource.SystemPackageSetState{InstallerRef: "apt", Packages: []string{"curl"}}))
+	require.NoError(t, sc.Flush())
+
+	diskState, err := store.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "delegation", diskState.SystemInstallers["apt"].Pattern)
+	assert.Equal(t, "apt", diskState.SystemPackageRepositories["docker"].InstallerRef)
+	assert.Equal(t, []string{"curl"}, diskState.SystemPackages["base"].Packages)
+
+	require.NoError(t, ps.Delete("base"))
+	_, exists, err := ps.Load("base")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
 // --- Concurrency Integration Tests ---
 
 func TestToolStore_ConcurrentSave(t *testing.T) {
@@ -504,6 +536,30 @@ func TestInstallerRepositoryStore_LoadNotFound(t *testing.T) {
 	assert.False(t, exists)
 }
 
+// --- Installer Store Tests ---
+
+func TestInstallerStore_SaveLoadDelete(t *testing.T) {
+	t.Parallel()
+	sc := newStateCache(t)
+	is := NewInstallerStore(sc)
+
+	require.NoError(t, is.Save("brew", &resource.InstallerState{
+		BinDir:    "/opt/homebrew/bin",
+		Bootstrap: &resource.BootstrapSpec{Install: []string{"install.sh"}, Remove: []string{"uninstall.sh"}},
+	}))
+
+	loaded, exists, err := is.Load("brew")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, "/opt/homebrew/bin", loaded.BinDir)
+	assert.Equal(t, []string{"uninstall.sh"}, loaded.Bootstrap.Remove)
+
+	require.NoError(t, is.Delete("brew"))
+	_, exists, err = is.Load("brew")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
 func TestInstallerRepositoryStore_ConcurrentSave(t *testing.T) {
 	t.Parallel()
 	sc := newStateCache(t)
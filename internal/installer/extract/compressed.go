@@ -0,0 +1,80 @@
+package extract
+
+import (
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// decompressor returns a reader of the decompressed content of r.
+type decompressor func(r io.Reader) (io.ReadCloser, error)
+
+func gzipReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+func xzReader(r io.Reader) (io.ReadCloser, error) {
+	xr, err := xz.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(xr), nil
+}
+
+func bzip2Reader(r io.Reader) (io.ReadCloser, error) {
+	return io.NopCloser(bzip2.NewReader(r)), nil
+}
+
+func zstdReader(r io.Reader) (io.ReadCloser, error) {
+	zr, err := zstd.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return zr.IOReadCloser(), nil
+}
+
+// compressedTarExtractor implements Extractor for tar archives compressed
+// with bzip2 or zstd.
+type compressedTarExtractor struct {
+	format     ArchiveType
+	decompress decompressor
+}
+
+// Extract extracts a compressed tar archive from the reader to the destination directory.
+func (e *compressedTarExtractor) Extract(r io.Reader, destDir string) error {
+	slog.Debug("extracting compressed tar archive", "format", e.format, "dest", destDir)
+
+	dr, err := e.decompress(r)
+	if err != nil {
+		return fmt.Errorf("failed to create %s reader: %w", e.format, err)
+	}
+	defer dr.Close()
+
+	return extractTar(dr, destDir)
+}
+
+// compressedFileExtractor implements Extractor for a single compressed binary
+// (e.g., tool_linux_amd64.gz). Like a raw binary, it is named after the base
+// name of destDir (the tool name).
+type compressedFileExtractor struct {
+	format     ArchiveType
+	decompress decompressor
+}
+
+// Extract decompresses the binary from the reader into the destination directory.
+func (e *compressedFileExtractor) Extract(r io.Reader, destDir string) error {
+	slog.Debug("extracting compressed binary", "format", e.format, "dest", destDir)
+
+	dr, err := e.decompress(r)
+	if err != nil {
+		return fmt.Errorf("failed to create %s reader: %w", e.format, err)
+	}
+	defer dr.Close()
+
+	return (&rawExtractor{}).Extract(dr, destDir)
+}
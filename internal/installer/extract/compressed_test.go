@@ -0,0 +1,485 @@
+package extract
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ulikunitz/xz"
+)
+
+// The standard library has no bzip2 writer, so bzip2 test data is fixed.
+const (
+	// tarBz2Fixture is a tar.bz2 archive holding bin/tool ("tool binary content", mode 0755).
+	tarBz2Fixture = "QlpoOTFBWSZTWV7p+MgAAG/bgMqAwAD+gABAeiWeIAgIIABUMpqAA0PUZD1PSCSUNGQBkBoH28ChCDupCEYTzeU2vrQIYGIezxcJzCNUIRY8LzGFtx3yXMVTDBgyFrAwfF4fUo+xJA/F3JFOFCQXun4yAA=="
+	// bz2Fixture is "binary content here" compressed with bzip2.
+	bz2Fixture = "QlpoOTFBWSZTWZtFCDwAAAGRgEAAOmGUICAAIoxMNqEDQNAMqCMe1IMLi0XckU4UJCbRQg8A"
+)
+
+func TestArchiveType_IsSingleFile(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		archiveType ArchiveType
+		want        bool
+	}{
+		{archiveType: ArchiveTypeRaw, want: true},
+		{archiveType: ArchiveTypeGz, want: true},
+		{archiveType: ArchiveTypeXz, want: true},
+		{archiveType: ArchiveTypeZst, want: true},
+		{archiveType: ArchiveTypeBz2, want: true},
+		{archiveType: ArchiveTypeTarGz, want: false},
+		{archiveType: ArchiveTypeTarBz2, want: false},
+		{archiveType: ArchiveTypeTarZst, want: false},
+		{archiveType: ArchiveTypeZip, want: false},
+		{archiveType: ArchiveType7z, want: false},
+		{archiveType: ArchiveTypePkg, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.archiveType), func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, tt.archiveType.IsSingleFile())
+		})
+	}
+}
+
+func TestExtractor_Extract_CompressedTar(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		archiveType ArchiveType
+		createData  func(t *testing.T) io.Reader
+		wantErr     bool
+	}{
+		{
+			name:        "tar.zst",
+			archiveType: ArchiveTypeTarZst,
+			createData: func(t *testing.T) io.Reader {
+				return zstdCompress(t, createTarWithExecutable(t))
+			},
+		},
+		{
+			name:        "tar.bz2",
+			archiveType: ArchiveTypeTarBz2,
+			createData: func(t *testing.T) io.Reader {
+				return decodeFixture(t, tarBz2Fixture)
+			},
+		},
+		{
+			name:        "invalid zstd stream",
+			archiveType: ArchiveTypeTarZst,
+			createData: func(t *testing.T) io.Reader {
+				return bytes.NewReader([]byte("not a valid zstd"))
+			},
+			wantErr: true,
+		},
+		{
+			name:        "invalid bzip2 stream",
+			archiveType: ArchiveTypeTarBz2,
+			createData: func(t *testing.T) io.Reader {
+				return bytes.NewReader([]byte("not a valid bzip2"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			destDir := filepath.Join(t.TempDir(), "dest")
+
+			extractor, err := NewExtractor(tt.archiveType)
+			require.NoError(t, err)
+
+			err = extractor.Extract(tt.createData(t), destDir)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			toolPath := filepath.Join(destDir, "bin", "tool")
+			content, err := os.ReadFile(toolPath)
+			require.NoError(t, err)
+			assert.Equal(t, "tool binary content", string(content))
+
+			info, err := os.Stat(toolPath)
+			require.NoError(t, err)
+			assert.NotEqual(t, fs.FileMode(0), info.Mode()&0111, "expected executable permission")
+		})
+	}
+}
+
+func TestExtractor_TarZst_LinkEscape(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "escape", Typeflag: tar.TypeSymlink, Linkname: "../../../etc/passwd"}))
+	require.NoError(t, tw.Close())
+
+	extractor, err := NewExtractor(ArchiveTypeTarZst)
+	require.NoError(t, err)
+
+	err = extractor.Extract(zstdCompress(t, buf.Bytes()), filepath.Join(t.TempDir(), "dest"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid symlink target")
+}
+
+func TestExtractor_Extract_CompressedFile(t *testing.T) {
+	t.Parallel()
+	const content = "binary content here"
+	tests := []struct {
+		name        string
+		archiveType ArchiveType
+		createData  func(t *testing.T) io.Reader
+	}{
+		{
+			name:        "gz",
+			archiveType: ArchiveTypeGz,
+			createData: func(t *testing.T) io.Reader {
+				var buf bytes.Buffer
+				gw := gzip.NewWriter(&buf)
+				_, err := gw.Write([]byte(content))
+				require.NoError(t, err)
+				require.NoError(t, gw.Close())
+				return &buf
+			},
+		},
+		{
+			name:        "xz",
+			archiveType: ArchiveTypeXz,
+			createData: func(t *testing.T) io.Reader {
+				var buf bytes.Buffer
+				xw, err := xz.NewWriter(&buf)
+				require.NoError(t, err)
+				_, err = xw.Write([]byte(content))
+				require.NoError(t, err)
+				require.NoError(t, xw.Close())
+				return &buf
+			},
+		},
+		{
+			name:        "zst",
+			archiveType: ArchiveTypeZst,
+			createData: func(t *testing.T) io.Reader {
+				return zstdCompress(t, []byte(content))
+			},
+		},
+		{
+			name:        "bz2",
+			archiveType: ArchiveTypeBz2,
+			createData: func(t *testing.T) io.Reader {
+				return decodeFixture(t, bz2Fixture)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			// The binary is named after the final component of destDir
+			destDir := filepath.Join(t.TempDir(), "mytool")
+
+			extractor, err := NewExtractor(tt.archiveType)
+			require.NoError(t, err)
+			require.NoError(t, extractor.Extract(tt.createData(t), destDir))
+
+			binaryPath := filepath.Join(destDir, "mytool")
+			got, err := os.ReadFile(binaryPath)
+			require.NoError(t, err)
+			assert.Equal(t, content, string(got))
+
+			info, err := os.Stat(binaryPath)
+			require.NoError(t, err)
+			assert.NotEqual(t, fs.FileMode(0), info.Mode()&0111, "expected executable permission")
+		})
+	}
+}
+
+func TestExtractor_CompressedFile_InvalidStream(t *testing.T) {
+	t.Parallel()
+
+	extractor, err := NewExtractor(ArchiveTypeGz)
+	require.NoError(t, err)
+
+	err = extractor.Extract(bytes.NewReader([]byte("not a valid gzip")), filepath.Join(t.TempDir(), "tool"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "gz reader")
+}
+
+func TestSevenZipExtractor_RequiresOSFile(t *testing.T) {
+	t.Parallel()
+
+	extractor, err := NewExtractor(ArchiveType7z)
+	require.NoError(t, err)
+
+	// Pass a non-*os.File reader — should fail with a clear error
+	err = extractor.Extract(bytes.NewReader([]byte("dummy")), t.TempDir())
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "*os.File")
+}
+
+// sevenZipListing returns a "7z l -slt" listing of the given entries, each
+// holding the Path and Attributes lines of an entry.
+func sevenZipListing(entries ...[2]string) string {
+	var b strings.Builder
+	b.WriteString("7-Zip (z) 24.08 (x64) : Copyright (c) 1999-2024 Igor Pavlov : 2024-08-11\n\n")
+	b.WriteString("Listing archive: tool.7z\n\n--\nPath = tool.7z\nType = 7z\nPhysical Size = 1234\n\n----------\n")
+	for _, e := range entries {
+		fmt.Fprintf(&b, "Path = %s\nSize = 19\nAttributes = %s\nCRC = \nEncrypted = -\n\n", e[0], e[1])
+	}
+	return b.String()
+}
+
+// Listings of the same archive as printed by "l -slt" of 7-Zip 23.01 (7zz)
+// and p7zip 16.02 (7z and 7za).
+const (
+	sevenZipListing7zz = `
+7-Zip (z) 23.01 (x64) : Copyright (c) 1999-2023 Igor Pavlov : 2023-06-20
+ 64-bit locale=C.UTF-8 Threads:8 OPEN_MAX:1024
+
+Scanning the drive for archives:
+1 file, 412 bytes (1 KiB)
+
+Listing archive: tool.7z
+
+--
+Path = tool.7z
+Type = 7z
+Physical Size = 412
+Headers Size = 230
+Method = LZMA2:12
+Solid = +
+Blocks = 1
+
+----------
+Path = bin
+Size = 0
+Packed Size = 0
+Modified = 2024-05-01 12:00:00.0000000
+Attributes = D drwxr-xr-x
+CRC = 
+Encrypted = -
+Method = 
+Block = 
+
+Path = bin/tool
+Size = 19
+Packed Size = 182
+Modified = 2024-05-01 12:00:00.0000000
+Attributes = A -rwxr-xr-x
+CRC = 3A4C1B2F
+Encrypted = -
+Method = LZMA2:12
+Block = 0
+
+`
+	sevenZipListing7z = `
+7-Zip [64] 16.02 : Copyright (c) 1999-2016 Igor Pavlov : 2016-05-21
+p7zip Version 16.02 (locale=C.UTF-8,Utf16=on,HugeFiles=on,64 bits,8 CPUs x64)
+
+Scanning the drive for archives:
+1 file, 412 bytes (1 KiB)
+
+Listing archive: tool.7z
+
+--
+Path = tool.7z
+Type = 7z
+Physical Size = 412
+Headers Size = 230
+Method = LZMA2:12
+Solid = +
+Blocks = 1
+
+----------
+Path = bin/tool
+Size = 19
+Packed Size = 182
+Modified = 2024-05-01 12:00:00
+Attributes = A_ -rwxr-xr-x
+CRC = 3A4C1B2F
+Encrypted = -
+Method = LZMA2:12
+Block = 0
+
+Path = bin
+Size = 0
+Packed Size = 0
+Modified = 2024-05-01 12:00:00
+Attributes = D_ drwxr-xr-x
+CRC = 
+Encrypted = -
+Method = 
+Block = 
+
+`
+)
+
+func TestCheckSevenZipListing(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		listing string
+		wantErr string
+	}{
+		{
+			name:    "regular files and directories",
+			listing: sevenZipListing([2]string{"bin", "D_ drwxr-xr-x"}, [2]string{"bin/tool", "A_ -rwxr-xr-x"}, [2]string{`doc\README`, "A"}),
+		},
+		{
+			name:    "7zz listing",
+			listing: sevenZipListing7zz,
+		},
+		{
+			name:    "7z listing",
+			listing: sevenZipListing7z,
+		},
+		{
+			name:    "7za listing",
+			listing: strings.Replace(sevenZipListing7z, "7-Zip [64] 16.02", "7-Zip (a) [64] 16.02", 1),
+		},
+		{
+			name:    "7zz symlink",
+			listing: strings.Replace(sevenZipListing7zz, "A -rwxr-xr-x", "A lrwxrwxrwx", 1),
+			wantErr: "links are not supported in 7z archives: bin/tool",
+		},
+		{
+			name:    "7z symlink",
+			listing: strings.Replace(sevenZipListing7z, "A_ -rwxr-xr-x", "A_ lrwxrwxrwx", 1),
+			wantErr: "links are not supported in 7z archives: bin/tool",
+		},
+		{
+			name:    "entry without a path",
+			listing: strings.Replace(sevenZipListing7zz, "Path = bin/tool\n", "", 1),
+			wantErr: "entry without a path",
+		},
+		{
+			name:    "last entry without a path",
+			listing: sevenZipListing([2]string{"bin", "D_ drwxr-xr-x"}) + "Size = 19\nAttributes = A_ lrwxrwxrwx\n",
+			wantErr: "entry without a path",
+		},
+		{
+			name:    "parent directory element",
+			listing: sevenZipListing([2]string{"bin/../../evil", "A_ -rw-r--r--"}),
+			wantErr: "invalid file path in 7z archive: bin/../../evil",
+		},
+		{
+			name:    "windows parent directory element",
+			listing: sevenZipListing([2]string{`..\evil`, "A"}),
+			wantErr: "invalid file path in 7z archive",
+		},
+		{
+			name:    "absolute path",
+			listing: sevenZipListing([2]string{"/etc/passwd", "A_ -rw-r--r--"}),
+			wantErr: "invalid file path in 7z archive: /etc/passwd",
+		},
+		{
+			name:    "drive letter",
+			listing: sevenZipListing([2]string{`C:\evil`, "A"}),
+			wantErr: "invalid file path in 7z archive",
+		},
+		{
+			name:    "unix symlink",
+			listing: sevenZipListing([2]string{"bin/tool", "A_ lrwxrwxrwx"}),
+			wantErr: "links are not supported in 7z archives: bin/tool",
+		},
+		{
+			name:    "windows reparse point",
+			listing: sevenZipListing([2]string{"bin/tool", "AL"}),
+			wantErr: "links are not supported in 7z archives: bin/tool",
+		},
+		{
+			name:    "link target property",
+			listing: sevenZipListing([2]string{"bin/tool", "A"}) + "Path = bin/link\nSymbolic Link = /etc/passwd\n",
+			wantErr: "links are not supported in 7z archives: bin/link -> /etc/passwd",
+		},
+		{
+			name:    "no entries",
+			listing: "7-Zip (z) 24.08 (x64)\n\nERROR: tool.7z\n",
+			wantErr: "no entries found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := checkSevenZipListing(strings.NewReader(tt.listing))
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestSevenZipExtractor_ChecksBeforeExtracting(t *testing.T) {
+	// The fake 7-Zip command is found through PATH
+	binDir := t.TempDir()
+	marker := filepath.Join(t.TempDir(), "extracted")
+	listing := sevenZipListing([2]string{"../../evil", "A_ -rw-r--r--"})
+	script := fmt.Sprintf("#!/bin/sh\nif [ \"$1\" = l ]; then\ncat <<'EOF'\n%sEOF\nexit 0\nfi\ntouch %s\n", listing, marker)
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "7zz"), []byte(script), 0755))
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	archive := filepath.Join(t.TempDir(), "tool.7z")
+	require.NoError(t, os.WriteFile(archive, []byte("7z"), 0644))
+	f, err := os.Open(archive)
+	require.NoError(t, err)
+	defer f.Close()
+
+	extractor, err := NewExtractor(ArchiveType7z)
+	require.NoError(t, err)
+	err = ExtractContext(context.Background(), extractor, f, t.TempDir())
+	require.ErrorContains(t, err, "invalid file path in 7z archive: ../../evil")
+	assert.NoFileExists(t, marker, "nothing is extracted from a rejected archive")
+}
+
+func createTarWithExecutable(t *testing.T) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	content := []byte("tool binary content")
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "bin/tool", Mode: 0755, Size: int64(len(content))}))
+	_, err := tw.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	return buf.Bytes()
+}
+
+func zstdCompress(t *testing.T, data []byte) io.Reader {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw, err := zstd.NewWriter(&buf)
+	require.NoError(t, err)
+	_, err = zw.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return &buf
+}
+
+func decodeFixture(t *testing.T, s string) io.Reader {
+	t.Helper()
+
+	data, err := base64.StdEncoding.DecodeString(s)
+	require.NoError(t, err)
+	return bytes.NewReader(data)
+}
@@ -4,6 +4,7 @@ import (
 	"archive/tar"
 	"archive/zip"
 	"compress/gzip"
+	"context"
 	"fmt"
 	"io"
 	"log/slog"
@@ -34,10 +35,45 @@ const (
 	// ArchiveTypePkg represents a macOS flat package (.pkg).
 	// Extracted using pkgutil --expand-full (available on macOS without sudo).
 	ArchiveTypePkg ArchiveType = "pkg"
+
+	// ArchiveTypeTarBz2 represents a bzip2-compressed tar archive (.tar.bz2, .tbz2).
+	ArchiveTypeTarBz2 ArchiveType = "tar.bz2"
+
+	// ArchiveTypeTarZst represents a zstd-compressed tar archive (.tar.zst, .tzst).
+	ArchiveTypeTarZst ArchiveType = "tar.zst"
+
+	// ArchiveTypeGz represents a single gzip-compressed binary (e.g., tool_linux_amd64.gz).
+	ArchiveTypeGz ArchiveType = "gz"
+
+	// ArchiveTypeXz represents a single xz-compressed binary.
+	ArchiveTypeXz ArchiveType = "xz"
+
+	// ArchiveTypeZst represents a single zstd-compressed binary.
+	ArchiveTypeZst ArchiveType = "zst"
+
+	// ArchiveTypeBz2 represents a single bzip2-compressed binary.
+	ArchiveTypeBz2 ArchiveType = "bz2"
+
+	// ArchiveType7z represents a 7-Zip archive (.7z).
+	// Extracted using the 7-Zip command (7zz, 7z or 7za), which must be in PATH.
+	ArchiveType7z ArchiveType = "7z"
 )
 
+// IsSingleFile reports whether the archive type holds a single binary rather
+// than a directory tree: a raw binary or a compressed binary. Such binaries are
+// extracted under the name of the destination directory (the tool name).
+func (t ArchiveType) IsSingleFile() bool {
+	switch t {
+	case ArchiveTypeRaw, ArchiveTypeGz, ArchiveTypeXz, ArchiveTypeZst, ArchiveTypeBz2:
+		return true
+	default:
+		return false
+	}
+}
+
 // NormalizeArchiveType normalizes an archive type string to a canonical ArchiveType constant.
-// It handles common aliases (e.g., "tgz" → ArchiveTypeTarGz, "txz" → ArchiveTypeTarXz).
+// It handles common aliases, including the format values of aqua-registry
+// (e.g., "tgz" → ArchiveTypeTarGz, "tbz2" → ArchiveTypeTarBz2, "zstd" → ArchiveTypeZst).
 // Unrecognized values are passed through as-is (NewExtractor will reject them).
 func NormalizeArchiveType(raw string) ArchiveType {
 	switch strings.ToLower(raw) {
@@ -51,6 +87,20 @@ func NormalizeArchiveType(raw string) ArchiveType {
 		return ArchiveTypeRaw
 	case "pkg":
 		return ArchiveTypePkg
+	case "tar.bz2", "tbz2", "tbz":
+		return ArchiveTypeTarBz2
+	case "tar.zst", "tar.zstd", "tzst":
+		return ArchiveTypeTarZst
+	case "gz", "gzip":
+		return ArchiveTypeGz
+	case "xz":
+		return ArchiveTypeXz
+	case "zst", "zstd":
+		return ArchiveTypeZst
+	case "bz2", "bzip2":
+		return ArchiveTypeBz2
+	case "7z":
+		return ArchiveType7z
 	default:
 		return ArchiveType(raw)
 	}
@@ -68,12 +118,35 @@ func DetectArchiveType(urlOrFilename string) ArchiveType {
 	if hasSuffix(lower, ".tar.xz") || hasSuffix(lower, ".txz") {
 		return ArchiveTypeTarXz
 	}
+	if hasSuffix(lower, ".tar.bz2") || hasSuffix(lower, ".tbz2") || hasSuffix(lower, ".tbz") {
+		return ArchiveTypeTarBz2
+	}
+	if hasSuffix(lower, ".tar.zst") || hasSuffix(lower, ".tzst") {
+		return ArchiveTypeTarZst
+	}
 	if hasSuffix(lower, ".zip") {
 		return ArchiveTypeZip
 	}
 	if hasSuffix(lower, ".pkg") {
 		return ArchiveTypePkg
 	}
+	if hasSuffix(lower, ".7z") {
+		return ArchiveType7z
+	}
+
+	// Single compressed binaries
+	if hasSuffix(lower, ".gz") {
+		return ArchiveTypeGz
+	}
+	if hasSuffix(lower, ".xz") {
+		return ArchiveTypeXz
+	}
+	if hasSuffix(lower, ".zst") {
+		return ArchiveTypeZst
+	}
+	if hasSuffix(lower, ".bz2") {
+		return ArchiveTypeBz2
+	}
 	return ""
 }
 
@@ -93,6 +166,21 @@ type Extractor interface {
 	Extract(r io.Reader, destDir string) error
 }
 
+// ContextExtractor is implemented by extractors that run external commands,
+// which are stopped when the context is canceled.
+type ContextExtractor interface {
+	ExtractContext(ctx context.Context, r io.Reader, destDir string) error
+}
+
+// ExtractContext extracts an archive with e, passing ctx to extractors that
+// implement ContextExtractor.
+func ExtractContext(ctx context.Context, e Extractor, r io.Reader, destDir string) error {
+	if ce, ok := e.(ContextExtractor); ok {
+		return ce.ExtractContext(ctx, r, destDir)
+	}
+	return e.Extract(r, destDir)
+}
+
 // NewExtractor creates an Extractor for the given archive type.
 func NewExtractor(archiveType ArchiveType) (Extractor, error) {
 	switch archiveType {
@@ -106,6 +194,20 @@ func NewExtractor(archiveType ArchiveType) (Extractor, error) {
 		return &rawExtractor{}, nil
 	case ArchiveTypePkg:
 		return &pkgExtractor{}, nil
+	case ArchiveTypeTarBz2:
+		return &compressedTarExtractor{format: archiveType, decompress: bzip2Reader}, nil
+	case ArchiveTypeTarZst:
+		return &compressedTarExtractor{format: archiveType, decompress: zstdReader}, nil
+	case ArchiveTypeGz:
+		return &compressedFileExtractor{format: archiveType, decompress: gzipReader}, nil
+	case ArchiveTypeXz:
+		return &compressedFileExtractor{format: archiveType, decompress: xzReader}, nil
+	case ArchiveTypeZst:
+		return &compressedFileExtractor{format: archiveType, decompress: zstdReader}, nil
+	case ArchiveTypeBz2:
+		return &compressedFileExtractor{format: archiveType, decompress: bzip2Reader}, nil
+	case ArchiveType7z:
+		return &sevenZipExtractor{}, nil
 	default:
 		return nil, fmt.Errorf("unsupported archive type: %s", archiveType)
 	}
@@ -117,6 +219,9 @@ var (
 	_ Extractor = (*zipExtractor)(nil)
 	_ Extractor = (*rawExtractor)(nil)
 	_ Extractor = (*pkgExtractor)(nil)
+	_ Extractor = (*compressedTarExtractor)(nil)
+	_ Extractor = (*compressedFileExtractor)(nil)
+	_ Extractor = (*sevenZipExtractor)(nil)
 )
 
 // tarGzExtractor implements Extractor for tar.gz archives.
@@ -31,6 +31,16 @@ func TestNormalizeArchiveType(t *testing.T) {
 		{name: "zip", input: "zip", want: ArchiveTypeZip},
 		{name: "raw", input: "raw", want: ArchiveTypeRaw},
 		{name: "pkg", input: "pkg", want: ArchiveTypePkg},
+		{name: "tar.bz2", input: "tar.bz2", want: ArchiveTypeTarBz2},
+		{name: "tbz2", input: "tbz2", want: ArchiveTypeTarBz2},
+		{name: "tar.zst", input: "tar.zst", want: ArchiveTypeTarZst},
+		{name: "tzst", input: "tzst", want: ArchiveTypeTarZst},
+		{name: "gz", input: "gz", want: ArchiveTypeGz},
+		{name: "xz", input: "xz", want: ArchiveTypeXz},
+		{name: "zst", input: "zst", want: ArchiveTypeZst},
+		{name: "zstd", input: "zstd", want: ArchiveTypeZst},
+		{name: "bz2", input: "bz2", want: ArchiveTypeBz2},
+		{name: "7z", input: "7z", want: ArchiveType7z},
 		{name: "unknown", input: "unknown", want: ArchiveType("unknown")},
 		{name: "empty", input: "", want: ArchiveType("")},
 	}
@@ -95,6 +105,46 @@ func TestDetectArchiveType(t *testing.T) {
 			input:    "https://github.com/gohugoio/hugo/releases/download/v0.153.0/hugo_0.153.0_darwin-universal.pkg",
 			expected: ArchiveTypePkg,
 		},
+		{
+			name:     "tar.bz2 extension",
+			input:    "https://example.com/tool-1.0.0.tar.bz2",
+			expected: ArchiveTypeTarBz2,
+		},
+		{
+			name:     "tbz2 extension",
+			input:    "https://example.com/tool.tbz2",
+			expected: ArchiveTypeTarBz2,
+		},
+		{
+			name:     "tar.zst extension",
+			input:    "https://example.com/tool-1.0.0.tar.zst",
+			expected: ArchiveTypeTarZst,
+		},
+		{
+			name:     "7z extension",
+			input:    "https://example.com/tool_windows_amd64.7z",
+			expected: ArchiveType7z,
+		},
+		{
+			name:     "single gz binary",
+			input:    "https://github.com/example/releases/download/v1.0.0/tool_linux_amd64.gz",
+			expected: ArchiveTypeGz,
+		},
+		{
+			name:     "single xz binary",
+			input:    "https://example.com/tool_linux_amd64.xz",
+			expected: ArchiveTypeXz,
+		},
+		{
+			name:     "single zst binary",
+			input:    "https://example.com/tool_linux_amd64.zst",
+			expected: ArchiveTypeZst,
+		},
+		{
+			name:     "single bz2 binary",
+			input:    "https://example.com/tool_linux_amd64.bz2",
+			expected: ArchiveTypeBz2,
+		},
 		{
 			name:     "unknown extension",
 			input:    "https://example.com/tool.exe",
@@ -78,7 +78,7 @@ func validateExtractedPaths(destDir string) error {
 			}
 
 			if !isInsideDir(absDestDir, resolved) {
-				return fmt.Errorf("invalid symlink target in pkg: %s -> %s (escapes %s)", path, linkTarget, absDestDir)
+				return fmt.Errorf("invalid symlink target in archive: %s -> %s (escapes %s)", path, linkTarget, absDestDir)
 			}
 		}
 
@@ -57,7 +57,7 @@ func TestValidateExtractedPaths(t *testing.T) {
 				require.NoError(t, os.Symlink("../../etc/passwd", filepath.Join(dir, "escape")))
 			},
 			wantErr:    true,
-			errContain: "invalid symlink target in pkg",
+			errContain: "invalid symlink target in archive",
 		},
 		{
 			name: "deep relative symlink escaping destDir",
@@ -67,7 +67,7 @@ func TestValidateExtractedPaths(t *testing.T) {
 				require.NoError(t, os.Symlink("../../../../../../../../etc/passwd", filepath.Join(sub, "escape")))
 			},
 			wantErr:    true,
-			errContain: "invalid symlink target in pkg",
+			errContain: "invalid symlink target in archive",
 		},
 		{
 			name: "no symlinks at all",
@@ -0,0 +1,191 @@
+package extract
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// sevenZipCommands are the names of the 7-Zip command, in order of preference:
+// 7zz (7-Zip), 7z and 7za (p7zip).
+var sevenZipCommands = []string{"7zz", "7z", "7za"}
+
+// sevenZipExtractor implements Extractor for 7-Zip archives (.7z).
+// It runs the 7-Zip command found in PATH.
+type sevenZipExtractor struct{}
+
+// Extract extracts a 7z archive to the destination directory.
+func (e *sevenZipExtractor) Extract(r io.Reader, destDir string) error {
+	return e.ExtractContext(context.Background(), r, destDir)
+}
+
+// ExtractContext extracts a 7z archive to the destination directory, killing
+// the 7-Zip command when ctx is canceled. The reader must be an *os.File
+// since 7-Zip operates on file paths.
+//
+// 7-Zip writes entries as they are stored, so the archive is listed first and
+// rejected if an entry would be written outside destDir or is a link.
+func (e *sevenZipExtractor) ExtractContext(ctx context.Context, r io.Reader, destDir string) error {
+	slog.Debug("extracting 7z archive", "dest", destDir)
+
+	f, ok := r.(*os.File)
+	if !ok {
+		return fmt.Errorf("7z extraction requires *os.File, got %T", r)
+	}
+
+	bin, err := lookPathAny(sevenZipCommands)
+	if err != nil {
+		return fmt.Errorf("7-Zip not found (7z extraction requires one of %v in PATH): %w", sevenZipCommands, err)
+	}
+
+	// l: list, -slt: one "key = value" line per property
+	listing, err := runSevenZip(ctx, bin, "l", "-slt", f.Name())
+	if err != nil {
+		return fmt.Errorf("failed to list 7z archive %s: %w", f.Name(), err)
+	}
+	if err := checkSevenZipListing(bytes.NewReader(listing)); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	// x: extract with full paths, -y: assume yes, -bd: no progress indicator
+	if _, err := runSevenZip(ctx, bin, "x", "-y", "-bd", "-o"+destDir, f.Name()); err != nil {
+		return fmt.Errorf("failed to extract 7z archive %s: %w", f.Name(), err)
+	}
+
+	// Security: verify all extracted symlink targets are inside destDir
+	if err := validateExtractedPaths(destDir); err != nil {
+		return err
+	}
+
+	slog.Debug("7z archive extracted", "dest", destDir)
+	return nil
+}
+
+// runSevenZip runs the 7-Zip command and returns its stdout.
+func runSevenZip(ctx context.Context, bin string, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, bin, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%w (stderr: %s)", err, stderr.String())
+	}
+	return stdout.Bytes(), nil
+}
+
+// checkSevenZipListing checks the entries of a "7z l -slt" listing. Entries
+// with an absolute path or a ".." element would be written outside the
+// destination directory, and links could point anywhere, so both are rejected
+// before anything is extracted. Path is the first property of every entry;
+// an entry without one cannot be checked and is rejected as well.
+func checkSevenZipListing(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	inEntries := false
+	// hasPath is set once the Path of the current entry is read. Entries are
+	// separated by blank lines.
+	hasPath := false
+	var path string
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		// The properties of the archive itself precede the separator line
+		if !inEntries {
+			inEntries = strings.HasPrefix(line, "----------")
+			continue
+		}
+		if line == "" {
+			hasPath, path = false, ""
+			continue
+		}
+		key, value, ok := strings.Cut(line, " = ")
+		if !ok {
+			key, value = strings.TrimSuffix(line, " ="), ""
+		}
+		if key != "Path" && !hasPath {
+			return fmt.Errorf("unexpected 7z listing: entry without a path (%s)", line)
+		}
+		switch key {
+		case "Path":
+			if hasPath {
+				return fmt.Errorf("unexpected 7z listing: entry with several paths: %s, %s", path, value)
+			}
+			hasPath = true
+			path = value
+			if !isSafeArchivePath(path) {
+				return fmt.Errorf("invalid file path in 7z archive: %s", path)
+			}
+		case "Symbolic Link", "Hard Link", "Link":
+			if value != "" {
+				return fmt.Errorf("links are not supported in 7z archives: %s -> %s", path, value)
+			}
+		case "Attributes":
+			if isLinkAttributes(value) {
+				return fmt.Errorf("links are not supported in 7z archives: %s", path)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read 7z listing: %w", err)
+	}
+	if !inEntries {
+		return fmt.Errorf("unexpected 7z listing: no entries found")
+	}
+	return nil
+}
+
+// isSafeArchivePath reports whether an archive entry path stays inside the
+// extraction directory. 7-Zip accepts both / and \ as separators.
+func isSafeArchivePath(p string) bool {
+	if p == "" || strings.HasPrefix(p, "/") || strings.HasPrefix(p, `\`) || (len(p) >= 2 && p[1] == ':') {
+		return false
+	}
+	for _, elem := range strings.FieldsFunc(p, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if elem == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// sevenZipWinAttribChars are the letters 7-Zip uses for Windows file
+// attributes; L marks a reparse point (a symbolic link or junction).
+const sevenZipWinAttribChars = "RHS8DAdNTsLCOIEV_"
+
+// isLinkAttributes reports whether the Attributes of a listed entry describe a
+// link: a Unix mode starting with "l" (e.g. "A_ lrwxrwxrwx") or a Windows
+// reparse point (e.g. "AL").
+func isLinkAttributes(attrs string) bool {
+	for _, field := range strings.Fields(attrs) {
+		if len(field) == 10 && field[0] == 'l' {
+			return true
+		}
+		if strings.Contains(field, "L") && strings.Trim(field, sevenZipWinAttribChars) == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// lookPathAny returns the path of the first of names found in PATH.
+func lookPathAny(names []string) (string, error) {
+	var firstErr error
+	for _, name := range names {
+		path, err := exec.LookPath(name)
+		if err == nil {
+			return path, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return "", firstErr
+}
@@ -1,5 +1,12 @@
 package installer
 
+import (
+	"context"
+	"fmt"
+
+	"github.com/terassyi/tomei/internal/checksum"
+)
+
 // InstallOption configures the installation.
 type InstallOption func(*InstallConfig)
 
@@ -8,6 +15,14 @@ type InstallConfig struct {
 	BinaryName    string // Binary name for placement and symlink (defaults to tool name)
 	SrcBinaryName string // Binary name to search in archive (e.g., krew-linux_arm64); empty = BinaryName
 	Force         bool   // Replace existing binary even if hash differs
+
+	// VerifyArchive additionally verifies the downloaded archive after its
+	// checksum and before extraction (optional).
+	VerifyArchive func(ctx context.Context, archivePath string) error
+
+	// VerifyInstalled verifies a binary that is already installed and is
+	// used without downloading it again, before it is linked (optional).
+	VerifyInstalled func(ctx context.Context, binaryPath string) error
 }
 
 // WithBinaryName sets the binary name to look for in the archive.
@@ -23,3 +38,46 @@ func WithForce(force bool) InstallOption {
 		c.Force = force
 	}
 }
+
+// Pin is a resolved version, download URL and archive digest recorded in
+// tomei.lock. Installers use it instead of resolving "latest" or alias
+// versions again.
+type Pin struct {
+	Version string          // Resolved version (e.g., "2.62.0")
+	URL     string          // Resolved download URL; empty when unknown
+	Digest  checksum.Digest // Archive digest; empty when unknown
+}
+
+// DigestFor returns the pinned digest when the pin applies to version.
+func (p Pin) DigestFor(version string) checksum.Digest {
+	if p.Version != version {
+		return ""
+	}
+	return p.Digest
+}
+
+// VerifyDigest checks the archive downloaded for version against the pinned
+// digest. It returns the archive digest, which is the pinned one when the pin
+// applies to version and a freshly calculated sha256 otherwise.
+func (p Pin) VerifyDigest(archivePath, version string) (checksum.Digest, error) {
+	if pinned := p.DigestFor(version); pinned != "" {
+		if err := checksum.Verify(archivePath, checksum.DetectAlgorithm(string(pinned)), pinned); err != nil {
+			return "", fmt.Errorf("archive does not match digest pinned in lockfile: %w", err)
+		}
+		return pinned, nil
+	}
+	digest, err := checksum.Calculate(archivePath, checksum.AlgorithmSHA256)
+	if err != nil {
+		return "", fmt.Errorf("failed to calculate archive digest: %w", err)
+	}
+	return digest, nil
+}
+
+// CheckURL returns an error if the pin applies to version and its URL
+// differs from the resolved download URL.
+func (p Pin) CheckURL(version, url string) error {
+	if p.Version != version || p.URL == "" || p.URL == url {
+		return nil
+	}
+	return fmt.Errorf("download URL %s does not match URL pinned in lockfile: %s", url, p.URL)
+}
@@ -0,0 +1,68 @@
+package installer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/terassyi/tomei/internal/checksum"
+)
+
+func TestPin_VerifyDigest(t *testing.T) {
+	t.Parallel()
+	archivePath := filepath.Join(t.TempDir(), "tool.tar.gz")
+	require.NoError(t, os.WriteFile(archivePath, []byte("archive"), 0644))
+	sum := sha256.Sum256([]byte("archive"))
+	archiveHash := checksum.Digest(hex.EncodeToString(sum[:]))
+
+	tests := []struct {
+		name       string
+		pin        Pin
+		version    string
+		wantDigest checksum.Digest
+		wantErr    bool
+	}{
+		{
+			name:       "no pin calculates sha256",
+			version:    "1.0.0",
+			wantDigest: archiveHash,
+		},
+		{
+			name:       "matching pin",
+			pin:        Pin{Version: "1.0.0", Digest: archiveHash},
+			version:    "1.0.0",
+			wantDigest: archiveHash,
+		},
+		{
+			name:    "mismatching pin",
+			pin:     Pin{Version: "1.0.0", Digest: checksum.Digest(strings.Repeat("0", 64))},
+			version: "1.0.0",
+			wantErr: true,
+		},
+		{
+			name:       "pin for another version is ignored",
+			pin:        Pin{Version: "0.9.0", Digest: checksum.Digest(strings.Repeat("0", 64))},
+			version:    "1.0.0",
+			wantDigest: archiveHash,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := tt.pin.VerifyDigest(archivePath, tt.version)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "does not match digest pinned in lockfile")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantDigest, got)
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package reconciler
+
+import (
+	"slices"
+
+	"github.com/terassyi/tomei/internal/path"
+	"github.com/terassyi/tomei/internal/resource"
+)
+
+// InstallerComparator returns a comparator for Installer resources.
+// Only fields that are persisted in InstallerState are compared;
+// delegation commands are re-registered on every apply.
+func InstallerComparator() Comparator[*resource.Installer, *resource.InstallerState] {
+	return func(res *resource.Installer, state *resource.InstallerState) (bool, string) {
+		spec := res.InstallerSpec
+		if spec.ToolRef != state.ToolRef {
+			return true, "toolRef changed: " + state.ToolRef + " -> " + spec.ToolRef
+		}
+		binDir := spec.BinDir
+		if expanded, err := path.Expand(binDir); err == nil {
+			binDir = expanded
+		}
+		if binDir != state.BinDir {
+			return true, "binDir changed: " + state.BinDir + " -> " + binDir
+		}
+		if !bootstrapEqual(spec.Bootstrap, state.Bootstrap) {
+			return true, "bootstrap commands changed"
+		}
+		return false, ""
+	}
+}
+
+// bootstrapEqual reports whether two bootstrap specs have the same commands.
+func bootstrapEqual(a, b *resource.BootstrapSpec) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return slices.Equal(a.Install, b.Install) &&
+		slices.Equal(a.Check, b.Check) &&
+		slices.Equal(a.Remove, b.Remove)
+}
+
+// NewInstallerReconciler creates a new Reconciler for Installer resources.
+func NewInstallerReconciler() *Reconciler[*resource.Installer, *resource.InstallerState] {
+	return New(InstallerComparator())
+}
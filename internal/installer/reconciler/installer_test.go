@@ -0,0 +1,111 @@
+package reconciler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/terassyi/tomei/internal/resource"
+)
+
+func newTestInstaller(name string, spec *resource.InstallerSpec) *resource.Installer {
+	return &resource.Installer{
+		BaseResource: resource.BaseResource{
+			APIVersion:   "tomei.terassyi.net/v1beta1",
+			ResourceKind: resource.KindInstaller,
+			Metadata:     resource.Metadata{Name: name},
+		},
+		InstallerSpec: spec,
+	}
+}
+
+func TestInstallerReconciler(t *testing.T) {
+	t.Parallel()
+
+	bootstrap := &resource.BootstrapSpec{
+		Install: []string{"install.sh"},
+		Check:   []string{"command -v brew"},
+		Remove:  []string{"uninstall.sh"},
+	}
+
+	tests := []struct {
+		name       string
+		spec       *resource.InstallerSpec
+		state      *resource.InstallerState
+		wantType   resource.ActionType
+		wantReason string
+	}{
+		{
+			name:     "new installer",
+			spec:     &resource.InstallerSpec{Type: resource.InstallTypeDelegation, Bootstrap: bootstrap},
+			wantType: resource.ActionInstall,
+		},
+		{
+			name:     "no change",
+			spec:     &resource.InstallerSpec{Type: resource.InstallTypeDelegation, Bootstrap: bootstrap, BinDir: "/opt/homebrew/bin"},
+			state:    &resource.InstallerState{Bootstrap: bootstrap, BinDir: "/opt/homebrew/bin"},
+			wantType: resource.ActionNone,
+		},
+		{
+			name:     "state without bootstrap from older versions",
+			spec:     &resource.InstallerSpec{Type: resource.InstallTypeDelegation, ToolRef: "cargo-binstall"},
+			state:    &resource.InstallerState{ToolRef: "cargo-binstall"},
+			wantType: resource.ActionNone,
+		},
+		{
+			name: "bootstrap changed",
+			spec: &resource.InstallerSpec{Type: resource.InstallTypeDelegation, Bootstrap: &resource.BootstrapSpec{
+				Install: []string{"install-v2.sh"},
+				Check:   bootstrap.Check,
+				Remove:  bootstrap.Remove,
+			}},
+			state:      &resource.InstallerState{Bootstrap: bootstrap},
+			wantType:   resource.ActionUpgrade,
+			wantReason: "bootstrap commands changed",
+		},
+		{
+			name:       "bootstrap added",
+			spec:       &resource.InstallerSpec{Type: resource.InstallTypeDelegation, Bootstrap: bootstrap},
+			state:      &resource.InstallerState{},
+			wantType:   resource.ActionUpgrade,
+			wantReason: "bootstrap commands changed",
+		},
+		{
+			name:       "toolRef changed",
+			spec:       &resource.InstallerSpec{Type: resource.InstallTypeDelegation, ToolRef: "b"},
+			state:      &resource.InstallerState{ToolRef: "a"},
+			wantType:   resource.ActionUpgrade,
+			wantReason: "toolRef changed: a -> b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			states := map[string]*resource.InstallerState{}
+			if tt.state != nil {
+				states["brew"] = tt.state
+			}
+			actions := NewInstallerReconciler().Reconcile([]*resource.Installer{newTestInstaller("brew", tt.spec)}, states)
+			if tt.wantType == resource.ActionNone {
+				assert.Empty(t, actions)
+				return
+			}
+			require.Len(t, actions, 1)
+			assert.Equal(t, tt.wantType, actions[0].Type)
+			if tt.wantReason != "" {
+				assert.Equal(t, tt.wantReason, actions[0].Reason)
+			}
+		})
+	}
+}
+
+func TestInstallerReconciler_Remove(t *testing.T) {
+	t.Parallel()
+
+	states := map[string]*resource.InstallerState{"brew": {}}
+	actions := NewInstallerReconciler().Reconcile(nil, states)
+	require.Len(t, actions, 1)
+	assert.Equal(t, resource.ActionRemove, actions[0].Type)
+	assert.Equal(t, "brew", actions[0].Name)
+}
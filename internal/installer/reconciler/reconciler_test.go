@@ -689,6 +689,67 @@ func TestRuntimeComparator_VersionKind(t *testing.T) {
 	}
 }
 
+func TestRuntimeComparator_ExtraVersions(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		spec       []string
+		installed  []string
+		wantUpdate bool
+		wantReason string
+	}{
+		{
+			name:       "same versions in a different order - no change",
+			spec:       []string{"1.24.3", "1.23.8"},
+			installed:  []string{"1.23.8", "1.24.3"},
+			wantUpdate: false,
+		},
+		{
+			name:       "version added",
+			spec:       []string{"1.23.8", "1.24.3"},
+			installed:  []string{"1.23.8"},
+			wantUpdate: true,
+			wantReason: "extra versions changed: [1.23.8] -> [1.23.8, 1.24.3]",
+		},
+		{
+			name:       "all versions removed",
+			installed:  []string{"1.23.8"},
+			wantUpdate: true,
+			wantReason: "extra versions changed: [1.23.8] -> []",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			res := &resource.Runtime{
+				BaseResource: resource.BaseResource{
+					Metadata: resource.Metadata{Name: "go"},
+				},
+				RuntimeSpec: &resource.RuntimeSpec{
+					Version:       "1.25.6",
+					ExtraVersions: tt.spec,
+				},
+			}
+			state := &resource.RuntimeState{
+				Version:     "1.25.6",
+				VersionKind: resource.VersionExact,
+				SpecVersion: "1.25.6",
+			}
+			for _, v := range tt.installed {
+				if state.Extras == nil {
+					state.Extras = make(map[string]*resource.RuntimeVersionState)
+				}
+				state.Extras[v] = &resource.RuntimeVersionState{}
+			}
+
+			needsUpdate, reason := RuntimeComparator()(res, state)
+			assert.Equal(t, tt.wantUpdate, needsUpdate)
+			assert.Equal(t, tt.wantReason, reason)
+		})
+	}
+}
+
 // --- Property tests for specVersionChanged ---
 
 // Property: VersionExact with same spec and state version → always false
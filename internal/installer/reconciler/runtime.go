@@ -1,6 +1,9 @@
 package reconciler
 
 import (
+	"slices"
+	"strings"
+
 	"github.com/terassyi/tomei/internal/resource"
 )
 
@@ -10,6 +13,9 @@ func RuntimeComparator() Comparator[*resource.Runtime, *resource.RuntimeState] {
 		if specVersionChanged(res.RuntimeSpec.Version, state.VersionKind, state.Version, state.SpecVersion) {
 			return true, "version changed: " + state.Version + " -> " + res.RuntimeSpec.Version
 		}
+		if want := slices.Sorted(slices.Values(res.RuntimeSpec.ExtraVersions)); !slices.Equal(want, state.ExtraVersions()) {
+			return true, "extra versions changed: [" + strings.Join(state.ExtraVersions(), ", ") + "] -> [" + strings.Join(want, ", ") + "]"
+		}
 		if state.IsTainted() {
 			return true, "tainted: " + string(state.TaintReason)
 		}
@@ -0,0 +1,104 @@
+package reconciler
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/terassyi/tomei/internal/resource"
+)
+
+// SystemInstallerComparator returns a comparator for SystemInstaller resources.
+func SystemInstallerComparator() Comparator[*resource.SystemInstaller, *resource.SystemInstallerState] {
+	return func(res *resource.SystemInstaller, state *resource.SystemInstallerState) (bool, string) {
+		spec := res.SystemInstallerSpec
+		if spec.Pattern != state.Pattern {
+			return true, "pattern changed: " + state.Pattern + " -> " + spec.Pattern
+		}
+		if spec.Privileged != state.Privileged {
+			return true, "privileged changed"
+		}
+		if spec.Commands != state.Commands {
+			return true, "commands changed"
+		}
+		return false, ""
+	}
+}
+
+// NewSystemInstallerReconciler creates a new Reconciler for SystemInstaller resources.
+func NewSystemInstallerReconciler() *Reconciler[*resource.SystemInstaller, *resource.SystemInstallerState] {
+	return New(SystemInstallerComparator())
+}
+
+// SystemPackageRepositoryComparator returns a comparator for SystemPackageRepository resources.
+func SystemPackageRepositoryComparator() Comparator[*resource.SystemPackageRepository, *resource.SystemPackageRepositoryState] {
+	return func(res *resource.SystemPackageRepository, state *resource.SystemPackageRepositoryState) (bool, string) {
+		spec := res.SystemPackageRepositorySpec
+		if spec.InstallerRef != state.InstallerRef {
+			return true, "installerRef changed: " + state.InstallerRef + " -> " + spec.InstallerRef
+		}
+		if spec.Source.URL != state.Source.URL {
+			return true, "source URL changed: " + state.Source.URL + " -> " + spec.Source.URL
+		}
+		if spec.Source.KeyURL != state.Source.KeyURL || spec.Source.KeyHash != state.Source.KeyHash {
+			return true, "signing key changed"
+		}
+		if !maps.Equal(spec.Source.Options, state.Source.Options) {
+			return true, "source options changed"
+		}
+		return false, ""
+	}
+}
+
+// NewSystemPackageRepositoryReconciler creates a new Reconciler for SystemPackageRepository resources.
+func NewSystemPackageRepositoryReconciler() *Reconciler[*resource.SystemPackageRepository, *resource.SystemPackageRepositoryState] {
+	return New(SystemPackageRepositoryComparator())
+}
+
+// SystemPackageSetComparator returns a comparator for SystemPackageSet resources.
+func SystemPackageSetComparator() Comparator[*resource.SystemPackageSet, *resource.SystemPackageSetState] {
+	return func(res *resource.SystemPackageSet, state *resource.SystemPackageSetState) (bool, string) {
+		spec := res.SystemPackageSetSpec
+		if spec.InstallerRef != state.InstallerRef {
+			return true, "installerRef changed: " + state.InstallerRef + " -> " + spec.InstallerRef
+		}
+		if spec.RepositoryRef != state.RepositoryRef {
+			return true, "repositoryRef changed: " + state.RepositoryRef + " -> " + spec.RepositoryRef
+		}
+		added, removed := DiffPackages(state.Packages, spec.Packages)
+		if len(added) == 0 && len(removed) == 0 {
+			return false, ""
+		}
+		var parts []string
+		for _, p := range added {
+			parts = append(parts, "+"+p)
+		}
+		for _, p := range removed {
+			parts = append(parts, "-"+p)
+		}
+		return true, "packages changed: " + strings.Join(parts, " ")
+	}
+}
+
+// NewSystemPackageSetReconciler creates a new Reconciler for SystemPackageSet resources.
+func NewSystemPackageSetReconciler() *Reconciler[*resource.SystemPackageSet, *resource.SystemPackageSetState] {
+	return New(SystemPackageSetComparator())
+}
+
+// DiffPackages returns the packages present only in desired (added) and
+// only in current (removed), each sorted. Order within the lists is ignored.
+func DiffPackages(current, desired []string) (added, removed []string) {
+	for _, p := range desired {
+		if !slices.Contains(current, p) && !slices.Contains(added, p) {
+			added = append(added, p)
+		}
+	}
+	for _, p := range current {
+		if !slices.Contains(desired, p) && !slices.Contains(removed, p) {
+			removed = append(removed, p)
+		}
+	}
+	slices.Sort(added)
+	slices.Sort(removed)
+	return added, removed
+}
@@ -0,0 +1,183 @@
+package reconciler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/terassyi/tomei/internal/resource"
+)
+
+func newTestSystemInstaller(name string) *resource.SystemInstaller {
+	return &resource.SystemInstaller{
+		BaseResource: resource.BaseResource{
+			APIVersion:   "tomei.terassyi.net/v1beta1",
+			ResourceKind: resource.KindSystemInstaller,
+			Metadata:     resource.Metadata{Name: name},
+		},
+		SystemInstallerSpec: &resource.SystemInstallerSpec{
+			Pattern:    resource.SystemInstallerPatternDelegation,
+			Privileged: true,
+			Commands: resource.SystemInstallerCommandsSpec{
+				Install: resource.CommandSpec{Command: "apt-get", Verb: "install -y"},
+				Remove:  resource.CommandSpec{Command: "apt-get", Verb: "remove -y"},
+			},
+		},
+	}
+}
+
+func newTestSystemPackageSet(name string, packages ...string) *resource.SystemPackageSet {
+	return &resource.SystemPackageSet{
+		BaseResource: resource.BaseResource{
+			APIVersion:   "tomei.terassyi.net/v1beta1",
+			ResourceKind: resource.KindSystemPackageSet,
+			Metadata:     resource.Metadata{Name: name},
+		},
+		SystemPackageSetSpec: &resource.SystemPackageSetSpec{
+			InstallerRef: "apt",
+			Packages:     packages,
+		},
+	}
+}
+
+func TestSystemInstallerReconciler(t *testing.T) {
+	t.Parallel()
+
+	inst := newTestSystemInstaller("apt")
+	unchanged := &resource.SystemInstallerState{
+		Pattern:    inst.SystemInstallerSpec.Pattern,
+		Privileged: true,
+		Commands:   inst.SystemInstallerSpec.Commands,
+	}
+	changed := *unchanged
+	changed.Commands.Install.Verb = "install"
+
+	tests := []struct {
+		name       string
+		states     map[string]*resource.SystemInstallerState
+		wantAction resource.ActionType
+		wantReason string
+	}{
+		{
+			name:       "install when not in state",
+			states:     map[string]*resource.SystemInstallerState{},
+			wantAction: resource.ActionInstall,
+		},
+		{
+			name:   "no change",
+			states: map[string]*resource.SystemInstallerState{"apt": unchanged},
+		},
+		{
+			name:       "upgrade when commands changed",
+			states:     map[string]*resource.SystemInstallerState{"apt": &changed},
+			wantAction: resource.ActionUpgrade,
+			wantReason: "commands changed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			actions := NewSystemInstallerReconciler().Reconcile([]*resource.SystemInstaller{inst}, tt.states)
+			if tt.wantAction == "" {
+				assert.Empty(t, actions)
+				return
+			}
+			require.Len(t, actions, 1)
+			assert.Equal(t, tt.wantAction, actions[0].Type)
+			if tt.wantReason != "" {
+				assert.Equal(t, tt.wantReason, actions[0].Reason)
+			}
+		})
+	}
+}
+
+func TestSystemPackageRepositoryReconciler_KeyChange(t *testing.T) {
+	t.Parallel()
+
+	repo := &resource.SystemPackageRepository{
+		BaseResource: resource.BaseResource{
+			APIVersion:   "tomei.terassyi.net/v1beta1",
+			ResourceKind: resource.KindSystemPackageRepository,
+			Metadata:     resource.Metadata{Name: "docker"},
+		},
+		SystemPackageRepositorySpec: &resource.SystemPackageRepositorySpec{
+			InstallerRef: "apt",
+			Source: resource.SourceConfig{
+				URL:     "https://download.docker.com/linux/ubuntu",
+				KeyURL:  "https://download.docker.com/linux/ubuntu/gpg",
+				KeyHash: "sha256:new",
+			},
+		},
+	}
+	states := map[string]*resource.SystemPackageRepositoryState{
+		"docker": {
+			InstallerRef: "apt",
+			Source: resource.SourceConfig{
+				URL:     "https://download.docker.com/linux/ubuntu",
+				KeyURL:  "https://download.docker.com/linux/ubuntu/gpg",
+				KeyHash: "sha256:old",
+			},
+		},
+	}
+
+	actions := NewSystemPackageRepositoryReconciler().Reconcile([]*resource.SystemPackageRepository{repo}, states)
+	require.Len(t, actions, 1)
+	assert.Equal(t, resource.ActionUpgrade, actions[0].Type)
+	assert.Equal(t, "signing key changed", actions[0].Reason)
+}
+
+func TestSystemPackageSetReconciler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		packages   []string
+		state      []string
+		wantAction resource.ActionType
+		wantReason string
+	}{
+		{
+			name:     "same packages in different order",
+			packages: []string{"curl", "git"},
+			state:    []string{"git", "curl"},
+		},
+		{
+			name:       "packages added and removed",
+			packages:   []string{"curl", "jq"},
+			state:      []string{"curl", "git"},
+			wantAction: resource.ActionUpgrade,
+			wantReason: "packages changed: +jq -git",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			set := newTestSystemPackageSet("base", tt.packages...)
+			states := map[string]*resource.SystemPackageSetState{
+				"base": {InstallerRef: "apt", Packages: tt.state},
+			}
+			actions := NewSystemPackageSetReconciler().Reconcile([]*resource.SystemPackageSet{set}, states)
+			if tt.wantAction == "" {
+				assert.Empty(t, actions)
+				return
+			}
+			require.Len(t, actions, 1)
+			assert.Equal(t, tt.wantAction, actions[0].Type)
+			assert.Equal(t, tt.wantReason, actions[0].Reason)
+		})
+	}
+}
+
+func TestDiffPackages(t *testing.T) {
+	t.Parallel()
+
+	added, removed := DiffPackages([]string{"b", "a", "c"}, []string{"d", "a", "b", "d"})
+	assert.Equal(t, []string{"d"}, added)
+	assert.Equal(t, []string{"c"}, removed)
+
+	added, removed = DiffPackages(nil, nil)
+	assert.Empty(t, added)
+	assert.Empty(t, removed)
+}
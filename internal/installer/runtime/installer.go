@@ -13,11 +13,14 @@ import (
 	"time"
 
 	"github.com/terassyi/tomei/internal/checksum"
+	"github.com/terassyi/tomei/internal/credential"
+	"github.com/terassyi/tomei/internal/installer"
 	"github.com/terassyi/tomei/internal/installer/command"
 	"github.com/terassyi/tomei/internal/installer/download"
 	"github.com/terassyi/tomei/internal/installer/executor"
 	"github.com/terassyi/tomei/internal/installer/extract"
 	"github.com/terassyi/tomei/internal/installer/resolve"
+	"github.com/terassyi/tomei/internal/mirror"
 	"github.com/terassyi/tomei/internal/path"
 	"github.com/terassyi/tomei/internal/resource"
 )
@@ -38,7 +41,10 @@ type Installer struct {
 	httpClient       *http.Client
 	resolver         *resolve.Resolver
 	runtimesDir      string
+	pins             map[string]installer.Pin // runtime name -> version/digest pinned by tomei.lock
 	progressCallback download.ProgressCallback
+	offline          bool           // reject delegation runtimes (apply --bundle)
+	credentials      credential.Set // credentials referenced by credentialRef
 }
 
 // NewInstaller creates a new runtime Installer.
@@ -71,6 +77,34 @@ func (i *Installer) SetHTTPClient(client *http.Client) {
 	i.resolver = resolve.NewResolver(i.cmdExecutor, client)
 }
 
+// SetPins sets the versions and digests pinned by tomei.lock, keyed by runtime name.
+// Runtimes with non-exact versions install the pinned version instead of
+// resolving it, and downloaded archives are verified against the pinned digest.
+func (i *Installer) SetPins(pins map[string]installer.Pin) {
+	i.pins = pins
+}
+
+// SetOffline enables offline mode (apply --bundle). Delegation runtimes run
+// their own bootstrap commands against the network, so they fail with an
+// explicit error instead of being attempted.
+func (i *Installer) SetOffline(offline bool) {
+	i.offline = offline
+}
+
+// SetCredentials sets the credentials that runtimes reference via credentialRef.
+func (i *Installer) SetCredentials(creds credential.Set) {
+	i.credentials = creds
+}
+
+// CredentialContext returns ctx carrying the credential referenced by the runtime.
+func (i *Installer) CredentialContext(ctx context.Context, res *resource.Runtime) (context.Context, error) {
+	ctx, err := i.credentials.Context(ctx, res.RuntimeSpec.CredentialRef)
+	if err != nil {
+		return nil, fmt.Errorf("runtime %s: %w", res.Name(), err)
+	}
+	return ctx, nil
+}
+
 // Resolver returns the shared version resolver.
 func (i *Installer) Resolver() *resolve.Resolver {
 	return i.resolver
@@ -85,23 +119,39 @@ func (i *Installer) Install(ctx context.Context, res *resource.Runtime, name str
 
 	switch spec.Type {
 	case resource.InstallTypeDownload:
+		ctx, err := i.CredentialContext(ctx, res)
+		if err != nil {
+			return nil, err
+		}
 		return i.installDownload(ctx, spec, name)
 	case resource.InstallTypeDelegation:
+		if i.offline {
+			return nil, fmt.Errorf("runtime %s is installed by delegation, which is not supported in offline mode", name)
+		}
 		return i.installDelegation(ctx, spec, name)
 	default:
 		return nil, fmt.Errorf("unsupported type: %s", spec.Type)
 	}
 }
 
-// installDownload installs a runtime using the download pattern.
-func (i *Installer) installDownload(ctx context.Context, spec *resource.RuntimeSpec, name string) (*resource.RuntimeState, error) {
+// ResolvedSource is the download source of a runtime with its version resolved.
+type ResolvedSource struct {
+	Version     string
+	VersionKind resource.VersionKind
+	URL         string
+	Checksum    *resource.Checksum
+}
+
+// ResolveSource resolves the version of a download-pattern runtime (honoring
+// pins) and expands it into the source and checksum URLs.
+func (i *Installer) ResolveSource(ctx context.Context, spec *resource.RuntimeSpec, name string) (*ResolvedSource, error) {
 	// Validate spec
 	if spec.Source == nil || spec.Source.URL == "" {
 		return nil, fmt.Errorf("source.url is required for download pattern")
 	}
 
 	// Resolve version if configured
-	resolvedVersion, versionKind, err := i.resolveVersion(ctx, spec.Version, spec.ResolveVersion)
+	resolvedVersion, versionKind, err := i.resolvePinnedVersion(ctx, name, spec.Version, spec.ResolveVersion)
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve version: %w", err)
 	}
@@ -111,6 +161,9 @@ func (i *Installer) installDownload(ctx context.Context, spec *resource.RuntimeS
 	if err != nil {
 		return nil, fmt.Errorf("failed to expand source URL template: %w", err)
 	}
+	if err := i.pins[name].CheckURL(resolvedVersion, sourceURL); err != nil {
+		return nil, err
+	}
 
 	// Expand {{.Version}} in checksum URL if present
 	var checksumSpec *resource.Checksum
@@ -128,23 +181,134 @@ func (i *Installer) installDownload(ctx context.Context, spec *resource.RuntimeS
 		}
 	}
 
+	return &ResolvedSource{
+		Version:     resolvedVersion,
+		VersionKind: versionKind,
+		URL:         sourceURL,
+		Checksum:    checksumSpec,
+	}, nil
+}
+
+// installDownload installs a runtime using the download pattern.
+func (i *Installer) installDownload(ctx context.Context, spec *resource.RuntimeSpec, name string) (*resource.RuntimeState, error) {
+	src, err := i.ResolveSource(ctx, spec, name)
+	if err != nil {
+		return nil, err
+	}
+
+	mirrors := mirror.NewRecorder()
+	ctx = mirror.NewContext(ctx, mirrors)
+	installPath, treeDigest, digest, err := i.installVersion(ctx, spec, name, src, executor.ContentDigestFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	// Create symlinks for binaries
+	binDir, err := i.ensureSymlinks(installPath, spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create symlinks: %w", err)
+	}
+
+	st := i.buildStateResolved(spec, installPath, binDir, src.Version, src.VersionKind)
+	st.SourceURL = src.URL
+	st.Mirror = mirrors.Mirror(src.URL)
+	st.TreeDigest = treeDigest
+	if st.Digest == "" {
+		st.Digest = digest
+	}
+
+	extras, err := i.installExtraVersions(ctx, spec, name, st.Version, mirrors)
+	if err != nil {
+		return nil, err
+	}
+	st.Extras = extras
+	return st, nil
+}
+
+// installExtraVersions installs the extra versions of a download-pattern
+// runtime side by side with the default version, without symlinks. Extra
+// versions recorded in state that are no longer declared are removed. The
+// mirrors that served the downloads are read from mirrors.
+func (i *Installer) installExtraVersions(ctx context.Context, spec *resource.RuntimeSpec, name, defaultVersion string, mirrors *mirror.Recorder) (map[string]*resource.RuntimeVersionState, error) {
+	previous := executor.ExtraVersionsFromContext(ctx)
+
+	var extras map[string]*resource.RuntimeVersionState
+	for _, version := range spec.ExtraVersions {
+		extraSpec := *spec
+		extraSpec.Version = version
+		src, err := i.ResolveSource(ctx, &extraSpec, name)
+		if err != nil {
+			return nil, fmt.Errorf("version %s: %w", version, err)
+		}
+
+		// Extra versions may be recorded as extras or, after the default
+		// version moved, as the previous default version.
+		recorded := executor.ContentDigestFromContext(ctx)
+		prev := previous[version]
+		if prev != nil {
+			recorded = executor.ContentDigest{Path: prev.InstallPath, Digest: prev.TreeDigest}
+		}
+		installPath, treeDigest, digest, err := i.installVersion(ctx, &extraSpec, name, src, recorded)
+		if err != nil {
+			return nil, fmt.Errorf("version %s: %w", version, err)
+		}
+		if digest == "" && prev != nil {
+			digest = prev.Digest
+		}
+
+		if extras == nil {
+			extras = make(map[string]*resource.RuntimeVersionState, len(spec.ExtraVersions))
+		}
+		extras[version] = &resource.RuntimeVersionState{
+			Digest:      digest,
+			TreeDigest:  treeDigest,
+			SourceURL:   src.URL,
+			Mirror:      mirrors.Mirror(src.URL),
+			InstallPath: installPath,
+			BinDir:      versionBinDir(installPath, spec.Binaries),
+			Env:         expandEnv(spec.Env, version),
+			UpdatedAt:   time.Now(),
+		}
+	}
+
+	for version, prev := range previous {
+		if _, ok := extras[version]; ok || version == defaultVersion || prev.InstallPath == "" {
+			continue
+		}
+		slog.Debug("removing extra runtime version", "name", name, "version", version)
+		if err := os.RemoveAll(prev.InstallPath); err != nil {
+			return nil, fmt.Errorf("failed to remove version %s: %w", version, err)
+		}
+	}
+	return extras, nil
+}
+
+// installVersion downloads and extracts a resolved runtime version into its
+// install path, reusing an existing install that matches the recorded tree.
+// It returns the install path, the digest of its tree manifest, and the
+// digest of the downloaded archive, which is empty when nothing was downloaded.
+func (i *Installer) installVersion(ctx context.Context, spec *resource.RuntimeSpec, name string, src *ResolvedSource, recorded executor.ContentDigest) (string, checksum.Digest, checksum.Digest, error) {
+	resolvedVersion, sourceURL, checksumSpec := src.Version, src.URL, src.Checksum
+
 	// Calculate install path using resolved version
 	installPath := filepath.Join(i.runtimesDir, name, resolvedVersion)
 
 	// Check if already installed
 	if _, err := os.Stat(installPath); err == nil {
-		slog.Debug("runtime already installed, rebuilding symlinks", "name", name, "version", resolvedVersion)
-		binDir, err := i.ensureSymlinks(installPath, spec)
+		treeDigest, drifted, err := checkRecordedTree(recorded, installPath)
 		if err != nil {
-			return nil, fmt.Errorf("failed to rebuild symlinks: %w", err)
+			return "", "", "", err
+		}
+		if !drifted {
+			slog.Debug("runtime already installed", "name", name, "version", resolvedVersion)
+			return installPath, treeDigest, "", nil
 		}
-		return i.buildStateResolved(spec, installPath, binDir, resolvedVersion, versionKind), nil
 	}
 
 	// Download
 	tmpDir, err := os.MkdirTemp("", "tomei-runtime-*")
 	if err != nil {
-		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+		return "", "", "", fmt.Errorf("failed to create temp dir: %w", err)
 	}
 	defer os.RemoveAll(tmpDir)
 
@@ -155,14 +319,21 @@ func (i *Installer) installDownload(ctx context.Context, spec *resource.RuntimeS
 	if progressCb == nil {
 		progressCb = i.progressCallback
 	}
-	_, err = i.downloader.DownloadWithProgress(ctx, sourceURL, archivePath, progressCb)
+	dlCtx := download.WithExpectedDigest(ctx, download.ExpectedDigest(i.pins[name].DigestFor(resolvedVersion), checksumSpec))
+	_, err = i.downloader.DownloadWithProgress(dlCtx, sourceURL, archivePath, progressCb)
 	if err != nil {
-		return nil, fmt.Errorf("failed to download: %w", err)
+		return "", "", "", fmt.Errorf("failed to download: %w", err)
 	}
 
 	// Verify checksum
 	if err := i.downloader.Verify(ctx, archivePath, checksumSpec); err != nil {
-		return nil, fmt.Errorf("failed to verify checksum: %w", err)
+		return "", "", "", fmt.Errorf("failed to verify checksum: %w", err)
+	}
+
+	// Verify against the lockfile and record the archive digest
+	digest, err := i.pins[name].VerifyDigest(archivePath, resolvedVersion)
+	if err != nil {
+		return "", "", "", err
 	}
 
 	// Determine archive type
@@ -170,7 +341,7 @@ func (i *Installer) installDownload(ctx context.Context, spec *resource.RuntimeS
 	if archiveType == "" {
 		archiveType = extract.DetectArchiveType(sourceURL)
 		if archiveType == "" {
-			return nil, fmt.Errorf("cannot determine archive type from URL: %s", sourceURL)
+			return "", "", "", fmt.Errorf("cannot determine archive type from URL: %s", sourceURL)
 		}
 		slog.Debug("auto-detected archive type", "type", archiveType)
 	}
@@ -178,48 +349,81 @@ func (i *Installer) installDownload(ctx context.Context, spec *resource.RuntimeS
 	// Extract
 	extractor, err := extract.NewExtractor(archiveType)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create extractor: %w", err)
+		return "", "", "", fmt.Errorf("failed to create extractor: %w", err)
 	}
 
 	extractDir := filepath.Join(tmpDir, "extracted")
 	archiveFile, err := os.Open(archivePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open archive: %w", err)
+		return "", "", "", fmt.Errorf("failed to open archive: %w", err)
 	}
 	defer archiveFile.Close()
 
-	if err := extractor.Extract(archiveFile, extractDir); err != nil {
-		return nil, fmt.Errorf("failed to extract: %w", err)
+	if err := extract.ExtractContext(ctx, extractor, archiveFile, extractDir); err != nil {
+		return "", "", "", fmt.Errorf("failed to extract: %w", err)
 	}
 
 	// Find the root directory in extracted content
 	// Many runtimes have a top-level directory (e.g., go1.25.1.linux-amd64 extracts to "go/")
 	rootDir, err := findExtractedRoot(extractDir)
 	if err != nil {
-		return nil, fmt.Errorf("failed to find extracted root: %w", err)
+		return "", "", "", fmt.Errorf("failed to find extracted root: %w", err)
 	}
 
 	// Move to install path
 	if err := os.MkdirAll(filepath.Dir(installPath), 0755); err != nil {
-		return nil, fmt.Errorf("failed to create install directory: %w", err)
+		return "", "", "", fmt.Errorf("failed to create install directory: %w", err)
 	}
 
 	if err := os.Rename(rootDir, installPath); err != nil {
 		// Rename may fail across filesystems, try copy
 		if err := copyDir(rootDir, installPath); err != nil {
-			return nil, fmt.Errorf("failed to move runtime to install path: %w", err)
+			return "", "", "", fmt.Errorf("failed to move runtime to install path: %w", err)
 		}
 	}
 
-	// Create symlinks for binaries
-	binDir, err := i.ensureSymlinks(installPath, spec)
+	// Record the installed tree to detect files modified later
+	treeDigest, err := checksum.WriteTreeManifest(installPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create symlinks: %w", err)
+		return "", "", "", fmt.Errorf("failed to record installed files: %w", err)
 	}
 
 	slog.Debug("runtime installed successfully", "name", name, "version", resolvedVersion, "path", installPath)
+	return installPath, treeDigest, digest, nil
+}
 
-	return i.buildStateResolved(spec, installPath, binDir, resolvedVersion, versionKind), nil
+// versionBinDir returns the directory containing the binaries of a runtime
+// installed at installPath: where the first binary is found, else installPath/bin.
+func versionBinDir(installPath string, binaries []string) string {
+	for _, binary := range binaries {
+		if p := findBinary(installPath, binary); p != "" {
+			return filepath.Dir(p)
+		}
+	}
+	return filepath.Join(installPath, "bin")
+}
+
+// checkRecordedTree compares an existing install directory with the tree
+// manifest recorded when it was installed. It returns the recorded digest to
+// keep in state, or removes the directory and reports drifted when files were
+// modified, removed or added since. Trees installed without a manifest are
+// kept as they are.
+func checkRecordedTree(recorded executor.ContentDigest, installPath string) (checksum.Digest, bool, error) {
+	if recorded.Digest == "" || recorded.Path != installPath {
+		return "", false, nil
+	}
+	manifest, err := checksum.ReadTreeManifest(installPath, recorded.Digest)
+	if err == nil {
+		current, hashErr := checksum.HashTree(installPath)
+		if hashErr == nil && manifest.Diff(current).Empty() {
+			return recorded.Digest, false, nil
+		}
+	}
+	slog.Warn("installed runtime differs from the recorded files, installing it again", "path", installPath)
+	if err := os.RemoveAll(installPath); err != nil {
+		return "", false, fmt.Errorf("failed to remove drifted runtime: %w", err)
+	}
+	return "", true, nil
 }
 
 // Remove removes an installed runtime.
@@ -230,9 +434,17 @@ func (i *Installer) Remove(ctx context.Context, st *resource.RuntimeState, name
 		return i.removeDelegation(ctx, st, name)
 	}
 
-	// Download pattern: remove symlinks and install directory
+	// Download pattern: remove symlinks and install directories
 	removeSymlinks(st.BinDir, st.Binaries, false)
 
+	for _, extra := range st.Extras {
+		if extra.InstallPath != "" {
+			if err := os.RemoveAll(extra.InstallPath); err != nil {
+				return fmt.Errorf("failed to remove install directory: %w", err)
+			}
+		}
+	}
+
 	if st.InstallPath != "" {
 		if err := os.RemoveAll(st.InstallPath); err != nil {
 			return fmt.Errorf("failed to remove install directory: %w", err)
@@ -320,6 +532,20 @@ func (i *Installer) buildStateResolved(spec *resource.RuntimeSpec, installPath,
 	}
 }
 
+// resolvePinnedVersion returns the version pinned in tomei.lock when the spec
+// version is not exact, and falls back to resolveVersion otherwise.
+func (i *Installer) resolvePinnedVersion(ctx context.Context, name, version string, cmds []string) (string, resource.VersionKind, error) {
+	pin, ok := i.pins[name]
+	if !ok || pin.Version == "" || resource.IsExactVersion(version) {
+		return i.resolveVersion(ctx, version, cmds)
+	}
+	slog.Debug("using version pinned in lockfile", "name", name, "version", pin.Version)
+	if len(cmds) == 0 {
+		return pin.Version, resource.ClassifyVersion(version), nil
+	}
+	return pin.Version, resource.VersionAlias, nil
+}
+
 // resolveVersion resolves the runtime version using the given commands.
 // If cmds is empty or the version is an exact version number,
 // returns the version as-is.
@@ -403,9 +629,10 @@ func (i *Installer) installDelegation(ctx context.Context, spec *resource.Runtim
 
 	// Resolve version using the shared resolver (supports http-text:, github-release:,
 	// and shell command fallback). When no resolveVersion commands are configured,
-	// resolveVersion returns spec.Version directly.
+	// resolveVersion returns spec.Version directly. A version pinned in
+	// tomei.lock takes precedence over resolution.
 	action := executor.ActionFromContext(ctx)
-	resolvedVersion, versionKind, err := i.resolveVersion(ctx, spec.Version, spec.Bootstrap.ResolveVersion)
+	resolvedVersion, versionKind, err := i.resolvePinnedVersion(ctx, name, spec.Version, spec.Bootstrap.ResolveVersion)
 	if err != nil {
 		// On first install, the runtime binary may not exist yet, so shell-based
 		// resolvers (e.g. "rustc --version") can fail. Fall back to spec.Version
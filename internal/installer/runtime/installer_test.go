@@ -19,6 +19,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/terassyi/tomei/internal/checksum"
+	"github.com/terassyi/tomei/internal/installer"
 	"github.com/terassyi/tomei/internal/installer/command"
 	"github.com/terassyi/tomei/internal/installer/download"
 	"github.com/terassyi/tomei/internal/installer/executor"
@@ -1754,6 +1755,130 @@ func TestInstaller_ResolveVersion_ExactSkip(t *testing.T) {
 	})
 }
 
+func TestInstaller_Download_LockPins(t *testing.T) {
+	t.Parallel()
+	tarGzContent := createRuntimeTarGz(t, "myruntime", []mockBinary{
+		{name: "mybin", content: []byte("#!/bin/sh\necho 'mock runtime'\n")},
+	})
+	archiveHash := sha256sum(tarGzContent)
+
+	newRuntime := func(binDir string) *resource.Runtime {
+		return &resource.Runtime{
+			RuntimeSpec: &resource.RuntimeSpec{
+				Type:    resource.InstallTypeDownload,
+				Version: "stable",
+				Source: &resource.DownloadSource{
+					URL: "https://example.com/myruntime-{{.Version}}.tar.gz",
+				},
+				Binaries:       []string{"mybin"},
+				BinDir:         binDir,
+				ToolBinPath:    "~/myruntime/bin",
+				ResolveVersion: []string{"echo 1.26.0"},
+			},
+		}
+	}
+
+	t.Run("pinned version skips resolution and records url and digest", func(t *testing.T) {
+		t.Parallel()
+		tmpDir := t.TempDir()
+		runner := &mockCommandRunner{captureResult: "1.26.0"}
+		inst := NewInstallerWithRunner(&mockRuntimeDownloader{archiveData: tarGzContent}, filepath.Join(tmpDir, "runtimes"), runner)
+		inst.SetPins(map[string]installer.Pin{"myruntime": {Version: "1.25.6"}})
+
+		st, err := inst.Install(context.Background(), newRuntime(filepath.Join(tmpDir, "bin")), "myruntime")
+		require.NoError(t, err)
+
+		assert.Empty(t, runner.captureCalls, "resolveVersion should not run for a pinned runtime")
+		assert.Equal(t, "1.25.6", st.Version)
+		assert.Equal(t, resource.VersionAlias, st.VersionKind)
+		assert.Equal(t, "stable", st.SpecVersion)
+		assert.Equal(t, "https://example.com/myruntime-1.25.6.tar.gz", st.SourceURL)
+		assert.Equal(t, checksum.Digest(archiveHash), st.Digest)
+	})
+
+	t.Run("pinned digest mismatch fails", func(t *testing.T) {
+		t.Parallel()
+		tmpDir := t.TempDir()
+		inst := NewInstallerWithRunner(&mockRuntimeDownloader{archiveData: tarGzContent}, filepath.Join(tmpDir, "runtimes"), &mockCommandRunner{})
+		inst.SetPins(map[string]installer.Pin{"myruntime": {Version: "1.25.6", Digest: checksum.Digest(strings.Repeat("0", 64))}})
+
+		_, err := inst.Install(context.Background(), newRuntime(filepath.Join(tmpDir, "bin")), "myruntime")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "does not match digest pinned in lockfile")
+	})
+
+	t.Run("pinned url mismatch fails", func(t *testing.T) {
+		t.Parallel()
+		tmpDir := t.TempDir()
+		inst := NewInstallerWithRunner(&mockRuntimeDownloader{archiveData: tarGzContent}, filepath.Join(tmpDir, "runtimes"), &mockCommandRunner{})
+		inst.SetPins(map[string]installer.Pin{"myruntime": {Version: "1.25.6", URL: "https://mirror.example.com/myruntime-1.25.6.tar.gz"}})
+
+		_, err := inst.Install(context.Background(), newRuntime(filepath.Join(tmpDir, "bin")), "myruntime")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "does not match URL pinned in lockfile")
+	})
+}
+
+func TestInstaller_Download_ExtraVersions(t *testing.T) {
+	t.Parallel()
+	tarGzContent := createRuntimeTarGz(t, "myruntime", []mockBinary{
+		{name: "mybin", content: []byte("#!/bin/sh\necho 'mock runtime'\n")},
+	})
+
+	tmpDir := t.TempDir()
+	runtimesDir := filepath.Join(tmpDir, "runtimes")
+	binDir := filepath.Join(tmpDir, "bin")
+	inst := NewInstallerWithRunner(&mockRuntimeDownloader{archiveData: tarGzContent}, runtimesDir, &mockCommandRunner{})
+
+	res := &resource.Runtime{
+		RuntimeSpec: &resource.RuntimeSpec{
+			Type:          resource.InstallTypeDownload,
+			Version:       "1.26.0",
+			ExtraVersions: []string{"1.24.3", "1.25.6"},
+			Source: &resource.DownloadSource{
+				URL: "https://example.com/myruntime-{{.Version}}.tar.gz",
+			},
+			Binaries: []string{"mybin"},
+			BinDir:   binDir,
+			Env:      map[string]string{"MYROOT": filepath.Join(runtimesDir, "myruntime", "{{.Version}}")},
+		},
+	}
+
+	st, err := inst.Install(context.Background(), res, "myruntime")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1.24.3", "1.25.6"}, st.ExtraVersions())
+
+	extra := st.Extras["1.24.3"]
+	extraPath := filepath.Join(runtimesDir, "myruntime", "1.24.3")
+	assert.Equal(t, extraPath, extra.InstallPath)
+	assert.Equal(t, filepath.Join(extraPath, "bin"), extra.BinDir)
+	assert.Equal(t, "https://example.com/myruntime-1.24.3.tar.gz", extra.SourceURL)
+	assert.Equal(t, map[string]string{"MYROOT": extraPath}, extra.Env)
+	assert.NotEmpty(t, extra.Digest)
+	assert.NotEmpty(t, extra.TreeDigest)
+	assert.FileExists(t, filepath.Join(extraPath, "bin", "mybin"))
+
+	target, err := os.Readlink(filepath.Join(binDir, "mybin"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(runtimesDir, "myruntime", "1.26.0", "bin", "mybin"), target,
+		"symlinks point at the default version")
+
+	t.Run("undeclared extra versions are removed", func(t *testing.T) {
+		res.RuntimeSpec.ExtraVersions = []string{"1.25.6"}
+		ctx := executor.WithExtraVersions(context.Background(), st.Extras)
+		updated, err := inst.Install(ctx, res, "myruntime")
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"1.25.6"}, updated.ExtraVersions())
+		assert.Equal(t, st.Extras["1.25.6"].TreeDigest, updated.Extras["1.25.6"].TreeDigest)
+		assert.Equal(t, st.Extras["1.25.6"].Digest, updated.Extras["1.25.6"].Digest, "reused versions keep their archive digest")
+		assert.NoDirExists(t, extraPath)
+
+		require.NoError(t, inst.Remove(context.Background(), updated, "myruntime"))
+		assert.NoDirExists(t, filepath.Join(runtimesDir, "myruntime"))
+	})
+}
+
 func TestExpandVersionTemplate(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,229 @@
+package system
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/terassyi/tomei/internal/installer/download"
+	"github.com/terassyi/tomei/internal/resource"
+)
+
+// AptInstallerName is the name of the builtin APT system installer.
+const AptInstallerName = "apt"
+
+const (
+	// aptKeyringsDir is where repository signing keys are written.
+	aptKeyringsDir = "/etc/apt/keyrings"
+	// aptSourcesDir is where deb822 .sources files are written.
+	aptSourcesDir = "/etc/apt/sources.list.d"
+	// aptFilePrefix prefixes every file tomei writes so that files managed
+	// by other tools are never overwritten.
+	aptFilePrefix = "tomei-"
+)
+
+// BuiltinAptInstaller is the builtin "apt" system installer definition.
+var BuiltinAptInstaller = &resource.SystemInstaller{
+	BaseResource: resource.BaseResource{
+		APIVersion:   resource.GroupVersion,
+		ResourceKind: resource.KindSystemInstaller,
+		Metadata:     resource.Metadata{Name: AptInstallerName},
+	},
+	SystemInstallerSpec: &resource.SystemInstallerSpec{
+		Pattern:    resource.SystemInstallerPatternDelegation,
+		Privileged: true,
+		Commands: resource.SystemInstallerCommandsSpec{
+			Install: resource.CommandSpec{Command: "apt-get", Verb: "install -y"},
+			Remove:  resource.CommandSpec{Command: "apt-get", Verb: "remove -y"},
+			Check:   resource.CommandSpec{Command: "dpkg", Verb: "-s"},
+			Update:  "apt-get update",
+		},
+	},
+}
+
+// deb822Fields maps well-known SourceConfig options to deb822 field names.
+// Other options are rendered with each hyphen-separated word capitalized.
+var deb822Fields = map[string]string{
+	"types":         "Types",
+	"suites":        "Suites",
+	"components":    "Components",
+	"architectures": "Architectures",
+	"arch":          "Architectures",
+}
+
+// aptBackend runs apt-get for packages and manages repository files
+// (signing keyrings and deb822 sources) under rootDir.
+type aptBackend struct {
+	*commandBackend
+	rootDir    string
+	downloader download.Downloader
+}
+
+// newAptBackend creates the builtin APT backend.
+func newAptBackend(runner commandRunner, rootDir string, downloader download.Downloader) *aptBackend {
+	b := newCommandBackend(runner, BuiltinAptInstaller.SystemInstallerSpec.Commands)
+	b.env = map[string]string{"DEBIAN_FRONTEND": "noninteractive"}
+	return &aptBackend{
+		commandBackend: b,
+		rootDir:        rootDir,
+		downloader:     downloader,
+	}
+}
+
+// AddRepository downloads and verifies the signing key (if any), writes it to
+// /etc/apt/keyrings and renders a deb822 .sources file referencing it.
+func (b *aptBackend) AddRepository(ctx context.Context, name string, source resource.SourceConfig) ([]string, error) {
+	var files []string
+
+	var keyring string
+	if source.KeyURL != "" {
+		data, err := b.fetchKey(ctx, source)
+		if err != nil {
+			return nil, err
+		}
+		ext := ".gpg"
+		if bytes.HasPrefix(bytes.TrimSpace(data), []byte("-----BEGIN PGP")) {
+			ext = ".asc"
+		}
+		keyring = filepath.Join(aptKeyringsDir, aptFilePrefix+name+ext)
+		if err := b.writeFile(keyring, data); err != nil {
+			return nil, fmt.Errorf("failed to write keyring: %w", err)
+		}
+		files = append(files, keyring)
+	}
+
+	content, err := renderDeb822(source, keyring)
+	if err != nil {
+		return files, err
+	}
+	sources := filepath.Join(aptSourcesDir, aptFilePrefix+name+".sources")
+	if err := b.writeFile(sources, []byte(content)); err != nil {
+		return files, fmt.Errorf("failed to write sources file: %w", err)
+	}
+	files = append(files, sources)
+
+	slog.Debug("apt repository configured", "name", name, "files", files)
+	return files, nil
+}
+
+// RemoveRepository removes the files written by AddRepository.
+// Files that no longer exist are ignored.
+func (b *aptBackend) RemoveRepository(_ context.Context, name string, files []string) error {
+	for _, f := range files {
+		if err := os.Remove(b.hostPath(f)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to remove %s: %w", f, err)
+		}
+	}
+	slog.Debug("apt repository removed", "name", name)
+	return nil
+}
+
+// fetchKey downloads the signing key and verifies it against KeyHash.
+func (b *aptBackend) fetchKey(ctx context.Context, source resource.SourceConfig) ([]byte, error) {
+	if source.KeyHash == "" {
+		return nil, fmt.Errorf("keyHash is required to verify the signing key %s", source.KeyURL)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "tomei-apt-key-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	keyPath, err := b.downloader.Download(ctx, source.KeyURL, filepath.Join(tmpDir, "key"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download signing key: %w", err)
+	}
+	if err := b.downloader.Verify(ctx, keyPath, &resource.Checksum{Value: source.KeyHash}); err != nil {
+		return nil, fmt.Errorf("failed to verify signing key: %w", err)
+	}
+	return os.ReadFile(keyPath)
+}
+
+// writeFile writes data to the absolute path p under rootDir.
+func (b *aptBackend) writeFile(p string, data []byte) error {
+	dest := b.hostPath(p)
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(dest, data, 0644)
+}
+
+// hostPath returns the location of the absolute path p under rootDir.
+func (b *aptBackend) hostPath(p string) string {
+	return filepath.Join(b.rootDir, p)
+}
+
+// renderDeb822 renders a deb822 sources stanza for the repository.
+// keyring is the Signed-By path of the pinned key; empty means the
+// signed-by option (if any) is used as is.
+func renderDeb822(source resource.SourceConfig, keyring string) (string, error) {
+	if strings.ContainsAny(source.URL, "\r\n") {
+		return "", fmt.Errorf("source.url must not contain newlines")
+	}
+	fields := map[string]string{"Types": "deb"}
+	for k, v := range source.Options {
+		// A newline would start a new field, e.g. a second Signed-By.
+		if strings.ContainsAny(k, "\r\n") || strings.ContainsAny(v, "\r\n") {
+			return "", fmt.Errorf("source.options.%s must not contain newlines", strings.TrimSpace(k))
+		}
+		fields[deb822FieldName(k)] = v
+	}
+	if fields["Suites"] == "" {
+		return "", fmt.Errorf("source.options.suites is required for apt repositories")
+	}
+	if keyring != "" && fields["Signed-By"] != "" {
+		return "", fmt.Errorf("source.options.signed-by cannot be combined with source.keyUrl")
+	}
+	if keyring == "" {
+		keyring = fields["Signed-By"]
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Types: %s\n", fields["Types"])
+	fmt.Fprintf(&sb, "URIs: %s\n", source.URL)
+	fmt.Fprintf(&sb, "Suites: %s\n", fields["Suites"])
+	for _, k := range []string{"Components", "Architectures"} {
+		if v := fields[k]; v != "" {
+			fmt.Fprintf(&sb, "%s: %s\n", k, v)
+		}
+	}
+	if keyring != "" {
+		fmt.Fprintf(&sb, "Signed-By: %s\n", keyring)
+	}
+
+	var extra []string
+	for k := range fields {
+		switch k {
+		case "Types", "URIs", "Suites", "Components", "Architectures", "Signed-By":
+			continue
+		}
+		extra = append(extra, k)
+	}
+	slices.Sort(extra)
+	for _, k := range extra {
+		fmt.Fprintf(&sb, "%s: %s\n", k, fields[k])
+	}
+	return sb.String(), nil
+}
+
+// deb822FieldName converts an option key to its deb822 field name.
+func deb822FieldName(key string) string {
+	if f, ok := deb822Fields[strings.ToLower(key)]; ok {
+		return f
+	}
+	words := strings.Split(key, "-")
+	for i, w := range words {
+		if w != "" {
+			words[i] = strings.ToUpper(w[:1]) + strings.ToLower(w[1:])
+		}
+	}
+	return strings.Join(words, "-")
+}
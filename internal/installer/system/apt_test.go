@@ -0,0 +1,250 @@
+package system
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/terassyi/tomei/internal/resource"
+)
+
+const testArmoredKey = "-----BEGIN PGP PUBLIC KEY BLOCK-----\n\nmQINBFit2ioBEADhWpZ8/wvZ6hUTiXOwQHXMAlaFHcPH9hAtr4F1y2+OYdbtMuth\n-----END PGP PUBLIC KEY BLOCK-----\n"
+
+func newKeyServer(t *testing.T, key string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(key))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func sha256Of(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func newTestAptRepository(source resource.SourceConfig) *resource.SystemPackageRepository {
+	return &resource.SystemPackageRepository{
+		BaseResource: resource.BaseResource{
+			APIVersion:   resource.GroupVersion,
+			ResourceKind: resource.KindSystemPackageRepository,
+			Metadata:     resource.Metadata{Name: "docker"},
+		},
+		SystemPackageRepositorySpec: &resource.SystemPackageRepositorySpec{
+			InstallerRef: AptInstallerName,
+			Source:       source,
+		},
+	}
+}
+
+func TestBuiltinAptInstaller(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, resource.KindSystemInstaller, BuiltinAptInstaller.ResourceKind)
+	assert.Equal(t, AptInstallerName, BuiltinAptInstaller.Name())
+	require.NotNil(t, BuiltinAptInstaller.SystemInstallerSpec)
+	assert.NoError(t, BuiltinAptInstaller.SystemInstallerSpec.Validate())
+	assert.True(t, BuiltinAptInstaller.SystemInstallerSpec.Privileged)
+}
+
+func TestAptRepository_InstallAndRemove(t *testing.T) {
+	t.Parallel()
+
+	srv := newKeyServer(t, testArmoredKey)
+	root := t.TempDir()
+	registry := newRegistryWithRunner(&mockRunner{}, WithRootDir(root))
+	inst := NewRepositoryInstaller(registry)
+
+	repo := newTestAptRepository(resource.SourceConfig{
+		URL:     "https://download.docker.com/linux/ubuntu",
+		KeyURL:  srv.URL + "/gpg",
+		KeyHash: sha256Of(testArmoredKey),
+		Options: map[string]string{
+			"suites":     "noble",
+			"components": "stable",
+			"arch":       "amd64",
+		},
+	})
+
+	st, err := inst.Install(context.Background(), repo, "docker")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"/etc/apt/keyrings/tomei-docker.asc",
+		"/etc/apt/sources.list.d/tomei-docker.sources",
+	}, st.InstalledFiles)
+
+	key, err := os.ReadFile(filepath.Join(root, "etc/apt/keyrings/tomei-docker.asc"))
+	require.NoError(t, err)
+	assert.Equal(t, testArmoredKey, string(key))
+
+	sources, err := os.ReadFile(filepath.Join(root, "etc/apt/sources.list.d/tomei-docker.sources"))
+	require.NoError(t, err)
+	assert.Equal(t, `Types: deb
+URIs: https://download.docker.com/linux/ubuntu
+Suites: noble
+Components: stable
+Architectures: amd64
+Signed-By: /etc/apt/keyrings/tomei-docker.asc
+`, string(sources))
+
+	require.NoError(t, inst.Remove(context.Background(), st, "docker"))
+	for _, f := range st.InstalledFiles {
+		assert.NoFileExists(t, filepath.Join(root, f))
+	}
+
+	// Removing again is a no-op
+	require.NoError(t, inst.Remove(context.Background(), st, "docker"))
+}
+
+func TestAptRepository_KeyHashMismatch(t *testing.T) {
+	t.Parallel()
+
+	srv := newKeyServer(t, testArmoredKey)
+	root := t.TempDir()
+	registry := newRegistryWithRunner(&mockRunner{}, WithRootDir(root))
+
+	repo := newTestAptRepository(resource.SourceConfig{
+		URL:     "https://download.docker.com/linux/ubuntu",
+		KeyURL:  srv.URL + "/gpg",
+		KeyHash: sha256Of("something else"),
+		Options: map[string]string{"suites": "noble"},
+	})
+
+	_, err := NewRepositoryInstaller(registry).Install(context.Background(), repo, "docker")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to verify signing key")
+	assert.NoDirExists(t, filepath.Join(root, "etc/apt/keyrings"))
+}
+
+func TestAptRepository_KeyHashRequired(t *testing.T) {
+	t.Parallel()
+
+	registry := newRegistryWithRunner(&mockRunner{}, WithRootDir(t.TempDir()))
+	repo := newTestAptRepository(resource.SourceConfig{
+		URL:     "https://download.docker.com/linux/ubuntu",
+		KeyURL:  "https://download.docker.com/linux/ubuntu/gpg",
+		Options: map[string]string{"suites": "noble"},
+	})
+
+	_, err := NewRepositoryInstaller(registry).Install(context.Background(), repo, "docker")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "keyHash is required")
+}
+
+func TestRenderDeb822(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		source  resource.SourceConfig
+		keyring string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "minimal without key",
+			source: resource.SourceConfig{
+				URL:     "https://example.com/debian",
+				Options: map[string]string{"suites": "stable"},
+			},
+			want: "Types: deb\nURIs: https://example.com/debian\nSuites: stable\n",
+		},
+		{
+			name: "extra options sorted after known fields",
+			source: resource.SourceConfig{
+				URL: "https://example.com/debian",
+				Options: map[string]string{
+					"types":             "deb deb-src",
+					"suites":            "bookworm",
+					"check-valid-until": "no",
+					"enabled":           "yes",
+				},
+			},
+			keyring: "/etc/apt/keyrings/tomei-example.gpg",
+			want: "Types: deb deb-src\nURIs: https://example.com/debian\nSuites: bookworm\n" +
+				"Signed-By: /etc/apt/keyrings/tomei-example.gpg\nCheck-Valid-Until: no\nEnabled: yes\n",
+		},
+		{
+			name: "signed-by option without key",
+			source: resource.SourceConfig{
+				URL: "https://example.com/debian",
+				Options: map[string]string{
+					"suites":    "stable",
+					"signed-by": "/usr/share/keyrings/example.gpg",
+				},
+			},
+			want: "Types: deb\nURIs: https://example.com/debian\nSuites: stable\nSigned-By: /usr/share/keyrings/example.gpg\n",
+		},
+		{
+			name: "signed-by option with pinned key",
+			source: resource.SourceConfig{
+				URL: "https://example.com/debian",
+				Options: map[string]string{
+					"suites":    "stable",
+					"signed-by": "/usr/share/keyrings/example.gpg",
+				},
+			},
+			keyring: "/etc/apt/keyrings/tomei-example.gpg",
+			wantErr: true,
+		},
+		{
+			name: "newline in option value",
+			source: resource.SourceConfig{
+				URL: "https://example.com/debian",
+				Options: map[string]string{
+					"suites":     "stable",
+					"components": "main\nSigned-By: /tmp/evil.gpg",
+				},
+			},
+			keyring: "/etc/apt/keyrings/tomei-example.gpg",
+			wantErr: true,
+		},
+		{
+			name: "newline in option key",
+			source: resource.SourceConfig{
+				URL: "https://example.com/debian",
+				Options: map[string]string{
+					"suites":                  "stable",
+					"x\nSigned-By: /tmp/evil": "yes",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "missing suites",
+			source:  resource.SourceConfig{URL: "https://example.com/debian"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := renderDeb822(tt.source, tt.keyring)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAptBackend_InstallPackages(t *testing.T) {
+	t.Parallel()
+
+	runner := &mockRunner{installed: map[string]bool{"curl": true}}
+	registry := newRegistryWithRunner(runner)
+
+	_, err := NewPackageSetInstaller(registry).Install(context.Background(), newTestPackageSet("curl", "git"), "base")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"apt-get update", "apt-get install -y git"}, runner.executed)
+}
@@ -0,0 +1,142 @@
+// Package system installs system-privilege resources: SystemInstaller,
+// SystemPackageRepository and SystemPackageSet.
+//
+// Package operations are performed by a Backend registered per
+// SystemInstaller name. User-defined installers get a command backend that
+// runs the commands declared in the manifest; builtin installers may provide
+// their own backend with extra capabilities (e.g., repository management).
+package system
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/terassyi/tomei/internal/installer/command"
+	"github.com/terassyi/tomei/internal/installer/download"
+	"github.com/terassyi/tomei/internal/resource"
+)
+
+// Backend performs package operations for a system installer.
+type Backend interface {
+	// InstallPackages installs the given packages.
+	InstallPackages(ctx context.Context, packages []string) error
+	// RemovePackages removes the given packages.
+	RemovePackages(ctx context.Context, packages []string) error
+	// IsInstalled reports whether the package is already installed.
+	IsInstalled(ctx context.Context, pkg string) bool
+}
+
+// VersionQuerier is implemented by backends that can report the installed
+// version of packages. Results are recorded in SystemPackageSetState.
+type VersionQuerier interface {
+	InstalledVersions(ctx context.Context, packages []string) (map[string]string, error)
+}
+
+// RepositoryManager is implemented by backends that can configure
+// third-party package repositories.
+type RepositoryManager interface {
+	// AddRepository configures the repository and returns the paths of all files it wrote.
+	AddRepository(ctx context.Context, name string, source resource.SourceConfig) ([]string, error)
+	// RemoveRepository removes the files previously returned by AddRepository.
+	RemoveRepository(ctx context.Context, name string, files []string) error
+}
+
+// commandRunner is the interface for executing shell commands.
+// This enables testing with mocks instead of real command execution.
+type commandRunner interface {
+	ExecuteWithEnv(ctx context.Context, cmds []string, vars command.Vars, env map[string]string) error
+	ExecuteWithOutput(ctx context.Context, cmds []string, vars command.Vars, env map[string]string, callback command.OutputCallback) error
+	Check(ctx context.Context, cmds []string, vars command.Vars, env map[string]string) bool
+}
+
+// commandBackend runs the commands declared in a SystemInstallerSpec.
+type commandBackend struct {
+	runner   commandRunner
+	commands resource.SystemInstallerCommandsSpec
+	env      map[string]string
+}
+
+// newCommandBackend creates a Backend from the installer's commands.
+func newCommandBackend(runner commandRunner, commands resource.SystemInstallerCommandsSpec) *commandBackend {
+	return &commandBackend{runner: runner, commands: commands}
+}
+
+// InstallPackages refreshes the package index (if an update command is configured)
+// and installs the packages in a single invocation.
+func (b *commandBackend) InstallPackages(ctx context.Context, packages []string) error {
+	if len(packages) == 0 {
+		return nil
+	}
+	if b.commands.Update != "" {
+		if err := b.run(ctx, b.commands.Update); err != nil {
+			return fmt.Errorf("failed to update package index: %w", err)
+		}
+	}
+	return b.run(ctx, shellJoin(b.commands.Install.Args(packages...)))
+}
+
+// RemovePackages removes the packages in a single invocation.
+func (b *commandBackend) RemovePackages(ctx context.Context, packages []string) error {
+	if len(packages) == 0 {
+		return nil
+	}
+	return b.run(ctx, shellJoin(b.commands.Remove.Args(packages...)))
+}
+
+// IsInstalled runs the check command for a single package.
+// Returns false when no check command is configured.
+func (b *commandBackend) IsInstalled(ctx context.Context, pkg string) bool {
+	if b.commands.Check.IsZero() {
+		return false
+	}
+	return b.runner.Check(ctx, []string{shellJoin(b.commands.Check.Args(pkg))}, command.Vars{}, b.env)
+}
+
+// run executes a command line, streaming output when a callback is available in ctx.
+func (b *commandBackend) run(ctx context.Context, cmdline string) error {
+	cmds := []string{cmdline}
+	if cb := download.CallbackFromContext[download.OutputCallback](ctx); cb != nil {
+		return b.runner.ExecuteWithOutput(ctx, cmds, command.Vars{}, b.env, command.OutputCallback(cb))
+	}
+	slog.Debug("running system installer command", "command", cmdline)
+	return b.runner.ExecuteWithEnv(ctx, cmds, command.Vars{}, b.env)
+}
+
+// shellJoin joins arguments into a single shell command line,
+// quoting arguments that contain characters special to the shell.
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		quoted[i] = shellQuote(a)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// shellQuote quotes s for POSIX sh if needed.
+func shellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	safe := true
+	for _, r := range s {
+		if !isShellSafe(r) {
+			safe = false
+			break
+		}
+	}
+	if safe {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// isShellSafe reports whether r can appear unquoted in a shell word.
+func isShellSafe(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	}
+	return strings.ContainsRune("-_.+:=/@,%", r)
+}
@@ -0,0 +1,153 @@
+package system
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/terassyi/tomei/internal/installer/command"
+	"github.com/terassyi/tomei/internal/installer/download"
+	"github.com/terassyi/tomei/internal/resource"
+)
+
+// Registry maps SystemInstaller names to their backends.
+// It is shared by the installer, repository and package set installers
+// so that all three operate on the same set of registered installers.
+// Builtin installers (apt) are registered on creation.
+type Registry struct {
+	mu         sync.RWMutex
+	runner     commandRunner
+	backends   map[string]Backend
+	rootDir    string
+	downloader download.Downloader
+}
+
+// RegistryOption configures a Registry.
+type RegistryOption func(*Registry)
+
+// WithRootDir sets the directory under which builtin installers write
+// system files (e.g., /etc/apt/keyrings). Defaults to "/".
+func WithRootDir(dir string) RegistryOption {
+	return func(r *Registry) {
+		r.rootDir = dir
+	}
+}
+
+// WithDownloader sets the downloader used to fetch repository signing keys.
+func WithDownloader(d download.Downloader) RegistryOption {
+	return func(r *Registry) {
+		r.downloader = d
+	}
+}
+
+// NewRegistry creates a Registry with the builtin installers that runs commands with the default executor.
+func NewRegistry(opts ...RegistryOption) *Registry {
+	return newRegistryWithRunner(command.NewExecutor(""), opts...)
+}
+
+// newRegistryWithRunner creates a Registry with a custom command runner (for testing).
+func newRegistryWithRunner(runner commandRunner, opts ...RegistryOption) *Registry {
+	r := &Registry{
+		runner:   runner,
+		backends: make(map[string]Backend),
+		rootDir:  "/",
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.downloader == nil {
+		r.downloader = download.NewDownloader()
+	}
+	r.backends[AptInstallerName] = newAptBackend(runner, r.rootDir, r.downloader)
+	return r
+}
+
+// Register registers a command backend for the installer spec.
+// A later registration with the same name replaces the earlier one.
+func (r *Registry) Register(name string, spec *resource.SystemInstallerSpec) {
+	r.RegisterBackend(name, newCommandBackend(r.runner, spec.Commands))
+}
+
+// RegisterBackend registers a custom backend under the given name.
+func (r *Registry) RegisterBackend(name string, backend Backend) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.backends[name] = backend
+}
+
+// Get returns the backend registered under the given name.
+func (r *Registry) Get(name string) (Backend, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	b, ok := r.backends[name]
+	if !ok {
+		return nil, fmt.Errorf("system installer %q is not defined", name)
+	}
+	return b, nil
+}
+
+// Installer installs SystemInstaller resources.
+// Installing a SystemInstaller verifies that its commands are usable
+// and registers it so that repositories and package sets can reference it.
+type Installer struct {
+	registry *Registry
+	lookPath func(file string) (string, error)
+	euid     func() int
+}
+
+// NewInstaller creates a new SystemInstaller installer backed by the registry.
+func NewInstaller(registry *Registry) *Installer {
+	return &Installer{
+		registry: registry,
+		lookPath: exec.LookPath,
+		euid:     os.Geteuid,
+	}
+}
+
+// RegisterInstaller registers the installer spec without verifying it.
+// Called for every SystemInstaller in the manifest and in state before execution,
+// so that unchanged installers and installers pending removal are still usable.
+func (i *Installer) RegisterInstaller(name string, spec *resource.SystemInstallerSpec) {
+	i.registry.Register(name, spec)
+}
+
+// Install verifies the installer and returns its state.
+func (i *Installer) Install(_ context.Context, res *resource.SystemInstaller, name string) (*resource.SystemInstallerState, error) {
+	spec := res.SystemInstallerSpec
+	if err := spec.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid system installer %q: %w", name, err)
+	}
+
+	if spec.Privileged && i.euid() != 0 {
+		return nil, fmt.Errorf("system installer %q requires root privileges; run with sudo", name)
+	}
+
+	for _, c := range []resource.CommandSpec{spec.Commands.Install, spec.Commands.Remove, spec.Commands.Check} {
+		if c.IsZero() {
+			continue
+		}
+		if _, err := i.lookPath(c.Command); err != nil {
+			return nil, fmt.Errorf("system installer %q: command %q not found: %w", name, c.Command, err)
+		}
+	}
+
+	i.registry.Register(name, spec)
+	slog.Debug("system installer registered", "name", name)
+
+	return &resource.SystemInstallerState{
+		Pattern:    spec.Pattern,
+		Privileged: spec.Privileged,
+		Commands:   spec.Commands,
+		UpdatedAt:  time.Now(),
+	}, nil
+}
+
+// Remove forgets the installer. The package manager itself is never uninstalled.
+func (i *Installer) Remove(_ context.Context, _ *resource.SystemInstallerState, name string) error {
+	slog.Debug("system installer removed from state", "name", name)
+	return nil
+}
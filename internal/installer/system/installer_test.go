@@ -0,0 +1,323 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/terassyi/tomei/internal/installer/command"
+	"github.com/terassyi/tomei/internal/installer/executor"
+	"github.com/terassyi/tomei/internal/resource"
+)
+
+// mockRunner records executed commands and reports packages in installed as present.
+type mockRunner struct {
+	mu        sync.Mutex
+	executed  []string
+	checked   []string
+	installed map[string]bool
+	failOn    string
+}
+
+func (m *mockRunner) ExecuteWithEnv(_ context.Context, cmds []string, _ command.Vars, _ map[string]string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, c := range cmds {
+		if m.failOn != "" && c == m.failOn {
+			return errors.New("command failed")
+		}
+		m.executed = append(m.executed, c)
+	}
+	return nil
+}
+
+func (m *mockRunner) ExecuteWithOutput(ctx context.Context, cmds []string, vars command.Vars, env map[string]string, _ command.OutputCallback) error {
+	return m.ExecuteWithEnv(ctx, cmds, vars, env)
+}
+
+func (m *mockRunner) Check(_ context.Context, cmds []string, _ command.Vars, _ map[string]string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.checked = append(m.checked, cmds...)
+	for _, c := range cmds {
+		for pkg := range m.installed {
+			if c == "dpkg -s "+pkg {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func testInstallerSpec() *resource.SystemInstallerSpec {
+	return &resource.SystemInstallerSpec{
+		Pattern:    resource.SystemInstallerPatternDelegation,
+		Privileged: true,
+		Commands: resource.SystemInstallerCommandsSpec{
+			Install: resource.CommandSpec{Command: "apt-get", Verb: "install -y"},
+			Remove:  resource.CommandSpec{Command: "apt-get", Verb: "remove -y"},
+			Check:   resource.CommandSpec{Command: "dpkg", Verb: "-s"},
+			Update:  "apt-get update",
+		},
+	}
+}
+
+func newTestSystemInstaller(spec *resource.SystemInstallerSpec) *resource.SystemInstaller {
+	return &resource.SystemInstaller{
+		BaseResource: resource.BaseResource{
+			APIVersion:   "tomei.terassyi.net/v1beta1",
+			ResourceKind: resource.KindSystemInstaller,
+			Metadata:     resource.Metadata{Name: "apt"},
+		},
+		SystemInstallerSpec: spec,
+	}
+}
+
+func newTestPackageSet(packages ...string) *resource.SystemPackageSet {
+	return &resource.SystemPackageSet{
+		BaseResource: resource.BaseResource{
+			APIVersion:   "tomei.terassyi.net/v1beta1",
+			ResourceKind: resource.KindSystemPackageSet,
+			Metadata:     resource.Metadata{Name: "base"},
+		},
+		SystemPackageSetSpec: &resource.SystemPackageSetSpec{
+			InstallerRef: "apt",
+			Packages:     packages,
+		},
+	}
+}
+
+func TestInstaller_Install(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		spec     *resource.SystemInstallerSpec
+		euid     int
+		lookPath func(string) (string, error)
+		wantErr  string
+	}{
+		{
+			name:     "privileged as root",
+			spec:     testInstallerSpec(),
+			euid:     0,
+			lookPath: func(f string) (string, error) { return "/usr/bin/" + f, nil },
+		},
+		{
+			name:     "privileged as non-root",
+			spec:     testInstallerSpec(),
+			euid:     1000,
+			lookPath: func(f string) (string, error) { return "/usr/bin/" + f, nil },
+			wantErr:  "requires root privileges",
+		},
+		{
+			name:     "command not found",
+			spec:     testInstallerSpec(),
+			euid:     0,
+			lookPath: func(string) (string, error) { return "", errors.New("not found") },
+			wantErr:  `command "apt-get" not found`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			registry := newRegistryWithRunner(&mockRunner{})
+			inst := NewInstaller(registry)
+			inst.euid = func() int { return tt.euid }
+			inst.lookPath = tt.lookPath
+
+			st, err := inst.Install(context.Background(), newTestSystemInstaller(tt.spec), "apt")
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, resource.SystemInstallerPatternDelegation, st.Pattern)
+			assert.True(t, st.Privileged)
+			assert.Equal(t, tt.spec.Commands, st.Commands)
+
+			_, err = registry.Get("apt")
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestPackageSetInstaller_InstallSkipsInstalled(t *testing.T) {
+	t.Parallel()
+
+	runner := &mockRunner{installed: map[string]bool{"curl": true}}
+	registry := newRegistryWithRunner(runner)
+	registry.Register("apt", testInstallerSpec())
+
+	st, err := NewPackageSetInstaller(registry).Install(context.Background(), newTestPackageSet("curl", "git", "build-essential"), "base")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"apt-get update", "apt-get install -y git build-essential"}, runner.executed)
+	assert.Equal(t, []string{"curl", "git", "build-essential"}, st.Packages)
+	assert.Equal(t, []string{"git", "build-essential"}, st.Owned)
+	assert.Equal(t, "apt", st.InstallerRef)
+}
+
+func TestPackageSetInstaller_AlreadyPresentIsNotRemoved(t *testing.T) {
+	t.Parallel()
+
+	// curl was present before tomei; git was installed by an earlier apply.
+	runner := &mockRunner{installed: map[string]bool{"curl": true, "git": true}}
+	registry := newRegistryWithRunner(runner)
+	registry.Register("apt", testInstallerSpec())
+	installer := NewPackageSetInstaller(registry)
+
+	ctx := executor.WithOwnedPackages(context.Background(), []string{"git", "jq"})
+	st, err := installer.Install(ctx, newTestPackageSet("curl", "git", "make"), "base")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"apt-get update", "apt-get install -y make"}, runner.executed)
+	assert.Equal(t, []string{"git", "make"}, st.Owned)
+
+	runner.executed = nil
+	require.NoError(t, installer.Remove(context.Background(), st, "base"))
+	assert.Equal(t, []string{"apt-get remove -y git make"}, runner.executed)
+}
+
+func TestPackageSetInstaller_AllInstalled(t *testing.T) {
+	t.Parallel()
+
+	runner := &mockRunner{installed: map[string]bool{"curl": true}}
+	registry := newRegistryWithRunner(runner)
+	registry.Register("apt", testInstallerSpec())
+
+	st, err := NewPackageSetInstaller(registry).Install(context.Background(), newTestPackageSet("curl"), "base")
+	require.NoError(t, err)
+	assert.Empty(t, runner.executed)
+	assert.Empty(t, st.Owned)
+
+	// Nothing is owned, so nothing is removed.
+	require.NoError(t, NewPackageSetInstaller(registry).Remove(context.Background(), st, "base"))
+	assert.Empty(t, runner.executed)
+}
+
+func TestPackageSetInstaller_Remove(t *testing.T) {
+	t.Parallel()
+
+	runner := &mockRunner{}
+	registry := newRegistryWithRunner(runner)
+	registry.Register("apt", testInstallerSpec())
+
+	err := NewPackageSetInstaller(registry).Remove(context.Background(), &resource.SystemPackageSetState{
+		InstallerRef: "apt",
+		Packages:     []string{"git", "jq", "curl"},
+		Owned:        []string{"git", "jq"},
+	}, "base")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"apt-get remove -y git jq"}, runner.executed)
+}
+
+func TestPackageSetInstaller_UnknownInstaller(t *testing.T) {
+	t.Parallel()
+
+	registry := newRegistryWithRunner(&mockRunner{})
+	set := newTestPackageSet("git")
+	set.SystemPackageSetSpec.InstallerRef = "unknown"
+	_, err := NewPackageSetInstaller(registry).Install(context.Background(), set, "base")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `system installer "unknown" is not defined`)
+}
+
+func TestPackageSetInstaller_InstallError(t *testing.T) {
+	t.Parallel()
+
+	runner := &mockRunner{failOn: "apt-get install -y git"}
+	registry := newRegistryWithRunner(runner)
+	registry.Register("apt", testInstallerSpec())
+
+	_, err := NewPackageSetInstaller(registry).Install(context.Background(), newTestPackageSet("git"), "base")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to install packages for base")
+}
+
+// fakeRepoBackend is a Backend that also implements RepositoryManager.
+type fakeRepoBackend struct {
+	added   map[string]resource.SourceConfig
+	removed []string
+}
+
+func (f *fakeRepoBackend) InstallPackages(context.Context, []string) error { return nil }
+func (f *fakeRepoBackend) RemovePackages(context.Context, []string) error  { return nil }
+func (f *fakeRepoBackend) IsInstalled(context.Context, string) bool        { return false }
+
+func (f *fakeRepoBackend) AddRepository(_ context.Context, name string, source resource.SourceConfig) ([]string, error) {
+	f.added[name] = source
+	return []string{"/etc/apt/sources.list.d/" + name + ".sources"}, nil
+}
+
+func (f *fakeRepoBackend) RemoveRepository(_ context.Context, _ string, files []string) error {
+	f.removed = append(f.removed, files...)
+	return nil
+}
+
+func TestRepositoryInstaller(t *testing.T) {
+	t.Parallel()
+
+	backend := &fakeRepoBackend{added: map[string]resource.SourceConfig{}}
+	registry := newRegistryWithRunner(&mockRunner{})
+	registry.RegisterBackend("apt", backend)
+	inst := NewRepositoryInstaller(registry)
+
+	repo := &resource.SystemPackageRepository{
+		BaseResource: resource.BaseResource{
+			APIVersion:   "tomei.terassyi.net/v1beta1",
+			ResourceKind: resource.KindSystemPackageRepository,
+			Metadata:     resource.Metadata{Name: "docker"},
+		},
+		SystemPackageRepositorySpec: &resource.SystemPackageRepositorySpec{
+			InstallerRef: "apt",
+			Source: resource.SourceConfig{
+				URL:     "https://download.docker.com/linux/ubuntu",
+				Options: map[string]string{"components": "stable"},
+			},
+		},
+	}
+
+	st, err := inst.Install(context.Background(), repo, "docker")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/etc/apt/sources.list.d/docker.sources"}, st.InstalledFiles)
+	assert.Equal(t, "stable", st.Source.Options["components"])
+	assert.Contains(t, backend.added, "docker")
+
+	require.NoError(t, inst.Remove(context.Background(), st, "docker"))
+	assert.True(t, slices.Equal(st.InstalledFiles, backend.removed))
+}
+
+func TestRepositoryInstaller_Unsupported(t *testing.T) {
+	t.Parallel()
+
+	registry := newRegistryWithRunner(&mockRunner{})
+	registry.Register("custom", testInstallerSpec())
+
+	repo := &resource.SystemPackageRepository{
+		BaseResource: resource.BaseResource{
+			APIVersion:   "tomei.terassyi.net/v1beta1",
+			ResourceKind: resource.KindSystemPackageRepository,
+			Metadata:     resource.Metadata{Name: "repo"},
+		},
+		SystemPackageRepositorySpec: &resource.SystemPackageRepositorySpec{
+			InstallerRef: "custom",
+			Source:       resource.SourceConfig{URL: "https://example.com"},
+		},
+	}
+	_, err := NewRepositoryInstaller(registry).Install(context.Background(), repo, "repo")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not support package repositories")
+}
+
+func TestShellJoin(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "apt-get install -y libc6:amd64 nginx=1.2", shellJoin([]string{"apt-get", "install", "-y", "libc6:amd64", "nginx=1.2"}))
+	assert.Equal(t, `echo 'a b' 'it'\''s' ''`, shellJoin([]string{"echo", "a b", "it's", ""}))
+}
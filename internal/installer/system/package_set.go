@@ -0,0 +1,97 @@
+package system
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/terassyi/tomei/internal/installer/executor"
+	"github.com/terassyi/tomei/internal/resource"
+)
+
+// PackageSetInstaller installs SystemPackageSet resources.
+type PackageSetInstaller struct {
+	registry *Registry
+}
+
+// NewPackageSetInstaller creates a new SystemPackageSet installer backed by the registry.
+func NewPackageSetInstaller(registry *Registry) *PackageSetInstaller {
+	return &PackageSetInstaller{registry: registry}
+}
+
+// Install installs every package in the set that is not already installed
+// and returns the resulting state. Only the packages installed here, and
+// those recorded as installed by tomei before, are owned by the set.
+func (i *PackageSetInstaller) Install(ctx context.Context, res *resource.SystemPackageSet, name string) (*resource.SystemPackageSetState, error) {
+	spec := res.SystemPackageSetSpec
+	backend, err := i.registry.Get(spec.InstallerRef)
+	if err != nil {
+		return nil, err
+	}
+
+	prevOwned := executor.OwnedPackagesFromContext(ctx)
+	var missing, owned []string
+	for _, pkg := range spec.Packages {
+		if slices.Contains(prevOwned, pkg) {
+			owned = append(owned, pkg)
+		}
+		if backend.IsInstalled(ctx, pkg) {
+			slog.Debug("system package already installed", "set", name, "package", pkg)
+			continue
+		}
+		missing = append(missing, pkg)
+		if !slices.Contains(owned, pkg) {
+			owned = append(owned, pkg)
+		}
+	}
+
+	if len(missing) > 0 {
+		slog.Debug("installing system packages", "set", name, "packages", missing)
+		if err := backend.InstallPackages(ctx, missing); err != nil {
+			return nil, fmt.Errorf("failed to install packages for %s: %w", name, err)
+		}
+	}
+
+	var versions map[string]string
+	if q, ok := backend.(VersionQuerier); ok {
+		versions, err = q.InstalledVersions(ctx, spec.Packages)
+		if err != nil {
+			slog.Warn("failed to query installed package versions", "set", name, "error", err)
+		}
+	}
+
+	return &resource.SystemPackageSetState{
+		InstallerRef:      spec.InstallerRef,
+		RepositoryRef:     spec.RepositoryRef,
+		Packages:          append([]string(nil), spec.Packages...),
+		Owned:             owned,
+		InstalledVersions: versions,
+		UpdatedAt:         time.Now(),
+	}, nil
+}
+
+// Remove removes the packages of the state that tomei installed. Packages
+// that were already installed before are left in place.
+func (i *PackageSetInstaller) Remove(ctx context.Context, st *resource.SystemPackageSetState, name string) error {
+	var owned []string
+	for _, pkg := range st.Packages {
+		if slices.Contains(st.Owned, pkg) {
+			owned = append(owned, pkg)
+		}
+	}
+	if len(owned) == 0 {
+		slog.Debug("no system packages owned by set", "set", name)
+		return nil
+	}
+	backend, err := i.registry.Get(st.InstallerRef)
+	if err != nil {
+		return err
+	}
+	slog.Debug("removing system packages", "set", name, "packages", owned)
+	if err := backend.RemovePackages(ctx, owned); err != nil {
+		return fmt.Errorf("failed to remove packages for %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package system
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"time"
+
+	"github.com/terassyi/tomei/internal/resource"
+)
+
+// RepositoryInstaller installs SystemPackageRepository resources.
+// The referenced installer's backend must implement RepositoryManager.
+type RepositoryInstaller struct {
+	registry *Registry
+}
+
+// NewRepositoryInstaller creates a new SystemPackageRepository installer backed by the registry.
+func NewRepositoryInstaller(registry *Registry) *RepositoryInstaller {
+	return &RepositoryInstaller{registry: registry}
+}
+
+// Install configures the repository and returns its state.
+func (i *RepositoryInstaller) Install(ctx context.Context, res *resource.SystemPackageRepository, name string) (*resource.SystemPackageRepositoryState, error) {
+	spec := res.SystemPackageRepositorySpec
+	mgr, err := i.repositoryManager(spec.InstallerRef)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := mgr.AddRepository(ctx, name, spec.Source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add repository %s: %w", name, err)
+	}
+	slog.Debug("system package repository configured", "name", name, "files", files)
+
+	source := spec.Source
+	source.Options = maps.Clone(spec.Source.Options)
+	return &resource.SystemPackageRepositoryState{
+		InstallerRef:   spec.InstallerRef,
+		Source:         source,
+		InstalledFiles: files,
+		UpdatedAt:      time.Now(),
+	}, nil
+}
+
+// Remove removes every file recorded in the repository state.
+func (i *RepositoryInstaller) Remove(ctx context.Context, st *resource.SystemPackageRepositoryState, name string) error {
+	mgr, err := i.repositoryManager(st.InstallerRef)
+	if err != nil {
+		return err
+	}
+	if err := mgr.RemoveRepository(ctx, name, st.InstalledFiles); err != nil {
+		return fmt.Errorf("failed to remove repository %s: %w", name, err)
+	}
+	slog.Debug("system package repository removed", "name", name)
+	return nil
+}
+
+// repositoryManager returns the installer's backend as a RepositoryManager.
+func (i *RepositoryInstaller) repositoryManager(installerRef string) (RepositoryManager, error) {
+	backend, err := i.registry.Get(installerRef)
+	if err != nil {
+		return nil, err
+	}
+	mgr, ok := backend.(RepositoryManager)
+	if !ok {
+		return nil, fmt.Errorf("system installer %q does not support package repositories", installerRef)
+	}
+	return mgr, nil
+}
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/terassyi/tomei/internal/checksum"
+	"github.com/terassyi/tomei/internal/credential"
 	"github.com/terassyi/tomei/internal/installer"
 	"github.com/terassyi/tomei/internal/installer/command"
 	"github.com/terassyi/tomei/internal/installer/download"
@@ -21,8 +22,10 @@ import (
 	"github.com/terassyi/tomei/internal/installer/extract"
 	"github.com/terassyi/tomei/internal/installer/place"
 	"github.com/terassyi/tomei/internal/installer/resolve"
+	"github.com/terassyi/tomei/internal/mirror"
 	"github.com/terassyi/tomei/internal/registry/aqua"
 	"github.com/terassyi/tomei/internal/resource"
+	"github.com/terassyi/tomei/internal/verify"
 )
 
 // RuntimeInfo contains the information needed to install tools via runtime delegation.
@@ -36,9 +39,14 @@ type RuntimeInfo struct {
 
 // InstallerInfo contains the information needed to install tools via installer delegation.
 type InstallerInfo struct {
-	Type     resource.InstallType // "download" or "delegation"
-	ToolRef  string               // Reference to tool (optional, e.g., cargo-binstall)
-	Commands *resource.CommandsSpec
+	Type          resource.InstallType // "download" or "delegation"
+	ToolRef       string               // Reference to tool (optional, e.g., cargo-binstall)
+	Commands      *resource.CommandsSpec
+	CredentialRef string // Credential for tools downloaded by this installer (optional)
+
+	// SignaturePolicy controls the verification of upstream signatures of
+	// registry tools (empty means resource.SignaturePolicyWarn).
+	SignaturePolicy resource.SignaturePolicy
 }
 
 // CommandRunner is the interface for executing shell commands.
@@ -59,10 +67,14 @@ type Installer struct {
 	runtimes         map[string]*RuntimeInfo   // name -> RuntimeInfo
 	installers       map[string]*InstallerInfo // name -> InstallerInfo
 	toolBinPaths     map[string]string         // installer name -> tool bin directory
+	pins             map[string]installer.Pin  // tool name -> version/digest pinned by tomei.lock
 	resolver         *aqua.Resolver            // aqua-registry resolver (optional)
 	registryRef      aqua.RegistryRef          // aqua-registry version ref (e.g., "v4.465.0")
 	progressCallback download.ProgressCallback // optional progress callback
 	outputCallback   download.OutputCallback   // optional output callback for delegation
+	offline          bool                      // reject install patterns that need the network
+	credentials      credential.Set            // credentials referenced by credentialRef
+	signatures       SignatureVerifier         // upstream signature verifier (optional)
 }
 
 // NewInstaller creates a new tool Installer.
@@ -110,6 +122,42 @@ func (i *Installer) SetToolBinPaths(paths map[string]string) {
 	i.toolBinPaths = paths
 }
 
+// SetPins sets the versions and digests pinned by tomei.lock, keyed by tool name.
+// Registry tools with "latest" versions install the pinned version instead of
+// resolving it, and downloaded archives are verified against the pinned digest.
+func (i *Installer) SetPins(pins map[string]installer.Pin) {
+	i.pins = pins
+}
+
+// SetOffline enables offline mode (apply --bundle). Tools installed by
+// commands or by runtime/installer delegation fetch from the network on
+// their own, so they fail with an explicit error instead of being attempted.
+func (i *Installer) SetOffline(offline bool) {
+	i.offline = offline
+}
+
+// SetCredentials sets the credentials that tools and installers reference
+// via credentialRef.
+func (i *Installer) SetCredentials(creds credential.Set) {
+	i.credentials = creds
+}
+
+// CredentialContext returns ctx carrying the credential of the tool: its own
+// credentialRef, or else the credentialRef of its installer.
+func (i *Installer) CredentialContext(ctx context.Context, res *resource.Tool) (context.Context, error) {
+	ref := res.ToolSpec.CredentialRef
+	if ref == "" {
+		if info, ok := i.installers[res.ToolSpec.InstallerRef]; ok {
+			ref = info.CredentialRef
+		}
+	}
+	ctx, err := i.credentials.Context(ctx, ref)
+	if err != nil {
+		return nil, fmt.Errorf("tool %s: %w", res.Name(), err)
+	}
+	return ctx, nil
+}
+
 // buildEnvWithToolPath builds an environment map with the tool's bin directory prepended to PATH.
 // This ensures installer delegation commands (e.g., helm pull) can find their toolRef binary.
 func (i *Installer) buildEnvWithToolPath(installerName string) map[string]string {
@@ -192,6 +240,17 @@ func (i *Installer) Install(ctx context.Context, res *resource.Tool, name string
 
 	slog.Debug("installing tool", "name", name, "version", spec.Version)
 
+	if i.offline {
+		if err := i.checkOffline(spec, name); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, err := i.CredentialContext(ctx, res)
+	if err != nil {
+		return nil, err
+	}
+
 	// Determine installation pattern
 	// 1. If commands is set, use self-managed commands pattern
 	if spec.Commands != nil {
@@ -219,6 +278,26 @@ func (i *Installer) Install(ctx context.Context, res *resource.Tool, name string
 	return i.installByDownload(ctx, res, name, nil)
 }
 
+// checkOffline returns an error if the tool is installed by a pattern that
+// cannot run from an offline bundle.
+func (i *Installer) checkOffline(spec *resource.ToolSpec, name string) error {
+	var pattern string
+	switch {
+	case spec.Commands != nil:
+		pattern = "commands"
+	case spec.RuntimeRef != "":
+		pattern = fmt.Sprintf("runtime delegation (%s)", spec.RuntimeRef)
+	default:
+		if info, ok := i.installers[spec.InstallerRef]; ok && info.Type == resource.InstallTypeDelegation {
+			pattern = fmt.Sprintf("installer delegation (%s)", spec.InstallerRef)
+		}
+	}
+	if pattern == "" {
+		return nil
+	}
+	return fmt.Errorf("tool %s is installed by %s, which is not supported in offline mode", name, pattern)
+}
+
 // installByDownload installs a tool using the download pattern.
 // cfg overrides default configuration (binary name mapping, etc.); nil uses defaults.
 func (i *Installer) installByDownload(ctx context.Context, res *resource.Tool, name string, cfg *installer.InstallConfig) (*resource.ToolState, error) {
@@ -248,6 +327,10 @@ func (i *Installer) installByDownload(ctx context.Context, res *resource.Tool, n
 		return nil, fmt.Errorf("source is required for download pattern")
 	}
 
+	if err := i.pins[name].CheckURL(spec.Version, spec.Source.URL); err != nil {
+		return nil, err
+	}
+
 	// Get expected hash for validation
 	var expectedHash checksum.Digest
 	if spec.Source.Checksum != nil {
@@ -268,9 +351,23 @@ func (i *Installer) installByDownload(ctx context.Context, res *resource.Tool, n
 		return nil, fmt.Errorf("failed to validate: %w", err)
 	}
 
+	// The digest recorded at install time takes precedence over the source
+	// checksum: a binary modified after installation is downloaded again.
+	if action != place.ValidateActionInstall {
+		action, err = i.checkRecordedBinary(ctx, target, action)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	switch action {
 	case place.ValidateActionSkip:
 		slog.Debug("tool already installed, skipping", "name", name, "version", spec.Version)
+		if cfg.VerifyInstalled != nil {
+			if err := cfg.VerifyInstalled(ctx, i.placer.BinaryPath(target)); err != nil {
+				return nil, err
+			}
+		}
 		// Even if binary exists, ensure symlink points to correct version
 		linkPath, err := i.placer.Symlink(target)
 		if err != nil {
@@ -278,7 +375,7 @@ func (i *Installer) installByDownload(ctx context.Context, res *resource.Tool, n
 		}
 		// Clean up old symlink if binaryName changed (e.g., upgrade with same binary but new name)
 		i.cleanupOldSymlink(ctx, linkPath)
-		return i.buildState(spec, target, expectedHash), nil
+		return withBinaryDigest(i.buildState(spec, target, expectedHash)), nil
 
 	case place.ValidateActionReplace:
 		if !cfg.Force {
@@ -306,7 +403,9 @@ func (i *Installer) installByDownload(ctx context.Context, res *resource.Tool, n
 	if progressCb == nil {
 		progressCb = i.progressCallback
 	}
-	_, err = i.downloader.DownloadWithProgress(ctx, spec.Source.URL, archivePath, progressCb)
+	mirrors := mirror.NewRecorder()
+	dlCtx := download.WithExpectedDigest(mirror.NewContext(ctx, mirrors), download.ExpectedDigest(i.pins[name].DigestFor(spec.Version), spec.Source.Checksum))
+	_, err = i.downloader.DownloadWithProgress(dlCtx, spec.Source.URL, archivePath, progressCb)
 	if err != nil {
 		return nil, fmt.Errorf("failed to download: %w", err)
 	}
@@ -316,6 +415,21 @@ func (i *Installer) installByDownload(ctx context.Context, res *resource.Tool, n
 		return nil, fmt.Errorf("failed to verify checksum: %w", err)
 	}
 
+	// Verify against the lockfile and record the archive digest when the spec has none
+	digest, err := i.pins[name].VerifyDigest(archivePath, spec.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.VerifyArchive != nil {
+		if err := cfg.VerifyArchive(ctx, archivePath); err != nil {
+			return nil, err
+		}
+	}
+	if expectedHash == "" {
+		expectedHash = digest
+	}
+
 	// Determine archive type: use explicit value or auto-detect from URL
 	archiveType := spec.Source.ArchiveType
 	if archiveType == "" {
@@ -332,9 +446,9 @@ func (i *Installer) installByDownload(ctx context.Context, res *resource.Tool, n
 		return nil, fmt.Errorf("failed to create extractor: %w", err)
 	}
 
-	// For raw binaries, use tool name as subdirectory so the binary gets the correct name
+	// For single binaries (raw or compressed), use tool name as subdirectory so the binary gets the correct name
 	extractDir := filepath.Join(tmpDir, "extracted")
-	if archiveType == extract.ArchiveTypeRaw {
+	if archiveType.IsSingleFile() {
 		extractDir = filepath.Join(tmpDir, "extracted", name)
 	}
 
@@ -344,12 +458,12 @@ func (i *Installer) installByDownload(ctx context.Context, res *resource.Tool, n
 	}
 	defer archiveFile.Close()
 
-	if err := extractor.Extract(archiveFile, extractDir); err != nil {
+	if err := extract.ExtractContext(ctx, extractor, archiveFile, extractDir); err != nil {
 		return nil, fmt.Errorf("failed to extract: %w", err)
 	}
 
 	// Reset extractDir for placer to search from
-	if archiveType == extract.ArchiveTypeRaw {
+	if archiveType.IsSingleFile() {
 		extractDir = filepath.Join(tmpDir, "extracted")
 	}
 
@@ -371,13 +485,199 @@ func (i *Installer) installByDownload(ctx context.Context, res *resource.Tool, n
 
 	slog.Debug("tool installed successfully", "name", name, "version", spec.Version, "path", result.BinaryPath)
 
-	return i.buildState(spec, target, expectedHash), nil
+	st := i.buildState(spec, target, expectedHash)
+	st.Mirror = mirrors.Mirror(spec.Source.URL)
+	return withBinaryDigest(st), nil
+}
+
+// checkRecordedBinary compares the placed binary with the digest recorded
+// when it was installed. A drifted binary is removed together with its
+// version directory and ValidateActionInstall is returned so that it is
+// downloaded again; a matching binary is kept.
+func (i *Installer) checkRecordedBinary(ctx context.Context, target place.Target, action place.ValidateAction) (place.ValidateAction, error) {
+	binaryPath := i.placer.BinaryPath(target)
+	recorded := executor.ContentDigestFromContext(ctx)
+	if recorded.Digest == "" || recorded.Path != binaryPath {
+		return action, nil
+	}
+	if current, err := checksum.Calculate(binaryPath, checksum.AlgorithmSHA256); err == nil && current == recorded.Digest {
+		return place.ValidateActionSkip, nil
+	}
+	slog.Warn("installed binary differs from the recorded digest, downloading it again", "name", target.Name, "path", binaryPath)
+	if err := i.placer.Cleanup(filepath.Dir(binaryPath)); err != nil {
+		return action, fmt.Errorf("failed to remove drifted binary: %w", err)
+	}
+	return place.ValidateActionInstall, nil
+}
+
+// withBinaryDigest records the digest of the installed binary in st.
+// Hashing is best-effort: a binary that cannot be read is left unrecorded.
+func withBinaryDigest(st *resource.ToolState) *resource.ToolState {
+	p := st.InstallPath
+	if p == "" {
+		p = st.BinPath
+	}
+	if p == "" {
+		return st
+	}
+	digest, err := checksum.Calculate(p, checksum.AlgorithmSHA256)
+	if err != nil {
+		slog.Debug("failed to record binary digest", "path", p, "error", err)
+		return st
+	}
+	st.BinaryDigest = digest
+	return st
 }
 
 // installFromRegistry installs a tool using aqua-registry to resolve the download URL.
 func (i *Installer) installFromRegistry(ctx context.Context, res *resource.Tool, name string) (*resource.ToolState, error) {
 	spec := res.ToolSpec
 
+	resolved, err := i.resolveRegistryTool(ctx, res, name)
+	if err != nil {
+		return nil, err
+	}
+	if resolved.delegation != nil {
+		return i.installRegistryByRuntime(ctx, res, name, resolved)
+	}
+
+	// Verify the upstream signatures of the archive before it is extracted
+	var signature *resource.SignatureState
+	policy := i.signaturePolicy(spec.InstallerRef)
+	if policy != resource.SignaturePolicyOff {
+		resolved.cfg.VerifyArchive = func(ctx context.Context, archivePath string) error {
+			st, err := i.verifySignatures(ctx, spec.Package.String(), policy, resolved.signatures, archivePath)
+			signature = st
+			return err
+		}
+		resolved.cfg.VerifyInstalled = func(ctx context.Context, binaryPath string) error {
+			st, err := i.installedSignature(ctx, spec.Package.String(), policy, binaryPath)
+			signature = st
+			return err
+		}
+	}
+
+	// Use existing download logic (name = resource name for storage path)
+	state, err := i.installByDownload(ctx, resolved.tool, name, resolved.cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update state to include package info and original spec version
+	state.Signature = signature
+	state.Package = spec.Package
+	state.VersionKind = resource.ClassifyVersion(spec.Version)
+	state.SpecVersion = spec.Version // preserve original spec version (e.g., "" for latest)
+
+	return state, nil
+}
+
+// installRegistryByRuntime installs a go_install or cargo registry package by
+// delegating to the runtime that builds it from source.
+func (i *Installer) installRegistryByRuntime(ctx context.Context, res *resource.Tool, name string, resolved *registryResolution) (*resource.ToolState, error) {
+	spec := res.ToolSpec
+	d := resolved.delegation
+
+	if i.offline {
+		return nil, fmt.Errorf("tool %s is installed by runtime delegation (%s), which is not supported in offline mode", name, d.Runtime)
+	}
+	if _, ok := i.runtimes[d.Runtime]; !ok {
+		return nil, fmt.Errorf("package %s is built from source and requires a Runtime named %q", spec.Package.String(), d.Runtime)
+	}
+
+	// Install as a runtime delegation tool. The binary name comes from the
+	// registry files metadata so that BinPath points at the built binary.
+	delegated := &resource.Tool{
+		BaseResource: res.BaseResource,
+		ToolSpec: &resource.ToolSpec{
+			InstallerRef: spec.InstallerRef,
+			RuntimeRef:   d.Runtime,
+			Version:      d.Version,
+			Enabled:      spec.Enabled,
+			Package:      &resource.Package{Name: d.Package},
+			BinaryName:   resolved.cfg.BinaryName,
+			Args:         spec.Args,
+		},
+	}
+	state, err := i.installByRuntime(ctx, delegated, name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Record the registry package and version, so that the spec keeps matching state
+	state.Version = resolved.tool.ToolSpec.Version
+	state.VersionKind = resource.ClassifyVersion(spec.Version)
+	state.SpecVersion = spec.Version
+	state.Package = spec.Package
+	state.BinaryName = spec.BinaryName
+
+	return state, nil
+}
+
+// ResolveSource returns the tool spec with the version and download source
+// resolved as the download pattern would install them, and the upstream
+// signatures of the archive (nil if none are declared). Registry packages are
+// resolved through aqua-registry (honoring pins); tools with an explicit source
+// are returned as-is. Returns an error for tools that are not installed by download.
+func (i *Installer) ResolveSource(ctx context.Context, res *resource.Tool, name string) (*resource.ToolSpec, *verify.ArtifactSignatures, error) {
+	spec := res.ToolSpec
+	if spec.Package.IsRegistry() {
+		resolved, err := i.resolveRegistryTool(ctx, res, name)
+		if err != nil {
+			return nil, nil, err
+		}
+		if resolved.delegation != nil {
+			return nil, nil, fmt.Errorf("tool %s is installed by runtime delegation (%s)", name, resolved.delegation.Runtime)
+		}
+		return resolved.tool.ToolSpec, resolved.signatures, nil
+	}
+	if spec.Source == nil {
+		return nil, nil, fmt.Errorf("tool %s is not installed by the download pattern", name)
+	}
+	return spec, nil, nil
+}
+
+// RegistryRuntime returns the name of the runtime that a registry tool is
+// delegated to (go_install and cargo packages), or an empty string if the
+// tool is not a registry package or is downloaded.
+// The aqua-registry resolver must be configured.
+func (i *Installer) RegistryRuntime(ctx context.Context, res *resource.Tool) (string, error) {
+	spec := res.ToolSpec
+	if spec == nil || spec.Commands != nil || spec.RuntimeRef != "" || !spec.Package.IsRegistry() {
+		return "", nil
+	}
+	if info, ok := i.installers[spec.InstallerRef]; ok && info.Type == resource.InstallTypeDelegation {
+		return "", nil
+	}
+	if i.resolver == nil || i.registryRef == "" {
+		return "", nil
+	}
+	info, err := i.resolver.FetchPackageInfo(ctx, i.registryRef, spec.Package.String())
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch package info for %s: %w", spec.Package.String(), err)
+	}
+	return info.DelegationRuntime(), nil
+}
+
+// registryResolution is a registry tool resolved for installation.
+type registryResolution struct {
+	// tool is a copy of the tool with the resolved version and download source.
+	// Source is nil for packages installed by delegation.
+	tool *resource.Tool
+	// cfg is the install config derived from the registry files metadata.
+	cfg *installer.InstallConfig
+	// delegation is set for go_install and cargo packages, which are built
+	// from source by a runtime instead of downloaded.
+	delegation *aqua.Delegation
+	// signatures are the upstream signatures of the archive (nil if none).
+	signatures *verify.ArtifactSignatures
+}
+
+// resolveRegistryTool resolves the version and download source of a registry
+// tool, together with the install config derived from the registry files metadata.
+func (i *Installer) resolveRegistryTool(ctx context.Context, res *resource.Tool, name string) (*registryResolution, error) {
+	spec := res.ToolSpec
+
 	// Check if resolver is configured
 	if i.resolver == nil {
 		return nil, fmt.Errorf("aqua-registry resolver not configured")
@@ -389,7 +689,10 @@ func (i *Installer) installFromRegistry(ctx context.Context, res *resource.Tool,
 	// Determine version: use spec.Version or fetch latest
 	pkgName := spec.Package.String()
 	version := spec.Version
-	if resource.IsLatestVersion(version) {
+	if pin, ok := i.pins[name]; ok && !resource.IsExactVersion(version) && pin.Version != "" {
+		version = pin.Version
+		slog.Debug("using version pinned in lockfile", "package", pkgName, "version", version)
+	} else if resource.IsLatestVersion(version) {
 		slog.Debug("fetching latest version from registry", "package", pkgName)
 		// Fetch package info to get repo owner/name for version lookup
 		info, err := i.resolver.FetchPackageInfo(ctx, i.registryRef, pkgName)
@@ -426,16 +729,19 @@ func (i *Installer) installFromRegistry(ctx context.Context, res *resource.Tool,
 	}
 
 	// Build DownloadSource from resolved info
-	source := &resource.DownloadSource{
-		URL:         resolved.URL,
-		ArchiveType: resolved.Format,
-	}
+	var source *resource.DownloadSource
+	if resolved.Delegation == nil {
+		source = &resource.DownloadSource{
+			URL:         resolved.URL,
+			ArchiveType: resolved.Format,
+		}
 
-	// Add checksum if available
-	if resolved.ChecksumURL != "" {
-		source.Checksum = &resource.Checksum{
-			URL:       resolved.ChecksumURL,
-			Algorithm: resolved.ChecksumAlgorithm,
+		// Add checksum if available
+		if resolved.ChecksumURL != "" {
+			source.Checksum = &resource.Checksum{
+				URL:       resolved.ChecksumURL,
+				Algorithm: resolved.ChecksumAlgorithm,
+			}
 		}
 	}
 
@@ -459,18 +765,12 @@ func (i *Installer) installFromRegistry(ctx context.Context, res *resource.Tool,
 			"package", spec.Package.String(), "fileCount", len(resolved.Files))
 	}
 
-	// Use existing download logic (name = resource name for storage path)
-	state, err := i.installByDownload(ctx, resolvedTool, name, cfg)
-	if err != nil {
-		return nil, err
-	}
-
-	// Update state to include package info and original spec version
-	state.Package = spec.Package
-	state.VersionKind = resource.ClassifyVersion(spec.Version)
-	state.SpecVersion = spec.Version // preserve original spec version (e.g., "" for latest)
-
-	return state, nil
+	return &registryResolution{
+		tool:       resolvedTool,
+		cfg:        cfg,
+		delegation: resolved.Delegation,
+		signatures: resolved.Signatures,
+	}, nil
 }
 
 // extractBinaryMapping builds an InstallConfig from aqua registry files metadata.
@@ -694,31 +994,14 @@ func (i *Installer) installByRuntime(ctx context.Context, res *resource.Tool, na
 		Args:    strings.Join(spec.Args, " "),
 	}
 
-	// Build environment with PATH including runtime's bin directory
-	env := make(map[string]string)
-	maps.Copy(env, info.Env)
-	// Add runtime's bin directory to PATH so commands like "go" or "pnpm" can be found.
-	// Download pattern: use InstallPath/bin (e.g., /runtimes/go/1.25.5/bin)
-	// Delegation pattern: use BinDir (e.g., ~/.local/share/pnpm)
-	var runtimeBinDir string
-	if info.InstallPath != "" {
-		runtimeBinDir = filepath.Join(info.InstallPath, "bin")
-	} else {
-		runtimeBinDir = info.BinDir
-	}
-	if runtimeBinDir != "" {
-		if currentPath := os.Getenv("PATH"); currentPath != "" {
-			env["PATH"] = runtimeBinDir + string(os.PathListSeparator) + currentPath
-		} else {
-			env["PATH"] = runtimeBinDir
+	// Execute install command with runtime's environment and output streaming,
+	// unless a batchInstall command has already installed the tool
+	if !executor.BatchInstalledFromContext(ctx) {
+		if err := i.executeCommand(ctx, info.Commands.Install, vars, runtimeEnv(info)); err != nil {
+			return nil, fmt.Errorf("failed to execute install command: %w", err)
 		}
 	}
 
-	// Execute install command with runtime's environment and output streaming
-	if err := i.executeCommand(ctx, info.Commands.Install, vars, env); err != nil {
-		return nil, fmt.Errorf("failed to execute install command: %w", err)
-	}
-
 	slog.Debug("tool installed via runtime", "name", name, "version", spec.Version, "runtime", spec.RuntimeRef)
 
 	// Clean up old binary when binaryName changes on upgrade/reinstall.
@@ -733,7 +1016,31 @@ func (i *Installer) installByRuntime(ctx context.Context, res *resource.Tool, na
 		}
 	}
 
-	return i.buildDelegationState(spec, vars.BinPath), nil
+	return withBinaryDigest(i.buildDelegationState(spec, vars.BinPath)), nil
+}
+
+// runtimeEnv returns the environment of the runtime's delegation commands:
+// its Env with the runtime's bin directory prepended to PATH, so that
+// commands like "go" or "pnpm" can be found.
+func runtimeEnv(info *RuntimeInfo) map[string]string {
+	env := make(map[string]string)
+	maps.Copy(env, info.Env)
+	// Download pattern: use InstallPath/bin (e.g., /runtimes/go/1.25.5/bin)
+	// Delegation pattern: use BinDir (e.g., ~/.local/share/pnpm)
+	var runtimeBinDir string
+	if info.InstallPath != "" {
+		runtimeBinDir = filepath.Join(info.InstallPath, "bin")
+	} else {
+		runtimeBinDir = info.BinDir
+	}
+	if runtimeBinDir != "" {
+		if currentPath := os.Getenv("PATH"); currentPath != "" {
+			env["PATH"] = runtimeBinDir + string(os.PathListSeparator) + currentPath
+		} else {
+			env["PATH"] = runtimeBinDir
+		}
+	}
+	return env
 }
 
 // installByInstaller installs a tool using Installer delegation (e.g., brew install).
@@ -762,9 +1069,12 @@ func (i *Installer) installByInstaller(ctx context.Context, res *resource.Tool,
 	// Build environment with PATH including the installer's toolRef binary directory
 	env := i.buildEnvWithToolPath(spec.InstallerRef)
 
-	// Execute install command with output streaming
-	if err := i.executeCommand(ctx, info.Commands.Install, vars, env); err != nil {
-		return nil, fmt.Errorf("failed to execute install command: %w", err)
+	// Execute install command with output streaming, unless a batchInstall
+	// command has already installed the tool
+	if !executor.BatchInstalledFromContext(ctx) {
+		if err := i.executeCommand(ctx, info.Commands.Install, vars, env); err != nil {
+			return nil, fmt.Errorf("failed to execute install command: %w", err)
+		}
 	}
 
 	slog.Debug("tool installed via installer", "name", name, "version", spec.Version, "installer", spec.InstallerRef)
@@ -772,6 +1082,116 @@ func (i *Installer) installByInstaller(ctx context.Context, res *resource.Tool,
 	return i.buildDelegationState(spec, ""), nil
 }
 
+// BatchInstall installs tools that share a runtime or installer with one
+// invocation of its batchInstall command, then checks each tool with the
+// check command (or, for runtime delegation, that the batch wrote a new
+// binary to toolBinPath, since an upgrade leaves the old one in place).
+// It returns the names of the tools that passed; their Install only records
+// state when called with executor.WithBatchInstalled. The other tools are
+// left to be installed individually, which reports their errors per tool.
+// Nothing is installed when the runtime or installer has no batchInstall
+// command, when there is no way to check the tools, or when the batch fails.
+func (i *Installer) BatchInstall(ctx context.Context, tools []*resource.Tool) map[string]bool {
+	if len(tools) < 2 || i.offline {
+		return nil
+	}
+
+	// Tools with args or self-managed commands are installed individually
+	var batch []*resource.Tool
+	for _, t := range tools {
+		if t.ToolSpec.Commands == nil && len(t.ToolSpec.Args) == 0 {
+			batch = append(batch, t)
+		}
+	}
+	if len(batch) < 2 {
+		return nil
+	}
+
+	var (
+		cmds        *resource.CommandsSpec
+		env         map[string]string
+		toolBinPath string
+	)
+	if ref := batch[0].ToolSpec.RuntimeRef; ref != "" {
+		info, ok := i.runtimes[ref]
+		if !ok {
+			return nil
+		}
+		cmds, env, toolBinPath = info.Commands, runtimeEnv(info), info.ToolBinPath
+		if toolBinPath != "" {
+			if err := os.MkdirAll(toolBinPath, 0755); err != nil {
+				slog.Warn("failed to create toolBinPath directory", "path", toolBinPath, "error", err)
+				return nil
+			}
+		}
+	} else {
+		info, ok := i.installers[batch[0].ToolSpec.InstallerRef]
+		if !ok || info.Type != resource.InstallTypeDelegation {
+			return nil
+		}
+		cmds, env = info.Commands, i.buildEnvWithToolPath(batch[0].ToolSpec.InstallerRef)
+	}
+	if cmds == nil || len(cmds.BatchInstall) == 0 {
+		return nil
+	}
+	if len(cmds.Check) == 0 && toolBinPath == "" {
+		// Only the exit status of the whole batch would tell what was installed
+		return nil
+	}
+
+	vars := make([]command.Vars, len(batch))
+	packages := make(command.Packages, len(batch))
+	for n, t := range batch {
+		spec := t.ToolSpec
+		pkg := spec.Package.String()
+		if pkg == "" && spec.RuntimeRef == "" {
+			pkg = t.Name() // installer delegation defaults to the tool name
+		}
+		var binPath string
+		if spec.RuntimeRef != "" {
+			binName := t.Name()
+			if spec.BinaryName != "" {
+				binName = spec.BinaryName
+			}
+			binPath = filepath.Join(toolBinPath, binName)
+		}
+		vars[n] = command.Vars{Package: pkg, Version: spec.Version, Name: t.Name(), BinPath: binPath}
+		packages[n] = command.Package{Package: pkg, Version: spec.Version, Name: t.Name()}
+	}
+
+	// Without a check command, a binary is installed by the batch only if it
+	// differs from the one before
+	before := make([]checksum.Digest, len(batch))
+	if len(cmds.Check) == 0 {
+		for n := range batch {
+			before[n], _ = checksum.Calculate(vars[n].BinPath, checksum.AlgorithmSHA256)
+		}
+	}
+
+	slog.Debug("installing tools in batch", "packages", packages.String())
+	if err := i.executeCommand(ctx, cmds.BatchInstall, command.Vars{Packages: packages}, env); err != nil {
+		slog.Warn("batch install failed, installing tools individually", "packages", packages.String(), "error", err)
+		return nil
+	}
+
+	installed := make(map[string]bool, len(batch))
+	for n, t := range batch {
+		var ok bool
+		if len(cmds.Check) > 0 {
+			ok = i.cmdExecutor.Check(ctx, cmds.Check, vars[n], env)
+		} else {
+			digest, err := checksum.Calculate(vars[n].BinPath, checksum.AlgorithmSHA256)
+			ok = err == nil && digest != before[n]
+		}
+		if !ok {
+			slog.Warn("tool not found after batch install, installing it individually", "name", t.Name())
+			continue
+		}
+		installed[t.Name()] = true
+	}
+	return installed
+}
+
 // buildDelegationState creates a ToolState for delegation pattern installations.
 func (i *Installer) buildDelegationState(spec *resource.ToolSpec, binPath string) *resource.ToolState {
 	return &resource.ToolState{
@@ -7,6 +7,7 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -22,6 +23,7 @@ import (
 	"github.com/terassyi/tomei/internal/installer/resolve"
 	"github.com/terassyi/tomei/internal/registry/aqua"
 	"github.com/terassyi/tomei/internal/resource"
+	"github.com/terassyi/tomei/internal/verify"
 )
 
 func TestNewInstaller(t *testing.T) {
@@ -284,7 +286,9 @@ func (m *mockDownloader) Verify(_ context.Context, _ string, cs *resource.Checks
 }
 
 // mockPlacer always returns install action and succeeds.
-type mockPlacer struct{}
+type mockPlacer struct {
+	installed bool // the target binary is already installed
+}
 
 func (m *mockPlacer) BinaryPath(target place.Target) string {
 	return "/tools/" + target.Name + "/" + target.Version + "/" + target.BinaryName
@@ -295,6 +299,9 @@ func (m *mockPlacer) LinkPath(target place.Target) string {
 }
 
 func (m *mockPlacer) Validate(_ place.Target, _ string) (place.ValidateAction, error) {
+	if m.installed {
+		return place.ValidateActionSkip, nil
+	}
 	return place.ValidateActionInstall, nil
 }
 
@@ -489,7 +496,9 @@ type mockCommandRunner struct {
 	methods      []string // "Execute", "ExecuteWithEnv", "ExecuteWithOutput", "Check"
 	checkedCmds  [][]string
 	checkResult  bool
+	checkFunc    func(vars command.Vars) bool // overrides checkResult when set
 	executeErr   error
+	onExecute    func() // side effect of the executed commands, if set
 }
 
 func (m *mockCommandRunner) Execute(_ context.Context, cmds []string, vars command.Vars) error {
@@ -503,6 +512,9 @@ func (m *mockCommandRunner) ExecuteWithEnv(_ context.Context, cmds []string, var
 	m.methods = append(m.methods, "ExecuteWithEnv")
 	m.executedCmds = append(m.executedCmds, cmds)
 	m.executedVars = append(m.executedVars, vars)
+	if m.onExecute != nil {
+		m.onExecute()
+	}
 	return m.executeErr
 }
 
@@ -510,6 +522,9 @@ func (m *mockCommandRunner) ExecuteWithOutput(_ context.Context, cmds []string,
 	m.methods = append(m.methods, "ExecuteWithOutput")
 	m.executedCmds = append(m.executedCmds, cmds)
 	m.executedVars = append(m.executedVars, vars)
+	if m.onExecute != nil {
+		m.onExecute()
+	}
 	return m.executeErr
 }
 
@@ -517,6 +532,9 @@ func (m *mockCommandRunner) Check(_ context.Context, cmds []string, vars command
 	m.methods = append(m.methods, "Check")
 	m.checkedCmds = append(m.checkedCmds, cmds)
 	m.executedVars = append(m.executedVars, vars)
+	if m.checkFunc != nil {
+		return m.checkFunc(vars)
+	}
 	return m.checkResult
 }
 
@@ -936,6 +954,228 @@ func TestInstallFromRegistry_ChecksumAlgorithmPropagation(t *testing.T) {
 	assert.NotEmpty(t, dl.lastVerifyChecksum.URL, "checksum URL should be set")
 }
 
+// mockSignatureVerifier returns a fixed result and records the signatures it was given.
+type mockSignatureVerifier struct {
+	result   *verify.ArtifactResult
+	err      error
+	lastSigs *verify.ArtifactSignatures
+}
+
+func (m *mockSignatureVerifier) Verify(_ context.Context, _ string, sigs *verify.ArtifactSignatures) (*verify.ArtifactResult, error) {
+	m.lastSigs = sigs
+	return m.result, m.err
+}
+
+func TestInstallFromRegistry_SignaturePolicy(t *testing.T) {
+	t.Parallel()
+
+	binaryContent := []byte("#!/bin/sh\necho hello")
+	tarGzContent := createTarGzContent(t, "mytool", binaryContent)
+
+	signedYAML := `packages:
+  - type: github_release
+    repo_owner: test
+    repo_name: mytool
+    asset: mytool_{{.OS}}_{{.Arch}}.tar.gz
+    format: tar.gz
+    github_artifact_attestations: {}
+`
+	unsignedYAML := `packages:
+  - type: github_release
+    repo_owner: test
+    repo_name: mytool
+    asset: mytool_{{.OS}}_{{.Arch}}.tar.gz
+    format: tar.gz
+`
+	tests := []struct {
+		name          string
+		registryYAML  string
+		policy        resource.SignaturePolicy
+		verifier      *mockSignatureVerifier
+		wantSigs      *verify.ArtifactSignatures
+		wantSignature *resource.SignatureState
+		wantErr       string
+	}{
+		{
+			name:          "verified",
+			registryYAML:  signedYAML,
+			verifier:      &mockSignatureVerifier{result: &verify.ArtifactResult{Verified: []string{verify.MethodGitHubAttestations}}},
+			wantSigs:      &verify.ArtifactSignatures{GitHubAttestations: &verify.GitHubAttestations{Owner: "test", Repo: "mytool"}},
+			wantSignature: &resource.SignatureState{Outcome: resource.SignatureVerified, Methods: []string{verify.MethodGitHubAttestations}},
+		},
+		{
+			name:          "warn records failed verification",
+			registryYAML:  signedYAML,
+			policy:        resource.SignaturePolicyWarn,
+			verifier:      &mockSignatureVerifier{err: errors.New("bad signature")},
+			wantSignature: &resource.SignatureState{Outcome: resource.SignatureUnverified, Reason: "bad signature"},
+		},
+		{
+			name:         "require fails on failed verification",
+			registryYAML: signedYAML,
+			policy:       resource.SignaturePolicyRequire,
+			verifier:     &mockSignatureVerifier{err: errors.New("bad signature")},
+			wantErr:      "failed to verify signature of package test/mytool: bad signature",
+		},
+		{
+			name:          "warn records unsigned package",
+			registryYAML:  unsignedYAML,
+			verifier:      &mockSignatureVerifier{err: verify.ErrUnsigned},
+			wantSignature: &resource.SignatureState{Outcome: resource.SignatureUnsigned},
+		},
+		{
+			name:         "require fails on unsigned package",
+			registryYAML: unsignedYAML,
+			policy:       resource.SignaturePolicyRequire,
+			verifier:     &mockSignatureVerifier{err: verify.ErrUnsigned},
+			wantErr:      "declares no signature",
+		},
+		{
+			name:         "require fails without verifier",
+			registryYAML: signedYAML,
+			policy:       resource.SignaturePolicyRequire,
+			wantErr:      "signature verification is not available",
+		},
+		{
+			name:         "warn without verifier records nothing",
+			registryYAML: signedYAML,
+		},
+		{
+			name:         "off skips verification",
+			registryYAML: signedYAML,
+			policy:       resource.SignaturePolicyOff,
+			verifier:     &mockSignatureVerifier{err: errors.New("must not be called")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cacheDir := t.TempDir()
+			ref := aqua.RegistryRef("v4.465.0")
+			cacheFile := filepath.Join(cacheDir, ref.String(), "pkgs", "test/mytool", "registry.yaml")
+			require.NoError(t, os.MkdirAll(filepath.Dir(cacheFile), 0o755))
+			require.NoError(t, os.WriteFile(cacheFile, []byte(tt.registryYAML), 0o644))
+
+			inst := NewInstaller(&mockDownloader{archiveData: tarGzContent}, &mockPlacer{})
+			inst.SetResolver(aqua.NewResolver(cacheDir, nil), ref)
+			inst.RegisterInstaller("aqua", &InstallerInfo{Type: resource.InstallTypeDownload, SignaturePolicy: tt.policy})
+			if tt.verifier != nil {
+				inst.SetSignatureVerifier(tt.verifier)
+			}
+
+			tool := &resource.Tool{
+				BaseResource: resource.BaseResource{Metadata: resource.Metadata{Name: "mytool"}},
+				ToolSpec: &resource.ToolSpec{
+					InstallerRef: "aqua",
+					Version:      "v1.0.0",
+					Package:      &resource.Package{Owner: "test", Repo: "mytool"},
+				},
+			}
+
+			state, err := inst.Install(context.Background(), tool, "mytool")
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantSignature, state.Signature)
+			if tt.wantSigs != nil {
+				assert.Equal(t, tt.wantSigs, tt.verifier.lastSigs, "signatures resolved from the registry are verified")
+			}
+		})
+	}
+}
+
+func TestInstallFromRegistry_SignatureOfInstalledBinary(t *testing.T) {
+	t.Parallel()
+
+	registryYAML := `packages:
+  - type: github_release
+    repo_owner: test
+    repo_name: mytool
+    asset: mytool_{{.OS}}_{{.Arch}}.tar.gz
+    format: tar.gz
+    github_artifact_attestations: {}
+`
+	binaryPath := "/tools/mytool/v1.0.0/mytool"
+	verified := &resource.SignatureState{Outcome: resource.SignatureVerified, Methods: []string{verify.MethodGitHubAttestations}}
+	unverified := &resource.SignatureState{Outcome: resource.SignatureUnverified, Reason: "bad signature"}
+
+	tests := []struct {
+		name          string
+		policy        resource.SignaturePolicy
+		recorded      executor.RecordedSignature
+		wantSignature *resource.SignatureState
+		wantErr       string
+	}{
+		{
+			name:          "keeps the outcome recorded for the binary",
+			recorded:      executor.RecordedSignature{Path: binaryPath, Signature: verified},
+			wantSignature: verified,
+		},
+		{
+			name:     "outcome of another version does not apply",
+			recorded: executor.RecordedSignature{Path: "/tools/mytool/v2.0.0/mytool", Signature: verified},
+		},
+		{
+			name:          "require keeps a verified outcome",
+			policy:        resource.SignaturePolicyRequire,
+			recorded:      executor.RecordedSignature{Path: binaryPath, Signature: verified},
+			wantSignature: verified,
+		},
+		{
+			name:     "require fails without a recorded outcome",
+			policy:   resource.SignaturePolicyRequire,
+			recorded: executor.RecordedSignature{Path: "/tools/mytool/v2.0.0/mytool", Signature: verified},
+			wantErr:  "none is recorded for the installed binary " + binaryPath,
+		},
+		{
+			name:     "require fails on an unverified outcome",
+			policy:   resource.SignaturePolicyRequire,
+			recorded: executor.RecordedSignature{Path: binaryPath, Signature: unverified},
+			wantErr:  "requires a verified signature",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cacheDir := t.TempDir()
+			ref := aqua.RegistryRef("v4.465.0")
+			cacheFile := filepath.Join(cacheDir, ref.String(), "pkgs", "test/mytool", "registry.yaml")
+			require.NoError(t, os.MkdirAll(filepath.Dir(cacheFile), 0o755))
+			require.NoError(t, os.WriteFile(cacheFile, []byte(registryYAML), 0o644))
+
+			verifier := &mockSignatureVerifier{err: errors.New("must not be called")}
+			inst := NewInstaller(&mockDownloader{}, &mockPlacer{installed: true})
+			inst.SetResolver(aqua.NewResolver(cacheDir, nil), ref)
+			inst.RegisterInstaller("aqua", &InstallerInfo{Type: resource.InstallTypeDownload, SignaturePolicy: tt.policy})
+			inst.SetSignatureVerifier(verifier)
+
+			tool := &resource.Tool{
+				BaseResource: resource.BaseResource{Metadata: resource.Metadata{Name: "mytool"}},
+				ToolSpec: &resource.ToolSpec{
+					InstallerRef: "aqua",
+					Version:      "v1.0.0",
+					Package:      &resource.Package{Owner: "test", Repo: "mytool"},
+				},
+			}
+
+			ctx := executor.WithSignature(context.Background(), tt.recorded)
+			state, err := inst.Install(ctx, tool, "mytool")
+			assert.Nil(t, verifier.lastSigs, "an installed binary is not verified again")
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantSignature, state.Signature)
+		})
+	}
+}
+
 func TestExtractBinaryMapping(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
@@ -1009,3 +1249,174 @@ func TestExtractBinaryMapping(t *testing.T) {
 		})
 	}
 }
+
+func TestToolInstaller_Install_Offline(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		spec    *resource.ToolSpec
+		wantErr string
+	}{
+		{
+			name:    "commands",
+			spec:    &resource.ToolSpec{Commands: &resource.ToolCommandSet{CommandSet: resource.CommandSet{Install: []string{"curl -sSL https://example.com | sh"}}}},
+			wantErr: "installed by commands, which is not supported in offline mode",
+		},
+		{
+			name:    "runtime delegation",
+			spec:    &resource.ToolSpec{RuntimeRef: "go", Package: &resource.Package{Name: "golang.org/x/tools/gopls"}},
+			wantErr: "installed by runtime delegation (go), which is not supported in offline mode",
+		},
+		{
+			name:    "installer delegation",
+			spec:    &resource.ToolSpec{InstallerRef: "brew", Package: &resource.Package{Name: "jq"}},
+			wantErr: "installed by installer delegation (brew), which is not supported in offline mode",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			runner := &mockCommandRunner{}
+			inst := NewInstallerWithRunner(download.NewDownloader(), &mockPlacer{}, runner)
+			inst.RegisterInstaller("brew", &InstallerInfo{Type: resource.InstallTypeDelegation})
+			inst.SetOffline(true)
+
+			tool := &resource.Tool{
+				BaseResource: resource.BaseResource{Metadata: resource.Metadata{Name: "mytool"}},
+				ToolSpec:     tt.spec,
+			}
+			_, err := inst.Install(context.Background(), tool, "mytool")
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+			assert.Empty(t, runner.executedCmds)
+		})
+	}
+}
+
+func TestInstaller_BatchInstall(t *testing.T) {
+	t.Parallel()
+
+	pnpmTool := func(name, version string, args ...string) *resource.Tool {
+		return &resource.Tool{
+			BaseResource: resource.BaseResource{Metadata: resource.Metadata{Name: name}},
+			ToolSpec: &resource.ToolSpec{
+				InstallerRef: "pnpm",
+				Version:      version,
+				Package:      &resource.Package{Name: name},
+				Args:         args,
+			},
+		}
+	}
+	tools := []*resource.Tool{
+		pnpmTool("typescript", "5.8.3"),
+		pnpmTool("prettier", "3.5.3"),
+		pnpmTool("wrangler", "4.10.0", "--allow-build=esbuild"),
+	}
+	batchCmds := &resource.CommandsSpec{
+		Install:      []string{"pnpm add -g {{.Package}}@{{.Version}}"},
+		Check:        []string{"pnpm list -g {{.Package}}"},
+		BatchInstall: []string{"pnpm add -g {{.Packages}}"},
+	}
+
+	tests := []struct {
+		name          string
+		cmds          *resource.CommandsSpec
+		executeErr    error
+		checkFunc     func(vars command.Vars) bool
+		wantInstalled map[string]bool
+		wantPackages  string
+	}{
+		{
+			name:          "installs tools without args in one invocation",
+			cmds:          batchCmds,
+			wantInstalled: map[string]bool{"typescript": true, "prettier": true},
+			wantPackages:  "typescript@5.8.3 prettier@3.5.3",
+		},
+		{
+			name:          "tools failing the check are left out",
+			cmds:          batchCmds,
+			checkFunc:     func(vars command.Vars) bool { return vars.Name != "prettier" },
+			wantInstalled: map[string]bool{"typescript": true},
+			wantPackages:  "typescript@5.8.3 prettier@3.5.3",
+		},
+		{
+			name:         "failed batch installs nothing",
+			cmds:         batchCmds,
+			executeErr:   errors.New("exit status 1"),
+			wantPackages: "typescript@5.8.3 prettier@3.5.3",
+		},
+		{
+			name: "no batchInstall command",
+			cmds: &resource.CommandsSpec{Install: batchCmds.Install},
+		},
+		{
+			name: "no check command",
+			cmds: &resource.CommandsSpec{Install: batchCmds.Install, BatchInstall: batchCmds.BatchInstall},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			runner := &mockCommandRunner{checkResult: true, checkFunc: tt.checkFunc, executeErr: tt.executeErr}
+			inst := NewInstallerWithRunner(download.NewDownloader(), &mockPlacer{}, runner)
+			inst.RegisterInstaller("pnpm", &InstallerInfo{Type: resource.InstallTypeDelegation, Commands: tt.cmds})
+
+			installed := inst.BatchInstall(context.Background(), tools)
+			assert.Equal(t, tt.wantInstalled, installed)
+
+			if tt.wantPackages == "" {
+				assert.Empty(t, runner.executedCmds)
+				return
+			}
+			require.Len(t, runner.executedCmds, 1)
+			assert.Equal(t, tt.cmds.BatchInstall, runner.executedCmds[0])
+			assert.Equal(t, tt.wantPackages, runner.executedVars[0].Packages.String())
+		})
+	}
+
+	t.Run("runtime tools without check need a new binary", func(t *testing.T) {
+		t.Parallel()
+		binDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(binDir, "gopls"), []byte("v0.18.0"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(binDir, "staticcheck"), []byte("2024.1"), 0755))
+		runner := &mockCommandRunner{onExecute: func() {
+			// gopls fails to upgrade and keeps its old binary
+			_ = os.WriteFile(filepath.Join(binDir, "staticcheck"), []byte("2025.1"), 0755)
+			_ = os.WriteFile(filepath.Join(binDir, "goimports"), []byte("v0.31.0"), 0755)
+		}}
+		inst := NewInstallerWithRunner(download.NewDownloader(), &mockPlacer{}, runner)
+		inst.RegisterRuntime("go", &RuntimeInfo{ToolBinPath: binDir, Commands: &resource.CommandsSpec{
+			Install:      []string{"go install {{.Package}}@{{.Version}}"},
+			BatchInstall: []string{"go install {{.Packages}}"},
+		}})
+		goTool := func(name, version string) *resource.Tool {
+			return &resource.Tool{
+				BaseResource: resource.BaseResource{Metadata: resource.Metadata{Name: name}},
+				ToolSpec:     &resource.ToolSpec{RuntimeRef: "go", Version: version, Package: &resource.Package{Name: name}},
+			}
+		}
+
+		installed := inst.BatchInstall(context.Background(), []*resource.Tool{
+			goTool("gopls", "v0.19.0"),
+			goTool("staticcheck", "2025.1"),
+			goTool("goimports", "v0.31.0"),
+		})
+		assert.Equal(t, map[string]bool{"staticcheck": true, "goimports": true}, installed)
+	})
+
+	t.Run("batch-installed tool skips its install command", func(t *testing.T) {
+		t.Parallel()
+		runner := &mockCommandRunner{checkResult: true}
+		inst := NewInstallerWithRunner(download.NewDownloader(), &mockPlacer{}, runner)
+		inst.RegisterInstaller("pnpm", &InstallerInfo{Type: resource.InstallTypeDelegation, Commands: batchCmds})
+
+		state, err := inst.Install(executor.WithBatchInstalled(context.Background()), tools[0], "typescript")
+		require.NoError(t, err)
+		assert.Equal(t, "5.8.3", state.Version)
+		assert.NotContains(t, runner.methods, "ExecuteWithEnv")
+		assert.NotContains(t, runner.methods, "ExecuteWithOutput")
+	})
+}
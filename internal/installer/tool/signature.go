@@ -0,0 +1,101 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/terassyi/tomei/internal/installer/executor"
+	"github.com/terassyi/tomei/internal/resource"
+	"github.com/terassyi/tomei/internal/verify"
+)
+
+// SignatureVerifier verifies the upstream signatures of a downloaded archive.
+// It is implemented by verify.ArtifactVerifier.
+type SignatureVerifier interface {
+	Verify(ctx context.Context, artifactPath string, sigs *verify.ArtifactSignatures) (*verify.ArtifactResult, error)
+}
+
+// SetSignatureVerifier sets the verifier of the upstream signatures that
+// aqua-registry declares for registry tools. Without a verifier, signatures
+// are not checked and tools under the "require" policy fail to install.
+func (i *Installer) SetSignatureVerifier(v SignatureVerifier) {
+	i.signatures = v
+}
+
+// VerifyArchive verifies the upstream signatures sigs, as returned by
+// ResolveSource, of a downloaded archive of the registry tool res under the
+// signature policy of its installer.
+func (i *Installer) VerifyArchive(ctx context.Context, res *resource.Tool, sigs *verify.ArtifactSignatures, archivePath string) error {
+	spec := res.ToolSpec
+	if !spec.Package.IsRegistry() {
+		return nil
+	}
+	policy := i.signaturePolicy(spec.InstallerRef)
+	if policy == resource.SignaturePolicyOff {
+		return nil
+	}
+	_, err := i.verifySignatures(ctx, spec.Package.String(), policy, sigs, archivePath)
+	return err
+}
+
+// signaturePolicy returns the signature policy of the installer.
+func (i *Installer) signaturePolicy(installerRef string) resource.SignaturePolicy {
+	if info, ok := i.installers[installerRef]; ok {
+		return info.SignaturePolicy.OrDefault()
+	}
+	return resource.SignaturePolicyWarn
+}
+
+// verifySignatures verifies the upstream signatures of a registry package
+// archive under the given policy and returns the outcome to record in state.
+//
+//   - require: the archive must carry a verified signature
+//   - warn: failures are logged and recorded as unverified
+func (i *Installer) verifySignatures(ctx context.Context, pkg string, policy resource.SignaturePolicy, sigs *verify.ArtifactSignatures, archivePath string) (*resource.SignatureState, error) {
+	if i.signatures == nil {
+		if policy == resource.SignaturePolicyRequire {
+			return nil, fmt.Errorf("package %s requires a verified signature, but signature verification is not available", pkg)
+		}
+		return nil, nil
+	}
+
+	result, err := i.signatures.Verify(ctx, archivePath, sigs)
+	switch {
+	case errors.Is(err, verify.ErrUnsigned):
+		if policy == resource.SignaturePolicyRequire {
+			return nil, fmt.Errorf("package %s declares no signature, but the signature policy is require", pkg)
+		}
+		slog.Debug("package declares no signature", "package", pkg)
+		return &resource.SignatureState{Outcome: resource.SignatureUnsigned}, nil
+
+	case err != nil:
+		if policy == resource.SignaturePolicyRequire {
+			return nil, fmt.Errorf("failed to verify signature of package %s: %w", pkg, err)
+		}
+		slog.Warn("failed to verify signature, continuing with signature policy warn", "package", pkg, "error", err)
+		return &resource.SignatureState{Outcome: resource.SignatureUnverified, Reason: err.Error()}, nil
+	}
+
+	for _, reason := range result.Skipped {
+		slog.Debug("signature not checked", "package", pkg, "reason", reason)
+	}
+	slog.Debug("signature verified", "package", pkg, "methods", result.Verified)
+	return &resource.SignatureState{Outcome: resource.SignatureVerified, Methods: result.Verified}, nil
+}
+
+// installedSignature returns the signature outcome to record for a registry
+// package binary that is already installed and is not downloaded again, such
+// as the version of a rollback. Only the outcome recorded for that binary
+// applies; without one, the require policy fails.
+func (i *Installer) installedSignature(ctx context.Context, pkg string, policy resource.SignaturePolicy, binaryPath string) (*resource.SignatureState, error) {
+	var st *resource.SignatureState
+	if recorded := executor.SignatureFromContext(ctx); recorded.Path == binaryPath {
+		st = recorded.Signature
+	}
+	if policy == resource.SignaturePolicyRequire && (st == nil || st.Outcome != resource.SignatureVerified) {
+		return nil, fmt.Errorf("package %s requires a verified signature, but none is recorded for the installed binary %s; remove it to download and verify it again", pkg, binaryPath)
+	}
+	return st, nil
+}
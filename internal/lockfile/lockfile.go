@@ -0,0 +1,271 @@
+// Package lockfile reads and writes tomei.lock, which pins the resolved
+// versions, download URLs, archive digests, and aqua registry ref of Tool
+// and Runtime resources so that the same manifests install the same
+// artifacts on every machine. Versions are shared by all platforms; URLs
+// and digests are pinned per platform, since each downloads its own artifact.
+package lockfile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/terassyi/tomei/internal/checksum"
+	"github.com/terassyi/tomei/internal/installer"
+	"github.com/terassyi/tomei/internal/resource"
+	"github.com/terassyi/tomei/internal/state"
+)
+
+// FileName is the name of the lockfile written next to the manifests.
+const FileName = "tomei.lock"
+
+// FormatVersion is the current lockfile format version.
+const FormatVersion = "1"
+
+// Lockfile is the on-disk representation of tomei.lock.
+type Lockfile struct {
+	Version  string            `json:"version"`
+	Registry *Registry         `json:"registry,omitempty"`
+	Runtimes map[string]*Entry `json:"runtimes,omitempty"`
+	Tools    map[string]*Entry `json:"tools,omitempty"`
+}
+
+// Registry pins the registry refs used for resolution.
+type Registry struct {
+	Aqua string `json:"aqua,omitempty"`
+}
+
+// Entry pins a single Tool or Runtime.
+type Entry struct {
+	// SpecVersion is the version as written in the manifest (e.g., "latest", "stable").
+	// An entry only applies while the manifest still specifies the same version.
+	SpecVersion string `json:"specVersion"`
+	// Version is the resolved version that was installed.
+	Version string `json:"version"`
+	// Platforms pins the artifact of Version downloaded on each platform,
+	// keyed by "os/arch" (download pattern only).
+	Platforms map[string]*Artifact `json:"platforms,omitempty"`
+}
+
+// Artifact pins the download of a version on one platform.
+type Artifact struct {
+	// URL is the resolved download URL. Apply fails when the version
+	// resolves to a different URL on the platform.
+	URL string `json:"url,omitempty"`
+	// Digest is the hex digest of the downloaded archive.
+	Digest checksum.Digest `json:"digest,omitempty"`
+}
+
+// Platform returns the key of the current platform in Entry.Platforms.
+func Platform() string {
+	return runtime.GOOS + "/" + runtime.GOARCH
+}
+
+// Artifact returns the artifact pinned for the platform, or nil.
+func (e *Entry) Artifact(platform string) *Artifact {
+	if e == nil {
+		return nil
+	}
+	return e.Platforms[platform]
+}
+
+// pin returns the pin of the entry on the current platform.
+func (e *Entry) pin() installer.Pin {
+	pin := installer.Pin{Version: e.Version}
+	if a := e.Artifact(Platform()); a != nil {
+		pin.URL, pin.Digest = a.URL, a.Digest
+	}
+	return pin
+}
+
+// New returns an empty lockfile.
+func New() *Lockfile {
+	return &Lockfile{Version: FormatVersion}
+}
+
+// PathFor returns the lockfile path for the given manifest paths.
+// The lockfile is placed in the first path if it is a directory,
+// or next to it if it is a file.
+func PathFor(paths []string) string {
+	if len(paths) == 0 {
+		return FileName
+	}
+	dir := paths[0]
+	if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+	return filepath.Join(dir, FileName)
+}
+
+// Load reads the lockfile at path.
+// Returns an empty lockfile if the file does not exist.
+func Load(path string) (*Lockfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return New(), nil
+		}
+		return nil, fmt.Errorf("failed to read lockfile: %w", err)
+	}
+
+	var l Lockfile
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, fmt.Errorf("failed to parse lockfile %s: %w", path, err)
+	}
+	if l.Version != FormatVersion {
+		return nil, fmt.Errorf("unsupported lockfile version %q in %s (expected %q)", l.Version, path, FormatVersion)
+	}
+	return &l, nil
+}
+
+// Save writes the lockfile to path atomically.
+func (l *Lockfile) Save(path string) error {
+	l.Version = FormatVersion
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal lockfile: %w", err)
+	}
+	data = append(data, '\n')
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write temp lockfile: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to rename lockfile: %w", err)
+	}
+	return nil
+}
+
+// AquaRef returns the pinned aqua registry ref, or an empty string.
+func (l *Lockfile) AquaRef() string {
+	if l.Registry == nil {
+		return ""
+	}
+	return l.Registry.Aqua
+}
+
+// Pins returns the entries that still apply to the given resources, keyed by name.
+// An entry applies when the manifest version equals the entry's SpecVersion.
+// Only the URL and digest pinned for the current platform are returned.
+func (l *Lockfile) Pins(resources []resource.Resource) (tools, runtimes map[string]installer.Pin) {
+	tools = make(map[string]installer.Pin)
+	runtimes = make(map[string]installer.Pin)
+	for _, res := range resources {
+		switch r := res.(type) {
+		case *resource.Tool:
+			if r.ToolSpec == nil {
+				continue
+			}
+			if e, ok := l.Tools[r.Name()]; ok && e.SpecVersion == r.ToolSpec.Version {
+				tools[r.Name()] = e.pin()
+			}
+		case *resource.Runtime:
+			if r.RuntimeSpec == nil {
+				continue
+			}
+			if e, ok := l.Runtimes[r.Name()]; ok && e.SpecVersion == r.RuntimeSpec.Version {
+				runtimes[r.Name()] = e.pin()
+			}
+		}
+	}
+	return tools, runtimes
+}
+
+// Update rebuilds the entries from the given resources and the state after apply.
+// Entries for resources no longer in the manifests are dropped. Resources
+// missing from state keep their previous entry. The aqua registry ref is
+// taken from state unless one is already pinned.
+func (l *Lockfile) Update(resources []resource.Resource, st *state.UserState) {
+	tools := make(map[string]*Entry)
+	runtimes := make(map[string]*Entry)
+	for _, res := range resources {
+		switch r := res.(type) {
+		case *resource.Tool:
+			if r.ToolSpec == nil {
+				continue
+			}
+			prev := l.Tools[r.Name()]
+			ts, ok := st.Tools[r.Name()]
+			if !ok {
+				if prev != nil {
+					tools[r.Name()] = prev
+				}
+				continue
+			}
+			var url string
+			if ts.Source != nil {
+				url = ts.Source.URL
+			}
+			if e := NewEntry(r.ToolSpec.Version, ts.Version, url, ts.Digest, prev); e != nil {
+				tools[r.Name()] = e
+			}
+		case *resource.Runtime:
+			if r.RuntimeSpec == nil {
+				continue
+			}
+			prev := l.Runtimes[r.Name()]
+			rs, ok := st.Runtimes[r.Name()]
+			if !ok {
+				if prev != nil {
+					runtimes[r.Name()] = prev
+				}
+				continue
+			}
+			if e := NewEntry(r.RuntimeSpec.Version, rs.Version, rs.SourceURL, rs.Digest, prev); e != nil {
+				runtimes[r.Name()] = e
+			}
+		}
+	}
+	l.Tools = tools
+	l.Runtimes = runtimes
+
+	if l.AquaRef() == "" && st.Registry != nil && st.Registry.Aqua != nil && st.Registry.Aqua.Ref != "" {
+		l.Registry = &Registry{Aqua: st.Registry.Aqua.Ref}
+	}
+}
+
+// NewEntry builds an entry for a version installed on the current platform
+// with the given URL and digest. Versions that were never resolved to an
+// exact version (e.g., delegation with "latest") are not pinned and nil is
+// returned. While the version is unchanged, the artifacts prev pins for
+// other platforms are kept, and the URL and digest prev pins for this
+// platform fill in those that are unknown (e.g., the artifact was already
+// installed).
+func NewEntry(specVersion, version, url string, digest checksum.Digest, prev *Entry) *Entry {
+	if !resource.IsExactVersion(version) {
+		return nil
+	}
+	e := &Entry{
+		SpecVersion: specVersion,
+		Version:     version,
+	}
+	a := &Artifact{URL: url, Digest: digest}
+	if prev != nil && prev.Version == version {
+		for platform, pa := range prev.Platforms {
+			if e.Platforms == nil {
+				e.Platforms = make(map[string]*Artifact)
+			}
+			e.Platforms[platform] = pa
+		}
+		if pa := prev.Artifact(Platform()); pa != nil {
+			if a.URL == "" {
+				a.URL = pa.URL
+			}
+			if a.Digest == "" {
+				a.Digest = pa.Digest
+			}
+		}
+	}
+	if a.URL != "" || a.Digest != "" {
+		if e.Platforms == nil {
+			e.Platforms = make(map[string]*Artifact)
+		}
+		e.Platforms[Platform()] = a
+	}
+	return e
+}
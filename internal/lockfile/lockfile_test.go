@@ -0,0 +1,287 @@
+package lockfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/terassyi/tomei/internal/checksum"
+	"github.com/terassyi/tomei/internal/installer"
+	"github.com/terassyi/tomei/internal/resource"
+	"github.com/terassyi/tomei/internal/state"
+)
+
+// otherPlatform is a platform key that never matches the current one.
+const otherPlatform = "plan9/mips"
+
+// artifacts returns the Platforms of an entry pinned on the current platform.
+func artifacts(url, digest string) map[string]*Artifact {
+	return map[string]*Artifact{Platform(): {URL: url, Digest: checksum.Digest(digest)}}
+}
+
+func newTool(name, version string) *resource.Tool {
+	return &resource.Tool{
+		BaseResource: resource.BaseResource{ResourceKind: resource.KindTool, Metadata: resource.Metadata{Name: name}},
+		ToolSpec:     &resource.ToolSpec{Version: version},
+	}
+}
+
+func newRuntime(name, version string) *resource.Runtime {
+	return &resource.Runtime{
+		BaseResource: resource.BaseResource{ResourceKind: resource.KindRuntime, Metadata: resource.Metadata{Name: name}},
+		RuntimeSpec:  &resource.RuntimeSpec{Version: version},
+	}
+}
+
+func TestLoadSave(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, FileName)
+
+	// Missing file returns an empty lockfile
+	l, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, FormatVersion, l.Version)
+	assert.Empty(t, l.Tools)
+
+	l.Registry = &Registry{Aqua: "v4.465.0"}
+	l.Tools = map[string]*Entry{
+		"gh": {SpecVersion: "latest", Version: "2.62.0", Platforms: artifacts("https://example.com/gh.tar.gz", "abc")},
+	}
+	require.NoError(t, l.Save(path))
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, l, loaded)
+
+	_, err = os.Stat(path + ".tmp")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestLoad_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "invalid json", content: "{", wantErr: "failed to parse lockfile"},
+		{name: "unsupported version", content: `{"version":"99"}`, wantErr: "unsupported lockfile version"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			path := filepath.Join(t.TempDir(), FileName)
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0644))
+			_, err := Load(path)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestPathFor(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "tools.cue")
+	require.NoError(t, os.WriteFile(file, nil, 0644))
+
+	assert.Equal(t, filepath.Join(dir, FileName), PathFor([]string{dir}))
+	assert.Equal(t, filepath.Join(dir, FileName), PathFor([]string{file, "/other"}))
+	assert.Equal(t, FileName, PathFor(nil))
+}
+
+func TestPins(t *testing.T) {
+	t.Parallel()
+
+	l := &Lockfile{
+		Version: FormatVersion,
+		Tools: map[string]*Entry{
+			"gh":      {SpecVersion: "latest", Version: "2.62.0", Platforms: artifacts("https://example.com/gh_2.62.0.tar.gz", "abc")},
+			"jq":      {SpecVersion: "latest", Version: "1.7.1"},
+			"ripgrep": {SpecVersion: "14.1.0", Version: "14.1.0", Platforms: artifacts("", "def")},
+			// written on another platform: only the version applies here
+			"bat": {SpecVersion: "latest", Version: "0.24.0", Platforms: map[string]*Artifact{otherPlatform: {URL: "https://example.com/bat", Digest: "bbb"}}},
+		},
+		Runtimes: map[string]*Entry{
+			"go": {SpecVersion: "stable", Version: "1.25.5"},
+		},
+	}
+	resources := []resource.Resource{
+		newTool("gh", "latest"),
+		newTool("jq", "1.8.0"), // manifest changed: entry no longer applies
+		newTool("ripgrep", "14.1.0"),
+		newTool("fd", "latest"), // not locked
+		newTool("bat", "latest"),
+		newRuntime("go", "stable"),
+	}
+
+	tools, runtimes := l.Pins(resources)
+	assert.Equal(t, map[string]installer.Pin{
+		"gh":      {Version: "2.62.0", URL: "https://example.com/gh_2.62.0.tar.gz", Digest: "abc"},
+		"ripgrep": {Version: "14.1.0", Digest: "def"},
+		"bat":     {Version: "0.24.0"},
+	}, tools)
+	assert.Equal(t, map[string]installer.Pin{
+		"go": {Version: "1.25.5"},
+	}, runtimes)
+}
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+
+	l := &Lockfile{
+		Version: FormatVersion,
+		Tools: map[string]*Entry{
+			"gh":      {SpecVersion: "latest", Version: "2.62.0", Platforms: artifacts("https://example.com/gh", "abc")},
+			"fd":      {SpecVersion: "latest", Version: "10.2.0", Platforms: map[string]*Artifact{otherPlatform: {URL: "https://example.com/fd-other", Digest: "ooo"}}},
+			"removed": {SpecVersion: "latest", Version: "1.0.0"},
+			"pending": {SpecVersion: "latest", Version: "3.0.0"},
+		},
+	}
+	st := state.NewUserState()
+	st.Registry = &state.RegistryState{Aqua: &state.AquaRegistryState{Ref: "v4.465.0"}}
+	st.Tools["gh"] = &resource.ToolState{Version: "2.62.0"} // already installed: no source/digest in state
+	st.Tools["fd"] = &resource.ToolState{
+		Version: "10.2.0",
+		Source:  &resource.DownloadSource{URL: "https://example.com/fd"},
+		Digest:  "fff",
+	}
+	st.Tools["gopls"] = &resource.ToolState{Version: "latest"}
+	st.Runtimes["go"] = &resource.RuntimeState{Version: "1.25.5", SourceURL: "https://go.dev/dl/go1.25.5.tar.gz", Digest: "ggg"}
+
+	l.Update([]resource.Resource{
+		newTool("gh", "latest"),
+		newTool("fd", "latest"),
+		newTool("gopls", "latest"),
+		newTool("pending", "latest"),
+		newRuntime("go", "stable"),
+	}, st)
+
+	assert.Equal(t, map[string]*Entry{
+		"gh": {SpecVersion: "latest", Version: "2.62.0", Platforms: artifacts("https://example.com/gh", "abc")},
+		"fd": {SpecVersion: "latest", Version: "10.2.0", Platforms: map[string]*Artifact{
+			Platform():    {URL: "https://example.com/fd", Digest: "fff"},
+			otherPlatform: {URL: "https://example.com/fd-other", Digest: "ooo"},
+		}},
+		"pending": {SpecVersion: "latest", Version: "3.0.0"},
+	}, l.Tools)
+	assert.Equal(t, map[string]*Entry{
+		"go": {SpecVersion: "stable", Version: "1.25.5", Platforms: artifacts("https://go.dev/dl/go1.25.5.tar.gz", "ggg")},
+	}, l.Runtimes)
+	assert.Equal(t, "v4.465.0", l.AquaRef())
+
+	// A pinned registry ref is kept
+	st.Registry.Aqua.Ref = "v4.500.0"
+	l.Update(nil, st)
+	assert.Equal(t, "v4.465.0", l.AquaRef())
+	assert.Empty(t, l.Tools)
+}
+
+func TestNewEntry(t *testing.T) {
+	t.Parallel()
+
+	other := &Entry{SpecVersion: "latest", Version: "1.0.0", Platforms: map[string]*Artifact{
+		otherPlatform: {URL: "https://example.com/other", Digest: "ooo"},
+	}}
+
+	tests := []struct {
+		name    string
+		version string
+		url     string
+		digest  checksum.Digest
+		prev    *Entry
+		want    *Entry
+	}{
+		{
+			name:    "inexact version is not pinned",
+			version: "latest",
+		},
+		{
+			name:    "new entry",
+			version: "1.0.0",
+			url:     "https://example.com/here",
+			digest:  "hhh",
+			want:    &Entry{SpecVersion: "latest", Version: "1.0.0", Platforms: artifacts("https://example.com/here", "hhh")},
+		},
+		{
+			name:    "artifacts of other platforms are kept",
+			version: "1.0.0",
+			url:     "https://example.com/here",
+			digest:  "hhh",
+			prev:    other,
+			want: &Entry{SpecVersion: "latest", Version: "1.0.0", Platforms: map[string]*Artifact{
+				Platform():    {URL: "https://example.com/here", Digest: "hhh"},
+				otherPlatform: {URL: "https://example.com/other", Digest: "ooo"},
+			}},
+		},
+		{
+			name:    "already installed keeps the artifacts",
+			version: "1.0.0",
+			prev:    other,
+			want:    other,
+		},
+		{
+			name:    "version change drops the artifacts",
+			version: "2.0.0",
+			prev:    other,
+			want:    &Entry{SpecVersion: "latest", Version: "2.0.0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, NewEntry("latest", tt.version, tt.url, tt.digest, tt.prev))
+		})
+	}
+}
+
+// TestPins_OtherPlatform loads a lockfile written on another platform and
+// checks that only its versions are pinned, so apply resolves and verifies
+// the artifacts of this platform instead of failing on the foreign ones.
+func TestPins_OtherPlatform(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), FileName)
+	content := `{
+  "version": "1",
+  "tools": {
+    "gh": {
+      "specVersion": "latest",
+      "version": "2.62.0",
+      "platforms": {
+        "plan9/mips": {"url": "https://example.com/gh_2.62.0_plan9_mips.tar.gz", "digest": "abc"}
+      }
+    }
+  },
+  "runtimes": {
+    "go": {
+      "specVersion": "stable",
+      "version": "1.25.5",
+      "platforms": {
+        "plan9/mips": {"url": "https://go.dev/dl/go1.25.5.plan9-mips.tar.gz", "digest": "def"}
+      }
+    }
+  }
+}
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	l, err := Load(path)
+	require.NoError(t, err)
+
+	tools, runtimes := l.Pins([]resource.Resource{newTool("gh", "latest"), newRuntime("go", "stable")})
+	assert.Equal(t, map[string]installer.Pin{"gh": {Version: "2.62.0"}}, tools)
+	assert.Equal(t, map[string]installer.Pin{"go": {Version: "1.25.5"}}, runtimes)
+	require.NoError(t, tools["gh"].CheckURL("2.62.0", "https://example.com/gh_2.62.0_linux_amd64.tar.gz"))
+
+	archivePath := filepath.Join(t.TempDir(), "go.tar.gz")
+	require.NoError(t, os.WriteFile(archivePath, []byte("archive"), 0644))
+	_, err = runtimes["go"].VerifyDigest(archivePath, "1.25.5")
+	require.NoError(t, err)
+}
@@ -0,0 +1,121 @@
+// Package mirror rewrites download URLs to mirrors configured in config.cue.
+//
+// Rules are ordered URL prefix rewrites. Every rule whose prefix matches a
+// URL adds a candidate, in order, and the original URL is tried last when a
+// matching rule allows fallback. The Transport returned by WrapTransport
+// tries the candidates until one answers without an error status, so every
+// HTTP client built on it (downloads, checksum files, aqua registry
+// definitions, GitHub release lookups) goes through the mirrors.
+package mirror
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Rule rewrites URLs starting with Prefix to start with URL instead.
+type Rule struct {
+	// Prefix is the start of the URLs to rewrite, e.g. "https://github.com/".
+	Prefix string `json:"prefix"`
+	// URL replaces Prefix, e.g. "https://artifactory.example.com/artifactory/github/".
+	URL string `json:"url"`
+	// Fallback tries the original URL when the mirror fails.
+	Fallback bool `json:"fallback,omitempty"`
+}
+
+// Validate checks that the prefix and URL of every rule are HTTPS URLs.
+func Validate(rules []Rule) error {
+	for i, r := range rules {
+		if err := validateURL(r.Prefix); err != nil {
+			return fmt.Errorf("mirrors[%d].prefix: %w", i, err)
+		}
+		if err := validateURL(r.URL); err != nil {
+			return fmt.Errorf("mirrors[%d].url: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func validateURL(s string) error {
+	u, err := url.Parse(s)
+	if err != nil {
+		return fmt.Errorf("invalid URL %q: %w", s, err)
+	}
+	if u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("%q is not an HTTPS URL", s)
+	}
+	return nil
+}
+
+// Candidate is a URL to try for a request.
+type Candidate struct {
+	URL string
+	// Mirror is the URL of the rule that produced the candidate, empty for
+	// the original URL.
+	Mirror string
+}
+
+// Candidates returns the URLs to try for rawURL, in order. Without a
+// matching rule, it is the original URL alone.
+func Candidates(rules []Rule, rawURL string) []Candidate {
+	var candidates []Candidate
+	fallback := false
+	for _, r := range rules {
+		rest, ok := strings.CutPrefix(rawURL, r.Prefix)
+		if !ok {
+			continue
+		}
+		candidates = append(candidates, Candidate{URL: r.URL + rest, Mirror: r.URL})
+		fallback = fallback || r.Fallback
+	}
+	if len(candidates) == 0 || fallback {
+		candidates = append(candidates, Candidate{URL: rawURL})
+	}
+	return candidates
+}
+
+// Recorder records the mirror that served each URL requested with a context
+// carrying it (see NewContext).
+type Recorder struct {
+	mu     sync.Mutex
+	served map[string]string // requested URL -> mirror
+}
+
+// NewRecorder creates an empty Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{served: make(map[string]string)}
+}
+
+// Mirror returns the mirror that served rawURL, or an empty string when it
+// was served by the original URL or not requested.
+func (r *Recorder) Mirror(rawURL string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.served[rawURL]
+}
+
+func (r *Recorder) record(rawURL, mirror string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if mirror == "" {
+		delete(r.served, rawURL)
+		return
+	}
+	r.served[rawURL] = mirror
+}
+
+type contextKey struct{}
+
+// NewContext returns a context that carries r.
+func NewContext(ctx context.Context, r *Recorder) context.Context {
+	return context.WithValue(ctx, contextKey{}, r)
+}
+
+// fromContext returns the recorder carried by ctx, or nil.
+func fromContext(ctx context.Context) *Recorder {
+	r, _ := ctx.Value(contextKey{}).(*Recorder)
+	return r
+}
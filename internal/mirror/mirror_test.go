@@ -0,0 +1,203 @@
+package mirror
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func response(status int, body string) *http.Response {
+	return &http.Response{StatusCode: status, Header: http.Header{}, Body: io.NopCloser(bytes.NewReader([]byte(body)))}
+}
+
+var rules = []Rule{
+	{Prefix: "https://github.com/", URL: "https://artifactory.example.com/github/"},
+	{Prefix: "https://github.com/", URL: "https://backup.example.com/github/"},
+	{Prefix: "https://go.dev/dl/", URL: "https://artifactory.example.com/go/", Fallback: true},
+}
+
+func TestCandidates(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		url  string
+		want []Candidate
+	}{
+		{
+			name: "ordered mirrors without fallback",
+			url:  "https://github.com/cli/cli/releases/download/v2.62.0/gh.tar.gz",
+			want: []Candidate{
+				{URL: "https://artifactory.example.com/github/cli/cli/releases/download/v2.62.0/gh.tar.gz", Mirror: "https://artifactory.example.com/github/"},
+				{URL: "https://backup.example.com/github/cli/cli/releases/download/v2.62.0/gh.tar.gz", Mirror: "https://backup.example.com/github/"},
+			},
+		},
+		{
+			name: "mirror with fallback",
+			url:  "https://go.dev/dl/go1.25.6.linux-amd64.tar.gz",
+			want: []Candidate{
+				{URL: "https://artifactory.example.com/go/go1.25.6.linux-amd64.tar.gz", Mirror: "https://artifactory.example.com/go/"},
+				{URL: "https://go.dev/dl/go1.25.6.linux-amd64.tar.gz"},
+			},
+		},
+		{
+			name: "no matching rule",
+			url:  "https://nodejs.org/dist/v22.0.0/node.tar.gz",
+			want: []Candidate{{URL: "https://nodejs.org/dist/v22.0.0/node.tar.gz"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, Candidates(rules, tt.url))
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, Validate(rules))
+	require.NoError(t, Validate(nil))
+	assert.ErrorContains(t, Validate([]Rule{{Prefix: "github.com/", URL: "https://mirror.example.com/"}}), "mirrors[0].prefix")
+	assert.ErrorContains(t, Validate([]Rule{{Prefix: "https://github.com/", URL: "http://mirror.example.com/"}}), "mirrors[0].url")
+}
+
+func TestTransport(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		url        string
+		responses  map[string]*http.Response
+		wantURLs   []string
+		wantStatus int
+		wantErr    bool
+		wantMirror string
+	}{
+		{
+			name:       "first mirror serves",
+			url:        "https://github.com/cli/cli/releases/download/v2.62.0/gh.tar.gz",
+			wantURLs:   []string{"https://artifactory.example.com/github/cli/cli/releases/download/v2.62.0/gh.tar.gz"},
+			wantStatus: http.StatusOK,
+			wantMirror: "https://artifactory.example.com/github/",
+		},
+		{
+			name: "next mirror after an error status",
+			url:  "https://github.com/cli/cli/releases/download/v2.62.0/gh.tar.gz",
+			responses: map[string]*http.Response{
+				"artifactory.example.com": response(http.StatusNotFound, ""),
+			},
+			wantURLs: []string{
+				"https://artifactory.example.com/github/cli/cli/releases/download/v2.62.0/gh.tar.gz",
+				"https://backup.example.com/github/cli/cli/releases/download/v2.62.0/gh.tar.gz",
+			},
+			wantStatus: http.StatusOK,
+			wantMirror: "https://backup.example.com/github/",
+		},
+		{
+			name: "all mirrors fail without fallback",
+			url:  "https://github.com/cli/cli/releases/download/v2.62.0/gh.tar.gz",
+			responses: map[string]*http.Response{
+				"artifactory.example.com": response(http.StatusBadGateway, ""),
+				"backup.example.com":      nil,
+			},
+			wantURLs: []string{
+				"https://artifactory.example.com/github/cli/cli/releases/download/v2.62.0/gh.tar.gz",
+				"https://backup.example.com/github/cli/cli/releases/download/v2.62.0/gh.tar.gz",
+			},
+			wantErr: true,
+		},
+		{
+			name: "fallback to the original URL",
+			url:  "https://go.dev/dl/go1.25.6.linux-amd64.tar.gz",
+			responses: map[string]*http.Response{
+				"artifactory.example.com": nil,
+			},
+			wantURLs: []string{
+				"https://artifactory.example.com/go/go1.25.6.linux-amd64.tar.gz",
+				"https://go.dev/dl/go1.25.6.linux-amd64.tar.gz",
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "no matching rule",
+			url:        "https://nodejs.org/dist/v22.0.0/node.tar.gz",
+			wantURLs:   []string{"https://nodejs.org/dist/v22.0.0/node.tar.gz"},
+			wantStatus: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var urls []string
+			base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				urls = append(urls, req.URL.String())
+				resp, ok := tt.responses[req.URL.Host]
+				if !ok {
+					return response(http.StatusOK, "ok"), nil
+				}
+				if resp == nil {
+					return nil, errors.New("connection refused")
+				}
+				return resp, nil
+			})
+
+			rec := NewRecorder()
+			req, err := http.NewRequestWithContext(NewContext(context.Background(), rec), http.MethodGet, tt.url, nil)
+			require.NoError(t, err)
+			resp, err := WrapTransport(rules, base).RoundTrip(req)
+			assert.Equal(t, tt.wantURLs, urls)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			assert.Equal(t, tt.wantMirror, rec.Mirror(tt.url))
+			if tt.wantMirror != "" {
+				assert.Equal(t, tt.url, resp.Request.URL.String(), "the response is reported against the requested URL")
+			}
+		})
+	}
+}
+
+func TestTransport_RelativeRedirect(t *testing.T) {
+	t.Parallel()
+
+	base := roundTripFunc(func(_ *http.Request) (*http.Response, error) {
+		resp := response(http.StatusFound, "")
+		resp.Header.Set("Location", "/artifactory/blobs/gh.tar.gz")
+		return resp, nil
+	})
+	req, err := http.NewRequest(http.MethodGet, "https://github.com/cli/cli/releases/download/v2.62.0/gh.tar.gz", nil)
+	require.NoError(t, err)
+	resp, err := WrapTransport(rules, base).RoundTrip(req)
+	require.NoError(t, err)
+	assert.Equal(t, "https://artifactory.example.com/artifactory/blobs/gh.tar.gz", resp.Header.Get("Location"))
+}
+
+func TestWrapClient(t *testing.T) {
+	t.Parallel()
+
+	client := &http.Client{}
+	assert.Same(t, client, WrapClient(client, nil), "no rules")
+
+	wrapped := WrapClient(client, rules)
+	assert.NotSame(t, client, wrapped)
+	assert.Nil(t, client.Transport, "the original client is not modified")
+	assert.NotNil(t, wrapped.Transport)
+}
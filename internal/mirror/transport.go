@@ -0,0 +1,108 @@
+package mirror
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+)
+
+// WrapTransport wraps base so that requests are sent to the mirrors of the
+// rules matching their URL. If base is nil, http.DefaultTransport is used.
+// Without rules, base is returned unchanged.
+//
+// WrapTransport must be the outermost transport so that authenticating
+// transports see the host actually requested: the GITHUB_TOKEN is not sent
+// to a mirror, and a Credential can cover the mirror host.
+func WrapTransport(rules []Rule, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if len(rules) == 0 {
+		return base
+	}
+	return &transport{rules: rules, base: base}
+}
+
+// WrapClient returns a copy of client whose requests go through the mirrors.
+// Without rules, client is returned unchanged.
+func WrapClient(client *http.Client, rules []Rule) *http.Client {
+	if len(rules) == 0 {
+		return client
+	}
+	wrapped := *client
+	wrapped.Transport = WrapTransport(rules, client.Transport)
+	return &wrapped
+}
+
+type transport struct {
+	rules []Rule
+	base  http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	candidates := Candidates(t.rules, req.URL.String())
+	if len(candidates) == 1 && candidates[0].Mirror == "" {
+		return t.base.RoundTrip(req)
+	}
+
+	var (
+		resp *http.Response
+		err  error
+	)
+	for i, c := range candidates {
+		resp, err = t.roundTripCandidate(req, c)
+		if err == nil && resp.StatusCode < http.StatusBadRequest {
+			if rec := fromContext(req.Context()); rec != nil {
+				rec.record(req.URL.String(), c.Mirror)
+			}
+			return resp, nil
+		}
+		// The body of the request cannot be sent again
+		if i == len(candidates)-1 || req.Context().Err() != nil || (req.Body != nil && req.GetBody == nil) {
+			break
+		}
+		if err != nil {
+			slog.Debug("mirror request failed, trying the next candidate", "url", c.URL, "error", err)
+		} else {
+			slog.Debug("mirror request failed, trying the next candidate", "url", c.URL, "status", resp.StatusCode)
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+	}
+	return resp, err
+}
+
+// roundTripCandidate sends req to the URL of c.
+func (t *transport) roundTripCandidate(req *http.Request, c Candidate) (*http.Response, error) {
+	if c.Mirror == "" {
+		return t.base.RoundTrip(req)
+	}
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mirror URL %q: %w", c.URL, err)
+	}
+	r := req.Clone(req.Context())
+	r.URL = u
+	r.Host = ""
+	if req.GetBody != nil {
+		if r.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	resp, err := t.base.RoundTrip(r)
+	if err != nil {
+		return nil, fmt.Errorf("mirror %s: %w", c.Mirror, err)
+	}
+	// The client resolves redirects against the requested URL, so a relative
+	// Location is made absolute against the mirror
+	if loc := resp.Header.Get("Location"); loc != "" {
+		if lu, err := u.Parse(loc); err == nil {
+			resp.Header.Set("Location", lu.String())
+		}
+	}
+	// Report the response against the requested URL
+	resp.Request = req
+	return resp, nil
+}
@@ -111,6 +111,32 @@ func (p *Paths) RuntimeInstallDir(name, version string) string {
 	return filepath.Join(p.userDataDir, "runtimes", name, version)
 }
 
+// ProjectsDir returns the directory holding project-scoped data.
+// Returns <userDataDir>/projects
+func (p *Paths) ProjectsDir() string {
+	return filepath.Join(p.userDataDir, "projects")
+}
+
+// ForProject returns the paths of a project-scoped installation: state, tools
+// and runtimes live in <userDataDir>/projects/<id> and binaries are linked into
+// its bin directory. The cache and env directories are shared.
+func (p *Paths) ForProject(id string) *Paths {
+	dataDir := filepath.Join(p.ProjectsDir(), id)
+	return &Paths{
+		userDataDir:   dataDir,
+		userBinDir:    filepath.Join(dataDir, "bin"),
+		userCacheDir:  p.userCacheDir,
+		envDir:        p.envDir,
+		systemDataDir: p.systemDataDir,
+	}
+}
+
+// ApplyHistoryFile returns the path to the apply history file.
+// Returns <userDataDir>/apply-history.jsonl
+func (p *Paths) ApplyHistoryFile() string {
+	return filepath.Join(p.userDataDir, "apply-history.jsonl")
+}
+
 // UserStateFile returns the path to the user state file.
 // Returns <userDataDir>/state.json
 func (p *Paths) UserStateFile() string {
@@ -191,6 +191,7 @@ func TestPaths_StateFiles(t *testing.T) {
 
 			assert.Equal(t, tt.wantUserState, p.UserStateFile())
 			assert.Equal(t, tt.wantUserLock, p.UserStateLockFile())
+			assert.Equal(t, filepath.Join(tt.userDataDir, "apply-history.jsonl"), p.ApplyHistoryFile())
 			assert.Equal(t, tt.wantSysState, p.SystemStateFile())
 			assert.Equal(t, tt.wantSysLock, p.SystemStateLockFile())
 		})
@@ -338,3 +339,17 @@ func TestNewFromConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestPaths_ForProject(t *testing.T) {
+	t.Parallel()
+
+	p, err := New(WithUserDataDir("/data"), WithUserBinDir("/bin"))
+	require.NoError(t, err)
+
+	proj := p.ForProject("0123abcd")
+	assert.Equal(t, "/data/projects", p.ProjectsDir())
+	assert.Equal(t, "/data/projects/0123abcd", proj.UserDataDir())
+	assert.Equal(t, "/data/projects/0123abcd/bin", proj.UserBinDir())
+	assert.Equal(t, "/data/projects/0123abcd/state.json", proj.UserStateFile())
+	assert.Equal(t, p.UserCacheDir(), proj.UserCacheDir())
+}
@@ -0,0 +1,220 @@
+// Package planfile reads and writes saved plans created by "tomei plan --out".
+// A saved plan records the actions to execute together with the resolved
+// versions and download URLs, and hashes of the state and manifests it was
+// computed from, so that "tomei apply" can execute exactly the reviewed plan
+// and refuse to run when either has changed since.
+package planfile
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/terassyi/tomei/internal/installer"
+	"github.com/terassyi/tomei/internal/resource"
+)
+
+// FormatVersion is the current plan file format version.
+const FormatVersion = "1"
+
+// Extension is the file extension that marks a saved plan on the command line.
+const Extension = ".json"
+
+// Plan is the on-disk representation of a saved plan.
+type Plan struct {
+	Version   string    `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+	// Paths are the absolute manifest paths the plan was computed from.
+	Paths []string `json:"paths"`
+	// ManifestHash is the hash of the loaded manifests (see HashResources).
+	ManifestHash string `json:"manifestHash"`
+	// LockHash is the hash of tomei.lock next to the manifests (see HashFile).
+	LockHash string `json:"lockHash,omitempty"`
+	// StateHash is the hash of state.json when the plan was computed (see HashFile).
+	StateHash string `json:"stateHash"`
+	// Registry is the aqua registry ref used for resolution.
+	Registry string `json:"registry,omitempty"`
+	// Update records the update flags the plan was computed with.
+	Update Update `json:"update"`
+	// Actions are the planned changes. Resources without changes are omitted.
+	Actions []Action `json:"actions"`
+}
+
+// Update records the update flags of "tomei plan".
+type Update struct {
+	Sync           bool `json:"sync,omitempty"`
+	UpdateTools    bool `json:"updateTools,omitempty"`
+	UpdateRuntimes bool `json:"updateRuntimes,omitempty"`
+}
+
+// Action is a single planned change.
+type Action struct {
+	Kind   resource.Kind       `json:"kind"`
+	Name   string              `json:"name"`
+	Action resource.ActionType `json:"action"`
+	// SpecVersion is the version as written in the manifest (e.g., "latest").
+	SpecVersion string `json:"specVersion,omitempty"`
+	// Version is the resolved version to install.
+	Version string `json:"version,omitempty"`
+	// URL is the resolved download URL (download pattern only).
+	URL string `json:"url,omitempty"`
+}
+
+// New returns an empty plan.
+func New() *Plan {
+	return &Plan{Version: FormatVersion}
+}
+
+// Load reads the plan file at path.
+func Load(path string) (*Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan file: %w", err)
+	}
+
+	var p Plan
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse plan file %s: %w", path, err)
+	}
+	if p.Version != FormatVersion {
+		return nil, fmt.Errorf("unsupported plan file version %q in %s (expected %q)", p.Version, path, FormatVersion)
+	}
+	return &p, nil
+}
+
+// planHeaderKeys are the top-level keys every saved plan has.
+var planHeaderKeys = []string{"version", "manifestHash", "stateHash", "actions"}
+
+// IsPlanFile reports whether the file at path is a saved plan: a JSON object
+// with the top-level keys of a plan. A manifest that happens to have the
+// plan file extension is not.
+func IsPlanFile(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	var header map[string]json.RawMessage
+	if err := json.Unmarshal(data, &header); err != nil {
+		return false
+	}
+	for _, key := range planHeaderKeys {
+		if _, ok := header[key]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Save writes the plan file to path atomically.
+func (p *Plan) Save(path string) error {
+	p.Version = FormatVersion
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal plan: %w", err)
+	}
+	data = append(data, '\n')
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write temp plan file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to rename plan file: %w", err)
+	}
+	return nil
+}
+
+// Add appends an action. Actions are kept sorted by kind then name.
+func (p *Plan) Add(a Action) {
+	p.Actions = append(p.Actions, a)
+	slices.SortFunc(p.Actions, func(a, b Action) int {
+		if c := cmp.Compare(a.Kind, b.Kind); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+}
+
+// ActionTypes returns the planned action type of each resource, keyed by "Kind/name".
+func (p *Plan) ActionTypes() map[string]resource.ActionType {
+	types := make(map[string]resource.ActionType, len(p.Actions))
+	for _, a := range p.Actions {
+		types[string(a.Kind)+"/"+a.Name] = a.Action
+	}
+	return types
+}
+
+// Pins returns the resolved versions and download URLs of the planned Tool and
+// Runtime installs, keyed by name, so that apply fails when a version resolves
+// to a different URL than the reviewed one. Digests are left empty; they come
+// from tomei.lock when it pins the same version.
+func (p *Plan) Pins() (tools, runtimes map[string]installer.Pin) {
+	tools = make(map[string]installer.Pin)
+	runtimes = make(map[string]installer.Pin)
+	for _, a := range p.Actions {
+		if a.Action == resource.ActionRemove || !resource.IsExactVersion(a.Version) {
+			continue
+		}
+		switch a.Kind {
+		case resource.KindTool:
+			tools[a.Name] = installer.Pin{Version: a.Version, URL: a.URL}
+		case resource.KindRuntime:
+			runtimes[a.Name] = installer.Pin{Version: a.Version, URL: a.URL}
+		}
+	}
+	return tools, runtimes
+}
+
+// Check returns an error if the manifests, the lockfile or the state differ
+// from the ones the plan was computed from.
+func (p *Plan) Check(manifestHash, lockHash, stateHash string) error {
+	if p.ManifestHash != manifestHash {
+		return errors.New("manifests have changed since the plan was created; run 'tomei plan --out' again")
+	}
+	if p.LockHash != lockHash {
+		return errors.New("tomei.lock has changed since the plan was created; run 'tomei plan --out' again")
+	}
+	if p.StateHash != stateHash {
+		return errors.New("state has changed since the plan was created; run 'tomei plan --out' again")
+	}
+	return nil
+}
+
+// HashFile returns the hex SHA-256 of the file at path, or an empty string
+// if the file does not exist.
+func HashFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// HashResources returns the hex SHA-256 of the JSON encoding of resources.
+// The hash does not depend on the order in which the resources were loaded.
+func HashResources(resources []resource.Resource) (string, error) {
+	sorted := slices.Clone(resources)
+	slices.SortFunc(sorted, func(a, b resource.Resource) int {
+		if c := cmp.Compare(a.Kind(), b.Kind()); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Name(), b.Name())
+	})
+	data, err := json.Marshal(sorted)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal resources: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
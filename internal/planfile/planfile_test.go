@@ -0,0 +1,175 @@
+package planfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/terassyi/tomei/internal/installer"
+	"github.com/terassyi/tomei/internal/resource"
+)
+
+func newTool(name, version string) *resource.Tool {
+	return &resource.Tool{
+		BaseResource: resource.BaseResource{ResourceKind: resource.KindTool, Metadata: resource.Metadata{Name: name}},
+		ToolSpec:     &resource.ToolSpec{Version: version},
+	}
+}
+
+func newRuntime(name, version string) *resource.Runtime {
+	return &resource.Runtime{
+		BaseResource: resource.BaseResource{ResourceKind: resource.KindRuntime, Metadata: resource.Metadata{Name: name}},
+		RuntimeSpec:  &resource.RuntimeSpec{Version: version},
+	}
+}
+
+func TestLoadSave(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "plan.json")
+
+	p := New()
+	p.CreatedAt = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	p.Paths = []string{"/home/user/.config/tomei"}
+	p.ManifestHash = "m"
+	p.LockHash = "l"
+	p.StateHash = "s"
+	p.Registry = "v4.465.0"
+	p.Update = Update{Sync: true}
+	p.Add(Action{Kind: resource.KindTool, Name: "gh", Action: resource.ActionInstall, SpecVersion: "latest", Version: "2.62.0", URL: "https://example.com/gh.tar.gz"})
+	require.NoError(t, p.Save(path))
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, p, loaded)
+
+	_, err = os.Stat(path + ".tmp")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestLoad_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "invalid json", content: "{", wantErr: "failed to parse plan file"},
+		{name: "unsupported version", content: `{"version":"99"}`, wantErr: "unsupported plan file version"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			path := filepath.Join(t.TempDir(), "plan.json")
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0644))
+			_, err := Load(path)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	_, err := Load(filepath.Join(t.TempDir(), "missing.json"))
+	require.Error(t, err)
+}
+
+func TestIsPlanFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	plan := filepath.Join(dir, "plan.json")
+	require.NoError(t, New().Save(plan))
+	assert.True(t, IsPlanFile(plan))
+
+	manifest := filepath.Join(dir, "tools.json")
+	require.NoError(t, os.WriteFile(manifest, []byte(`{"apiVersion":"tomei.terassyi.net/v1beta1","kind":"Tool","metadata":{"name":"rg"}}`), 0644))
+	assert.False(t, IsPlanFile(manifest))
+
+	assert.False(t, IsPlanFile(filepath.Join(dir, "missing.json")))
+}
+
+func TestPlan_ActionTypesAndPins(t *testing.T) {
+	t.Parallel()
+
+	p := New()
+	p.Add(Action{Kind: resource.KindTool, Name: "rg", Action: resource.ActionUpgrade, SpecVersion: "latest", Version: "14.1.0", URL: "https://example.com/rg-14.1.0.tar.gz"})
+	p.Add(Action{Kind: resource.KindTool, Name: "gopls", Action: resource.ActionInstall, SpecVersion: "latest", Version: "latest"})
+	p.Add(Action{Kind: resource.KindTool, Name: "jq", Action: resource.ActionRemove, Version: "1.7.1"})
+	p.Add(Action{Kind: resource.KindRuntime, Name: "go", Action: resource.ActionInstall, SpecVersion: "stable", Version: "1.25.5"})
+
+	// Sorted by kind then name
+	var names []string
+	for _, a := range p.Actions {
+		names = append(names, a.Name)
+	}
+	assert.Equal(t, []string{"go", "gopls", "jq", "rg"}, names)
+
+	assert.Equal(t, map[string]resource.ActionType{
+		"Tool/rg":    resource.ActionUpgrade,
+		"Tool/gopls": resource.ActionInstall,
+		"Tool/jq":    resource.ActionRemove,
+		"Runtime/go": resource.ActionInstall,
+	}, p.ActionTypes())
+
+	tools, runtimes := p.Pins()
+	assert.Equal(t, map[string]installer.Pin{"rg": {Version: "14.1.0", URL: "https://example.com/rg-14.1.0.tar.gz"}}, tools)
+	assert.Equal(t, map[string]installer.Pin{"go": {Version: "1.25.5"}}, runtimes)
+}
+
+func TestPlan_Check(t *testing.T) {
+	t.Parallel()
+
+	p := &Plan{ManifestHash: "m", LockHash: "l", StateHash: "s"}
+	require.NoError(t, p.Check("m", "l", "s"))
+
+	err := p.Check("other", "l", "s")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "manifests have changed")
+
+	err = p.Check("m", "other", "s")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "tomei.lock has changed")
+
+	err = p.Check("m", "l", "other")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "state has changed")
+}
+
+func TestHashFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+
+	h, err := HashFile(path)
+	require.NoError(t, err)
+	assert.Empty(t, h)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"version":"1"}`), 0644))
+	h1, err := HashFile(path)
+	require.NoError(t, err)
+	assert.Len(t, h1, 64)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"version":"2"}`), 0644))
+	h2, err := HashFile(path)
+	require.NoError(t, err)
+	assert.NotEqual(t, h1, h2)
+}
+
+func TestHashResources(t *testing.T) {
+	t.Parallel()
+
+	a, err := HashResources([]resource.Resource{newTool("rg", "14.1.0"), newRuntime("go", "1.25.5")})
+	require.NoError(t, err)
+	b, err := HashResources([]resource.Resource{newRuntime("go", "1.25.5"), newTool("rg", "14.1.0")})
+	require.NoError(t, err)
+	assert.Equal(t, a, b, "hash must not depend on load order")
+
+	c, err := HashResources([]resource.Resource{newTool("rg", "14.1.1"), newRuntime("go", "1.25.5")})
+	require.NoError(t, err)
+	assert.NotEqual(t, a, c)
+}
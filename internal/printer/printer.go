@@ -135,7 +135,7 @@ type runtimeFormatter struct{}
 func (runtimeFormatter) Headers(wide bool) []string {
 	h := []string{colName, colVersion, colVersionKind, "TYPE"}
 	if wide {
-		h = append(h, "INSTALL_PATH", "BINARIES")
+		h = append(h, "INSTALL_PATH", "BINARIES", "EXTRA_VERSIONS")
 	}
 	return h
 }
@@ -143,7 +143,7 @@ func (runtimeFormatter) Headers(wide bool) []string {
 func (runtimeFormatter) FormatRow(name string, r *resource.RuntimeState, wide bool) []string {
 	row := []string{name, r.Version, formatVersionKind(r.VersionKind, r.SpecVersion), string(r.Type)}
 	if wide {
-		row = append(row, r.InstallPath, strings.Join(r.Binaries, ","))
+		row = append(row, r.InstallPath, strings.Join(r.Binaries, ","), strings.Join(r.ExtraVersions(), ","))
 	}
 	return row
 }
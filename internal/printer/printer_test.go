@@ -248,7 +248,7 @@ func TestRuntimeFormatter_Headers(t *testing.T) {
 		t.Parallel()
 
 		h := f.Headers(true)
-		assert.Equal(t, []string{"NAME", "VERSION", "VERSION_KIND", "TYPE", "INSTALL_PATH", "BINARIES"}, h)
+		assert.Equal(t, []string{"NAME", "VERSION", "VERSION_KIND", "TYPE", "INSTALL_PATH", "BINARIES", "EXTRA_VERSIONS"}, h)
 	})
 }
 
@@ -283,7 +283,7 @@ func TestRuntimeFormatter_FormatRow(t *testing.T) {
 		assert.Equal(t, "delegation", row[3])
 	})
 
-	t.Run("wide adds install_path and binaries", func(t *testing.T) {
+	t.Run("wide adds install_path, binaries and extra versions", func(t *testing.T) {
 		t.Parallel()
 
 		rs := &resource.RuntimeState{
@@ -292,11 +292,15 @@ func TestRuntimeFormatter_FormatRow(t *testing.T) {
 			VersionKind: resource.VersionExact,
 			InstallPath: "/home/user/.local/share/tomei/runtimes/go/1.25.1",
 			Binaries:    []string{"go", "gofmt"},
+			Extras: map[string]*resource.RuntimeVersionState{
+				"1.24.3": {}, "1.23.8": {},
+			},
 		}
 		row := f.FormatRow("go", rs, true)
-		assert.Len(t, row, 6)
+		assert.Len(t, row, 7)
 		assert.Equal(t, "/home/user/.local/share/tomei/runtimes/go/1.25.1", row[4])
 		assert.Equal(t, "go,gofmt", row[5])
+		assert.Equal(t, "1.23.8,1.24.3", row[6])
 	})
 }
 
@@ -0,0 +1,148 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/parser"
+
+	"github.com/terassyi/tomei/internal/verify"
+)
+
+// cueModDir is the directory marking the root of a CUE module.
+const cueModDir = "cue.mod"
+
+// Files returns the files the project manifest is built from: tomei.cue and,
+// when the project is inside a CUE module, cue.mod/module.cue and the files of
+// the packages the manifest imports from the module or from cue.mod/pkg,
+// gen and usr, directly or through other imported packages. Packages of
+// registry modules are pinned by module.cue and are not listed.
+func (p *Project) Files() ([]string, error) {
+	files := []string{p.Manifest()}
+
+	modRoot := findModuleRoot(p.Root)
+	if modRoot == "" {
+		return files, nil
+	}
+	modFile, err := verify.ParseModuleFile(filepath.Join(modRoot, cueModDir))
+	if err != nil {
+		return nil, err
+	}
+	var modPath string
+	if modFile != nil {
+		files = append(files, filepath.Join(modRoot, cueModDir, "module.cue"))
+		modPath = modFile.ModulePath()
+	}
+
+	seen := map[string]bool{p.Manifest(): true}
+	queue := []string{p.Manifest()}
+	for len(queue) > 0 {
+		file := queue[0]
+		queue = queue[1:]
+		for _, imp := range fileImports(file) {
+			for _, dep := range importedFiles(modRoot, modPath, imp) {
+				if !seen[dep] {
+					seen[dep] = true
+					files = append(files, dep)
+					queue = append(queue, dep)
+				}
+			}
+		}
+	}
+	slices.Sort(files[1:])
+	return files, nil
+}
+
+// findModuleRoot returns the nearest directory at or above dir containing a
+// cue.mod directory, or "" when there is none.
+func findModuleRoot(dir string) string {
+	for {
+		if info, err := os.Stat(filepath.Join(dir, cueModDir)); err == nil && info.IsDir() {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// fileImports returns the imports of a CUE file. A file that cannot be parsed
+// imports nothing; loading the manifest reports the error.
+func fileImports(file string) []ast.ImportPath {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+	f, err := parser.ParseFile(file, data, parser.ImportsOnly)
+	if err != nil {
+		return nil
+	}
+	var imports []ast.ImportPath
+	for _, spec := range f.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		imports = append(imports, ast.ParseImportPath(path))
+	}
+	return imports
+}
+
+// importedFiles returns the files of the package imported by imp that live in
+// the module at modRoot. Like CUE, the files of a package of the module
+// include those of the same package in the parent directories up to the
+// module root.
+func importedFiles(modRoot, modPath string, imp ast.ImportPath) []string {
+	if modPath != "" && (imp.Path == modPath || strings.HasPrefix(imp.Path, modPath+"/")) {
+		dir := filepath.Join(modRoot, filepath.FromSlash(strings.TrimPrefix(imp.Path, modPath)))
+		if rel, err := filepath.Rel(modRoot, dir); err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+			return nil
+		}
+		var files []string
+		for {
+			files = append(files, packageFiles(dir, imp.Qualifier)...)
+			if dir == modRoot {
+				return files
+			}
+			dir = filepath.Dir(dir)
+		}
+	}
+
+	var files []string
+	for _, sub := range []string{"gen", "pkg", "usr"} {
+		files = append(files, packageFiles(filepath.Join(modRoot, cueModDir, sub, filepath.FromSlash(imp.Path)), imp.Qualifier)...)
+	}
+	return files
+}
+
+// packageFiles returns the CUE files in dir that belong to package pkg.
+func packageFiles(dir, pkg string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || filepath.Ext(name) != ".cue" || strings.HasPrefix(name, "_") || strings.HasPrefix(name, ".") {
+			continue
+		}
+		file := filepath.Join(dir, name)
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		f, err := parser.ParseFile(file, data, parser.PackageClauseOnly)
+		if err != nil || f.PackageName() != pkg {
+			continue
+		}
+		files = append(files, file)
+	}
+	return files
+}
@@ -0,0 +1,122 @@
+// Package project locates project-scoped manifests and records which of them
+// the user allowed to be applied.
+//
+// A project is a directory containing a tomei.cue manifest. Its tools and
+// runtimes are installed into a data directory of their own, and are put on
+// PATH by the shell hook only while the working directory is inside the
+// project.
+package project
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/terassyi/tomei/internal/checksum"
+	"github.com/terassyi/tomei/internal/installer/builtin"
+	"github.com/terassyi/tomei/internal/resource"
+)
+
+// ManifestFile is the name of the project manifest.
+const ManifestFile = "tomei.cue"
+
+// Project is a directory with a project manifest.
+type Project struct {
+	// Root is the absolute path of the project directory.
+	Root string
+}
+
+// Find returns the nearest project at or above dir, skipping exclude (the
+// global configuration directory, whose manifests are not project-scoped).
+// It returns nil when there is none.
+func Find(dir, exclude string) *Project {
+	cur, err := filepath.Abs(dir)
+	if err != nil {
+		return nil
+	}
+	for {
+		if cur != exclude {
+			if info, err := os.Stat(filepath.Join(cur, ManifestFile)); err == nil && !info.IsDir() {
+				return &Project{Root: cur}
+			}
+		}
+		parent := filepath.Dir(cur)
+		if parent == cur {
+			return nil
+		}
+		cur = parent
+	}
+}
+
+// Manifest returns the path of the project manifest.
+func (p *Project) Manifest() string {
+	return filepath.Join(p.Root, ManifestFile)
+}
+
+// ID returns a stable identifier of the project derived from its root.
+func (p *Project) ID() string {
+	sum := sha256.Sum256([]byte(p.Root))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Digest returns the digest of the files the project manifest is built from,
+// which the user allows. Editing any of them changes the digest.
+func (p *Project) Digest() (checksum.Digest, error) {
+	files, err := p.Files()
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		rel, err := filepath.Rel(p.Root, file)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00", filepath.ToSlash(rel), len(data))
+		h.Write(data)
+	}
+	return checksum.Digest(hex.EncodeToString(h.Sum(nil))), nil
+}
+
+// CheckResources reports an error for resources that cannot be project-scoped.
+// Projects install tools into their own bin directory, so only tools
+// downloaded by a builtin installer (aqua or download) and runtimes of the
+// download pattern are supported. Tools built by runtimes, delegation
+// installers or commands and runtimes installed by bootstrap commands place
+// binaries in shared directories and must be declared in the global
+// manifests instead.
+func CheckResources(resources []resource.Resource) error {
+	for _, res := range resources {
+		switch r := res.(type) {
+		case *resource.Tool:
+			if !builtin.IsBuiltin(r.ToolSpec.InstallerRef) {
+				return fmt.Errorf("tool %s: only tools installed by the aqua or download installer can be project-scoped; declare it in the global manifests", r.Name())
+			}
+		case *resource.Runtime:
+			if r.RuntimeSpec.Type != resource.InstallTypeDownload {
+				return fmt.Errorf("runtime %s: only runtimes of the download pattern can be project-scoped; declare it in the global manifests", r.Name())
+			}
+		case *resource.Credential:
+		default:
+			return fmt.Errorf("%s %s is not supported in project manifests; only tools, runtimes and credentials can be project-scoped", res.Kind(), res.Name())
+		}
+	}
+	return nil
+}
+
+// PlaceRuntimes makes the runtimes among resources link their binaries into
+// binDir, the bin directory of the project, instead of the shared directory
+// named by binDir or toolBinPath of their specs.
+func PlaceRuntimes(resources []resource.Resource, binDir string) {
+	for _, res := range resources {
+		if r, ok := res.(*resource.Runtime); ok {
+			r.RuntimeSpec.BinDir = binDir
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/terassyi/tomei/internal/resource"
+)
+
+func TestFind(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	global := filepath.Join(root, "config")
+	service := filepath.Join(global, "service")
+	sub := filepath.Join(service, "cmd", "server")
+	require.NoError(t, os.MkdirAll(sub, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(global, ManifestFile), []byte("package tomei\n"), 0644))
+
+	assert.Nil(t, Find(sub, global), "the global configuration directory is not a project")
+
+	require.NoError(t, os.WriteFile(filepath.Join(service, ManifestFile), []byte("package tomei\n"), 0644))
+	p := Find(sub, global)
+	require.NotNil(t, p)
+	assert.Equal(t, service, p.Root)
+	assert.Equal(t, filepath.Join(service, ManifestFile), p.Manifest())
+	assert.Len(t, p.ID(), 16)
+	assert.NotEqual(t, p.ID(), (&Project{Root: global}).ID())
+}
+
+func TestCheckResources(t *testing.T) {
+	t.Parallel()
+
+	runtime := func(spec *resource.RuntimeSpec) *resource.Runtime {
+		return &resource.Runtime{
+			BaseResource: resource.BaseResource{
+				APIVersion:   resource.GroupVersion,
+				ResourceKind: resource.KindRuntime,
+				Metadata:     resource.Metadata{Name: "go"},
+			},
+			RuntimeSpec: spec,
+		}
+	}
+	tool := func(spec *resource.ToolSpec) *resource.Tool {
+		return &resource.Tool{
+			BaseResource: resource.BaseResource{
+				APIVersion:   resource.GroupVersion,
+				ResourceKind: resource.KindTool,
+				Metadata:     resource.Metadata{Name: "rg"},
+			},
+			ToolSpec: spec,
+		}
+	}
+
+	tests := []struct {
+		name      string
+		resources []resource.Resource
+		wantErr   string
+	}{
+		{
+			name: "aqua and download tools",
+			resources: []resource.Resource{
+				tool(&resource.ToolSpec{InstallerRef: "aqua", Version: "14.1.1"}),
+				tool(&resource.ToolSpec{InstallerRef: "download", Version: "14.1.1"}),
+			},
+		},
+		{
+			name:      "runtime-built tool",
+			resources: []resource.Resource{tool(&resource.ToolSpec{RuntimeRef: "go", Version: "latest"})},
+			wantErr:   "tool rg: only tools installed by the aqua or download installer can be project-scoped",
+		},
+		{
+			name:      "download runtime",
+			resources: []resource.Resource{runtime(&resource.RuntimeSpec{Type: resource.InstallTypeDownload, Version: "1.25.6"})},
+		},
+		{
+			name:      "delegation runtime",
+			resources: []resource.Resource{runtime(&resource.RuntimeSpec{Type: resource.InstallTypeDelegation, Version: "stable"})},
+			wantErr:   "runtime go: only runtimes of the download pattern can be project-scoped",
+		},
+		{
+			name: "installer",
+			resources: []resource.Resource{&resource.Installer{
+				BaseResource: resource.BaseResource{
+					APIVersion:   resource.GroupVersion,
+					ResourceKind: resource.KindInstaller,
+					Metadata:     resource.Metadata{Name: "brew"},
+				},
+				InstallerSpec: &resource.InstallerSpec{Type: resource.InstallTypeDelegation},
+			}},
+			wantErr: "Installer brew is not supported in project manifests",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := CheckResources(tt.resources)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestTrustStore(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, ManifestFile), []byte("package tomei\n"), 0644))
+	p := &Project{Root: root}
+	store := NewTrustStore(filepath.Join(t.TempDir(), "projects"))
+
+	status, err := store.Status(p)
+	require.NoError(t, err)
+	assert.Equal(t, TrustNotAllowed, status)
+
+	require.NoError(t, store.Allow(p))
+	status, err = store.Status(p)
+	require.NoError(t, err)
+	assert.Equal(t, TrustAllowed, status)
+
+	require.NoError(t, os.WriteFile(p.Manifest(), []byte("package tomei\n\nrg: {}\n"), 0644))
+	status, err = store.Status(p)
+	require.NoError(t, err)
+	assert.Equal(t, TrustChanged, status, "a modified manifest must be allowed again")
+
+	require.NoError(t, store.Allow(p))
+	require.NoError(t, store.Deny(p))
+	status, err = store.Status(p)
+	require.NoError(t, err)
+	assert.Equal(t, TrustNotAllowed, status)
+}
+
+func TestPlaceRuntimes(t *testing.T) {
+	t.Parallel()
+
+	rt := &resource.Runtime{RuntimeSpec: &resource.RuntimeSpec{Type: resource.InstallTypeDownload, ToolBinPath: "~/go/bin"}}
+	tool := &resource.Tool{ToolSpec: &resource.ToolSpec{InstallerRef: "aqua"}}
+	PlaceRuntimes([]resource.Resource{rt, tool}, "/data/projects/abc/bin")
+	assert.Equal(t, "/data/projects/abc/bin", rt.RuntimeSpec.BinDir)
+}
+
+func TestFiles(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	write := func(name, content string) string {
+		p := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0644))
+		return p
+	}
+	write("cue.mod/module.cue", "module: \"example.com/service@v0\"\nlanguage: version: \"v0.9.0\"\n")
+	write(ManifestFile, "package tomei\n\nimport (\n\t\"example.com/service/tools\"\n\t\"tomei.terassyi.net/presets/go\"\n)\n")
+	write("sibling.cue", "package tomei\n")
+	write("tools/tools.cue", "package tools\n\nimport \"example.com/service/tools/lint\"\n")
+	write("tools/other.cue", "package other\n")
+	write("tools/lint/lint.cue", "package lint\n")
+	write("lint.cue", "package lint\n")
+
+	p := &Project{Root: root}
+	files, err := p.Files()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(root, ManifestFile),
+		filepath.Join(root, "cue.mod", "module.cue"),
+		filepath.Join(root, "lint.cue"),
+		filepath.Join(root, "tools", "lint", "lint.cue"),
+		filepath.Join(root, "tools", "tools.cue"),
+	}, files, "imported packages of the module, not siblings or registry modules")
+
+	digest, err := p.Digest()
+	require.NoError(t, err)
+	write("sibling.cue", "package tomei\n\nfoo: 1\n")
+	unchanged, err := p.Digest()
+	require.NoError(t, err)
+	assert.Equal(t, digest, unchanged)
+	write("tools/lint/lint.cue", "package lint\n\nfoo: 1\n")
+	changed, err := p.Digest()
+	require.NoError(t, err)
+	assert.NotEqual(t, digest, changed, "editing an imported package changes the digest")
+}
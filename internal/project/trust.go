@@ -0,0 +1,110 @@
+package project
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/terassyi/tomei/internal/checksum"
+)
+
+// TrustFile is the file in the projects directory that records the allowed manifests.
+const TrustFile = "allowed.json"
+
+// Trust is the trust status of a project manifest.
+type Trust string
+
+const (
+	// TrustAllowed means the manifest was allowed as it is.
+	TrustAllowed Trust = "allowed"
+	// TrustChanged means the manifest changed since it was allowed.
+	TrustChanged Trust = "changed"
+	// TrustNotAllowed means the manifest was never allowed, or was denied.
+	TrustNotAllowed Trust = "not allowed"
+)
+
+// TrustStore records the digests of the project manifests the user allowed,
+// keyed by project root. A manifest must be allowed again after it changes,
+// so that a checked-out repository cannot install tools the user did not review.
+type TrustStore struct {
+	path string
+}
+
+// NewTrustStore returns the trust store in the projects directory.
+func NewTrustStore(projectsDir string) *TrustStore {
+	return &TrustStore{path: filepath.Join(projectsDir, TrustFile)}
+}
+
+// Status returns the trust status of the project manifest.
+func (s *TrustStore) Status(p *Project) (Trust, error) {
+	allowed, err := s.load()
+	if err != nil {
+		return "", err
+	}
+	recorded, ok := allowed[p.Root]
+	if !ok {
+		return TrustNotAllowed, nil
+	}
+	digest, err := p.Digest()
+	if err != nil {
+		return "", err
+	}
+	if digest != recorded {
+		return TrustChanged, nil
+	}
+	return TrustAllowed, nil
+}
+
+// Allow records the current project manifest as allowed.
+func (s *TrustStore) Allow(p *Project) error {
+	digest, err := p.Digest()
+	if err != nil {
+		return err
+	}
+	allowed, err := s.load()
+	if err != nil {
+		return err
+	}
+	allowed[p.Root] = digest
+	return s.save(allowed)
+}
+
+// Deny removes the project from the allowed manifests.
+func (s *TrustStore) Deny(p *Project) error {
+	allowed, err := s.load()
+	if err != nil {
+		return err
+	}
+	delete(allowed, p.Root)
+	return s.save(allowed)
+}
+
+func (s *TrustStore) load() (map[string]checksum.Digest, error) {
+	allowed := make(map[string]checksum.Digest)
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return allowed, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", s.path, err)
+	}
+	if err := json.Unmarshal(data, &allowed); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", s.path, err)
+	}
+	return allowed, nil
+}
+
+func (s *TrustStore) save(allowed map[string]checksum.Digest) error {
+	data, err := json.MarshalIndent(allowed, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(s.path), err)
+	}
+	if err := os.WriteFile(s.path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", s.path, err)
+	}
+	return nil
+}
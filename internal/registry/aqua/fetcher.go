@@ -3,6 +3,7 @@ package aqua
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -130,6 +131,18 @@ func (f *fetcher) fetchRemote(ctx context.Context, ref, pkg string) ([]byte, err
 		return nil, err
 	}
 
+	data, err := f.get(ctx, registryURL)
+	if errors.Is(err, errNotFound) {
+		return nil, fmt.Errorf("package not found: %s", pkg)
+	}
+	return data, err
+}
+
+// errNotFound is returned by get when the registry responds with 404.
+var errNotFound = errors.New("not found")
+
+// get fetches a registry file.
+func (f *fetcher) get(ctx context.Context, registryURL string) ([]byte, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, registryURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -142,7 +155,7 @@ func (f *fetcher) fetchRemote(ctx context.Context, ref, pkg string) ([]byte, err
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusNotFound {
-		return nil, fmt.Errorf("package not found: %s", pkg)
+		return nil, errNotFound
 	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
@@ -0,0 +1,204 @@
+package aqua
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// indexFile is the name of the registry index at the root of aqua-registry.
+// It aggregates the definitions of all packages under pkgs/.
+const indexFile = "registry.yaml"
+
+// indexCachePath returns the cache path of the registry index for ref.
+func (f *fetcher) indexCachePath(ref string) (string, error) {
+	if err := validatePathComponent(ref); err != nil {
+		return "", fmt.Errorf("invalid ref: %w", err)
+	}
+	return filepath.Join(f.cacheDir, ref, indexFile), nil
+}
+
+// fetchIndex fetches all package definitions of the registry (cache-first).
+func (f *fetcher) fetchIndex(ctx context.Context, ref string) ([]PackageInfo, error) {
+	cacheFilePath, err := f.indexCachePath(ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct cache path: %w", err)
+	}
+
+	// 1. Check cache
+	if data, err := os.ReadFile(cacheFilePath); err == nil {
+		if pkgs, err := parseIndexYAML(data); err == nil {
+			slog.Debug("cache hit", "index", ref)
+			return pkgs, nil
+		}
+	}
+
+	// 2. Fetch from remote
+	slog.Debug("cache miss, fetching registry index from remote", "ref", ref)
+	base, err := url.Parse(f.baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	base.Path = path.Join(base.Path, ref, indexFile)
+	data, err := f.get(ctx, base.String())
+	if errors.Is(err, errNotFound) {
+		return nil, fmt.Errorf("registry index not found for ref %s", ref)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Parse before caching so that a broken response is not cached
+	pkgs, err := parseIndexYAML(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// 4. Save to cache
+	if err := f.writeCache(cacheFilePath, data); err != nil {
+		slog.Warn("failed to cache registry index", "path", cacheFilePath, "error", err)
+	}
+	return pkgs, nil
+}
+
+// parseIndexYAML parses the registry index.
+func parseIndexYAML(data []byte) ([]PackageInfo, error) {
+	var file registryFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse registry index: %w", err)
+	}
+	if len(file.Packages) == 0 {
+		return nil, fmt.Errorf("no packages found in registry index")
+	}
+	return file.Packages, nil
+}
+
+// Match ranks of search results, best first.
+const (
+	matchExact = iota
+	matchName
+	matchAlias
+	matchDescription
+	matchNone
+)
+
+// Search returns the packages of the registry index whose name, aliases, or
+// description contain every word of query (case-insensitive).
+//
+// Results are ordered by relevance: exact name or alias matches first, then
+// name matches, alias matches, and description-only matches, each sorted by
+// package name.
+//
+// Parameters:
+//   - ref: aqua-registry version (e.g., "v4.465.0")
+//   - query: search words (e.g., "ripgrep", "kubernetes cli")
+func (r *Resolver) Search(ctx context.Context, ref RegistryRef, query string) ([]PackageInfo, error) {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("search query is empty")
+	}
+
+	pkgs, err := r.fetcher.fetchIndex(ctx, string(ref))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch registry index: %w", err)
+	}
+
+	type hit struct {
+		info PackageInfo
+		rank int
+	}
+	var hits []hit
+	for _, p := range pkgs {
+		rank := matchExact
+		for _, term := range terms {
+			rank = max(rank, matchRank(&p, term))
+		}
+		if rank == matchNone {
+			continue
+		}
+		hits = append(hits, hit{info: p, rank: rank})
+	}
+
+	slices.SortFunc(hits, func(a, b hit) int {
+		return cmp.Or(cmp.Compare(a.rank, b.rank), cmp.Compare(a.info.PackageName(), b.info.PackageName()))
+	})
+	results := make([]PackageInfo, 0, len(hits))
+	for _, h := range hits {
+		results = append(results, h.info)
+	}
+	return results, nil
+}
+
+// FindCommand returns the packages of the registry index that install an
+// executable named command, sorted by package name. A package installs the
+// executables listed in its files, or the last element of its name when it
+// lists none.
+//
+// Parameters:
+//   - ref: aqua-registry version (e.g., "v4.465.0")
+//   - command: executable name (e.g., "rg")
+func (r *Resolver) FindCommand(ctx context.Context, ref RegistryRef, command string) ([]PackageInfo, error) {
+	pkgs, err := r.fetcher.fetchIndex(ctx, string(ref))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch registry index: %w", err)
+	}
+
+	var results []PackageInfo
+	for _, p := range pkgs {
+		if installsCommand(&p, command) {
+			results = append(results, p)
+		}
+	}
+	slices.SortFunc(results, func(a, b PackageInfo) int {
+		return cmp.Compare(a.PackageName(), b.PackageName())
+	})
+	return results, nil
+}
+
+// installsCommand reports whether p installs an executable named command.
+func installsCommand(p *PackageInfo, command string) bool {
+	if len(p.Files) == 0 {
+		return path.Base(p.PackageName()) == command
+	}
+	for _, f := range p.Files {
+		if f.Name == command {
+			return true
+		}
+	}
+	return false
+}
+
+// matchRank returns how well a lower-cased search term matches p.
+func matchRank(p *PackageInfo, term string) int {
+	name := strings.ToLower(p.PackageName())
+	if name == term || path.Base(name) == term {
+		return matchExact
+	}
+	for _, a := range p.Aliases {
+		if strings.ToLower(a.Name) == term {
+			return matchExact
+		}
+	}
+	if strings.Contains(name, term) {
+		return matchName
+	}
+	for _, a := range p.Aliases {
+		if strings.Contains(strings.ToLower(a.Name), term) {
+			return matchAlias
+		}
+	}
+	if strings.Contains(strings.ToLower(p.Description), term) {
+		return matchDescription
+	}
+	return matchNone
+}
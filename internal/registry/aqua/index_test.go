@@ -0,0 +1,156 @@
+package aqua
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testIndexYAML = `packages:
+  - type: github_release
+    repo_owner: BurntSushi
+    repo_name: ripgrep
+    description: ripgrep recursively searches directories for a regex pattern
+    aliases:
+      - name: rg
+    files:
+      - name: rg
+  - type: github_release
+    repo_owner: sharkdp
+    repo_name: fd
+    description: A simple, fast and user-friendly alternative to 'find'
+  - name: kubernetes/kubernetes/kubectl
+    type: http
+    repo_owner: kubernetes
+    repo_name: kubernetes
+    description: Kubernetes CLI
+  - type: github_release
+    repo_owner: junegunn
+    repo_name: fzf
+    description: A command-line fuzzy finder that searches with ripgrep-like speed
+`
+
+func TestResolver_Search(t *testing.T) {
+	t.Parallel()
+
+	requests := 0
+	client := &http.Client{
+		Transport: &mockRoundTripper{
+			handler: func(req *http.Request) (*http.Response, error) {
+				requests++
+				assert.Equal(t, "/aquaproj/aqua-registry/v4.465.0/registry.yaml", req.URL.Path)
+				return newMockResponse(http.StatusOK, testIndexYAML), nil
+			},
+		},
+	}
+	cacheDir := t.TempDir()
+	r := NewResolver(cacheDir, client)
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{name: "alias exact match first", query: "rg", want: []string{"BurntSushi/ripgrep"}},
+		{name: "name before description", query: "ripgrep", want: []string{"BurntSushi/ripgrep", "junegunn/fzf"}},
+		{name: "repo name exact match", query: "fd", want: []string{"sharkdp/fd"}},
+		{name: "explicit name", query: "kubectl", want: []string{"kubernetes/kubernetes/kubectl"}},
+		{name: "all words must match", query: "kubernetes cli", want: []string{"kubernetes/kubernetes/kubectl"}},
+		{name: "case insensitive description", query: "FUZZY", want: []string{"junegunn/fzf"}},
+		{name: "no match", query: "nonexistent", want: []string{}},
+	}
+	for _, tt := range tests {
+		results, err := r.Search(context.Background(), "v4.465.0", tt.query)
+		require.NoError(t, err, tt.name)
+		got := []string{}
+		for _, p := range results {
+			got = append(got, p.PackageName())
+		}
+		assert.Equal(t, tt.want, got, tt.name)
+	}
+
+	assert.Equal(t, 1, requests, "index should be fetched once and then read from cache")
+	assert.FileExists(t, filepath.Join(cacheDir, "v4.465.0", "registry.yaml"))
+
+	_, err := r.Search(context.Background(), "v4.465.0", "  ")
+	require.Error(t, err)
+}
+
+func TestResolver_FindCommand(t *testing.T) {
+	t.Parallel()
+
+	client := &http.Client{
+		Transport: &mockRoundTripper{
+			handler: func(_ *http.Request) (*http.Response, error) {
+				return newMockResponse(http.StatusOK, testIndexYAML), nil
+			},
+		},
+	}
+	r := NewResolver(t.TempDir(), client)
+
+	tests := []struct {
+		name    string
+		command string
+		want    []string
+	}{
+		{name: "files name", command: "rg", want: []string{"BurntSushi/ripgrep"}},
+		{name: "repo name is not a command when files are listed", command: "ripgrep", want: []string{}},
+		{name: "repo name without files", command: "fd", want: []string{"sharkdp/fd"}},
+		{name: "last element of explicit name", command: "kubectl", want: []string{"kubernetes/kubernetes/kubectl"}},
+		{name: "no match", command: "nonexistent", want: []string{}},
+	}
+	for _, tt := range tests {
+		results, err := r.FindCommand(context.Background(), "v4.465.0", tt.command)
+		require.NoError(t, err, tt.name)
+		got := []string{}
+		for _, p := range results {
+			got = append(got, p.PackageName())
+		}
+		assert.Equal(t, tt.want, got, tt.name)
+	}
+}
+
+func TestFetcher_FetchIndex_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr string
+	}{
+		{name: "not found", status: http.StatusNotFound, wantErr: "registry index not found for ref v0.0.1"},
+		{name: "empty index", status: http.StatusOK, body: "packages: []\n", wantErr: "no packages found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cacheDir := t.TempDir()
+			client := &http.Client{
+				Transport: &mockRoundTripper{
+					handler: func(req *http.Request) (*http.Response, error) {
+						return newMockResponse(tt.status, tt.body), nil
+					},
+				},
+			}
+			_, err := newFetcher(cacheDir, client).fetchIndex(context.Background(), "v0.0.1")
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+
+			_, statErr := os.Stat(filepath.Join(cacheDir, "v0.0.1", "registry.yaml"))
+			assert.True(t, os.IsNotExist(statErr), "failed responses must not be cached")
+		})
+	}
+}
+
+func TestPackageInfo_PackageName(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "cli/cli", (&PackageInfo{RepoOwner: "cli", RepoName: "cli"}).PackageName())
+	assert.Equal(t, "kubernetes/kubernetes/kubectl", (&PackageInfo{Name: "kubernetes/kubernetes/kubectl", RepoOwner: "kubernetes", RepoName: "kubernetes"}).PackageName())
+	assert.Empty(t, (&PackageInfo{}).PackageName())
+}
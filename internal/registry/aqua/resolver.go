@@ -10,6 +10,7 @@ import (
 
 	"github.com/terassyi/tomei/internal/checksum"
 	"github.com/terassyi/tomei/internal/installer/extract"
+	"github.com/terassyi/tomei/internal/verify"
 )
 
 // ResolvedSource contains the resolved download information for a package.
@@ -54,6 +55,28 @@ type ResolvedSource struct {
 	// If non-empty, the package cannot be installed.
 	// Example: "package example/tool does not support windows/amd64"
 	Errors []string
+
+	// Signatures are the upstream signatures of the asset declared by the
+	// package (nil if none).
+	Signatures *verify.ArtifactSignatures
+
+	// Delegation is set for packages that are built from source by a runtime
+	// (go_install, cargo) instead of downloaded. URL is empty in that case.
+	Delegation *Delegation
+}
+
+// Delegation describes how a runtime installs a package from source.
+type Delegation struct {
+	// Runtime is the name of the runtime that installs the package (GoRuntime or RustRuntime).
+	Runtime string
+
+	// Package is passed to the runtime install command as {{.Package}}.
+	// Example: "golang.org/x/tools/cmd/goimports" (go_install), "xh" (cargo)
+	Package string
+
+	// Version is passed to the runtime install command as {{.Version}}.
+	// Example: "v0.30.0" (go_install), "0.20.0" (cargo)
+	Version string
 }
 
 // Resolver resolves aqua-registry packages to download URLs.
@@ -65,6 +88,10 @@ type ResolvedSource struct {
 //  4. Apply OS-specific overrides (overrides)
 //  5. Apply replacements (e.g., amd64 → x86_64, darwin → macOS)
 //  6. Render asset template to build the final download URL
+//  7. Render checksum and signature file URLs
+//
+// go_install and cargo packages are not downloaded: after step 5 they resolve
+// to a Delegation describing the runtime install command instead.
 //
 // Usage:
 //
@@ -198,6 +225,17 @@ func (r *Resolver) ResolveWithOS(ctx context.Context, ref RegistryRef, pkg, vers
 		Format:  info.Format,
 	}
 
+	// Packages built from source are handed to a runtime; there is nothing to download
+	if info.DelegationRuntime() != "" {
+		delegation, err := buildDelegation(info, vars)
+		if err != nil {
+			return nil, err
+		}
+		result.Delegation = delegation
+		result.Files = info.Files
+		return result, nil
+	}
+
 	// 7. Render asset name first (needed for checksum templates like "{{.Asset}}.sha256")
 	if info.Asset != "" {
 		renderedAsset, err := RenderTemplate(info.Asset, vars)
@@ -230,7 +268,16 @@ func (r *Resolver) ResolveWithOS(ctx context.Context, ref RegistryRef, pkg, vers
 		}
 	}
 
-	// 10. Render FileSpec.Src templates (e.g., "krew-{{.OS}}_{{.Arch}}" → "krew-linux_arm64")
+	// 10. Render the upstream signatures of the asset (cosign, minisign, SLSA provenance, attestations)
+	signatures, err := buildSignatures(info, vars, result.ChecksumURL)
+	if err != nil {
+		result.Warnings = append(result.Warnings,
+			fmt.Sprintf("failed to resolve signatures: %v", err))
+	} else {
+		result.Signatures = signatures
+	}
+
+	// 11. Render FileSpec.Src templates (e.g., "krew-{{.OS}}_{{.Arch}}" → "krew-linux_arm64")
 	// Note: info is freshly allocated by fetch() on each call, so in-place mutation is safe.
 	for idx := range info.Files {
 		if info.Files[idx].Src != "" {
@@ -242,11 +289,16 @@ func (r *Resolver) ResolveWithOS(ctx context.Context, ref RegistryRef, pkg, vers
 		}
 	}
 
-	// 11. Set format and files
+	// 12. Set format and files
 	result.Format = extract.NormalizeArchiveType(info.Format)
-	if result.Format == "" && info.Asset != "" {
-		// Auto-detect raw binary format when asset has no archive extension
-		if !hasArchiveExtension(info.Asset) {
+	if result.Format == "" {
+		switch {
+		case info.Type == TypeGitHubArchive:
+			result.Format = extract.ArchiveTypeTarGz
+		case info.Type == TypeGitHubContent:
+			result.Format = extract.ArchiveTypeRaw
+		case info.Asset != "" && !hasArchiveExtension(info.Asset):
+			// Auto-detect raw binary format when asset has no archive extension
 			result.Format = extract.ArchiveTypeRaw
 		}
 	}
@@ -260,18 +312,35 @@ func (r *Resolver) ResolveWithOS(ctx context.Context, ref RegistryRef, pkg, vers
 // Supported types:
 //   - "github_release": https://github.com/{owner}/{repo}/releases/download/{version}/{asset}
 //   - "http": arbitrary URL with template variables
+//   - "github_archive": https://github.com/{owner}/{repo}/archive/refs/tags/{version}.tar.gz
+//   - "github_content": https://raw.githubusercontent.com/{owner}/{repo}/{version}/{path}
 func (r *Resolver) buildURL(info *PackageInfo, vars TemplateVars) (string, error) {
 	switch info.Type {
-	case "github_release":
+	case TypeGitHubRelease:
 		asset, err := RenderTemplate(info.Asset, vars)
 		if err != nil {
 			return "", fmt.Errorf("failed to render asset template: %w", err)
 		}
 		return githubReleaseURL(info, vars.Version, asset), nil
 
-	case "http":
+	case TypeHTTP:
 		return RenderTemplate(info.URL, vars)
 
+	case TypeGitHubArchive:
+		return fmt.Sprintf("https://github.com/%s/%s/archive/refs/tags/%s.tar.gz",
+			info.RepoOwner, info.RepoName, info.VersionPrefix+vars.Version), nil
+
+	case TypeGitHubContent:
+		if info.Path == "" {
+			return "", fmt.Errorf("github_content package has no path")
+		}
+		contentPath, err := RenderTemplate(info.Path, vars)
+		if err != nil {
+			return "", fmt.Errorf("failed to render path template: %w", err)
+		}
+		return fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s/%s",
+			info.RepoOwner, info.RepoName, info.VersionPrefix+vars.Version, strings.TrimPrefix(contentPath, "/")), nil
+
 	default:
 		return "", fmt.Errorf("unsupported package type: %s", info.Type)
 	}
@@ -289,13 +358,41 @@ func (r *Resolver) buildChecksumURL(info *PackageInfo, vars TemplateVars) (strin
 	}
 
 	switch info.Checksum.Type {
-	case "github_release", "":
+	case TypeGitHubRelease, "":
 		return githubReleaseURL(info, vars.Version, checksumAsset), nil
 	default:
 		return "", fmt.Errorf("unsupported checksum type: %s", info.Checksum.Type)
 	}
 }
 
+// buildDelegation returns how the runtime installs a go_install or cargo package.
+//
+//   - go_install: "go install {path}@{version}"; path defaults to github.com/{owner}/{repo}
+//   - cargo: "cargo install {crate}@{version}"; crates.io versions have no "v" prefix
+func buildDelegation(info *PackageInfo, vars TemplateVars) (*Delegation, error) {
+	switch info.Type {
+	case TypeGoInstall:
+		pkg := fmt.Sprintf("github.com/%s/%s", info.RepoOwner, info.RepoName)
+		if info.Path != "" {
+			rendered, err := RenderTemplate(info.Path, vars)
+			if err != nil {
+				return nil, fmt.Errorf("failed to render path template: %w", err)
+			}
+			pkg = rendered
+		}
+		return &Delegation{Runtime: GoRuntime, Package: pkg, Version: vars.SemVer}, nil
+
+	case TypeCargo:
+		if info.Crate == "" {
+			return nil, fmt.Errorf("cargo package has no crate")
+		}
+		return &Delegation{Runtime: RustRuntime, Package: info.Crate, Version: strings.TrimPrefix(vars.SemVer, "v")}, nil
+
+	default:
+		return nil, fmt.Errorf("package type %s is not installed by a runtime", info.Type)
+	}
+}
+
 // githubReleaseURL builds a GitHub release download URL.
 // It applies VersionPrefix to the tag (e.g., "kustomize/" + "v5.8.1" → "kustomize/v5.8.1").
 func githubReleaseURL(info *PackageInfo, version, asset string) string {
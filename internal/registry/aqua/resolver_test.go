@@ -699,8 +699,11 @@ func TestHasArchiveExtension(t *testing.T) {
 		{"tar.xz", "tool_{{.OS}}_{{.Arch}}.tar.xz", true},
 		{"txz", "tool_{{.OS}}_{{.Arch}}.txz", true},
 		{"pkg", "tool_{{.OS}}_{{.Arch}}.pkg", true},
-		{"tar.bz2 not recognized", "tool_{{.OS}}_{{.Arch}}.tar.bz2", false},
-		{"xz not recognized", "tool_{{.OS}}_{{.Arch}}.xz", false},
+		{"tar.bz2", "tool_{{.OS}}_{{.Arch}}.tar.bz2", true},
+		{"tar.zst", "tool_{{.OS}}_{{.Arch}}.tar.zst", true},
+		{"7z", "tool_{{.OS}}_{{.Arch}}.7z", true},
+		{"xz", "tool_{{.OS}}_{{.Arch}}.xz", true},
+		{"zst", "tool_{{.OS}}_{{.Arch}}.zst", true},
 		{"signature file", "tool.tar.gz.sig", false},
 		{"empty string", "", false},
 	}
@@ -775,3 +778,126 @@ func TestIsSupportedEnv(t *testing.T) {
 		})
 	}
 }
+
+func TestResolver_Resolve_SourcePackageTypes(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name           string
+		pkg            string
+		registryYAML   string
+		version        string
+		wantURL        string
+		wantFormat     extract.ArchiveType
+		wantDelegation *Delegation
+		wantErr        string
+	}{
+		{
+			name: "github_archive",
+			pkg:  "tfutils/tfenv",
+			registryYAML: `packages:
+  - type: github_archive
+    repo_owner: tfutils
+    repo_name: tfenv
+    files:
+      - name: tfenv
+        src: tfenv-{{trimV .Version}}/bin/tfenv
+`,
+			version:    "v3.0.0",
+			wantURL:    "https://github.com/tfutils/tfenv/archive/refs/tags/v3.0.0.tar.gz",
+			wantFormat: extract.ArchiveTypeTarGz,
+		},
+		{
+			name: "github_content",
+			pkg:  "example/script",
+			registryYAML: `packages:
+  - type: github_content
+    repo_owner: example
+    repo_name: script
+    path: bin/script-{{.OS}}
+`,
+			version:    "v1.2.0",
+			wantURL:    "https://raw.githubusercontent.com/example/script/v1.2.0/bin/script-linux",
+			wantFormat: extract.ArchiveTypeRaw,
+		},
+		{
+			name: "github_content without path",
+			pkg:  "example/script",
+			registryYAML: `packages:
+  - type: github_content
+    repo_owner: example
+    repo_name: script
+`,
+			version: "v1.2.0",
+			wantErr: "github_content package has no path",
+		},
+		{
+			name: "go_install with default path",
+			pkg:  "example/gotool",
+			registryYAML: `packages:
+  - type: go_install
+    repo_owner: example
+    repo_name: gotool
+`,
+			version:        "v0.5.0",
+			wantDelegation: &Delegation{Runtime: GoRuntime, Package: "github.com/example/gotool", Version: "v0.5.0"},
+		},
+		{
+			name: "go_install with path and version prefix",
+			pkg:  "golang/tools/gopls",
+			registryYAML: `packages:
+  - name: golang/tools/gopls
+    type: go_install
+    repo_owner: golang
+    repo_name: tools
+    path: golang.org/x/tools/gopls
+    version_prefix: gopls/
+`,
+			version:        "v0.18.1",
+			wantDelegation: &Delegation{Runtime: GoRuntime, Package: "golang.org/x/tools/gopls", Version: "v0.18.1"},
+		},
+		{
+			name: "cargo",
+			pkg:  "crates.io/xh",
+			registryYAML: `packages:
+  - name: crates.io/xh
+    type: cargo
+    crate: xh
+`,
+			version:        "v0.23.0",
+			wantDelegation: &Delegation{Runtime: RustRuntime, Package: "xh", Version: "0.23.0"},
+		},
+		{
+			name: "cargo without crate",
+			pkg:  "crates.io/xh",
+			registryYAML: `packages:
+  - name: crates.io/xh
+    type: cargo
+`,
+			version: "0.23.0",
+			wantErr: "cargo package has no crate",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cacheDir := t.TempDir()
+			ref := RegistryRef("v4.465.0")
+			cacheFile := filepath.Join(cacheDir, ref.String(), "pkgs", tt.pkg, "registry.yaml")
+			require.NoError(t, os.MkdirAll(filepath.Dir(cacheFile), 0o755))
+			require.NoError(t, os.WriteFile(cacheFile, []byte(tt.registryYAML), 0o644))
+
+			result, err := NewResolver(cacheDir, nil).ResolveWithOS(context.Background(), ref, tt.pkg, tt.version, "linux", "amd64")
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantURL, result.URL)
+			assert.Equal(t, tt.wantFormat, result.Format)
+			assert.Equal(t, tt.wantDelegation, result.Delegation)
+			assert.Empty(t, result.Errors)
+		})
+	}
+}
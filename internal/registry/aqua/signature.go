@@ -0,0 +1,191 @@
+package aqua
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/terassyi/tomei/internal/verify"
+)
+
+// buildSignatures renders the signature blocks of the package into the
+// signatures of the asset. checksumURL is the rendered checksum file URL,
+// which a checksum.cosign signature covers. Returns nil if no signature is
+// declared.
+func buildSignatures(info *PackageInfo, vars TemplateVars, checksumURL string) (*verify.ArtifactSignatures, error) {
+	sigs := &verify.ArtifactSignatures{}
+
+	if info.Cosign.GetEnabled() {
+		sig, err := buildCosignSignature(info, info.Cosign, vars)
+		if err != nil {
+			return nil, fmt.Errorf("cosign: %w", err)
+		}
+		sigs.Cosign = sig
+	}
+
+	if info.Checksum != nil && info.Checksum.Cosign.GetEnabled() && checksumURL != "" {
+		sig, err := buildCosignSignature(info, info.Checksum.Cosign, vars)
+		if err != nil {
+			return nil, fmt.Errorf("checksum.cosign: %w", err)
+		}
+		sigs.ChecksumCosign = sig
+		sigs.ChecksumURL = checksumURL
+	}
+
+	if m := info.Minisign; m.GetEnabled() {
+		if m.PublicKey == "" {
+			return nil, fmt.Errorf("minisign: public_key is not set")
+		}
+		url, err := downloadedFileURL(info, &DownloadedFile{
+			Type: m.Type, RepoOwner: m.RepoOwner, RepoName: m.RepoName, Asset: m.Asset, URL: m.URL,
+		}, vars)
+		if err != nil {
+			return nil, fmt.Errorf("minisign: %w", err)
+		}
+		sigs.Minisign = &verify.MinisignSignature{SignatureURL: url, PublicKey: m.PublicKey}
+	}
+
+	if s := info.SLSAProvenance; s.GetEnabled() {
+		url, err := downloadedFileURL(info, &DownloadedFile{
+			Type: s.Type, RepoOwner: s.RepoOwner, RepoName: s.RepoName, Asset: s.Asset, URL: s.URL,
+		}, vars)
+		if err != nil {
+			return nil, fmt.Errorf("slsa_provenance: %w", err)
+		}
+		provenance := &verify.SLSAProvenance{
+			URL:       url,
+			SourceURI: s.SourceURI,
+			SourceTag: s.SourceTag,
+		}
+		if provenance.SourceURI == "" {
+			provenance.SourceURI = fmt.Sprintf("github.com/%s/%s", info.RepoOwner, info.RepoName)
+		}
+		if provenance.SourceTag == "" {
+			provenance.SourceTag = info.VersionPrefix + vars.Version
+		}
+		sigs.SLSAProvenance = provenance
+	}
+
+	if g := info.GitHubArtifactAttestations; g.GetEnabled() {
+		sigs.GitHubAttestations = &verify.GitHubAttestations{
+			Owner:          info.RepoOwner,
+			Repo:           info.RepoName,
+			SignerWorkflow: g.SignerWorkflow,
+		}
+	}
+
+	if sigs.IsEmpty() {
+		return nil, nil
+	}
+	return sigs, nil
+}
+
+// buildCosignSignature renders the cosign options and signature files.
+// Files declared in the signature, certificate, key and bundle blocks take
+// precedence over the equivalent options.
+func buildCosignSignature(info *PackageInfo, c *Cosign, vars TemplateVars) (*verify.CosignSignature, error) {
+	opts := make([]string, 0, len(c.Opts))
+	for _, opt := range c.Opts {
+		rendered, err := RenderTemplate(opt, vars)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render opts template: %w", err)
+		}
+		opts = append(opts, rendered)
+	}
+	sig, err := parseCosignOpts(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []struct {
+		file *DownloadedFile
+		dst  *string
+	}{
+		{c.Signature, &sig.SignatureURL},
+		{c.Certificate, &sig.CertificateURL},
+		{c.Key, &sig.KeyURL},
+		{c.Bundle, &sig.BundleURL},
+	}
+	for _, f := range files {
+		if f.file == nil {
+			continue
+		}
+		url, err := downloadedFileURL(info, f.file, vars)
+		if err != nil {
+			return nil, err
+		}
+		*f.dst = url
+	}
+	return sig, nil
+}
+
+// parseCosignOpts extracts the options of "cosign verify-blob" that locate
+// signature files and the certificate identity. Both "--opt value" and
+// "--opt=value" forms are accepted; other options are ignored.
+func parseCosignOpts(opts []string) (*verify.CosignSignature, error) {
+	sig := &verify.CosignSignature{}
+	targets := map[string]*string{
+		"--signature":                      &sig.SignatureURL,
+		"--certificate":                    &sig.CertificateURL,
+		"--key":                            &sig.KeyURL,
+		"--bundle":                         &sig.BundleURL,
+		"--certificate-identity":           &sig.CertificateIdentity,
+		"--certificate-identity-regexp":    &sig.CertificateIdentityRegexp,
+		"--certificate-oidc-issuer":        &sig.CertificateOIDCIssuer,
+		"--certificate-oidc-issuer-regexp": &sig.CertificateOIDCIssuerRegexp,
+	}
+	for i := 0; i < len(opts); i++ {
+		name, value, hasValue := strings.Cut(opts[i], "=")
+		dst, ok := targets[name]
+		if !ok {
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(opts) {
+				return nil, fmt.Errorf("option %s has no value", name)
+			}
+			i++
+			value = opts[i]
+		}
+		*dst = value
+	}
+	return sig, nil
+}
+
+// downloadedFileURL renders the URL of a signature file.
+//
+//   - "github_release" (default): an asset of a release of the package
+//     repository, or of repo_owner/repo_name
+//   - "http": the url template
+func downloadedFileURL(info *PackageInfo, f *DownloadedFile, vars TemplateVars) (string, error) {
+	switch f.Type {
+	case TypeGitHubRelease, "":
+		if f.Asset == "" {
+			return "", fmt.Errorf("github_release signature file has no asset")
+		}
+		asset, err := RenderTemplate(f.Asset, vars)
+		if err != nil {
+			return "", fmt.Errorf("failed to render signature asset template: %w", err)
+		}
+		repo := *info
+		if f.RepoOwner != "" {
+			repo.RepoOwner = f.RepoOwner
+		}
+		if f.RepoName != "" {
+			repo.RepoName = f.RepoName
+		}
+		return githubReleaseURL(&repo, vars.Version, asset), nil
+
+	case TypeHTTP:
+		if f.URL == "" {
+			return "", fmt.Errorf("http signature file has no url")
+		}
+		url, err := RenderTemplate(f.URL, vars)
+		if err != nil {
+			return "", fmt.Errorf("failed to render signature url template: %w", err)
+		}
+		return url, nil
+
+	default:
+		return "", fmt.Errorf("unsupported signature file type: %s", f.Type)
+	}
+}
@@ -0,0 +1,194 @@
+package aqua
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/terassyi/tomei/internal/verify"
+)
+
+func TestResolver_Resolve_Signatures(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name         string
+		registryYAML string
+		want         *verify.ArtifactSignatures
+		wantWarning  string
+	}{
+		{
+			name: "no signature",
+			registryYAML: `packages:
+  - type: github_release
+    repo_owner: example
+    repo_name: tool
+    asset: tool_{{.OS}}_{{.Arch}}.tar.gz
+`,
+			want: nil,
+		},
+		{
+			name: "cosign bundle and checksum cosign",
+			registryYAML: `packages:
+  - type: github_release
+    repo_owner: example
+    repo_name: tool
+    asset: tool_{{.OS}}_{{.Arch}}.tar.gz
+    cosign:
+      opts:
+        - --certificate-identity-regexp
+        - '^https://github\.com/example/tool/'
+        - --certificate-oidc-issuer=https://token.actions.githubusercontent.com
+      bundle:
+        type: github_release
+        asset: '{{.Asset}}.sigstore.json'
+    checksum:
+      type: github_release
+      asset: checksums.txt
+      cosign:
+        opts:
+          - --key
+          - https://example.com/cosign.pub
+          - --signature
+          - https://github.com/example/tool/releases/download/{{.Version}}/checksums.txt.sig
+`,
+			want: &verify.ArtifactSignatures{
+				Cosign: &verify.CosignSignature{
+					BundleURL:                 "https://github.com/example/tool/releases/download/v1.2.0/tool_linux_amd64.tar.gz.sigstore.json",
+					CertificateIdentityRegexp: `^https://github\.com/example/tool/`,
+					CertificateOIDCIssuer:     "https://token.actions.githubusercontent.com",
+				},
+				ChecksumCosign: &verify.CosignSignature{
+					KeyURL:       "https://example.com/cosign.pub",
+					SignatureURL: "https://github.com/example/tool/releases/download/v1.2.0/checksums.txt.sig",
+				},
+				ChecksumURL: "https://github.com/example/tool/releases/download/v1.2.0/checksums.txt",
+			},
+		},
+		{
+			name: "minisign, SLSA provenance and attestations",
+			registryYAML: `packages:
+  - type: github_release
+    repo_owner: example
+    repo_name: tool
+    version_prefix: tool/
+    asset: tool_{{.OS}}_{{.Arch}}.tar.gz
+    minisign:
+      type: http
+      url: https://example.com/{{.Version}}/{{.Asset}}.minisig
+      public_key: RWQxxxx
+    slsa_provenance:
+      type: github_release
+      repo_owner: example
+      repo_name: provenance
+      asset: multiple.intoto.jsonl
+    github_artifact_attestations:
+      signer_workflow: example/tool/.github/workflows/release.yml
+`,
+			want: &verify.ArtifactSignatures{
+				Minisign: &verify.MinisignSignature{
+					SignatureURL: "https://example.com/v1.2.0/tool_linux_amd64.tar.gz.minisig",
+					PublicKey:    "RWQxxxx",
+				},
+				SLSAProvenance: &verify.SLSAProvenance{
+					URL:       "https://github.com/example/provenance/releases/download/tool/v1.2.0/multiple.intoto.jsonl",
+					SourceURI: "github.com/example/tool",
+					SourceTag: "tool/v1.2.0",
+				},
+				GitHubAttestations: &verify.GitHubAttestations{
+					Owner:          "example",
+					Repo:           "tool",
+					SignerWorkflow: "example/tool/.github/workflows/release.yml",
+				},
+			},
+		},
+		{
+			name: "disabled signature",
+			registryYAML: `packages:
+  - type: github_release
+    repo_owner: example
+    repo_name: tool
+    asset: tool_{{.OS}}_{{.Arch}}.tar.gz
+    cosign:
+      enabled: false
+      bundle:
+        type: github_release
+        asset: '{{.Asset}}.sigstore.json'
+`,
+			want: nil,
+		},
+		{
+			name: "version override replaces signature",
+			registryYAML: `packages:
+  - type: github_release
+    repo_owner: example
+    repo_name: tool
+    asset: tool_{{.OS}}_{{.Arch}}.tar.gz
+    version_constraint: "false"
+    version_overrides:
+      - version_constraint: semver(">= 1.0.0")
+        github_artifact_attestations: {}
+`,
+			want: &verify.ArtifactSignatures{
+				GitHubAttestations: &verify.GitHubAttestations{Owner: "example", Repo: "tool"},
+			},
+		},
+		{
+			name: "minisign without public key",
+			registryYAML: `packages:
+  - type: github_release
+    repo_owner: example
+    repo_name: tool
+    asset: tool_{{.OS}}_{{.Arch}}.tar.gz
+    minisign:
+      asset: '{{.Asset}}.minisig'
+`,
+			want:        nil,
+			wantWarning: "minisign: public_key is not set",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cacheDir := t.TempDir()
+			ref := RegistryRef("v4.465.0")
+			pkg := "example/tool"
+			cacheFile := filepath.Join(cacheDir, ref.String(), "pkgs", pkg, "registry.yaml")
+			require.NoError(t, os.MkdirAll(filepath.Dir(cacheFile), 0o755))
+			require.NoError(t, os.WriteFile(cacheFile, []byte(tt.registryYAML), 0o644))
+
+			result, err := NewResolver(cacheDir, nil).ResolveWithOS(context.Background(), ref, pkg, "v1.2.0", "linux", "amd64")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, result.Signatures)
+			if tt.wantWarning != "" {
+				require.Len(t, result.Warnings, 1)
+				assert.Contains(t, result.Warnings[0], tt.wantWarning)
+			}
+		})
+	}
+}
+
+func TestParseCosignOpts(t *testing.T) {
+	t.Parallel()
+	got, err := parseCosignOpts([]string{
+		"--certificate-identity", "https://github.com/example/tool/.github/workflows/release.yml@refs/tags/v1.0.0",
+		"--certificate-oidc-issuer-regexp=^https://token\\.actions\\.githubusercontent\\.com$",
+		"--insecure-ignore-sct",
+		"--signature", "https://example.com/tool.sig",
+		"--certificate=https://example.com/tool.pem",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &verify.CosignSignature{
+		SignatureURL:                "https://example.com/tool.sig",
+		CertificateURL:              "https://example.com/tool.pem",
+		CertificateIdentity:         "https://github.com/example/tool/.github/workflows/release.yml@refs/tags/v1.0.0",
+		CertificateOIDCIssuerRegexp: `^https://token\.actions\.githubusercontent\.com$`,
+	}, got)
+
+	_, err = parseCosignOpts([]string{"--key"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "option --key has no value")
+}
@@ -21,11 +21,12 @@ type TemplateVars struct {
 }
 
 // archiveExtensions lists known archive extensions, ordered so that compound
-// extensions (.tar.gz, .tar.xz) are checked before their single suffixes (.gz).
+// extensions (.tar.gz, .tar.xz) are checked before their single suffixes (.gz, .xz).
 // This list is for template variable derivation (AssetWithoutExt); for extraction
 // format detection, see internal/installer/extract.DetectArchiveType.
 var archiveExtensions = []string{
-	".tar.gz", ".tgz", ".tar.xz", ".txz", ".zip", ".pkg", ".gz",
+	".tar.gz", ".tgz", ".tar.xz", ".txz", ".tar.bz2", ".tbz2", ".tbz", ".tar.zst", ".tzst",
+	".zip", ".pkg", ".7z", ".gz", ".xz", ".zst", ".bz2",
 }
 
 // TrimArchiveExtension removes a known archive extension from the asset name.
@@ -255,7 +255,10 @@ func TestTrimArchiveExtension(t *testing.T) {
 		{"no extension", "yq_linux_amd64", "yq_linux_amd64"},
 		{"empty", "", ""},
 		{"non-archive suffix", "tool.tar.gz.sig", "tool.tar.gz.sig"},
-		{"tar.bz2 not recognized", "tool.tar.bz2", "tool.tar.bz2"},
+		{"tar.bz2", "tool.tar.bz2", "tool"},
+		{"tar.zst", "tool.tar.zst", "tool"},
+		{"7z", "tool.7z", "tool"},
+		{"zst", "tool.zst", "tool"},
 		{"extension only", ".tar.gz", ""},
 		{"extension only zip", ".zip", ""},
 		{"dot prefix with extension", "..tar.gz", "."},
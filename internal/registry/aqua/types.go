@@ -59,14 +59,41 @@ func (r RegistryRef) Validate() error {
 // Source: https://github.com/aquaproj/aqua/blob/main/pkg/config/registry/package_info.go
 // License: MIT (https://github.com/aquaproj/aqua/blob/main/LICENSE)
 
+// Package types supported by the resolver.
+const (
+	// TypeGitHubRelease downloads a release asset.
+	TypeGitHubRelease = "github_release"
+	// TypeHTTP downloads from an arbitrary URL template.
+	TypeHTTP = "http"
+	// TypeGitHubArchive downloads the source archive of a tag.
+	TypeGitHubArchive = "github_archive"
+	// TypeGitHubContent downloads a single file of the repository at a tag.
+	TypeGitHubContent = "github_content"
+	// TypeGoInstall builds the package with "go install".
+	TypeGoInstall = "go_install"
+	// TypeCargo builds the package with "cargo install".
+	TypeCargo = "cargo"
+)
+
+// Runtimes that go_install and cargo packages are delegated to. The names
+// match the Runtime names of the go and rust presets.
+const (
+	GoRuntime   = "go"
+	RustRuntime = "rust"
+)
+
 // PackageInfo represents a package definition from aqua registry.yaml.
 type PackageInfo struct {
+	Name              string            `yaml:"name,omitempty"`
+	Aliases           []Alias           `yaml:"aliases,omitempty"`
 	Type              string            `yaml:"type"`
 	RepoOwner         string            `yaml:"repo_owner"`
 	RepoName          string            `yaml:"repo_name"`
 	Description       string            `yaml:"description,omitempty"`
 	Asset             string            `yaml:"asset,omitempty"`
 	URL               string            `yaml:"url,omitempty"`
+	Path              string            `yaml:"path,omitempty"`  // github_content file path or go_install module path
+	Crate             string            `yaml:"crate,omitempty"` // cargo crate name
 	Format            string            `yaml:"format,omitempty"`
 	VersionPrefix     string            `yaml:"version_prefix,omitempty"`
 	VersionConstraint string            `yaml:"version_constraint,omitempty"`
@@ -76,6 +103,42 @@ type PackageInfo struct {
 	VersionOverrides  []VersionOverride `yaml:"version_overrides,omitempty"`
 	SupportedEnvs     []string          `yaml:"supported_envs,omitempty"`
 	Overrides         []Override        `yaml:"overrides,omitempty"`
+
+	// Upstream signatures of the downloaded asset
+	Cosign                     *Cosign                     `yaml:"cosign,omitempty"`
+	SLSAProvenance             *SLSAProvenance             `yaml:"slsa_provenance,omitempty"`
+	Minisign                   *Minisign                   `yaml:"minisign,omitempty"`
+	GitHubArtifactAttestations *GitHubArtifactAttestations `yaml:"github_artifact_attestations,omitempty"`
+}
+
+// PackageName returns the registry name of the package.
+// Packages without an explicit name are named "<repo_owner>/<repo_name>".
+func (p *PackageInfo) PackageName() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	if p.RepoOwner == "" || p.RepoName == "" {
+		return ""
+	}
+	return p.RepoOwner + "/" + p.RepoName
+}
+
+// DelegationRuntime returns the name of the runtime that installs the package
+// from source, or an empty string if the package is downloaded.
+func (p *PackageInfo) DelegationRuntime() string {
+	switch p.Type {
+	case TypeGoInstall:
+		return GoRuntime
+	case TypeCargo:
+		return RustRuntime
+	default:
+		return ""
+	}
+}
+
+// Alias is an alternative name of a package.
+type Alias struct {
+	Name string `yaml:"name"`
 }
 
 // FileSpec specifies a file to install from the archive.
@@ -86,10 +149,88 @@ type FileSpec struct {
 
 // ChecksumSpec specifies checksum verification settings.
 type ChecksumSpec struct {
-	Enabled   bool   `yaml:"enabled,omitempty"`
-	Type      string `yaml:"type,omitempty"`      // e.g., "github_release"
-	Asset     string `yaml:"asset,omitempty"`     // checksum file asset name template
-	Algorithm string `yaml:"algorithm,omitempty"` // e.g., "sha256"
+	Enabled   bool    `yaml:"enabled,omitempty"`
+	Type      string  `yaml:"type,omitempty"`      // e.g., "github_release"
+	Asset     string  `yaml:"asset,omitempty"`     // checksum file asset name template
+	Algorithm string  `yaml:"algorithm,omitempty"` // e.g., "sha256"
+	Cosign    *Cosign `yaml:"cosign,omitempty"`    // signature of the checksum file
+}
+
+// Cosign specifies a cosign signature. Opts are the "cosign verify-blob"
+// options (e.g., "--certificate-identity-regexp"), which may reference
+// templates. Signature, Certificate, Key and Bundle locate the files that
+// the --signature, --certificate, --key and --bundle options refer to.
+type Cosign struct {
+	Enabled     *bool           `yaml:"enabled,omitempty"`
+	Opts        []string        `yaml:"opts,omitempty"`
+	Signature   *DownloadedFile `yaml:"signature,omitempty"`
+	Certificate *DownloadedFile `yaml:"certificate,omitempty"`
+	Key         *DownloadedFile `yaml:"key,omitempty"`
+	Bundle      *DownloadedFile `yaml:"bundle,omitempty"`
+}
+
+// GetEnabled reports whether the signature is declared and not disabled.
+// A block without "enabled" is enabled.
+func (c *Cosign) GetEnabled() bool {
+	return c != nil && (c.Enabled == nil || *c.Enabled)
+}
+
+// DownloadedFile locates a signature file: a release asset (github_release,
+// by default of the package repository) or a URL (http).
+type DownloadedFile struct {
+	Type      string `yaml:"type"`
+	RepoOwner string `yaml:"repo_owner,omitempty"`
+	RepoName  string `yaml:"repo_name,omitempty"`
+	Asset     string `yaml:"asset,omitempty"`
+	URL       string `yaml:"url,omitempty"`
+}
+
+// SLSAProvenance specifies a SLSA provenance file generated by
+// slsa-github-generator. SourceURI and SourceTag default to the package
+// repository and the release tag.
+type SLSAProvenance struct {
+	Enabled   *bool  `yaml:"enabled,omitempty"`
+	Type      string `yaml:"type,omitempty"`
+	RepoOwner string `yaml:"repo_owner,omitempty"`
+	RepoName  string `yaml:"repo_name,omitempty"`
+	Asset     string `yaml:"asset,omitempty"`
+	URL       string `yaml:"url,omitempty"`
+	SourceURI string `yaml:"source_uri,omitempty"`
+	SourceTag string `yaml:"source_tag,omitempty"`
+}
+
+// GetEnabled reports whether the provenance is declared and not disabled.
+func (s *SLSAProvenance) GetEnabled() bool {
+	return s != nil && (s.Enabled == nil || *s.Enabled)
+}
+
+// Minisign specifies a minisign signature and the public key that verifies it.
+type Minisign struct {
+	Enabled   *bool  `yaml:"enabled,omitempty"`
+	Type      string `yaml:"type,omitempty"`
+	RepoOwner string `yaml:"repo_owner,omitempty"`
+	RepoName  string `yaml:"repo_name,omitempty"`
+	Asset     string `yaml:"asset,omitempty"`
+	URL       string `yaml:"url,omitempty"`
+	PublicKey string `yaml:"public_key,omitempty"`
+}
+
+// GetEnabled reports whether the signature is declared and not disabled.
+func (m *Minisign) GetEnabled() bool {
+	return m != nil && (m.Enabled == nil || *m.Enabled)
+}
+
+// GitHubArtifactAttestations specifies that GitHub stores artifact
+// attestations of the asset. SignerWorkflow restricts the workflow that
+// signed them (e.g., "owner/repo/.github/workflows/release.yml").
+type GitHubArtifactAttestations struct {
+	Enabled        *bool  `yaml:"enabled,omitempty"`
+	SignerWorkflow string `yaml:"signer_workflow,omitempty"`
+}
+
+// GetEnabled reports whether attestations are declared and not disabled.
+func (g *GitHubArtifactAttestations) GetEnabled() bool {
+	return g != nil && (g.Enabled == nil || *g.Enabled)
 }
 
 // VersionOverride specifies version-specific configuration overrides.
@@ -97,6 +238,7 @@ type VersionOverride struct {
 	VersionConstraint string            `yaml:"version_constraint"`
 	Asset             string            `yaml:"asset,omitempty"`
 	URL               string            `yaml:"url,omitempty"`
+	Path              string            `yaml:"path,omitempty"`
 	Format            string            `yaml:"format,omitempty"`
 	VersionPrefix     *string           `yaml:"version_prefix,omitempty"`
 	Checksum          *ChecksumSpec     `yaml:"checksum,omitempty"`
@@ -104,6 +246,11 @@ type VersionOverride struct {
 	Overrides         []Override        `yaml:"overrides,omitempty"`
 	SupportedEnvs     []string          `yaml:"supported_envs,omitempty"`
 	Rosetta2          bool              `yaml:"rosetta2,omitempty"`
+
+	Cosign                     *Cosign                     `yaml:"cosign,omitempty"`
+	SLSAProvenance             *SLSAProvenance             `yaml:"slsa_provenance,omitempty"`
+	Minisign                   *Minisign                   `yaml:"minisign,omitempty"`
+	GitHubArtifactAttestations *GitHubArtifactAttestations `yaml:"github_artifact_attestations,omitempty"`
 }
 
 // Override specifies OS/Arch-specific configuration overrides.
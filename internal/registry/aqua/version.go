@@ -75,6 +75,9 @@ func ApplyVersionOverrides(info *PackageInfo, version string) *PackageInfo {
 			if override.URL != "" {
 				result.URL = override.URL
 			}
+			if override.Path != "" {
+				result.Path = override.Path
+			}
 			if override.VersionPrefix != nil {
 				result.VersionPrefix = *override.VersionPrefix
 			}
@@ -93,6 +96,18 @@ func ApplyVersionOverrides(info *PackageInfo, version string) *PackageInfo {
 			if override.SupportedEnvs != nil {
 				result.SupportedEnvs = override.SupportedEnvs
 			}
+			if override.Cosign != nil {
+				result.Cosign = override.Cosign
+			}
+			if override.SLSAProvenance != nil {
+				result.SLSAProvenance = override.SLSAProvenance
+			}
+			if override.Minisign != nil {
+				result.Minisign = override.Minisign
+			}
+			if override.GitHubArtifactAttestations != nil {
+				result.GitHubArtifactAttestations = override.GitHubArtifactAttestations
+			}
 			// Only apply the first matching override
 			break
 		}
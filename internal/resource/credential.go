@@ -0,0 +1,139 @@
+package resource
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// CredentialType represents how a credential authenticates requests.
+type CredentialType string
+
+const (
+	// CredentialTypeGitHub authenticates to GitHub or GitHub Enterprise hosts
+	// with a token. Release asset downloads are resolved through the releases
+	// API so that assets of private repositories can be fetched.
+	CredentialTypeGitHub CredentialType = "github"
+
+	// CredentialTypeBearer sends the secret as "Authorization: Bearer <secret>".
+	CredentialTypeBearer CredentialType = "bearer"
+
+	// CredentialTypeBasic sends "Authorization: Basic" with Username and the secret as password.
+	CredentialTypeBasic CredentialType = "basic"
+)
+
+// DefaultGitHubHost is the host a github credential applies to when Hosts is empty.
+const DefaultGitHubHost = "github.com"
+
+// CredentialSpec defines a secret used to authenticate downloads.
+// The secret is read from exactly one of EnvVar, File, or Command when it is
+// first needed; it is never written to state or the lockfile.
+//
+// Tools, Installers, and Runtimes reference a credential by name via credentialRef.
+// Requests are only authenticated when their host is listed in Hosts.
+type CredentialSpec struct {
+	// Type specifies how requests are authenticated.
+	// Must be "github", "bearer", or "basic".
+	Type CredentialType `json:"type"`
+
+	// Hosts lists the hosts this credential is sent to (e.g., "github.example.com").
+	// For github type: defaults to ["github.com"]. GitHub Enterprise hosts use
+	// their API at https://<host>/api/v3; github.com also covers api.github.com.
+	// Required for bearer and basic types.
+	Hosts []string `json:"hosts,omitempty"`
+
+	// Username is the user name for basic type.
+	Username string `json:"username,omitempty"`
+
+	// EnvVar is the environment variable holding the secret.
+	EnvVar string `json:"envVar,omitempty"`
+
+	// File is the path of a file holding the secret. Supports "~/" expansion.
+	// Surrounding whitespace is trimmed.
+	File string `json:"file,omitempty"`
+
+	// Command is a shell command that prints the secret to stdout
+	// (e.g., "gh auth token --hostname github.example.com").
+	Command string `json:"command,omitempty"`
+}
+
+// UnmarshalJSON handles CUE's MarshalJSON quirk where single-element lists
+// are serialized as bare strings for the Hosts field.
+func (s *CredentialSpec) UnmarshalJSON(data []byte) error {
+	type Alias CredentialSpec
+	var r struct {
+		Alias
+		Hosts json.RawMessage `json:"hosts,omitempty"`
+	}
+	if err := json.Unmarshal(data, &r); err != nil {
+		return err
+	}
+	*s = CredentialSpec(r.Alias)
+	return unmarshalStringFields([]stringField{
+		{"hosts", r.Hosts, &s.Hosts},
+	})
+}
+
+// Validate validates the CredentialSpec.
+func (s *CredentialSpec) Validate() error {
+	switch s.Type {
+	case CredentialTypeGitHub, CredentialTypeBearer:
+		if s.Username != "" {
+			return fmt.Errorf("username is only supported for basic type")
+		}
+	case CredentialTypeBasic:
+		if s.Username == "" {
+			return fmt.Errorf("username is required for basic type")
+		}
+	case "":
+		return fmt.Errorf("type is required")
+	default:
+		return fmt.Errorf("type must be 'github', 'bearer', or 'basic', got %q", s.Type)
+	}
+
+	if len(s.Hosts) == 0 && s.Type != CredentialTypeGitHub {
+		return fmt.Errorf("hosts is required for %s type", s.Type)
+	}
+	for _, host := range s.Hosts {
+		if host == "" || strings.ContainsAny(host, "/ ") {
+			return fmt.Errorf("invalid host %q: must be a host name without scheme or path", host)
+		}
+	}
+
+	sources := 0
+	for _, v := range []string{s.EnvVar, s.File, s.Command} {
+		if v != "" {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return fmt.Errorf("exactly one of envVar, file, or command is required")
+	}
+	return nil
+}
+
+// Dependencies returns the resources this credential depends on.
+func (s *CredentialSpec) Dependencies() []Ref {
+	// Credentials are resolved at install time and are not part of the DAG
+	return nil
+}
+
+// CredentialHosts returns the hosts the credential applies to, with defaults applied.
+func (s *CredentialSpec) CredentialHosts() []string {
+	if len(s.Hosts) == 0 && s.Type == CredentialTypeGitHub {
+		return []string{DefaultGitHubHost}
+	}
+	return s.Hosts
+}
+
+// Credential is a concrete resource type for download credentials.
+type Credential struct {
+	BaseResource
+	CredentialSpec *CredentialSpec `json:"spec"`
+}
+
+// Kind returns the resource kind (can be called on nil).
+func (*Credential) Kind() Kind { return KindCredential }
+
+// Spec returns the spec as Spec interface.
+func (c *Credential) Spec() Spec { return c.CredentialSpec }
@@ -0,0 +1,90 @@
+package resource
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCredentialSpec_Validate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		spec    CredentialSpec
+		wantErr string
+	}{
+		{
+			name: "github with default host",
+			spec: CredentialSpec{Type: CredentialTypeGitHub, EnvVar: "CORP_GITHUB_TOKEN"},
+		},
+		{
+			name: "github enterprise with command",
+			spec: CredentialSpec{Type: CredentialTypeGitHub, Hosts: []string{"github.example.com"}, Command: "gh auth token --hostname github.example.com"},
+		},
+		{
+			name: "basic with username and file",
+			spec: CredentialSpec{Type: CredentialTypeBasic, Hosts: []string{"artifacts.example.com"}, Username: "ci", File: "~/.config/artifacts/password"},
+		},
+		{
+			name:    "missing type",
+			spec:    CredentialSpec{EnvVar: "TOKEN"},
+			wantErr: "type is required",
+		},
+		{
+			name:    "unknown type",
+			spec:    CredentialSpec{Type: "token", EnvVar: "TOKEN"},
+			wantErr: "type must be",
+		},
+		{
+			name:    "bearer without hosts",
+			spec:    CredentialSpec{Type: CredentialTypeBearer, EnvVar: "TOKEN"},
+			wantErr: "hosts is required for bearer type",
+		},
+		{
+			name:    "basic without username",
+			spec:    CredentialSpec{Type: CredentialTypeBasic, Hosts: []string{"artifacts.example.com"}, EnvVar: "PASSWORD"},
+			wantErr: "username is required for basic type",
+		},
+		{
+			name:    "username on bearer",
+			spec:    CredentialSpec{Type: CredentialTypeBearer, Hosts: []string{"artifacts.example.com"}, Username: "ci", EnvVar: "TOKEN"},
+			wantErr: "username is only supported for basic type",
+		},
+		{
+			name:    "host with scheme",
+			spec:    CredentialSpec{Type: CredentialTypeGitHub, Hosts: []string{"https://github.example.com"}, EnvVar: "TOKEN"},
+			wantErr: "invalid host",
+		},
+		{
+			name:    "no secret source",
+			spec:    CredentialSpec{Type: CredentialTypeGitHub},
+			wantErr: "exactly one of envVar, file, or command is required",
+		},
+		{
+			name:    "multiple secret sources",
+			spec:    CredentialSpec{Type: CredentialTypeGitHub, EnvVar: "TOKEN", Command: "gh auth token"},
+			wantErr: "exactly one of envVar, file, or command is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := tt.spec.Validate()
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCredentialSpec_CredentialHosts(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, []string{"github.com"}, (&CredentialSpec{Type: CredentialTypeGitHub}).CredentialHosts())
+	assert.Equal(t, []string{"github.example.com"}, (&CredentialSpec{Type: CredentialTypeGitHub, Hosts: []string{"github.example.com"}}).CredentialHosts())
+	assert.Empty(t, (&CredentialSpec{Type: CredentialTypeBearer}).CredentialHosts())
+}
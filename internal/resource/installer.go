@@ -54,6 +54,51 @@ type InstallerSpec struct {
 	// Used by `tomei env` to include the directory in PATH.
 	// Must start with "~/" (home-relative) or "/" (absolute). Only meaningful for delegation type.
 	BinDir string `json:"binDir,omitempty"`
+
+	// CredentialRef references a Credential resource used to authenticate
+	// downloads of tools installed by this installer. Only meaningful for download type.
+	CredentialRef string `json:"credentialRef,omitempty"`
+
+	// SignaturePolicy controls how upstream signatures (cosign, minisign,
+	// SLSA provenance, GitHub artifact attestations) declared by the aqua
+	// registry are enforced for tools installed by this installer.
+	// Defaults to "warn". Only meaningful for download type.
+	SignaturePolicy SignaturePolicy `json:"signaturePolicy,omitempty"`
+}
+
+// SignaturePolicy controls the verification of upstream signatures of
+// downloaded registry tools.
+type SignaturePolicy string
+
+const (
+	// SignaturePolicyRequire fails the installation if the signatures cannot
+	// be verified, including when the package declares none.
+	SignaturePolicyRequire SignaturePolicy = "require"
+
+	// SignaturePolicyWarn verifies declared signatures and warns when the
+	// verification fails. This is the default.
+	SignaturePolicyWarn SignaturePolicy = "warn"
+
+	// SignaturePolicyOff skips signature verification.
+	SignaturePolicyOff SignaturePolicy = "off"
+)
+
+// OrDefault returns the policy, or SignaturePolicyWarn if it is empty.
+func (p SignaturePolicy) OrDefault() SignaturePolicy {
+	if p == "" {
+		return SignaturePolicyWarn
+	}
+	return p
+}
+
+// Validate checks that the policy is empty or a known policy.
+func (p SignaturePolicy) Validate() error {
+	switch p {
+	case "", SignaturePolicyRequire, SignaturePolicyWarn, SignaturePolicyOff:
+		return nil
+	default:
+		return fmt.Errorf("signaturePolicy must be 'require', 'warn' or 'off', got %q", p)
+	}
 }
 
 // UnmarshalJSON handles CUE's MarshalJSON quirk where single-element lists
@@ -105,6 +150,17 @@ func (s *InstallerSpec) Validate() error {
 		}
 	}
 
+	if s.CredentialRef != "" && s.Type.IsDelegation() {
+		return fmt.Errorf("credentialRef is not supported for delegation type")
+	}
+
+	if err := s.SignaturePolicy.Validate(); err != nil {
+		return err
+	}
+	if s.SignaturePolicy != "" && s.Type.IsDelegation() {
+		return fmt.Errorf("signaturePolicy is not supported for delegation type")
+	}
+
 	// Validate dependsOn entries
 	seen := make(map[string]struct{}, len(s.DependsOn))
 	for _, dep := range s.DependsOn {
@@ -171,6 +227,12 @@ type BootstrapSpec = CommandSet
 //   - Install: "go install {{.Package}}@{{.Version}}"
 //   - Check: "go version -m {{.BinPath}}"
 //   - Remove: "rm {{.BinPath}}"
+//
+// BatchInstall installs all pending tools of the runtime or installer in one
+// invocation. {{.Packages}} expands to the space-separated "<package>@<version>"
+// of each tool, and can be ranged over for other formats
+// ({{range .Packages}}{{.Package}}=={{.Version}} {{end}}):
+//   - BatchInstall: "pnpm add -g {{.Packages}}"
 type CommandsSpec = CommandSet
 
 // InstallerState represents the persisted state of an installer.
@@ -186,6 +248,11 @@ type InstallerState struct {
 	// Used by `tomei env` to include the directory in PATH.
 	BinDir string `json:"binDir,omitempty"`
 
+	// Bootstrap holds the bootstrap commands the installer was installed with.
+	// Check is used for drift detection and Remove for uninstallation after the
+	// Installer is dropped from the manifest.
+	Bootstrap *BootstrapSpec `json:"bootstrap,omitempty"`
+
 	// UpdatedAt is the timestamp when this installer was last configured.
 	UpdatedAt time.Time `json:"updatedAt"`
 }
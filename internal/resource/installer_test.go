@@ -174,6 +174,33 @@ func TestInstallerSpec_Validate(t *testing.T) {
 			},
 			wantErr: "binDir is not supported for download type",
 		},
+		{
+			name: "signaturePolicy require",
+			spec: InstallerSpec{
+				Type:            InstallTypeDownload,
+				SignaturePolicy: SignaturePolicyRequire,
+			},
+			wantErr: "",
+		},
+		{
+			name: "unknown signaturePolicy",
+			spec: InstallerSpec{
+				Type:            InstallTypeDownload,
+				SignaturePolicy: "strict",
+			},
+			wantErr: "signaturePolicy must be 'require', 'warn' or 'off'",
+		},
+		{
+			name: "signaturePolicy on delegation type rejected",
+			spec: InstallerSpec{
+				Type:            InstallTypeDelegation,
+				SignaturePolicy: SignaturePolicyOff,
+				Commands: &CommandsSpec{
+					Install: []string{"some command"},
+				},
+			},
+			wantErr: "signaturePolicy is not supported for delegation type",
+		},
 	}
 
 	for _, tt := range tests {
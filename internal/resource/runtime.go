@@ -3,6 +3,8 @@ package resource
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
+	"slices"
 	"time"
 
 	"github.com/terassyi/tomei/internal/checksum"
@@ -27,6 +29,13 @@ type RuntimeSpec struct {
 	// When using an alias, Bootstrap.ResolveVersion is used to resolve the actual version.
 	Version string `json:"version"`
 
+	// ExtraVersions lists additional exact versions installed side by side
+	// with Version (download pattern only). Extra versions are installed under
+	// their own install path without symlinks in BinDir, and are selected per
+	// project with a .tomei-version file read by "tomei env".
+	// Example: ["1.23.8", "1.24.3"]
+	ExtraVersions []string `json:"extraVersions,omitempty"`
+
 	// Source configures where to download the runtime from.
 	// Required for download pattern. Not used for delegation pattern.
 	Source *DownloadSource `json:"source,omitempty"`
@@ -79,6 +88,10 @@ type RuntimeSpec struct {
 	// Example: ["github-release:oven-sh/bun:bun-v"]
 	// Example: ["curl -sL https://go.dev/VERSION?m=text | head -1 | sed 's/^go//'"]
 	ResolveVersion []string `json:"resolveVersion,omitempty"`
+
+	// CredentialRef references a Credential resource used to authenticate
+	// the runtime download and version resolution. Only meaningful for download type.
+	CredentialRef string `json:"credentialRef,omitempty"`
 }
 
 // UnmarshalJSON handles CUE's MarshalJSON quirk where single-element lists
@@ -88,6 +101,7 @@ func (s *RuntimeSpec) UnmarshalJSON(data []byte) error {
 	var r struct {
 		Alias
 		Binaries       json.RawMessage `json:"binaries,omitempty"`
+		ExtraVersions  json.RawMessage `json:"extraVersions,omitempty"`
 		ResolveVersion json.RawMessage `json:"resolveVersion,omitempty"`
 	}
 	if err := json.Unmarshal(data, &r); err != nil {
@@ -96,6 +110,7 @@ func (s *RuntimeSpec) UnmarshalJSON(data []byte) error {
 	*s = RuntimeSpec(r.Alias)
 	return unmarshalStringFields([]stringField{
 		{"binaries", r.Binaries, &s.Binaries},
+		{"extraVersions", r.ExtraVersions, &s.ExtraVersions},
 		{"resolveVersion", r.ResolveVersion, &s.ResolveVersion},
 	})
 }
@@ -157,6 +172,19 @@ func (s *RuntimeSpec) Validate() error {
 		if s.Source == nil || s.Source.URL == "" {
 			return fmt.Errorf("source.url is required for download type")
 		}
+		if len(s.ExtraVersions) > 0 && s.Source.Checksum != nil && s.Source.Checksum.Value != "" {
+			return fmt.Errorf("extraVersions requires source.checksum.url: checksum.value only matches one version")
+		}
+		seen := make(map[string]bool, len(s.ExtraVersions))
+		for _, v := range s.ExtraVersions {
+			if !IsExactVersion(v) {
+				return fmt.Errorf("extraVersions must be exact versions: %q", v)
+			}
+			if v == s.Version || seen[v] {
+				return fmt.Errorf("extraVersions contains duplicate version %q", v)
+			}
+			seen[v] = true
+		}
 	}
 	if s.Type.IsDelegation() {
 		if s.Bootstrap == nil {
@@ -168,6 +196,12 @@ func (s *RuntimeSpec) Validate() error {
 		if len(s.Bootstrap.Check) == 0 {
 			return fmt.Errorf("bootstrap.check is required for delegation type")
 		}
+		if s.CredentialRef != "" {
+			return fmt.Errorf("credentialRef is not supported for delegation type")
+		}
+		if len(s.ExtraVersions) > 0 {
+			return fmt.Errorf("extraVersions is not supported for delegation type")
+		}
 	}
 
 	return nil
@@ -215,6 +249,19 @@ type RuntimeState struct {
 	// Used to verify integrity and detect corruption.
 	Digest checksum.Digest `json:"digest,omitempty"`
 
+	// TreeDigest is the SHA256 hash of the manifest of the installed tree
+	// (checksum.TreeManifestFile), recorded at install time to detect files
+	// modified after installation (download pattern only).
+	TreeDigest checksum.Digest `json:"treeDigest,omitempty"`
+
+	// SourceURL is the download URL with {{.Version}} expanded (download pattern only).
+	// Recorded in tomei.lock alongside the resolved version and digest.
+	SourceURL string `json:"sourceUrl,omitempty"`
+
+	// Mirror is the download mirror (a url of the mirrors in config.cue) that
+	// served the archive. Empty when it was downloaded from SourceURL.
+	Mirror string `json:"mirror,omitempty"`
+
 	// InstallPath is the absolute path where the runtime is installed.
 	// For download pattern: ~/.local/share/tomei/runtimes/go/1.25.1
 	// For delegation pattern: may be empty (managed by external tool)
@@ -240,6 +287,10 @@ type RuntimeState struct {
 	// Used when executing tools that depend on this runtime.
 	Env map[string]string `json:"env,omitempty"`
 
+	// Extras records the extra versions installed side by side with Version,
+	// keyed by version (download pattern only).
+	Extras map[string]*RuntimeVersionState `json:"extras,omitempty"`
+
 	// RemoveCommand is the shell command(s) to uninstall a delegation-pattern runtime.
 	// Stored in state because Remove() only receives state (no spec).
 	RemoveCommand []string `json:"removeCommand,omitempty"`
@@ -258,6 +309,89 @@ type RuntimeState struct {
 
 func (*RuntimeState) isState() {}
 
+// RuntimeVersionState represents an extra version of a runtime installed
+// side by side with its default version.
+type RuntimeVersionState struct {
+	// Digest is the SHA256 hash of the downloaded archive.
+	Digest checksum.Digest `json:"digest,omitempty"`
+
+	// TreeDigest is the SHA256 hash of the manifest of the installed tree.
+	TreeDigest checksum.Digest `json:"treeDigest,omitempty"`
+
+	// SourceURL is the download URL with {{.Version}} expanded.
+	SourceURL string `json:"sourceUrl,omitempty"`
+
+	// Mirror is the download mirror that served the archive, if any.
+	Mirror string `json:"mirror,omitempty"`
+
+	// InstallPath is the absolute path where this version is installed.
+	InstallPath string `json:"installPath"`
+
+	// BinDir is the directory containing this version's binaries.
+	// Unlike RuntimeState.BinDir, no symlinks are created for extra versions.
+	BinDir string `json:"binDir,omitempty"`
+
+	// Env records the environment variables expanded for this version.
+	Env map[string]string `json:"env,omitempty"`
+
+	// UpdatedAt is the timestamp when this version was installed.
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// ExtraVersions returns the installed extra versions, sorted.
+// Nil-safe: returns nil if receiver is nil.
+func (s *RuntimeState) ExtraVersions() []string {
+	if s == nil || len(s.Extras) == 0 {
+		return nil
+	}
+	return slices.Sorted(maps.Keys(s.Extras))
+}
+
+// GetExtras returns the recorded extra versions.
+// Nil-safe: returns nil if receiver is nil.
+func (s *RuntimeState) GetExtras() map[string]*RuntimeVersionState {
+	if s == nil {
+		return nil
+	}
+	return s.Extras
+}
+
+// ForVersion returns the state of an installed version of the runtime: the
+// runtime itself for its default version, or a copy describing an extra
+// version, with BinDir pointing at the directory of its binaries. It reports
+// false when the version is not installed.
+func (s *RuntimeState) ForVersion(version string) (*RuntimeState, bool) {
+	if version == s.Version {
+		return s, true
+	}
+	ev, ok := s.Extras[version]
+	if !ok {
+		return nil, false
+	}
+	rs := *s
+	rs.Version = version
+	rs.VersionKind = VersionExact
+	rs.SpecVersion = version
+	rs.Digest = ev.Digest
+	rs.TreeDigest = ev.TreeDigest
+	rs.SourceURL = ev.SourceURL
+	rs.InstallPath = ev.InstallPath
+	rs.BinDir = ev.BinDir
+	rs.Env = ev.Env
+	rs.Extras = nil
+	rs.UpdatedAt = ev.UpdatedAt
+	return &rs, true
+}
+
+// GetContentDigest returns the install path and the recorded digest of its tree manifest.
+// Nil-safe: returns empty strings if receiver is nil.
+func (s *RuntimeState) GetContentDigest() (string, checksum.Digest) {
+	if s == nil {
+		return "", ""
+	}
+	return s.InstallPath, s.TreeDigest
+}
+
 // IsTainted returns true if the runtime needs reinstallation.
 func (s *RuntimeState) IsTainted() bool {
 	return s.TaintReason != ""
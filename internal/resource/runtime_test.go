@@ -207,6 +207,63 @@ func TestRuntimeSpec_Validate(t *testing.T) {
 			},
 			wantErr: "toolBinPath is required when commands is defined",
 		},
+		{
+			name: "valid download with extra versions",
+			spec: RuntimeSpec{
+				Type:          InstallTypeDownload,
+				Version:       "1.25.6",
+				ExtraVersions: []string{"1.23.8", "1.24.3"},
+				Source:        &DownloadSource{URL: "https://go.dev/dl/go{{.Version}}.tar.gz"},
+			},
+		},
+		{
+			name: "extra version is not exact",
+			spec: RuntimeSpec{
+				Type:          InstallTypeDownload,
+				Version:       "1.25.6",
+				ExtraVersions: []string{"latest"},
+				Source:        &DownloadSource{URL: "https://go.dev/dl/go{{.Version}}.tar.gz"},
+			},
+			wantErr: `extraVersions must be exact versions: "latest"`,
+		},
+		{
+			name: "extra version duplicates the default version",
+			spec: RuntimeSpec{
+				Type:          InstallTypeDownload,
+				Version:       "1.25.6",
+				ExtraVersions: []string{"1.25.6"},
+				Source:        &DownloadSource{URL: "https://go.dev/dl/go{{.Version}}.tar.gz"},
+			},
+			wantErr: `extraVersions contains duplicate version "1.25.6"`,
+		},
+		{
+			name: "extra versions with a fixed checksum value",
+			spec: RuntimeSpec{
+				Type:          InstallTypeDownload,
+				Version:       "1.25.6",
+				ExtraVersions: []string{"1.24.3"},
+				Source: &DownloadSource{
+					URL:      "https://go.dev/dl/go{{.Version}}.tar.gz",
+					Checksum: &Checksum{Value: "sha256:abc"},
+				},
+			},
+			wantErr: "extraVersions requires source.checksum.url",
+		},
+		{
+			name: "delegation with extra versions",
+			spec: RuntimeSpec{
+				Type:          InstallTypeDelegation,
+				Version:       "stable",
+				ExtraVersions: []string{"1.83.0"},
+				Bootstrap: &RuntimeBootstrapSpec{
+					CommandSet: CommandSet{
+						Install: []string{"curl -sSf https://sh.rustup.rs | sh"},
+						Check:   []string{"rustc --version"},
+					},
+				},
+			},
+			wantErr: "extraVersions is not supported for delegation type",
+		},
 	}
 
 	for _, tt := range tests {
@@ -287,6 +344,46 @@ func TestRuntimeState_Taint(t *testing.T) {
 	}
 }
 
+func TestRuntimeState_ForVersion(t *testing.T) {
+	t.Parallel()
+	st := &RuntimeState{
+		Version:     "1.25.6",
+		VersionKind: VersionAlias,
+		SpecVersion: "stable",
+		InstallPath: "/data/runtimes/go/1.25.6",
+		BinDir:      "/home/user/go/bin",
+		ToolBinPath: "/home/user/go/bin",
+		Env:         map[string]string{"GOROOT": "/data/runtimes/go/1.25.6"},
+		Extras: map[string]*RuntimeVersionState{
+			"1.24.3": {
+				InstallPath: "/data/runtimes/go/1.24.3",
+				BinDir:      "/data/runtimes/go/1.24.3/bin",
+				Env:         map[string]string{"GOROOT": "/data/runtimes/go/1.24.3"},
+			},
+		},
+	}
+
+	got, ok := st.ForVersion("1.25.6")
+	require.True(t, ok)
+	assert.Same(t, st, got)
+
+	got, ok = st.ForVersion("1.24.3")
+	require.True(t, ok)
+	assert.Equal(t, "1.24.3", got.Version)
+	assert.Equal(t, VersionExact, got.VersionKind)
+	assert.Equal(t, "/data/runtimes/go/1.24.3", got.InstallPath)
+	assert.Equal(t, "/data/runtimes/go/1.24.3/bin", got.BinDir)
+	assert.Equal(t, "/home/user/go/bin", got.ToolBinPath)
+	assert.Equal(t, map[string]string{"GOROOT": "/data/runtimes/go/1.24.3"}, got.Env)
+	assert.Nil(t, got.Extras)
+	assert.Equal(t, "/data/runtimes/go/1.25.6", st.InstallPath, "the runtime state is not modified")
+
+	_, ok = st.ForVersion("1.23.8")
+	assert.False(t, ok)
+
+	assert.Equal(t, []string{"1.24.3"}, st.ExtraVersions())
+}
+
 func TestRuntimeBootstrapSpec_UnmarshalJSON(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
@@ -2,6 +2,7 @@ package resource
 
 import (
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -12,11 +13,24 @@ type SystemInstallerSpec struct {
 	Commands   SystemInstallerCommandsSpec `json:"commands"`
 }
 
+// SystemInstallerPatternDelegation indicates that the system installer runs
+// its configured commands (e.g., "apt-get install -y <packages>").
+const SystemInstallerPatternDelegation = "delegation"
+
 // Validate validates the SystemInstallerSpec.
 func (s *SystemInstallerSpec) Validate() error {
 	if s.Pattern == "" {
 		return fmt.Errorf("pattern is required")
 	}
+	if s.Pattern != SystemInstallerPatternDelegation {
+		return fmt.Errorf("pattern must be %q, got %q", SystemInstallerPatternDelegation, s.Pattern)
+	}
+	if s.Commands.Install.Command == "" {
+		return fmt.Errorf("commands.install.command is required")
+	}
+	if s.Commands.Remove.Command == "" {
+		return fmt.Errorf("commands.remove.command is required")
+	}
 	return nil
 }
 
@@ -39,6 +53,8 @@ func (*SystemInstaller) Kind() Kind { return KindSystemInstaller }
 func (s *SystemInstaller) Spec() Spec { return s.SystemInstallerSpec }
 
 // SystemInstallerCommandsSpec defines commands for a system installer.
+// Each command is invoked as "<command> <verb> <packages...>".
+// Check is invoked once per package and must exit 0 if the package is installed.
 type SystemInstallerCommandsSpec struct {
 	Install CommandSpec `json:"install"`
 	Remove  CommandSpec `json:"remove"`
@@ -52,9 +68,40 @@ type CommandSpec struct {
 	Verb    string `json:"verb"`
 }
 
+// IsZero returns true if no command is configured.
+func (c CommandSpec) IsZero() bool {
+	return c.Command == ""
+}
+
+// Args returns the command line for the given packages as
+// "<command> <verb...> <packages...>". The verb may contain multiple
+// space-separated words (e.g., "install -y").
+func (c CommandSpec) Args(packages ...string) []string {
+	args := []string{c.Command}
+	args = append(args, strings.Fields(c.Verb)...)
+	return append(args, packages...)
+}
+
+// Spec rebuilds the SystemInstallerSpec recorded in this state.
+func (s *SystemInstallerState) Spec() *SystemInstallerSpec {
+	return &SystemInstallerSpec{
+		Pattern:    s.Pattern,
+		Privileged: s.Privileged,
+		Commands:   s.Commands,
+	}
+}
+
 // SystemInstallerState represents the state of a system installer.
 type SystemInstallerState struct {
-	Version   string    `json:"version"`
+	Version string `json:"version"`
+
+	// Pattern, Privileged and Commands record the spec this installer was applied with.
+	// Stored in state because package sets and repositories that reference this
+	// installer may need to be removed after the installer left the manifest.
+	Pattern    string                      `json:"pattern,omitempty"`
+	Privileged bool                        `json:"privileged,omitempty"`
+	Commands   SystemInstallerCommandsSpec `json:"commands"`
+
 	UpdatedAt time.Time `json:"updatedAt"`
 }
 
@@ -3,6 +3,8 @@ package resource
 import (
 	"fmt"
 	"time"
+
+	"github.com/terassyi/tomei/internal/checksum"
 )
 
 // SystemPackageRepositorySpec defines a third-party repository.
@@ -19,6 +21,14 @@ func (s *SystemPackageRepositorySpec) Validate() error {
 	if s.Source.URL == "" {
 		return fmt.Errorf("source.url is required")
 	}
+	if s.Source.KeyHash != "" {
+		if s.Source.KeyURL == "" {
+			return fmt.Errorf("source.keyHash requires source.keyUrl")
+		}
+		if _, _, err := checksum.Parse(s.Source.KeyHash); err != nil {
+			return fmt.Errorf("invalid source.keyHash: %w", err)
+		}
+	}
 	return nil
 }
 
@@ -102,11 +112,23 @@ func (*SystemPackageRepositoryState) isState() {}
 
 // SystemPackageSetState represents the state of installed system packages.
 type SystemPackageSetState struct {
-	InstallerRef      string            `json:"installerRef"`
-	RepositoryRef     string            `json:"repositoryRef,omitempty"`
-	Packages          []string          `json:"packages"`
+	InstallerRef  string   `json:"installerRef"`
+	RepositoryRef string   `json:"repositoryRef,omitempty"`
+	Packages      []string `json:"packages"`
+	// Owned lists the packages of the set that tomei installed. Packages that
+	// were already installed are left in place on removal.
+	Owned             []string          `json:"owned,omitempty"`
 	InstalledVersions map[string]string `json:"installedVersions"`
 	UpdatedAt         time.Time         `json:"updatedAt"`
 }
 
 func (*SystemPackageSetState) isState() {}
+
+// GetOwned returns the packages that tomei installed.
+// Nil-safe: returns nil if receiver is nil.
+func (s *SystemPackageSetState) GetOwned() []string {
+	if s == nil {
+		return nil
+	}
+	return s.Owned
+}
@@ -23,7 +23,7 @@ type DownloadSource struct {
 
 	// ArchiveType specifies the archive format explicitly.
 	// If empty, the type is auto-detected from the URL extension.
-	// See extract.ArchiveTypeTarGz, extract.ArchiveTypeZip, extract.ArchiveTypeRaw.
+	// See extract.ArchiveTypeTarGz, extract.ArchiveTypeZip, extract.ArchiveTypeRaw, etc.
 	ArchiveType extract.ArchiveType `json:"archiveType,omitempty"`
 }
 
@@ -263,6 +263,11 @@ type ToolSpec struct {
 	// These are joined with spaces and available as {{.Args}} in command templates.
 	// Example: ["--with-executables-from", "ansible-core"] for uv tool install.
 	Args []string `json:"args,omitempty"`
+
+	// CredentialRef references a Credential resource used to authenticate
+	// downloads (e.g., release assets of a private repository).
+	// When empty, the credentialRef of the referenced Installer is used.
+	CredentialRef string `json:"credentialRef,omitempty"`
 }
 
 // UnmarshalJSON handles CUE's MarshalJSON quirk where single-element lists
@@ -393,6 +398,9 @@ type ToolSetSpec struct {
 	// Either InstallerRef or RuntimeRef must be specified (mutually exclusive).
 	RuntimeRef string `json:"runtimeRef,omitempty"`
 
+	// CredentialRef references a Credential resource for all tools in this set.
+	CredentialRef string `json:"credentialRef,omitempty"`
+
 	// Tools maps tool names to their individual configurations.
 	// The key becomes the tool name (and typically the binary name).
 	// Each tool can override version and source settings.
@@ -466,6 +474,7 @@ func buildToolFromSetItem(ts *ToolSet, name string, item ToolItem) *Tool {
 			InstallerRef:  ts.ToolSetSpec.InstallerRef,
 			RepositoryRef: ts.ToolSetSpec.RepositoryRef,
 			RuntimeRef:    ts.ToolSetSpec.RuntimeRef,
+			CredentialRef: ts.ToolSetSpec.CredentialRef,
 			Version:       item.Version,
 			Source:        item.Source,
 			Package:       item.Package,
@@ -542,10 +551,14 @@ type ToolState struct {
 	// Version is the installed version of the tool.
 	Version string `json:"version"`
 
-	// Digest is the SHA256 hash of the installed binary (for download pattern).
-	// Used to verify integrity and detect if the binary was modified.
+	// Digest is the SHA256 hash of the downloaded artifact (for download pattern).
+	// Recorded in tomei.lock and used to verify re-downloads.
 	Digest checksum.Digest `json:"digest,omitempty"`
 
+	// BinaryDigest is the SHA256 hash of the installed binary, recorded at
+	// install time to detect binaries modified after installation.
+	BinaryDigest checksum.Digest `json:"binaryDigest,omitempty"`
+
 	// InstallPath is the absolute path to the installed binary.
 	// For download pattern: ~/.local/share/tomei/tools/{name}/{version}/{binary}
 	// For delegation pattern: depends on the installer (e.g., ~/go/bin/{name})
@@ -560,6 +573,15 @@ type ToolState struct {
 	// Stored for reference and potential re-download if needed.
 	Source *DownloadSource `json:"source,omitempty"`
 
+	// Mirror is the download mirror (a url of the mirrors in config.cue) that
+	// served the archive. Empty when it was downloaded from Source.URL.
+	Mirror string `json:"mirror,omitempty"`
+
+	// Signature records the outcome of verifying the upstream signatures of
+	// the downloaded archive. Nil when verification did not run (e.g.,
+	// signature policy "off" or a tool not installed from a registry).
+	Signature *SignatureState `json:"signature,omitempty"`
+
 	// Package records the package identifier used for installation.
 	// For registry-based: { owner: "cli", repo: "cli" }
 	// For delegation-based: { name: "golang.org/x/tools/gopls" }
@@ -599,6 +621,33 @@ type ToolState struct {
 
 func (*ToolState) isState() {}
 
+// SignatureOutcome is the outcome of verifying the upstream signatures of a tool.
+type SignatureOutcome string
+
+const (
+	// SignatureVerified indicates that a declared signature was verified.
+	SignatureVerified SignatureOutcome = "verified"
+
+	// SignatureUnverified indicates that the declared signatures could not
+	// be verified and the installation proceeded under the "warn" policy.
+	SignatureUnverified SignatureOutcome = "unverified"
+
+	// SignatureUnsigned indicates that the package declares no signature.
+	SignatureUnsigned SignatureOutcome = "unsigned"
+)
+
+// SignatureState records how the upstream signatures of a tool were verified.
+type SignatureState struct {
+	// Outcome is the verification outcome.
+	Outcome SignatureOutcome `json:"outcome"`
+
+	// Methods lists the verified methods (e.g., "cosign", "slsa_provenance").
+	Methods []string `json:"methods,omitempty"`
+
+	// Reason explains why the signatures are unverified.
+	Reason string `json:"reason,omitempty"`
+}
+
 // GetBinPath returns the symlink path for this tool.
 // Nil-safe: returns empty string if receiver is nil.
 func (t *ToolState) GetBinPath() string {
@@ -608,6 +657,31 @@ func (t *ToolState) GetBinPath() string {
 	return t.BinPath
 }
 
+// GetContentDigest returns the path of the installed binary and its recorded digest.
+// Nil-safe: returns empty strings if receiver is nil.
+func (t *ToolState) GetContentDigest() (string, checksum.Digest) {
+	if t == nil {
+		return "", ""
+	}
+	if t.InstallPath != "" {
+		return t.InstallPath, t.BinaryDigest
+	}
+	return t.BinPath, t.BinaryDigest
+}
+
+// GetSignature returns the path of the installed binary and the outcome of
+// verifying its upstream signatures.
+// Nil-safe: returns an empty path if receiver is nil.
+func (t *ToolState) GetSignature() (string, *SignatureState) {
+	if t == nil {
+		return "", nil
+	}
+	if t.InstallPath != "" {
+		return t.InstallPath, t.Signature
+	}
+	return t.BinPath, t.Signature
+}
+
 // IsTainted returns true if the tool needs reinstallation.
 func (t *ToolState) IsTainted() bool {
 	return t.TaintReason != ""
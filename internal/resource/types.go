@@ -21,6 +21,7 @@ const (
 	KindRuntime             Kind = "Runtime"
 	KindTool                Kind = "Tool"
 	KindToolSet             Kind = "ToolSet"
+	KindCredential          Kind = "Credential"
 )
 
 const (
@@ -50,7 +51,7 @@ func init() {
 	kinds := []Kind{
 		KindSystemInstaller, KindSystemPackageRepository, KindSystemPackageSet,
 		KindInstaller, KindInstallerRepository, KindRuntime,
-		KindTool, KindToolSet,
+		KindTool, KindToolSet, KindCredential,
 	}
 	knownKinds = make(map[string]Kind, len(kinds))
 	for _, k := range kinds {
@@ -243,6 +244,15 @@ const (
 
 	// TaintReasonUpdateRequested indicates the user requested an update via --update-tools/--update-runtimes.
 	TaintReasonUpdateRequested TaintReason = "update_requested"
+
+	// TaintReasonLockChanged indicates the version pinned in tomei.lock differs from the installed one.
+	TaintReasonLockChanged TaintReason = "lock_changed"
+
+	// TaintReasonRepair indicates "tomei doctor --fix" found the installed files broken.
+	TaintReasonRepair TaintReason = "repair"
+
+	// TaintReasonDrifted indicates "tomei verify --taint" found installed files modified after installation.
+	TaintReasonDrifted TaintReason = "drifted"
 )
 
 // CommandSet defines a set of shell commands for install/check/remove operations.
@@ -259,6 +269,11 @@ type CommandSet struct {
 
 	// Remove is the shell command(s) to uninstall/cleanup.
 	Remove []string `json:"remove,omitempty"`
+
+	// BatchInstall is the shell command(s) to install several tools in one
+	// invocation. Only used by the delegation commands of Runtimes and
+	// Installers (CommandsSpec), with the {{.Packages}} template variable.
+	BatchInstall []string `json:"batchInstall,omitempty"`
 }
 
 // stringField describes a single []string field to be unmarshaled with
@@ -308,9 +323,10 @@ func unmarshalStringFields(fields []stringField) error {
 // are serialized as bare strings (e.g., ["cmd"] becomes "cmd").
 func (c *CommandSet) UnmarshalJSON(data []byte) error {
 	var r struct {
-		Install json.RawMessage `json:"install"`
-		Check   json.RawMessage `json:"check,omitempty"`
-		Remove  json.RawMessage `json:"remove,omitempty"`
+		Install      json.RawMessage `json:"install"`
+		Check        json.RawMessage `json:"check,omitempty"`
+		Remove       json.RawMessage `json:"remove,omitempty"`
+		BatchInstall json.RawMessage `json:"batchInstall,omitempty"`
 	}
 	if err := json.Unmarshal(data, &r); err != nil {
 		return err
@@ -319,6 +335,7 @@ func (c *CommandSet) UnmarshalJSON(data []byte) error {
 		{"install", r.Install, &c.Install},
 		{"check", r.Check, &c.Check},
 		{"remove", r.Remove, &c.Remove},
+		{"batchInstall", r.BatchInstall, &c.BatchInstall},
 	})
 }
 
@@ -0,0 +1,170 @@
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	historyDirName = "history"
+
+	// HistoryLimit is the number of state generations kept in the history directory.
+	HistoryLimit = 10
+
+	// generationTimeFormat is the timestamp layout used in generation file names.
+	generationTimeFormat = "20060102T150405Z"
+)
+
+// Generation is a snapshot of the state file stored in the history directory.
+type Generation struct {
+	Number    int       `json:"number"`
+	CreatedAt time.Time `json:"createdAt"`
+	Path      string    `json:"path"`
+}
+
+// HistoryDir returns the history directory for the given state file path.
+func HistoryDir(statePath string) string {
+	return filepath.Join(filepath.Dir(statePath), historyDirName)
+}
+
+// RecordGeneration copies the current state file into the history directory
+// as a new generation, unless it is identical to the latest generation.
+// Generations beyond limit are pruned, oldest first.
+// Returns nil if nothing was recorded. Must be called while holding the store lock.
+func RecordGeneration[T State](s *Store[T], limit int) (*Generation, error) {
+	data, err := os.ReadFile(s.statePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil // nothing to record
+		}
+		return nil, fmt.Errorf("failed to read state for history: %w", err)
+	}
+
+	gens, err := ListGenerations(s.statePath)
+	if err != nil {
+		return nil, err
+	}
+
+	number := 1
+	if len(gens) > 0 {
+		latest := gens[len(gens)-1]
+		prev, err := os.ReadFile(latest.Path)
+		if err == nil && bytes.Equal(prev, data) {
+			return nil, nil
+		}
+		number = latest.Number + 1
+	}
+
+	dir := HistoryDir(s.statePath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	gen := &Generation{Number: number, CreatedAt: time.Now().UTC().Truncate(time.Second)}
+	gen.Path = filepath.Join(dir, generationFileName(gen.Number, gen.CreatedAt))
+	tmpPath := gen.Path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write generation: %w", err)
+	}
+	if err := os.Rename(tmpPath, gen.Path); err != nil {
+		os.Remove(tmpPath)
+		return nil, fmt.Errorf("failed to rename generation: %w", err)
+	}
+
+	gens = append(gens, *gen)
+	if limit > 0 && len(gens) > limit {
+		for _, old := range gens[:len(gens)-limit] {
+			if err := os.Remove(old.Path); err != nil && !os.IsNotExist(err) {
+				return gen, fmt.Errorf("failed to prune generation %d: %w", old.Number, err)
+			}
+		}
+	}
+
+	return gen, nil
+}
+
+// ListGenerations returns the generations in the history directory, oldest first.
+// Returns an empty list if the history directory doesn't exist.
+func ListGenerations(statePath string) ([]Generation, error) {
+	dir := HistoryDir(statePath)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read history directory: %w", err)
+	}
+
+	var gens []Generation
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		number, createdAt, ok := parseGenerationFileName(entry.Name())
+		if !ok {
+			continue
+		}
+		gens = append(gens, Generation{
+			Number:    number,
+			CreatedAt: createdAt,
+			Path:      filepath.Join(dir, entry.Name()),
+		})
+	}
+	slices.SortFunc(gens, func(a, b Generation) int { return a.Number - b.Number })
+	return gens, nil
+}
+
+// LoadGeneration reads the state snapshot of the given generation number.
+func LoadGeneration[T State](statePath string, number int) (*T, error) {
+	gens, err := ListGenerations(statePath)
+	if err != nil {
+		return nil, err
+	}
+	idx := slices.IndexFunc(gens, func(g Generation) bool { return g.Number == number })
+	if idx < 0 {
+		return nil, fmt.Errorf("generation %d not found in %s", number, HistoryDir(statePath))
+	}
+
+	data, err := os.ReadFile(gens[idx].Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read generation %d: %w", number, err)
+	}
+	var st T
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("failed to parse generation %d: %w", number, err)
+	}
+	return &st, nil
+}
+
+// generationFileName returns the file name for a generation, e.g. "000012-20260102T150405Z.json".
+func generationFileName(number int, createdAt time.Time) string {
+	return fmt.Sprintf("%06d-%s.json", number, createdAt.UTC().Format(generationTimeFormat))
+}
+
+// parseGenerationFileName parses a file name produced by generationFileName.
+func parseGenerationFileName(name string) (int, time.Time, bool) {
+	base, ok := strings.CutSuffix(name, ".json")
+	if !ok {
+		return 0, time.Time{}, false
+	}
+	numStr, tsStr, ok := strings.Cut(base, "-")
+	if !ok {
+		return 0, time.Time{}, false
+	}
+	number, err := strconv.Atoi(numStr)
+	if err != nil || number <= 0 {
+		return 0, time.Time{}, false
+	}
+	createdAt, err := time.Parse(generationTimeFormat, tsStr)
+	if err != nil {
+		return 0, time.Time{}, false
+	}
+	return number, createdAt, true
+}
@@ -0,0 +1,99 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/terassyi/tomei/internal/resource"
+)
+
+func saveToolVersion(t *testing.T, store *Store[UserState], version string) {
+	t.Helper()
+	st := &UserState{
+		Version: Version,
+		Tools: map[string]*resource.ToolState{
+			"gh": {Version: version, VersionKind: resource.VersionLatest},
+		},
+	}
+	require.NoError(t, store.Save(st))
+}
+
+func TestRecordGeneration(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	store, err := NewStore[UserState](dir)
+	require.NoError(t, err)
+	require.NoError(t, store.Lock())
+	defer func() { _ = store.Unlock() }()
+
+	// No state file yet
+	gen, err := RecordGeneration(store, 3)
+	require.NoError(t, err)
+	assert.Nil(t, gen)
+
+	saveToolVersion(t, store, "2.60.0")
+	gen, err = RecordGeneration(store, 3)
+	require.NoError(t, err)
+	require.NotNil(t, gen)
+	assert.Equal(t, 1, gen.Number)
+	assert.Equal(t, filepath.Join(dir, "history"), filepath.Dir(gen.Path))
+
+	// Unchanged state is not recorded again
+	gen, err = RecordGeneration(store, 3)
+	require.NoError(t, err)
+	assert.Nil(t, gen)
+
+	for _, v := range []string{"2.61.0", "2.62.0", "2.63.0"} {
+		saveToolVersion(t, store, v)
+		_, err := RecordGeneration(store, 3)
+		require.NoError(t, err)
+	}
+
+	// Oldest generation is pruned
+	gens, err := ListGenerations(store.StatePath())
+	require.NoError(t, err)
+	require.Len(t, gens, 3)
+	assert.Equal(t, []int{2, 3, 4}, []int{gens[0].Number, gens[1].Number, gens[2].Number})
+
+	st, err := LoadGeneration[UserState](store.StatePath(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, "2.61.0", st.Tools["gh"].Version)
+
+	_, err = LoadGeneration[UserState](store.StatePath(), 1)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "generation 1 not found")
+}
+
+func TestListGenerations(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	statePath := filepath.Join(dir, "state.json")
+
+	// Missing history directory
+	gens, err := ListGenerations(statePath)
+	require.NoError(t, err)
+	assert.Empty(t, gens)
+
+	historyDir := HistoryDir(statePath)
+	require.NoError(t, os.MkdirAll(historyDir, 0755))
+	for _, name := range []string{
+		"000010-20260102T150405Z.json",
+		"000002-20260101T000000Z.json",
+		"000003-20260101T000000Z.json.tmp", // in-progress write
+		"notes.txt",
+		"abc-20260101T000000Z.json",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(historyDir, name), []byte("{}"), 0644))
+	}
+
+	gens, err = ListGenerations(statePath)
+	require.NoError(t, err)
+	require.Len(t, gens, 2)
+	assert.Equal(t, 2, gens[0].Number)
+	assert.Equal(t, 10, gens[1].Number)
+	assert.Equal(t, time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC), gens[1].CreatedAt)
+}
@@ -42,7 +42,9 @@ type taskState struct {
 	startTime   time.Time
 	downloaded  int64
 	total       int64
-	hasProgress bool // true after first EventProgress received
+	hasProgress bool      // true after first EventProgress received
+	retryUntil  time.Time // set while waiting to retry a failed download
+	retryReason string
 	installPath string
 	logLines    []string
 	elapsed     time.Duration // set on complete/error; for running tasks, computed from startTime
@@ -6,6 +6,7 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/mattn/go-isatty"
@@ -167,6 +168,10 @@ func (pm *ProgressManager) handleCommandStart(event engine.Event, key string) {
 
 // handleProgress handles EventProgress.
 func (pm *ProgressManager) handleProgress(event engine.Event, key string) {
+	if event.RetryReason != "" {
+		pm.printRetry(event)
+		return
+	}
 	if !pm.isTTY {
 		return
 	}
@@ -183,6 +188,19 @@ func (pm *ProgressManager) handleProgress(event engine.Event, key string) {
 	}
 }
 
+// printRetry reports a failed download attempt that is about to be retried.
+// On a TTY the line is printed above the progress bars.
+func (pm *ProgressManager) printRetry(event engine.Event) {
+	var w io.Writer = pm.w
+	if pm.isTTY {
+		w = pm.progress
+	}
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	fmt.Fprintf(w, "    %s/%s: %s, retrying in %s\n",
+		event.Kind, event.Name, event.RetryReason, event.RetryWait.Round(100*time.Millisecond))
+}
+
 // handleOutput handles EventOutput.
 func (pm *ProgressManager) handleOutput(event engine.Event, key string) {
 	pm.cmdView.AddOutput(key, event.Output)
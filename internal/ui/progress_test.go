@@ -6,6 +6,7 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/terassyi/tomei/internal/installer/engine"
@@ -162,6 +163,30 @@ func TestProgressManager_HandleEvent_DownloadError_NonTTY(t *testing.T) {
 	assert.Equal(t, 1, results.Failed)
 }
 
+func TestProgressManager_HandleEvent_DownloadRetry_NonTTY(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	pm := newNonTTYProgressManager(&buf)
+
+	pm.HandleEvent(engine.Event{
+		Type:        engine.EventProgress,
+		Kind:        resource.KindRuntime,
+		Name:        "go",
+		RetryWait:   2 * time.Second,
+		RetryReason: "HTTP 503",
+	}, &ApplyResults{})
+	// Plain progress is not printed without a TTY
+	pm.HandleEvent(engine.Event{
+		Type:       engine.EventProgress,
+		Kind:       resource.KindRuntime,
+		Name:       "go",
+		Downloaded: 1024,
+		Total:      2048,
+	}, &ApplyResults{})
+
+	assert.Equal(t, "    Runtime/go: HTTP 503, retrying in 2s\n", buf.String())
+}
+
 func TestProgressManager_UpdateResults(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
@@ -190,7 +190,10 @@ func renderProgressLine(t *taskState, layerElapsed time.Duration, width int) str
 	taskElapsed := formatElapsed(layerElapsed)
 	label := taskLabel(t)
 	bar := renderProgressBar(t.downloaded, t.total)
-	sizes := fmt.Sprintf("%s / %s", formatSize(t.downloaded), formatSize(t.total))
+	sizes := fmt.Sprintf("%s / %s", FormatSize(t.downloaded), FormatSize(t.total))
+	if t.retryReason != "" {
+		sizes = fmt.Sprintf("%s, retrying in %s", t.retryReason, formatElapsed(max(time.Until(t.retryUntil), 0)))
+	}
 
 	prefix := fmt.Sprintf(" %s %s  %s  %s", runningMark, label, bar, sizes)
 	return rightAlign(prefix, taskElapsed, width)
@@ -256,8 +259,8 @@ func formatElapsed(d time.Duration) string {
 	return fmt.Sprintf("%.1fs", secs)
 }
 
-// formatSize formats bytes as human-readable size.
-func formatSize(bytes int64) string {
+// FormatSize formats bytes as human-readable size.
+func FormatSize(bytes int64) string {
 	const (
 		kib = 1024
 		mib = 1024 * kib
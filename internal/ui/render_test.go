@@ -65,7 +65,7 @@ func TestFormatSize(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			assert.Equal(t, tt.want, formatSize(tt.bytes))
+			assert.Equal(t, tt.want, FormatSize(tt.bytes))
 		})
 	}
 }
@@ -132,9 +132,16 @@ func (m *ApplyModel) handleProgress(event engine.Event) (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
+	task.hasProgress = true
+	if event.RetryReason != "" {
+		task.retryUntil = time.Now().Add(event.RetryWait)
+		task.retryReason = event.RetryReason
+		return m, nil
+	}
+	task.retryUntil = time.Time{}
+	task.retryReason = ""
 	task.downloaded = event.Downloaded
 	task.total = event.Total
-	task.hasProgress = true
 
 	return m, nil
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log/slog"
 	"testing"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/stretchr/testify/assert"
@@ -164,6 +165,44 @@ func TestUpdate_EventProgress_SetsHasProgress(t *testing.T) {
 	assert.Equal(t, int64(3000000), task.total)
 }
 
+func TestUpdate_EventProgress_Retry(t *testing.T) {
+	t.Parallel()
+	results := &ApplyResults{}
+	m := NewApplyModel(results)
+
+	m.Update(engineEventMsg{event: engine.Event{
+		Type: engine.EventLayerStart, Layer: 0, TotalLayers: 1,
+		LayerNodes: []string{"Tool/bat"}, AllLayerNodes: [][]string{{"Tool/bat"}},
+	}})
+	m.Update(engineEventMsg{event: engine.Event{
+		Type: engine.EventStart, Kind: resource.KindTool, Name: "bat", Version: "0.25.0",
+	}})
+	m.Update(engineEventMsg{event: engine.Event{
+		Type: engine.EventProgress, Kind: resource.KindTool, Name: "bat", Downloaded: 500000, Total: 3000000,
+	}})
+
+	// A failed attempt keeps the downloaded size until the retry reports progress
+	updated, _ := m.Update(engineEventMsg{event: engine.Event{
+		Type:        engine.EventProgress,
+		Kind:        resource.KindTool,
+		Name:        "bat",
+		RetryWait:   30 * time.Second,
+		RetryReason: "rate limited",
+	}})
+	model := updated.(*ApplyModel)
+	task := model.tasks["Tool/bat"]
+	assert.Equal(t, "rate limited", task.retryReason)
+	assert.Equal(t, int64(500000), task.downloaded)
+	assert.Contains(t, renderProgressLine(task, 0, 120), "rate limited, retrying in ")
+
+	updated, _ = m.Update(engineEventMsg{event: engine.Event{
+		Type: engine.EventProgress, Kind: resource.KindTool, Name: "bat", Downloaded: 600000, Total: 3000000,
+	}})
+	task = updated.(*ApplyModel).tasks["Tool/bat"]
+	assert.Empty(t, task.retryReason)
+	assert.Equal(t, int64(600000), task.downloaded)
+}
+
 func TestUpdate_EventProgress_IgnoredWithoutStart(t *testing.T) {
 	t.Parallel()
 	results := &ApplyResults{}
@@ -0,0 +1,313 @@
+package verify
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	sgverify "github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+// Signature verification methods of release artifacts, named after the
+// aqua-registry blocks that declare them.
+const (
+	MethodCosign             = "cosign"
+	MethodChecksumCosign     = "checksum.cosign"
+	MethodMinisign           = "minisign"
+	MethodSLSAProvenance     = "slsa_provenance"
+	MethodGitHubAttestations = "github_artifact_attestations"
+)
+
+// ErrUnsigned is returned by ArtifactVerifier.Verify when no signature is
+// declared for the artifact.
+var ErrUnsigned = errors.New("no signature is declared for the artifact")
+
+// maxSignatureFileSize is the maximum size of a downloaded signature,
+// certificate, key, bundle or provenance file.
+const maxSignatureFileSize = 16 << 20 // 16 MB
+
+// ArtifactSignatures declares the upstream signatures of a downloaded
+// release artifact. URLs are fully rendered.
+type ArtifactSignatures struct {
+	// Cosign is a cosign signature of the artifact itself.
+	Cosign *CosignSignature
+	// ChecksumCosign is a cosign signature of the checksum file at
+	// ChecksumURL. The verified checksum file must list the artifact.
+	ChecksumCosign *CosignSignature
+	ChecksumURL    string
+	// Minisign is a minisign signature of the artifact.
+	Minisign *MinisignSignature
+	// SLSAProvenance is a SLSA provenance attesting the artifact.
+	SLSAProvenance *SLSAProvenance
+	// GitHubAttestations are GitHub artifact attestations of the artifact.
+	GitHubAttestations *GitHubAttestations
+}
+
+// IsEmpty reports whether no signature is declared.
+func (s *ArtifactSignatures) IsEmpty() bool {
+	return s == nil || (s.Cosign == nil && s.ChecksumCosign == nil && s.Minisign == nil &&
+		s.SLSAProvenance == nil && s.GitHubAttestations == nil)
+}
+
+// CosignSignature is a cosign blob signature: a Sigstore bundle, a
+// signature made with a public key, or a keyless signature and certificate.
+type CosignSignature struct {
+	BundleURL      string
+	SignatureURL   string
+	CertificateURL string
+	KeyURL         string
+
+	// Identity of the signing certificate of keyless signatures
+	// (cosign --certificate-identity[-regexp] and
+	// --certificate-oidc-issuer[-regexp]).
+	CertificateIdentity         string
+	CertificateIdentityRegexp   string
+	CertificateOIDCIssuer       string
+	CertificateOIDCIssuerRegexp string
+}
+
+// MinisignSignature is a minisign signature verified with a public key.
+type MinisignSignature struct {
+	SignatureURL string
+	// PublicKey is the base64 minisign public key (e.g., "RWQ...").
+	PublicKey string
+}
+
+// SLSAProvenance is a SLSA provenance generated by slsa-github-generator.
+type SLSAProvenance struct {
+	URL string
+	// SourceURI is the repository that the artifact must be built from
+	// (e.g., "github.com/cli/cli").
+	SourceURI string
+	// SourceTag is the tag that the artifact must be built from. Empty
+	// skips the check.
+	SourceTag string
+}
+
+// GitHubAttestations are the artifact attestations stored by GitHub for a
+// repository.
+type GitHubAttestations struct {
+	Owner string
+	Repo  string
+	// SignerWorkflow is the workflow that must have signed the attestation
+	// (e.g., "owner/repo/.github/workflows/release.yml"). Empty accepts any
+	// workflow of the repository.
+	SignerWorkflow string
+}
+
+// ArtifactResult is the outcome of verifying the signatures of an artifact.
+type ArtifactResult struct {
+	// Verified lists the methods whose signature was verified.
+	Verified []string
+	// Skipped lists the methods that could not be checked, with the reason
+	// (e.g., "cosign: ...").
+	Skipped []string
+}
+
+// ArtifactVerifier verifies the upstream signatures of downloaded release
+// artifacts: cosign, minisign, SLSA provenance and GitHub artifact
+// attestations. Sigstore signatures are verified against the public-good
+// Sigstore trusted root, like SigstoreVerifier.
+type ArtifactVerifier struct {
+	client       *http.Client
+	githubAPIURL string
+	rekorURL     string
+	trustedRoot  trustedRoot
+}
+
+// NewArtifactVerifier creates an ArtifactVerifier that fetches signature
+// files and attestations with client. If client is nil, http.DefaultClient is used.
+func NewArtifactVerifier(client *http.Client) *ArtifactVerifier {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &ArtifactVerifier{
+		client:       client,
+		githubAPIURL: "https://api.github.com",
+		rekorURL:     defaultRekorURL,
+	}
+}
+
+// WithGitHubAPIURL sets the base URL of the GitHub API (for testing).
+func (v *ArtifactVerifier) WithGitHubAPIURL(url string) *ArtifactVerifier {
+	v.githubAPIURL = strings.TrimSuffix(url, "/")
+	return v
+}
+
+// WithRekorURL sets the base URL of the Rekor transparency log (for testing).
+func (v *ArtifactVerifier) WithRekorURL(url string) *ArtifactVerifier {
+	v.rekorURL = strings.TrimSuffix(url, "/")
+	return v
+}
+
+// WithTrustedRootFile makes the verifier use only the Sigstore trusted root
+// at path, such as the one recorded in an offline bundle.
+func (v *ArtifactVerifier) WithTrustedRootFile(path string) *ArtifactVerifier {
+	v.trustedRoot.source = TrustedRootCached
+	v.trustedRoot.cachePath = path
+	return v
+}
+
+// LoadedTrustedRoot returns the JSON of the Sigstore trusted root that
+// signatures were verified against, or nil if no Sigstore signature has
+// been verified yet. It must not be called concurrently with Verify.
+func (v *ArtifactVerifier) LoadedTrustedRoot() []byte {
+	return v.trustedRoot.data
+}
+
+// Verify checks the declared signatures of the artifact at artifactPath.
+// A declared signature that fails to verify is an error. Signatures that
+// cannot be checked are reported in ArtifactResult.Skipped, and it is an
+// error if none of the declared signatures could be verified. Returns
+// ErrUnsigned if sigs declares nothing.
+func (v *ArtifactVerifier) Verify(ctx context.Context, artifactPath string, sigs *ArtifactSignatures) (*ArtifactResult, error) {
+	if sigs.IsEmpty() {
+		return nil, ErrUnsigned
+	}
+
+	digest, err := fileSHA256(artifactPath)
+	if err != nil {
+		return nil, err
+	}
+	artifact := &artifactFile{path: artifactPath, digest: digest}
+
+	type check struct {
+		method string
+		run    func() (skipReason string, err error)
+	}
+	var checks []check
+	if sigs.Cosign != nil {
+		checks = append(checks, check{MethodCosign, func() (string, error) {
+			return v.verifyCosign(ctx, sigs.Cosign, artifact)
+		}})
+	}
+	if sigs.ChecksumCosign != nil {
+		checks = append(checks, check{MethodChecksumCosign, func() (string, error) {
+			return v.verifyChecksumCosign(ctx, sigs.ChecksumCosign, sigs.ChecksumURL, artifact)
+		}})
+	}
+	if sigs.Minisign != nil {
+		checks = append(checks, check{MethodMinisign, func() (string, error) {
+			return "", v.verifyMinisign(ctx, sigs.Minisign, artifact)
+		}})
+	}
+	if sigs.SLSAProvenance != nil {
+		checks = append(checks, check{MethodSLSAProvenance, func() (string, error) {
+			return v.verifySLSAProvenance(ctx, sigs.SLSAProvenance, artifact)
+		}})
+	}
+	if sigs.GitHubAttestations != nil {
+		checks = append(checks, check{MethodGitHubAttestations, func() (string, error) {
+			return v.verifyGitHubAttestations(ctx, sigs.GitHubAttestations, artifact)
+		}})
+	}
+
+	result := &ArtifactResult{}
+	var errs []error
+	for _, c := range checks {
+		skipReason, err := c.run()
+		switch {
+		case err != nil:
+			errs = append(errs, fmt.Errorf("%s: %w", c.method, err))
+		case skipReason != "":
+			result.Skipped = append(result.Skipped, c.method+": "+skipReason)
+		default:
+			result.Verified = append(result.Verified, c.method)
+		}
+	}
+	if len(errs) > 0 {
+		return result, fmt.Errorf("signature verification failed: %w", errors.Join(errs...))
+	}
+	if len(result.Verified) == 0 {
+		return result, fmt.Errorf("no signature could be verified (%s)", strings.Join(result.Skipped, "; "))
+	}
+	return result, nil
+}
+
+// artifactFile is the artifact being verified and its SHA256 digest. The
+// content of a small artifact such as a checksum file is held in data
+// instead of a file at path.
+type artifactFile struct {
+	path   string
+	data   []byte
+	digest []byte
+}
+
+func (a *artifactFile) read() ([]byte, error) {
+	if a.data != nil {
+		return a.data, nil
+	}
+	data, err := os.ReadFile(a.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read artifact: %w", err)
+	}
+	return data, nil
+}
+
+func fileSHA256(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open artifact: %w", err)
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, fmt.Errorf("failed to read artifact: %w", err)
+	}
+	return h.Sum(nil), nil
+}
+
+// fetch downloads a signature file.
+func (v *ArtifactVerifier) fetch(ctx context.Context, url string, header http.Header) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+	return v.do(req)
+}
+
+// do sends req and returns the body of a 200 response.
+func (v *ArtifactVerifier) do(req *http.Request) ([]byte, error) {
+	url := req.URL.String()
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSignatureFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", url, err)
+	}
+	if len(data) > maxSignatureFileSize {
+		return nil, fmt.Errorf("%s exceeds %d bytes", url, maxSignatureFileSize)
+	}
+	return data, nil
+}
+
+// verifySigstore verifies a Sigstore signed entity against the public-good
+// trusted root, the artifact digest and the certificate identity.
+func (v *ArtifactVerifier) verifySigstore(entity sgverify.SignedEntity, digest []byte, identity sgverify.CertificateIdentity) (*sgverify.VerificationResult, error) {
+	verifier, err := newPublicGoodVerifier(&v.trustedRoot)
+	if err != nil {
+		return nil, err
+	}
+	result, err := verifier.Verify(entity, sgverify.NewPolicy(
+		sgverify.WithArtifactDigest("sha256", digest),
+		sgverify.WithCertificateIdentity(identity),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("sigstore verification failed: %w", err)
+	}
+	return result, nil
+}
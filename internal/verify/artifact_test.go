@@ -0,0 +1,358 @@
+package verify
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/blake2b"
+)
+
+// newSignatureServer serves the given files (path -> content).
+func newSignatureServer(t *testing.T, files map[string][]byte) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func writeArtifact(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tool_linux_amd64.tar.gz")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+// minisignKey returns a minisign public key string and a function signing
+// data into a minisign signature file.
+func minisignKey(t *testing.T, prehashed bool) (string, func(data []byte) []byte) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	keyID := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	pubKey := base64.StdEncoding.EncodeToString(append(append([]byte(minisignAlgEd), keyID...), pub...))
+
+	sign := func(data []byte) []byte {
+		alg, message := minisignAlgEd, data
+		if prehashed {
+			h := blake2b.Sum512(data)
+			alg, message = minisignAlgPrehashed, h[:]
+		}
+		sig := ed25519.Sign(priv, message)
+		trustedComment := "timestamp:1700000000\tfile:tool_linux_amd64.tar.gz"
+		global := ed25519.Sign(priv, append(append([]byte{}, sig...), trustedComment...))
+		return fmt.Appendf(nil, "untrusted comment: signature from minisign secret key\n%s\ntrusted comment: %s\n%s\n",
+			base64.StdEncoding.EncodeToString(append(append([]byte(alg), keyID...), sig...)),
+			trustedComment,
+			base64.StdEncoding.EncodeToString(global))
+	}
+	return pubKey, sign
+}
+
+// cosignKey returns a PEM ECDSA public key and a function signing data like
+// "cosign sign-blob --key".
+func cosignKey(t *testing.T) ([]byte, func(data []byte) []byte) {
+	t.Helper()
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	require.NoError(t, err)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	sign := func(data []byte) []byte {
+		digest := sha256.Sum256(data)
+		sig, err := ecdsa.SignASN1(rand.Reader, priv, digest[:])
+		require.NoError(t, err)
+		return []byte(base64.StdEncoding.EncodeToString(sig))
+	}
+	return keyPEM, sign
+}
+
+func TestArtifactVerifier_Unsigned(t *testing.T) {
+	t.Parallel()
+
+	v := NewArtifactVerifier(nil)
+	_, err := v.Verify(context.Background(), writeArtifact(t, "tool"), &ArtifactSignatures{})
+	assert.ErrorIs(t, err, ErrUnsigned)
+}
+
+func TestArtifactVerifier_Minisign(t *testing.T) {
+	t.Parallel()
+
+	for _, prehashed := range []bool{true, false} {
+		t.Run(fmt.Sprintf("prehashed=%v", prehashed), func(t *testing.T) {
+			t.Parallel()
+
+			pubKey, sign := minisignKey(t, prehashed)
+			otherKey, _ := minisignKey(t, prehashed)
+			srv := newSignatureServer(t, map[string][]byte{
+				"/tool.minisig": sign([]byte("tool binary")),
+			})
+			v := NewArtifactVerifier(srv.Client())
+			sigs := func(key string) *ArtifactSignatures {
+				return &ArtifactSignatures{Minisign: &MinisignSignature{SignatureURL: srv.URL + "/tool.minisig", PublicKey: key}}
+			}
+
+			result, err := v.Verify(context.Background(), writeArtifact(t, "tool binary"), sigs(pubKey))
+			require.NoError(t, err)
+			assert.Equal(t, []string{MethodMinisign}, result.Verified)
+
+			_, err = v.Verify(context.Background(), writeArtifact(t, "tampered binary"), sigs(pubKey))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "minisign: invalid signature")
+
+			_, err = v.Verify(context.Background(), writeArtifact(t, "tool binary"), sigs(otherKey))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "invalid signature")
+		})
+	}
+}
+
+func TestParseMinisignPublicKey(t *testing.T) {
+	t.Parallel()
+
+	_, err := parseMinisignPublicKey("not base64!")
+	require.Error(t, err)
+
+	_, err = parseMinisignPublicKey(base64.StdEncoding.EncodeToString([]byte("Ed short")))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid minisign public key")
+}
+
+func TestArtifactVerifier_CosignKey(t *testing.T) {
+	t.Parallel()
+
+	keyPEM, sign := cosignKey(t)
+	srv := newSignatureServer(t, map[string][]byte{
+		"/cosign.pub": keyPEM,
+		"/tool.sig":   sign([]byte("tool binary")),
+	})
+	v := NewArtifactVerifier(srv.Client())
+	sigs := &ArtifactSignatures{Cosign: &CosignSignature{
+		KeyURL:       srv.URL + "/cosign.pub",
+		SignatureURL: srv.URL + "/tool.sig",
+	}}
+
+	result, err := v.Verify(context.Background(), writeArtifact(t, "tool binary"), sigs)
+	require.NoError(t, err)
+	assert.Equal(t, []string{MethodCosign}, result.Verified)
+
+	_, err = v.Verify(context.Background(), writeArtifact(t, "tampered binary"), sigs)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cosign: invalid signature")
+}
+
+func TestArtifactVerifier_ChecksumCosign(t *testing.T) {
+	t.Parallel()
+
+	artifact := writeArtifact(t, "tool binary")
+	digest := sha256.Sum256([]byte("tool binary"))
+	checksums := []byte(hex.EncodeToString(digest[:]) + "  tool_linux_amd64.tar.gz\n")
+
+	keyPEM, sign := cosignKey(t)
+	srv := newSignatureServer(t, map[string][]byte{
+		"/cosign.pub":        keyPEM,
+		"/checksums.txt":     checksums,
+		"/checksums.txt.sig": sign(checksums),
+		"/forged.txt":        []byte(hex.EncodeToString(digest[:]) + "  tool_linux_amd64.tar.gz\n# forged\n"),
+	})
+	v := NewArtifactVerifier(srv.Client())
+	sigs := func(checksumFile string) *ArtifactSignatures {
+		return &ArtifactSignatures{
+			ChecksumURL: srv.URL + checksumFile,
+			ChecksumCosign: &CosignSignature{
+				KeyURL:       srv.URL + "/cosign.pub",
+				SignatureURL: srv.URL + "/checksums.txt.sig",
+			},
+		}
+	}
+
+	result, err := v.Verify(context.Background(), artifact, sigs("/checksums.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{MethodChecksumCosign}, result.Verified)
+
+	_, err = v.Verify(context.Background(), artifact, sigs("/forged.txt"))
+	require.Error(t, err, "the signature does not cover a modified checksum file")
+
+	_, err = v.Verify(context.Background(), writeArtifact(t, "tampered binary"), sigs("/checksums.txt"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not match the signed checksum file")
+}
+
+func TestArtifactVerifier_Skipped(t *testing.T) {
+	t.Parallel()
+
+	srv := newSignatureServer(t, map[string][]byte{
+		"/provenance.intoto.jsonl": []byte(`{"payloadType":"application/vnd.in-toto+json","payload":"e30=","signatures":[{"sig":"c2ln"}]}` + "\n"),
+	})
+	v := NewArtifactVerifier(srv.Client())
+	artifact := writeArtifact(t, "tool binary")
+
+	// Legacy SLSA provenance cannot be checked and is skipped, but one
+	// signature must verify
+	provenance := &SLSAProvenance{URL: srv.URL + "/provenance.intoto.jsonl", SourceURI: "github.com/example/tool"}
+	result, err := v.Verify(context.Background(), artifact, &ArtifactSignatures{SLSAProvenance: provenance})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no signature could be verified")
+	assert.Contains(t, err.Error(), "cannot pass signaturePolicy require")
+	assert.Empty(t, result.Verified)
+	assert.Len(t, result.Skipped, 1)
+
+	// A skipped signature does not fail a verified one
+	pubKey, sign := minisignKey(t, true)
+	srv2 := newSignatureServer(t, map[string][]byte{"/tool.minisig": sign([]byte("tool binary"))})
+	result, err = NewArtifactVerifier(srv.Client()).Verify(context.Background(), artifact, &ArtifactSignatures{
+		SLSAProvenance: provenance,
+		Minisign:       &MinisignSignature{SignatureURL: srv2.URL + "/tool.minisig", PublicKey: pubKey},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{MethodMinisign}, result.Verified)
+	assert.Len(t, result.Skipped, 1)
+}
+
+func TestArtifactVerifier_CosignKeylessRekor(t *testing.T) {
+	t.Parallel()
+
+	artifact := writeArtifact(t, "tool binary")
+	digest := sha256.Sum256([]byte("tool binary"))
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("not a real certificate")})
+
+	tests := []struct {
+		name    string
+		index   string
+		wantErr string
+	}{
+		{
+			name:    "no transparency log entry",
+			index:   `[]`,
+			wantErr: "no transparency log entry found for sha256:" + hex.EncodeToString(digest[:]),
+		},
+		{
+			name:    "invalid entry",
+			index:   `["24296fb24b8ad77a"]`,
+			wantErr: "rekor entry 24296fb24b8ad77a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var searched string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/tool.sig":
+					_, _ = w.Write([]byte(base64.StdEncoding.EncodeToString([]byte("signature"))))
+				case "/tool.pem":
+					// cosign writes the certificate base64-encoded
+					_, _ = w.Write([]byte(base64.StdEncoding.EncodeToString(certPEM)))
+				case "/api/v1/index/retrieve":
+					body, _ := io.ReadAll(r.Body)
+					searched = r.Method + " " + string(body)
+					_, _ = w.Write([]byte(tt.index))
+				case "/api/v1/log/entries/24296fb24b8ad77a":
+					_, _ = w.Write([]byte(`{"24296fb24b8ad77a":{"body":"not base64","logID":"zz"}}`))
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer srv.Close()
+
+			v := NewArtifactVerifier(srv.Client()).WithRekorURL(srv.URL)
+			_, err := v.Verify(context.Background(), artifact, &ArtifactSignatures{
+				Cosign: &CosignSignature{
+					SignatureURL:          srv.URL + "/tool.sig",
+					CertificateURL:        srv.URL + "/tool.pem",
+					CertificateIdentity:   "https://github.com/example/tool/.github/workflows/release.yml@refs/tags/v1.0.0",
+					CertificateOIDCIssuer: "https://token.actions.githubusercontent.com",
+				},
+			})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+			assert.Equal(t, `POST {"hash":"sha256:`+hex.EncodeToString(digest[:])+`"}`, searched)
+		})
+	}
+}
+
+func TestArtifactVerifier_CosignKeylessRequiresIdentity(t *testing.T) {
+	t.Parallel()
+
+	v := NewArtifactVerifier(nil)
+	_, err := v.Verify(context.Background(), writeArtifact(t, "tool binary"), &ArtifactSignatures{
+		Cosign: &CosignSignature{SignatureURL: "https://example.com/tool.sig", CertificateURL: "https://example.com/tool.pem"},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no certificate identity is declared")
+}
+
+func TestArtifactVerifier_CosignBundleRequiresIdentity(t *testing.T) {
+	t.Parallel()
+
+	v := NewArtifactVerifier(nil)
+	_, err := v.Verify(context.Background(), writeArtifact(t, "tool binary"), &ArtifactSignatures{
+		Cosign: &CosignSignature{BundleURL: "https://example.com/tool.sigstore.json"},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no certificate identity is declared")
+}
+
+func TestArtifactVerifier_GitHubAttestations_NotFound(t *testing.T) {
+	t.Parallel()
+
+	digest := sha256.Sum256([]byte("tool binary"))
+	var gotPath, gotAccept string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotAccept = r.Header.Get("Accept")
+		_, _ = w.Write([]byte(`{"attestations":[]}`))
+	}))
+	defer srv.Close()
+
+	v := NewArtifactVerifier(srv.Client()).WithGitHubAPIURL(srv.URL)
+	_, err := v.Verify(context.Background(), writeArtifact(t, "tool binary"), &ArtifactSignatures{
+		GitHubAttestations: &GitHubAttestations{Owner: "example", Repo: "tool"},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no attestation found")
+	assert.Equal(t, "/repos/example/tool/attestations/sha256:"+hex.EncodeToString(digest[:]), gotPath)
+	assert.Equal(t, "application/vnd.github+json", gotAccept)
+}
+
+func TestGitHubRepositorySANRegex(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, `^https://github\.com/cli/cli/`, githubRepositorySANRegex("cli", "cli", ""))
+	assert.Equal(t, `^https://github\.com/owner/workflows/\.github/workflows/release\.yml@`,
+		githubRepositorySANRegex("cli", "cli", "owner/workflows/.github/workflows/release.yml"))
+}
+
+func TestNormalizeSourceURI(t *testing.T) {
+	t.Parallel()
+
+	for _, uri := range []string{"github.com/cli/cli", "https://github.com/cli/cli", "git+https://github.com/cli/cli.git"} {
+		assert.Equal(t, "github.com/cli/cli", normalizeSourceURI(uri), uri)
+	}
+}
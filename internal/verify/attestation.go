@@ -0,0 +1,60 @@
+package verify
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/sigstore/sigstore-go/pkg/fulcio/certificate"
+)
+
+// githubAttestationsResponse is the response of the GitHub API
+// "List attestations" endpoint.
+type githubAttestationsResponse struct {
+	Attestations []struct {
+		Bundle json.RawMessage `json:"bundle"`
+	} `json:"attestations"`
+}
+
+// verifyGitHubAttestations verifies that a GitHub artifact attestation of
+// the repository, signed by the signer workflow, attests the artifact. One
+// verified attestation is enough.
+func (v *ArtifactVerifier) verifyGitHubAttestations(ctx context.Context, att *GitHubAttestations, artifact *artifactFile) (string, error) {
+	identity, err := githubActionsIdentity(githubRepositorySANRegex(att.Owner, att.Repo, att.SignerWorkflow), certificate.Extensions{
+		SourceRepositoryURI: fmt.Sprintf("https://github.com/%s/%s", att.Owner, att.Repo),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	url := fmt.Sprintf("%s/repos/%s/%s/attestations/sha256:%s", v.githubAPIURL, att.Owner, att.Repo, hex.EncodeToString(artifact.digest))
+	data, err := v.fetch(ctx, url, http.Header{"Accept": {"application/vnd.github+json"}})
+	if err != nil {
+		return "", err
+	}
+	var resp githubAttestationsResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return "", fmt.Errorf("failed to parse attestations: %w", err)
+	}
+	if len(resp.Attestations) == 0 {
+		return "", errors.New("no attestation found for the artifact")
+	}
+
+	var errs []error
+	for _, a := range resp.Attestations {
+		b, err := parseBundle(a.Bundle)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if _, err := v.verifySigstore(b, artifact.digest, identity); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		return "", nil
+	}
+	return "", errors.Join(errs...)
+}
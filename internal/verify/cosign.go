@@ -0,0 +1,195 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	sgverify "github.com/sigstore/sigstore-go/pkg/verify"
+
+	"github.com/terassyi/tomei/internal/checksum"
+)
+
+// cosignBlobBundle is the bundle written by "cosign sign-blob --bundle"
+// before cosign adopted the Sigstore bundle format.
+type cosignBlobBundle struct {
+	Base64Signature string            `json:"base64Signature"`
+	Cert            string            `json:"cert"`
+	RekorBundle     *cosignRekorEntry `json:"rekorBundle"`
+}
+
+// verifyCosign verifies a cosign signature of the artifact.
+func (v *ArtifactVerifier) verifyCosign(ctx context.Context, sig *CosignSignature, artifact *artifactFile) (string, error) {
+	switch {
+	case sig.BundleURL != "":
+		identity, err := sig.identity()
+		if err != nil {
+			return "", err
+		}
+		data, err := v.fetch(ctx, sig.BundleURL, nil)
+		if err != nil {
+			return "", err
+		}
+		b, err := parseCosignBundle(data, artifact.digest)
+		if err != nil {
+			return "", err
+		}
+		_, err = v.verifySigstore(b, artifact.digest, identity)
+		return "", err
+
+	case sig.KeyURL != "" && sig.SignatureURL != "":
+		key, err := v.fetch(ctx, sig.KeyURL, nil)
+		if err != nil {
+			return "", err
+		}
+		signature, err := v.fetch(ctx, sig.SignatureURL, nil)
+		if err != nil {
+			return "", err
+		}
+		return "", verifyWithPublicKey(key, signature, artifact)
+
+	case sig.SignatureURL != "" && sig.CertificateURL != "":
+		return "", v.verifyKeyless(ctx, sig, artifact)
+
+	default:
+		return "", errors.New("no bundle, or signature with a key or certificate, is declared")
+	}
+}
+
+// verifyChecksumCosign verifies the cosign signature of the checksum file
+// and that the signed checksum file lists the digest of the artifact.
+func (v *ArtifactVerifier) verifyChecksumCosign(ctx context.Context, sig *CosignSignature, checksumURL string, artifact *artifactFile) (string, error) {
+	if checksumURL == "" {
+		return "", errors.New("no checksum file is declared")
+	}
+	content, err := v.fetch(ctx, checksumURL, nil)
+	if err != nil {
+		return "", err
+	}
+
+	digest := sha256.Sum256(content)
+
+	skipReason, err := v.verifyCosign(ctx, sig, &artifactFile{data: content, digest: digest[:]})
+	if err != nil || skipReason != "" {
+		return skipReason, err
+	}
+
+	algorithm, expected, err := checksum.ParseFile(content, filepath.Base(artifact.path))
+	if err != nil {
+		return "", fmt.Errorf("signed checksum file does not list the artifact: %w", err)
+	}
+	if err := checksum.Verify(artifact.path, algorithm, expected); err != nil {
+		return "", fmt.Errorf("artifact does not match the signed checksum file: %w", err)
+	}
+	return "", nil
+}
+
+// identity returns the certificate identity that keyless signatures must match.
+func (s *CosignSignature) identity() (sgverify.CertificateIdentity, error) {
+	if s.CertificateIdentity == "" && s.CertificateIdentityRegexp == "" {
+		return sgverify.CertificateIdentity{}, errors.New("no certificate identity is declared (--certificate-identity or --certificate-identity-regexp)")
+	}
+	if s.CertificateOIDCIssuer == "" && s.CertificateOIDCIssuerRegexp == "" {
+		return sgverify.CertificateIdentity{}, errors.New("no certificate OIDC issuer is declared (--certificate-oidc-issuer or --certificate-oidc-issuer-regexp)")
+	}
+	identity, err := sgverify.NewShortCertificateIdentity(
+		s.CertificateOIDCIssuer,
+		s.CertificateOIDCIssuerRegexp,
+		s.CertificateIdentity,
+		s.CertificateIdentityRegexp,
+	)
+	if err != nil {
+		return sgverify.CertificateIdentity{}, fmt.Errorf("invalid certificate identity: %w", err)
+	}
+	return identity, nil
+}
+
+// parseCosignBundle parses a Sigstore bundle, or a legacy cosign blob
+// bundle which is converted to a v0.1 Sigstore bundle signing digest.
+func parseCosignBundle(data, digest []byte) (*bundle.Bundle, error) {
+	var probe struct {
+		MediaType string `json:"mediaType"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("failed to parse cosign bundle: %w", err)
+	}
+	if probe.MediaType != "" {
+		return parseBundle(data)
+	}
+
+	var legacy cosignBlobBundle
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return nil, fmt.Errorf("failed to parse cosign bundle: %w", err)
+	}
+	if legacy.Base64Signature == "" || legacy.Cert == "" || legacy.RekorBundle == nil {
+		return nil, errors.New("cosign bundle has no signature, certificate or Rekor entry")
+	}
+	sig, err := base64.StdEncoding.DecodeString(legacy.Base64Signature)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode signature base64: %w", err)
+	}
+	certPEM, err := base64.StdEncoding.DecodeString(legacy.Cert)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode certificate base64: %w", err)
+	}
+	certs, err := parsePEMCertificates(string(certPEM))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+	b, err := newRekorBundle(certs, sig, digest, legacy.RekorBundle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sigstore bundle from cosign bundle: %w", err)
+	}
+	return b, nil
+}
+
+// verifyWithPublicKey verifies a base64 cosign signature made with the
+// private key of the PEM public key (ECDSA, RSA or Ed25519).
+func verifyWithPublicKey(keyPEM, signature []byte, artifact *artifactFile) error {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return errors.New("no PEM block found in the public key")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("failed to parse public key: %w", err)
+	}
+	sig, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(signature)))
+	if err != nil {
+		return fmt.Errorf("failed to decode signature base64: %w", err)
+	}
+
+	switch key := pub.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, artifact.digest, sig) {
+			return errors.New("invalid signature")
+		}
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, artifact.digest, sig); err != nil {
+			return fmt.Errorf("invalid signature: %w", err)
+		}
+	case ed25519.PublicKey:
+		data, err := artifact.read()
+		if err != nil {
+			return err
+		}
+		if !ed25519.Verify(key, data, sig) {
+			return errors.New("invalid signature")
+		}
+	default:
+		return fmt.Errorf("unsupported public key type %T", pub)
+	}
+	return nil
+}
@@ -31,10 +31,21 @@ func ParseModuleFile(cueModDir string) (*modfile.File, error) {
 	return f, nil
 }
 
+// ExtractDeps reads cue.mod/module.cue from the given cue.mod directory
+// and returns all module dependencies, sorted.
+// Returns nil (no error) if the directory does not exist.
+func ExtractDeps(cueModDir string) ([]module.Version, error) {
+	return extractDeps(cueModDir, func(string) bool { return true })
+}
+
 // ExtractFirstPartyDeps reads cue.mod/module.cue from the given cue.mod directory
 // and returns the list of first-party (tomei.terassyi.net) module dependencies.
 // Returns nil (no error) if the directory does not exist.
 func ExtractFirstPartyDeps(cueModDir string) ([]module.Version, error) {
+	return extractDeps(cueModDir, IsFirstParty)
+}
+
+func extractDeps(cueModDir string, match func(modulePath string) bool) ([]module.Version, error) {
 	f, err := ParseModuleFile(cueModDir)
 	if err != nil {
 		return nil, err
@@ -45,7 +56,7 @@ func ExtractFirstPartyDeps(cueModDir string) ([]module.Version, error) {
 
 	var deps []module.Version
 	for modPath, dep := range f.Deps {
-		if IsFirstParty(modPath) {
+		if match(modPath) {
 			v, err := module.NewVersion(modPath, dep.Version)
 			if err != nil {
 				return nil, fmt.Errorf("invalid module version %s@%s: %w", modPath, dep.Version, err)
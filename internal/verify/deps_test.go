@@ -192,3 +192,25 @@ func TestExtractFirstPartyDeps_InvalidModuleCUE(t *testing.T) {
 	_, err := ExtractFirstPartyDeps(cueModDir)
 	require.Error(t, err)
 }
+
+func TestExtractDeps(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	cueModDir := filepath.Join(dir, "cue.mod")
+	require.NoError(t, os.MkdirAll(cueModDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(cueModDir, "module.cue"), []byte(`module: "manifests.local@v0"
+language: version: "v0.9.0"
+deps: {
+	"tomei.terassyi.net@v0": v: "v0.0.3"
+	"example.com@v0": v: "v0.1.0"
+}
+`), 0644))
+
+	got, err := ExtractDeps(cueModDir)
+	require.NoError(t, err)
+	assert.Equal(t, []module.Version{
+		module.MustNewVersion("example.com@v0", "v0.1.0"),
+		module.MustNewVersion("tomei.terassyi.net@v0", "v0.0.3"),
+	}, got)
+}
@@ -0,0 +1,133 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// Minisign signature algorithms: "Ed" signs the file, "ED" (the default
+// since minisign 0.11) signs its BLAKE2b-512 hash.
+const (
+	minisignAlgEd        = "Ed"
+	minisignAlgPrehashed = "ED"
+)
+
+const trustedCommentPrefix = "trusted comment: "
+
+// minisignPublicKey is a decoded minisign public key.
+type minisignPublicKey struct {
+	keyID [8]byte
+	key   ed25519.PublicKey
+}
+
+// parseMinisignPublicKey decodes a base64 minisign public key:
+// "Ed" || key id (8 bytes) || Ed25519 public key (32 bytes).
+func parseMinisignPublicKey(s string) (*minisignPublicKey, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode minisign public key: %w", err)
+	}
+	if len(data) != 2+8+ed25519.PublicKeySize || string(data[:2]) != minisignAlgEd {
+		return nil, errors.New("invalid minisign public key")
+	}
+	pk := &minisignPublicKey{key: ed25519.PublicKey(data[10:])}
+	copy(pk.keyID[:], data[2:10])
+	return pk, nil
+}
+
+// verifyMinisign verifies a minisign signature of the artifact.
+func (v *ArtifactVerifier) verifyMinisign(ctx context.Context, sig *MinisignSignature, artifact *artifactFile) error {
+	pk, err := parseMinisignPublicKey(sig.PublicKey)
+	if err != nil {
+		return err
+	}
+	signature, err := v.fetch(ctx, sig.SignatureURL, nil)
+	if err != nil {
+		return err
+	}
+	return verifyMinisignSignature(pk, signature, artifact)
+}
+
+// verifyMinisignSignature verifies a minisign signature file:
+//
+//	untrusted comment: <text>
+//	base64(<alg> || key id || signature)
+//	trusted comment: <text>
+//	base64(global signature of signature || trusted comment)
+func verifyMinisignSignature(pk *minisignPublicKey, signatureFile []byte, artifact *artifactFile) error {
+	lines := strings.Split(strings.ReplaceAll(string(signatureFile), "\r\n", "\n"), "\n")
+	if len(lines) < 4 {
+		return errors.New("invalid minisign signature file")
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil {
+		return fmt.Errorf("failed to decode minisign signature: %w", err)
+	}
+	if len(sig) != 2+8+ed25519.SignatureSize {
+		return errors.New("invalid minisign signature")
+	}
+	if !bytes.Equal(sig[2:10], pk.keyID[:]) {
+		return fmt.Errorf("minisign signature key id %X does not match the public key %X", sig[2:10], pk.keyID)
+	}
+
+	var message []byte
+	switch string(sig[:2]) {
+	case minisignAlgEd:
+		message, err = artifact.read()
+	case minisignAlgPrehashed:
+		message, err = artifact.blake2b512()
+	default:
+		return fmt.Errorf("unsupported minisign signature algorithm %q", sig[:2])
+	}
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(pk.key, message, sig[10:]) {
+		return errors.New("invalid signature")
+	}
+
+	trustedComment, ok := strings.CutPrefix(lines[2], trustedCommentPrefix)
+	if !ok {
+		return errors.New("minisign signature file has no trusted comment")
+	}
+	globalSig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil {
+		return fmt.Errorf("failed to decode minisign global signature: %w", err)
+	}
+	signed := make([]byte, 0, ed25519.SignatureSize+len(trustedComment))
+	signed = append(append(signed, sig[10:]...), trustedComment...)
+	if !ed25519.Verify(pk.key, signed, globalSig) {
+		return errors.New("invalid trusted comment signature")
+	}
+	return nil
+}
+
+// blake2b512 returns the BLAKE2b-512 hash of the artifact.
+func (a *artifactFile) blake2b512() ([]byte, error) {
+	h, err := blake2b.New512(nil)
+	if err != nil {
+		return nil, err
+	}
+	if a.data != nil {
+		h.Write(a.data)
+		return h.Sum(nil), nil
+	}
+	f, err := os.Open(a.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open artifact: %w", err)
+	}
+	defer f.Close()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, fmt.Errorf("failed to read artifact: %w", err)
+	}
+	return h.Sum(nil), nil
+}
@@ -215,34 +215,44 @@ func buildBundleFromCosignAnnotations(annotations map[string]string, payload []b
 		return nil, fmt.Errorf("failed to parse rekor entry JSON: %w", err)
 	}
 
-	// 5. Decode SET (Signed Entry Timestamp)
+	// 5. Compute message digest (SHA256 of SimpleSigning payload)
+	digest := sha256.Sum256(payload)
+
+	b, err := newRekorBundle(certs, sigBytes, digest[:], &rekorEntry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sigstore bundle from cosign annotations: %w", err)
+	}
+	return b, nil
+}
+
+// newRekorBundle assembles a v0.1 Sigstore protobuf Bundle (with an
+// InclusionPromise) from a signature over a SHA256 digest, the signing
+// certificates and the Rekor entry recorded by cosign.
+func newRekorBundle(certs []*protocommon.X509Certificate, sig, digest []byte, rekorEntry *cosignRekorEntry) (*bundle.Bundle, error) {
+	// Decode SET (Signed Entry Timestamp)
 	set, err := base64.StdEncoding.DecodeString(rekorEntry.SignedEntryTimestamp)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode signed entry timestamp: %w", err)
 	}
 
-	// 6. Decode logID (hex → bytes)
+	// Decode logID (hex → bytes)
 	logIDBytes, err := hex.DecodeString(rekorEntry.Payload.LogID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode log ID: %w", err)
 	}
 
-	// 7. Decode body (base64 → bytes)
+	// Decode body (base64 → bytes)
 	bodyBytes, err := base64.StdEncoding.DecodeString(rekorEntry.Payload.Body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode rekor body: %w", err)
 	}
 
-	// 8. Parse KindVersion from body
+	// Parse KindVersion from body
 	var bodyMeta rekorBodyMeta
 	if err := json.Unmarshal(bodyBytes, &bodyMeta); err != nil {
 		return nil, fmt.Errorf("failed to parse rekor body for KindVersion: %w", err)
 	}
 
-	// 9. Compute message digest (SHA256 of SimpleSigning payload)
-	digest := sha256.Sum256(payload)
-
-	// 10. Assemble protobuf Bundle (v0.1 format with InclusionPromise)
 	pb := &protobundle.Bundle{
 		MediaType: "application/vnd.dev.sigstore.bundle+json;version=0.1",
 		VerificationMaterial: &protobundle.VerificationMaterial{
@@ -271,18 +281,14 @@ func buildBundleFromCosignAnnotations(annotations map[string]string, payload []b
 			MessageSignature: &protocommon.MessageSignature{
 				MessageDigest: &protocommon.HashOutput{
 					Algorithm: protocommon.HashAlgorithm_SHA2_256,
-					Digest:    digest[:],
+					Digest:    digest,
 				},
-				Signature: sigBytes,
+				Signature: sig,
 			},
 		},
 	}
 
-	b, err := bundle.NewBundle(pb)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create sigstore bundle from cosign annotations: %w", err)
-	}
-	return b, nil
+	return bundle.NewBundle(pb)
 }
 
 // parsePEMCertificates parses PEM-encoded certificate data into protobuf X509Certificate entries.
@@ -0,0 +1,157 @@
+package verify
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Verification modes of CUE module signatures.
+const (
+	// ModeWarn logs modules whose signature is missing or invalid and
+	// continues. This is the default.
+	ModeWarn = "warn"
+	// ModeRequire fails when a module has no verified signature, including
+	// modules without a configured identity.
+	ModeRequire = "require"
+)
+
+// Sources of the Sigstore trusted root.
+const (
+	// TrustedRootLive fetches the trusted root from the Sigstore TUF
+	// repository and caches it, falling back to the cache when the fetch
+	// fails. This is the default.
+	TrustedRootLive = "live"
+	// TrustedRootCached only uses the cached trusted root (offline).
+	TrustedRootCached = "cached"
+)
+
+// firstPartyIdentity is the signing identity of the first-party modules,
+// published by the terassyi/tomei GitHub Actions workflow.
+var firstPartyIdentity = Identity{
+	Module:    FirstPartyPrefix,
+	Issuer:    expectedOIDCIssuer,
+	SANRegexp: expectedSANRegex,
+}
+
+// Policy configures the signature verification of CUE module dependencies
+// (moduleVerification in config.cue). The zero value verifies first-party
+// modules in warn mode against the live trusted root.
+type Policy struct {
+	// Mode is ModeWarn or ModeRequire (empty means ModeWarn).
+	Mode string `json:"mode,omitempty"`
+
+	// TrustedRoot is TrustedRootLive or TrustedRootCached (empty means TrustedRootLive).
+	TrustedRoot string `json:"trustedRoot,omitempty"`
+
+	// Identities map module path prefixes to the certificate identity that
+	// must have signed them. The longest matching prefix wins; first-party
+	// modules fall back to the tomei release workflow.
+	Identities []Identity `json:"identities,omitempty"`
+}
+
+// Identity is the expected keyless signing identity of the modules under a
+// path prefix. One of Issuer or IssuerRegexp and one of SAN or SANRegexp
+// are required.
+type Identity struct {
+	// Module is the module path prefix (e.g., "example.com/presets").
+	Module string `json:"module"`
+
+	// Issuer or IssuerRegexp matches the OIDC issuer of the certificate
+	// (e.g., "https://token.actions.githubusercontent.com").
+	Issuer       string `json:"issuer,omitempty"`
+	IssuerRegexp string `json:"issuerRegexp,omitempty"`
+
+	// SAN or SANRegexp matches the subject alternative name of the
+	// certificate (e.g., "^https://github\\.com/example/presets/").
+	SAN       string `json:"san,omitempty"`
+	SANRegexp string `json:"sanRegexp,omitempty"`
+}
+
+// Validate checks the mode, the trusted root source and the identities.
+func (p *Policy) Validate() error {
+	if p == nil {
+		return nil
+	}
+	switch p.Mode {
+	case "", ModeWarn, ModeRequire:
+	default:
+		return fmt.Errorf("moduleVerification.mode must be %q or %q, got %q", ModeWarn, ModeRequire, p.Mode)
+	}
+	switch p.TrustedRoot {
+	case "", TrustedRootLive, TrustedRootCached:
+	default:
+		return fmt.Errorf("moduleVerification.trustedRoot must be %q or %q, got %q", TrustedRootLive, TrustedRootCached, p.TrustedRoot)
+	}
+	for i, id := range p.Identities {
+		if err := id.validate(); err != nil {
+			return fmt.Errorf("moduleVerification.identities[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (id *Identity) validate() error {
+	if id.Module == "" {
+		return fmt.Errorf("module is required")
+	}
+	if (id.Issuer == "") == (id.IssuerRegexp == "") {
+		return fmt.Errorf("exactly one of issuer or issuerRegexp is required")
+	}
+	if (id.SAN == "") == (id.SANRegexp == "") {
+		return fmt.Errorf("exactly one of san or sanRegexp is required")
+	}
+	for _, re := range []string{id.IssuerRegexp, id.SANRegexp} {
+		if _, err := regexp.Compile(re); err != nil {
+			return fmt.Errorf("invalid regexp %q: %w", re, err)
+		}
+	}
+	return nil
+}
+
+// Requires reports whether every module must have a verified signature.
+func (p *Policy) Requires() bool {
+	return p != nil && p.Mode == ModeRequire
+}
+
+// trustedRootSource returns the source of the trusted root.
+func (p *Policy) trustedRootSource() string {
+	if p == nil || p.TrustedRoot == "" {
+		return TrustedRootLive
+	}
+	return p.TrustedRoot
+}
+
+// identityFor returns the identity that must have signed the module: the
+// configured identity with the longest matching prefix, or the first-party
+// identity. Returns false if the module is not covered by the policy.
+func (p *Policy) identityFor(modulePath string) (Identity, bool) {
+	var best Identity
+	found := false
+	if p != nil {
+		for _, id := range p.Identities {
+			if hasModulePrefix(modulePath, id.Module) && (!found || len(id.Module) > len(best.Module)) {
+				best, found = id, true
+			}
+		}
+	}
+	if found {
+		return best, true
+	}
+	if IsFirstParty(modulePath) {
+		return firstPartyIdentity, true
+	}
+	return Identity{}, false
+}
+
+// hasModulePrefix reports whether modulePath is prefix or a module under it.
+// The prefix must be followed by a path separator, a major version
+// separator, or the end of the path.
+func hasModulePrefix(modulePath, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if prefix == "" || !strings.HasPrefix(modulePath, prefix) {
+		return false
+	}
+	rest := modulePath[len(prefix):]
+	return rest == "" || rest[0] == '/' || rest[0] == '@'
+}
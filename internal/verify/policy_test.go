@@ -0,0 +1,172 @@
+package verify
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy_Validate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		policy  *Policy
+		wantErr string
+	}{
+		{
+			name:   "nil policy",
+			policy: nil,
+		},
+		{
+			name: "require mode with identities",
+			policy: &Policy{
+				Mode:        ModeRequire,
+				TrustedRoot: TrustedRootCached,
+				Identities: []Identity{
+					{Module: "example.com/presets", Issuer: expectedOIDCIssuer, SANRegexp: `^https://github\.com/example/presets/`},
+				},
+			},
+		},
+		{
+			name:    "unknown mode",
+			policy:  &Policy{Mode: "strict"},
+			wantErr: "moduleVerification.mode must be",
+		},
+		{
+			name:    "unknown trusted root",
+			policy:  &Policy{TrustedRoot: "offline"},
+			wantErr: "moduleVerification.trustedRoot must be",
+		},
+		{
+			name: "identity without module",
+			policy: &Policy{Identities: []Identity{
+				{Issuer: expectedOIDCIssuer, SAN: "https://github.com/example/presets"},
+			}},
+			wantErr: "identities[0]: module is required",
+		},
+		{
+			name: "identity with issuer and issuerRegexp",
+			policy: &Policy{Identities: []Identity{
+				{Module: "example.com", Issuer: expectedOIDCIssuer, IssuerRegexp: ".*", SAN: "https://github.com/example/presets"},
+			}},
+			wantErr: "exactly one of issuer or issuerRegexp is required",
+		},
+		{
+			name: "identity without san",
+			policy: &Policy{Identities: []Identity{
+				{Module: "example.com", Issuer: expectedOIDCIssuer},
+			}},
+			wantErr: "exactly one of san or sanRegexp is required",
+		},
+		{
+			name: "identity with invalid regexp",
+			policy: &Policy{Identities: []Identity{
+				{Module: "example.com", Issuer: expectedOIDCIssuer, SANRegexp: "^(unclosed"},
+			}},
+			wantErr: "invalid regexp",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := tt.policy.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestPolicy_Requires(t *testing.T) {
+	t.Parallel()
+	assert.True(t, (&Policy{Mode: ModeRequire}).Requires())
+	assert.False(t, (&Policy{Mode: ModeWarn}).Requires())
+	assert.False(t, (&Policy{}).Requires())
+	assert.False(t, (*Policy)(nil).Requires())
+}
+
+func TestPolicy_IdentityFor(t *testing.T) {
+	t.Parallel()
+
+	presets := Identity{Module: "example.com/presets", Issuer: expectedOIDCIssuer, SAN: "https://github.com/example/presets"}
+	goPresets := Identity{Module: "example.com/presets/go/", Issuer: expectedOIDCIssuer, SAN: "https://github.com/example/go-presets"}
+	override := Identity{Module: "tomei.terassyi.net", Issuer: "https://issuer.example.com", SAN: "https://github.com/fork/tomei"}
+
+	tests := []struct {
+		name       string
+		policy     *Policy
+		modulePath string
+		want       Identity
+		wantOK     bool
+	}{
+		{
+			name:       "nil policy covers first-party modules",
+			policy:     nil,
+			modulePath: "tomei.terassyi.net/presets/go@v0",
+			want:       firstPartyIdentity,
+			wantOK:     true,
+		},
+		{
+			name:       "nil policy does not cover third-party modules",
+			policy:     nil,
+			modulePath: "example.com/presets@v0",
+			wantOK:     false,
+		},
+		{
+			name:       "configured prefix",
+			policy:     &Policy{Identities: []Identity{presets, goPresets}},
+			modulePath: "example.com/presets/rust@v0",
+			want:       presets,
+			wantOK:     true,
+		},
+		{
+			name:       "longest prefix wins",
+			policy:     &Policy{Identities: []Identity{presets, goPresets}},
+			modulePath: "example.com/presets/go@v0",
+			want:       goPresets,
+			wantOK:     true,
+		},
+		{
+			name:       "prefix must end at a path element",
+			policy:     &Policy{Identities: []Identity{presets}},
+			modulePath: "example.com/presets-extra@v0",
+			wantOK:     false,
+		},
+		{
+			name:       "configured identity overrides first-party",
+			policy:     &Policy{Identities: []Identity{override}},
+			modulePath: "tomei.terassyi.net@v0",
+			want:       override,
+			wantOK:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, ok := tt.policy.identityFor(tt.modulePath)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTrustedRoot_CachedMissing(t *testing.T) {
+	t.Parallel()
+
+	cachePath := filepath.Join(t.TempDir(), "sigstore", "trusted_root.json")
+	tr := &trustedRoot{source: TrustedRootCached, cachePath: cachePath}
+	_, err := tr.get()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no cached trusted root at "+cachePath)
+
+	tr = &trustedRoot{source: TrustedRootCached}
+	_, err = tr.get()
+	assert.ErrorContains(t, err, "no trusted root cache is configured")
+}
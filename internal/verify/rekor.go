@@ -0,0 +1,154 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// defaultRekorURL is the public-good Rekor transparency log.
+const defaultRekorURL = "https://rekor.sigstore.dev"
+
+// maxRekorEntries is the maximum number of transparency log entries tried
+// for one artifact.
+const maxRekorEntries = 10
+
+// rekorLogEntry is a transparency log entry returned by the Rekor API.
+type rekorLogEntry struct {
+	Body           string `json:"body"`
+	IntegratedTime int64  `json:"integratedTime"`
+	LogID          string `json:"logID"`
+	LogIndex       int64  `json:"logIndex"`
+	Verification   struct {
+		SignedEntryTimestamp string `json:"signedEntryTimestamp"`
+	} `json:"verification"`
+}
+
+// verifyKeyless verifies a keyless cosign signature made without a bundle
+// ("cosign sign-blob --output-signature --output-certificate"). The Rekor
+// entries of the artifact digest are searched, and the signature is
+// verified with the first entry that records it.
+func (v *ArtifactVerifier) verifyKeyless(ctx context.Context, sig *CosignSignature, artifact *artifactFile) error {
+	identity, err := sig.identity()
+	if err != nil {
+		return err
+	}
+	signature, err := v.fetch(ctx, sig.SignatureURL, nil)
+	if err != nil {
+		return err
+	}
+	sigBytes, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(signature)))
+	if err != nil {
+		return fmt.Errorf("failed to decode signature base64: %w", err)
+	}
+	cert, err := v.fetch(ctx, sig.CertificateURL, nil)
+	if err != nil {
+		return err
+	}
+	certs, err := parsePEMCertificates(decodeCertificate(cert))
+	if err != nil {
+		return fmt.Errorf("failed to parse certificate: %w", err)
+	}
+
+	uuids, err := v.searchRekor(ctx, artifact.digest)
+	if err != nil {
+		return err
+	}
+	if len(uuids) == 0 {
+		return fmt.Errorf("no transparency log entry found for sha256:%x", artifact.digest)
+	}
+	if len(uuids) > maxRekorEntries {
+		uuids = uuids[:maxRekorEntries]
+	}
+
+	var errs []error
+	for _, uuid := range uuids {
+		entry, err := v.fetchRekorEntry(ctx, uuid)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		b, err := newRekorBundle(certs, sigBytes, artifact.digest, entry)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("rekor entry %s: %w", uuid, err))
+			continue
+		}
+		if _, err := v.verifySigstore(b, artifact.digest, identity); err != nil {
+			errs = append(errs, fmt.Errorf("rekor entry %s: %w", uuid, err))
+			continue
+		}
+		return nil
+	}
+	return errors.Join(errs...)
+}
+
+// decodeCertificate returns the PEM certificate of a cosign certificate
+// file, which cosign writes base64-encoded.
+func decodeCertificate(data []byte) string {
+	data = bytes.TrimSpace(data)
+	if bytes.HasPrefix(data, []byte("-----BEGIN")) {
+		return string(data)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(string(data))
+	if err != nil {
+		return string(data)
+	}
+	return string(decoded)
+}
+
+// searchRekor returns the UUIDs of the Rekor entries of a SHA256 digest.
+func (v *ArtifactVerifier) searchRekor(ctx context.Context, digest []byte) ([]string, error) {
+	body, err := json.Marshal(struct {
+		Hash string `json:"hash"`
+	}{Hash: "sha256:" + hex.EncodeToString(digest)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode rekor search: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.rekorURL+"/api/v1/index/retrieve", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	data, err := v.do(req)
+	if err != nil {
+		return nil, err
+	}
+	var uuids []string
+	if err := json.Unmarshal(data, &uuids); err != nil {
+		return nil, fmt.Errorf("failed to parse rekor search result: %w", err)
+	}
+	return uuids, nil
+}
+
+// fetchRekorEntry fetches a Rekor entry in the form cosign records it.
+func (v *ArtifactVerifier) fetchRekorEntry(ctx context.Context, uuid string) (*cosignRekorEntry, error) {
+	if uuid == "" || strings.ContainsAny(uuid, "/?#") {
+		return nil, fmt.Errorf("invalid rekor entry UUID %q", uuid)
+	}
+	data, err := v.fetch(ctx, v.rekorURL+"/api/v1/log/entries/"+uuid, nil)
+	if err != nil {
+		return nil, err
+	}
+	var entries map[string]rekorLogEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse rekor entry %s: %w", uuid, err)
+	}
+	if len(entries) != 1 {
+		return nil, fmt.Errorf("rekor returned %d entries for %s", len(entries), uuid)
+	}
+	entry := &cosignRekorEntry{}
+	for _, e := range entries {
+		entry.SignedEntryTimestamp = e.Verification.SignedEntryTimestamp
+		entry.Payload.Body = e.Body
+		entry.Payload.IntegratedTime = e.IntegratedTime
+		entry.Payload.LogIndex = e.LogIndex
+		entry.Payload.LogID = e.LogID
+	}
+	return entry, nil
+}
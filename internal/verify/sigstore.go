@@ -4,15 +4,13 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
-	"sync"
 
 	"cuelang.org/go/mod/module"
 	ociv1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/sigstore/sigstore-go/pkg/bundle"
-	"github.com/sigstore/sigstore-go/pkg/root"
-	"github.com/sigstore/sigstore-go/pkg/tuf"
 	sgverify "github.com/sigstore/sigstore-go/pkg/verify"
 )
 
@@ -28,12 +26,12 @@ var _ Verifier = (*SigstoreVerifier)(nil)
 
 // SigstoreVerifier verifies cosign signatures on OCI artifacts using sigstore-go.
 // In production, it performs keyless verification via Fulcio + Rekor.
+// The Policy decides which modules are verified, against which identity,
+// and whether a missing or invalid signature is an error.
 type SigstoreVerifier struct {
 	refResolver *ReferenceResolver
-
-	trustedRootOnce sync.Once
-	trustedRoot     *root.LiveTrustedRoot
-	trustedRootErr  error
+	policy      *Policy
+	trustedRoot trustedRoot
 }
 
 // NewSigstoreVerifier creates a new SigstoreVerifier for the given CUE_REGISTRY value.
@@ -47,22 +45,65 @@ func NewSigstoreVerifier(cueRegistry string) (*SigstoreVerifier, error) {
 	}, nil
 }
 
+// WithPolicy sets the verification policy. Nil verifies first-party
+// modules in warn mode against the live trusted root.
+func (v *SigstoreVerifier) WithPolicy(p *Policy) *SigstoreVerifier {
+	v.policy = p
+	v.trustedRoot.source = p.trustedRootSource()
+	return v
+}
+
+// Requires reports whether the policy requires every module to have a
+// verified signature.
+func (v *SigstoreVerifier) Requires() bool {
+	return v.policy.Requires()
+}
+
+// WithTrustedRootCache sets the file that caches the Sigstore trusted root
+// for offline verification.
+func (v *SigstoreVerifier) WithTrustedRootCache(path string) *SigstoreVerifier {
+	v.trustedRoot.cachePath = path
+	return v
+}
+
 // Verify checks cosign signatures for the given module dependencies.
-// For the initial release, unsigned modules produce a warning but do not fail (warn + continue).
-// This will be changed to hard-fail after all published modules have been signed.
+// Modules without an identity in the policy (and that are not first-party)
+// are skipped. In warn mode, unsigned modules and failed verifications are
+// logged and skipped; in require mode they, and modules without an
+// identity, make Verify return an error.
 func (v *SigstoreVerifier) Verify(ctx context.Context, deps []module.Version) ([]Result, error) {
 	results := make([]Result, 0, len(deps))
+	var failures []error
 
 	for _, dep := range deps {
-		result := v.verifyOne(ctx, dep)
+		identity, ok := v.policy.identityFor(dep.Path())
+		if !ok {
+			if v.policy.Requires() {
+				failures = append(failures, fmt.Errorf("%s: no signing identity is configured for the module", dep))
+			}
+			results = append(results, Result{
+				Module:     dep,
+				Skipped:    true,
+				SkipReason: "no signing identity is configured for the module",
+			})
+			continue
+		}
+
+		result := v.verifyOne(ctx, dep, identity)
+		if result.Skipped && v.policy.Requires() {
+			failures = append(failures, fmt.Errorf("%s: %s", dep, result.SkipReason))
+		}
 		results = append(results, result)
 	}
 
+	if len(failures) > 0 {
+		return results, fmt.Errorf("signatures are required for all modules: %w", errors.Join(failures...))
+	}
 	return results, nil
 }
 
-// verifyOne verifies a single module dependency.
-func (v *SigstoreVerifier) verifyOne(ctx context.Context, dep module.Version) Result {
+// verifyOne verifies a single module dependency against identity.
+func (v *SigstoreVerifier) verifyOne(ctx context.Context, dep module.Version, identity Identity) Result {
 	ref, err := v.refResolver.Resolve(dep)
 	if err != nil {
 		slog.Warn("cosign verification skipped: cannot resolve OCI reference",
@@ -94,7 +135,7 @@ func (v *SigstoreVerifier) verifyOne(ctx context.Context, dep module.Version) Re
 	}
 
 	if result == nil || len(result.Signatures) == 0 {
-		// No signatures found — warn and continue (initial release: soft-fail)
+		// No signatures found — warn and continue (an error in require mode)
 		slog.Warn("cosign signature not found for module (unsigned)",
 			"module", dep.Path(),
 			"version", dep.Version(),
@@ -109,7 +150,7 @@ func (v *SigstoreVerifier) verifyOne(ctx context.Context, dep module.Version) Re
 
 	// Try to verify each signature, binding to the artifact digest
 	for _, sig := range result.Signatures {
-		if err := v.verifySigstoreBundle(sig.Bundle, sig.SimpleSigningPayload, result.ArtifactDigest); err != nil {
+		if err := v.verifySigstoreBundle(sig.Bundle, sig.SimpleSigningPayload, result.ArtifactDigest, identity); err != nil {
 			slog.Debug("cosign signature verification attempt failed",
 				"module", dep.Path(),
 				"error", err,
@@ -127,7 +168,7 @@ func (v *SigstoreVerifier) verifyOne(ctx context.Context, dep module.Version) Re
 		}
 	}
 
-	// All signature verification attempts failed — warn and continue (soft-fail)
+	// All signature verification attempts failed — warn and continue (an error in require mode)
 	slog.Warn("cosign signature verification failed for all signatures",
 		"module", dep.Path(),
 		"version", dep.Version(),
@@ -139,18 +180,31 @@ func (v *SigstoreVerifier) verifyOne(ctx context.Context, dep module.Version) Re
 	}
 }
 
-// getTrustedRoot returns the cached public-good Sigstore trusted root,
-// fetching it on the first call.
-func (v *SigstoreVerifier) getTrustedRoot() (*root.LiveTrustedRoot, error) {
-	v.trustedRootOnce.Do(func() {
-		v.trustedRoot, v.trustedRootErr = root.NewLiveTrustedRoot(tuf.DefaultOptions())
-	})
-	return v.trustedRoot, v.trustedRootErr
+// newPublicGoodVerifier creates a sigstore-go verifier against the
+// public-good Sigstore trusted root (Fulcio + Rekor).
+// WithIntegratedTimestamps(1) verifies timestamps embedded in Rekor
+// transparency log entries, which is how GitHub Actions keyless signing works.
+func newPublicGoodVerifier(trustedRoot *trustedRoot) (*sgverify.Verifier, error) {
+	tr, err := trustedRoot.get()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load trusted root: %w", err)
+	}
+	verifier, err := sgverify.NewVerifier(
+		tr,
+		sgverify.WithSignedCertificateTimestamps(1),
+		sgverify.WithTransparencyLog(1),
+		sgverify.WithIntegratedTimestamps(1),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create verifier: %w", err)
+	}
+	return verifier, nil
 }
 
 // verifySigstoreBundle verifies a parsed Sigstore bundle using the public-good
-// Sigstore trusted root (Fulcio + Rekor). It checks certificate identity for
-// the terassyi/tomei GitHub Actions workflow.
+// Sigstore trusted root (Fulcio + Rekor). It checks that the certificate was
+// issued to identity (for first-party modules, the terassyi/tomei GitHub
+// Actions workflow).
 //
 // For cosign v2 signatures (simpleSigningPayload != nil), verification binds
 // the signature to the SimpleSigning payload, then verifies that the payload's
@@ -158,31 +212,17 @@ func (v *SigstoreVerifier) getTrustedRoot() (*root.LiveTrustedRoot, error) {
 //
 // For legacy protobuf bundles (simpleSigningPayload == nil), it falls back to
 // direct artifact digest binding.
-func (v *SigstoreVerifier) verifySigstoreBundle(b *bundle.Bundle, simpleSigningPayload []byte, artifactDigest ociv1.Hash) error {
-	trustedRoot, err := v.getTrustedRoot()
-	if err != nil {
-		return fmt.Errorf("failed to fetch trusted root: %w", err)
-	}
-
-	// Create a verifier with certificate identity policy.
-	// WithIntegratedTimestamps(1) verifies timestamps embedded in Rekor
-	// transparency log entries, which is how GitHub Actions keyless signing works.
-	verifierConfig, err := sgverify.NewVerifier(
-		trustedRoot,
-		sgverify.WithSignedCertificateTimestamps(1),
-		sgverify.WithTransparencyLog(1),
-		sgverify.WithIntegratedTimestamps(1),
-	)
+func (v *SigstoreVerifier) verifySigstoreBundle(b *bundle.Bundle, simpleSigningPayload []byte, artifactDigest ociv1.Hash, identity Identity) error {
+	verifierConfig, err := newPublicGoodVerifier(&v.trustedRoot)
 	if err != nil {
-		return fmt.Errorf("failed to create verifier: %w", err)
+		return err
 	}
 
-	// Build certificate identity for GitHub Actions OIDC
 	certIdentity, err := sgverify.NewShortCertificateIdentity(
-		expectedOIDCIssuer,
-		"",
-		"",
-		expectedSANRegex,
+		identity.Issuer,
+		identity.IssuerRegexp,
+		identity.SAN,
+		identity.SANRegexp,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create certificate identity: %w", err)
@@ -1,8 +1,10 @@
 package verify
 
 import (
+	"context"
 	"testing"
 
+	"cuelang.org/go/mod/module"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -15,3 +17,44 @@ func TestNewSigstoreVerifier_ValidRegistry(t *testing.T) {
 	assert.NotNil(t, sv)
 	assert.NotNil(t, sv.refResolver)
 }
+
+func TestSigstoreVerifier_Verify_UncoveredModule(t *testing.T) {
+	t.Parallel()
+
+	deps := []module.Version{module.MustNewVersion("example.com/presets@v0", "v0.1.0")}
+
+	tests := []struct {
+		name    string
+		policy  *Policy
+		wantErr bool
+	}{
+		{
+			name:   "default policy skips the module",
+			policy: nil,
+		},
+		{
+			name:    "require mode fails",
+			policy:  &Policy{Mode: ModeRequire},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			sv, err := NewSigstoreVerifier("")
+			require.NoError(t, err)
+
+			results, err := sv.WithPolicy(tt.policy).Verify(context.Background(), deps)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "signatures are required for all modules")
+			} else {
+				require.NoError(t, err)
+			}
+			require.Len(t, results, 1)
+			assert.True(t, results[0].Skipped)
+			assert.Equal(t, "no signing identity is configured for the module", results[0].SkipReason)
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package verify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/sigstore/sigstore-go/pkg/fulcio/certificate"
+	sgverify "github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+// slsaGeneratorSANRegex matches the workflows of slsa-github-generator that
+// sign SLSA provenance.
+const slsaGeneratorSANRegex = `^https://github\.com/slsa-framework/slsa-github-generator/\.github/workflows/`
+
+// verifySLSAProvenance verifies that a SLSA provenance generated by
+// slsa-github-generator attests the artifact, built from the source
+// repository (and tag) of the package. The provenance file holds one
+// attestation per line; one verified attestation is enough.
+func (v *ArtifactVerifier) verifySLSAProvenance(ctx context.Context, prov *SLSAProvenance, artifact *artifactFile) (string, error) {
+	identity, err := githubActionsIdentity(slsaGeneratorSANRegex, certificate.Extensions{
+		SourceRepositoryURI: "https://" + normalizeSourceURI(prov.SourceURI),
+		SourceRepositoryRef: sourceTagRef(prov.SourceTag),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	data, err := v.fetch(ctx, prov.URL, nil)
+	if err != nil {
+		return "", err
+	}
+
+	var errs []error
+	legacy := false
+	for line := range strings.SplitSeq(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		var probe struct {
+			MediaType   string `json:"mediaType"`
+			PayloadType string `json:"payloadType"`
+		}
+		if err := json.Unmarshal([]byte(line), &probe); err != nil {
+			errs = append(errs, fmt.Errorf("failed to parse provenance: %w", err))
+			continue
+		}
+		if probe.MediaType == "" {
+			// A bare DSSE envelope: its Rekor entry must be looked up
+			legacy = legacy || probe.PayloadType != ""
+			continue
+		}
+		b, err := parseBundle([]byte(line))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if _, err := v.verifySigstore(b, artifact.digest, identity); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		return "", nil
+	}
+
+	if len(errs) > 0 {
+		return "", errors.Join(errs...)
+	}
+	if legacy {
+		return "legacy .intoto.jsonl provenance without a Sigstore bundle is not supported; a package that only publishes it cannot pass signaturePolicy require", nil
+	}
+	return "", errors.New("no attestation found in the provenance")
+}
+
+// githubActionsIdentity returns the certificate identity of a GitHub
+// Actions workflow matching sanRegex, with the given certificate extensions.
+func githubActionsIdentity(sanRegex string, extensions certificate.Extensions) (sgverify.CertificateIdentity, error) {
+	san, err := sgverify.NewSANMatcher("", sanRegex)
+	if err != nil {
+		return sgverify.CertificateIdentity{}, fmt.Errorf("invalid certificate identity: %w", err)
+	}
+	issuer, err := sgverify.NewIssuerMatcher(expectedOIDCIssuer, "")
+	if err != nil {
+		return sgverify.CertificateIdentity{}, fmt.Errorf("invalid certificate issuer: %w", err)
+	}
+	return sgverify.NewCertificateIdentity(san, issuer, extensions)
+}
+
+// normalizeSourceURI strips the scheme and ".git" suffix of a repository
+// URI (e.g., "git+https://github.com/cli/cli.git" → "github.com/cli/cli").
+func normalizeSourceURI(uri string) string {
+	uri = strings.TrimPrefix(uri, "git+")
+	uri = strings.TrimPrefix(uri, "https://")
+	return strings.TrimSuffix(uri, ".git")
+}
+
+// sourceTagRef returns the git ref of a tag, or an empty string (no check)
+// for an empty tag.
+func sourceTagRef(tag string) string {
+	if tag == "" {
+		return ""
+	}
+	return "refs/tags/" + tag
+}
+
+// githubRepositorySANRegex matches the workflows of a GitHub repository, or
+// the given workflow ("owner/repo/.github/workflows/release.yml").
+func githubRepositorySANRegex(owner, repo, workflow string) string {
+	if workflow != "" {
+		return `^https://github\.com/` + regexp.QuoteMeta(workflow) + `@`
+	}
+	return `^https://github\.com/` + regexp.QuoteMeta(owner+"/"+repo) + `/`
+}
@@ -0,0 +1,125 @@
+package verify
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/tuf"
+)
+
+// trustedRootTarget is the TUF target holding the Sigstore trusted root.
+const trustedRootTarget = "trusted_root.json"
+
+// trustedRoot loads the public-good Sigstore trusted root on first use.
+//
+// With TrustedRootLive (the default) it is fetched from the Sigstore TUF
+// repository and written to cachePath; if the fetch fails, the cached copy
+// is used so that verification keeps working offline. With
+// TrustedRootCached only the cached copy is used. An empty cachePath
+// disables caching.
+type trustedRoot struct {
+	source    string
+	cachePath string
+
+	once sync.Once
+	root *root.TrustedRoot
+	// data is the JSON that root was parsed from.
+	data []byte
+	err  error
+}
+
+func (t *trustedRoot) get() (*root.TrustedRoot, error) {
+	t.once.Do(func() {
+		t.root, t.err = t.load()
+	})
+	return t.root, t.err
+}
+
+func (t *trustedRoot) load() (*root.TrustedRoot, error) {
+	if t.source == TrustedRootCached {
+		return t.loadCached()
+	}
+
+	data, fetchErr := fetchTrustedRoot()
+	if fetchErr == nil {
+		tr, err := root.NewTrustedRootFromJSON(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse trusted root: %w", err)
+		}
+		t.data = data
+		t.writeCache(data)
+		return tr, nil
+	}
+	if t.cachePath == "" {
+		return nil, fetchErr
+	}
+
+	tr, err := t.loadCached()
+	if err != nil {
+		return nil, errors.Join(fetchErr, err)
+	}
+	slog.Warn("failed to fetch the Sigstore trusted root, using the cached one",
+		"path", t.cachePath,
+		"error", fetchErr,
+	)
+	return tr, nil
+}
+
+// fetchTrustedRoot fetches the trusted root from the public-good Sigstore
+// TUF repository.
+func fetchTrustedRoot() ([]byte, error) {
+	client, err := tuf.New(tuf.DefaultOptions())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create TUF client: %w", err)
+	}
+	data, err := client.GetTarget(trustedRootTarget)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch trusted root: %w", err)
+	}
+	return data, nil
+}
+
+func (t *trustedRoot) loadCached() (*root.TrustedRoot, error) {
+	if t.cachePath == "" {
+		return nil, errors.New("no trusted root cache is configured")
+	}
+	data, err := os.ReadFile(t.cachePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("no cached trusted root at %s; run once with network access to cache it", t.cachePath)
+		}
+		return nil, fmt.Errorf("failed to load cached trusted root: %w", err)
+	}
+	tr, err := root.NewTrustedRootFromJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cached trusted root: %w", err)
+	}
+	t.data = data
+	return tr, nil
+}
+
+// writeCache stores the trusted root for offline use. Failures are logged:
+// the fetched root is still used.
+func (t *trustedRoot) writeCache(data []byte) {
+	if t.cachePath == "" {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(t.cachePath), 0755); err != nil {
+		slog.Debug("failed to cache trusted root", "path", t.cachePath, "error", err)
+		return
+	}
+	tmp := t.cachePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		slog.Debug("failed to cache trusted root", "path", t.cachePath, "error", err)
+		return
+	}
+	if err := os.Rename(tmp, t.cachePath); err != nil {
+		os.Remove(tmp)
+		slog.Debug("failed to cache trusted root", "path", t.cachePath, "error", err)
+	}
+}
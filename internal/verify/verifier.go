@@ -1,10 +1,13 @@
 // Package verify provides cosign signature verification for CUE module OCI artifacts.
-// It verifies that first-party modules (tomei.terassyi.net) are signed before CUE evaluation.
+// It verifies that first-party modules (tomei.terassyi.net), and the modules
+// that a Policy maps to a signing identity, are signed before CUE evaluation.
+//
+// ArtifactVerifier verifies the upstream signatures (cosign, minisign, SLSA
+// provenance and GitHub artifact attestations) of downloaded tool releases.
 package verify
 
 import (
 	"context"
-	"strings"
 
 	"cuelang.org/go/mod/module"
 )
@@ -33,13 +36,5 @@ type Verifier interface {
 // It checks for the "tomei.terassyi.net" prefix followed by either
 // a path separator, major version separator, or end of string.
 func IsFirstParty(modulePath string) bool {
-	if modulePath == "" {
-		return false
-	}
-	if !strings.HasPrefix(modulePath, FirstPartyPrefix) {
-		return false
-	}
-	// Ensure it's an exact prefix match, not a partial domain match
-	rest := modulePath[len(FirstPartyPrefix):]
-	return rest == "" || rest[0] == '/' || rest[0] == '@'
+	return hasModulePrefix(modulePath, FirstPartyPrefix)
 }
@@ -67,6 +67,10 @@ func (m *mockToolInstaller) RegisterRuntime(_ string, _ *tool.RuntimeInfo) {}
 
 func (m *mockToolInstaller) RegisterInstaller(_ string, _ *tool.InstallerInfo) {}
 
+func (m *mockToolInstaller) RegistryRuntime(_ context.Context, _ *resource.Tool) (string, error) {
+	return "", nil
+}
+
 func (m *mockToolInstaller) SetToolBinPaths(_ map[string]string) {}
 
 func (m *mockToolInstaller) SetProgressCallback(_ download.ProgressCallback) {}
@@ -125,6 +129,44 @@ func (m *mockRuntimeInstaller) Remove(_ context.Context, _ *resource.RuntimeStat
 
 func (m *mockRuntimeInstaller) SetProgressCallback(_ download.ProgressCallback) {}
 
+// mockInstallerInstaller is a thread-safe mock implementation of engine.InstallerInstaller.
+type mockInstallerInstaller struct {
+	mu        sync.Mutex
+	installed map[string]*resource.InstallerState
+	removed   map[string]bool
+}
+
+func newMockInstallerInstaller() *mockInstallerInstaller {
+	return &mockInstallerInstaller{
+		installed: make(map[string]*resource.InstallerState),
+		removed:   make(map[string]bool),
+	}
+}
+
+func (m *mockInstallerInstaller) Install(_ context.Context, res *resource.Installer, name string) (*resource.InstallerState, error) {
+	st := &resource.InstallerState{
+		ToolRef:   res.InstallerSpec.ToolRef,
+		BinDir:    res.InstallerSpec.BinDir,
+		Bootstrap: res.InstallerSpec.Bootstrap,
+	}
+	m.mu.Lock()
+	m.installed[name] = st
+	m.mu.Unlock()
+	return st, nil
+}
+
+func (m *mockInstallerInstaller) Remove(_ context.Context, _ *resource.InstallerState, name string) error {
+	m.mu.Lock()
+	m.removed[name] = true
+	delete(m.installed, name)
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *mockInstallerInstaller) IsInstalled(_ context.Context, _ *resource.InstallerState, _ string) bool {
+	return true
+}
+
 // mockInstallerRepositoryInstaller is a thread-safe mock implementation of engine.InstallerRepositoryInstaller.
 type mockInstallerRepositoryInstaller struct {
 	mu          sync.Mutex
@@ -206,7 +248,7 @@ ripgrep: {
 
 	mockTool := newMockToolInstaller()
 	mockRuntime := newMockRuntimeInstaller()
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	ctx := context.Background()
 	runtimeActions, _, toolActions, err := eng.PlanAll(ctx, resources)
@@ -251,7 +293,7 @@ goRuntime: {
 
 	mockTool := newMockToolInstaller()
 	mockRuntime := newMockRuntimeInstaller()
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	ctx := context.Background()
 	runtimeActions, _, toolActions, err := eng.PlanAll(ctx, resources)
@@ -294,7 +336,7 @@ jq: {
 
 	mockTool := newMockToolInstaller()
 	mockRuntime := newMockRuntimeInstaller()
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	ctx := context.Background()
 
@@ -352,7 +394,7 @@ goRuntime: {
 
 	mockTool := newMockToolInstaller()
 	mockRuntime := newMockRuntimeInstaller()
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	ctx := context.Background()
 
@@ -405,7 +447,7 @@ fd: {
 
 	mockTool := newMockToolInstaller()
 	mockRuntime := newMockRuntimeInstaller()
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	ctx := context.Background()
 
@@ -454,7 +496,7 @@ bat: {
 
 	mockTool := newMockToolInstaller()
 	mockRuntime := newMockRuntimeInstaller()
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	ctx := context.Background()
 
@@ -537,7 +579,7 @@ fzf: {
 
 	mockTool := newMockToolInstaller()
 	mockRuntime := newMockRuntimeInstaller()
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	ctx := context.Background()
 
@@ -646,7 +688,7 @@ jq: {
 
 	mockTool := newMockToolInstaller()
 	mockRuntime := newMockRuntimeInstaller()
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	ctx := context.Background()
 
@@ -725,7 +767,7 @@ bat: {
 
 	mockTool := newMockToolInstaller()
 	mockRuntime := newMockRuntimeInstaller()
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	ctx := context.Background()
 
@@ -801,7 +843,7 @@ gopls: {
 
 	mockTool := newMockToolInstaller()
 	mockRuntime := newMockRuntimeInstaller()
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	ctx := context.Background()
 
@@ -883,7 +925,7 @@ jq: {
 
 	mockTool := newMockToolInstaller()
 	mockRuntime := newMockRuntimeInstaller()
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	ctx := context.Background()
 
@@ -991,7 +1033,7 @@ staticcheck: {
 
 	mockTool := newMockToolInstaller()
 	mockRuntime := newMockRuntimeInstaller()
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	ctx := context.Background()
 
@@ -1066,7 +1108,7 @@ goRuntime: {
 
 	mockTool := newMockToolInstaller()
 	mockRuntime := newMockRuntimeInstaller()
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	ctx := context.Background()
 
@@ -1150,7 +1192,7 @@ tool: {
 
 	mockTool := newMockToolInstaller()
 	mockRuntime := newMockRuntimeInstaller()
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	// Collect events
 	var mu sync.Mutex
@@ -1247,7 +1289,7 @@ tool: {
 
 	mockTool := newMockToolInstaller()
 	mockRuntime := newMockRuntimeInstaller()
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	// Collect events
 	var mu sync.Mutex
@@ -1329,7 +1371,7 @@ bat: {
 	require.NoError(t, store.Unlock())
 
 	mockTool := newMockToolInstaller()
-	eng := engine.NewEngine(mockTool, newMockRuntimeInstaller(), newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, newMockRuntimeInstaller(), newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	// Collect events
 	var mu sync.Mutex
@@ -1436,7 +1478,7 @@ tool: {
 	require.NoError(t, store.Save(initialState))
 	require.NoError(t, store.Unlock())
 
-	eng := engine.NewEngine(newMockToolInstaller(), newMockRuntimeInstaller(), newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(newMockToolInstaller(), newMockRuntimeInstaller(), newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	runtimeActions, _, toolActions, err := eng.PlanAll(context.Background(), resources)
 	require.NoError(t, err)
@@ -1447,7 +1489,7 @@ tool: {
 	assert.Equal(t, "go", runtimeActions[0].Name)
 
 	// PlanAll doesn't predict taint — it only sees current state.
-	// The taint prediction is in buildResourceInfo (plan.go), not in engine.PlanAll.
+	// The taint prediction is in engine.RuntimeTaints (used by plan.go), not in engine.PlanAll.
 	// Since gopls has the same version and is not yet tainted, PlanAll omits it
 	// (no action needed from reconciler's perspective).
 	var goplsAction *engine.ToolAction
@@ -1458,9 +1500,9 @@ tool: {
 		}
 	}
 	// gopls is not present in actions (same version, not tainted) — this is expected.
-	// The taint prediction happens in plan.go's buildResourceInfo, not in engine.PlanAll.
+	// The taint prediction happens in engine.RuntimeTaints, not in engine.PlanAll.
 	assert.Nil(t, goplsAction,
-		"PlanAll should not include gopls because taint hasn't happened yet; prediction is in plan.go buildResourceInfo")
+		"PlanAll should not include gopls because taint hasn't happened yet; prediction is in engine.RuntimeTaints")
 }
 
 // TestEngine_PlanAll_DetectsToolRemoval tests that PlanAll detects a tool
@@ -1510,7 +1552,7 @@ bat: {
 
 	mockTool := newMockToolInstaller()
 	mockRuntime := newMockRuntimeInstaller()
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	ctx := context.Background()
 
@@ -1594,7 +1636,7 @@ myruntime: {
 
 	mockTool := newMockToolInstaller()
 	mockRuntime := newMockRuntimeInstaller()
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	ctx := context.Background()
 
@@ -1651,7 +1693,7 @@ fzf: {
 
 	mockTool := newMockToolInstaller()
 	mockRuntime := newMockRuntimeInstaller()
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	ctx := context.Background()
 
@@ -1699,7 +1741,7 @@ fzf: {
 
 	mockTool := newMockToolInstaller()
 	mockRuntime := newMockRuntimeInstaller()
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	ctx := context.Background()
 
@@ -1779,7 +1821,7 @@ fzf: {
 
 	mockTool := newMockToolInstaller()
 	mockRuntime := newMockRuntimeInstaller()
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	ctx := context.Background()
 
@@ -1836,7 +1878,7 @@ fzf: {
 
 	mockTool := newMockToolInstaller()
 	mockRuntime := newMockRuntimeInstaller()
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	ctx := context.Background()
 
@@ -1902,7 +1944,7 @@ fzf: {
 
 	mockTool := newMockToolInstaller()
 	mockRuntime := newMockRuntimeInstaller()
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	ctx := context.Background()
 
@@ -1999,7 +2041,7 @@ gopls: {
 
 	mockTool := newMockToolInstaller()
 	mockRuntime := newMockRuntimeInstaller()
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	ctx := context.Background()
 
@@ -2105,7 +2147,7 @@ cliTools: {
 
 	mockTool := newMockToolInstaller()
 	mockRuntime := newMockRuntimeInstaller()
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	err = eng.Apply(context.Background(), resources)
 	require.NoError(t, err)
@@ -2186,7 +2228,7 @@ goTools: {
 
 	mockTool := newMockToolInstaller()
 	mockRuntime := newMockRuntimeInstaller()
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	err = eng.Apply(context.Background(), resources)
 	require.NoError(t, err)
@@ -2252,7 +2294,7 @@ cliTools: {
 
 	mockTool := newMockToolInstaller()
 	mockRuntime := newMockRuntimeInstaller()
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	err = eng.Apply(context.Background(), resources)
 	require.NoError(t, err)
@@ -2316,7 +2358,7 @@ ripgrep: {
 	}
 
 	mockRuntime := newMockRuntimeInstaller()
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	// Capture events to verify callback generates correct engine events
 	var mu sync.Mutex
@@ -2398,7 +2440,7 @@ jq: {
 	}
 
 	mockRuntime := newMockRuntimeInstaller()
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	var mu sync.Mutex
 	var outputEvents []engine.Event
@@ -2485,7 +2527,7 @@ goRuntime: {
 	}
 
 	mockTool := newMockToolInstaller()
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	var mu sync.Mutex
 	var progressEvents []engine.Event
@@ -2591,7 +2633,7 @@ bat: {
 	}
 
 	mockRuntime := newMockRuntimeInstaller()
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	var mu sync.Mutex
 	eventsByTool := make(map[string][]engine.Event)
@@ -2714,7 +2756,7 @@ bat: {
 	}
 
 	mockRuntime := newMockRuntimeInstaller()
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	err = eng.Apply(context.Background(), resources)
 	require.NoError(t, err)
@@ -2817,7 +2859,7 @@ bat: {
 	}
 
 	mockRuntime := newMockRuntimeInstaller()
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	err = eng.Apply(context.Background(), resources)
 	require.Error(t, err)
@@ -2887,7 +2929,7 @@ func TestEngine_Apply_ParallelismLimit(t *testing.T) {
 	}
 
 	mockRuntime := newMockRuntimeInstaller()
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 	eng.SetParallelism(2)
 
 	err = eng.Apply(context.Background(), resources)
@@ -2989,7 +3031,7 @@ ripgrep: {
 		},
 	}
 
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	err = eng.Apply(context.Background(), resources)
 	require.NoError(t, err)
@@ -3101,7 +3143,7 @@ nodeRuntime: {
 	}
 
 	mockTool := newMockToolInstaller()
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	err = eng.Apply(context.Background(), resources)
 	require.NoError(t, err)
@@ -3151,7 +3193,7 @@ myTool: {
 
 	mockTool := newMockToolInstaller()
 	mockRuntime := newMockRuntimeInstaller()
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	ctx := context.Background()
 	runtimeActions, _, toolActions, err := eng.PlanAll(ctx, resources)
@@ -3211,7 +3253,7 @@ myTool: {
 	}
 
 	mockRuntime := newMockRuntimeInstaller()
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	ctx := context.Background()
 
@@ -3278,7 +3320,7 @@ myTool: {
 	}
 
 	mockRuntime := newMockRuntimeInstaller()
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	ctx := context.Background()
 
@@ -3338,7 +3380,7 @@ myTool: {
 	}
 
 	mockRuntime := newMockRuntimeInstaller()
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	ctx := context.Background()
 
@@ -3450,7 +3492,7 @@ dlTool: {
 	}
 
 	mockRuntime := newMockRuntimeInstaller()
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	ctx := context.Background()
 
@@ -3511,7 +3553,7 @@ failTool: {
 	}
 
 	mockRuntime := newMockRuntimeInstaller()
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	ctx := context.Background()
 
@@ -3554,7 +3596,7 @@ goRuntime: {
 
 	mockTool := newMockToolInstaller()
 	mockRuntime := newMockRuntimeInstaller()
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	ctx := context.Background()
 
@@ -3661,7 +3703,7 @@ ctx: {
 	}
 
 	mockRuntime := newMockRuntimeInstaller()
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 
 	applyCtx := context.Background()
 
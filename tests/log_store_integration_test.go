@@ -105,7 +105,7 @@ bravo: {
 	require.NoError(t, err)
 	defer logStore.Close()
 
-	eng := engine.NewEngine(mockTool, newMockRuntimeInstaller(), newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, newMockRuntimeInstaller(), newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 	eng.SetEventHandler(func(event engine.Event) {
 		handleLogEvent(logStore, event)
 	})
@@ -188,7 +188,7 @@ goRuntime: {
 	require.NoError(t, err)
 	defer logStore.Close()
 
-	eng := engine.NewEngine(newMockToolInstaller(), mockRuntime, newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(newMockToolInstaller(), mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 	eng.SetEventHandler(func(event engine.Event) {
 		handleLogEvent(logStore, event)
 	})
@@ -271,7 +271,7 @@ bravo: {
 	logStore, err := tomeilog.NewStore(logsDir)
 	require.NoError(t, err)
 
-	eng := engine.NewEngine(mockTool, newMockRuntimeInstaller(), newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, newMockRuntimeInstaller(), newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 	eng.SetEventHandler(func(event engine.Event) {
 		handleLogEvent(logStore, event)
 	})
@@ -371,7 +371,7 @@ toolC: {
 	require.NoError(t, err)
 	defer logStore.Close()
 
-	eng := engine.NewEngine(mockTool, newMockRuntimeInstaller(), newMockInstallerRepositoryInstaller(), store)
+	eng := engine.NewEngine(mockTool, newMockRuntimeInstaller(), newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), store)
 	eng.SetParallelism(3)
 
 	var mu sync.Mutex
@@ -109,14 +109,14 @@ exactTool: {
 	ctx := context.Background()
 
 	// Without update flag: PlanAll shows no actions (versions match state)
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), env.store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), env.store)
 	runtimeActions, _, toolActions, err := eng.PlanAll(ctx, resources)
 	require.NoError(t, err)
 	assert.Empty(t, runtimeActions)
 	assert.Empty(t, toolActions, "expected no actions without --update-tools")
 
 	// With update flag: PlanAll should show update for latest-tool only
-	engWithUpdate := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), env.store)
+	engWithUpdate := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), env.store)
 	engWithUpdate.SetUpdateConfig(engine.UpdateConfig{UpdateTools: true})
 
 	runtimeActions, _, toolActions, err = engWithUpdate.PlanAll(ctx, resources)
@@ -168,13 +168,13 @@ mockRuntime: {
 	ctx := context.Background()
 
 	// Without update flag: no actions
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), env.store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), env.store)
 	runtimeActions, _, _, err := eng.PlanAll(ctx, resources)
 	require.NoError(t, err)
 	assert.Empty(t, runtimeActions)
 
 	// With --update-runtimes: should show update action
-	engWithUpdate := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), env.store)
+	engWithUpdate := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), env.store)
 	engWithUpdate.SetUpdateConfig(engine.UpdateConfig{UpdateRuntimes: true})
 
 	runtimeActions, _, _, err = engWithUpdate.PlanAll(ctx, resources)
@@ -255,7 +255,7 @@ gopls: {
 		}, nil
 	}
 
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), env.store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), env.store)
 
 	ctx := context.Background()
 
@@ -358,7 +358,7 @@ gopls: {
 		}, nil
 	}
 
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), env.store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), env.store)
 
 	ctx := context.Background()
 
@@ -370,7 +370,7 @@ gopls: {
 	// Taint runtime via --update-runtimes and apply.
 	// The runtime will be "reinstalled" (mock returns same version 1.25.5).
 	// Since version is unchanged, cascade should NOT happen.
-	engWithUpdate := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), env.store)
+	engWithUpdate := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), env.store)
 	engWithUpdate.SetUpdateConfig(engine.UpdateConfig{UpdateRuntimes: true})
 
 	err = engWithUpdate.Apply(ctx, resources)
@@ -452,7 +452,7 @@ exactTool: {
 	ctx := context.Background()
 
 	// --sync only: should taint latest-tool only (not alias-tool)
-	engSync := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), env.store)
+	engSync := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), env.store)
 	engSync.SetUpdateConfig(engine.UpdateConfig{SyncMode: true})
 
 	_, _, toolActions, err := engSync.PlanAll(ctx, resources)
@@ -461,7 +461,7 @@ exactTool: {
 	assert.Equal(t, "latest-tool", toolActions[0].Name)
 
 	// --update-tools: should taint latest-tool AND alias-tool
-	engUpdate := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), env.store)
+	engUpdate := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), env.store)
 	engUpdate.SetUpdateConfig(engine.UpdateConfig{UpdateTools: true})
 
 	_, _, toolActions, err = engUpdate.PlanAll(ctx, resources)
@@ -556,14 +556,14 @@ gopls: {
 	ctx := context.Background()
 
 	// Without --update-runtimes: no actions (alias matches specVersion)
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), env.store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), env.store)
 	runtimeActions, _, toolActions, err := eng.PlanAll(ctx, resources)
 	require.NoError(t, err)
 	assert.Empty(t, runtimeActions, "expected no runtime actions without --update-runtimes")
 	assert.Empty(t, toolActions, "expected no tool actions")
 
 	// With --update-runtimes: should taint download runtime with VersionAlias
-	engWithUpdate := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), env.store)
+	engWithUpdate := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), env.store)
 	engWithUpdate.SetUpdateConfig(engine.UpdateConfig{UpdateRuntimes: true})
 
 	runtimeActions, _, _, err = engWithUpdate.PlanAll(ctx, resources)
@@ -663,7 +663,7 @@ gopls: {
 	ctx := context.Background()
 
 	// Apply with --update-runtimes; runtime re-resolved to same version
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), env.store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), env.store)
 	eng.SetUpdateConfig(engine.UpdateConfig{UpdateRuntimes: true})
 
 	err := eng.Apply(ctx, resources)
@@ -718,14 +718,14 @@ myTool: {
 	ctx := context.Background()
 
 	// Without --update-tools: no actions (version matches state since VersionLatest is unchanged)
-	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), env.store)
+	eng := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), env.store)
 	runtimeActions, _, toolActions, err := eng.PlanAll(ctx, resources)
 	require.NoError(t, err)
 	assert.Empty(t, runtimeActions)
 	assert.Empty(t, toolActions, "expected no actions without --update-tools")
 
 	// With --update-tools: should taint the VersionLatest commands-pattern tool
-	engWithUpdate := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), env.store)
+	engWithUpdate := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), env.store)
 	engWithUpdate.SetUpdateConfig(engine.UpdateConfig{UpdateTools: true})
 
 	runtimeActions, _, toolActions, err = engWithUpdate.PlanAll(ctx, resources)
@@ -748,7 +748,7 @@ myTool: {
 		}, nil
 	}
 
-	engApply := engine.NewEngine(mockToolForApply, mockRuntime, newMockInstallerRepositoryInstaller(), env.store)
+	engApply := engine.NewEngine(mockToolForApply, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), env.store)
 	engApply.SetUpdateConfig(engine.UpdateConfig{UpdateTools: true})
 
 	err = engApply.Apply(ctx, resources)
@@ -840,7 +840,7 @@ exactTool: {
 	ctx := context.Background()
 
 	// --update-all: both flags on
-	engAll := engine.NewEngine(mockTool, mockRuntime, newMockInstallerRepositoryInstaller(), env.store)
+	engAll := engine.NewEngine(mockTool, mockRuntime, newMockInstallerInstaller(), newMockInstallerRepositoryInstaller(), env.store)
 	engAll.SetUpdateConfig(engine.UpdateConfig{UpdateTools: true, UpdateRuntimes: true})
 
 	runtimeActions, _, toolActions, err := engAll.PlanAll(ctx, resources)